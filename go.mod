@@ -3,6 +3,7 @@ module github.com/goat-systems/tzpay/v3
 go 1.13
 
 require (
+	github.com/btcsuite/btcutil v1.0.2
 	github.com/caarlos0/env/v6 v6.2.1
 	github.com/dghubble/go-twitter v0.0.0-20200725221434-4bc8ad7ad1b4
 	github.com/dghubble/oauth1 v0.6.0
@@ -10,9 +11,11 @@ require (
 	github.com/go-playground/validator/v10 v10.2.0 // indirect
 	github.com/goat-systems/go-tezos/v2 v2.0.0
 	github.com/goat-systems/go-tezos/v3 v3.0.0-20200913230314-15c03bad0ffa
+	github.com/golang/protobuf v1.3.5
 	github.com/gomodule/oauth1 v0.0.0-20181215000758-9a59ed3b0a84 // indirect
 	github.com/magiconair/properties v1.8.1 // indirect
 	github.com/mattn/go-runewidth v0.0.8 // indirect
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/olekukonko/tablewriter v0.0.4
 	github.com/pkg/errors v0.9.1
 	github.com/sfreiberg/gotwilio v0.0.0-20200424172909-47a95c1c632a
@@ -20,10 +23,12 @@ require (
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/stretchr/testify v1.6.1
-	github.com/tyler-smith/go-bip39 v1.0.2 // indirect
+	github.com/tyler-smith/go-bip39 v1.0.2
 	github.com/valyala/fastjson v1.5.4
-	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a // indirect
-	golang.org/x/sys v0.0.0-20200828194041-157a740278f4 // indirect
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/crypto v0.0.0-20200820211705-5c72a883971a
+	golang.org/x/sys v0.0.0-20200828194041-157a740278f4
+	google.golang.org/grpc v1.27.1
 	gopkg.in/alexcesaro/quotedprintable.v3 v3.0.0-20150716171945-2caba252f4dc // indirect
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
 )
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/status"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Server serves the payouts recorded in the store as JSON over HTTP
+type Server struct {
+	store   store.IFace
+	rpc     rpc.IFace
+	config  func() config.Config
+	apiKey  string
+	public  bool
+	control Controller
+	now     func() time.Time
+}
+
+// ServerInput is the input for NewServer
+type ServerInput struct {
+	Store store.IFace
+	RPC   rpc.IFace
+	// Config returns the configuration of every baker served, with the overrides in effect
+	Config func() config.Config
+	APIKey string // optional, requests must carry it in the X-API-Key header if set
+	// Public adds the lookup page of delegators and its endpoint, which are served without APIKey
+	Public bool
+	// Control adds the endpoints of the control API if set, which are only served with an APIKey
+	Control Controller
+}
+
+// apiError is the body of every response of an error
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// NewServer returns a new Server
+func NewServer(input ServerInput) *Server {
+	return &Server{
+		store:   input.Store,
+		rpc:     input.RPC,
+		config:  input.Config,
+		apiKey:  input.APIKey,
+		public:  input.Public,
+		control: input.Control,
+		now:     time.Now,
+	}
+}
+
+/*
+Handler returns the handler of the API:
+
+	GET /v1/payouts                every payout recorded, oldest cycle first
+	GET /v1/payouts/{cycle}        the payouts recorded for cycle and what each delegator was paid
+	GET /v1/delegators/{address}   every payment made to a delegator, from the cycle in ?from_cycle on
+	GET /v1/status                 the status of the payouts, as on the status page
+
+Every endpoint serves the primary baker, or the baker in ?baker when multiple bakers are configured. The endpoints of
+the control API are added with a Controller and an API key, and the public lookup page of delegators with Public.
+*/
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/payouts", s.payouts)
+	mux.HandleFunc("/v1/payouts/", s.cycle)
+	mux.HandleFunc("/v1/delegators/", s.delegator)
+	mux.HandleFunc("/v1/status", s.status)
+	if s.control != nil && s.apiKey != "" {
+		s.controlHandler(mux)
+	}
+	if s.public {
+		s.publicHandler(mux)
+	}
+
+	return s.authenticate(mux)
+}
+
+// Start serves the API on listen in the background. The server keeps paying out if the API stops.
+func (s *Server) Start(listen string) {
+	log.WithField("listen", listen).Info("Starting HTTP API.")
+	go func() {
+		if err := http.ListenAndServe(listen, s.Handler()); err != nil {
+			log.WithFields(log.Fields{"error": err.Error(), "listen": listen}).Error("HTTP API stopped.")
+		}
+	}()
+}
+
+// authenticate refuses requests that do not carry the API key when one is configured, but for the public endpoints,
+// and requests that are not GET outside of the control API
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/control/") && !allow(w, r, http.MethodGet) {
+			return
+		}
+
+		public := s.public && strings.HasPrefix(r.URL.Path, "/public/")
+		if s.apiKey != "" && !public && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-API-Key")), []byte(s.apiKey)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid api key"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) payouts(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.baker(w, r)
+	if !ok {
+		return
+	}
+
+	records, err := payout.Records(s.store, cfg.Baker.Address)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if records == nil {
+		records = []payout.Record{}
+	}
+
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (s *Server) cycle(w http.ResponseWriter, r *http.Request) {
+	cycle, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/v1/payouts/"))
+	if err != nil || cycle < 0 {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid cycle '%s'", strings.TrimPrefix(r.URL.Path, "/v1/payouts/")))
+		return
+	}
+
+	cfg, ok := s.baker(w, r)
+	if !ok {
+		return
+	}
+
+	record, ok, err := payout.CycleRecords(s.store, cfg.Baker.Address, cycle)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	} else if !ok {
+		writeError(w, http.StatusNotFound, errors.Errorf("no payout recorded for cycle %d", cycle))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+func (s *Server) delegator(w http.ResponseWriter, r *http.Request) {
+	address := strings.TrimPrefix(r.URL.Path, "/v1/delegators/")
+	if address == "" || strings.Contains(address, "/") {
+		writeError(w, http.StatusNotFound, errors.Errorf("no such endpoint '%s'", r.URL.Path))
+		return
+	}
+
+	var fromCycle int
+	if v := r.URL.Query().Get("from_cycle"); v != "" {
+		var err error
+		if fromCycle, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, errors.Errorf("invalid from_cycle '%s'", v))
+			return
+		}
+	}
+
+	cfg, ok := s.baker(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := payout.History(s.store, cfg.Baker.Address, address, fromCycle)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	cfg, ok := s.baker(w, r)
+	if !ok {
+		return
+	}
+
+	st, err := status.Generate(status.GenerateInput{
+		RPC:    s.rpc,
+		Store:  s.store,
+		Config: cfg,
+		Now:    s.now(),
+	})
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, st)
+}
+
+// baker returns the configuration of the baker the request is for, and writes an error if it is not configured
+func (s *Server) baker(w http.ResponseWriter, r *http.Request) (config.Config, bool) {
+	cfg, err := s.config().ForBaker(r.URL.Query().Get("baker"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return cfg, false
+	}
+
+	return cfg, true
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to write HTTP API response.")
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, apiError{Error: err.Error()})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	baker     = "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+	delegator = "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"
+)
+
+func Test_Handler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-api")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	paidAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put("payouts/"+baker, "00000300/2021-03-01T12:00:00Z", payout.Record{Cycle: 300, Time: paidAt, Operations: []string{"oo1"}}))
+	assert.Nil(t, s.Put("paid/"+baker, "00000300/"+delegator, payout.PaidEntry{Amount: 1000, Time: paidAt}))
+
+	cfg := config.Config{
+		Baker:     config.Baker{Address: baker},
+		Delegates: []config.Delegate{{Baker: config.Baker{Address: "tz1other"}}},
+	}
+	server := NewServer(ServerInput{
+		Store:  s,
+		RPC:    &test.RPCMock{HeadCycle: 301},
+		Config: func() config.Config { return cfg },
+		APIKey: "some_api_key",
+	})
+	handler := server.Handler()
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		key      string
+		code     int
+		contains string
+	}{
+		{"lists payouts", http.MethodGet, "/v1/payouts", "some_api_key", http.StatusOK, `"operations":["oo1"]`},
+		{"lists payouts of another baker", http.MethodGet, "/v1/payouts?baker=tz1other", "some_api_key", http.StatusOK, `[]`},
+		{"handles unknown baker", http.MethodGet, "/v1/payouts?baker=tz1unknown", "some_api_key", http.StatusNotFound, `baker 'tz1unknown' is not configured`},
+		{"gets cycle", http.MethodGet, "/v1/payouts/300", "some_api_key", http.StatusOK, `"delegators":{"` + delegator + `":1000}`},
+		{"handles cycle not paid", http.MethodGet, "/v1/payouts/299", "some_api_key", http.StatusNotFound, `no payout recorded for cycle 299`},
+		{"handles invalid cycle", http.MethodGet, "/v1/payouts/abc", "some_api_key", http.StatusBadRequest, `invalid cycle 'abc'`},
+		{"gets delegator", http.MethodGet, "/v1/delegators/" + delegator, "some_api_key", http.StatusOK, `"total":1000`},
+		{"handles invalid from_cycle", http.MethodGet, "/v1/delegators/" + delegator + "?from_cycle=abc", "some_api_key", http.StatusBadRequest, `invalid from_cycle`},
+		{"gets status", http.MethodGet, "/v1/status", "some_api_key", http.StatusOK, `"last_paid_cycle":300`},
+		{"handles missing api key", http.MethodGet, "/v1/payouts", "", http.StatusUnauthorized, `missing or invalid api key`},
+		{"handles invalid api key", http.MethodGet, "/v1/payouts", "wrong_api_key", http.StatusUnauthorized, `missing or invalid api key`},
+		{"handles other methods", http.MethodPost, "/v1/payouts", "some_api_key", http.StatusMethodNotAllowed, `method POST not allowed`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), tt.contains)
+			assert.True(t, json.Valid(rec.Body.Bytes()))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/pkg/errors"
+)
+
+// Client calls the control API of a running tzpay serv
+type Client struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// ClientInput is the input for NewClient
+type ClientInput struct {
+	URL    string // e.g. http://localhost:8080
+	APIKey string
+}
+
+// NewClient returns a new Client
+func NewClient(input ClientInput) *Client {
+	return &Client{
+		url:    strings.TrimSuffix(input.URL, "/"),
+		apiKey: input.APIKey,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Queue returns the payouts being executed and waiting in the queue
+func (c *Client) Queue() (payout.QueueStatus, error) {
+	var status payout.QueueStatus
+	err := c.do(http.MethodGet, "/v1/control/queue", nil, &status)
+	return status, errors.Wrap(err, "failed to get queue")
+}
+
+// Pause stops the queue from executing payouts
+func (c *Client) Pause() (payout.QueueStatus, error) {
+	var status payout.QueueStatus
+	err := c.do(http.MethodPost, "/v1/control/pause", nil, &status)
+	return status, errors.Wrap(err, "failed to pause queue")
+}
+
+// Resume lets the queue execute payouts again
+func (c *Client) Resume() (payout.QueueStatus, error) {
+	var status payout.QueueStatus
+	err := c.do(http.MethodPost, "/v1/control/resume", nil, &status)
+	return status, errors.Wrap(err, "failed to resume queue")
+}
+
+// Drain pauses the queue and stops tzpay serv once the payouts being executed are done
+func (c *Client) Drain() (payout.QueueStatus, error) {
+	var status payout.QueueStatus
+	err := c.do(http.MethodPost, "/v1/control/drain", nil, &status)
+	return status, errors.Wrap(err, "failed to drain queue")
+}
+
+// Remove removes the payout id from the queue without executing it
+func (c *Client) Remove(id string) (payout.Queued, error) {
+	var removed payout.Queued
+	err := c.do(http.MethodDelete, "/v1/control/queue/"+url.PathEscape(id), nil, &removed)
+	return removed, errors.Wrap(err, "failed to remove payout from queue")
+}
+
+// Approve approves the payout of baker, the primary baker if empty, held for cycle on behalf of approver
+func (c *Client) Approve(baker string, cycle int, approver string) (payout.Approval, error) {
+	var approved payout.Approval
+	err := c.do(http.MethodPost, "/v1/control/approvals", ApproveRequest{Baker: baker, Cycle: cycle, Approver: approver}, &approved)
+	return approved, errors.Wrap(err, "failed to approve payout")
+}
+
+func (c *Client) do(method, path string, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr apiError
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+			return errors.Errorf("unexpected response: %s", resp.Status)
+		}
+		return errors.New(apiErr.Error)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Client(t *testing.T) {
+	control := newControllerMock()
+	defer control.holdPayout(t, 300)()
+	queued := control.Enqueue(payout.Payout{})
+	server := httptest.NewServer(NewServer(ServerInput{
+		Config:  func() config.Config { return config.Config{Baker: config.Baker{Address: baker}} },
+		APIKey:  "some_api_key",
+		Control: control,
+	}).Handler())
+	defer server.Close()
+
+	client := NewClient(ClientInput{URL: server.URL + "/", APIKey: "some_api_key"})
+
+	status, err := client.Pause()
+	assert.Nil(t, err)
+	assert.True(t, status.Paused)
+
+	status, err = client.Queue()
+	assert.Nil(t, err)
+	assert.Equal(t, []payout.Queued{queued}, status.Queued)
+
+	removed, err := client.Remove(queued.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, queued, removed)
+
+	_, err = client.Remove(queued.ID)
+	assert.EqualError(t, err, "failed to remove payout from queue: failed to remove payout '"+queued.ID+"': no such payout in queue")
+
+	approved, err := client.Approve("", 300, "cli")
+	assert.Nil(t, err)
+	assert.Equal(t, "cli", approved.Approver)
+	assert.Equal(t, 3, approved.Transfers)
+
+	_, err = client.Approve("", 301, "cli")
+	assert.EqualError(t, err, "failed to approve payout: failed to approve payout for cycle 301 of '"+baker+"': no payout is held for the cycle")
+
+	status, err = client.Resume()
+	assert.Nil(t, err)
+	assert.False(t, status.Paused)
+
+	status, err = client.Drain()
+	assert.Nil(t, err)
+	assert.True(t, status.Paused)
+	assert.True(t, control.drained)
+
+	_, err = NewClient(ClientInput{URL: server.URL, APIKey: "wrong_api_key"}).Queue()
+	assert.EqualError(t, err, "failed to get queue: missing or invalid api key")
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Controller controls the queue of tzpay serv
+type Controller interface {
+	// Trigger adds the payout of cycle for baker to the queue
+	Trigger(baker string, cycle int, partial bool) (payout.Queued, error)
+	Pause()
+	Resume()
+	// Drain pauses the queue, and stops tzpay serv once the payouts being executed are done, without waiting for it
+	Drain()
+	// Remove removes the payout id from the queue without executing it
+	Remove(id string) (payout.Queued, error)
+	// Approve approves the payout of baker held for cycle on behalf of approver, when payouts are held without approval keys
+	Approve(baker string, cycle int, approver string) (payout.Approval, error)
+	Status() payout.QueueStatus
+	// Reload reloads the configuration of tzpay serv, and returns the variables changed that take a restart
+	Reload() ([]string, error)
+	// Subscribe returns the events of payouts from now on, and a function to call once done with them
+	Subscribe() (<-chan notifier.Event, func())
+}
+
+// TriggerRequest is the body of a request to trigger a payout
+type TriggerRequest struct {
+	Baker   string `json:"baker,omitempty"` // the primary baker if empty
+	Cycle   int    `json:"cycle"`
+	Partial bool   `json:"partial,omitempty"`
+}
+
+// ReloadResponse is the response to a reload of the configuration
+type ReloadResponse struct {
+	// Restart are the variables changed that take a restart, the running settings being kept until then
+	Restart []string `json:"restart"`
+}
+
+// ApproveRequest is the body of a request to approve a payout held
+type ApproveRequest struct {
+	Baker    string `json:"baker,omitempty"` // the primary baker if empty
+	Cycle    int    `json:"cycle"`
+	Approver string `json:"approver,omitempty"` // who approves the payout, for the audit log, the control API if empty
+}
+
+// controlHandler adds the endpoints of the control API to mux:
+//
+//	POST   /v1/control/payouts      adds the payout of a TriggerRequest to the queue
+//	POST   /v1/control/approvals    approves the payout held of an ApproveRequest, injected when the queue retries it
+//	POST   /v1/control/pause        stops the queue from executing payouts, and returns its status
+//	POST   /v1/control/resume       lets the queue execute payouts again, and returns its status
+//	POST   /v1/control/drain        pauses the queue and stops tzpay serv once the payouts being executed are done
+//	POST   /v1/control/reload       reloads the configuration, as on SIGHUP, and returns a ReloadResponse
+//	GET    /v1/control/queue        the payouts being executed and waiting in the queue
+//	DELETE /v1/control/queue/{id}   removes a payout waiting in the queue without executing it
+//	GET    /v1/control/events       streams the events of payouts as JSON, one per line, until the client disconnects
+func (s *Server) controlHandler(mux *http.ServeMux) {
+	mux.HandleFunc("/v1/control/payouts", s.trigger)
+	mux.HandleFunc("/v1/control/approvals", s.approve)
+	mux.HandleFunc("/v1/control/pause", s.pause)
+	mux.HandleFunc("/v1/control/resume", s.resume)
+	mux.HandleFunc("/v1/control/drain", s.drain)
+	mux.HandleFunc("/v1/control/reload", s.reload)
+	mux.HandleFunc("/v1/control/queue", s.queue)
+	mux.HandleFunc("/v1/control/queue/", s.remove)
+	mux.HandleFunc("/v1/control/events", s.events)
+}
+
+func (s *Server) trigger(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	var req TriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "invalid request"))
+		return
+	} else if req.Cycle <= 0 {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid cycle %d", req.Cycle))
+		return
+	}
+
+	cfg, err := s.config().ForBaker(req.Baker)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	queued, err := s.control.Trigger(cfg.Baker.Address, req.Cycle, req.Partial)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.WithFields(log.Fields{"payout-cycle": queued.Cycle, "baker": queued.Baker, "partial": queued.Partial}).Info("Payout triggered through the control API.")
+	writeJSON(w, http.StatusAccepted, queued)
+}
+
+func (s *Server) approve(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	var req ApproveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.Wrap(err, "invalid request"))
+		return
+	} else if req.Cycle <= 0 {
+		writeError(w, http.StatusBadRequest, errors.Errorf("invalid cycle %d", req.Cycle))
+		return
+	}
+	if req.Approver == "" {
+		req.Approver = "control API"
+	}
+
+	cfg, err := s.config().ForBaker(req.Baker)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if len(cfg.Approval.Keys) > 0 {
+		// Dual control is not to be bypassed by anyone holding the API key
+		writeError(w, http.StatusForbidden, errors.Errorf("payouts need the signature of one of the approvers, approve it with 'tzpay approve %d --token'", req.Cycle))
+		return
+	}
+
+	approved, err := s.control.Approve(cfg.Baker.Address, req.Cycle, req.Approver)
+	if payout.NotHeld(err) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.WithFields(log.Fields{"payout-cycle": approved.Cycle, "baker": approved.Baker, "approver": approved.Approver}).Info("Payout approved through the control API.")
+	writeJSON(w, http.StatusOK, approved)
+}
+
+func (s *Server) pause(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	s.control.Pause()
+	log.Info("Queue paused through the control API.")
+	writeJSON(w, http.StatusOK, s.control.Status())
+}
+
+func (s *Server) resume(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	s.control.Resume()
+	log.Info("Queue resumed through the control API.")
+	writeJSON(w, http.StatusOK, s.control.Status())
+}
+
+func (s *Server) drain(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	s.control.Drain()
+	log.Info("Queue draining through the control API.")
+	writeJSON(w, http.StatusAccepted, s.control.Status())
+}
+
+func (s *Server) reload(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+
+	log.Info("Reloading configuration through the control API.")
+	restart, err := s.control.Reload()
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if restart == nil {
+		restart = []string{}
+	}
+	writeJSON(w, http.StatusOK, ReloadResponse{Restart: restart})
+}
+
+func (s *Server) remove(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodDelete) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/control/queue/")
+	removed, err := s.control.Remove(id)
+	if payout.NotQueued(err) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	} else if payout.Running(err) {
+		writeError(w, http.StatusConflict, err)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.WithFields(log.Fields{"id": id, "payout-cycle": removed.Cycle, "baker": removed.Baker}).Info("Payout removed from queue through the control API.")
+	writeJSON(w, http.StatusOK, removed)
+}
+
+func (s *Server) queue(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.control.Status())
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	events, done := s.control.Subscribe()
+	defer done()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// allow writes an error and returns false if the request is not of method
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %s not allowed", r.Method))
+		return false
+	}
+
+	return true
+}
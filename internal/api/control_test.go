@@ -0,0 +1,243 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type controllerMock struct {
+	*payout.Queue
+	triggered []payout.Queued
+	drained   bool
+	reload    error
+	events    chan notifier.Event
+	store     store.IFace
+}
+
+func newControllerMock() *controllerMock {
+	return &controllerMock{Queue: payout.NewQueue(nil), events: make(chan notifier.Event, 2)}
+}
+
+func (c *controllerMock) Trigger(baker string, cycle int, partial bool) (payout.Queued, error) {
+	queued := payout.Queued{Baker: baker, Cycle: cycle, Partial: partial}
+	c.triggered = append(c.triggered, queued)
+	return queued, nil
+}
+
+func (c *controllerMock) Approve(baker string, cycle int, approver string) (payout.Approval, error) {
+	return payout.ApproveHeld(c.store, config.Config{Baker: config.Baker{Address: baker}}, cycle, approver)
+}
+
+// holdPayout opens a store for the mock in which the payout of baker for cycle is held, and returns a function removing it
+func (c *controllerMock) holdPayout(t *testing.T, cycle int) func() {
+	dir, err := ioutil.TempDir("", "tzpay-control")
+	assert.Nil(t, err)
+
+	c.store, err = store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, c.store.Put("approvals/"+baker, fmt.Sprintf("%08d", cycle), payout.Approval{Cycle: cycle, Baker: baker, Transfers: 3}))
+
+	return func() { os.RemoveAll(dir) }
+}
+
+func (c *controllerMock) Reload() ([]string, error) {
+	if c.reload != nil {
+		return nil, c.reload
+	}
+	return []string{"TZPAY_API_*"}, nil
+}
+
+func (c *controllerMock) Drain() {
+	c.drained = true
+	c.Queue.Pause()
+}
+
+func (c *controllerMock) Subscribe() (<-chan notifier.Event, func()) {
+	return c.events, func() {}
+}
+
+func Test_Control(t *testing.T) {
+	cfg := config.Config{
+		Baker:     config.Baker{Address: baker},
+		Delegates: []config.Delegate{{Baker: config.Baker{Address: "tz1other"}}},
+	}
+	control := newControllerMock()
+	queued := control.Enqueue(payout.Payout{})
+	handler := NewServer(ServerInput{
+		Config:  func() config.Config { return cfg },
+		APIKey:  "some_api_key",
+		Control: control,
+	}).Handler()
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		key      string
+		code     int
+		contains string
+	}{
+		{"triggers payout", http.MethodPost, "/v1/control/payouts", `{"cycle":300}`, "some_api_key", http.StatusAccepted, `"baker":"` + baker + `","cycle":300`},
+		{"triggers payout of another baker", http.MethodPost, "/v1/control/payouts", `{"baker":"tz1other","cycle":301,"partial":true}`, "some_api_key", http.StatusAccepted, `"partial":true`},
+		{"handles unknown baker", http.MethodPost, "/v1/control/payouts", `{"baker":"tz1unknown","cycle":300}`, "some_api_key", http.StatusNotFound, `baker 'tz1unknown' is not configured`},
+		{"handles invalid cycle", http.MethodPost, "/v1/control/payouts", `{"cycle":0}`, "some_api_key", http.StatusBadRequest, `invalid cycle 0`},
+		{"handles invalid request", http.MethodPost, "/v1/control/payouts", `{`, "some_api_key", http.StatusBadRequest, `invalid request`},
+		{"handles trigger with get", http.MethodGet, "/v1/control/payouts", "", "some_api_key", http.StatusMethodNotAllowed, `method GET not allowed`},
+		{"pauses queue", http.MethodPost, "/v1/control/pause", "", "some_api_key", http.StatusOK, `"paused":true`},
+		{"gets queue", http.MethodGet, "/v1/control/queue", "", "some_api_key", http.StatusOK, `"paused":true`},
+		{"resumes queue", http.MethodPost, "/v1/control/resume", "", "some_api_key", http.StatusOK, `"paused":false`},
+		{"removes payout", http.MethodDelete, "/v1/control/queue/" + queued.ID, "", "some_api_key", http.StatusOK, `"id":"` + queued.ID + `"`},
+		{"handles payout not queued", http.MethodDelete, "/v1/control/queue/" + queued.ID, "", "some_api_key", http.StatusNotFound, `no such payout in queue`},
+		{"drains queue", http.MethodPost, "/v1/control/drain", "", "some_api_key", http.StatusAccepted, `"paused":true`},
+		{"handles missing api key", http.MethodPost, "/v1/control/pause", "", "", http.StatusUnauthorized, `missing or invalid api key`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.contains)
+			assert.True(t, json.Valid(rec.Body.Bytes()))
+		})
+	}
+
+	assert.Len(t, control.triggered, 2)
+	assert.True(t, control.drained)
+	assert.Empty(t, control.Status().Queued)
+}
+
+func Test_Control_approve(t *testing.T) {
+	cfg := config.Config{Baker: config.Baker{Address: baker}}
+	control := newControllerMock()
+	defer control.holdPayout(t, 300)()
+	handler := NewServer(ServerInput{
+		Config:  func() config.Config { return cfg },
+		APIKey:  "some_api_key",
+		Control: control,
+	}).Handler()
+
+	cases := []struct {
+		name     string
+		body     string
+		keys     []string
+		code     int
+		contains string
+	}{
+		{"handles payout not held", `{"cycle":301}`, nil, http.StatusNotFound, `no payout is held for the cycle`},
+		{"handles invalid cycle", `{"cycle":-1}`, nil, http.StatusBadRequest, `invalid cycle -1`},
+		{"refuses approval under dual control", `{"cycle":300}`, []string{"edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP"}, http.StatusForbidden, `need the signature of one of the approvers`},
+		{"approves payout", `{"cycle":300}`, nil, http.StatusOK, `"approver":"control API"`},
+		{"approves payout on behalf of approver", `{"cycle":300,"approver":"alice"}`, nil, http.StatusOK, `"approver":"alice"`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg.Approval.Keys = tt.keys
+			req := httptest.NewRequest(http.MethodPost, "/v1/control/approvals", strings.NewReader(tt.body))
+			req.Header.Set("X-API-Key", "some_api_key")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.contains)
+		})
+	}
+}
+
+func Test_Control_reload(t *testing.T) {
+	control := newControllerMock()
+	handler := NewServer(ServerInput{
+		Config:  func() config.Config { return config.Config{Baker: config.Baker{Address: baker}} },
+		APIKey:  "some_api_key",
+		Control: control,
+	}).Handler()
+
+	reload := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/control/reload", nil)
+		req.Header.Set("X-API-Key", "some_api_key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := reload()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"restart":["TZPAY_API_*"]}`, rec.Body.String())
+
+	control.reload = errors.New("failed to reload configuration: invalid input")
+	rec = reload()
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "failed to reload configuration: invalid input")
+}
+
+func Test_Control_events(t *testing.T) {
+	control := newControllerMock()
+	server := httptest.NewServer(NewServer(ServerInput{
+		Config:  func() config.Config { return config.Config{Baker: config.Baker{Address: baker}} },
+		APIKey:  "some_api_key",
+		Control: control,
+	}).Handler())
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/v1/control/events", nil)
+	assert.Nil(t, err)
+	req.Header.Set("X-API-Key", "some_api_key")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	control.events <- notifier.Event{Name: notifier.EventInjected, Baker: baker, Cycle: 300}
+	control.events <- notifier.Event{Name: notifier.EventMessage, Message: "some_message"}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var events []notifier.Event
+	for len(events) < 2 && scanner.Scan() {
+		var event notifier.Event
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	assert.Nil(t, scanner.Err())
+	assert.Equal(t, []notifier.Event{
+		{Name: notifier.EventInjected, Baker: baker, Cycle: 300},
+		{Name: notifier.EventMessage, Message: "some_message"},
+	}, events)
+}
+
+func Test_Control_disabled(t *testing.T) {
+	handler := NewServer(ServerInput{
+		Config:  func() config.Config { return config.Config{Baker: config.Baker{Address: baker}} },
+		Control: newControllerMock(),
+	}).Handler()
+
+	// without an API key, the control API is not served
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/control/pause", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
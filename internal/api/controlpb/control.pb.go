@@ -0,0 +1,1207 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: control.proto
+
+package controlpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type TriggerRequest struct {
+	Baker                string   `protobuf:"bytes,1,opt,name=baker,proto3" json:"baker,omitempty"`
+	Cycle                int64    `protobuf:"varint,2,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Partial              bool     `protobuf:"varint,3,opt,name=partial,proto3" json:"partial,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TriggerRequest) Reset()         { *m = TriggerRequest{} }
+func (m *TriggerRequest) String() string { return proto.CompactTextString(m) }
+func (*TriggerRequest) ProtoMessage()    {}
+func (*TriggerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{0}
+}
+
+func (m *TriggerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TriggerRequest.Unmarshal(m, b)
+}
+func (m *TriggerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TriggerRequest.Marshal(b, m, deterministic)
+}
+func (m *TriggerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TriggerRequest.Merge(m, src)
+}
+func (m *TriggerRequest) XXX_Size() int {
+	return xxx_messageInfo_TriggerRequest.Size(m)
+}
+func (m *TriggerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TriggerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TriggerRequest proto.InternalMessageInfo
+
+func (m *TriggerRequest) GetBaker() string {
+	if m != nil {
+		return m.Baker
+	}
+	return ""
+}
+
+func (m *TriggerRequest) GetCycle() int64 {
+	if m != nil {
+		return m.Cycle
+	}
+	return 0
+}
+
+func (m *TriggerRequest) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+type ApproveRequest struct {
+	Baker                string   `protobuf:"bytes,1,opt,name=baker,proto3" json:"baker,omitempty"`
+	Cycle                int64    `protobuf:"varint,2,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Approver             string   `protobuf:"bytes,3,opt,name=approver,proto3" json:"approver,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ApproveRequest) Reset()         { *m = ApproveRequest{} }
+func (m *ApproveRequest) String() string { return proto.CompactTextString(m) }
+func (*ApproveRequest) ProtoMessage()    {}
+func (*ApproveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{1}
+}
+
+func (m *ApproveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApproveRequest.Unmarshal(m, b)
+}
+func (m *ApproveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApproveRequest.Marshal(b, m, deterministic)
+}
+func (m *ApproveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApproveRequest.Merge(m, src)
+}
+func (m *ApproveRequest) XXX_Size() int {
+	return xxx_messageInfo_ApproveRequest.Size(m)
+}
+func (m *ApproveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApproveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApproveRequest proto.InternalMessageInfo
+
+func (m *ApproveRequest) GetBaker() string {
+	if m != nil {
+		return m.Baker
+	}
+	return ""
+}
+
+func (m *ApproveRequest) GetCycle() int64 {
+	if m != nil {
+		return m.Cycle
+	}
+	return 0
+}
+
+func (m *ApproveRequest) GetApprover() string {
+	if m != nil {
+		return m.Approver
+	}
+	return ""
+}
+
+type PauseRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PauseRequest) Reset()         { *m = PauseRequest{} }
+func (m *PauseRequest) String() string { return proto.CompactTextString(m) }
+func (*PauseRequest) ProtoMessage()    {}
+func (*PauseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{2}
+}
+
+func (m *PauseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PauseRequest.Unmarshal(m, b)
+}
+func (m *PauseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PauseRequest.Marshal(b, m, deterministic)
+}
+func (m *PauseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PauseRequest.Merge(m, src)
+}
+func (m *PauseRequest) XXX_Size() int {
+	return xxx_messageInfo_PauseRequest.Size(m)
+}
+func (m *PauseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PauseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PauseRequest proto.InternalMessageInfo
+
+type ResumeRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResumeRequest) Reset()         { *m = ResumeRequest{} }
+func (m *ResumeRequest) String() string { return proto.CompactTextString(m) }
+func (*ResumeRequest) ProtoMessage()    {}
+func (*ResumeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{3}
+}
+
+func (m *ResumeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResumeRequest.Unmarshal(m, b)
+}
+func (m *ResumeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResumeRequest.Marshal(b, m, deterministic)
+}
+func (m *ResumeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResumeRequest.Merge(m, src)
+}
+func (m *ResumeRequest) XXX_Size() int {
+	return xxx_messageInfo_ResumeRequest.Size(m)
+}
+func (m *ResumeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResumeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResumeRequest proto.InternalMessageInfo
+
+type DrainRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DrainRequest) Reset()         { *m = DrainRequest{} }
+func (m *DrainRequest) String() string { return proto.CompactTextString(m) }
+func (*DrainRequest) ProtoMessage()    {}
+func (*DrainRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{4}
+}
+
+func (m *DrainRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DrainRequest.Unmarshal(m, b)
+}
+func (m *DrainRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DrainRequest.Marshal(b, m, deterministic)
+}
+func (m *DrainRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DrainRequest.Merge(m, src)
+}
+func (m *DrainRequest) XXX_Size() int {
+	return xxx_messageInfo_DrainRequest.Size(m)
+}
+func (m *DrainRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DrainRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DrainRequest proto.InternalMessageInfo
+
+type ReloadRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadRequest) Reset()         { *m = ReloadRequest{} }
+func (m *ReloadRequest) String() string { return proto.CompactTextString(m) }
+func (*ReloadRequest) ProtoMessage()    {}
+func (*ReloadRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{5}
+}
+
+func (m *ReloadRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadRequest.Unmarshal(m, b)
+}
+func (m *ReloadRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadRequest.Marshal(b, m, deterministic)
+}
+func (m *ReloadRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadRequest.Merge(m, src)
+}
+func (m *ReloadRequest) XXX_Size() int {
+	return xxx_messageInfo_ReloadRequest.Size(m)
+}
+func (m *ReloadRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadRequest proto.InternalMessageInfo
+
+type StatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusRequest) Reset()         { *m = StatusRequest{} }
+func (m *StatusRequest) String() string { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()    {}
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{6}
+}
+
+func (m *StatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusRequest.Unmarshal(m, b)
+}
+func (m *StatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusRequest.Marshal(b, m, deterministic)
+}
+func (m *StatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusRequest.Merge(m, src)
+}
+func (m *StatusRequest) XXX_Size() int {
+	return xxx_messageInfo_StatusRequest.Size(m)
+}
+func (m *StatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusRequest proto.InternalMessageInfo
+
+type RemoveRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveRequest) Reset()         { *m = RemoveRequest{} }
+func (m *RemoveRequest) String() string { return proto.CompactTextString(m) }
+func (*RemoveRequest) ProtoMessage()    {}
+func (*RemoveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{7}
+}
+
+func (m *RemoveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveRequest.Unmarshal(m, b)
+}
+func (m *RemoveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveRequest.Marshal(b, m, deterministic)
+}
+func (m *RemoveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveRequest.Merge(m, src)
+}
+func (m *RemoveRequest) XXX_Size() int {
+	return xxx_messageInfo_RemoveRequest.Size(m)
+}
+func (m *RemoveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveRequest proto.InternalMessageInfo
+
+func (m *RemoveRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type EventsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EventsRequest) Reset()         { *m = EventsRequest{} }
+func (m *EventsRequest) String() string { return proto.CompactTextString(m) }
+func (*EventsRequest) ProtoMessage()    {}
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{8}
+}
+
+func (m *EventsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EventsRequest.Unmarshal(m, b)
+}
+func (m *EventsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EventsRequest.Marshal(b, m, deterministic)
+}
+func (m *EventsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EventsRequest.Merge(m, src)
+}
+func (m *EventsRequest) XXX_Size() int {
+	return xxx_messageInfo_EventsRequest.Size(m)
+}
+func (m *EventsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EventsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EventsRequest proto.InternalMessageInfo
+
+type Queued struct {
+	Id                   string               `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Baker                string               `protobuf:"bytes,2,opt,name=baker,proto3" json:"baker,omitempty"`
+	Cycle                int64                `protobuf:"varint,3,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Partial              bool                 `protobuf:"varint,4,opt,name=partial,proto3" json:"partial,omitempty"`
+	Enqueued             *timestamp.Timestamp `protobuf:"bytes,5,opt,name=enqueued,proto3" json:"enqueued,omitempty"`
+	Attempts             int64                `protobuf:"varint,6,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	Approval             bool                 `protobuf:"varint,7,opt,name=approval,proto3" json:"approval,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Queued) Reset()         { *m = Queued{} }
+func (m *Queued) String() string { return proto.CompactTextString(m) }
+func (*Queued) ProtoMessage()    {}
+func (*Queued) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{9}
+}
+
+func (m *Queued) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Queued.Unmarshal(m, b)
+}
+func (m *Queued) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Queued.Marshal(b, m, deterministic)
+}
+func (m *Queued) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Queued.Merge(m, src)
+}
+func (m *Queued) XXX_Size() int {
+	return xxx_messageInfo_Queued.Size(m)
+}
+func (m *Queued) XXX_DiscardUnknown() {
+	xxx_messageInfo_Queued.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Queued proto.InternalMessageInfo
+
+func (m *Queued) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Queued) GetBaker() string {
+	if m != nil {
+		return m.Baker
+	}
+	return ""
+}
+
+func (m *Queued) GetCycle() int64 {
+	if m != nil {
+		return m.Cycle
+	}
+	return 0
+}
+
+func (m *Queued) GetPartial() bool {
+	if m != nil {
+		return m.Partial
+	}
+	return false
+}
+
+func (m *Queued) GetEnqueued() *timestamp.Timestamp {
+	if m != nil {
+		return m.Enqueued
+	}
+	return nil
+}
+
+func (m *Queued) GetAttempts() int64 {
+	if m != nil {
+		return m.Attempts
+	}
+	return 0
+}
+
+func (m *Queued) GetApproval() bool {
+	if m != nil {
+		return m.Approval
+	}
+	return false
+}
+
+type QueueStatus struct {
+	Paused               bool      `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	Running              []*Queued `protobuf:"bytes,2,rep,name=running,proto3" json:"running,omitempty"`
+	Queued               []*Queued `protobuf:"bytes,3,rep,name=queued,proto3" json:"queued,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *QueueStatus) Reset()         { *m = QueueStatus{} }
+func (m *QueueStatus) String() string { return proto.CompactTextString(m) }
+func (*QueueStatus) ProtoMessage()    {}
+func (*QueueStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{10}
+}
+
+func (m *QueueStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueueStatus.Unmarshal(m, b)
+}
+func (m *QueueStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueueStatus.Marshal(b, m, deterministic)
+}
+func (m *QueueStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueueStatus.Merge(m, src)
+}
+func (m *QueueStatus) XXX_Size() int {
+	return xxx_messageInfo_QueueStatus.Size(m)
+}
+func (m *QueueStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueueStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueueStatus proto.InternalMessageInfo
+
+func (m *QueueStatus) GetPaused() bool {
+	if m != nil {
+		return m.Paused
+	}
+	return false
+}
+
+func (m *QueueStatus) GetRunning() []*Queued {
+	if m != nil {
+		return m.Running
+	}
+	return nil
+}
+
+func (m *QueueStatus) GetQueued() []*Queued {
+	if m != nil {
+		return m.Queued
+	}
+	return nil
+}
+
+type Approval struct {
+	Cycle                int64                `protobuf:"varint,1,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Baker                string               `protobuf:"bytes,2,opt,name=baker,proto3" json:"baker,omitempty"`
+	Wallet               string               `protobuf:"bytes,3,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Transfers            int64                `protobuf:"varint,4,opt,name=transfers,proto3" json:"transfers,omitempty"`
+	Amount               int64                `protobuf:"varint,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Payload              string               `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+	Forged               *timestamp.Timestamp `protobuf:"bytes,7,opt,name=forged,proto3" json:"forged,omitempty"`
+	Approver             string               `protobuf:"bytes,8,opt,name=approver,proto3" json:"approver,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Approval) Reset()         { *m = Approval{} }
+func (m *Approval) String() string { return proto.CompactTextString(m) }
+func (*Approval) ProtoMessage()    {}
+func (*Approval) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{11}
+}
+
+func (m *Approval) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Approval.Unmarshal(m, b)
+}
+func (m *Approval) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Approval.Marshal(b, m, deterministic)
+}
+func (m *Approval) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Approval.Merge(m, src)
+}
+func (m *Approval) XXX_Size() int {
+	return xxx_messageInfo_Approval.Size(m)
+}
+func (m *Approval) XXX_DiscardUnknown() {
+	xxx_messageInfo_Approval.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Approval proto.InternalMessageInfo
+
+func (m *Approval) GetCycle() int64 {
+	if m != nil {
+		return m.Cycle
+	}
+	return 0
+}
+
+func (m *Approval) GetBaker() string {
+	if m != nil {
+		return m.Baker
+	}
+	return ""
+}
+
+func (m *Approval) GetWallet() string {
+	if m != nil {
+		return m.Wallet
+	}
+	return ""
+}
+
+func (m *Approval) GetTransfers() int64 {
+	if m != nil {
+		return m.Transfers
+	}
+	return 0
+}
+
+func (m *Approval) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *Approval) GetPayload() string {
+	if m != nil {
+		return m.Payload
+	}
+	return ""
+}
+
+func (m *Approval) GetForged() *timestamp.Timestamp {
+	if m != nil {
+		return m.Forged
+	}
+	return nil
+}
+
+func (m *Approval) GetApprover() string {
+	if m != nil {
+		return m.Approver
+	}
+	return ""
+}
+
+type ReloadResponse struct {
+	Restart              []string `protobuf:"bytes,1,rep,name=restart,proto3" json:"restart,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReloadResponse) Reset()         { *m = ReloadResponse{} }
+func (m *ReloadResponse) String() string { return proto.CompactTextString(m) }
+func (*ReloadResponse) ProtoMessage()    {}
+func (*ReloadResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{12}
+}
+
+func (m *ReloadResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReloadResponse.Unmarshal(m, b)
+}
+func (m *ReloadResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReloadResponse.Marshal(b, m, deterministic)
+}
+func (m *ReloadResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReloadResponse.Merge(m, src)
+}
+func (m *ReloadResponse) XXX_Size() int {
+	return xxx_messageInfo_ReloadResponse.Size(m)
+}
+func (m *ReloadResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReloadResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReloadResponse proto.InternalMessageInfo
+
+func (m *ReloadResponse) GetRestart() []string {
+	if m != nil {
+		return m.Restart
+	}
+	return nil
+}
+
+type Event struct {
+	Event                string               `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Baker                string               `protobuf:"bytes,3,opt,name=baker,proto3" json:"baker,omitempty"`
+	Cycle                int64                `protobuf:"varint,4,opt,name=cycle,proto3" json:"cycle,omitempty"`
+	Transfers            int64                `protobuf:"varint,5,opt,name=transfers,proto3" json:"transfers,omitempty"`
+	Amount               int64                `protobuf:"varint,6,opt,name=amount,proto3" json:"amount,omitempty"`
+	Operations           []string             `protobuf:"bytes,7,rep,name=operations,proto3" json:"operations,omitempty"`
+	Error                string               `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	Message              string               `protobuf:"bytes,9,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+func (*Event) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0c5120591600887d, []int{13}
+}
+
+func (m *Event) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Event.Unmarshal(m, b)
+}
+func (m *Event) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Event.Marshal(b, m, deterministic)
+}
+func (m *Event) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Event.Merge(m, src)
+}
+func (m *Event) XXX_Size() int {
+	return xxx_messageInfo_Event.Size(m)
+}
+func (m *Event) XXX_DiscardUnknown() {
+	xxx_messageInfo_Event.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Event proto.InternalMessageInfo
+
+func (m *Event) GetEvent() string {
+	if m != nil {
+		return m.Event
+	}
+	return ""
+}
+
+func (m *Event) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *Event) GetBaker() string {
+	if m != nil {
+		return m.Baker
+	}
+	return ""
+}
+
+func (m *Event) GetCycle() int64 {
+	if m != nil {
+		return m.Cycle
+	}
+	return 0
+}
+
+func (m *Event) GetTransfers() int64 {
+	if m != nil {
+		return m.Transfers
+	}
+	return 0
+}
+
+func (m *Event) GetAmount() int64 {
+	if m != nil {
+		return m.Amount
+	}
+	return 0
+}
+
+func (m *Event) GetOperations() []string {
+	if m != nil {
+		return m.Operations
+	}
+	return nil
+}
+
+func (m *Event) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *Event) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*TriggerRequest)(nil), "tzpay.control.v1.TriggerRequest")
+	proto.RegisterType((*ApproveRequest)(nil), "tzpay.control.v1.ApproveRequest")
+	proto.RegisterType((*PauseRequest)(nil), "tzpay.control.v1.PauseRequest")
+	proto.RegisterType((*ResumeRequest)(nil), "tzpay.control.v1.ResumeRequest")
+	proto.RegisterType((*DrainRequest)(nil), "tzpay.control.v1.DrainRequest")
+	proto.RegisterType((*ReloadRequest)(nil), "tzpay.control.v1.ReloadRequest")
+	proto.RegisterType((*StatusRequest)(nil), "tzpay.control.v1.StatusRequest")
+	proto.RegisterType((*RemoveRequest)(nil), "tzpay.control.v1.RemoveRequest")
+	proto.RegisterType((*EventsRequest)(nil), "tzpay.control.v1.EventsRequest")
+	proto.RegisterType((*Queued)(nil), "tzpay.control.v1.Queued")
+	proto.RegisterType((*QueueStatus)(nil), "tzpay.control.v1.QueueStatus")
+	proto.RegisterType((*Approval)(nil), "tzpay.control.v1.Approval")
+	proto.RegisterType((*ReloadResponse)(nil), "tzpay.control.v1.ReloadResponse")
+	proto.RegisterType((*Event)(nil), "tzpay.control.v1.Event")
+}
+
+func init() {
+	proto.RegisterFile("control.proto", fileDescriptor_0c5120591600887d)
+}
+
+var fileDescriptor_0c5120591600887d = []byte{
+	// 729 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0x5f, 0x6f, 0xd3, 0x3e,
+	0x14, 0x55, 0x9a, 0x35, 0x69, 0xef, 0xb6, 0xfe, 0x7e, 0xb2, 0xd0, 0x88, 0x22, 0xd8, 0xa2, 0x3e,
+	0x55, 0x48, 0x24, 0xa3, 0x93, 0x40, 0x3c, 0x8e, 0xfd, 0x61, 0x12, 0x2f, 0x10, 0x26, 0x84, 0x78,
+	0x73, 0x5b, 0x2f, 0x44, 0x24, 0x71, 0x66, 0x3b, 0x45, 0xe5, 0x2b, 0xf0, 0xcc, 0xd7, 0xe2, 0xa3,
+	0xf0, 0x15, 0x40, 0xb1, 0x9d, 0x36, 0xd9, 0x12, 0x2a, 0xf1, 0xd6, 0xe3, 0x9e, 0x7b, 0x73, 0xef,
+	0xf1, 0xf1, 0x81, 0xfd, 0x39, 0xcd, 0x04, 0xa3, 0x89, 0x9f, 0x33, 0x2a, 0x28, 0xfa, 0x5f, 0x7c,
+	0xcb, 0xf1, 0xca, 0xaf, 0x0e, 0x97, 0xcf, 0xdc, 0xa3, 0x88, 0xd2, 0x28, 0x21, 0x81, 0xfc, 0x7f,
+	0x56, 0xdc, 0x04, 0x22, 0x4e, 0x09, 0x17, 0x38, 0xcd, 0x55, 0xc9, 0xf8, 0x03, 0x8c, 0xae, 0x59,
+	0x1c, 0x45, 0x84, 0x85, 0xe4, 0xb6, 0x20, 0x5c, 0xa0, 0x07, 0xd0, 0x9f, 0xe1, 0x2f, 0x84, 0x39,
+	0x86, 0x67, 0x4c, 0x86, 0xa1, 0x02, 0xe5, 0xe9, 0x7c, 0x35, 0x4f, 0x88, 0xd3, 0xf3, 0x8c, 0x89,
+	0x19, 0x2a, 0x80, 0x1c, 0xb0, 0x73, 0xcc, 0x44, 0x8c, 0x13, 0xc7, 0xf4, 0x8c, 0xc9, 0x20, 0xac,
+	0xe0, 0xf8, 0x23, 0x8c, 0x4e, 0xf3, 0x9c, 0xd1, 0x25, 0xf9, 0x97, 0xbe, 0x2e, 0x0c, 0xb0, 0xaa,
+	0x66, 0xb2, 0xf1, 0x30, 0x5c, 0xe3, 0xf1, 0x08, 0xf6, 0xde, 0xe2, 0x82, 0x57, 0x7d, 0xc7, 0xff,
+	0xc1, 0x7e, 0x48, 0x78, 0x91, 0xae, 0x0f, 0x46, 0xb0, 0x77, 0xce, 0x70, 0x9c, 0x35, 0x08, 0x09,
+	0xc5, 0x8b, 0xda, 0xc1, 0x7b, 0x81, 0x45, 0xc1, 0xab, 0x83, 0xa3, 0x92, 0x91, 0xd6, 0x66, 0x1d,
+	0x41, 0x2f, 0x5e, 0xe8, 0x41, 0x7b, 0xf1, 0xa2, 0xac, 0xb8, 0x58, 0x92, 0x4c, 0xac, 0x2b, 0x7e,
+	0x1a, 0x60, 0xbd, 0x2b, 0x48, 0x41, 0x16, 0x77, 0xb9, 0x9b, 0x3d, 0x7b, 0xad, 0x7b, 0x9a, 0x1d,
+	0xfa, 0xed, 0x34, 0xf4, 0x43, 0xcf, 0x61, 0x40, 0xb2, 0x5b, 0xf9, 0x05, 0xa7, 0xef, 0x19, 0x93,
+	0xdd, 0xa9, 0xeb, 0xab, 0xbb, 0xf4, 0xab, 0xbb, 0xf4, 0xaf, 0xab, 0xbb, 0x0c, 0xd7, 0x5c, 0xa9,
+	0x9c, 0x10, 0x24, 0xcd, 0x05, 0x77, 0x2c, 0xf9, 0xa9, 0x35, 0xde, 0xa8, 0x8a, 0x13, 0xc7, 0x96,
+	0x9f, 0x5b, 0xe3, 0xf1, 0x77, 0x03, 0x76, 0xe5, 0x42, 0x4a, 0x19, 0x74, 0x00, 0x56, 0x5e, 0xaa,
+	0xac, 0x36, 0x1b, 0x84, 0x1a, 0xa1, 0x29, 0xd8, 0xac, 0xc8, 0xb2, 0x38, 0x8b, 0x9c, 0x9e, 0x67,
+	0x4e, 0x76, 0xa7, 0x8e, 0x7f, 0xd7, 0x74, 0xbe, 0x12, 0x26, 0xac, 0x88, 0xe8, 0x18, 0x2c, 0xbd,
+	0x89, 0xb9, 0xa5, 0x44, 0xf3, 0xc6, 0xbf, 0x0c, 0x18, 0x9c, 0xea, 0xd1, 0x36, 0xd2, 0x19, 0x75,
+	0xe9, 0xda, 0x65, 0x3e, 0x00, 0xeb, 0x2b, 0x4e, 0x12, 0x22, 0xb4, 0x6d, 0x34, 0x42, 0x8f, 0x60,
+	0x28, 0x18, 0xce, 0xf8, 0x0d, 0x61, 0x5c, 0x4a, 0x6d, 0x86, 0x9b, 0x83, 0xb2, 0x0a, 0xa7, 0xb4,
+	0xc8, 0x84, 0x94, 0xda, 0x0c, 0x35, 0x52, 0xd7, 0xb3, 0x2a, 0xad, 0x23, 0xb5, 0x1c, 0x86, 0x15,
+	0x44, 0x53, 0xb0, 0x6e, 0x28, 0x8b, 0xc8, 0xc2, 0xb1, 0xb7, 0x5e, 0x8e, 0x66, 0x36, 0x4c, 0x3d,
+	0xb8, 0x63, 0xea, 0x27, 0x30, 0xaa, 0x3c, 0xca, 0x73, 0x9a, 0x71, 0x69, 0x0d, 0x56, 0x36, 0x60,
+	0xc2, 0x31, 0x3c, 0xb3, 0xfc, 0xb6, 0x86, 0xe3, 0xdf, 0x06, 0xf4, 0xa5, 0x1b, 0x4b, 0x0d, 0x48,
+	0xf9, 0xa3, 0x7a, 0x52, 0x12, 0x20, 0x1f, 0x76, 0xca, 0x57, 0xee, 0xf4, 0xb6, 0x4e, 0x26, 0x79,
+	0x1b, 0x25, 0xcd, 0x56, 0xc3, 0xee, 0xd4, 0x55, 0x6f, 0xe8, 0xd8, 0xef, 0xd6, 0xd1, 0x6a, 0xe8,
+	0x78, 0x08, 0x40, 0x73, 0xc2, 0xb0, 0x88, 0x69, 0xc6, 0x1d, 0x5b, 0xae, 0x53, 0x3b, 0x91, 0x7b,
+	0x30, 0x46, 0x2b, 0x59, 0x14, 0x28, 0x15, 0x48, 0x09, 0xe7, 0x38, 0x22, 0xce, 0x50, 0xa9, 0xaf,
+	0xe1, 0xf4, 0x47, 0x1f, 0xec, 0x33, 0x65, 0x1e, 0x74, 0x01, 0xb6, 0x0e, 0x30, 0xe4, 0xdd, 0xf7,
+	0x55, 0x33, 0xdb, 0xdc, 0x4e, 0xe7, 0xa1, 0xd7, 0x60, 0xeb, 0xbc, 0x6a, 0x6b, 0xd3, 0x8c, 0x32,
+	0xd7, 0xed, 0x62, 0xe0, 0x04, 0x5d, 0x42, 0x5f, 0xc6, 0x13, 0x3a, 0xbc, 0x4f, 0xaa, 0xe7, 0x96,
+	0xfb, 0xb8, 0x63, 0x16, 0xfd, 0x00, 0xaf, 0xc0, 0x52, 0xb1, 0x86, 0x8e, 0xee, 0x13, 0x1b, 0x81,
+	0xb7, 0xad, 0xd3, 0x25, 0xf4, 0x65, 0x1e, 0xb6, 0x4d, 0x54, 0x0f, 0xca, 0x6d, 0x7d, 0xde, 0x80,
+	0xa5, 0x3c, 0xda, 0x3e, 0x51, 0x2d, 0x61, 0x5d, 0xaf, 0x9b, 0xa0, 0xed, 0x7d, 0x05, 0x96, 0x6e,
+	0xdb, 0xd2, 0xac, 0x91, 0xce, 0xdb, 0xc6, 0x3a, 0x03, 0x4b, 0x85, 0x77, 0xfb, 0x58, 0xb5, 0x58,
+	0xff, 0xcb, 0xf5, 0x9f, 0x83, 0xa5, 0x02, 0xbe, 0xad, 0x49, 0x23, 0xfa, 0xdd, 0x87, 0x1d, 0x84,
+	0x63, 0xe3, 0xd5, 0xcb, 0x4f, 0x2f, 0xa2, 0x58, 0x7c, 0x2e, 0x66, 0xfe, 0x9c, 0xa6, 0x41, 0x44,
+	0xb1, 0x78, 0xca, 0x57, 0x5c, 0x90, 0x94, 0x07, 0xb2, 0x26, 0x58, 0x9e, 0x04, 0x71, 0x26, 0x08,
+	0xcb, 0x70, 0x12, 0xe0, 0x3c, 0x0e, 0x74, 0x8f, 0x7c, 0x36, 0xb3, 0xe4, 0xf3, 0x3c, 0xf9, 0x33,
+	0x00, 0xbc, 0x99, 0xe6, 0x3d, 0xd2, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ControlClient is the client API for Control service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ControlClient interface {
+	Trigger(ctx context.Context, in *TriggerRequest, opts ...grpc.CallOption) (*Queued, error)
+	Approve(ctx context.Context, in *ApproveRequest, opts ...grpc.CallOption) (*Approval, error)
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*QueueStatus, error)
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*QueueStatus, error)
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*QueueStatus, error)
+	Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error)
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*QueueStatus, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*Queued, error)
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Control_EventsClient, error)
+}
+
+type controlClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlClient(cc grpc.ClientConnInterface) ControlClient {
+	return &controlClient{cc}
+}
+
+func (c *controlClient) Trigger(ctx context.Context, in *TriggerRequest, opts ...grpc.CallOption) (*Queued, error) {
+	out := new(Queued)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Trigger", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Approve(ctx context.Context, in *ApproveRequest, opts ...grpc.CallOption) (*Approval, error) {
+	out := new(Approval)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Approve", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*QueueStatus, error) {
+	out := new(QueueStatus)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Pause", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*QueueStatus, error) {
+	out := new(QueueStatus)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Resume", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*QueueStatus, error) {
+	out := new(QueueStatus)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Drain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Reload(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error) {
+	out := new(ReloadResponse)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Reload", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*QueueStatus, error) {
+	out := new(QueueStatus)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*Queued, error) {
+	out := new(Queued)
+	err := c.cc.Invoke(ctx, "/tzpay.control.v1.Control/Remove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (Control_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Control_serviceDesc.Streams[0], "/tzpay.control.v1.Control/Events", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &controlEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Control_EventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type controlEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *controlEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ControlServer is the server API for Control service.
+type ControlServer interface {
+	Trigger(context.Context, *TriggerRequest) (*Queued, error)
+	Approve(context.Context, *ApproveRequest) (*Approval, error)
+	Pause(context.Context, *PauseRequest) (*QueueStatus, error)
+	Resume(context.Context, *ResumeRequest) (*QueueStatus, error)
+	Drain(context.Context, *DrainRequest) (*QueueStatus, error)
+	Reload(context.Context, *ReloadRequest) (*ReloadResponse, error)
+	Status(context.Context, *StatusRequest) (*QueueStatus, error)
+	Remove(context.Context, *RemoveRequest) (*Queued, error)
+	Events(*EventsRequest, Control_EventsServer) error
+}
+
+// UnimplementedControlServer can be embedded to have forward compatible implementations.
+type UnimplementedControlServer struct {
+}
+
+func (*UnimplementedControlServer) Trigger(ctx context.Context, req *TriggerRequest) (*Queued, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Trigger not implemented")
+}
+func (*UnimplementedControlServer) Approve(ctx context.Context, req *ApproveRequest) (*Approval, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Approve not implemented")
+}
+func (*UnimplementedControlServer) Pause(ctx context.Context, req *PauseRequest) (*QueueStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (*UnimplementedControlServer) Resume(ctx context.Context, req *ResumeRequest) (*QueueStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resume not implemented")
+}
+func (*UnimplementedControlServer) Drain(ctx context.Context, req *DrainRequest) (*QueueStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drain not implemented")
+}
+func (*UnimplementedControlServer) Reload(ctx context.Context, req *ReloadRequest) (*ReloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reload not implemented")
+}
+func (*UnimplementedControlServer) Status(ctx context.Context, req *StatusRequest) (*QueueStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (*UnimplementedControlServer) Remove(ctx context.Context, req *RemoveRequest) (*Queued, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
+func (*UnimplementedControlServer) Events(req *EventsRequest, srv Control_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+
+func RegisterControlServer(s *grpc.Server, srv ControlServer) {
+	s.RegisterService(&_Control_serviceDesc, srv)
+}
+
+func _Control_Trigger_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Trigger(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Trigger",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Trigger(ctx, req.(*TriggerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Approve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Approve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Approve",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Approve(ctx, req.(*ApproveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Pause",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Resume",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Resume(ctx, req.(*ResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Drain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Reload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Reload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Reload",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Reload(ctx, req.(*ReloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/tzpay.control.v1.Control/Remove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControlServer).Events(m, &controlEventsServer{stream})
+}
+
+type Control_EventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type controlEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *controlEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Control_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "tzpay.control.v1.Control",
+	HandlerType: (*ControlServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Trigger",
+			Handler:    _Control_Trigger_Handler,
+		},
+		{
+			MethodName: "Approve",
+			Handler:    _Control_Approve_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _Control_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _Control_Resume_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _Control_Drain_Handler,
+		},
+		{
+			MethodName: "Reload",
+			Handler:    _Control_Reload_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _Control_Status_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Control_Remove_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Control_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "control.proto",
+}
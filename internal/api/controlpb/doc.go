@@ -0,0 +1,4 @@
+// Package controlpb is the gRPC control service of tzpay serv, generated from control.proto with protoc-gen-go v1.3.5.
+package controlpb
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. control.proto
@@ -0,0 +1,282 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/api/controlpb"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata is the metadata every call of the gRPC control service carries the API key in
+const apiKeyMetadata = "x-api-key"
+
+// GRPCServer serves the control API over gRPC, the service of internal/api/controlpb/control.proto
+type GRPCServer struct {
+	control Controller
+	config  func() config.Config
+	apiKey  string
+	server  *grpc.Server
+}
+
+// GRPCServerInput is the input for NewGRPCServer
+type GRPCServerInput struct {
+	Control Controller
+	// Config returns the configuration of every baker served, with the overrides in effect
+	Config func() config.Config
+	APIKey string // required, calls must carry it in the x-api-key metadata
+}
+
+// NewGRPCServer returns a new GRPCServer
+func NewGRPCServer(input GRPCServerInput) *GRPCServer {
+	s := &GRPCServer{
+		control: input.Control,
+		config:  input.Config,
+		apiKey:  input.APIKey,
+	}
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(s.authenticateUnary), grpc.StreamInterceptor(s.authenticateStream))
+	controlpb.RegisterControlServer(s.server, s)
+
+	return s
+}
+
+// Start serves the gRPC control service on listen in the background. The server keeps paying out if it stops.
+func (s *GRPCServer) Start(listen string) error {
+	lis, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+
+	log.WithField("listen", listen).Info("Starting gRPC control API.")
+	go s.Serve(lis)
+
+	return nil
+}
+
+// Serve serves the gRPC control service on lis until it is stopped
+func (s *GRPCServer) Serve(lis net.Listener) {
+	if err := s.server.Serve(lis); err != nil {
+		log.WithFields(log.Fields{"error": err.Error(), "listen": lis.Addr().String()}).Error("gRPC control API stopped.")
+	}
+}
+
+// Stop stops the gRPC control service, closing the calls in flight, e.g. the streams of events
+func (s *GRPCServer) Stop() {
+	s.server.Stop()
+}
+
+// authenticated returns an error unless the metadata of ctx carries the API key
+func (s *GRPCServer) authenticated(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range md.Get(apiKeyMetadata) {
+		if s.apiKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.apiKey)) == 1 {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "missing or invalid api key")
+}
+
+func (s *GRPCServer) authenticateUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authenticated(ctx); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *GRPCServer) authenticateStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authenticated(stream.Context()); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+// Trigger adds the payout of a cycle to the queue
+func (s *GRPCServer) Trigger(ctx context.Context, req *controlpb.TriggerRequest) (*controlpb.Queued, error) {
+	if req.Cycle <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cycle %d", req.Cycle)
+	}
+
+	cfg, err := s.config().ForBaker(req.Baker)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	queued, err := s.control.Trigger(cfg.Baker.Address, int(req.Cycle), req.Partial)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.WithFields(log.Fields{"payout-cycle": queued.Cycle, "baker": queued.Baker, "partial": queued.Partial}).Info("Payout triggered through the gRPC control API.")
+	return toQueued(queued), nil
+}
+
+// Approve approves a payout held, when payouts are held without approval keys
+func (s *GRPCServer) Approve(ctx context.Context, req *controlpb.ApproveRequest) (*controlpb.Approval, error) {
+	if req.Cycle <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cycle %d", req.Cycle)
+	}
+	if req.Approver == "" {
+		req.Approver = "control API"
+	}
+
+	cfg, err := s.config().ForBaker(req.Baker)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if len(cfg.Approval.Keys) > 0 {
+		// Dual control is not to be bypassed by anyone holding the API key
+		return nil, status.Errorf(codes.PermissionDenied, "payouts need the signature of one of the approvers, approve it with 'tzpay approve %d --token'", req.Cycle)
+	}
+
+	approved, err := s.control.Approve(cfg.Baker.Address, int(req.Cycle), req.Approver)
+	if payout.NotHeld(err) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.WithFields(log.Fields{"payout-cycle": approved.Cycle, "baker": approved.Baker, "approver": approved.Approver}).Info("Payout approved through the gRPC control API.")
+	return &controlpb.Approval{
+		Cycle:     int64(approved.Cycle),
+		Baker:     approved.Baker,
+		Wallet:    approved.Wallet,
+		Transfers: int64(approved.Transfers),
+		Amount:    approved.Amount,
+		Payload:   approved.Payload,
+		Forged:    toTimestamp(approved.Forged),
+		Approver:  approved.Approver,
+	}, nil
+}
+
+// Pause stops the queue from executing payouts
+func (s *GRPCServer) Pause(ctx context.Context, req *controlpb.PauseRequest) (*controlpb.QueueStatus, error) {
+	s.control.Pause()
+	log.Info("Queue paused through the gRPC control API.")
+	return toQueueStatus(s.control.Status()), nil
+}
+
+// Resume lets the queue execute payouts again
+func (s *GRPCServer) Resume(ctx context.Context, req *controlpb.ResumeRequest) (*controlpb.QueueStatus, error) {
+	s.control.Resume()
+	log.Info("Queue resumed through the gRPC control API.")
+	return toQueueStatus(s.control.Status()), nil
+}
+
+// Drain pauses the queue and stops tzpay serv once the payouts being executed are done
+func (s *GRPCServer) Drain(ctx context.Context, req *controlpb.DrainRequest) (*controlpb.QueueStatus, error) {
+	s.control.Drain()
+	log.Info("Queue draining through the gRPC control API.")
+	return toQueueStatus(s.control.Status()), nil
+}
+
+// Reload reloads the configuration, as on SIGHUP
+func (s *GRPCServer) Reload(ctx context.Context, req *controlpb.ReloadRequest) (*controlpb.ReloadResponse, error) {
+	log.Info("Reloading configuration through the gRPC control API.")
+	restart, err := s.control.Reload()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &controlpb.ReloadResponse{Restart: restart}, nil
+}
+
+// Status returns the payouts being executed and waiting in the queue
+func (s *GRPCServer) Status(ctx context.Context, req *controlpb.StatusRequest) (*controlpb.QueueStatus, error) {
+	return toQueueStatus(s.control.Status()), nil
+}
+
+// Remove removes a payout waiting in the queue without executing it
+func (s *GRPCServer) Remove(ctx context.Context, req *controlpb.RemoveRequest) (*controlpb.Queued, error) {
+	removed, err := s.control.Remove(req.Id)
+	if payout.NotQueued(err) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if payout.Running(err) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	log.WithFields(log.Fields{"id": req.Id, "payout-cycle": removed.Cycle, "baker": removed.Baker}).Info("Payout removed from queue through the gRPC control API.")
+	return toQueued(removed), nil
+}
+
+// Events streams the events of payouts until the client cancels the call
+func (s *GRPCServer) Events(req *controlpb.EventsRequest, stream controlpb.Control_EventsServer) error {
+	events, done := s.control.Subscribe()
+	defer done()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(toEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toQueued(queued payout.Queued) *controlpb.Queued {
+	return &controlpb.Queued{
+		Id:       queued.ID,
+		Baker:    queued.Baker,
+		Cycle:    int64(queued.Cycle),
+		Partial:  queued.Partial,
+		Enqueued: toTimestamp(queued.Enqueued),
+		Attempts: int64(queued.Attempts),
+		Approval: queued.Approval,
+	}
+}
+
+func toQueueStatus(queueStatus payout.QueueStatus) *controlpb.QueueStatus {
+	resp := &controlpb.QueueStatus{Paused: queueStatus.Paused}
+	for _, queued := range queueStatus.Running {
+		resp.Running = append(resp.Running, toQueued(queued))
+	}
+	for _, queued := range queueStatus.Queued {
+		resp.Queued = append(resp.Queued, toQueued(queued))
+	}
+
+	return resp
+}
+
+func toEvent(event notifier.Event) *controlpb.Event {
+	return &controlpb.Event{
+		Event:      event.Name,
+		Time:       toTimestamp(event.Time),
+		Baker:      event.Baker,
+		Cycle:      int64(event.Cycle),
+		Transfers:  int64(event.Transfers),
+		Amount:     int64(event.Amount),
+		Operations: event.Operations,
+		Error:      event.Error,
+		Message:    event.Message,
+	}
+}
+
+// toTimestamp returns the protobuf timestamp of t, or none for the zero time
+func toTimestamp(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	ts, _ := ptypes.TimestampProto(t)
+	return ts
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/api/controlpb"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func Test_GRPCServer(t *testing.T) {
+	cfg := config.Config{
+		Baker:     config.Baker{Address: baker},
+		Delegates: []config.Delegate{{Baker: config.Baker{Address: "tz1other"}}},
+	}
+	control := newControllerMock()
+	defer control.holdPayout(t, 270)()
+
+	server := NewGRPCServer(GRPCServerInput{Control: control, Config: func() config.Config { return cfg }, APIKey: "secret"})
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	assert.Nil(t, err)
+	defer conn.Close()
+	client := controlpb.NewControlClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	authenticated := metadata.AppendToOutgoingContext(ctx, "x-api-key", "secret")
+
+	// Every call needs the API key
+	_, err = client.Status(ctx, &controlpb.StatusRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.Status(metadata.AppendToOutgoingContext(ctx, "x-api-key", "wrong"), &controlpb.StatusRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	queued, err := client.Trigger(authenticated, &controlpb.TriggerRequest{Baker: "tz1other", Cycle: 270, Partial: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "tz1other", queued.Baker)
+	assert.Equal(t, int64(270), queued.Cycle)
+	assert.True(t, queued.Partial)
+
+	_, err = client.Trigger(authenticated, &controlpb.TriggerRequest{Cycle: 270})
+	assert.Nil(t, err)
+	assert.Equal(t, baker, control.triggered[1].Baker)
+
+	_, err = client.Trigger(authenticated, &controlpb.TriggerRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.Trigger(authenticated, &controlpb.TriggerRequest{Baker: "tz1unknown", Cycle: 270})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	queueStatus, err := client.Pause(authenticated, &controlpb.PauseRequest{})
+	assert.Nil(t, err)
+	assert.True(t, queueStatus.Paused)
+	queueStatus, err = client.Resume(authenticated, &controlpb.ResumeRequest{})
+	assert.Nil(t, err)
+	assert.False(t, queueStatus.Paused)
+
+	_, err = client.Remove(authenticated, &controlpb.RemoveRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	approved, err := client.Approve(authenticated, &controlpb.ApproveRequest{Cycle: 270})
+	assert.Nil(t, err)
+	assert.Equal(t, "control API", approved.Approver)
+	assert.Equal(t, int64(3), approved.Transfers)
+	_, err = client.Approve(authenticated, &controlpb.ApproveRequest{Cycle: 271})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	reloaded, err := client.Reload(authenticated, &controlpb.ReloadRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"TZPAY_API_*"}, reloaded.Restart)
+
+	stream, err := client.Events(authenticated, &controlpb.EventsRequest{})
+	assert.Nil(t, err)
+	control.events <- notifier.Event{Name: notifier.EventInjected, Time: time.Unix(1596240000, 0), Baker: baker, Cycle: 270, Operations: []string{"oo1"}}
+	event, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, notifier.EventInjected, event.Event)
+	assert.Equal(t, int64(1596240000), event.Time.Seconds)
+	assert.Equal(t, []string{"oo1"}, event.Operations)
+
+	queueStatus, err = client.Drain(authenticated, &controlpb.DrainRequest{})
+	assert.Nil(t, err)
+	assert.True(t, queueStatus.Paused)
+	assert.True(t, control.drained)
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/lookup"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/pkg/errors"
+)
+
+// publicHandler adds the public endpoints to mux, served without the API key:
+//
+//	GET /public/                       the page where a delegator enters their address to see their payment history
+//	GET /public/delegators/{address}   every payment made to a delegator, fetched by the page
+func (s *Server) publicHandler(mux *http.ServeMux) {
+	mux.HandleFunc("/public/", s.lookupPage)
+	mux.HandleFunc("/public/delegators/", s.publicDelegator)
+}
+
+func (s *Server) lookupPage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/public/" {
+		writeError(w, http.StatusNotFound, errors.Errorf("no such endpoint '%s'", r.URL.Path))
+		return
+	}
+
+	cfg, ok := s.baker(w, r)
+	if !ok {
+		return
+	}
+
+	p := lookup.Page{Baker: cfg.Baker.Address, Prefix: "delegators/"}
+	if baker := r.URL.Query().Get("baker"); baker != "" {
+		p.Suffix = "?baker=" + url.QueryEscape(baker)
+	}
+
+	html, err := lookup.HTML(p)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(html)
+}
+
+func (s *Server) publicDelegator(w http.ResponseWriter, r *http.Request) {
+	// The history may be fetched by a page hosted on the baker's website
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	address := strings.TrimPrefix(r.URL.Path, "/public/delegators/")
+	if address == "" || strings.Contains(address, "/") {
+		writeError(w, http.StatusNotFound, errors.Errorf("no such endpoint '%s'", r.URL.Path))
+		return
+	}
+
+	cfg, ok := s.baker(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := payout.History(s.store, cfg.Baker.Address, address, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Public(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-api")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, s.Put("paid/"+baker, "00000300/"+delegator, payout.PaidEntry{Amount: 1000, Time: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}))
+
+	cfg := config.Config{
+		Baker:     config.Baker{Address: baker},
+		Delegates: []config.Delegate{{Baker: config.Baker{Address: "tz1other"}}},
+	}
+	handler := NewServer(ServerInput{
+		Store:  s,
+		Config: func() config.Config { return cfg },
+		APIKey: "some_api_key",
+		Public: true,
+	}).Handler()
+
+	cases := []struct {
+		name        string
+		path        string
+		code        int
+		contentType string
+		contains    string
+	}{
+		{"gets lookup page", "/public/", http.StatusOK, "text/html; charset=utf-8", `var suffix = "";`},
+		{"gets lookup page of another baker", "/public/?baker=tz1other", http.StatusOK, "text/html; charset=utf-8", `var suffix = "?baker=tz1other";`},
+		{"handles unknown page", "/public/other", http.StatusNotFound, "application/json", `no such endpoint`},
+		{"gets delegator", "/public/delegators/" + delegator, http.StatusOK, "application/json", `"total":1000`},
+		{"gets delegator not paid", "/public/delegators/tz1unknown", http.StatusOK, "application/json", `"payments":[]`},
+		{"handles unknown baker", "/public/delegators/" + delegator + "?baker=tz1unknown", http.StatusNotFound, "application/json", `baker 'tz1unknown' is not configured`},
+		{"handles other endpoints without api key", "/v1/delegators/" + delegator, http.StatusUnauthorized, "application/json", `missing or invalid api key`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), tt.contains)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/public/delegators/"+delegator, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func Test_Public_disabled(t *testing.T) {
+	handler := NewServer(ServerInput{
+		Config: func() config.Config { return config.Config{Baker: config.Baker{Address: baker}} },
+	}).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public/delegators/"+delegator, nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,78 @@
+/*
+Package approval signs and verifies the second approval a payout needs before it is injected under dual control.
+
+An approval token is the ed25519 signature, by one of the approvers, of the blake2b hash of the payload identifying a
+payout. It is the signature 'octez-client sign bytes 0x<payload> for <approver>' prints, so that approvers may sign
+with the tools they already hold their keys in.
+*/
+package approval
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/goat-systems/tzpay/v3/internal/wallet"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// edpkPrefix and edsigPrefix are the base58 prefixes of edpk... and edsig...
+	edpkPrefix  = []byte{13, 15, 37, 217}
+	edsigPrefix = []byte{9, 245, 205, 134, 18}
+)
+
+// Payload returns the hex encoded bytes approvers sign to approve the transfers fingerprinted of a payout
+func Payload(cycle int, baker, wallet, fingerprint string) string {
+	digest := blake2b.Sum256([]byte(fmt.Sprintf("tzpay approval:%d:%s:%s:%s", cycle, baker, wallet, fingerprint)))
+	return hex.EncodeToString(digest[:])
+}
+
+// Sign returns the approval token of payload, signed with the ed25519 key of an approver encrypted in esk
+func Sign(esk, password, payload string) (string, error) {
+	curve, secret, err := wallet.Decrypt(esk, password)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign approval")
+	} else if curve != wallet.Ed25519 {
+		return "", errors.New("failed to sign approval: approvers sign with ed25519 keys")
+	}
+
+	message, err := hex.DecodeString(payload)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign approval: invalid payload")
+	}
+	digest := blake2b.Sum256(message)
+
+	signature := ed25519.Sign(ed25519.NewKeyFromSeed(secret), digest[:])
+
+	return base58check.Encode(edsigPrefix, signature), nil
+}
+
+// Verify returns the public key of the approver whose signature of payload token is, or an error if it is none's
+func Verify(approvers []string, payload, token string) (string, error) {
+	signature, err := base58check.Decode(edsigPrefix, token)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return "", errors.Errorf("invalid approval token '%s': expected an edsig signature", token)
+	}
+
+	message, err := hex.DecodeString(payload)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid approval payload")
+	}
+	digest := blake2b.Sum256(message)
+
+	for _, approver := range approvers {
+		publicKey, err := base58check.Decode(edpkPrefix, approver)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return "", errors.Errorf("invalid approver '%s': expected an edpk public key", approver)
+		}
+
+		if ed25519.Verify(publicKey, digest[:], signature) {
+			return approver, nil
+		}
+	}
+
+	return "", errors.New("approval token is not signed by any of the approvers")
+}
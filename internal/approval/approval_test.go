@@ -0,0 +1,86 @@
+package approval
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Approval(t *testing.T) {
+	esk := "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2"
+	key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Esk: esk, Password: "password12345##"})
+	assert.Nil(t, err)
+	approver := key.PubKey.GetPublicKey()
+	other := "edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP"
+
+	payload := Payload(300, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", key.PubKey.GetPublicKeyHash(), "fingerprint")
+	assert.Len(t, payload, 64)
+	assert.NotEqual(t, payload, Payload(301, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", key.PubKey.GetPublicKeyHash(), "fingerprint"))
+
+	token, err := Sign(esk, "password12345##", payload)
+	assert.Nil(t, err)
+	assert.Equal(t, "edsig", token[:5])
+
+	_, err = Sign(esk, "wrong", payload)
+	test.CheckErr(t, true, "invalid password", err)
+
+	cases := []struct {
+		name      string
+		approvers []string
+		payload   string
+		token     string
+		want      string
+		wantErr   bool
+		contains  string
+	}{
+		{
+			name:      "verifies the token of an approver",
+			approvers: []string{other, approver},
+			payload:   payload,
+			token:     token,
+			want:      approver,
+		},
+		{
+			name:      "refuses the token of someone else",
+			approvers: []string{other},
+			payload:   payload,
+			token:     token,
+			wantErr:   true,
+			contains:  "not signed by any of the approvers",
+		},
+		{
+			name:      "refuses the token of another payout",
+			approvers: []string{approver},
+			payload:   Payload(301, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", key.PubKey.GetPublicKeyHash(), "fingerprint"),
+			token:     token,
+			wantErr:   true,
+			contains:  "not signed by any of the approvers",
+		},
+		{
+			name:      "handles invalid token",
+			approvers: []string{approver},
+			payload:   payload,
+			token:     "edsig123",
+			wantErr:   true,
+			contains:  "expected an edsig signature",
+		},
+		{
+			name:      "handles invalid approver",
+			approvers: []string{"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"},
+			payload:   payload,
+			token:     token,
+			wantErr:   true,
+			contains:  "expected an edpk public key",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			approver, err := Verify(tt.approvers, tt.payload, tt.token)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			assert.Equal(t, tt.want, approver)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	bucket    = "audit"
+	keyFormat = "2006-01-02T15:04:05.000000000Z"
+)
+
+// Event is an entry in the audit log of operator actions
+type Event struct {
+	Time    time.Time         `json:"time"`
+	Action  string            `json:"action"`
+	Baker   string            `json:"baker"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Record appends an event to the audit log kept in the store
+func Record(s store.IFace, event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	key := fmt.Sprintf("%s/%s", event.Time.UTC().Format(keyFormat), event.Action)
+	if err := s.Put(bucket, key, event); err != nil {
+		return errors.Wrapf(err, "failed to record '%s' in audit log", event.Action)
+	}
+
+	return nil
+}
+
+// List returns the events in the audit log, oldest first
+func List(s store.IFace) ([]Event, error) {
+	keys, err := s.Keys(bucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list audit log")
+	}
+
+	events := []Event{}
+	for _, key := range keys {
+		var event Event
+		if _, err := s.Get(bucket, key, &event); err != nil {
+			return nil, errors.Wrap(err, "failed to list audit log")
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
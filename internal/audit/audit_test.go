@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-audit")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	first := time.Date(2020, 9, 1, 10, 0, 0, 100000000, time.UTC)
+	second := time.Date(2020, 9, 1, 10, 0, 0, 120000000, time.UTC)
+
+	assert.Nil(t, Record(s, Event{Time: second, Action: "wallet_rotate", Baker: "tz1b"}))
+	assert.Nil(t, Record(s, Event{Time: first, Action: "wallet_rotate", Baker: "tz1a", Details: map[string]string{"to": "tz1new"}}))
+
+	events, err := List(s)
+	assert.Nil(t, err)
+	assert.Equal(t, []Event{
+		{Time: first, Action: "wallet_rotate", Baker: "tz1a", Details: map[string]string{"to": "tz1new"}},
+		{Time: second, Action: "wallet_rotate", Baker: "tz1b"},
+	}, events)
+}
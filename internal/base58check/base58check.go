@@ -0,0 +1,48 @@
+/*
+Package base58check encodes and decodes the base58check strings Tezos writes its keys, signatures, addresses and hashes
+in: a prefix telling what the payload is (tz1..., edpk..., edsig...), the payload, and the first four bytes of its
+double sha256 as a checksum.
+*/
+package base58check
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/pkg/errors"
+)
+
+// Encode returns the base58check encoding of payload behind prefix
+func Encode(prefix, payload []byte) string {
+	data := append(append([]byte{}, prefix...), payload...)
+
+	return base58.Encode(append(data, checksum(data)...))
+}
+
+// Decode returns the payload of a base58check encoding behind prefix. A nil prefix decodes any payload, prefix included.
+func Decode(prefix []byte, encoded string) ([]byte, error) {
+	decoded := base58.Decode(encoded)
+	if len(decoded) < len(prefix)+4 {
+		return nil, errors.New("invalid encoding")
+	}
+
+	data, sum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	if !bytes.Equal(checksum(data), sum) {
+		return nil, errors.New("invalid checksum")
+	}
+
+	if !bytes.HasPrefix(data, prefix) {
+		return nil, errors.New("invalid prefix")
+	}
+
+	return data[len(prefix):], nil
+}
+
+// checksum returns the first four bytes of the double sha256 of data
+func checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+
+	return second[:4]
+}
@@ -0,0 +1,51 @@
+package base58check
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Encode(t *testing.T) {
+	hash, _ := hex.DecodeString("0bd0b4bb8bf8c1d9d8b1e1b1b5a0fc3f8d98a19b")
+	tz1 := []byte{6, 161, 159}
+
+	address := Encode(tz1, hash)
+	assert.Equal(t, "tz1", address[:3])
+
+	payload, err := Decode(tz1, address)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, payload)
+
+	payload, err = Decode(nil, address)
+	assert.Nil(t, err)
+	assert.Equal(t, append(append([]byte{}, tz1...), hash...), payload)
+}
+
+func Test_Decode(t *testing.T) {
+	cases := []struct {
+		name     string
+		prefix   []byte
+		encoded  string
+		err      bool
+		contains string
+	}{
+		{"is successful", []byte{6, 161, 159}, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", false, ""},
+		{"handles a short encoding", []byte{6, 161, 159}, "tz1", true, "invalid encoding"},
+		{"handles a wrong checksum", []byte{6, 161, 159}, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yd", true, "invalid checksum"},
+		{"handles a wrong prefix", []byte{13, 15, 37, 217}, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", true, "invalid prefix"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := Decode(tt.prefix, tt.encoded)
+			test.CheckErr(t, tt.err, tt.contains, err)
+			if !tt.err {
+				assert.Len(t, payload, 20)
+				assert.Equal(t, tt.encoded, Encode(tt.prefix, payload))
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package books
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+const stateBucket = "books"
+
+// Accounts of the books of a payout wallet. The wallet is the only asset, every other account records where its
+// tez came from or went to.
+const (
+	AccountWallet      = "wallet"
+	AccountOpening     = "opening"     // balance of the wallet when the books were opened
+	AccountRewards     = "rewards"     // transfers from the baker
+	AccountDeposits    = "deposits"    // transfers from anyone else
+	AccountDelegators  = "delegators"  // transfers to delegators, directly or through the disperse contract
+	AccountSweeps      = "sweeps"      // transfers to the sweep address
+	AccountDonations   = "donations"   // transfers to the donation address
+	AccountFees        = "fees"        // network fees
+	AccountBurns       = "burns"       // storage and allocation burns
+	AccountAdjustments = "adjustments" // differences found reconciling the books with the chain
+)
+
+// Entry moves Amount mutez from the Credit account to the Debit account
+type Entry struct {
+	Level        int       `json:"level"`
+	Time         time.Time `json:"time"`
+	Operation    string    `json:"operation,omitempty"`
+	Debit        string    `json:"debit"`
+	Credit       string    `json:"credit"`
+	Amount       int       `json:"amount"`
+	Counterparty string    `json:"counterparty,omitempty"`
+}
+
+// State is where the books of a baker's payout wallet stand
+type State struct {
+	Wallet  string    `json:"wallet"`
+	Level   int       `json:"level"`   // last level booked
+	Balance int       `json:"balance"` // balance of the wallet account
+	Updated time.Time `json:"updated"`
+}
+
+// Balance sums the entries of an account
+type Balance struct {
+	Account string `json:"account"`
+	Debits  int    `json:"debits"`
+	Credits int    `json:"credits"`
+}
+
+// Net returns the debits of the account less its credits
+func (b Balance) Net() int {
+	return b.Debits - b.Credits
+}
+
+// entriesBucket keeps the books of every payout wallet apart, so that a rotated wallet starts new books
+func entriesBucket(wallet string) string {
+	return "books/" + wallet
+}
+
+// entryKey orders entries by level, then by the tzkt id of their operation
+func entryKey(level, id, index int) string {
+	return fmt.Sprintf("%010d/%012d/%02d", level, id, index)
+}
+
+// Entries returns the entries of the books of a payout wallet, oldest first
+func Entries(s store.IFace, wallet string) ([]Entry, error) {
+	keys, err := s.Keys(entriesBucket(wallet))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list entries")
+	}
+
+	entries := []Entry{}
+	for _, key := range keys {
+		var entry Entry
+		if _, err := s.Get(entriesBucket(wallet), key, &entry); err != nil {
+			return nil, errors.Wrap(err, "failed to list entries")
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// LoadState returns the state of the books of baker's payout wallet, and false if they were never opened
+func LoadState(s store.IFace, baker string) (State, bool, error) {
+	var state State
+	ok, err := s.Get(stateBucket, baker, &state)
+	if err != nil {
+		return state, false, errors.Wrap(err, "failed to load state of books")
+	}
+
+	return state, ok, nil
+}
+
+// TrialBalance returns the balance of every account used by entries, sorted by account. Debits and credits of the
+// accounts always add up to the same total.
+func TrialBalance(entries []Entry) []Balance {
+	accounts := map[string]*Balance{}
+	account := func(name string) *Balance {
+		if _, ok := accounts[name]; !ok {
+			accounts[name] = &Balance{Account: name}
+		}
+		return accounts[name]
+	}
+
+	for _, entry := range entries {
+		account(entry.Debit).Debits += entry.Amount
+		account(entry.Credit).Credits += entry.Amount
+	}
+
+	balances := []Balance{}
+	for _, balance := range accounts {
+		balances = append(balances, *balance)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Account < balances[j].Account })
+
+	return balances
+}
+
+/*
+book turns a transaction to or from the wallet into entries. A transaction sent by the wallet pays its fee whatever
+its status, but only moves its amount and burns storage if it was applied.
+*/
+func book(transaction tzkt.Transaction, wallet string, accounts map[string]string) []Entry {
+	entry := func(debit, credit string, amount int, counterparty string) Entry {
+		return Entry{
+			Level:        transaction.Level,
+			Time:         transaction.Timestamp,
+			Operation:    transaction.Hash,
+			Debit:        debit,
+			Credit:       credit,
+			Amount:       amount,
+			Counterparty: counterparty,
+		}
+	}
+
+	applied := transaction.Status == "applied"
+	var entries []Entry
+	if transaction.Sender.Address == wallet {
+		if transaction.BakerFee > 0 {
+			entries = append(entries, entry(AccountFees, AccountWallet, transaction.BakerFee, ""))
+		}
+		if applied && transaction.Amount > 0 {
+			entries = append(entries, entry(outflowAccount(transaction.Target.Address, accounts), AccountWallet, transaction.Amount, transaction.Target.Address))
+		}
+		if burn := transaction.StorageFee + transaction.AllocationFee; applied && burn > 0 {
+			entries = append(entries, entry(AccountBurns, AccountWallet, burn, ""))
+		}
+	}
+
+	if transaction.Target.Address == wallet && applied && transaction.Amount > 0 {
+		entries = append(entries, entry(AccountWallet, inflowAccount(transaction.Sender.Address, accounts), transaction.Amount, transaction.Sender.Address))
+	}
+
+	return entries
+}
+
+func outflowAccount(target string, accounts map[string]string) string {
+	if account, ok := accounts[target]; ok && account != AccountRewards {
+		return account
+	}
+
+	return AccountDelegators
+}
+
+func inflowAccount(sender string, accounts map[string]string) string {
+	if account, ok := accounts[sender]; ok && account == AccountRewards {
+		return account
+	}
+
+	return AccountDeposits
+}
@@ -0,0 +1,210 @@
+package books
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	baker  = "tz1baker"
+	wallet = "tz1wallet"
+)
+
+type booksRPCMock struct {
+	test.RPCMock
+	level    int
+	balances map[string]int
+}
+
+func (b *booksRPCMock) Head() (*rpc.Block, error) {
+	head, err := b.RPCMock.Head()
+	if err != nil {
+		return head, err
+	}
+	head.Header.Level = b.level
+
+	return head, nil
+}
+
+func (b *booksRPCMock) Balance(input rpc.BalanceInput) (int, error) {
+	return b.balances[input.Blockhash], nil
+}
+
+type booksTzktMock struct {
+	tzkt.IFace
+	level        int
+	transactions []tzkt.Transaction
+}
+
+func (b *booksTzktMock) GetHead() (tzkt.Head, error) {
+	return tzkt.Head{Level: b.level}, nil
+}
+
+func (b *booksTzktMock) GetTransactions(options ...tzkt.URLParameters) ([]tzkt.Transaction, error) {
+	return b.transactions, nil
+}
+
+func transaction(id int, sender, target string, amount, fee, burn int, status string) tzkt.Transaction {
+	var transaction tzkt.Transaction
+	transaction.ID, transaction.Level, transaction.Hash, transaction.Status = id, 110, "ooHash", status
+	transaction.Sender.Address, transaction.Target.Address = sender, target
+	transaction.Amount, transaction.BakerFee, transaction.AllocationFee = amount, fee, burn
+
+	return transaction
+}
+
+func Test_book(t *testing.T) {
+	accounts := map[string]string{baker: AccountRewards, "tz1sweep": AccountSweeps}
+
+	cases := []struct {
+		name        string
+		transaction tzkt.Transaction
+		want        [][3]interface{}
+	}{
+		{
+			"books rewards from baker",
+			transaction(1, baker, wallet, 1000, 0, 0, "applied"),
+			[][3]interface{}{{AccountWallet, AccountRewards, 1000}},
+		},
+		{
+			"books deposit from anyone else",
+			transaction(1, "tz1other", wallet, 1000, 0, 0, "applied"),
+			[][3]interface{}{{AccountWallet, AccountDeposits, 1000}},
+		},
+		{
+			"books payout with fee and burn",
+			transaction(1, wallet, "tz1delegator", 500, 20, 64250, "applied"),
+			[][3]interface{}{{AccountFees, AccountWallet, 20}, {AccountDelegators, AccountWallet, 500}, {AccountBurns, AccountWallet, 64250}},
+		},
+		{
+			"books sweep",
+			transaction(1, wallet, "tz1sweep", 500, 20, 0, "applied"),
+			[][3]interface{}{{AccountFees, AccountWallet, 20}, {AccountSweeps, AccountWallet, 500}},
+		},
+		{
+			"books only fee of failed transfer",
+			transaction(1, wallet, "tz1delegator", 500, 20, 64250, "backtracked"),
+			[][3]interface{}{{AccountFees, AccountWallet, 20}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][3]interface{}
+			for _, entry := range book(tt.transaction, wallet, accounts) {
+				got = append(got, [3]interface{}{entry.Debit, entry.Credit, entry.Amount})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_TrialBalance(t *testing.T) {
+	balances := TrialBalance([]Entry{
+		{Debit: AccountWallet, Credit: AccountOpening, Amount: 1000},
+		{Debit: AccountDelegators, Credit: AccountWallet, Amount: 300},
+		{Debit: AccountFees, Credit: AccountWallet, Amount: 20},
+	})
+
+	assert.Equal(t, []Balance{
+		{Account: AccountDelegators, Debits: 300},
+		{Account: AccountFees, Debits: 20},
+		{Account: AccountOpening, Credits: 1000},
+		{Account: AccountWallet, Debits: 1000, Credits: 320},
+	}, balances)
+	assert.Equal(t, 680, balances[3].Net())
+}
+
+func Test_Reconcile(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC) }
+
+	dir, err := ioutil.TempDir("", "tzpay-books")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	r := &booksRPCMock{level: 100, balances: map[string]int{"100": 1000, "120": 1500, "130": 1400}}
+	z := &booksTzktMock{level: 100}
+	reconciler := NewReconciler(ReconcilerInput{RPC: r, Tzkt: z, Store: s, Config: config.Config{Baker: config.Baker{Address: baker}}, Wallet: wallet})
+
+	// opens the books with the balance of the wallet
+	discrepancy, err := reconciler.Reconcile()
+	assert.Nil(t, err)
+	assert.Nil(t, discrepancy)
+
+	// books transfers that reconcile with the balance
+	r.level, z.level = 125, 120
+	z.transactions = []tzkt.Transaction{
+		transaction(1, baker, wallet, 1000, 0, 0, "applied"),
+		transaction(2, wallet, "tz1delegator", 480, 20, 0, "applied"),
+	}
+	discrepancy, err = reconciler.Reconcile()
+	assert.Nil(t, err)
+	assert.Nil(t, discrepancy)
+
+	state, ok, err := LoadState(s, baker)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, State{Wallet: wallet, Level: 120, Balance: 1500, Updated: now().UTC()}, state)
+
+	// raises and adjusts a discrepancy once
+	r.level, z.level = 130, 130
+	z.transactions = nil
+	discrepancy, err = reconciler.Reconcile()
+	assert.Nil(t, err)
+	assert.Equal(t, &Discrepancy{Wallet: wallet, Level: 130, Books: 1500, Chain: 1400}, discrepancy)
+	assert.Equal(t, -100, discrepancy.Difference())
+
+	entries, err := Entries(s, wallet)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 5)
+	assert.Equal(t, Entry{Level: 130, Time: now().UTC(), Debit: AccountAdjustments, Credit: AccountWallet, Amount: 100}, entries[4])
+
+	for _, balance := range TrialBalance(entries) {
+		if balance.Account == AccountWallet {
+			assert.Equal(t, 1400, balance.Net())
+		}
+	}
+
+	discrepancy, err = reconciler.Reconcile()
+	assert.Nil(t, err)
+	assert.Nil(t, discrepancy)
+}
+
+func Test_Reconciler_check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-books")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, s.Put(stateBucket, baker, State{Wallet: wallet, Level: 100, Balance: 1000}))
+
+	client := &notifier.MockClient{}
+	reconciler := NewReconciler(ReconcilerInput{
+		RPC:       &booksRPCMock{RPCMock: test.RPCMock{HeadCycle: 10}, level: 110, balances: map[string]int{"110": 900}},
+		Tzkt:      &booksTzktMock{level: 110},
+		Store:     s,
+		Config:    config.Config{Baker: config.Baker{Address: baker}},
+		Wallet:    wallet,
+		Notifiers: []notifier.ClientIFace{client},
+	})
+
+	assert.Nil(t, reconciler.check())
+	assert.Nil(t, reconciler.check())
+	assert.Equal(t, []string{"[TZPAY]: books of payout wallet tz1wallet are off by -0.000100 XTZ at level 110: 0.001000 XTZ booked, 0.000900 XTZ on chain"}, client.Messages)
+}
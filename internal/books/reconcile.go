@@ -0,0 +1,279 @@
+package books
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// now is overridden in tests
+var now = time.Now
+
+// Discrepancy is a difference between the books of a payout wallet and its balance on chain
+type Discrepancy struct {
+	Wallet string `json:"wallet"`
+	Level  int    `json:"level"`
+	Books  int    `json:"books"`
+	Chain  int    `json:"chain"`
+}
+
+// Difference returns what the wallet holds on chain beyond what the books say, negative if it holds less
+func (d Discrepancy) Difference() int {
+	return d.Chain - d.Books
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("[TZPAY]: books of payout wallet %s are off by %.6f XTZ at level %d: %.6f XTZ booked, %.6f XTZ on chain",
+		d.Wallet, tez(d.Difference()), d.Level, tez(d.Books), tez(d.Chain))
+}
+
+func tez(mutez int) float64 {
+	return float64(mutez) / float64(gotezos.MUTEZ)
+}
+
+// ReconcilerInput is the input for NewReconciler
+type ReconcilerInput struct {
+	RPC       rpc.IFace
+	Tzkt      tzkt.IFace
+	Store     store.IFace
+	Config    config.Config
+	Wallet    string
+	Notifiers []notifier.ClientIFace
+}
+
+/*
+Reconciler keeps the double-entry books of a baker's payout wallet: every transfer to or from the wallet is booked
+from tzkt, and once per cycle the balance of the wallet account is reconciled against the balance of the wallet on
+chain. A discrepancy, e.g. from an operation tzpay does not know about, is notified and booked as an adjustment so
+that it is only raised once.
+*/
+type Reconciler struct {
+	rpc        rpc.IFace
+	tzkt       tzkt.IFace
+	store      store.IFace
+	config     config.Config
+	wallet     string
+	notifiers  []notifier.ClientIFace
+	reconciled int
+}
+
+// NewReconciler returns a new Reconciler
+func NewReconciler(input ReconcilerInput) *Reconciler {
+	return &Reconciler{
+		rpc:        input.RPC,
+		tzkt:       input.Tzkt,
+		store:      input.Store,
+		config:     input.Config,
+		wallet:     input.Wallet,
+		notifiers:  input.Notifiers,
+		reconciled: -1,
+	}
+}
+
+// Start reconciles the books once every cycle until the process exits
+func (r *Reconciler) Start() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		for {
+			if err := r.check(); err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "baker": r.config.Baker.Address}).Error("Failed to reconcile books of payout wallet.")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+func (r *Reconciler) check() error {
+	head, err := r.rpc.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to get current cycle")
+	}
+
+	if head.Metadata.Level.Cycle == r.reconciled {
+		return nil
+	}
+
+	discrepancy, err := r.Reconcile()
+	if err != nil {
+		return err
+	}
+	r.reconciled = head.Metadata.Level.Cycle
+
+	if discrepancy == nil {
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"baker":      r.config.Baker.Address,
+		"wallet":     discrepancy.Wallet,
+		"level":      discrepancy.Level,
+		"difference": discrepancy.Difference(),
+	}).Warn("Books of payout wallet differ from its balance.")
+	for _, n := range r.notifiers {
+		if err := n.Send(discrepancy.String()); err != nil {
+			return errors.Wrap(err, "failed to notify")
+		}
+	}
+
+	return nil
+}
+
+/*
+Reconcile books the transfers of the wallet up to the last level both the node and tzkt know of, and compares the
+balance of the wallet account with the balance of the wallet at that level. It returns the discrepancy found, if
+any. The books are opened with the balance of the wallet the first time, or when the payout wallet changed.
+*/
+func (r *Reconciler) Reconcile() (*Discrepancy, error) {
+	level, err := r.level()
+	if err != nil {
+		return nil, err
+	}
+
+	baker := r.config.Baker.Address
+	state, ok, err := LoadState(r.store, baker)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || state.Wallet != r.wallet {
+		return nil, r.open(level)
+	}
+
+	if level <= state.Level {
+		return nil, nil
+	}
+
+	transactions, err := r.tzkt.GetTransactions(
+		tzkt.URLParameters{Key: "anyof.sender.target", Value: r.wallet},
+		tzkt.URLParameters{Key: "level.gt", Value: strconv.Itoa(state.Level)},
+		tzkt.URLParameters{Key: "level.le", Value: strconv.Itoa(level)},
+		tzkt.URLParameters{Key: "sort.asc", Value: "id"},
+		tzkt.URLParameters{Key: "limit", Value: "10000"},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get transactions of payout wallet")
+	}
+
+	accounts := r.accounts()
+	for _, transaction := range transactions {
+		for i, entry := range book(transaction, r.wallet, accounts) {
+			if err := r.store.Put(entriesBucket(r.wallet), entryKey(entry.Level, transaction.ID, i), entry); err != nil {
+				return nil, errors.Wrap(err, "failed to book transaction")
+			}
+			state.Balance += walletNet(entry)
+		}
+	}
+
+	chain, err := r.balance(level)
+	if err != nil {
+		return nil, err
+	}
+
+	var discrepancy *Discrepancy
+	if chain != state.Balance {
+		discrepancy = &Discrepancy{Wallet: r.wallet, Level: level, Books: state.Balance, Chain: chain}
+
+		adjustment := Entry{Level: level, Time: now().UTC(), Debit: AccountWallet, Credit: AccountAdjustments, Amount: discrepancy.Difference()}
+		if adjustment.Amount < 0 {
+			adjustment.Debit, adjustment.Credit, adjustment.Amount = AccountAdjustments, AccountWallet, -adjustment.Amount
+		}
+		if err := r.store.Put(entriesBucket(r.wallet), entryKey(level, 0, 0), adjustment); err != nil {
+			return nil, errors.Wrap(err, "failed to book adjustment")
+		}
+		state.Balance = chain
+	}
+
+	state.Level, state.Updated = level, now().UTC()
+	if err := r.store.Put(stateBucket, baker, state); err != nil {
+		return nil, errors.Wrap(err, "failed to save state of books")
+	}
+
+	return discrepancy, nil
+}
+
+func (r *Reconciler) open(level int) error {
+	balance, err := r.balance(level)
+	if err != nil {
+		return err
+	}
+
+	baker := r.config.Baker.Address
+	if balance > 0 {
+		opening := Entry{Level: level, Time: now().UTC(), Debit: AccountWallet, Credit: AccountOpening, Amount: balance, Counterparty: r.wallet}
+		if err := r.store.Put(entriesBucket(r.wallet), entryKey(level, 0, 0), opening); err != nil {
+			return errors.Wrap(err, "failed to open books")
+		}
+	}
+
+	state := State{Wallet: r.wallet, Level: level, Balance: balance, Updated: now().UTC()}
+	if err := r.store.Put(stateBucket, baker, state); err != nil {
+		return errors.Wrap(err, "failed to open books")
+	}
+
+	log.WithFields(log.Fields{"baker": baker, "wallet": r.wallet, "level": level, "balance": balance}).Info("Opened books of payout wallet.")
+	return nil
+}
+
+// level returns the last level known to both the node and tzkt, so that the books and the balance agree
+func (r *Reconciler) level() (int, error) {
+	head, err := r.rpc.Head()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get current level")
+	}
+
+	tzktHead, err := r.tzkt.GetHead()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get level indexed by tzkt")
+	}
+
+	if tzktHead.Level < head.Header.Level {
+		return tzktHead.Level, nil
+	}
+
+	return head.Header.Level, nil
+}
+
+func (r *Reconciler) balance(level int) (int, error) {
+	balance, err := r.rpc.Balance(rpc.BalanceInput{
+		Blockhash: strconv.Itoa(level),
+		Address:   r.wallet,
+	})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get balance of payout wallet at level %d", level)
+	}
+
+	return balance, nil
+}
+
+// accounts maps the addresses the wallet deals with to the account their transfers are booked to
+func (r *Reconciler) accounts() map[string]string {
+	accounts := map[string]string{r.config.Baker.Address: AccountRewards}
+	if r.config.Baker.SweepAddress != "" {
+		accounts[r.config.Baker.SweepAddress] = AccountSweeps
+	}
+	if r.config.Baker.DonationAddress != "" {
+		accounts[r.config.Baker.DonationAddress] = AccountDonations
+	}
+
+	return accounts
+}
+
+// walletNet returns what an entry adds to the wallet account
+func walletNet(entry Entry) int {
+	if entry.Debit == AccountWallet {
+		return entry.Amount
+	} else if entry.Credit == AccountWallet {
+		return -entry.Amount
+	}
+
+	return 0
+}
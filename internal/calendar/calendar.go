@@ -0,0 +1,127 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+)
+
+// Kinds of rights
+const (
+	Baking    = "baking"
+	Endorsing = "endorsing"
+)
+
+const icsTimeFormat = "20060102T150405Z"
+
+// Right is an upcoming baking or endorsing right of a baker
+type Right struct {
+	Kind          string    `json:"kind"`
+	Cycle         int       `json:"cycle"`
+	Level         int       `json:"level"`
+	Priority      int       `json:"priority,omitempty"`
+	Slots         int       `json:"slots,omitempty"`
+	EstimatedTime time.Time `json:"estimated_time"`
+}
+
+// RightsInput is the input for Rights
+type RightsInput struct {
+	RPC         rpc.IFace
+	Baker       string
+	Cycles      []int
+	MaxPriority int
+}
+
+// Rights returns the baking and endorsing rights of a baker for the cycles passed that are not yet past, ordered by level
+func Rights(input RightsInput) ([]Right, error) {
+	head, err := input.RPC.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get rights")
+	}
+
+	var rights []Right
+	for _, cycle := range input.Cycles {
+		baking, err := input.RPC.BakingRights(rpc.BakingRightsInput{
+			BlockHash:   head.Hash,
+			Cycle:       cycle,
+			Delegate:    input.Baker,
+			MaxPriority: input.MaxPriority,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get baking rights for cycle %d", cycle)
+		}
+
+		for _, right := range *baking {
+			if right.Level > head.Header.Level {
+				rights = append(rights, Right{
+					Kind:          Baking,
+					Cycle:         cycle,
+					Level:         right.Level,
+					Priority:      right.Priority,
+					EstimatedTime: right.EstimatedTime,
+				})
+			}
+		}
+
+		endorsing, err := input.RPC.EndorsingRights(rpc.EndorsingRightsInput{
+			BlockHash: head.Hash,
+			Cycle:     cycle,
+			Delegate:  input.Baker,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get endorsing rights for cycle %d", cycle)
+		}
+
+		for _, right := range *endorsing {
+			if right.Level > head.Header.Level {
+				rights = append(rights, Right{
+					Kind:          Endorsing,
+					Cycle:         cycle,
+					Level:         right.Level,
+					Slots:         len(right.Slots),
+					EstimatedTime: right.EstimatedTime,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(rights, func(i, j int) bool {
+		return rights[i].Level < rights[j].Level
+	})
+
+	return rights, nil
+}
+
+// Summary returns a short human readable description of a right
+func (r Right) Summary() string {
+	if r.Kind == Baking {
+		return fmt.Sprintf("Baking right at level %d (priority %d)", r.Level, r.Priority)
+	}
+
+	return fmt.Sprintf("Endorsing right at level %d (%d slots)", r.Level, r.Slots)
+}
+
+// ICS returns the rights of a baker as an iCalendar, with an event of a minute for every right
+func ICS(baker string, rights []Right, stamp time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("BEGIN:VCALENDAR\r\n")
+	sb.WriteString("VERSION:2.0\r\n")
+	sb.WriteString("PRODID:-//tzpay//rights calendar//EN\r\n")
+	for _, right := range rights {
+		sb.WriteString("BEGIN:VEVENT\r\n")
+		sb.WriteString(fmt.Sprintf("UID:%s-%d-%s@tzpay\r\n", right.Kind, right.Level, baker))
+		sb.WriteString(fmt.Sprintf("DTSTAMP:%s\r\n", stamp.UTC().Format(icsTimeFormat)))
+		sb.WriteString(fmt.Sprintf("DTSTART:%s\r\n", right.EstimatedTime.UTC().Format(icsTimeFormat)))
+		sb.WriteString("DURATION:PT1M\r\n")
+		sb.WriteString(fmt.Sprintf("SUMMARY:%s\r\n", right.Summary()))
+		sb.WriteString(fmt.Sprintf("DESCRIPTION:%s for %s in cycle %d\r\n", right.Summary(), baker, right.Cycle))
+		sb.WriteString("END:VEVENT\r\n")
+	}
+	sb.WriteString("END:VCALENDAR\r\n")
+
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Rights(t *testing.T) {
+	estimated := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	type want struct {
+		err         bool
+		errContains string
+		rights      []Right
+	}
+
+	cases := []struct {
+		name  string
+		input test.RPCMock
+		want  want
+	}{
+		{
+			"is successful",
+			test.RPCMock{RightsEstimatedTime: estimated},
+			want{
+				rights: []Right{
+					{Kind: Baking, Cycle: 10, Level: 100, EstimatedTime: estimated},
+					{Kind: Endorsing, Cycle: 10, Level: 100, EstimatedTime: estimated},
+				},
+			},
+		},
+		{
+			"handles failure to get head",
+			test.RPCMock{HeadErr: true},
+			want{
+				err:         true,
+				errContains: "failed to get rights",
+			},
+		},
+		{
+			"handles failure to get baking rights",
+			test.RPCMock{BakingRightsErr: true},
+			want{
+				err:         true,
+				errContains: "failed to get baking rights for cycle 10",
+			},
+		},
+		{
+			"handles failure to get endorsing rights",
+			test.RPCMock{EndorsingRightsErr: true},
+			want{
+				err:         true,
+				errContains: "failed to get endorsing rights for cycle 10",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rights, err := Rights(RightsInput{
+				RPC:    &tt.input,
+				Baker:  "some_delegate",
+				Cycles: []int{10},
+			})
+			test.CheckErr(t, tt.want.err, tt.want.errContains, err)
+			assert.Equal(t, tt.want.rights, rights)
+		})
+	}
+}
+
+func Test_ICS(t *testing.T) {
+	stamp := time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC)
+	ics := ICS("tz1baker", []Right{
+		{Kind: Baking, Cycle: 10, Level: 100, Priority: 0, EstimatedTime: time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)},
+	}, stamp)
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//tzpay//rights calendar//EN\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:baking-100-tz1baker@tzpay\r\n"+
+		"DTSTAMP:20200314T000000Z\r\n"+
+		"DTSTART:20200315T120000Z\r\n"+
+		"DURATION:PT1M\r\n"+
+		"SUMMARY:Baking right at level 100 (priority 0)\r\n"+
+		"DESCRIPTION:Baking right at level 100 (priority 0) for tz1baker in cycle 10\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n", ics)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/approval"
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ApproveCommand returns the cobra command for approve
+func ApproveCommand() *cobra.Command {
+	var baker string
+	var token string
+	var esk string
+	var cycle int
+	var url string
+
+	var approve = &cobra.Command{
+		Use:   "approve",
+		Short: "approve approves a payout held until a second person, or the operator, approves it",
+		Long: `approve prints the payout held for a cycle when TZPAY_APPROVAL_KEYS is set, with the payload an approver signs
+to approve it. The approval token is the signature of the payload by one of the approvers, passed with --token, e.g.
+as printed by 'octez-client sign bytes 0x<payload> for <approver>', or made from the approver's encrypted key passed
+with --esk. The payout is injected by the next run for the cycle, as long as its transfers are unchanged.
+
+With TZPAY_APPROVAL_MANUAL set instead, approve approves the payout held by a running tzpay serv for the cycle through
+its control API, which is served with TZPAY_HTTP_LISTEN, TZPAY_HTTP_API_KEY and TZPAY_HTTP_CONTROL set. The payout is
+injected when the queue retries it.`,
+		Example: `tzpay approve 300
+tzpay approve 300 --token edsig...
+tzpay approve 300 --esk edesk...
+tzpay approve --cycle 300`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) > 0 {
+				var err error
+				if cycle, err = strconv.Atoi(args[0]); err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to parse cycle argument into integer.")
+				}
+			} else if cycle == 0 {
+				log.Fatal("Missing cycle as argument or --cycle.")
+			}
+
+			config := newBakerConfig(baker)
+			if approvedByOperator(config) {
+				approved, err := newQueueClient(url).Approve(config.Baker.Address, cycle, "tzpay approve")
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to approve payout.")
+				}
+
+				log.WithFields(log.Fields{
+					"cycle":     cycle,
+					"baker":     approved.Baker,
+					"transfers": approved.Transfers,
+					"amount":    approved.Amount,
+				}).Info("Payout approved, it is injected when tzpay serv retries it.")
+				return
+			} else if len(config.Approval.Keys) == 0 {
+				log.Fatal("Payouts are not held for approval, set TZPAY_APPROVAL_KEYS or TZPAY_APPROVAL_MANUAL to require one.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			held, found, err := payout.HeldApproval(s, config.Baker.Address, cycle)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to get payout held.")
+			} else if !found {
+				log.WithField("cycle", cycle).Fatal("No payout is held for the cycle.")
+			}
+
+			if token == "" && esk == "" {
+				data, err := json.MarshalIndent(held, "", "    ")
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to print payout held.")
+				}
+				fmt.Println(string(data))
+				return
+			}
+
+			if esk != "" {
+				password, err := readPassword("Password of the approver's key: ")
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to read password.")
+				}
+
+				if token, err = approval.Sign(esk, password, held.Payload); err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to sign approval.")
+				}
+			}
+
+			approved, err := payout.Approve(s, config, cycle, token)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to approve payout.")
+			}
+
+			err = audit.Record(s, audit.Event{
+				Action: "payout_approve",
+				Baker:  config.Baker.Address,
+				Details: map[string]string{
+					"cycle":    fmt.Sprintf("%d", cycle),
+					"approver": approved.Approver,
+					"payload":  approved.Payload,
+				},
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Error("Failed to record approval in audit log.")
+			}
+
+			log.WithFields(log.Fields{
+				"cycle":     cycle,
+				"approver":  approved.Approver,
+				"transfers": approved.Transfers,
+				"amount":    approved.Amount,
+			}).Info("Payout approved, it is injected by the next run for the cycle.")
+		},
+	}
+
+	approve.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to approve the payout of when multiple bakers are configured (Default: primary baker)")
+	approve.PersistentFlags().StringVar(&token, "token", "", "the edsig signature of the payload by one of the approvers")
+	approve.PersistentFlags().StringVar(&esk, "esk", "", "the encrypted ed25519 key of the approver to sign the payload with, its password is prompted for")
+	approve.PersistentFlags().IntVarP(&cycle, "cycle", "c", 0, "the cycle to approve the payout of, instead of as argument")
+	approve.PersistentFlags().StringVarP(&url, "url", "u", "", "the URL of the running tzpay serv with TZPAY_APPROVAL_MANUAL (Default: http://localhost with the port of TZPAY_HTTP_LISTEN)")
+
+	return approve
+}
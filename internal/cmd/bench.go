@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// BenchCommand returns the cobra command for bench
+func BenchCommand() *cobra.Command {
+	var delegators []int
+
+	var bench = &cobra.Command{
+		Use:   "bench",
+		Short: "bench measures payout computation and forging throughput",
+		Long: "bench computes and forges payouts for synthetic delegator sets without contacting a node or tzkt, " +
+			"and fails if either step exceeds its performance budget",
+		Example: `tzpay bench --delegators 1000,10000`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var results []payout.BenchmarkResult
+			for _, size := range delegators {
+				result, err := payout.Benchmark(size)
+				if err != nil {
+					log.WithFields(log.Fields{"error": err.Error(), "delegators": size}).Fatal("Failed to run benchmark.")
+				}
+				results = append(results, result)
+			}
+
+			log.WithFields(log.Fields{"compute": payout.ComputeBudget, "forge": payout.ForgeBudget}).Info("Performance budgets per delegator.")
+			printBenchTable(results)
+
+			for _, result := range results {
+				if !result.WithinBudget() {
+					log.WithField("delegators", result.Delegators).Fatal("Benchmark exceeded its performance budget.")
+				}
+			}
+		},
+	}
+
+	bench.PersistentFlags().IntSliceVarP(&delegators, "delegators", "d", payout.BenchmarkSizes, "the sizes of the synthetic delegator sets to benchmark")
+
+	return bench
+}
+
+func printBenchTable(results []payout.BenchmarkResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Delegators", "Operations", "Compute", "Per Delegator", "Forge", "Per Delegator", "Within Budget"})
+	for _, result := range results {
+		table.Append([]string{
+			strconv.Itoa(result.Delegators),
+			strconv.Itoa(result.Operations),
+			result.Compute.Round(time.Microsecond).String(),
+			(result.Compute / time.Duration(result.Delegators)).String(),
+			result.Forge.Round(time.Microsecond).String(),
+			(result.Forge / time.Duration(result.Delegators)).String(),
+			strconv.FormatBool(result.WithinBudget()),
+		})
+	}
+	table.Render()
+}
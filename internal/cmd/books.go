@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/books"
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// BooksCommand returns the cobra command for books
+func BooksCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var entries bool
+	var reconcile bool
+
+	var booksCommand = &cobra.Command{
+		Use:   "books",
+		Short: "books prints the double-entry books of a payout wallet",
+		Long: `books prints the balance of every account of the books kept for a payout wallet by tzpay serv with TZPAY_BOOKS_ENABLED,
+or every entry with --entries. With --reconcile, the transfers of the wallet are booked and the books reconciled with
+its balance on chain first.`,
+		Example: `tzpay books --table
+tzpay books --reconcile --entries`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			if reconcile {
+				config, err = unlockWallets(config)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to unlock payout wallet.")
+				}
+
+				wallet, err := payout.CheckWallet(config)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to check payout wallet.")
+				}
+
+				r, err := node.New(interruptContext(), config.API)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to connect to tezos RPC.")
+				}
+
+				discrepancy, err := books.NewReconciler(books.ReconcilerInput{
+					RPC:    r,
+					Tzkt:   indexer.NewWithContext(interruptContext(), config.API),
+					Store:  s,
+					Config: config,
+					Wallet: wallet,
+				}).Reconcile()
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to reconcile books.")
+				}
+				if discrepancy != nil {
+					log.WithFields(log.Fields{
+						"wallet":     discrepancy.Wallet,
+						"level":      discrepancy.Level,
+						"difference": discrepancy.Difference(),
+					}).Warn("Books of payout wallet differ from its balance, booked the difference as an adjustment.")
+				}
+			}
+
+			state, ok, err := books.LoadState(s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load books.")
+			}
+			if !ok {
+				log.WithField("baker", config.Baker.Address).Fatal("No books kept for the payout wallet yet.")
+			}
+
+			list, err := books.Entries(s, state.Wallet)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load books.")
+			}
+
+			if table && entries {
+				printEntriesTable(list)
+				return
+			} else if table {
+				printTrialBalanceTable(state, books.TrialBalance(list))
+				return
+			}
+
+			var report interface{} = books.TrialBalance(list)
+			if entries {
+				report = list
+			}
+
+			prettyJSON, err := json.Marshal(report)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			log.WithFields(log.Fields{"wallet": state.Wallet, "level": state.Level, "books": string(prettyJSON)}).Info("Books of payout wallet.")
+		},
+	}
+
+	booksCommand.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	booksCommand.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker whose payout wallet to print the books of when multiple bakers are configured (Default: primary baker)")
+	booksCommand.PersistentFlags().BoolVarP(&entries, "entries", "e", false, "prints every entry instead of the balance of every account")
+	booksCommand.PersistentFlags().BoolVarP(&reconcile, "reconcile", "r", false, "books the latest transfers and reconciles the books first")
+
+	return booksCommand
+}
+
+func printTrialBalanceTable(state books.State, balances []books.Balance) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Account", "Debits", "Credits", "Balance"})
+	var debits, credits int
+	for _, balance := range balances {
+		debits, credits = debits+balance.Debits, credits+balance.Credits
+		table.Append([]string{
+			balance.Account,
+			tez(balance.Debits),
+			tez(balance.Credits),
+			tez(balance.Net()),
+		})
+	}
+	table.SetFooter([]string{fmt.Sprintf("%s (level %d)", state.Wallet, state.Level), tez(debits), tez(credits), ""})
+
+	table.Render()
+}
+
+func printEntriesTable(entries []books.Entry) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Level", "Operation", "Debit", "Credit", "Amount", "Counterparty"})
+	for _, entry := range entries {
+		table.Append([]string{
+			strconv.Itoa(entry.Level),
+			entry.Operation,
+			entry.Debit,
+			entry.Credit,
+			tez(entry.Amount),
+			entry.Counterparty,
+		})
+	}
+
+	table.Render()
+}
+
+func tez(mutez int) string {
+	return fmt.Sprintf("%.6f", float64(mutez)/float64(gotezos.MUTEZ))
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/calendar"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// CalendarCommand returns the cobra command for calendar
+func CalendarCommand() *cobra.Command {
+	var cycles int
+	var format string
+	var output string
+	var maxPriority int
+	var baker string
+
+	var cal = &cobra.Command{
+		Use:     "calendar",
+		Short:   "calendar exports upcoming baking and endorsing rights",
+		Long:    "calendar exports the upcoming baking and endorsing rights of a baker as an iCalendar or JSON, to plan maintenance around them",
+		Example: `tzpay calendar --format ics --output rights.ics`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "ics" && format != "json" {
+				log.WithField("format", format).Fatal("Unsupported calendar format, expected ics or json.")
+			}
+
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			client, err := node.New(interruptContext(), config.API)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to connect to tezos rpc.")
+			}
+
+			head, err := client.Head()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to get current cycle.")
+			}
+
+			var upcoming []int
+			for cycle := head.Metadata.Level.Cycle; cycle < head.Metadata.Level.Cycle+cycles; cycle++ {
+				upcoming = append(upcoming, cycle)
+			}
+
+			rights, err := calendar.Rights(calendar.RightsInput{
+				RPC:         client,
+				Baker:       config.Baker.Address,
+				Cycles:      upcoming,
+				MaxPriority: maxPriority,
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to get rights.")
+			}
+
+			var export []byte
+			if format == "ics" {
+				export = []byte(calendar.ICS(config.Baker.Address, rights, time.Now()))
+			} else if export, err = json.MarshalIndent(rights, "", "  "); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to export rights.")
+			}
+
+			if output == "" {
+				fmt.Println(string(export))
+				return
+			}
+
+			if err := ioutil.WriteFile(output, export, 0644); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to write calendar.")
+			}
+			log.WithFields(log.Fields{"rights": len(rights), "output": output}).Info("Exported rights calendar.")
+		},
+	}
+
+	cal.PersistentFlags().IntVarP(&cycles, "cycles", "c", 2, "the number of cycles to export, starting with the current cycle")
+	cal.PersistentFlags().StringVarP(&format, "format", "f", "ics", "the format of the export (ics or json)")
+	cal.PersistentFlags().StringVarP(&output, "output", "o", "", "the file to write the export to (Default: stdout)")
+	cal.PersistentFlags().IntVarP(&maxPriority, "max-priority", "p", 0, "the lowest baking priority to export")
+	cal.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to export rights for when multiple bakers are configured (Default: primary baker)")
+
+	return cal
+}
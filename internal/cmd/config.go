@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/overrides"
+	"github.com/goat-systems/tzpay/v3/internal/redact"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// passwordAttempts is how many times the password of an encrypted key is prompted for before giving up
+const passwordAttempts = 3
+
+var (
+	redactOnce sync.Once
+
+	// passwords holds the passwords prompted for by encrypted key, so that a key is only unlocked once per process
+	passwords   = map[string]string{}
+	passwordsMu sync.Mutex
+)
+
+// newConfig loads the configuration with the overrides found at TZPAY_OVERRIDES_URL, fetched once
+func newConfig() (config.Config, error) {
+	cfg, err := loadConfig()
+	if err != nil || cfg.Overrides.URL == "" {
+		return cfg, err
+	}
+
+	provider := overrides.NewProvider(overrides.ProviderInput{URL: cfg.Overrides.URL})
+	if err := provider.Refresh(); err != nil {
+		return cfg, err
+	}
+
+	return provider.Apply(cfg), nil
+}
+
+// loadConfig loads the configuration and applies the settings that affect the whole process, like log redaction
+func loadConfig() (config.Config, error) {
+	cfg, err := config.New()
+	if err != nil {
+		return cfg, err
+	}
+
+	if redactor := newRedactor(cfg); redactor.Enabled() {
+		redactOnce.Do(func() {
+			log.AddHook(redactor)
+		})
+	}
+
+	return cfg, nil
+}
+
+// newRedactor returns the Redactor configured, keeping the addresses of the bakers tzpay pays out for
+func newRedactor(cfg config.Config) *redact.Redactor {
+	var keep []string
+	for _, bakerConfig := range cfg.Bakers() {
+		keep = append(keep, bakerConfig.Baker.Address)
+	}
+
+	return redact.New(cfg.Redaction.Fields, cfg.Redaction.Addresses, keep...)
+}
+
+/*
+unlockWallets prompts on the terminal for the password of every payout wallet whose encrypted key has none set in
+TZPAY_WALLET_PASSWORD or TZPAY_WALLET_PASSWORD_FILE, until it decrypts the key. Passwords are only kept in memory.
+*/
+func unlockWallets(cfg config.Config) (config.Config, error) {
+	var err error
+	if cfg.Key, err = unlockWallet(cfg.Baker.Address, cfg.Key); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Delegates != nil {
+		delegates := make([]config.Delegate, len(cfg.Delegates))
+		for i, delegate := range cfg.Delegates {
+			if delegate.Key, err = unlockWallet(delegate.Baker.Address, delegate.Key); err != nil {
+				return cfg, err
+			}
+			delegates[i] = delegate
+		}
+		cfg.Delegates = delegates
+	}
+
+	return cfg, nil
+}
+
+func unlockWallet(baker string, key config.Key) (config.Key, error) {
+	if key.Esk == "" || key.Password != "" || key.Signer != "" || key.KMSKeyID != "" || key.PKCS11Module != "" {
+		return key, nil
+	}
+
+	passwordsMu.Lock()
+	defer passwordsMu.Unlock()
+
+	if password, ok := passwords[key.Esk]; ok {
+		key.Password = password
+		return key, nil
+	}
+
+	for attempt := 1; attempt <= passwordAttempts; attempt++ {
+		password, err := readPassword(fmt.Sprintf("Password of the payout wallet of %s: ", baker))
+		if err != nil {
+			return key, errors.Wrapf(err, "failed to read password of the payout wallet of '%s', set TZPAY_WALLET_PASSWORD or TZPAY_WALLET_PASSWORD_FILE", baker)
+		}
+
+		// The curve of the key is given by its prefix, edesk, spesk or p2esk
+		if _, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Esk: key.Esk, Password: password}); err != nil {
+			log.WithField("baker", baker).Warn("Failed to decrypt payout wallet key, wrong password.")
+			continue
+		}
+
+		passwords[key.Esk] = password
+		key.Password = password
+		return key, nil
+	}
+
+	return key, errors.Errorf("failed to decrypt the payout wallet key of '%s' after %d attempts", baker, passwordAttempts)
+}
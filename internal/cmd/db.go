@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// DBCommand returns the cobra command for db
+func DBCommand() *cobra.Command {
+	var dbCommand = &cobra.Command{
+		Use:   "db",
+		Short: "db manages the store tzpay persists the history of its payouts to",
+	}
+	dbCommand.AddCommand(dbMigrateCommand(), dbCompactCommand(), dbBackupCommand(), dbRestoreCommand())
+
+	return dbCommand
+}
+
+func dbMigrateCommand() *cobra.Command {
+	var from string
+	var fromKey string
+
+	var migrate = &cobra.Command{
+		Use:   "migrate",
+		Short: "migrate copies every value of another store into the store configured",
+		Long: `migrate copies every value of the store at --from into the store at TZPAY_STORE_PATH, e.g. to move the history of
+the payouts from a JSON file to SQLite or bbolt by pointing TZPAY_STORE_PATH at a path ending in .db or .bolt. Values
+already in the store configured are overwritten by the values of --from. Stop 'tzpay serv' while migrating.`,
+		Example: `TZPAY_STORE_PATH=/var/lib/tzpay/tzpay.db tzpay db migrate --from /var/lib/tzpay/tzpay.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			storeConfig, err := config.NewStore()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			src, err := store.New(from, fromKey)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store to migrate from.")
+			}
+
+			dst, err := store.New(storeConfig.Path, storeConfig.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			copied, err := store.Copy(dst, src)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "copied": copied}).Fatal("Failed to migrate store.")
+			}
+
+			log.WithFields(log.Fields{"from": from, "to": storeConfig.Path, "copied": copied}).Info("Migrated store.")
+		},
+	}
+
+	migrate.PersistentFlags().StringVar(&from, "from", "", "the path of the store to migrate from")
+	migrate.PersistentFlags().StringVar(&fromKey, "from-key", "", "the passphrase of the store to migrate from (Default: not encrypted)")
+	migrate.MarkPersistentFlagRequired("from")
+
+	return migrate
+}
+
+func dbCompactCommand() *cobra.Command {
+	var compact = &cobra.Command{
+		Use:   "compact",
+		Short: "compact rewrites the store to reclaim the space of the values deleted",
+		Long: `compact rewrites the SQLite or bbolt store at TZPAY_STORE_PATH to reclaim the space of the values deleted, e.g. the
+rewards carried forward once paid. A JSON store is rewritten on every write and never needs compacting. Stop
+'tzpay serv' while compacting.`,
+		Example: `TZPAY_STORE_PATH=/var/lib/tzpay/tzpay.bolt tzpay db compact`,
+		Run: func(cmd *cobra.Command, args []string) {
+			storeConfig, err := config.NewStore()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(storeConfig.Path, storeConfig.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			compacter, ok := s.(store.Compacter)
+			if !ok {
+				log.WithField("store", storeConfig.Path).Info("Store is a JSON file, nothing to compact.")
+				return
+			}
+
+			before, after, err := compacter.Compact()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to compact store.")
+			}
+
+			log.WithFields(log.Fields{"store": storeConfig.Path, "before": before, "after": after}).Info("Compacted store.")
+		},
+	}
+
+	return compact
+}
+
+func dbBackupCommand() *cobra.Command {
+	var backup = &cobra.Command{
+		Use:   "backup <path>",
+		Short: "backup snapshots the store to a file",
+		Long: `backup writes every record of the store at TZPAY_STORE_PATH to the file at path, with the version of the backup format
+and the checksum of the records, encrypted with TZPAY_STORE_KEY if set. A backup can be restored to a store of any
+backend with 'tzpay db restore', e.g. on another host. A bbolt store is locked by 'tzpay serv', which must be stopped
+to back it up.`,
+		Example: `tzpay db backup /var/backups/tzpay-$(date +%F).backup`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			storeConfig, err := config.NewStore()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(storeConfig.Path, storeConfig.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			info, err := store.Backup(s, args[0], storeConfig.Key, time.Now())
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to back up store.")
+			}
+
+			log.WithFields(log.Fields{"backup": args[0], "records": info.Count, "checksum": info.Checksum}).Info("Backed up store.")
+		},
+	}
+
+	return backup
+}
+
+func dbRestoreCommand() *cobra.Command {
+	var verify bool
+	var keep string
+
+	var restore = &cobra.Command{
+		Use:   "restore <path>",
+		Short: "restore replaces the store with a backup",
+		Long: `restore verifies the version and checksum of the backup at path, then replaces the store at TZPAY_STORE_PATH with it
+at once, so that the store is either left as it was or fully restored. The store replaced is first backed up to
+--keep. An encrypted backup is read with TZPAY_STORE_KEY. Stop 'tzpay serv' while restoring.`,
+		Example: `tzpay db restore /var/backups/tzpay-2020-09-01.backup`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			storeConfig, err := config.NewStore()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if verify {
+				info, _, err := store.VerifyBackup(args[0], storeConfig.Key)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to verify backup.")
+				}
+
+				log.WithFields(log.Fields{"backup": args[0], "version": info.Version, "created": info.Created, "records": info.Count}).Info("Backup is valid.")
+				return
+			}
+
+			if keep == "" {
+				keep = storeConfig.Path + ".before-restore"
+			}
+			if _, err := os.Stat(storeConfig.Path); os.IsNotExist(err) {
+				keep = ""
+			}
+
+			info, err := store.Restore(storeConfig.Path, storeConfig.Key, args[0], keep, time.Now())
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to restore store.")
+			}
+
+			log.WithFields(log.Fields{"backup": args[0], "store": storeConfig.Path, "records": info.Count, "kept": keep}).Info("Restored store.")
+		},
+	}
+
+	restore.PersistentFlags().BoolVar(&verify, "verify", false, "only verifies the backup, without restoring it")
+	restore.PersistentFlags().StringVar(&keep, "keep", "", "the path to back up the store replaced to (Default: TZPAY_STORE_PATH.before-restore)")
+
+	return restore
+}
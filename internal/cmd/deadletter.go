@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// DeadLetterCommand returns the cobra command for deadletter
+func DeadLetterCommand() *cobra.Command {
+	var deadLetter = &cobra.Command{
+		Use:   "deadletter",
+		Short: "deadletter manages the payouts tzpay serv gave up on after failing too many times",
+	}
+	deadLetter.AddCommand(deadLetterListCommand(), deadLetterRetryCommand(), deadLetterDiscardCommand())
+
+	return deadLetter
+}
+
+func deadLetterListCommand() *cobra.Command {
+	var table bool
+
+	var list = &cobra.Command{
+		Use:     "list",
+		Short:   "list prints the payouts moved to the dead letters with the error of their last attempt",
+		Example: `tzpay deadletter list --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			s := openDeadLetterStore()
+
+			letters, err := payout.DeadLetters(s)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to list dead letters.")
+			}
+
+			if table {
+				printDeadLetterTable(letters)
+				return
+			}
+
+			prettyJSON, err := json.Marshal(letters)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	list.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+
+	return list
+}
+
+func deadLetterRetryCommand() *cobra.Command {
+	var table bool
+	var verbose bool
+
+	var retry = &cobra.Command{
+		Use:   "retry <id>",
+		Short: "retry executes a payout of the dead letters again",
+		Long: `retry executes the payout of the dead letter id, as listed by 'tzpay deadletter list', like 'tzpay run' would, and
+removes it from the dead letters once executed. A payout that fails again stays in the dead letters.`,
+		Example: `tzpay deadletter retry 01598954400000000000`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s := openDeadLetterStore()
+
+			letter, err := payout.GetDeadLetter(s, args[0])
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to get dead letter.")
+			}
+
+			log.WithFields(log.Fields{"id": letter.ID, "payout-cycle": letter.Cycle, "baker": letter.Baker}).Info("Retrying payout of dead letter.")
+			run := NewRun(table, verbose, letter.Baker)
+			run.partial = letter.Partial
+			run.execute(letter.Cycle)
+
+			if err := payout.DiscardDeadLetter(s, letter.ID); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to remove dead letter.")
+			}
+		},
+	}
+
+	retry.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	retry.PersistentFlags().BoolVarP(&verbose, "verbose", "v", true, "will print confirmations in between injections.")
+
+	return retry
+}
+
+func deadLetterDiscardCommand() *cobra.Command {
+	var discard = &cobra.Command{
+		Use:     "discard <id>",
+		Short:   "discard removes a payout from the dead letters without executing it",
+		Example: `tzpay deadletter discard 01598954400000000000`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s := openDeadLetterStore()
+
+			if err := payout.DiscardDeadLetter(s, args[0]); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to discard dead letter.")
+			}
+
+			log.WithField("id", args[0]).Info("Discarded dead letter.")
+		},
+	}
+
+	return discard
+}
+
+// openDeadLetterStore opens the store the dead letters of every baker are kept in
+func openDeadLetterStore() store.IFace {
+	storeConfig, err := config.NewStore()
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	s, err := store.New(storeConfig.Path, storeConfig.Key)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to open store.")
+	}
+
+	return s
+}
+
+func printDeadLetterTable(letters []payout.DeadLetter) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Baker", "Cycle", "Partial", "Attempts", "Failed", "Error"})
+	for _, letter := range letters {
+		table.Append([]string{
+			letter.ID,
+			letter.Baker,
+			strconv.Itoa(letter.Cycle),
+			strconv.FormatBool(letter.Partial),
+			strconv.Itoa(letter.Attempts),
+			letter.Failed.Format("2006-01-02 15:04"),
+			letter.Error,
+		})
+	}
+
+	table.Render()
+}
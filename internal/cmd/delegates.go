@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// DelegatesCommand returns the cobra command for delegates
+func DelegatesCommand() *cobra.Command {
+	var table bool
+	var cycles int
+	var network bool
+	var active bool
+	var inactive bool
+	var page int
+	var limit int
+
+	var delegates = &cobra.Command{
+		Use:   "delegates",
+		Short: "delegates lists the registration status of the bakers",
+		Long: `delegates lists whether each baker configured is active, in the grace period before its deactivation, or deactivated,
+and warns when a baker is deactivated within --cycles cycles. With --network, it lists the delegates registered on the network instead,
+a page at a time.`,
+		Example: `tzpay delegates --table
+tzpay delegates --network --active --page 0 --limit 100`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if network {
+				options := tzkt.Page(page, limit)
+				if active != inactive {
+					options = append(options, tzkt.Active(active))
+				}
+
+				list, err := indexer.NewWithContext(interruptContext(), config.API).GetDelegates(options...)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to list delegates.")
+				}
+
+				if table {
+					printNetworkDelegatesTable(list)
+					return
+				}
+
+				prettyJSON, err := json.Marshal(list)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+				}
+				log.WithFields(log.Fields{"page": page, "delegates": string(prettyJSON)}).Info("Delegates registered on the network.")
+				return
+			}
+
+			rpcClient, err := node.New(interruptContext(), config.API)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to connect to tezos rpc.")
+			}
+
+			// Warn from the same cycle tzpay serv does, unless told otherwise
+			if !cmd.Flags().Changed("cycles") && config.Notifications.Deactivation.Cycles > 0 {
+				cycles = config.Notifications.Deactivation.Cycles
+			}
+
+			var statuses []notifier.DelegateStatus
+			for _, bakerConfig := range config.Bakers() {
+				status, err := notifier.CheckDelegate(rpcClient, bakerConfig.Baker.Address)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to get delegate status.")
+				}
+				statuses = append(statuses, status)
+
+				switch status.State(cycles) {
+				case notifier.DelegateDeactivated:
+					log.WithField("baker", status.Baker).Warn("Baker is deactivated.")
+				case notifier.DelegateGracePeriod:
+					log.WithFields(log.Fields{"baker": status.Baker, "cycles-left": status.CyclesLeft}).Warn("Baker will be deactivated unless it bakes or endorses.")
+				}
+			}
+
+			if table {
+				printDelegatesTable(statuses, cycles)
+				return
+			}
+
+			prettyJSON, err := json.Marshal(statuses)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			log.WithField("delegates", string(prettyJSON)).Info("Status of the bakers.")
+		},
+	}
+
+	delegates.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	delegates.PersistentFlags().IntVarP(&cycles, "cycles", "c", 3, "the number of cycles before deactivation to warn from (Default: TZPAY_NOTIFY_DEACTIVATION_CYCLES, or 3)")
+	delegates.PersistentFlags().BoolVarP(&network, "network", "n", false, "lists the delegates registered on the network")
+	delegates.PersistentFlags().BoolVar(&active, "active", false, "lists only active delegates (with --network)")
+	delegates.PersistentFlags().BoolVar(&inactive, "inactive", false, "lists only inactive delegates (with --network)")
+	delegates.PersistentFlags().IntVarP(&page, "page", "p", 0, "the page of delegates to list, starting at 0 (with --network)")
+	delegates.PersistentFlags().IntVarP(&limit, "limit", "l", 100, "the number of delegates per page (with --network)")
+
+	return delegates
+}
+
+func printDelegatesTable(statuses []notifier.DelegateStatus, cycles int) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Baker", "Status", "Cycle", "Grace Period", "Cycles Left", "Staking Balance"})
+	for _, status := range statuses {
+		stakingBalance, _ := strconv.Atoi(status.StakingBalance)
+		table.Append([]string{
+			status.Baker,
+			status.State(cycles),
+			strconv.Itoa(status.Cycle),
+			strconv.Itoa(status.GracePeriod),
+			strconv.Itoa(status.CyclesLeft),
+			fmt.Sprintf("%.6f", float64(stakingBalance)/float64(gotezos.MUTEZ)),
+		})
+	}
+
+	table.Render()
+}
+
+func printNetworkDelegatesTable(delegates []tzkt.Delegate) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Delegate", "Alias", "Active", "Delegators", "Staking Balance", "Last Activity"})
+	for _, delegate := range delegates {
+		table.Append([]string{
+			delegate.Address,
+			delegate.Alias,
+			strconv.FormatBool(delegate.Active),
+			strconv.Itoa(delegate.NumDelegators),
+			fmt.Sprintf("%.6f", float64(delegate.StakingBalance)/float64(gotezos.MUTEZ)),
+			strconv.Itoa(delegate.LastActivity),
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// DelegatorCommand returns a new delegator cobra command
+func DelegatorCommand() *cobra.Command {
+	var delegator = &cobra.Command{
+		Use:   "delegator",
+		Short: "delegator manages the data tzpay stores about a delegator",
+	}
+
+	delegator.AddCommand(delegatorExportCommand(), delegatorAnonymizeCommand(), delegatorEmailCommand())
+
+	return delegator
+}
+
+func delegatorExportCommand() *cobra.Command {
+	var output string
+
+	var export = &cobra.Command{
+		Use:     "export",
+		Short:   "export prints every stored record about a delegator as json",
+		Example: `tzpay delegator export tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc --output export.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				log.Fatal("Missing delegator address as argument.")
+			}
+
+			s := openStore()
+			records, err := store.Export(s, args[0])
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to export delegator data.")
+			}
+
+			data, err := json.MarshalIndent(struct {
+				Delegator string         `json:"delegator"`
+				Records   []store.Record `json:"records"`
+			}{args[0], records}, "", "    ")
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to export delegator data.")
+			}
+
+			if output == "" {
+				fmt.Println(string(data))
+				return
+			}
+
+			if err := ioutil.WriteFile(output, data, 0600); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to write delegator data.")
+			}
+		},
+	}
+
+	export.PersistentFlags().StringVarP(&output, "output", "o", "", "file to write the export to (Default: stdout)")
+
+	return export
+}
+
+func delegatorAnonymizeCommand() *cobra.Command {
+	var confirm bool
+
+	var anonymize = &cobra.Command{
+		Use:   "anonymize",
+		Short: "anonymize replaces a delegator's address with a pseudonym in every stored record",
+		Long: `anonymize replaces a delegator's address with a pseudonym in every stored record. Amounts are kept, 
+so aggregate accounting remains intact, but rewards carried forward for the delegator will no longer be paid to them.`,
+		Example: `tzpay delegator anonymize tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc --confirm`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				log.Fatal("Missing delegator address as argument.")
+			}
+
+			if !confirm {
+				log.Fatal("Anonymizing is irreversible, pass --confirm to proceed.")
+			}
+
+			s := openStore()
+			// The email address would otherwise stay in the store under the pseudonym
+			if err := payout.SetDelegatorEmail(s, args[0], ""); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to anonymize delegator data.")
+			}
+
+			changed, err := store.Anonymize(s, args[0])
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to anonymize delegator data.")
+			}
+
+			pseudonym := store.Pseudonym(args[0])
+			err = audit.Record(s, audit.Event{
+				Action: "delegator_anonymize",
+				Details: map[string]string{
+					"pseudonym": pseudonym,
+					"records":   fmt.Sprintf("%d", changed),
+				},
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Error("Failed to record anonymization in audit log.")
+			}
+
+			log.WithFields(log.Fields{"pseudonym": pseudonym, "records": changed}).Info("Delegator data anonymized.")
+		},
+	}
+
+	anonymize.PersistentFlags().BoolVar(&confirm, "confirm", false, "confirms the irreversible anonymization")
+
+	return anonymize
+}
+
+func delegatorEmailCommand() *cobra.Command {
+	var remove bool
+
+	var email = &cobra.Command{
+		Use:   "email",
+		Short: "email sets the email address a delegator's payout receipts are sent to",
+		Long: `email sets the email address a delegator's payout receipts are sent to, with TZPAY_EMAIL_RECEIPTS set. 
+Addresses set in TZPAY_EMAIL_DELEGATORS take precedence.`,
+		Example: `tzpay delegator email tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc alice@example.com
+tzpay delegator email tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc --remove`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				log.Fatal("Missing delegator address as argument.")
+			} else if len(args) == 1 && !remove {
+				log.Fatal("Missing email address as argument.")
+			}
+
+			var address string
+			if !remove {
+				address = args[1]
+			}
+
+			s := openStore()
+			if err := payout.SetDelegatorEmail(s, args[0], address); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to set delegator email.")
+			}
+
+			err := audit.Record(s, audit.Event{
+				Action:  "delegator_email",
+				Details: map[string]string{"delegator": args[0], "removed": fmt.Sprintf("%t", remove)},
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Error("Failed to record delegator email in audit log.")
+			}
+
+			if remove {
+				log.WithField("delegator", args[0]).Info("Delegator email removed.")
+				return
+			}
+			log.WithField("delegator", args[0]).Info("Delegator email set.")
+		},
+	}
+
+	email.PersistentFlags().BoolVar(&remove, "remove", false, "removes the delegator's email address")
+
+	return email
+}
+
+// openStore opens the store configured, exiting on failure
+func openStore() store.IFace {
+	cfg, err := newConfig()
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	s, err := store.New(cfg.Store.Path, cfg.Store.Key)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to open store.")
+	}
+
+	return s
+}
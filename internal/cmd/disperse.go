@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// DisperseCommand returns a new disperse cobra command
+func DisperseCommand() *cobra.Command {
+	var disperse = &cobra.Command{
+		Use:   "disperse",
+		Short: "disperse manages the contract used to pay batches in a single contract call",
+	}
+
+	disperse.AddCommand(disperseOriginateCommand())
+
+	return disperse
+}
+
+func disperseOriginateCommand() *cobra.Command {
+	var baker string
+
+	var originate = &cobra.Command{
+		Use:     "originate",
+		Short:   "originate deploys a disperse contract from the payout wallet",
+		Long:    "originate deploys a disperse contract from the payout wallet and prints its address for TZPAY_OPERATIONS_DISPERSE_CONTRACT",
+		Example: `tzpay disperse originate`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = unlockWallets(config)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to unlock payout wallet.")
+			}
+
+			payout, err := payout.NewWithContext(interruptContext(), config, 0, true, true)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
+			}
+
+			contract, err := payout.OriginateDisperseContract()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to originate disperse contract.")
+			}
+
+			fmt.Println(contract)
+			log.WithField("contract", contract).Info("Disperse contract originated, set TZPAY_OPERATIONS_DISPERSE_CONTRACT to use it.")
+		},
+	}
+
+	originate.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker whose wallet originates the contract (Default: primary baker)")
+
+	return originate
+}
@@ -1,26 +1,39 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
 	"strconv"
 
+	gotezos "github.com/goat-systems/go-tezos/v2"
 	"github.com/goat-systems/tzpay/v3/internal/config"
 	"github.com/goat-systems/tzpay/v3/internal/payout"
 	"github.com/goat-systems/tzpay/v3/internal/print"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/olekukonko/tablewriter"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 // DryRun -
 type DryRun struct {
-	payout payout.IFace
-	config config.Config
-	cycle  int
-	table  bool
+	payout   payout.IFace
+	previous payout.IFace
+	config   config.Config
+	cycle    int
+	table    bool
+	swing    float64
 }
 
-// NewDryRun returns a new dryrun
-func NewDryRun(cycle string, table bool) DryRun {
-	config, err := config.New()
+// NewDryRun returns a new dryrun for the baker passed, or the primary baker if baker is empty
+func NewDryRun(cycle string, table bool, baker string) DryRun {
+	config, err := newConfig()
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	config, err = config.ForBaker(baker)
 	if err != nil {
 		log.WithField("error", err.Error()).Fatal("Failed to load config.")
 	}
@@ -34,7 +47,7 @@ func NewDryRun(cycle string, table bool) DryRun {
 		log.WithField("error", err.Error()).Fatal("Failed to parse cycle argument into integer.")
 	}
 
-	payout, err := payout.New(config, c, false, false)
+	payout, err := payout.NewWithContext(interruptContext(), config, c, false, false)
 	if err != nil {
 		log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
 	}
@@ -47,25 +60,46 @@ func NewDryRun(cycle string, table bool) DryRun {
 	}
 }
 
+// withDiff compares the payout to the payout of the previous cycle, reporting swings above swing percent
+func (d DryRun) withDiff(swing float64) DryRun {
+	previous, err := payout.NewWithContext(interruptContext(), d.config, d.cycle-1, false, false)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to intialize payout for previous cycle.")
+	}
+
+	d.previous = previous
+	d.swing = swing / 100
+	return d
+}
+
 // DryRunCommand returns the cobra command for dryrun
 func DryRunCommand() *cobra.Command {
 	var table bool
+	var baker string
+	var diff bool
+	var swing float64
 
 	var dryrun = &cobra.Command{
 		Use:     "dryrun",
 		Short:   "dryrun simulates a payout",
 		Long:    "dryrun simulates a payout and prints the result in json or a table",
-		Example: `tzpay dryrun <cycle>`,
+		Example: `tzpay dryrun <cycle> --diff`,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				log.Fatal("Missing cycle as argument.")
 			}
 
-			dryrun := NewDryRun(args[0], table)
+			dryrun := NewDryRun(args[0], table, baker)
+			if diff {
+				dryrun = dryrun.withDiff(swing)
+			}
 			dryrun.execute()
 		},
 	}
 	dryrun.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	dryrun.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to simulate a payout for when multiple bakers are configured (Default: primary baker)")
+	dryrun.PersistentFlags().BoolVarP(&diff, "diff", "d", false, "compares the payout to the payout of the previous cycle")
+	dryrun.PersistentFlags().Float64VarP(&swing, "swing", "s", 25, "the variance in percent of a delegator's share or rewards reported by --diff")
 
 	return dryrun
 }
@@ -84,4 +118,57 @@ func (d *DryRun) execute() {
 			log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
 		}
 	}
+
+	if d.previous != nil {
+		d.diff(rewardsSplit)
+	}
+}
+
+// diff prints the delegators that joined, left, or whose payout swung since the previous cycle
+func (d *DryRun) diff(rewardsSplit tzkt.RewardsSplit) {
+	previous, err := d.previous.Execute()
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to execute payout for previous cycle.")
+	}
+
+	changes := payout.Diff(previous, rewardsSplit, d.swing)
+	if len(changes) == 0 {
+		log.WithField("cycle", d.cycle).Info("No change from the previous cycle.")
+		return
+	}
+
+	if d.table {
+		printDiffTable(d.cycle, changes)
+		return
+	}
+
+	prettyJSON, err := json.Marshal(changes)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to print JSON diff.")
+	}
+	log.WithField("diff", string(prettyJSON)).Warn("Changes from the previous cycle.")
+}
+
+func printDiffTable(cycle int, changes []payout.DelegatorChange) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Delegation", "Change", fmt.Sprintf("Share %d", cycle-1), fmt.Sprintf("Share %d", cycle),
+		fmt.Sprintf("Net %d", cycle-1), fmt.Sprintf("Net %d", cycle), "Variance"})
+	for _, change := range changes {
+		variance := ""
+		if change.RelativeVariance > 0 {
+			variance = fmt.Sprintf("%.1f%%", change.RelativeVariance*100)
+		}
+
+		table.Append([]string{
+			change.Address,
+			change.Change,
+			fmt.Sprintf("%.6f", change.PreviousShare),
+			fmt.Sprintf("%.6f", change.Share),
+			fmt.Sprintf("%.6f", float64(change.PreviousRewards)/float64(gotezos.MUTEZ)),
+			fmt.Sprintf("%.6f", float64(change.NetRewards)/float64(gotezos.MUTEZ)),
+			variance,
+		})
+	}
+
+	table.Render()
 }
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// configFile is the enviroment file passed with --config, if any
+var configFile string
+
+/*
+AddGlobalFlags adds the flags every command of root accepts, to point it at another node, config file or store
+than the enviroment does. The config file is loaded first, so --node and --store take precedence over it.
+*/
+func AddGlobalFlags(root *cobra.Command) {
+	var node, storePath string
+
+	root.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if configFile != "" {
+			if err := config.LoadEnvFile(configFile); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config file.")
+			}
+		}
+
+		setEnv("TZPAY_API_TEZOS", node)
+		setEnv("TZPAY_STORE_PATH", storePath)
+	}
+
+	root.PersistentFlags().StringVar(&node, "node", "", "the tezos node to use (Default: TZPAY_API_TEZOS)")
+	root.PersistentFlags().StringVar(&configFile, "config", "", "an enviroment file to load the configuration from, e.g. as written by tzpay setup")
+	root.PersistentFlags().StringVar(&storePath, "store", "", "the store to use (Default: TZPAY_STORE_PATH)")
+}
+
+func setEnv(name, value string) {
+	if value == "" {
+		return
+	}
+
+	if err := os.Setenv(name, value); err != nil {
+		log.WithFields(log.Fields{"error": err.Error(), "variable": name}).Fatal("Failed to set enviroment variable.")
+	}
+}
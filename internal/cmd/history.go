@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// HistoryCommand returns the cobra command for history
+func HistoryCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var delegator string
+	var fromCycle int
+
+	var history = &cobra.Command{
+		Use:   "history",
+		Short: "history prints every payment made to a delegator",
+		Long: `history prints every payment the store records as made to --delegator, oldest cycle first, with the operations of
+the payout of each cycle and the total paid. What partial payouts paid for a cycle is included in its payment.`,
+		Example: `tzpay history --delegator tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc
+tzpay history --delegator tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc --from-cycle 300 --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			delegatorHistory, err := payout.History(s, config.Baker.Address, delegator, fromCycle)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to get payment history.")
+			}
+
+			if table {
+				printHistoryTable(delegatorHistory)
+				return
+			}
+
+			prettyJSON, err := json.Marshal(delegatorHistory)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	history.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	history.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to print the payments of when multiple bakers are configured (Default: primary baker)")
+	history.PersistentFlags().StringVarP(&delegator, "delegator", "d", "", "the address of the delegator")
+	history.PersistentFlags().IntVarP(&fromCycle, "from-cycle", "f", 0, "the first cycle to print the payments of (Default: every cycle)")
+	history.MarkPersistentFlagRequired("delegator")
+
+	return history
+}
+
+func printHistoryTable(history payout.DelegatorHistory) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Cycle", "Date", "Amount (XTZ)", "Partial (XTZ)", "Operations"})
+	for _, payment := range history.Payments {
+		var date string
+		if !payment.Time.IsZero() {
+			date = payment.Time.Format("2006-01-02")
+		}
+
+		table.Append([]string{
+			strconv.Itoa(payment.Cycle),
+			date,
+			tez(payment.Amount),
+			tez(payment.Partial),
+			strings.Join(payment.Operations, "\n"),
+		})
+	}
+	table.SetFooter([]string{strconv.Itoa(len(history.Payments)), "TOTAL", tez(history.Total), "", ""})
+
+	table.Render()
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	interruptOnce sync.Once
+	interrupted   context.Context
+)
+
+/*
+interruptContext returns the context the calls of tzpay to the node are bound to, cancelled once the process receives
+SIGINT or SIGTERM, e.g. on Ctrl-C, so that the calls in flight are cancelled rather than waited for. A second signal
+exits right away, in case tzpay waits on something other than the node, e.g. a confirmation.
+*/
+func interruptContext() context.Context {
+	interruptOnce.Do(func() {
+		var cancel context.CancelFunc
+		interrupted, cancel = context.WithCancel(context.Background())
+
+		signals := make(chan os.Signal, 2)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			sig := <-signals
+			log.WithField("signal", sig.String()).Warn("Interrupted, cancelling calls in flight. Interrupt again to exit right away.")
+			cancel()
+
+			<-signals
+			os.Exit(1)
+		}()
+	})
+
+	return interrupted
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/lookup"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// LookupCommand returns the cobra command for lookup
+func LookupCommand() *cobra.Command {
+	var dir string
+	var baker string
+
+	var lk = &cobra.Command{
+		Use:   "lookup",
+		Short: "lookup exports the public lookup page of a baker",
+		Long: `lookup exports the page where a delegator enters their address to see their payment history as a static site, to
+host on the baker's website: <baker>/index.html and the history of every delegator paid in <baker>/<delegator>.json.
+tzpay serv exports it after every cycle when TZPAY_LOOKUP_DIR is set.`,
+		Example: `tzpay lookup --dir /var/www/lookup`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if dir == "" {
+				dir = config.Lookup.Dir
+			}
+			if dir == "" {
+				log.Fatal("No directory to export the lookup page to, pass --dir or set TZPAY_LOOKUP_DIR.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			exported, err := lookup.Write(dir, s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to export lookup page.")
+			}
+			log.WithFields(log.Fields{"baker": config.Baker.Address, "dir": dir, "delegators": exported}).Info("Exported lookup page.")
+		},
+	}
+
+	lk.PersistentFlags().StringVarP(&dir, "dir", "d", "", "the directory to export the page to (Default: TZPAY_LOOKUP_DIR)")
+	lk.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to export the page of when multiple bakers are configured (Default: primary baker)")
+
+	return lk
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/print"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ForgeCommand returns the cobra command for forge
+func ForgeCommand() *cobra.Command {
+	var baker string
+	var memo string
+	var out string
+
+	var forge = &cobra.Command{
+		Use:   "forge",
+		Short: "forge forges a payout to sign offline",
+		Long: `forge constructs the payout for a cycle and forges its operations without the key of the payout wallet, which
+only TZPAY_WALLET_ADDRESS needs to name. The unsigned payout is written to --out with a summary of its transfers, to be
+carried to the machine holding the key and signed with 'tzpay sign'. The operations expire some 60 blocks after forging.`,
+		Example: `tzpay forge 300 --out payout-300.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				log.Fatal("Missing cycle as argument.")
+			}
+
+			cycle, err := strconv.Atoi(args[0])
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to parse cycle argument into integer.")
+			}
+
+			if out == "" {
+				out = fmt.Sprintf("payout-%d.json", cycle)
+			}
+
+			config := newBakerConfig(baker)
+			p, err := payout.NewOffline(config, cycle, false)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
+			}
+			p.SetMemo(memo)
+
+			offline, err := p.Forge()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to forge payout.")
+			}
+
+			writeOfflinePayout(out, offline)
+			printOfflinePayout(offline)
+			log.WithFields(log.Fields{"file": out, "branch": offline.Branch}).Info("Payout forged, sign it with 'tzpay sign'.")
+		},
+	}
+
+	forge.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to forge the payout of when multiple bakers are configured (Default: primary baker)")
+	forge.PersistentFlags().StringVarP(&memo, "memo", "m", "", "an operator memo stored with the payout record once injected")
+	forge.PersistentFlags().StringVarP(&out, "out", "o", "", "the file to write the unsigned payout to (Default: payout-<cycle>.json)")
+
+	return forge
+}
+
+// SignCommand returns the cobra command for sign
+func SignCommand() *cobra.Command {
+	var baker string
+	var out string
+
+	var sign = &cobra.Command{
+		Use:   "sign",
+		Short: "sign signs a payout forged offline",
+		Long: `sign checks that the operations of a payout forged with 'tzpay forge' are the transfers they list, signs them with
+the payout wallet, and prints the transfers signed. The signed payout is written to --out, to be injected with 'tzpay inject'.`,
+		Example: `tzpay sign payout-300.json --out payout-300-signed.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				log.Fatal("Missing payout file as argument.")
+			}
+
+			if out == "" {
+				out = args[0]
+			}
+
+			offline := readOfflinePayout(args[0])
+			config, err := unlockWallets(newBakerConfig(baker))
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to unlock payout wallet.")
+			}
+
+			signed, err := payout.SignOffline(config, offline)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to sign payout.")
+			}
+
+			// The transfers printed are those of the operations signed, checked against the bytes forged
+			printOfflinePayout(signed)
+			writeOfflinePayout(out, signed)
+			log.WithFields(log.Fields{"file": out, "operations": len(signed.Operations)}).Info("Payout signed, inject it with 'tzpay inject'.")
+		},
+	}
+
+	sign.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to sign the payout of when multiple bakers are configured (Default: primary baker)")
+	sign.PersistentFlags().StringVarP(&out, "out", "o", "", "the file to write the signed payout to (Default: the payout file)")
+
+	return sign
+}
+
+// InjectCommand returns the cobra command for inject
+func InjectCommand() *cobra.Command {
+	var table bool
+	var verbose bool
+	var baker string
+
+	var inject = &cobra.Command{
+		Use:   "inject",
+		Short: "inject injects a payout signed offline",
+		Long: `inject injects the operations of a payout signed with 'tzpay sign', records it as 'tzpay run' would, and prints
+the result in json or a table`,
+		Example: `tzpay inject payout-300-signed.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				log.Fatal("Missing payout file as argument.")
+			}
+
+			offline := readOfflinePayout(args[0])
+			config := newBakerConfig(baker)
+
+			p, err := payout.NewOffline(config, offline.Cycle, verbose)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
+			}
+
+			rewardsSplit, err := p.InjectOffline(offline)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to inject payout.")
+			}
+
+			if table {
+				print.Table(offline.Cycle, config.Baker.Address, rewardsSplit)
+			} else if err := print.JSON(rewardsSplit); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+		},
+	}
+
+	inject.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	inject.PersistentFlags().BoolVarP(&verbose, "verbose", "v", true, "will print confirmations in between injections.")
+	inject.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to inject the payout of when multiple bakers are configured (Default: primary baker)")
+
+	return inject
+}
+
+func newBakerConfig(baker string) config.Config {
+	cfg, err := newConfig()
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	config, err := cfg.ForBaker(baker)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	return config
+}
+
+func readOfflinePayout(path string) payout.OfflinePayout {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to read payout file.")
+	}
+
+	var offline payout.OfflinePayout
+	if err := json.Unmarshal(raw, &offline); err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to parse payout file.")
+	}
+
+	return offline
+}
+
+func writeOfflinePayout(path string, offline payout.OfflinePayout) {
+	raw, err := json.MarshalIndent(offline, "", "  ")
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to encode payout.")
+	}
+
+	if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to write payout file.")
+	}
+}
+
+// printOfflinePayout prints every transfer of an offline payout, to be checked before it is signed
+func printOfflinePayout(offline payout.OfflinePayout) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Operation", "Counter", "Destination", "Amount", "Fee"})
+	table.SetCaption(true, fmt.Sprintf("Cycle %d of %s from %s: %d transfers of %.6f XTZ in %d operations, %.6f XTZ of fees",
+		offline.Cycle,
+		offline.Baker,
+		offline.Wallet,
+		offline.Summary.Transfers,
+		float64(offline.Summary.Amount)/float64(gotezos.MUTEZ),
+		offline.Summary.Operations,
+		float64(offline.Summary.Fees)/float64(gotezos.MUTEZ),
+	))
+
+	for i, operation := range offline.Operations {
+		for _, content := range operation.Contents {
+			table.Append([]string{
+				strconv.Itoa(i + 1),
+				strconv.Itoa(content.Counter),
+				content.Destination,
+				fmt.Sprintf("%.6f", float64(content.Amount)/float64(gotezos.MUTEZ)),
+				fmt.Sprintf("%.6f", float64(content.Fee)/float64(gotezos.MUTEZ)),
+			})
+		}
+	}
+	table.Render()
+}
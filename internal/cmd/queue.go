@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/api"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// QueueCommand returns the cobra command for queue
+func QueueCommand() *cobra.Command {
+	var url string
+
+	var queue = &cobra.Command{
+		Use:   "queue",
+		Short: "queue manages the payout queue of a running tzpay serv",
+		Long: `queue manages the payout queue of a running tzpay serv through its control API, which is served with
+TZPAY_HTTP_LISTEN, TZPAY_HTTP_API_KEY and TZPAY_HTTP_CONTROL set. Pause or drain the queue before a maintenance window
+rather than killing the server in the middle of a payout.`,
+	}
+	queue.PersistentFlags().StringVarP(&url, "url", "u", "", "the URL of the running tzpay serv (Default: http://localhost with the port of TZPAY_HTTP_LISTEN)")
+	queue.AddCommand(
+		queueListCommand(&url),
+		queuePauseCommand(&url),
+		queueResumeCommand(&url),
+		queueRemoveCommand(&url),
+		queueDrainCommand(&url),
+	)
+
+	return queue
+}
+
+func queueListCommand(url *string) *cobra.Command {
+	var table bool
+
+	var list = &cobra.Command{
+		Use:     "list",
+		Short:   "list prints the payouts being executed and waiting in the queue",
+		Example: `tzpay queue list --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			status, err := newQueueClient(*url).Queue()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to list queue.")
+			}
+			printQueueStatus(status, table)
+		},
+	}
+
+	list.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+
+	return list
+}
+
+func queuePauseCommand(url *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "pause stops the queue from executing payouts until resumed",
+		Long: `pause stops the queue from executing payouts until resumed. The payouts being executed are finished, and new
+payouts are still queued.`,
+		Example: `tzpay queue pause`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := newQueueClient(*url).Pause(); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to pause queue.")
+			}
+			log.Info("Paused queue.")
+		},
+	}
+}
+
+func queueResumeCommand(url *string) *cobra.Command {
+	return &cobra.Command{
+		Use:     "resume",
+		Short:   "resume lets the queue execute payouts again",
+		Example: `tzpay queue resume`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if _, err := newQueueClient(*url).Resume(); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to resume queue.")
+			}
+			log.Info("Resumed queue.")
+		},
+	}
+}
+
+func queueRemoveCommand(url *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id>",
+		Short: "remove removes a payout waiting in the queue without executing it",
+		Long: `remove removes the payout id, as listed by 'tzpay queue list', from the queue without executing it. A payout being
+executed cannot be removed.`,
+		Example: `tzpay queue remove 01598954400000000000`,
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			removed, err := newQueueClient(*url).Remove(args[0])
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to remove payout from queue.")
+			}
+			log.WithFields(log.Fields{"id": removed.ID, "payout-cycle": removed.Cycle, "baker": removed.Baker}).Info("Removed payout from queue.")
+		},
+	}
+}
+
+func queueDrainCommand(url *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "drain",
+		Short: "drain stops tzpay serv once the payouts being executed are done",
+		Long: `drain pauses the queue, and stops tzpay serv once the payouts being executed are done. The payouts waiting stay in
+the queue, and are executed once tzpay serv is started again.`,
+		Example: `tzpay queue drain`,
+		Run: func(cmd *cobra.Command, args []string) {
+			status, err := newQueueClient(*url).Drain()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to drain queue.")
+			}
+			log.WithFields(log.Fields{"running": len(status.Running), "queued": len(status.Queued)}).Info("Draining queue, tzpay serv stops once the payouts being executed are done.")
+		},
+	}
+}
+
+// newQueueClient returns a client of the control API of the tzpay serv at url, or at TZPAY_HTTP_LISTEN without it
+func newQueueClient(url string) *api.Client {
+	httpConfig, err := config.NewHTTP()
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	if url == "" {
+		if httpConfig.Listen == "" {
+			log.Fatal("No tzpay serv to call, pass --url or set TZPAY_HTTP_LISTEN.")
+		}
+		url = "http://" + httpConfig.Listen
+		if strings.HasPrefix(httpConfig.Listen, ":") {
+			url = "http://localhost" + httpConfig.Listen
+		}
+	}
+
+	return api.NewClient(api.ClientInput{URL: url, APIKey: httpConfig.APIKey})
+}
+
+func printQueueStatus(status payout.QueueStatus, table bool) {
+	if !table {
+		prettyJSON, err := json.Marshal(status)
+		if err != nil {
+			log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+		}
+		fmt.Println(string(prettyJSON))
+		return
+	}
+
+	fmt.Printf("Paused: %t\n", status.Paused)
+	t := tablewriter.NewWriter(os.Stdout)
+	t.SetHeader([]string{"ID", "State", "Baker", "Cycle", "Partial", "Attempts", "Enqueued"})
+	for state, payouts := range [][]payout.Queued{status.Running, status.Queued} {
+		for _, queued := range payouts {
+			t.Append([]string{
+				queued.ID,
+				[]string{"running", "queued"}[state],
+				queued.Baker,
+				strconv.Itoa(queued.Cycle),
+				strconv.FormatBool(queued.Partial),
+				strconv.Itoa(queued.Attempts),
+				queued.Enqueued.Format("2006-01-02 15:04"),
+			})
+		}
+	}
+	t.Render()
+}
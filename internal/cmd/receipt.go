@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/receipt"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ReceiptCommand returns the cobra command for receipt
+func ReceiptCommand() *cobra.Command {
+	var receiptCommand = &cobra.Command{
+		Use:   "receipt",
+		Short: "receipt prints and verifies the receipts of payments signed by the baker",
+	}
+	receiptCommand.AddCommand(receiptShowCommand(), receiptVerifyCommand())
+
+	return receiptCommand
+}
+
+func receiptShowCommand() *cobra.Command {
+	var baker string
+	var delegator string
+	var cycle int
+
+	var show = &cobra.Command{
+		Use:   "show",
+		Short: "show prints the signed receipts of the payments made to a delegator as json",
+		Long: `show prints the receipts of the payments made to --delegator signed with TZPAY_RECEIPTS_SIGN, or only the receipt of
+--cycle, as json to hand to the delegator, who can check them with 'tzpay receipt verify'.`,
+		Example: `tzpay receipt show --delegator tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc --cycle 300 > receipt.json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			var v interface{}
+			if cycle != 0 {
+				signed, err := payout.SignedReceipt(s, config.Baker.Address, delegator, cycle)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to get signed receipt.")
+				} else if signed == nil {
+					log.WithFields(log.Fields{"delegator": delegator, "cycle": cycle}).Fatal("No signed receipt for cycle.")
+				}
+				v = signed
+			} else {
+				if v, err = payout.SignedReceipts(s, config.Baker.Address, delegator); err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to get signed receipts.")
+				}
+			}
+
+			prettyJSON, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON receipt.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	show.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker that signed the receipts when multiple bakers are configured (Default: primary baker)")
+	show.PersistentFlags().StringVarP(&delegator, "delegator", "d", "", "the address of the delegator")
+	show.PersistentFlags().IntVarP(&cycle, "cycle", "c", 0, "the cycle of the receipt (Default: every cycle)")
+	show.MarkPersistentFlagRequired("delegator")
+
+	return show
+}
+
+func receiptVerifyCommand() *cobra.Command {
+	var publicKey string
+
+	var verify = &cobra.Command{
+		Use:   "verify",
+		Short: "verify checks that receipts were signed by their baker",
+		Long: `verify checks that the signed receipts in the file given, or read from stdin, were signed by the key of their baker.
+The key is --public-key, the baker's edpk public key as published on chain, or else the key carried by each receipt, and
+must be the key of the baker's address either way. A receipt or a list of receipts, as printed by 'tzpay receipt show', is
+accepted. No configuration is needed, so delegators can verify their receipts themselves.`,
+		Example: `tzpay receipt verify receipt.json --public-key edpk...`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var data []byte
+			var err error
+			if len(args) == 0 || args[0] == "-" {
+				data, err = ioutil.ReadAll(os.Stdin)
+			} else {
+				data, err = ioutil.ReadFile(args[0])
+			}
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to read receipts.")
+			}
+
+			receipts := []receipt.Signed{}
+			if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+				var signed receipt.Signed
+				err = json.Unmarshal(trimmed, &signed)
+				receipts = append(receipts, signed)
+			} else {
+				err = json.Unmarshal(trimmed, &receipts)
+			}
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to parse receipts.")
+			}
+
+			var invalid int
+			for _, signed := range receipts {
+				logger := log.WithFields(log.Fields{"baker": signed.Baker, "delegator": signed.Delegator, "cycle": signed.Cycle, "amount": signed.Amount})
+				if err := receipt.Verify(signed, publicKey); err != nil {
+					logger.WithField("error", err.Error()).Error("Receipt is invalid.")
+					invalid++
+					continue
+				}
+				logger.Info("Receipt is valid.")
+			}
+
+			if invalid > 0 {
+				log.WithField("invalid", invalid).Fatal("Failed to verify receipts.")
+			}
+		},
+	}
+
+	verify.PersistentFlags().StringVarP(&publicKey, "public-key", "k", "", "the public key of the baker (Default: the key carried by each receipt)")
+
+	return verify
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/wallet"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// RecoverCommand returns a new recover cobra command
+func RecoverCommand() *cobra.Command {
+	var baker string
+	var repair bool
+
+	var rec = &cobra.Command{
+		Use:   "recover",
+		Short: "recover diagnoses the state left behind by interrupted payouts",
+		Long: `recover inspects the store for inconsistent states left behind by a crash or a manual intervention, explains
+each of them with the action that repairs it, and applies the repairs that do not inject anything when --repair is passed`,
+		Example: `tzpay recover --repair`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			bakerConfig, err := cfg.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			bakerConfig, err = unlockWallets(bakerConfig)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to unlock payout wallet.")
+			}
+
+			s, err := store.New(cfg.Store.Path, cfg.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			issues, err := payout.Diagnose(s, bakerConfig.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to diagnose store.")
+			}
+
+			if issue, ok, err := rotationIssue(s, cfg.Store.Path, bakerConfig); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to diagnose keystore.")
+			} else if ok {
+				issues = append(issues, issue)
+			}
+
+			if len(issues) == 0 {
+				log.WithField("store", cfg.Store.Path).Info("No inconsistent state found.")
+				return
+			}
+
+			for _, issue := range issues {
+				logger := log.WithFields(log.Fields{
+					"cycle":       issue.Cycle,
+					"explanation": issue.Explanation,
+					"action":      issue.Action,
+				})
+				logger.Warn(issue.Problem)
+
+				if !repair || !issue.Repairable() {
+					continue
+				}
+
+				if err := issue.Repair(); err != nil {
+					logger.WithField("error", err.Error()).Error("Failed to repair.")
+					continue
+				}
+
+				err = audit.Record(s, audit.Event{
+					Action: "recover",
+					Baker:  bakerConfig.Baker.Address,
+					Details: map[string]string{
+						"cycle":   fmt.Sprintf("%d", issue.Cycle),
+						"problem": issue.Problem,
+						"action":  issue.Action,
+					},
+				})
+				if err != nil {
+					logger.WithField("error", err.Error()).Error("Failed to record repair in audit log.")
+				}
+				logger.Info("Repaired.")
+			}
+		},
+	}
+
+	rec.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to diagnose when multiple bakers are configured (Default: primary baker)")
+	rec.PersistentFlags().BoolVarP(&repair, "repair", "r", false, "applies the repairs that do not inject anything")
+
+	return rec
+}
+
+// rotationIssue reports a wallet rotation that generated a wallet but stopped before the configuration used it
+func rotationIssue(s store.IFace, path string, bakerConfig config.Config) (payout.Issue, bool, error) {
+	latest, entry, ok, err := wallet.Latest(s, bakerConfig.Baker.Address)
+	if err != nil || !ok {
+		return payout.Issue{}, false, err
+	}
+
+	configured, err := payout.WalletAddress(bakerConfig)
+	if err != nil {
+		return payout.Issue{}, false, errors.Wrap(err, "failed to import payout wallet")
+	}
+
+	if configured != latest {
+		return payout.Issue{
+			Problem: fmt.Sprintf("wallet '%s' generated at %s is not the configured payout wallet '%s'", latest, entry.Created, configured),
+			Explanation: "a wallet rotation stopped after generating the new wallet, either before transferring the funds or " +
+				"before updating the configuration",
+			Action: fmt.Sprintf("check the balances of both wallets, then configure the new wallet from the keystore at '%s' or run 'tzpay wallet rotate' again", path),
+		}, true, nil
+	}
+
+	return payout.Issue{}, false, nil
+}
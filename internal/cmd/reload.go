@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// liveConfig is the configuration of the server, swapped at once when reloaded
+type liveConfig struct {
+	mu  sync.RWMutex
+	cfg config.Config
+}
+
+// Get returns the configuration in effect
+func (l *liveConfig) Get() config.Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cfg
+}
+
+func (l *liveConfig) set(cfg config.Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+/*
+reloader reloads the configuration of the server from the enviroment file passed with --config and the enviroment,
+e.g. on SIGHUP. The configuration reloaded is validated, notification services included, before it replaces the
+running configuration and notification services at once; a configuration that does not validate is reported and the
+running configuration is kept, so that a bad edit never stops the server.
+*/
+type reloader struct {
+	live     *liveConfig
+	registry *notifier.Registry
+	notifier *notifier.PayoutNotifier
+	// control is the client of the control API sent every notification, registered again with the services reloaded
+	control notifier.ClientIFace
+	mu      sync.Mutex
+}
+
+// Reload reloads the configuration, and returns the variables changed that take a restart and were kept
+func (r *reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running := r.live.Get()
+	var reloaded config.Config
+	var registry *notifier.Registry
+	var restart []string
+	_, err := config.Reload(configFile, func(loaded config.Config) error {
+		var err error
+		if reloaded, restart, err = config.KeepStartup(running, loaded); err != nil {
+			return err
+		}
+		registry, err = buildRegistry(reloaded)
+		return err
+	})
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Failed to reload configuration, keeping the running configuration.")
+		if nerr := r.notifier.Notify(fmt.Sprintf("[TZPAY] configuration rejected, keeping the running configuration: %s", err.Error())); nerr != nil {
+			log.WithField("error", nerr.Error()).Error("Failed to notify.")
+		}
+		return nil, errors.Wrap(err, "failed to reload configuration")
+	}
+
+	if r.control != nil {
+		registry.Register("control", r.control)
+	}
+	r.live.set(reloaded)
+	r.registry.Replace(registry)
+
+	if len(restart) > 0 {
+		log.WithField("restart", restart).Warn("Reloaded configuration, keeping the running settings that take a restart.")
+	} else {
+		log.Info("Reloaded configuration.")
+	}
+
+	return restart, nil
+}
+
+// onHangup reloads the configuration every time the process receives SIGHUP, e.g. from 'systemctl reload'
+func (r *reloader) onHangup() {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			log.Info("Received SIGHUP, reloading configuration.")
+			r.Reload()
+		}
+	}()
+}
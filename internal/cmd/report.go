@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/history"
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/price"
+	"github.com/goat-systems/tzpay/v3/internal/report"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ReportCommand returns the cobra command for report
+func ReportCommand() *cobra.Command {
+	var reportCommand = &cobra.Command{
+		Use:   "report",
+		Short: "report generates reports of the payouts recorded",
+	}
+	reportCommand.AddCommand(reportTaxCommand(), reportEfficiencyCommand(), reportHTMLCommand(), reportExportCommand())
+
+	return reportCommand
+}
+
+func reportTaxCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var year int
+	var currency string
+
+	var tax = &cobra.Command{
+		Use:   "tax",
+		Short: "tax generates the yearly report of the fees earned and the amounts distributed, valued in a fiat currency",
+		Long: `tax generates the report of the fees earned and the amounts distributed by the payouts recorded in --year, valued at the
+price of XTZ in --currency when each payout was made. The price recorded with a payout when TZPAY_PRICE_CURRENCY is set is used,
+and the price of the day is fetched from TZPAY_PRICE_SOURCE otherwise.`,
+		Example: `tzpay report tax --year 2021 --currency EUR --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if currency == "" {
+				currency = config.Price.Currency
+			}
+			if currency == "" {
+				log.Fatal("Failed to generate tax report: set --currency or TZPAY_PRICE_CURRENCY.")
+			}
+
+			source, err := price.New(price.Input{Source: config.Price.Source, URL: config.Price.URL})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to initialize price source.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			records, err := payout.Records(s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load payout records.")
+			}
+
+			taxReport, err := report.Tax(report.TaxInput{
+				Baker:    config.Baker.Address,
+				Records:  records,
+				Year:     year,
+				Currency: currency,
+				Price:    source,
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate tax report.")
+			}
+
+			if table {
+				printTaxTable(taxReport)
+				return
+			}
+
+			prettyJSON, err := json.Marshal(taxReport)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	tax.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	tax.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to report on when multiple bakers are configured (Default: primary baker)")
+	tax.PersistentFlags().IntVarP(&year, "year", "y", time.Now().Year(), "the year to report on")
+	tax.PersistentFlags().StringVarP(&currency, "currency", "c", "", "the fiat currency to value payouts in, e.g. EUR (Default: TZPAY_PRICE_CURRENCY)")
+
+	return tax
+}
+
+func printTaxTable(taxReport report.TaxReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Cycle", "Date", "Fees (XTZ)", "Distributed (XTZ)", "Rate", "Fees (" + taxReport.Currency + ")", "Distributed (" + taxReport.Currency + ")"})
+	for _, entry := range taxReport.Entries {
+		table.Append([]string{
+			strconv.Itoa(entry.Cycle),
+			entry.Time.Format("2006-01-02"),
+			tez(int(entry.Fees)),
+			tez(int(entry.Amount)),
+			fmt.Sprintf("%.4f", entry.Rate),
+			fmt.Sprintf("%.2f", entry.FeesFiat),
+			fmt.Sprintf("%.2f", entry.AmountFiat),
+		})
+	}
+	table.SetFooter([]string{strconv.Itoa(taxReport.Year), "TOTAL", tez(int(taxReport.Fees)), tez(int(taxReport.Amount)), "",
+		fmt.Sprintf("%.2f", taxReport.FeesFiat), fmt.Sprintf("%.2f", taxReport.AmountFiat)})
+
+	table.Render()
+}
+
+func reportEfficiencyCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var fromCycle int
+	var toCycle int
+
+	var efficiency = &cobra.Command{
+		Use:   "efficiency",
+		Short: "efficiency reports the rewards earned against the rewards of the baking and endorsing rights, cycle by cycle",
+		Long: `efficiency reports the rewards the baker earned in every cycle against the rewards of its baking rights of priority 0 and
+of its endorsing rights, as a percentage. Blocks baked on the rights of others count, so a cycle may exceed 100%. The cycles of the
+history synced to the store (TZPAY_SYNC_INTERVAL) are reported, and the cycles between --from-cycle and --to-cycle missing from it
+are fetched from tzkt.`,
+		Example: `tzpay report efficiency --table
+tzpay report efficiency --from-cycle 300 --to-cycle 320 --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			synced, err := history.Cycles(s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load history.")
+			}
+
+			var cycles []tzkt.RewardsSplit
+			found := map[int]bool{}
+			for _, cycle := range synced {
+				if cycle.Cycle >= fromCycle && (toCycle == 0 || cycle.Cycle <= toCycle) {
+					cycles = append(cycles, cycle)
+					found[cycle.Cycle] = true
+				}
+			}
+
+			if fromCycle > 0 && toCycle >= fromCycle {
+				client := indexer.NewWithContext(interruptContext(), config.API)
+				for cycle := fromCycle; cycle <= toCycle; cycle++ {
+					if found[cycle] {
+						continue
+					}
+
+					rewardsSplit, err := client.GetRewardsSplit(config.Baker.Address, cycle, tzkt.URLParameters{Key: "limit", Value: "1"})
+					if err != nil {
+						log.WithFields(log.Fields{"cycle": cycle, "error": err.Error()}).Fatal("Failed to get rewards.")
+					}
+					cycles = append(cycles, rewardsSplit)
+				}
+				sort.Slice(cycles, func(i, j int) bool { return cycles[i].Cycle < cycles[j].Cycle })
+			}
+
+			if len(cycles) == 0 {
+				log.Fatal("Failed to generate efficiency report: no history synced, set TZPAY_SYNC_INTERVAL or pass --from-cycle and --to-cycle.")
+			}
+
+			efficiencyReport := report.Efficiency(report.EfficiencyInput{Baker: config.Baker.Address, Cycles: cycles})
+			if table {
+				printEfficiencyTable(efficiencyReport)
+				return
+			}
+
+			prettyJSON, err := json.Marshal(efficiencyReport)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	efficiency.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	efficiency.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to report on when multiple bakers are configured (Default: primary baker)")
+	efficiency.PersistentFlags().IntVarP(&fromCycle, "from-cycle", "f", 0, "the first cycle to report on (Default: first cycle synced)")
+	efficiency.PersistentFlags().IntVarP(&toCycle, "to-cycle", "", 0, "the last cycle to report on (Default: last cycle synced)")
+
+	return efficiency
+}
+
+func printEfficiencyTable(efficiencyReport report.EfficiencyReport) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Cycle", "Blocks", "Missed Blocks", "Endorsements", "Missed Endorsements", "Ideal (XTZ)", "Actual (XTZ)", "Efficiency"})
+	for _, entry := range efficiencyReport.Entries {
+		table.Append([]string{
+			strconv.Itoa(entry.Cycle),
+			strconv.Itoa(entry.Blocks),
+			strconv.Itoa(entry.MissedBlocks),
+			strconv.Itoa(entry.Endorsements),
+			strconv.Itoa(entry.MissedEndorsements),
+			tez(entry.Ideal),
+			tez(entry.Actual),
+			fmt.Sprintf("%.2f%%", entry.Efficiency),
+		})
+	}
+	table.SetFooter([]string{strconv.Itoa(len(efficiencyReport.Entries)), "", "", "", "TOTAL", tez(efficiencyReport.Ideal),
+		tez(efficiencyReport.Actual), fmt.Sprintf("%.2f%%", efficiencyReport.Efficiency)})
+
+	table.Render()
+}
+
+func reportHTMLCommand() *cobra.Command {
+	var baker string
+	var dir string
+
+	var html = &cobra.Command{
+		Use:   "html",
+		Short: "html writes a static HTML report of the rewards and payouts of every cycle",
+		Long: `html writes a static HTML report to --dir, to publish on the baker's website: index.html, with charts of the staking balance,
+rewards and fees earned over time and a table of every cycle, and cycle-<cycle>.html per cycle. Rewards come from the history synced
+to the store (TZPAY_SYNC_INTERVAL) and payouts from the payouts recorded.`,
+		Example: `tzpay report html --dir /var/www/tzpay`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			cycles, err := history.Cycles(s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load history.")
+			}
+
+			records, err := payout.Records(s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load payout records.")
+			}
+
+			pages, err := report.HTML(report.HTMLInput{
+				Baker:   config.Baker.Address,
+				Cycles:  cycles,
+				Records: records,
+				Now:     time.Now(),
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate HTML report.")
+			}
+
+			if err := report.WriteHTML(dir, pages); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate HTML report.")
+			}
+			log.WithFields(log.Fields{"dir": dir, "pages": len(pages)}).Info("Wrote HTML report.")
+		},
+	}
+
+	html.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to report on when multiple bakers are configured (Default: primary baker)")
+	html.PersistentFlags().StringVarP(&dir, "dir", "d", "", "the directory to write the report to")
+	html.MarkPersistentFlagRequired("dir")
+
+	return html
+}
+
+func reportExportCommand() *cobra.Command {
+	var baker string
+	var format string
+	var year int
+	var account string
+	var output string
+
+	var export = &cobra.Command{
+		Use:   "export",
+		Short: "export exports the payouts recorded for accounting software, as QIF, OFX or a ledger-cli journal",
+		Long: `export exports the payouts recorded as transactions of the baker's XTZ, for accounting software to import: every payout
+distributes its amount, booked to Expenses:Tezos:Distributions, and earns its fees, booked to Income:Tezos:Fees. --format is qif,
+ofx or ledger, and --year limits the export to the payouts made that year.`,
+		Example: `tzpay report export --format ledger --year 2021 --output tzpay.ledger
+tzpay report export --format ofx --output tzpay.ofx`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			records, err := payout.Records(s, config.Baker.Address)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load payout records.")
+			}
+
+			data, err := report.Accounting(format, report.AccountingInput{
+				Baker:   config.Baker.Address,
+				Records: records,
+				Year:    year,
+				Account: account,
+				Now:     time.Now(),
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to export payouts.")
+			}
+
+			if output == "" {
+				fmt.Print(string(data))
+				return
+			}
+
+			if err := ioutil.WriteFile(output, data, 0600); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to write export.")
+			}
+		},
+	}
+
+	export.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to export the payouts of when multiple bakers are configured (Default: primary baker)")
+	export.PersistentFlags().StringVarP(&format, "format", "f", report.FormatLedger, "the format to export to: qif, ofx or ledger")
+	export.PersistentFlags().IntVarP(&year, "year", "y", 0, "the year to export the payouts of (Default: every year)")
+	export.PersistentFlags().StringVarP(&account, "account", "a", "", "the ledger account holding the baker's XTZ (Default: Assets:Tezos:<baker>)")
+	export.PersistentFlags().StringVarP(&output, "output", "o", "", "file to write the export to (Default: stdout)")
+
+	return export
+}
@@ -6,10 +6,15 @@ import (
 
 	"github.com/goat-systems/tzpay/v3/internal/config"
 	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/notifier/discord"
+	"github.com/goat-systems/tzpay/v3/internal/notifier/email"
+	"github.com/goat-systems/tzpay/v3/internal/notifier/telegram"
 	"github.com/goat-systems/tzpay/v3/internal/notifier/twilio"
 	"github.com/goat-systems/tzpay/v3/internal/notifier/twitter"
+	"github.com/goat-systems/tzpay/v3/internal/notifier/webhook"
 	"github.com/goat-systems/tzpay/v3/internal/payout"
 	"github.com/goat-systems/tzpay/v3/internal/print"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -19,20 +24,58 @@ type Run struct {
 	config   config.Config
 	table    bool
 	verbose  bool
+	memo     string
+	partial  bool
 	notifier notifier.PayoutNotifier
 }
 
-// NewRun returns a new Run
-func NewRun(table bool, verbose bool) Run {
-	config, err := config.New()
+// NewRun returns a new Run for the baker passed, or the primary baker if baker is empty
+func NewRun(table bool, verbose bool, baker string) Run {
+	cfg, err := newConfig()
 	if err != nil {
 		log.WithField("error", err.Error()).Fatal("Failed to load config.")
 	}
 
-	var messengers []notifier.ClientIFace
+	config, err := cfg.ForBaker(baker)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to load config.")
+	}
+
+	config, err = unlockWallets(config)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to unlock payout wallet.")
+	}
+
+	return Run{
+		config:  config,
+		table:   table,
+		verbose: verbose,
+		notifier: notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{
+			Registry: newRegistry(config),
+			Redactor: newRedactor(cfg),
+		}),
+	}
+}
+
+// newRegistry returns the registry of buildRegistry, stopping tzpay if the notifications are misconfigured
+func newRegistry(config config.Config) *notifier.Registry {
+	registry, err := buildRegistry(config)
+	if err != nil {
+		log.WithField("error", err.Error()).Fatal("Failed to initialize notifications.")
+	}
+
+	return registry
+}
+
+/*
+buildRegistry returns a registry of a client for every notification service configured, named after the service and
+rate limited if configured to, with the routes of TZPAY_NOTIFY_ROUTES.
+*/
+func buildRegistry(config config.Config) (*notifier.Registry, error) {
+	registry := notifier.NewRegistry()
 	if config.Notifications.Twilio.AccountSID != "" && config.Notifications.Twilio.AuthToken != "" &&
 		config.Notifications.Twilio.From != "" && config.Notifications.Twilio.To != nil {
-		messengers = append(messengers, twilio.New(twilio.Client{
+		registry.Register("twilio", twilio.New(twilio.Client{
 			AccountSID: config.Notifications.Twilio.AccountSID,
 			AuthToken:  config.Notifications.Twilio.AuthToken,
 			From:       config.Notifications.Twilio.From,
@@ -41,7 +84,7 @@ func NewRun(table bool, verbose bool) Run {
 	}
 
 	if config.Notifications.Twitter.ConsumerKey != "" && config.Notifications.Twitter.ConsumerSecret != "" && config.Notifications.Twitter.AccessToken != "" && config.Notifications.Twitter.AccessSecret != "" {
-		messengers = append(messengers, twitter.NewClient(
+		registry.Register("twitter", twitter.NewClient(
 			config.Notifications.Twitter.ConsumerKey,
 			config.Notifications.Twitter.ConsumerSecret,
 			config.Notifications.Twitter.AccessToken,
@@ -49,20 +92,80 @@ func NewRun(table bool, verbose bool) Run {
 		))
 	}
 
-	return Run{
-		config:  config,
-		table:   table,
-		verbose: verbose,
-		notifier: notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{
-			Notifiers: messengers,
-		}),
+	if config.Notifications.Telegram.Token != "" && config.Notifications.Telegram.ChatID != "" {
+		registry.Register("telegram", telegram.New(telegram.Client{
+			Token:  config.Notifications.Telegram.Token,
+			ChatID: config.Notifications.Telegram.ChatID,
+		}))
+	}
+
+	if config.Notifications.Discord.WebhookURL != "" {
+		registry.Register("discord", discord.New(discord.Client{
+			WebhookURL: config.Notifications.Discord.WebhookURL,
+			Username:   config.Notifications.Discord.Username,
+		}))
 	}
+
+	if config.Notifications.Email.Host != "" && config.Notifications.Email.To != nil {
+		registry.Register("email", email.New(email.Client{
+			Host:     config.Notifications.Email.Host,
+			Port:     config.Notifications.Email.Port,
+			Username: config.Notifications.Email.Username,
+			Password: config.Notifications.Email.Password,
+			From:     config.Notifications.Email.From,
+			To:       config.Notifications.Email.To,
+		}))
+	}
+
+	// A payout template that does not parse stops tzpay before any payout
+	if _, err := payout.ParseNotificationTemplate(config.Notifications.Templates.Payout); err != nil {
+		return nil, err
+	}
+
+	names, clients := registry.Names(), registry.Clients()
+	for i := range clients {
+		client, err := notifier.NewTemplatedClient(clients[i], names[i], config.Notifications.Templates.Message)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(names[i], client)
+	}
+
+	if config.Notifications.RateLimit.Max > 0 {
+		names, clients := registry.Names(), registry.Clients()
+		for i := range clients {
+			registry.Register(names[i], notifier.NewRateLimitedClient(clients[i], config.Notifications.RateLimit.Max, config.Notifications.RateLimit.Period))
+		}
+	}
+
+	// Events are for the operator's own automation, they are not rate limited
+	if config.Notifications.Webhook.URL != "" {
+		client, err := webhook.New(webhook.Input{
+			URL:      config.Notifications.Webhook.URL,
+			Template: config.Notifications.Webhook.Template,
+			Headers:  config.Notifications.Webhook.Headers,
+			Events:   config.Notifications.Webhook.Events,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize webhook")
+		}
+		registry.Register("webhook", client)
+	}
+
+	if err := registry.SetRoutes(config.Notifications.Routes); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
 }
 
 // RunCommand returns a new run cobra command
 func RunCommand() *cobra.Command {
 	var table bool
 	var verbose bool
+	var baker string
+	var memo string
+	var partial bool
 
 	var run = &cobra.Command{
 		Use:     "run",
@@ -79,29 +182,70 @@ func RunCommand() *cobra.Command {
 				log.WithField("error", err.Error()).Fatal("Failed to parse cycle argument into integer.")
 			}
 
-			run := NewRun(table, verbose)
+			run := NewRun(table, verbose, baker)
+			run.memo = memo
+			run.partial = partial
 			run.execute(cycle)
 		},
 	}
 
 	run.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
 	run.PersistentFlags().BoolVarP(&verbose, "verbose", "v", true, "will print confirmations in between injections.")
+	run.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to payout for when multiple bakers are configured (Default: primary baker)")
+	run.PersistentFlags().StringVarP(&memo, "memo", "m", "", "an operator memo stored with the payout record and included in notifications and reports")
+	run.PersistentFlags().BoolVarP(&partial, "partial", "p", false, "pays the rewards accrued so far in a cycle still in progress (experimental)")
 
 	return run
 }
 
 func (r *Run) execute(cycle int) {
-	payout, err := payout.New(r.config, cycle, true, r.verbose)
+	p, err := payout.NewWithContext(interruptContext(), r.config, cycle, true, r.verbose)
 	if err != nil {
 		log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
 	}
+	p.SetMemo(r.memo)
+	p.SetNotifier(&r.notifier)
+	if r.partial {
+		p.SetPartial()
+	}
 
-	rewardsSplit, err := payout.Execute()
-	if err != nil {
+	rewardsSplit, err := p.Execute()
+	if payout.SpendingCapExceeded(err) {
+		msg := fmt.Sprintf("[TZPAY] ALERT payout for cycle %d aborted: %s", cycle, err.Error())
+		if err := r.notifier.NotifyKind(notifier.KindAlert, msg); err != nil {
+			log.WithField("error", err.Error()).Error("Failed to notify.")
+		}
+		log.WithField("error", err.Error()).Fatal("Payout aborted above the spending cap.")
+	} else if payout.AwaitingApproval(err) {
+		msg := fmt.Sprintf("[TZPAY] payout for cycle %d awaits approval: %s", cycle, err.Error())
+		if err := r.notifier.NotifyKind(notifier.KindApproval, msg); err != nil {
+			log.WithField("error", err.Error()).Error("Failed to notify.")
+		}
+		log.WithField("error", err.Error()).Fatal("Payout held until approved, run it again once approved.")
+	} else if err != nil {
 		log.WithField("error", err.Error()).Fatal("Failed to execute payout.")
 	}
 
-	err = r.notifier.Notify(fmt.Sprintf("[TZPAY] payout for cycle %d: \n%s\n #tezos #blockchain", cycle, rewardsSplit.OperationLink))
+	msg := fmt.Sprintf("[TZPAY] payout for cycle %d: \n%s\n #tezos #blockchain", cycle, rewardsSplit.OperationLink)
+	if r.partial {
+		msg = fmt.Sprintf("[TZPAY] partial payout for cycle %d: \n%s\n #tezos #blockchain", cycle, rewardsSplit.OperationLink)
+	}
+	if rewardsSplit.Skipped {
+		log.Warn("Payout skipped because the baker was denounced.")
+		msg = fmt.Sprintf("[TZPAY] payout for cycle %d skipped: baker was denounced #tezos #blockchain", cycle)
+	}
+
+	if r.memo != "" {
+		msg = fmt.Sprintf("%s\nmemo: %s", msg, r.memo)
+	}
+
+	if text, ok, err := p.Notification(rewardsSplit); err != nil {
+		log.WithField("error", err.Error()).Error("Failed to render payout notification.")
+	} else if ok {
+		msg = text
+	}
+
+	err = r.notifier.NotifyKind(notifier.KindPayout, msg)
 	if err != nil {
 		log.WithField("error", err.Error()).Error("Failed to notify.")
 	}
@@ -1,48 +1,463 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/api"
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/books"
 	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/departures"
+	"github.com/goat-systems/tzpay/v3/internal/history"
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/lookup"
+	"github.com/goat-systems/tzpay/v3/internal/monitor"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/notifier/telegram"
+	"github.com/goat-systems/tzpay/v3/internal/overrides"
 	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/schedule"
+	"github.com/goat-systems/tzpay/v3/internal/status"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/systemd"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 type server struct {
+	// ctx is cancelled once the server is interrupted, cancelling the calls to the node in flight
+	ctx       context.Context
 	queue     *payout.Queue
 	rpcClient rpc.IFace
-	cfg       config.Config
+	cfg       *liveConfig
+	reloader  *reloader
+	overrides *overrides.Provider
+	store     store.IFace
 	runner    Run
+	// schedule holds the payouts of the queue until they may start, nil without scheduling
+	schedule *schedule.Schedule
+	// inclusions waits for the operations of the payouts of the queue to be included, bumping the fees of stuck ones
+	inclusions *payout.InclusionWatcher
+	// quit stops the server once closed, e.g. once the queue is drained
+	quit chan struct{}
 }
 
 func newServer(verbose bool) (server, error) {
-	config, err := config.New()
+	// Overrides are kept up to date by their provider rather than fetched once
+	config, err := loadConfig()
 	if err != nil {
 		return server{}, errors.Wrap(err, "failed to load configuration")
 	}
 
-	rpc, err := rpc.New(config.API.Tezos)
+	config, err = unlockWallets(config)
+	if err != nil {
+		return server{}, errors.Wrap(err, "failed to unlock payout wallets")
+	}
+
+	ctx := interruptContext()
+	rpc, err := node.New(ctx, config.API)
 	if err != nil {
 		return server{}, errors.Wrap(err, "failed to connect to tezos rpc")
 	}
 
-	runner := NewRun(false, verbose)
+	// A build forging or signing differently from the protocol stops the server before any payout is queued
+	head, err := rpc.Head()
+	if err != nil {
+		return server{}, errors.Wrap(err, "failed to get head")
+	}
+	if err := payout.CheckVectors(head.Metadata.Protocol); err != nil {
+		return server{}, err
+	}
+
+	// Every notifier of the server shares the same clients, so that rate limits apply to all of their messages
+	registry := newRegistry(config)
+	var broadcaster *notifier.Broadcaster
+	if config.HTTP.Control || config.HTTP.GRPCListen != "" {
+		// The clients of the control API streaming events are sent every notification, whatever the routes
+		broadcaster = notifier.NewBroadcaster()
+		registry.Register("control", broadcaster)
+	}
+	var digest *notifier.Digest
+	if config.Notifications.Digest.Interval > 0 {
+		digest = notifier.NewDigest(notifier.DigestInput{
+			Notifiers: registry.Route(notifier.KindPayout),
+			Interval:  config.Notifications.Digest.Interval,
+		})
+		digest.Start()
+	}
+
+	runner := NewRun(false, verbose, "")
+	runner.notifier = notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{
+		Registry: registry,
+		Redactor: newRedactor(config),
+		Digest:   digest,
+	})
 	queue := payout.NewQueue(&runner.notifier)
+	live := &liveConfig{cfg: config}
+	reloader := &reloader{live: live, registry: registry, notifier: &runner.notifier}
+	if broadcaster != nil {
+		reloader.control = broadcaster
+	}
+	var bot telegram.IFace
+	if config.Notifications.Telegram.Token != "" && (config.Notifications.Telegram.Confirm || approvedByOperator(config)) {
+		// Confirmations and approvals share the bot, so that every reply of the chat goes to one of them
+		bot = telegram.New(telegram.Client{
+			Token:   config.Notifications.Telegram.Token,
+			ChatID:  config.Notifications.Telegram.ChatID,
+			Timeout: config.Notifications.Telegram.ConfirmTimeout,
+		})
+	}
+	if config.Notifications.Telegram.Confirm && bot != nil {
+		// The payout waits for the chat to confirm it, the server being unattended
+		queue.SetConfirmer(bot)
+	}
+	if config.Approval.Manual {
+		// Every payout computed is held until the operator approves it
+		queue.RequireApproval()
+	}
+	// Operations of payouts are followed on every block the server handles, rather than by each payout
+	inclusions := payout.NewInclusionWatcher(rpc)
+	queue.SetInclusionWatcher(inclusions)
+
+	sched, err := schedule.New(config.Scheduling.Input())
+	if err != nil {
+		return server{}, errors.Wrap(err, "failed to load schedule")
+	}
+	if sched.Enabled() {
+		sched.SetPosition(head.Metadata.Level.CyclePosition)
+		queue.SetScheduler(sched)
+	} else {
+		sched = nil
+	}
+
+	var provider *overrides.Provider
+	if config.Overrides.URL != "" {
+		provider = overrides.NewProvider(overrides.ProviderInput{
+			URL:      config.Overrides.URL,
+			Notifier: &runner.notifier,
+		})
+		if err := provider.Refresh(); err != nil {
+			return server{}, errors.Wrap(err, "failed to load overrides")
+		}
+		if config.Overrides.Interval > 0 {
+			provider.Start(config.Overrides.Interval)
+		}
+	}
+
+	// The queue is persisted to the store, so that payouts not yet executed survive a restart
+	s, err := store.New(config.Store.Path, config.Store.Key)
+	if err != nil {
+		return server{}, errors.Wrap(err, "failed to open store")
+	}
+	queue.SetStore(s)
+	if approvedByOperator(config) && bot != nil {
+		bot.Listen(telegramApprovals(s, withOverrides(provider, live)))
+	}
+
+	for _, bakerConfig := range config.Bakers() {
+		log.WithField("baker", bakerConfig.Baker.Address).Info("Paying out for baker.")
+
+		// A payout wallet that does not match its pinned address stops the server before any payout is queued
+		wallet, err := payout.CheckWallet(bakerConfig)
+		if err != nil {
+			return server{}, errors.Wrapf(err, "failed to check payout wallet of '%s'", bakerConfig.Baker.Address)
+		}
+
+		if config.Notifications.Rights.Before > 0 {
+			notifier.NewUpcomingRightsNotifier(notifier.UpcomingRightsNotifierInput{
+				Notifiers:   registry.Route(notifier.KindNotice),
+				RPCClient:   rpc,
+				Baker:       bakerConfig.Baker.Address,
+				Before:      config.Notifications.Rights.Before,
+				MaxPriority: config.Notifications.Rights.MaxPriority,
+			}).Start()
+		}
+
+		if config.Notifications.Rights.Missed {
+			notifier.NewMissedOpportunityNotifier(notifier.MissedOpportunityNotifierInput{
+				Notifiers: registry.Route(notifier.KindAlert),
+				RPCClient: rpc,
+				Baker:     bakerConfig.Baker.Address,
+			}).Start()
+		}
+
+		if config.Notifications.Deactivation.Cycles > 0 {
+			input := notifier.DeactivationNotifierInput{
+				Notifiers: registry.Route(notifier.KindAlert),
+				RPCClient: rpc,
+				Baker:     bakerConfig.Baker.Address,
+				Cycles:    config.Notifications.Deactivation.Cycles,
+			}
+			// The baker's own key only registers the primary baker
+			if config.Notifications.Deactivation.Register && bakerConfig.Baker.Address == config.Baker.Address {
+				bakerConfig := bakerConfig
+				input.Register = func() (string, error) {
+					return payout.Register(bakerConfig, rpc)
+				}
+			}
+			notifier.NewDeactivationNotifier(input).Start()
+		}
+
+		if config.Sync.Interval > 0 {
+			history.NewSyncer(history.SyncerInput{
+				RPC:       rpc,
+				Tzkt:      indexer.NewWithContext(ctx, config.API),
+				Store:     s,
+				Baker:     bakerConfig.Baker.Address,
+				FromCycle: config.Sync.FromCycle,
+				MaxCycles: config.Sync.MaxCycles,
+				Delay:     config.Sync.Delay,
+			}).Start(config.Sync.Interval)
+		}
+
+		if config.Books.Enabled {
+			books.NewReconciler(books.ReconcilerInput{
+				RPC:       rpc,
+				Tzkt:      indexer.NewWithContext(ctx, config.API),
+				Store:     s,
+				Config:    bakerConfig,
+				Wallet:    wallet,
+				Notifiers: registry.Route(notifier.KindAlert),
+			}).Start()
+		}
+
+		if config.Notifications.LowBalance.Enabled && config.Notifications.LowBalance.Interval > 0 {
+			payout.NewBalanceWatcher(payout.BalanceWatcherInput{
+				RPC:      rpc,
+				Store:    s,
+				Config:   bakerConfig,
+				Wallet:   wallet,
+				Notifier: &runner.notifier,
+			}).Start(config.Notifications.LowBalance.Interval)
+		}
+
+		if config.Notifications.Departures.Interval > 0 {
+			watcher, err := departures.NewWatcher(departures.WatcherInput{
+				RPC:      rpc,
+				Tzkt:     indexer.NewWithContext(ctx, config.API),
+				Store:    s,
+				Config:   bakerConfig,
+				Notifier: &runner.notifier,
+				Template: config.Notifications.Departures.Template,
+			})
+			if err != nil {
+				return server{}, errors.Wrap(err, "failed to start departures watcher")
+			}
+			watcher.Start(config.Notifications.Departures.Interval)
+		}
+	}
+
+	loaded, err := queue.Load(func(queued payout.Queued) (*payout.Payout, error) {
+		return rebuildPayout(ctx, provider.Apply(config), queued, verbose)
+	})
+	if err != nil {
+		return server{}, err
+	}
+	if loaded > 0 {
+		log.WithField("payouts", loaded).Info("Loaded payouts left in queue.")
+	}
+
+	quit := make(chan struct{})
+	var control *controller
+	if broadcaster != nil {
+		control = &controller{
+			ctx:         ctx,
+			Queue:       queue,
+			Broadcaster: broadcaster,
+			store:       s,
+			reloader:    reloader,
+			config:      withOverrides(provider, live),
+			verbose:     verbose,
+			quit:        quit,
+		}
+	}
+	if config.HTTP.Listen != "" {
+		input := api.ServerInput{
+			Store:  s,
+			RPC:    rpc,
+			Config: withOverrides(provider, live),
+			APIKey: config.HTTP.APIKey,
+			Public: config.HTTP.Public,
+		}
+		if control != nil && config.HTTP.Control {
+			input.Control = control
+		}
+		api.NewServer(input).Start(config.HTTP.Listen)
+	}
+	if config.HTTP.GRPCListen != "" {
+		grpcServer := api.NewGRPCServer(api.GRPCServerInput{
+			Control: control,
+			Config:  withOverrides(provider, live),
+			APIKey:  config.HTTP.APIKey,
+		})
+		if err := grpcServer.Start(config.HTTP.GRPCListen); err != nil {
+			return server{}, errors.Wrap(err, "failed to start gRPC control API")
+		}
+	}
 
 	log.Info("Starting tzpay payout server.")
 	queue.Start()
 
 	return server{
-		queue:     queue,
-		rpcClient: rpc,
-		cfg:       config,
-		runner:    runner,
+		ctx:        ctx,
+		queue:      queue,
+		rpcClient:  rpc,
+		cfg:        live,
+		reloader:   reloader,
+		overrides:  provider,
+		store:      s,
+		runner:     runner,
+		schedule:   sched,
+		inclusions: inclusions,
+		quit:       quit,
 	}, nil
 }
 
+// withOverrides returns a function returning the configuration of live with the overrides of provider in effect at the time
+func withOverrides(provider *overrides.Provider, live *liveConfig) func() config.Config {
+	return func() config.Config {
+		return provider.Apply(live.Get())
+	}
+}
+
+// controller controls the queue of the server through the control API
+type controller struct {
+	ctx context.Context
+	*payout.Queue
+	*notifier.Broadcaster
+	store    store.IFace
+	reloader *reloader
+	config   func() config.Config
+	verbose  bool
+	quit     chan struct{}
+	drain    sync.Once
+}
+
+// Trigger adds the payout of cycle for baker to the queue, as the server would once the cycle is due
+func (c *controller) Trigger(baker string, cycle int, partial bool) (payout.Queued, error) {
+	p, err := rebuildPayout(c.ctx, c.config(), payout.Queued{Baker: baker, Cycle: cycle, Partial: partial}, c.verbose)
+	if err != nil {
+		return payout.Queued{}, err
+	}
+
+	log.WithFields(log.Fields{"payout-cycle": cycle, "baker": baker, "partial": partial}).Info("Adding payout to queue.")
+	return c.Queue.Enqueue(*p), nil
+}
+
+// Approve approves the payout of baker held for cycle on behalf of approver
+func (c *controller) Approve(baker string, cycle int, approver string) (payout.Approval, error) {
+	return approveHeld(c.store, c.config(), baker, cycle, approver)
+}
+
+// Reload reloads the configuration of the server, and returns the variables changed that take a restart
+func (c *controller) Reload() ([]string, error) {
+	return c.reloader.Reload()
+}
+
+// Drain pauses the queue, and stops the server once the payouts being executed are done
+func (c *controller) Drain() {
+	c.drain.Do(func() {
+		go func() {
+			c.Queue.Drain()
+			log.Info("Queue drained.")
+			close(c.quit)
+		}()
+	})
+}
+
+// approveHeld approves the payout of baker of cfg held for cycle on behalf of approver, and records it in the audit log
+func approveHeld(s store.IFace, cfg config.Config, baker string, cycle int, approver string) (payout.Approval, error) {
+	bakerConfig, err := cfg.ForBaker(baker)
+	if err != nil {
+		return payout.Approval{}, err
+	}
+
+	approved, err := payout.ApproveHeld(s, bakerConfig, cycle, approver)
+	if err != nil {
+		return approved, err
+	}
+
+	err = audit.Record(s, audit.Event{
+		Action: "payout_approve",
+		Baker:  approved.Baker,
+		Details: map[string]string{
+			"cycle":    fmt.Sprintf("%d", cycle),
+			"approver": approved.Approver,
+			"payload":  approved.Payload,
+		},
+	})
+	if err != nil {
+		log.WithField("error", err.Error()).Error("Failed to record approval in audit log.")
+	}
+
+	return approved, nil
+}
+
+// telegramApprovals handles the replies of 'approve <cycle> [baker]' to the telegram bot, approving the payout held
+func telegramApprovals(s store.IFace, cfg func() config.Config) telegram.Handler {
+	return func(text string) (string, bool) {
+		fields := strings.Fields(text)
+		if len(fields) < 2 || len(fields) > 3 || strings.ToLower(fields[0]) != "approve" {
+			return "", false
+		}
+
+		cycle, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Sprintf("[TZPAY] invalid cycle '%s', reply approve <cycle> [baker].", fields[1]), true
+		}
+		var baker string
+		if len(fields) == 3 {
+			baker = fields[2]
+		}
+
+		approved, err := approveHeld(s, cfg(), baker, cycle, "telegram")
+		if err != nil {
+			return fmt.Sprintf("[TZPAY] %s", err.Error()), true
+		}
+
+		log.WithFields(log.Fields{"payout-cycle": cycle, "baker": approved.Baker, "approver": approved.Approver}).Info("Payout approved through telegram.")
+		return fmt.Sprintf("[TZPAY] approved payout for cycle %d (%s) of %d transfers of %d mutez, it is injected when the queue retries it.",
+			cycle, approved.Baker, approved.Transfers, approved.Amount), true
+	}
+}
+
+// approvedByOperator returns true if payouts of cfg can be held until the operator approves them, without approval keys
+func approvedByOperator(cfg config.Config) bool {
+	return len(cfg.Approval.Keys) == 0 && (cfg.Approval.Manual || cfg.Catchup.Confirm)
+}
+
+// rebuildPayout returns the payout persisted to the queue as queued, of a baker of cfg, bound to ctx
+func rebuildPayout(ctx context.Context, cfg config.Config, queued payout.Queued, verbose bool) (*payout.Payout, error) {
+	for _, bakerConfig := range cfg.Bakers() {
+		if bakerConfig.Baker.Address != queued.Baker {
+			continue
+		}
+
+		p, err := payout.NewWithContext(ctx, bakerConfig, queued.Cycle, true, verbose)
+		if err != nil {
+			return nil, err
+		}
+		if queued.Partial {
+			p.SetPartial()
+		}
+		if queued.Approval {
+			p.RequireApproval()
+		}
+
+		return p, nil
+	}
+
+	return nil, errors.Errorf("baker '%s' is no longer configured", queued.Baker)
+}
+
 // ServCommand returns a new run cobra command
 func ServCommand() *cobra.Command {
 	var verbose bool
@@ -65,8 +480,6 @@ func ServCommand() *cobra.Command {
 }
 
 func (s *server) start() {
-	quit := make(chan struct{})
-
 	block, err := s.rpcClient.Head()
 	if err != nil {
 		log.WithField("error", err.Error()).Fatal("Server failed to starting cycle.")
@@ -77,37 +490,261 @@ func (s *server) start() {
 		log.WithField("error", err.Error()).Fatal("Server failed to get network constants used for cycle math.")
 	}
 
+	s.catchUp(block, constants)
+	watchdog := s.notifyReady()
+	s.reloader.onHangup()
 	go func() {
+		protocol := block.Metadata.Protocol
 		currentCycle := block.Metadata.Level.Cycle
 		log.WithField("current-cycle", currentCycle).Info("Current cycle.")
-		ticker := time.NewTicker(time.Second * 30)
-		for range ticker.C {
+		partials := map[string]int{}
+		statuses := s.allBakers()
+		s.publishStatuses(statuses)
+		// The node announces every new head, the server polling for it only while the node cannot be monitored
+		heads := monitor.NewHeads(monitor.HeadsInput{Node: s.cfg.Get().API.Tezos}).Start()
+		for range heads {
+			if watchdog != nil {
+				watchdog.Alive()
+			}
+
 			b, err := s.rpcClient.Head()
 			if err != nil {
 				log.WithField("error", err.Error()).Warn("Server failed to get current cycle.")
 				continue
 			}
 			log.WithField("level", b.Header.Level).Debug("Found a new block.")
+			s.inclusions.Block(b)
+			constants, protocol = s.refreshConstants(b, constants, protocol)
+			if s.schedule != nil {
+				s.schedule.SetPosition(b.Metadata.Level.CyclePosition)
+			}
 
 			if currentCycle < b.Metadata.Level.Cycle {
 				log.WithFields(log.Fields{"current-cycle": b.Metadata.Level.Cycle, "last-cycle": currentCycle}).Info("New current cycle found.")
 
-				cycleToPayoutFor := currentCycle
-				if s.runner.config.Baker.PayoutWhenRewardsUnfrozen {
-					cycleToPayoutFor = b.Metadata.Level.Cycle - constants.PreservedCycles
-				}
+				for _, bakerConfig := range s.overrides.Apply(s.cfg.Get()).Bakers() {
+					cycleToPayoutFor := currentCycle
+					if bakerConfig.Baker.PayoutWhenRewardsUnfrozen {
+						cycleToPayoutFor = b.Metadata.Level.Cycle - constants.PreservedCycles
+					}
 
-				payout, err := payout.New(s.runner.config, cycleToPayoutFor, true, s.runner.verbose)
-				if err != nil {
-					log.WithFields(log.Fields{"error": err.Error(), "payout-cycle": cycleToPayoutFor}).Error("Failed to intialize payout.")
-					continue
+					payout, err := payout.NewWithContext(s.ctx, bakerConfig, cycleToPayoutFor, true, s.runner.verbose)
+					if err != nil {
+						log.WithFields(log.Fields{"error": err.Error(), "payout-cycle": cycleToPayoutFor, "baker": bakerConfig.Baker.Address}).Error("Failed to intialize payout.")
+						continue
+					}
+					log.WithFields(log.Fields{"payout-cycle": cycleToPayoutFor, "baker": bakerConfig.Baker.Address}).Info("Adding payout to queue.")
+					s.queue.Enqueue(*payout)
 				}
-				log.WithField("payout-cycle", cycleToPayoutFor).Info("Adding payout to queue.")
-				s.queue.Enqueue(*payout)
 				currentCycle = b.Metadata.Level.Cycle
+				partials = map[string]int{}
+				statuses = s.allBakers()
 			}
+
+			s.enqueuePartials(b, constants.BlocksPerCycle, partials)
+			s.publishStatuses(statuses)
 		}
 	}()
 
-	<-quit
+	select {
+	case <-s.quit:
+	case <-s.ctx.Done():
+		// The payouts being executed fail with their calls cancelled, and stay queued until the server is restarted
+		log.Info("Waiting for the payouts being executed to stop.")
+		s.queue.Drain()
+	}
+	log.Info("Stopping tzpay payout server.")
+	if _, err := systemd.Notify(systemd.Stopping); err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to notify systemd.")
+	}
+}
+
+/*
+notifyReady tells systemd the server is ready when run as a service of Type=notify, and starts pinging its watchdog
+when WatchdogSec is set, returning the watchdog for the server to show it is alive every block. A server that stops
+handling blocks is then restarted by systemd.
+*/
+func (s *server) notifyReady() *systemd.Watchdog {
+	if ok, err := systemd.Notify(systemd.Ready); err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to notify systemd.")
+	} else if ok {
+		log.Info("Notified systemd that the server is ready.")
+	}
+
+	interval, err := systemd.WatchdogInterval()
+	if err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to start systemd watchdog.")
+		return nil
+	} else if interval == 0 {
+		return nil
+	}
+
+	watchdog := systemd.NewWatchdog(interval)
+	watchdog.Start()
+
+	return watchdog
+}
+
+/*
+refreshConstants re-fetches the network constants once the protocol of block differs from protocol, the one they
+were fetched for, as a migration may change the length of cycles or the number of preserved cycles. The constants are
+kept as they were if they could not be fetched, to be fetched again on the next block.
+*/
+func (s *server) refreshConstants(block *rpc.Block, constants rpc.Constants, protocol string) (rpc.Constants, string) {
+	if block.Metadata.Protocol == protocol {
+		return constants, protocol
+	}
+
+	refreshed, err := s.rpcClient.Constants(block.Hash)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err.Error(), "protocol": block.Metadata.Protocol}).Warn("Server failed to refresh network constants after protocol change.")
+		return constants, protocol
+	}
+
+	log.WithFields(log.Fields{
+		"protocol":          block.Metadata.Protocol,
+		"previous-protocol": protocol,
+		"blocks-per-cycle":  refreshed.BlocksPerCycle,
+		"preserved-cycles":  refreshed.PreservedCycles,
+	}).Info("Protocol changed, refreshed network constants.")
+	return refreshed, block.Metadata.Protocol
+}
+
+// allBakers returns the set of the addresses of every baker paid out by the server
+func (s *server) allBakers() map[string]bool {
+	bakers := map[string]bool{}
+	for _, bakerConfig := range s.cfg.Get().Bakers() {
+		bakers[bakerConfig.Baker.Address] = true
+	}
+
+	return bakers
+}
+
+/*
+publishStatuses writes the public status page and exports the lookup page of the bakers in pending whose payouts all
+left the queue, so that the pages of a new cycle show the payout of the cycle that just ended, and removes them from
+pending. A page that could not be written stays pending, to be written again on the next block.
+*/
+func (s *server) publishStatuses(pending map[string]bool) {
+	cfg := s.cfg.Get()
+	if (cfg.Status.Dir == "" && cfg.Lookup.Dir == "") || len(pending) == 0 {
+		return
+	}
+
+	queued := map[string]bool{}
+	for _, baker := range s.queue.Bakers() {
+		queued[baker] = true
+	}
+
+	for _, bakerConfig := range s.overrides.Apply(cfg).Bakers() {
+		address := bakerConfig.Baker.Address
+		if !pending[address] || queued[address] {
+			continue
+		}
+
+		if cfg.Status.Dir != "" {
+			st, err := status.Generate(status.GenerateInput{
+				RPC:    s.rpcClient,
+				Store:  s.store,
+				Config: bakerConfig,
+				Now:    time.Now(),
+			})
+			if err == nil {
+				err = status.Write(cfg.Status.Dir, st)
+			}
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "baker": address}).Warn("Failed to publish status page.")
+				continue
+			}
+			log.WithFields(log.Fields{"baker": address, "dir": cfg.Status.Dir}).Info("Published status page.")
+		}
+
+		if cfg.Lookup.Dir != "" {
+			exported, err := lookup.Write(cfg.Lookup.Dir, s.store, address)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "baker": address}).Warn("Failed to export lookup page.")
+				continue
+			}
+			log.WithFields(log.Fields{"baker": address, "dir": cfg.Lookup.Dir, "delegators": exported}).Info("Exported lookup page.")
+		}
+
+		delete(pending, address)
+	}
+}
+
+/*
+enqueuePartials adds the partial payouts of the cycle in progress that are due to the queue. The partial payouts of a
+baker are spread evenly over the cycle, and partials holds how many of them were already queued for each baker.
+*/
+func (s *server) enqueuePartials(block *rpc.Block, blocksPerCycle int, partials map[string]int) {
+	if blocksPerCycle == 0 {
+		return
+	}
+
+	for _, bakerConfig := range s.overrides.Apply(s.cfg.Get()).Bakers() {
+		address := bakerConfig.Baker.Address
+		due := block.Metadata.Level.CyclePosition * (bakerConfig.Baker.PartialPayouts + 1) / blocksPerCycle
+		if due <= partials[address] || due > bakerConfig.Baker.PartialPayouts {
+			continue
+		}
+		partials[address] = due
+
+		payout, err := payout.NewWithContext(s.ctx, bakerConfig, block.Metadata.Level.Cycle, true, s.runner.verbose)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err.Error(), "payout-cycle": block.Metadata.Level.Cycle, "baker": address}).Error("Failed to intialize partial payout.")
+			continue
+		}
+		payout.SetPartial()
+
+		log.WithFields(log.Fields{"payout-cycle": block.Metadata.Level.Cycle, "baker": address, "partial": due}).Info("Adding partial payout to queue.")
+		s.queue.Enqueue(*payout)
+	}
+}
+
+/*
+catchUp adds the payouts of the cycles missed while the server was down to the queue, the cycles of every baker still
+within the preserved cycles that were never paid. A baker that missed more than the cycles allowed is only reported,
+for the operator to pay them by hand.
+*/
+func (s *server) catchUp(block *rpc.Block, constants rpc.Constants) {
+	cfg := s.cfg.Get()
+	if cfg.Catchup.MaxCycles == 0 || s.store == nil {
+		return
+	}
+
+	for _, bakerConfig := range s.overrides.Apply(cfg).Bakers() {
+		address := bakerConfig.Baker.Address
+		last := block.Metadata.Level.Cycle - 1
+		if bakerConfig.Baker.PayoutWhenRewardsUnfrozen {
+			last = block.Metadata.Level.Cycle - constants.PreservedCycles
+		}
+
+		unpaid, err := payout.UnpaidCycles(s.store, address, last-constants.PreservedCycles+1, last)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err.Error(), "baker": address}).Error("Failed to find missed payouts.")
+			continue
+		} else if len(unpaid) > cfg.Catchup.MaxCycles {
+			msg := fmt.Sprintf("[TZPAY] ALERT %d cycles of %s were never paid, more than the %d caught up automatically: %v",
+				len(unpaid), address, cfg.Catchup.MaxCycles, unpaid)
+			if err := s.runner.notifier.NotifyKind(notifier.KindAlert, msg); err != nil {
+				log.WithField("error", err.Error()).Error("Failed to notify.")
+			}
+			log.WithFields(log.Fields{"baker": address, "cycles": unpaid}).Warn("Too many missed payouts to catch up, pay them by hand.")
+			continue
+		}
+
+		for _, cycle := range unpaid {
+			p, err := payout.NewWithContext(s.ctx, bakerConfig, cycle, true, s.runner.verbose)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "payout-cycle": cycle, "baker": address}).Error("Failed to intialize payout.")
+				continue
+			}
+			if cfg.Catchup.Confirm {
+				p.RequireApproval()
+			}
+
+			log.WithFields(log.Fields{"payout-cycle": cycle, "baker": address}).Info("Adding missed payout to queue.")
+			s.queue.Enqueue(*p)
+		}
+	}
 }
@@ -20,16 +20,130 @@ func NewSetupCommand() *cobra.Command {
 			sb.WriteString("TZPAY_BAKER_FEE=<TODO (e.g. 0.05 for 5%)>\n")
 			sb.WriteString("TZPAY_WALLET_ESK=<TODO (e.g. edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2)>\n")
 			sb.WriteString("TZPAY_WALLET_PASSWORD=<TODO (e.g. password12345##)>\n")
+			sb.WriteString("TZPAY_WALLET_PASSWORD_FILE=<TODO (e.g. /run/secrets/tzpay_password, instead of TZPAY_WALLET_PASSWORD)>\n")
 			sb.WriteString("###### OPTIONAL ENVIROMENT VARIABLES ######\n")
+			sb.WriteString("TZPAY_WALLET_ADDRESS=<TODO (e.g. tz1... of the payout wallet)>\n")
+			sb.WriteString("TZPAY_WALLET_SIGNER=<TODO (e.g. http://localhost:6732, instead of TZPAY_WALLET_ESK)>\n")
+			sb.WriteString("TZPAY_WALLET_SIGNER_HEADERS=<TODO (e.g. Authorization:Bearer token)>\n")
+			sb.WriteString("TZPAY_WALLET_SIGNER_TIMEOUT=<TODO (e.g. 10s)>\n")
+			sb.WriteString("TZPAY_WALLET_KMS_KEY_ID=<TODO (e.g. alias/tzpay, instead of TZPAY_WALLET_ESK)>\n")
+			sb.WriteString("TZPAY_WALLET_KMS_REGION=<TODO (e.g. eu-west-1)>\n")
+			sb.WriteString("TZPAY_WALLET_PKCS11_MODULE=<TODO (e.g. /usr/lib/softhsm/libsofthsm2.so, instead of TZPAY_WALLET_ESK)>\n")
+			sb.WriteString("TZPAY_WALLET_PKCS11_SLOT=<TODO (e.g. 0)>\n")
+			sb.WriteString("TZPAY_WALLET_PKCS11_PIN=<TODO (e.g. 1234)>\n")
+			sb.WriteString("TZPAY_WALLET_PKCS11_LABEL=<TODO (e.g. payout)>\n")
+			sb.WriteString("TZPAY_WALLET_EXTERNAL_DIR=<TODO (e.g. /var/lib/tzpay/requests, instead of TZPAY_WALLET_ESK)>\n")
+			sb.WriteString("TZPAY_WALLET_EXTERNAL_URL=<TODO (e.g. https://custody.example.com/requests, instead of TZPAY_WALLET_ESK)>\n")
+			sb.WriteString("TZPAY_WALLET_EXTERNAL_WAIT=<TODO (e.g. 1h)>\n")
+			sb.WriteString("TZPAY_FUND_WALLET=<TODO (e.g. True)>\n")
 			sb.WriteString("TZPAY_BAKER_MINIMUM_PAYMENT=<TODO (e.g. MUTEZ 10000)>\n")
 			sb.WriteString("TZPAY_BAKER_EARNINGS_ONLY=<TODO (e.g. True)>\n")
 			sb.WriteString("TZPAY_BAKER_BLACK_LIST=<TODO (e.g. KT19Aro5JcjKH7J7RA6sCRihPiBQzQED3oQC, KT1CQiyDJ3mMVDoEqLY8Fz1onFXo5ycp5BDN)>\n")
 			sb.WriteString("TZPAY_BAKER_LIQUIDITY_CONTRACTS=<TODO (e.g. KT19Aro5JcjKH7J7RA6sCRihPiBQzQED3oQC, KT1CQiyDJ3mMVDoEqLY8Fz1onFXo5ycp5BDN)>\n")
+			sb.WriteString("TZPAY_BAKER_EXCLUDE_CONTRACTS=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_BAKER_CHECK_CONTRACTS=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_BAKER_CONTRACT_FALLBACK_ADDRESS=<TODO (e.g. tz1...)>\n")
 			sb.WriteString("TZPAY_API_TZKT=<TODO (e.g. https://api.tzkt.io )>\n")
 			sb.WriteString("TZPAY_API_TEZOS=<TODO (e.g. https://tezos.giganode.io/)>\n")
+			sb.WriteString("TZPAY_API_INDEXER=<TODO (e.g. tzstats)>\n")
+			sb.WriteString("TZPAY_API_TZSTATS=<TODO (e.g. https://api.tzstats.com)>\n")
+			sb.WriteString("TZPAY_API_TIMEOUT=<TODO (e.g. 30s)>\n")
+			sb.WriteString("TZPAY_API_CONCURRENCY=<TODO (e.g. 8)>\n")
 			sb.WriteString("TZPAY_OPERATIONS_NETWORK_FEE=<TODO (e.g. 2941)>\n")
 			sb.WriteString("TZPAY_OPERATIONS_GAS_LIMIT=<TODO (e.g. 26283)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_ESTIMATE=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_ESTIMATE_MARGIN=<TODO (e.g. 10)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_BUMP_AFTER_BLOCKS=<TODO (e.g. 5)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_BUMP_ATTEMPTS=<TODO (e.g. 3)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_BUMP_INCREMENT=<TODO (e.g. MUTEZ 1000)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_CONFIRMATIONS=<TODO (e.g. 2)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_SPENDING_CAP=<TODO (e.g. MUTEZ 500000000)>\n")
+			sb.WriteString("TZPAY_OPERATIONS_MAX_ATTEMPTS=<TODO (e.g. 60)>\n")
+			sb.WriteString("TZPAY_APPROVAL_KEYS=<TODO (e.g. edpk..., edpk...)>\n")
+			sb.WriteString("TZPAY_APPROVAL_MANUAL=<TODO (e.g. True)>\n")
 			sb.WriteString("TZPAY_OPERATIONS_BATCH_SIZE=<TODO (e.g. 125)>\n")
+			sb.WriteString("TZPAY_DELEGATES_FILE=<TODO (e.g. /etc/tzpay/delegates.json)>\n")
+			sb.WriteString("TZPAY_BAKER_ACTUAL_REWARDS=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_CROSS_CHECK=<TODO (e.g. refuse)>\n")
+			sb.WriteString("TZPAY_CROSS_CHECK_TOLERANCE=<TODO (e.g. 1)>\n")
+			sb.WriteString("TZPAY_BAKER_DENUNCIATION_POLICY=<TODO (e.g. reduce)>\n")
+			sb.WriteString("TZPAY_BAKER_ROUNDING=<TODO (e.g. round)>\n")
+			sb.WriteString("TZPAY_BAKER_DISTRIBUTE_REMAINDER=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_BAKER_CONSOLIDATE_MANAGERS=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_BAKER_DONATION_PERCENTAGE=<TODO (e.g. 10)>\n")
+			sb.WriteString("TZPAY_BAKER_DONATION_ADDRESS=<TODO (e.g. tz1...)>\n")
+			sb.WriteString("TZPAY_BAKER_BOND_POOL=<TODO (e.g. tz1member:60,tz1other:40)>\n")
+			sb.WriteString("TZPAY_BAKER_SWEEP_ADDRESS=<TODO (e.g. tz1coldstorage)>\n")
+			sb.WriteString("TZPAY_BAKER_SWEEP_FLOAT=<TODO (e.g. MUTEZ 10000000)>\n")
+			sb.WriteString("TZPAY_BAKER_PAYOUT_SCHEDULES=<TODO (e.g. tz1...:weekly,tz1...:monthly)>\n")
+			sb.WriteString("TZPAY_BAKER_PAYOUT_ADDRESSES=<TODO (e.g. tz1delegator:tz1coldwallet)>\n")
+			sb.WriteString("TZPAY_BAKER_FEES=<TODO (e.g. tz1delegator:0.02)>\n")
+			sb.WriteString("TZPAY_BAKER_CAMPAIGNS=<TODO (e.g. 300-310:0.02,311-320:x1.1:20)>\n")
+			sb.WriteString("TZPAY_OVERRIDES_URL=<TODO (e.g. https://docs.google.com/spreadsheets/d/.../edit#gid=0)>\n")
+			sb.WriteString("TZPAY_OVERRIDES_INTERVAL=<TODO (e.g. 10m)>\n")
+			sb.WriteString("TZPAY_BAKER_PARTIAL_PAYOUTS=<TODO (e.g. 3)>\n")
+			sb.WriteString("TZPAY_BAKER_ACCUMULATE_THRESHOLD=<TODO (e.g. MUTEZ 100000)>\n")
+			sb.WriteString("TZPAY_STORE_PATH=<TODO (e.g. /var/lib/tzpay/tzpay.json)>\n")
+			sb.WriteString("TZPAY_STORE_KEY=<TODO (e.g. a long random passphrase)>\n")
+			sb.WriteString("TZPAY_REDACT_FIELDS=<TODO (e.g. delegator,amount)>\n")
+			sb.WriteString("TZPAY_REDACT_ADDRESSES=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_NOTIFY_RIGHTS_BEFORE=<TODO (e.g. 30m)>\n")
+			sb.WriteString("TZPAY_NOTIFY_RIGHTS_MAX_PRIORITY=<TODO (e.g. 0)>\n")
+			sb.WriteString("TZPAY_NOTIFY_MISSED_RIGHTS=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_NOTIFY_DIGEST_INTERVAL=<TODO (e.g. 24h)>\n")
+			sb.WriteString("TZPAY_NOTIFY_RATE_LIMIT=<TODO (e.g. 10)>\n")
+			sb.WriteString("TZPAY_NOTIFY_RATE_LIMIT_PERIOD=<TODO (e.g. 1h)>\n")
+			sb.WriteString("TZPAY_NOTIFY_ROUTES=<TODO (e.g. alert:twilio+telegram,payout:twitter)>\n")
+			sb.WriteString("TZPAY_NOTIFY_PAYOUT_TEMPLATE=<TODO (e.g. Paid {{tez .Amount}} XTZ for cycle {{.Cycle}})>\n")
+			sb.WriteString("TZPAY_NOTIFY_MESSAGE_TEMPLATE=<TODO (e.g. {{.Text}} - My Baker)>\n")
+			sb.WriteString("TZPAY_TELEGRAM_TOKEN=<TODO (e.g. 123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11)>\n")
+			sb.WriteString("TZPAY_TELEGRAM_CHAT_ID=<TODO (e.g. -1001234567890)>\n")
+			sb.WriteString("TZPAY_TELEGRAM_CONFIRM=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_TELEGRAM_CONFIRM_TIMEOUT=<TODO (e.g. 1h)>\n")
+			sb.WriteString("TZPAY_DISCORD_WEBHOOK_URL=<TODO (e.g. https://discord.com/api/webhooks/123456789/abcdef)>\n")
+			sb.WriteString("TZPAY_DISCORD_USERNAME=<TODO (e.g. tzpay)>\n")
+			sb.WriteString("TZPAY_WEBHOOK_URL=<TODO (e.g. https://hooks.example.com/tzpay)>\n")
+			sb.WriteString("TZPAY_WEBHOOK_HEADERS=<TODO (e.g. Authorization:Bearer token)>\n")
+			sb.WriteString("TZPAY_WEBHOOK_EVENTS=<TODO (e.g. injected,failed)>\n")
+			sb.WriteString("TZPAY_EMAIL_HOST=<TODO (e.g. smtp.example.com)>\n")
+			sb.WriteString("TZPAY_EMAIL_USERNAME=<TODO (e.g. tzpay@example.com)>\n")
+			sb.WriteString("TZPAY_EMAIL_PASSWORD=<TODO (e.g. password)>\n")
+			sb.WriteString("TZPAY_EMAIL_FROM=<TODO (e.g. tzpay@example.com)>\n")
+			sb.WriteString("TZPAY_EMAIL_RECEIPTS=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_EMAIL_DELEGATORS=<TODO (e.g. tz1...:alice@example.com)>\n")
+			sb.WriteString("TZPAY_RECEIPTS_SIGN=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_NOTIFY_DEACTIVATION_CYCLES=<TODO (e.g. 3)>\n")
+			sb.WriteString("TZPAY_REGISTER_WHEN_DEACTIVATED=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_BAKER_ESK=<TODO (e.g. edesk...)>\n")
+			sb.WriteString("TZPAY_BAKER_PASSWORD=<TODO (e.g. password)>\n")
+			sb.WriteString("TZPAY_NOTIFY_LOW_BALANCE=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_NOTIFY_LOW_BALANCE_BUFFER=<TODO (e.g. 10000000)>\n")
+			sb.WriteString("TZPAY_NOTIFY_LOW_BALANCE_INTERVAL=<TODO (e.g. 1h)>\n")
+			sb.WriteString("TZPAY_NOTIFY_DEPARTURES_INTERVAL=<TODO (e.g. 10m)>\n")
+			sb.WriteString("TZPAY_NOTIFY_DEPARTURES_TEMPLATE=<TODO (e.g. {{.Address}} is owed {{.PendingTez}} XTZ)>\n")
+			sb.WriteString("TZPAY_INSURANCE_THRESHOLD=<TODO (e.g. 10)>\n")
+			sb.WriteString("TZPAY_INSURANCE_WALLET_ESK=<TODO (e.g. edesk...)>\n")
+			sb.WriteString("TZPAY_INSURANCE_WALLET_PASSWORD=<TODO (e.g. password)>\n")
+			sb.WriteString("TZPAY_SYNC_INTERVAL=<TODO (e.g. 1h)>\n")
+			sb.WriteString("TZPAY_SYNC_FROM_CYCLE=<TODO (e.g. 200)>\n")
+			sb.WriteString("TZPAY_SYNC_MAX_CYCLES=<TODO (e.g. 20)>\n")
+			sb.WriteString("TZPAY_SYNC_DELAY=<TODO (e.g. 1s)>\n")
+			sb.WriteString("TZPAY_BOOKS_ENABLED=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_STATUS_DIR=<TODO (e.g. /var/www/status)>\n")
+			sb.WriteString("TZPAY_LOOKUP_DIR=<TODO (e.g. /var/www/lookup)>\n")
+			sb.WriteString("TZPAY_HTTP_LISTEN=<TODO (e.g. :8080)>\n")
+			sb.WriteString("TZPAY_HTTP_API_KEY=<TODO (e.g. some_api_key)>\n")
+			sb.WriteString("TZPAY_HTTP_CONTROL=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_HTTP_PUBLIC=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_HTTP_GRPC_LISTEN=<TODO (e.g. :9090)>\n")
+			sb.WriteString("TZPAY_SCHEDULING_CRON=<TODO (e.g. 0 3 * * *)>\n")
+			sb.WriteString("TZPAY_SCHEDULING_WINDOWS=<TODO (e.g. 02:00-06:00)>\n")
+			sb.WriteString("TZPAY_SCHEDULING_SKIP_BLOCKS=<TODO (e.g. 10)>\n")
+			sb.WriteString("TZPAY_CATCHUP_MAX_CYCLES=<TODO (e.g. 3)>\n")
+			sb.WriteString("TZPAY_CATCHUP_CONFIRM=<TODO (e.g. True)>\n")
+			sb.WriteString("TZPAY_ARCHIVE_DIR=<TODO (e.g. /var/lib/tzpay/archive)>\n")
+			sb.WriteString("TZPAY_PRICE_CURRENCY=<TODO (e.g. EUR)>\n")
+			sb.WriteString("TZPAY_PRICE_SOURCE=<TODO (e.g. coingecko)>\n")
 			fmt.Println(sb.String())
 		},
 	}
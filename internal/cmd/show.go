@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/print"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ShowCommand returns the cobra command for show
+func ShowCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var cycle int
+	var dir string
+
+	var show = &cobra.Command{
+		Use:   "show",
+		Short: "show prints the payouts archived for a cycle",
+		Long: `show prints every payout of --cycle archived to TZPAY_ARCHIVE_DIR, oldest first, with every delegation computed, the
+bytes of the operations forged and the error of a payout that failed.`,
+		Example: `tzpay show --cycle 300
+tzpay show --cycle 300 --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if dir == "" {
+				dir = config.Archive.Dir
+			}
+			if dir == "" {
+				log.Fatal("Failed to show payouts: set --dir or TZPAY_ARCHIVE_DIR.")
+			}
+
+			archives, err := payout.ReadArchive(dir, config.Baker.Address, cycle)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to read archive.")
+			}
+			if len(archives) == 0 {
+				log.WithFields(log.Fields{"baker": config.Baker.Address, "cycle": cycle}).Fatal("No payout archived for cycle.")
+			}
+
+			if table {
+				for _, archive := range archives {
+					fmt.Printf("%s payout of %s (%s)\n", archive.Time.Format("2006-01-02 15:04:05 MST"), archive.Wallet, archiveOutcome(archive))
+					print.Table(archive.Cycle, archive.Baker, archive.Payout)
+				}
+				return
+			}
+
+			prettyJSON, err := json.MarshalIndent(archives, "", "  ")
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	show.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	show.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to show the payouts of when multiple bakers are configured (Default: primary baker)")
+	show.PersistentFlags().IntVarP(&cycle, "cycle", "c", 0, "the cycle to show the payouts of")
+	show.PersistentFlags().StringVarP(&dir, "dir", "d", "", "the archive directory (Default: TZPAY_ARCHIVE_DIR)")
+	show.MarkPersistentFlagRequired("cycle")
+
+	return show
+}
+
+func archiveOutcome(archive payout.Archive) string {
+	switch {
+	case archive.Error != "":
+		return "failed: " + archive.Error
+	case archive.Partial:
+		return "partial"
+	default:
+		return "paid"
+	}
+}
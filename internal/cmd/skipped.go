@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// SkippedCommand returns the cobra command for skipped
+func SkippedCommand() *cobra.Command {
+	var cycle int
+	var table bool
+	var baker string
+
+	var skipped = &cobra.Command{
+		Use:     "skipped",
+		Short:   "skipped lists the delegators a payout will not pay",
+		Long:    "skipped simulates a payout and lists every delegator that will not be paid with the reason why",
+		Example: `tzpay skipped --cycle <cycle>`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cycle == 0 {
+				log.Fatal("Missing cycle flag.")
+			}
+
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			// Clear sensitive data if loaded
+			config.Key.Password = ""
+			config.Key.Esk = ""
+
+			p, err := payout.NewWithContext(interruptContext(), config, cycle, false, false)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
+			}
+
+			rewardsSplit, err := p.Execute()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to execute payout.")
+			}
+
+			if rewardsSplit.Skipped {
+				log.WithField("cycle", cycle).Warn("Every delegator is skipped because the baker was denounced.")
+				return
+			}
+
+			if table {
+				printSkippedTable(cycle, config.Baker.Address, payout.Skipped(rewardsSplit))
+				return
+			}
+
+			prettyJSON, err := json.Marshal(payout.Skipped(rewardsSplit))
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			log.WithField("skipped", string(prettyJSON)).Info("Skipped delegators for cycle.")
+		},
+	}
+
+	skipped.PersistentFlags().IntVarP(&cycle, "cycle", "c", 0, "the cycle to list skipped delegators for")
+	skipped.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	skipped.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to list skipped delegators for when multiple bakers are configured (Default: primary baker)")
+
+	return skipped
+}
+
+func printSkippedTable(cycle int, delegate string, skipped []payout.SkippedDelegator) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Cycle", "Baker", "Delegation", "Contract", "Net", "Reason"})
+	for _, delegator := range skipped {
+		table.Append([]string{
+			strconv.Itoa(cycle),
+			delegate,
+			delegator.Address,
+			delegator.Contract,
+			fmt.Sprintf("%.6f", float64(delegator.NetRewards)/float64(gotezos.MUTEZ)),
+			delegator.Reason,
+		})
+	}
+
+	table.Render()
+}
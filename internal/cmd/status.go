@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/goat-systems/tzpay/v3/internal/status"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// StatusCommand returns the cobra command for status
+func StatusCommand() *cobra.Command {
+	var dir string
+	var baker string
+
+	var st = &cobra.Command{
+		Use:     "status",
+		Short:   "status generates the public status page of a baker",
+		Long:    "status generates the public status of a baker's payouts as JSON and HTML, to host on the baker's website. tzpay serv refreshes it after every cycle when TZPAY_STATUS_DIR is set.",
+		Example: `tzpay status --dir /var/www/status`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			client, err := node.New(interruptContext(), config.API)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to connect to tezos rpc.")
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			generated, err := status.Generate(status.GenerateInput{
+				RPC:    client,
+				Store:  s,
+				Config: config,
+				Now:    time.Now(),
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate status.")
+			}
+
+			if dir == "" {
+				dir = config.Status.Dir
+			}
+			if dir == "" {
+				js, err := json.MarshalIndent(generated, "", "  ")
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to export status.")
+				}
+				fmt.Println(string(js))
+				return
+			}
+
+			if err := status.Write(dir, generated); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to write status.")
+			}
+			log.WithFields(log.Fields{"baker": generated.Baker, "dir": dir}).Info("Published status page.")
+		},
+	}
+
+	st.PersistentFlags().StringVarP(&dir, "dir", "d", "", "the directory to write <baker>.json and <baker>.html to (Default: TZPAY_STATUS_DIR, or JSON to stdout)")
+	st.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to generate the status of when multiple bakers are configured (Default: primary baker)")
+
+	return st
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/goat-systems/tzpay/v3/internal/redact"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/support"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// SupportBundleCommand returns the cobra command for support-bundle
+func SupportBundleCommand() *cobra.Command {
+	var output string
+	var logs string
+	var lines int
+	var keepAddresses bool
+
+	var bundle = &cobra.Command{
+		Use:   "support-bundle",
+		Short: "support-bundle gathers diagnostics to attach to a bug report",
+		Long: `support-bundle writes an archive holding the configuration without its secrets, the last lines of a log file,
+a summary of the store, the state of the tezos node and tzkt, and the version of tzpay. Delegator addresses are masked
+unless --keep-addresses is passed.`,
+		Example: `tzpay support-bundle --logs /var/log/tzpay.log`,
+		Run: func(cmd *cobra.Command, args []string) {
+			input := support.BundleInput{
+				Version:  support.Version{Version: version, Changed: changed},
+				LogLines: lines,
+			}
+
+			// The bundle is most useful when something is broken, so whatever fails to load is reported in it
+			cfg, err := newConfig()
+			if err != nil {
+				input.ConfigErr = err
+				input.Redactor = redact.New(nil, !keepAddresses)
+			} else {
+				input.Config = &cfg
+				input.Redactor = newRedactor(cfg)
+				if !keepAddresses {
+					var bakers []string
+					for _, bakerConfig := range cfg.Bakers() {
+						bakers = append(bakers, bakerConfig.Baker.Address, bakerConfig.Key.Address)
+					}
+					input.Redactor = redact.New(cfg.Redaction.Fields, true, bakers...)
+				}
+
+				if input.RPC, err = node.New(interruptContext(), cfg.API); err != nil {
+					input.RPC, input.RPCErr = nil, err
+				}
+				input.Tzkt = indexer.NewWithContext(interruptContext(), cfg.API)
+
+				if _, err := os.Stat(cfg.Store.Path); err == nil {
+					if s, err := store.New(cfg.Store.Path, cfg.Store.Key); err != nil {
+						input.StoreErr = err
+					} else {
+						input.Store = s
+					}
+				}
+			}
+
+			if logs != "" {
+				file, err := os.Open(logs)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to open logs.")
+				}
+				defer file.Close()
+				input.Logs = file
+			}
+
+			if output == "" {
+				output = fmt.Sprintf("tzpay-support-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+			}
+
+			if err := writeBundle(output, input); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to write support bundle.")
+			}
+
+			log.WithField("bundle", output).Info("Wrote support bundle, check its content before sharing it.")
+		},
+	}
+
+	bundle.PersistentFlags().StringVarP(&output, "output", "o", "", "the file to write the bundle to (Default: tzpay-support-<time>.tar.gz)")
+	bundle.PersistentFlags().StringVarP(&logs, "logs", "l", "", "a file holding tzpay's logs to include")
+	bundle.PersistentFlags().IntVarP(&lines, "lines", "n", 1000, "the number of log lines to include, starting with the most recent")
+	bundle.PersistentFlags().BoolVarP(&keepAddresses, "keep-addresses", "k", false, "keeps delegator addresses in the bundle")
+
+	return bundle
+}
+
+func writeBundle(path string, input support.BundleInput) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := support.WriteBundle(file, input); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package cmd
+
+import "github.com/pkg/errors"
+
+// readPassword is not supported without a unix terminal
+func readPassword(prompt string) (string, error) {
+	return "", errors.New("prompting for a password is not supported on this platform")
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// ThresholdsCommand returns the cobra command for thresholds
+func ThresholdsCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var cycle int
+	var cycles int
+	var from int
+	var to int
+	var step int
+
+	var thresholds = &cobra.Command{
+		Use:   "thresholds",
+		Short: "thresholds analyzes minimum payments over recent cycles",
+		Long: `thresholds replays the payouts of the last --cycles cycles under every minimum payment from --from to --to by --step (MUTEZ),
+and reports for each how many delegators and transfers would have been skipped, the rewards left unpaid, and the network fees saved,
+to choose TZPAY_BAKER_MINIMUM_PAYMENT from data. The minimum payment configured is always analyzed.`,
+		Example: `tzpay thresholds --cycles 10 --from 0 --to 500000 --step 50000 --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if step <= 0 || from < 0 || to < from || cycles <= 0 {
+				log.Fatal("Invalid range, expected --cycles and --step above 0 and 0 <= --from <= --to.")
+			}
+
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if cycle == 0 {
+				client, err := node.New(interruptContext(), config.API)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to connect to tezos rpc.")
+				}
+
+				head, err := client.Head()
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to get head.")
+				}
+
+				// The last cycle paid out is the one before the next payout
+				cycle = head.Metadata.Level.Cycle - 1
+				if config.Baker.PayoutWhenRewardsUnfrozen {
+					constants, err := client.Constants(head.Hash)
+					if err != nil {
+						log.WithField("error", err.Error()).Fatal("Failed to get constants.")
+					}
+					cycle = head.Metadata.Level.Cycle - constants.PreservedCycles
+				}
+			}
+
+			// Every delegator is paid in the payouts replayed, whatever the minimum payment configured
+			minimum := config.Baker.MinimumPayment
+			config.Baker.MinimumPayment = 0
+			config.Key.Password = ""
+			config.Key.Esk = ""
+
+			var rewardsSplits []tzkt.RewardsSplit
+			for c := cycle - cycles + 1; c <= cycle; c++ {
+				p, err := payout.NewWithContext(interruptContext(), config, c, false, false)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
+				}
+
+				rewardsSplit, err := p.Execute()
+				if err != nil {
+					log.WithFields(log.Fields{"error": err.Error(), "cycle": c}).Fatal("Failed to execute payout.")
+				}
+				rewardsSplits = append(rewardsSplits, rewardsSplit)
+			}
+
+			values := []int{minimum}
+			for threshold := from; threshold <= to; threshold += step {
+				if threshold != minimum {
+					values = append(values, threshold)
+				}
+			}
+			analyses := payout.AnalyzeThresholds(rewardsSplits, values, config.Operations.NetworkFee)
+
+			if table {
+				printThresholdsTable(cycle-cycles+1, cycle, minimum, analyses)
+				return
+			}
+
+			prettyJSON, err := json.Marshal(analyses)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	thresholds.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	thresholds.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to analyze when multiple bakers are configured (Default: primary baker)")
+	thresholds.PersistentFlags().IntVarP(&cycle, "cycle", "c", 0, "the last cycle to analyze (Default: the last cycle paid out)")
+	thresholds.PersistentFlags().IntVarP(&cycles, "cycles", "n", 5, "the number of cycles to analyze")
+	thresholds.PersistentFlags().IntVar(&from, "from", 0, "the lowest minimum payment to analyze (MUTEZ)")
+	thresholds.PersistentFlags().IntVar(&to, "to", 1000000, "the highest minimum payment to analyze (MUTEZ)")
+	thresholds.PersistentFlags().IntVar(&step, "step", 100000, "the step between the minimum payments analyzed (MUTEZ)")
+
+	return thresholds
+}
+
+func printThresholdsTable(first, last, minimum int, analyses []payout.ThresholdAnalysis) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Minimum Payment", "Delegators Skipped", "Transfers Skipped", "Ratio", "Withheld", "Fees Saved"})
+	table.SetCaption(true, fmt.Sprintf("Cycles %d to %d, * is the minimum payment configured", first, last))
+	for _, analysis := range analyses {
+		threshold := fmt.Sprintf("%.6f", float64(analysis.MinimumPayment)/float64(gotezos.MUTEZ))
+		if analysis.MinimumPayment == minimum {
+			threshold += " *"
+		}
+
+		table.Append([]string{
+			threshold,
+			strconv.Itoa(analysis.Delegators),
+			strconv.Itoa(analysis.Skipped),
+			fmt.Sprintf("%.1f%%", analysis.SkippedRatio*100),
+			fmt.Sprintf("%.6f", float64(analysis.Withheld)/float64(gotezos.MUTEZ)),
+			fmt.Sprintf("%.6f", float64(analysis.FeeSavings)/float64(gotezos.MUTEZ)),
+		})
+	}
+	table.Render()
+}
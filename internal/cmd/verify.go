@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// VerifyCommand returns the cobra command for verify
+func VerifyCommand() *cobra.Command {
+	var table bool
+	var baker string
+	var wallet string
+	var cycle int
+
+	var verify = &cobra.Command{
+		Use:   "verify",
+		Short: "verify cross-checks the payout of a cycle recorded in the store against the chain",
+		Long: `verify scans the transactions the payout wallet sent for --cycle, as seen by TZPAY_API_TZKT, and reports every
+delegator the store records as paid without a matching transaction, paid another amount than recorded, every
+transaction to an address the store does not record as paid, and every operation recorded that is not on chain. It
+exits with an error if the store and the chain disagree.`,
+		Example: `tzpay verify --cycle 300
+tzpay verify --cycle 300 --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			config, err = config.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			if wallet == "" {
+				if wallet, err = payout.WalletAddress(config); err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to get payout wallet address.")
+				}
+			}
+
+			s, err := store.New(config.Store.Path, config.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			verification, err := payout.Verify(payout.VerifyInput{
+				Store:  s,
+				Tzkt:   indexer.NewWithContext(interruptContext(), config.API),
+				Config: config,
+				Wallet: wallet,
+				Cycle:  cycle,
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to verify payout.")
+			}
+
+			if table {
+				printVerificationTable(verification)
+			} else {
+				prettyJSON, err := json.Marshal(verification)
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+				}
+				fmt.Println(string(prettyJSON))
+			}
+
+			if !verification.OK() {
+				log.WithFields(log.Fields{"cycle": cycle, "mismatches": len(verification.Mismatches)}).Fatal("Payout recorded does not match the chain.")
+			}
+		},
+	}
+
+	verify.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	verify.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to verify the payout of when multiple bakers are configured (Default: primary baker)")
+	verify.PersistentFlags().StringVar(&wallet, "wallet", "", "the address the payout was sent from (Default: the configured payout wallet)")
+	verify.PersistentFlags().IntVarP(&cycle, "cycle", "c", 0, "the cycle to verify the payout of")
+	verify.MarkPersistentFlagRequired("cycle")
+
+	return verify
+}
+
+func printVerificationTable(verification payout.Verification) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "Address", "Recorded (XTZ)", "Chain (XTZ)", "Operation"})
+	for _, mismatch := range verification.Mismatches {
+		table.Append([]string{
+			mismatch.Kind,
+			mismatch.Address,
+			tez(mismatch.Recorded),
+			tez(mismatch.Chain),
+			mismatch.Operation,
+		})
+	}
+	table.SetFooter([]string{strconv.Itoa(len(verification.Mismatches)), "PAID / FOUND", strconv.Itoa(verification.Paid), strconv.Itoa(verification.Found), ""})
+
+	table.Render()
+}
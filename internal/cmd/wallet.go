@@ -0,0 +1,486 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/wallet"
+	"github.com/olekukonko/tablewriter"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// WalletCommand returns a new wallet cobra command
+func WalletCommand() *cobra.Command {
+	var w = &cobra.Command{
+		Use:   "wallet",
+		Short: "wallet manages the payout wallet",
+	}
+
+	w.AddCommand(walletNewCommand())
+	w.AddCommand(walletRotateCommand())
+	w.AddCommand(walletDeriveCommand())
+	w.AddCommand(walletImportCommand())
+
+	return w
+}
+
+func walletNewCommand() *cobra.Command {
+	var table bool
+	var curve string
+	var baker string
+	var password string
+
+	var newWallet = &cobra.Command{
+		Use:   "new",
+		Short: "new generates a fresh payout wallet",
+		Long: `new generates a fresh payout wallet on the curve chosen, ed25519 (tz1) or p256 (tz3), encrypts its key with a
+passphrase, saves it to the keystore at TZPAY_STORE_PATH, and prints its address and encrypted secret key to set as
+TZPAY_WALLET_ESK. Only the store needs configuring, so it bootstraps the payout wallet of a new baker.`,
+		Example: `tzpay wallet new --curve p256 --baker tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			c, err := wallet.ParseCurve(curve)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate wallet.")
+			}
+
+			if password == "" {
+				if password, err = readPassword("Passphrase to encrypt the payout wallet with: "); err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to read passphrase.")
+				}
+				confirmation, err := readPassword("Passphrase again: ")
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to read passphrase.")
+				}
+				if confirmation != password {
+					log.Fatal("Passphrases do not match.")
+				}
+			}
+
+			storeConfig, err := config.NewStore()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			s, err := store.New(storeConfig.Path, storeConfig.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			w, err := wallet.Generate(c, password)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate wallet.")
+			}
+
+			if err := wallet.Save(s, baker, w); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to save wallet.")
+			}
+
+			err = audit.Record(s, audit.Event{
+				Action: "wallet_new",
+				Baker:  baker,
+				Details: map[string]string{
+					"address": w.Address,
+					"curve":   string(w.Curve),
+				},
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Error("Failed to record new wallet in audit log.")
+			}
+
+			if table {
+				tbl := tablewriter.NewWriter(os.Stdout)
+				tbl.SetHeader([]string{"Curve", "Address", "Public Key", "Encrypted Secret Key"})
+				tbl.Append([]string{string(w.Curve), w.Address, w.PublicKey, w.Esk})
+				tbl.Render()
+			} else {
+				prettyJSON, err := json.Marshal(map[string]string{
+					"curve":      string(w.Curve),
+					"address":    w.Address,
+					"public_key": w.PublicKey,
+					"esk":        w.Esk,
+				})
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+				}
+				fmt.Println(string(prettyJSON))
+			}
+
+			log.WithFields(log.Fields{"wallet": w.Address, "keystore": storeConfig.Path}).Info("Payout wallet generated, set TZPAY_WALLET_ESK and fund it before the first payout.")
+		},
+	}
+
+	newWallet.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	newWallet.PersistentFlags().StringVar(&curve, "curve", string(wallet.Ed25519), "the curve of the key, ed25519 (tz1) or p256 (tz3)")
+	newWallet.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker the wallet pays out for, kept with it in the keystore")
+	newWallet.PersistentFlags().StringVarP(&password, "password", "p", "", "the passphrase to encrypt the wallet with (Default: prompt)")
+
+	return newWallet
+}
+
+func walletRotateCommand() *cobra.Command {
+	var baker string
+	var password string
+	var envFile string
+
+	var rotate = &cobra.Command{
+		Use:   "rotate",
+		Short: "rotate replaces the payout wallet with a newly generated one",
+		Long: `rotate generates a new payout wallet, saves it to the keystore, transfers the funds of the current wallet to it, 
+updates the configuration with the new key, and records the rotation in the audit log`,
+		Example: `tzpay wallet rotate --env-file /etc/tzpay/tzpay.env`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if envFile == "" {
+				envFile = configFile
+			}
+
+			cfg, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			bakerConfig, err := cfg.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+			primary := bakerConfig.Baker.Address == cfg.Baker.Address
+
+			refuseHeldWallet("rotate", bakerConfig.Key)
+
+			// A password read from a file or prompted for is kept out of the configuration updated
+			stored := bakerConfig.Key.Password != "" && bakerConfig.Key.PasswordFile == ""
+			bakerConfig, err = unlockWallets(bakerConfig)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to unlock payout wallet.")
+			}
+
+			if password == "" {
+				password = bakerConfig.Key.Password
+			}
+			if !stored && password != bakerConfig.Key.Password {
+				log.Warn("The new wallet is encrypted with the password passed, enter it or update TZPAY_WALLET_PASSWORD_FILE to unlock it.")
+			}
+
+			s, err := store.New(cfg.Store.Path, cfg.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			w, err := wallet.New(password)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to generate wallet.")
+			}
+
+			if err := wallet.Save(s, bakerConfig.Baker.Address, w); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to save wallet.")
+			}
+
+			p, err := payout.NewWithContext(interruptContext(), bakerConfig, 0, true, true)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to intialize payout.")
+			}
+
+			from := p.Wallet()
+			to := w.Address
+			log.WithFields(log.Fields{"from": from, "to": to}).Info("Transferring funds to new wallet.")
+
+			ophash, err := p.Sweep(to)
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "keystore": cfg.Store.Path}).Fatal("Failed to transfer funds, the new wallet is kept in the keystore.")
+			}
+
+			// The address of the payout wallet follows the key if it is configured
+			var address string
+			if bakerConfig.Key.Address != "" {
+				address = to
+			}
+
+			var storedPassword string
+			if stored {
+				storedPassword = w.Password
+			}
+
+			switch {
+			case !primary:
+				err = wallet.UpdateDelegatesFile(cfg.DelegatesFile, bakerConfig.Baker.Address, w.Esk, storedPassword, address)
+			case envFile != "":
+				values := map[string]string{"TZPAY_WALLET_ESK": w.Esk}
+				if stored {
+					values["TZPAY_WALLET_PASSWORD"] = w.Password
+				}
+				if address != "" {
+					values["TZPAY_WALLET_ADDRESS"] = address
+				}
+				err = wallet.UpdateEnvFile(envFile, values)
+			default:
+				log.WithField("esk", w.Esk).Warn("No enviroment file passed, set TZPAY_WALLET_ESK to the new encrypted secret key.")
+				if address != "" {
+					log.WithField("address", address).Warn("No enviroment file passed, set TZPAY_WALLET_ADDRESS to the new payout wallet.")
+				}
+			}
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "keystore": cfg.Store.Path}).Fatal("Failed to update config, the new wallet is kept in the keystore.")
+			}
+
+			err = audit.Record(s, audit.Event{
+				Action: "wallet_rotate",
+				Baker:  bakerConfig.Baker.Address,
+				Details: map[string]string{
+					"from":      from,
+					"to":        to,
+					"operation": ophash,
+				},
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Error("Failed to record rotation in audit log.")
+			}
+
+			log.WithFields(log.Fields{"wallet": to, "operation": ophash}).Info("Payout wallet rotated.")
+		},
+	}
+
+	rotate.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to rotate the payout wallet of (Default: primary baker)")
+	rotate.PersistentFlags().StringVarP(&password, "password", "p", "", "the password to encrypt the new wallet with (Default: current wallet password)")
+	rotate.PersistentFlags().StringVarP(&envFile, "env-file", "e", "", "the enviroment file holding TZPAY_WALLET_ESK to update for the primary baker (Default: --config)")
+
+	return rotate
+}
+
+// refuseHeldWallet stops a command replacing the key of a payout wallet held outside of tzpay, which only its holder can replace
+func refuseHeldWallet(action string, key config.Key) {
+	if key.Signer != "" {
+		log.WithField("signer", key.Signer).Fatalf("Refusing to %s a payout wallet held by a remote signer, rotate its key on the signer instead.", action)
+	}
+	if key.KMSKeyID != "" {
+		log.WithField("key", key.KMSKeyID).Fatalf("Refusing to %s a payout wallet held in AWS KMS, create a new key in KMS instead.", action)
+	}
+	if key.PKCS11Module != "" {
+		log.WithField("label", key.PKCS11Label).Fatalf("Refusing to %s a payout wallet held by a PKCS#11 token, generate a new key pair on the token instead.", action)
+	}
+	if key.ExternalDir != "" || key.ExternalURL != "" {
+		log.WithField("wallet", key.Address).Fatalf("Refusing to %s a payout wallet signed for externally, rotate its key with the signing infrastructure instead.", action)
+	}
+}
+
+func walletDeriveCommand() *cobra.Command {
+	var table bool
+	var count int
+	var path string
+	var mnemonicFile string
+	var passphrase bool
+
+	var derive = &cobra.Command{
+		Use:   "derive",
+		Short: "derive lists the addresses derived from a mnemonic",
+		Long: `derive lists the first --count addresses derived from a BIP39 mnemonic at --path, where {i} is replaced by the index
+of each, to pick the one to import as the payout wallet with 'tzpay wallet import'. The mnemonic is prompted for unless
+read from --mnemonic-file.`,
+		Example: `tzpay wallet derive --count 5 --table`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if count <= 0 {
+				log.Fatal("Invalid count, expected --count above 0.")
+			}
+
+			mnemonic, bip39Passphrase := readMnemonic(mnemonicFile, passphrase)
+
+			var derived []wallet.Derived
+			for i := 0; i < count; i++ {
+				key, err := wallet.Derive(mnemonic, bip39Passphrase, strings.Replace(path, "{i}", strconv.Itoa(i), -1))
+				if err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to derive address.")
+				}
+				derived = append(derived, key)
+			}
+
+			if table {
+				tbl := tablewriter.NewWriter(os.Stdout)
+				tbl.SetHeader([]string{"Index", "Path", "Address"})
+				for i, key := range derived {
+					tbl.Append([]string{strconv.Itoa(i), key.Path, key.Address})
+				}
+				tbl.Render()
+				return
+			}
+
+			type address struct {
+				Index   int    `json:"index"`
+				Path    string `json:"path"`
+				Address string `json:"address"`
+			}
+			var addresses []address
+			for i, key := range derived {
+				addresses = append(addresses, address{Index: i, Path: key.Path, Address: key.Address})
+			}
+
+			prettyJSON, err := json.Marshal(addresses)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+			}
+			fmt.Println(string(prettyJSON))
+		},
+	}
+
+	derive.PersistentFlags().BoolVarP(&table, "table", "t", false, "formats result into a table (Default: json)")
+	derive.PersistentFlags().IntVarP(&count, "count", "n", 5, "the number of addresses to derive")
+	derive.PersistentFlags().StringVar(&path, "path", "m/44'/1729'/{i}'/0'", "the derivation path, {i} being replaced by the index of the address")
+	derive.PersistentFlags().StringVar(&mnemonicFile, "mnemonic-file", "", "a file holding the mnemonic (Default: prompt)")
+	derive.PersistentFlags().BoolVar(&passphrase, "passphrase", false, "prompts for the BIP39 passphrase of the mnemonic")
+
+	return derive
+}
+
+func walletImportCommand() *cobra.Command {
+	var baker string
+	var path string
+	var password string
+	var envFile string
+	var mnemonicFile string
+	var passphrase bool
+
+	var imp = &cobra.Command{
+		Use:   "import",
+		Short: "import sets the payout wallet to a key derived from a mnemonic",
+		Long: `import derives the key at --path from a BIP39 mnemonic, as Ledger Live and Temple do, encrypts it, saves it to the
+keystore, updates the configuration with it, and records the import in the audit log. Funds of the previous payout wallet
+are not moved.`,
+		Example: `tzpay wallet import --path "m/44'/1729'/0'/0'" --env-file /etc/tzpay/tzpay.env`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if envFile == "" {
+				envFile = configFile
+			}
+
+			cfg, err := newConfig()
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+
+			bakerConfig, err := cfg.ForBaker(baker)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to load config.")
+			}
+			primary := bakerConfig.Baker.Address == cfg.Baker.Address
+			refuseHeldWallet("import", bakerConfig.Key)
+
+			mnemonic, bip39Passphrase := readMnemonic(mnemonicFile, passphrase)
+			derived, err := wallet.Derive(mnemonic, bip39Passphrase, path)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to derive payout wallet.")
+			}
+
+			// A password read from a file or prompted for is kept out of the configuration updated
+			stored := bakerConfig.Key.Password != "" && bakerConfig.Key.PasswordFile == ""
+			if password == "" && stored {
+				password = bakerConfig.Key.Password
+			} else if password == "" {
+				if password, err = readPassword("Password to encrypt the payout wallet with: "); err != nil {
+					log.WithField("error", err.Error()).Fatal("Failed to read password.")
+				}
+			}
+
+			w, err := wallet.FromSeed(derived.Seed, password)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to import wallet.")
+			}
+
+			s, err := store.New(cfg.Store.Path, cfg.Store.Key)
+			if err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to open store.")
+			}
+
+			if err := wallet.Save(s, bakerConfig.Baker.Address, w); err != nil {
+				log.WithField("error", err.Error()).Fatal("Failed to save wallet.")
+			}
+
+			// The address of the payout wallet follows the key if it is configured
+			var address string
+			if bakerConfig.Key.Address != "" {
+				address = derived.Address
+			}
+
+			var storedPassword string
+			if stored {
+				storedPassword = w.Password
+			}
+
+			switch {
+			case !primary:
+				err = wallet.UpdateDelegatesFile(cfg.DelegatesFile, bakerConfig.Baker.Address, w.Esk, storedPassword, address)
+			case envFile != "":
+				values := map[string]string{"TZPAY_WALLET_ESK": w.Esk}
+				if stored {
+					values["TZPAY_WALLET_PASSWORD"] = w.Password
+				}
+				if address != "" {
+					values["TZPAY_WALLET_ADDRESS"] = address
+				}
+				err = wallet.UpdateEnvFile(envFile, values)
+			default:
+				log.WithField("esk", w.Esk).Warn("No enviroment file passed, set TZPAY_WALLET_ESK to the new encrypted secret key.")
+				if address != "" {
+					log.WithField("address", address).Warn("No enviroment file passed, set TZPAY_WALLET_ADDRESS to the new payout wallet.")
+				}
+			}
+			if err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "keystore": cfg.Store.Path}).Fatal("Failed to update config, the new wallet is kept in the keystore.")
+			}
+
+			err = audit.Record(s, audit.Event{
+				Action: "wallet_import",
+				Baker:  bakerConfig.Baker.Address,
+				Details: map[string]string{
+					"to":   derived.Address,
+					"path": derived.Path,
+				},
+			})
+			if err != nil {
+				log.WithField("error", err.Error()).Error("Failed to record import in audit log.")
+			}
+
+			log.WithFields(log.Fields{"wallet": derived.Address, "path": derived.Path}).Info("Payout wallet imported, fund it before the next payout.")
+		},
+	}
+
+	imp.PersistentFlags().StringVarP(&baker, "baker", "b", "", "the baker to import the payout wallet of (Default: primary baker)")
+	imp.PersistentFlags().StringVar(&path, "path", wallet.DefaultPath, "the derivation path of the key")
+	imp.PersistentFlags().StringVarP(&password, "password", "p", "", "the password to encrypt the wallet with (Default: current wallet password, or prompt)")
+	imp.PersistentFlags().StringVarP(&envFile, "env-file", "e", "", "the enviroment file holding TZPAY_WALLET_ESK to update for the primary baker (Default: --config)")
+	imp.PersistentFlags().StringVar(&mnemonicFile, "mnemonic-file", "", "a file holding the mnemonic (Default: prompt)")
+	imp.PersistentFlags().BoolVar(&passphrase, "passphrase", false, "prompts for the BIP39 passphrase of the mnemonic")
+
+	return imp
+}
+
+// readMnemonic reads a mnemonic from file, or prompts for it, and prompts for its BIP39 passphrase if passphrase is set
+func readMnemonic(file string, passphrase bool) (string, string) {
+	var mnemonic string
+	if file != "" {
+		byts, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.WithField("error", err.Error()).Fatal("Failed to read mnemonic file.")
+		}
+		mnemonic = string(byts)
+	} else {
+		var err error
+		if mnemonic, err = readPassword("Mnemonic: "); err != nil {
+			log.WithField("error", err.Error()).Fatal("Failed to read mnemonic.")
+		}
+	}
+
+	var bip39Passphrase string
+	if passphrase {
+		var err error
+		if bip39Passphrase, err = readPassword("BIP39 passphrase: "); err != nil {
+			log.WithField("error", err.Error()).Fatal("Failed to read passphrase.")
+		}
+	}
+
+	return mnemonic, bip39Passphrase
+}
@@ -1,10 +1,18 @@
 package config
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/go-playground/validator"
+	"github.com/goat-systems/tzpay/v3/internal/schedule"
 	"github.com/pkg/errors"
 )
 
@@ -15,6 +23,45 @@ type Config struct {
 	Key           Key
 	Operations    Operations
 	Notifications Notifications
+	Store         Store
+	Redaction     Redaction
+	Insurance     Insurance
+	Sync          Sync
+	Overrides     Overrides
+	Books         Books
+	Funding       Funding
+	Status        Status
+	Lookup        Lookup
+	Approval      Approval
+	Price         Price
+	Archive       Archive
+	Receipts      Receipts
+	HTTP          HTTP
+	Scheduling    Scheduling
+	Catchup       Catchup
+	CrossCheck    CrossCheck
+	DelegatesFile string     `env:"TZPAY_DELEGATES_FILE"`
+	Delegates     []Delegate `validate:"dive"`
+}
+
+/*
+Delegate contains the configurations for an additional baker paid out by the same tzpay instance.
+Delegates are loaded from the JSON file found at TZPAY_DELEGATES_FILE, e.g:
+
+	[
+		{
+			"baker": {"address": "tz1...", "fee": 0.05, "blacklist": ["KT1..."]},
+			"key": {"esk": "edesk...", "password": "..."}
+		}
+	]
+
+Baker settings omitted for a delegate are inherited from the primary baker, except for the address,
+blacklist, liquidity contracts, payout schedules, payout addresses, fees, bond pool and sweep address which always belong to a
+single baker.
+*/
+type Delegate struct {
+	Baker Baker
+	Key   Key
 }
 
 // Baker contains configurations related to the how a baker might run their baking operation
@@ -24,16 +71,228 @@ type Baker struct {
 	MinimumPayment               int      `env:"TZPAY_BAKER_MINIMUM_PAYMENT" envDefault:"1"`
 	EarningsOnly                 bool     `env:"TZPAY_BAKER_EARNINGS_ONLY"`
 	DexterLiquidityContractsOnly bool     `env:"TZPAY_BAKER_LIQUIDITY_CONTRACTS_ONLY"`
+	ExcludeContracts             bool     `env:"TZPAY_BAKER_EXCLUDE_CONTRACTS"`
+	CheckContracts               bool     `env:"TZPAY_BAKER_CHECK_CONTRACTS"`
+	ContractFallbackAddress      string   `env:"TZPAY_BAKER_CONTRACT_FALLBACK_ADDRESS"`
 	Blacklist                    []string `env:"TZPAY_BAKER_BLACK_LIST" envSeparator:","`
 	DexterLiquidityContracts     []string `env:"TZPAY_BAKER_LIQUIDITY_CONTRACTS" envSeparator:","`
 	BakerPaysBurnFees            bool     `env:"TZPAY_BAKER_PAYS_BURN_FEES"`
 	PayoutWhenRewardsUnfrozen    bool     `env:"TZPAY_REWARDS_UNFROZEN_WAIT"`
+	AccumulateThreshold          int      `env:"TZPAY_BAKER_ACCUMULATE_THRESHOLD"`
+	ActualRewards                bool     `env:"TZPAY_BAKER_ACTUAL_REWARDS"`
+	DenunciationPolicy           string   `env:"TZPAY_BAKER_DENUNCIATION_POLICY" envDefault:"pay" validate:"oneof=pay reduce skip"`
+	Rounding                     string   `env:"TZPAY_BAKER_ROUNDING" envDefault:"floor" validate:"oneof=floor round ceil"`
+	DistributeRemainder          bool     `env:"TZPAY_BAKER_DISTRIBUTE_REMAINDER"`
+	ConsolidateManagers          bool     `env:"TZPAY_BAKER_CONSOLIDATE_MANAGERS"`
+	DonationPercentage           float64  `env:"TZPAY_BAKER_DONATION_PERCENTAGE" validate:"gte=0,lte=100"`
+	DonationAddress              string   `env:"TZPAY_BAKER_DONATION_ADDRESS" validate:"required_with=DonationPercentage"`
+	// PayoutSchedules lists the delegators paid less often than every cycle, e.g. tz1...:weekly,tz1...:monthly
+	PayoutSchedules []string `env:"TZPAY_BAKER_PAYOUT_SCHEDULES" envSeparator:","`
+	// PayoutAddresses lists the delegators paid to another address, e.g. tz1delegator:tz1coldwallet
+	PayoutAddresses []string `env:"TZPAY_BAKER_PAYOUT_ADDRESSES" envSeparator:","`
+	// Fees lists the delegators charged another fee than Fee, e.g. tz1delegator:0.02
+	Fees []string `env:"TZPAY_BAKER_FEES" envSeparator:","`
+	// BondPool lists the members of a bond pool sharing the baker's rewards and fees by weight, e.g. tz1member:60,tz1other:40
+	BondPool []string `env:"TZPAY_BAKER_BOND_POOL" envSeparator:","`
+	// SweepAddress is a cold storage address the baker's cut of every payout is swept to, less SweepFloat mutez left in
+	// the payout wallet to pay network fees with
+	SweepAddress string `env:"TZPAY_BAKER_SWEEP_ADDRESS"`
+	SweepFloat   int    `env:"TZPAY_BAKER_SWEEP_FLOAT" validate:"gte=0"`
+	// PartialPayouts is the number of payouts made from the rewards accrued while a cycle is in progress, before the
+	// payout of the cycle pays what is left (experimental)
+	PartialPayouts int `env:"TZPAY_BAKER_PARTIAL_PAYOUTS" validate:"gte=0"`
+	// Campaigns lists the promotions for the delegators joining the baker within a window of cycles, e.g.
+	// 300-310:0.02 charges them a 2% fee and 300-310:x1.1:20 adds 10% to their rewards for their first 20 cycles
+	Campaigns []string `env:"TZPAY_BAKER_CAMPAIGNS" envSeparator:","`
+}
+
+// Schedule returns the payout schedule of a delegator, or an empty string if it is paid every cycle
+func (b Baker) Schedule(address string) string {
+	return lookup(b.PayoutSchedules, address)
+}
+
+// FeeFor returns the fee charged to a delegator
+func (b Baker) FeeFor(address string) float64 {
+	if fee, err := strconv.ParseFloat(lookup(b.Fees, address), 64); err == nil {
+		return fee
+	}
+
+	return b.Fee
+}
+
+// PayoutAddress returns the address the rewards of a delegator are sent to
+func (b Baker) PayoutAddress(address string) string {
+	if payoutAddress := lookup(b.PayoutAddresses, address); payoutAddress != "" {
+		return payoutAddress
+	}
+
+	return address
+}
+
+// lookup returns the value of the key passed in a list of key:value pairs
+func lookup(pairs []string, key string) string {
+	for _, pair := range pairs {
+		if parts := strings.SplitN(pair, ":", 2); len(parts) == 2 && parts[0] == key {
+			return parts[1]
+		}
+	}
+
+	return ""
+}
+
+func validatePayoutAddresses(addresses []string) error {
+	for _, address := range addresses {
+		parts := strings.SplitN(address, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return errors.Errorf("invalid payout address '%s': expected <delegator>:<payout address>", address)
+		}
+	}
+
+	return nil
+}
+
+func validateDelegatorEmails(emails []string) error {
+	for _, email := range emails {
+		parts := strings.SplitN(email, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || !strings.Contains(parts[1], "@") {
+			return errors.Errorf("invalid delegator email '%s': expected <delegator>:<email address>", email)
+		}
+	}
+
+	return nil
+}
+
+func validateFees(fees []string) error {
+	for _, fee := range fees {
+		parts := strings.SplitN(fee, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.Errorf("invalid fee '%s': expected <delegator>:<fee>", fee)
+		}
+		if rate, err := strconv.ParseFloat(parts[1], 64); err != nil || rate < 0 || rate > 1 {
+			return errors.Errorf("invalid fee '%s': expected a fee between 0 and 1", fee)
+		}
+	}
+
+	return nil
+}
+
+func validateBondPool(members []string) error {
+	for _, member := range members {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.Errorf("invalid bond pool member '%s': expected <address>:<weight>", member)
+		}
+		if weight, err := strconv.ParseFloat(parts[1], 64); err != nil || weight <= 0 {
+			return errors.Errorf("invalid bond pool member '%s': expected a positive weight", member)
+		}
+	}
+
+	return nil
+}
+
+/*
+Campaign is a promotion for the delegators who first delegate to the baker from cycle First to cycle Last. They are
+charged Fee instead of the baker's fee when it is lower, or get their rewards multiplied by Bonus when it is set, for
+the Cycles cycles following the one they joined in, or for as long as they stay if Cycles is 0.
+*/
+type Campaign struct {
+	Spec   string
+	First  int
+	Last   int
+	Fee    float64
+	Bonus  float64
+	Cycles int
+}
+
+// ParseCampaigns parses campaigns of the form <first>-<last>:<fee>[:<cycles>] or <first>-<last>:x<bonus>[:<cycles>]
+func ParseCampaigns(campaigns []string) ([]Campaign, error) {
+	var parsed []Campaign
+	for _, spec := range campaigns {
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, errors.Errorf("invalid campaign '%s': expected <first>-<last>:<fee>[:<cycles>] or <first>-<last>:x<bonus>[:<cycles>]", spec)
+		}
+
+		campaign := Campaign{Spec: spec}
+		window := strings.SplitN(parts[0], "-", 2)
+		var errFirst, errLast error
+		if len(window) == 2 {
+			campaign.First, errFirst = strconv.Atoi(window[0])
+			campaign.Last, errLast = strconv.Atoi(window[1])
+		}
+		if len(window) != 2 || errFirst != nil || errLast != nil || campaign.First < 0 || campaign.Last < campaign.First {
+			return nil, errors.Errorf("invalid campaign '%s': expected a window of cycles <first>-<last>", spec)
+		}
+
+		if strings.HasPrefix(parts[1], "x") {
+			bonus, err := strconv.ParseFloat(strings.TrimPrefix(parts[1], "x"), 64)
+			if err != nil || bonus <= 1 {
+				return nil, errors.Errorf("invalid campaign '%s': expected a bonus multiplier above 1", spec)
+			}
+			campaign.Bonus = bonus
+		} else {
+			fee, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil || fee < 0 || fee > 1 {
+				return nil, errors.Errorf("invalid campaign '%s': expected a fee between 0 and 1", spec)
+			}
+			campaign.Fee = fee
+		}
+
+		if len(parts) == 3 {
+			cycles, err := strconv.Atoi(parts[2])
+			if err != nil || cycles <= 0 {
+				return nil, errors.Errorf("invalid campaign '%s': expected a positive number of cycles", spec)
+			}
+			campaign.Cycles = cycles
+		}
+
+		parsed = append(parsed, campaign)
+	}
+
+	return parsed, nil
+}
+
+func validateSchedules(schedules []string) error {
+	for _, schedule := range schedules {
+		parts := strings.SplitN(schedule, ":", 2)
+		if len(parts) != 2 || (parts[1] != ScheduleWeekly && parts[1] != ScheduleMonthly) {
+			return errors.Errorf("invalid payout schedule '%s': expected <address>:%s or <address>:%s", schedule, ScheduleWeekly, ScheduleMonthly)
+		}
+	}
+
+	return nil
 }
 
-// API contains configurations for the tzkt API and a tezos node
+// Schedules for paying out delegators less often than every cycle
+const (
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+// Policies for rounding rewards and fees to whole mutez
+const (
+	RoundingFloor = "floor"
+	RoundingRound = "round"
+	RoundingCeil  = "ceil"
+)
+
+// Policies for paying out a cycle in which the baker was denounced for double baking or endorsing
+const (
+	DenunciationPolicyPay    = "pay"    // pay delegators as if the baker was not denounced
+	DenunciationPolicyReduce = "reduce" // share the rewards and fees lost between delegators
+	DenunciationPolicySkip   = "skip"   // skip paying out the cycle
+)
+
+// API contains configurations for the indexer, tzkt or tzstats at TZKT or TzStats, and a tezos node
 type API struct {
-	TZKT  string `env:"TZPAY_API_TZKT" envDefault:"https://api.tzkt.io" validate:"required"`
-	Tezos string `env:"TZPAY_API_TEZOS" envDefault:"https://mainnet-tezos.giganode.io" validate:"required"`
+	TZKT    string `env:"TZPAY_API_TZKT" envDefault:"https://api.tzkt.io" validate:"required"`
+	Tezos   string `env:"TZPAY_API_TEZOS" envDefault:"https://mainnet-tezos.giganode.io" validate:"required"`
+	Indexer string `env:"TZPAY_API_INDEXER" envDefault:"tzkt" validate:"oneof=tzkt tzstats"`
+	TzStats string `env:"TZPAY_API_TZSTATS" envDefault:"https://api.tzstats.com" validate:"required"`
+	// Timeout is how long a call to the tezos node may take before it is given up on
+	Timeout time.Duration `env:"TZPAY_API_TIMEOUT" envDefault:"10s" validate:"gt=0"`
+	// Concurrency is how many delegators a payout looks up on the node and indexer at once
+	Concurrency int `env:"TZPAY_API_CONCURRENCY" envDefault:"4" validate:"gte=1"`
 }
 
 // Operations contains configurations for modifying the actual operation to be injected into a node
@@ -41,18 +300,295 @@ type Operations struct {
 	NetworkFee int `env:"TZPAY_OPERATIONS_NETWORK_FEE" envDefault:"2941"`
 	GasLimit   int `env:"TZPAY_OPERATIONS_GAS_LIMIT" envDefault:"26283"`
 	BatchSize  int `env:"TZPAY_OPERATIONS_BATCH_SIZE" envDefault:"125"`
+	// DisperseContract is the address of a disperse contract used to pay each batch with a single contract call
+	DisperseContract string `env:"TZPAY_OPERATIONS_DISPERSE_CONTRACT"`
+	// DuplicateCheck refuses to inject a payout whose transfers the indexer shows were already sent
+	DuplicateCheck bool `env:"TZPAY_OPERATIONS_DUPLICATE_CHECK" envDefault:"true"`
+	// Estimate replaces NetworkFee and GasLimit with the gas, storage and fee of a simulation plus EstimateMargin percent
+	Estimate       bool    `env:"TZPAY_OPERATIONS_ESTIMATE"`
+	EstimateMargin float64 `env:"TZPAY_OPERATIONS_ESTIMATE_MARGIN" envDefault:"10" validate:"gte=0"`
+	// BumpAfterBlocks re-injects operations not included within that many blocks with each fee raised by BumpIncrement,
+	// at most BumpAttempts times
+	BumpAfterBlocks int `env:"TZPAY_OPERATIONS_BUMP_AFTER_BLOCKS" validate:"gte=0"`
+	BumpAttempts    int `env:"TZPAY_OPERATIONS_BUMP_ATTEMPTS" envDefault:"3"`
+	BumpIncrement   int `env:"TZPAY_OPERATIONS_BUMP_INCREMENT" envDefault:"1000"`
+	// Confirmations waits for that many blocks on top of the including block before a payout is marked paid
+	Confirmations int `env:"TZPAY_OPERATIONS_CONFIRMATIONS" validate:"gte=0"`
+	// SpendingCap refuses a payout run injecting more than that many mutez, network fees included. 0 disables the cap.
+	SpendingCap int64 `env:"TZPAY_OPERATIONS_SPENDING_CAP" validate:"gte=0"`
+	// MaxAttempts moves a payout of the queue of tzpay serv to the dead letters once it failed that many times in a row.
+	// 0 retries it forever.
+	MaxAttempts int `env:"TZPAY_OPERATIONS_MAX_ATTEMPTS" envDefault:"60" validate:"gte=0"`
+}
+
+// Store contains configurations for the file tzpay persists state to between payouts
+type Store struct {
+	Path string `env:"TZPAY_STORE_PATH" envDefault:"tzpay.json"`
+	Key  string `env:"TZPAY_STORE_KEY"`
+}
+
+// Redaction contains configurations for removing delegator identifying data from logs and notifications
+type Redaction struct {
+	Fields    []string `env:"TZPAY_REDACT_FIELDS" envSeparator:","`
+	Addresses bool     `env:"TZPAY_REDACT_ADDRESSES"`
+}
+
+/*
+Insurance contains configurations for topping up payouts from a dedicated wallet when the baker misses more than
+Threshold percent of its rights in a cycle. A Threshold of 0 disables insurance.
+*/
+type Insurance struct {
+	Threshold float64 `env:"TZPAY_INSURANCE_THRESHOLD"`
+	Esk       string  `env:"TZPAY_INSURANCE_WALLET_ESK"`
+	Password  string  `env:"TZPAY_INSURANCE_WALLET_PASSWORD"`
+}
+
+/*
+Sync contains configurations for ingesting the baker's reward and delegation history from tzkt into the store.
+The history is synced every Interval, at most MaxCycles at a time and waiting Delay between requests to tzkt.
+An Interval of 0 disables syncing.
+*/
+type Sync struct {
+	Interval  time.Duration `env:"TZPAY_SYNC_INTERVAL"`
+	FromCycle int           `env:"TZPAY_SYNC_FROM_CYCLE"`
+	MaxCycles int           `env:"TZPAY_SYNC_MAX_CYCLES" envDefault:"20"`
+	Delay     time.Duration `env:"TZPAY_SYNC_DELAY" envDefault:"1s"`
+}
+
+// Status contains configurations for the public status page of every baker, written to <baker>.json and <baker>.html in Dir
+type Status struct {
+	Dir string `env:"TZPAY_STATUS_DIR"`
+}
+
+// Lookup contains configurations for the static lookup page of every baker, where a delegator sees their payment
+// history, exported to <baker>/ in Dir
+type Lookup struct {
+	Dir string `env:"TZPAY_LOOKUP_DIR"`
+}
+
+// Books contains configurations for keeping the double-entry books of the payout wallets, reconciled every cycle
+type Books struct {
+	Enabled bool `env:"TZPAY_BOOKS_ENABLED"`
+}
+
+/*
+Funding contains configurations for funding the payout wallet from the baker before each payout, when the wallet is
+kept apart from the baker's key. The rewards the baker earned in the cycle paid out, unfrozen by then, are transferred
+from the baker signing with its own key in Esk and Password. Only the primary baker is funded.
+*/
+type Funding struct {
+	Enabled  bool   `env:"TZPAY_FUND_WALLET"`
+	Esk      string `env:"TZPAY_BAKER_ESK" validate:"required_with=Enabled"`
+	Password string `env:"TZPAY_BAKER_PASSWORD" validate:"required_with=Enabled"`
+}
+
+/*
+Approval contains configurations for the second approval a payout needs before it is injected, for bakers under dual
+control of fund movements. Keys are the edpk public keys of the approvers, any one of whom approves a payout forged by
+signing its payload. No keys disables approvals, unless Manual is set, which holds every payout of tzpay serv until the
+operator approves it, e.g. with 'tzpay approve --cycle'.
+*/
+type Approval struct {
+	Keys   []string `env:"TZPAY_APPROVAL_KEYS" envSeparator:"," validate:"dive,startswith=edpk"`
+	Manual bool     `env:"TZPAY_APPROVAL_MANUAL"`
+}
+
+/*
+Price contains configurations for recording the price of XTZ in Currency, e.g. EUR, with every payout, for tax
+reports. Source is coingecko or coinbase, at URL if set. No Currency disables it.
+*/
+type Price struct {
+	Currency string `env:"TZPAY_PRICE_CURRENCY"`
+	Source   string `env:"TZPAY_PRICE_SOURCE" validate:"omitempty,oneof=coingecko coinbase"`
+	URL      string `env:"TZPAY_PRICE_URL" validate:"omitempty,url"`
+}
+
+// Archive contains configurations for archiving the full computed payout of every cycle as JSON to Dir
+type Archive struct {
+	Dir string `env:"TZPAY_ARCHIVE_DIR"`
+}
+
+/*
+Receipts contains configurations for signing a receipt of every payment to a delegator with the baker's own key in
+TZPAY_BAKER_ESK and TZPAY_BAKER_PASSWORD, so that delegators can verify what they were paid. Only the primary baker's
+payments are signed.
+*/
+type Receipts struct {
+	Sign bool `env:"TZPAY_RECEIPTS_SIGN"`
+}
+
+/*
+HTTP contains configurations for the HTTP API of tzpay serv, serving the payouts recorded in the store as JSON on
+Listen, e.g. :8080. Requests must carry APIKey in the X-API-Key header if it is set. No Listen disables the API.
+Control adds the endpoints controlling the queue of serv, which require APIKey. Public adds the lookup page of
+delegators, served without APIKey. GRPCListen serves the same control methods as a gRPC service there, e.g. :9090,
+which requires APIKey too.
+*/
+type HTTP struct {
+	Listen     string `env:"TZPAY_HTTP_LISTEN"`
+	APIKey     string `env:"TZPAY_HTTP_API_KEY" validate:"required_with=Control GRPCListen"`
+	Control    bool   `env:"TZPAY_HTTP_CONTROL"`
+	Public     bool   `env:"TZPAY_HTTP_PUBLIC"`
+	GRPCListen string `env:"TZPAY_HTTP_GRPC_LISTEN"`
+}
+
+/*
+Scheduling contains configurations for when tzpay serv starts the payouts of its queue: at the first time matching the
+cron expression Cron after a payout is queued, e.g. 0 3 * * *, only within one of the Windows in UTC, e.g.
+02:00-06:00, and never in the first SkipBlocks blocks of a cycle. Every payout starts as soon as it is queued without
+them.
+*/
+type Scheduling struct {
+	Cron       string   `env:"TZPAY_SCHEDULING_CRON"`
+	Windows    []string `env:"TZPAY_SCHEDULING_WINDOWS" envSeparator:","`
+	SkipBlocks int      `env:"TZPAY_SCHEDULING_SKIP_BLOCKS" validate:"gte=0"`
+}
+
+// Input returns the input of the schedule of s
+func (s Scheduling) Input() schedule.Input {
+	return schedule.Input{Cron: s.Cron, Windows: s.Windows, SkipBlocks: s.SkipBlocks}
+}
+
+/*
+Catchup contains configurations for the cycles tzpay serv missed while it was down, which it queues when it starts:
+the cycles still within the preserved cycles that were never paid, after the first payout recorded. More than
+MaxCycles missed are only reported, for the operator to pay them by hand, and 0 disables catching up. With Confirm set,
+every payout caught up is held until the operator approves it, as with TZPAY_APPROVAL_MANUAL.
+*/
+type Catchup struct {
+	MaxCycles int  `env:"TZPAY_CATCHUP_MAX_CYCLES" envDefault:"3" validate:"gte=0"`
+	Confirm   bool `env:"TZPAY_CATCHUP_CONFIRM"`
+}
+
+/*
+CrossCheck contains configurations for checking the rewards the indexer reports for a cycle against the rewards summed
+from the blocks of the node before a payout is made, against a bug of the indexer or a misbehaving node. Rewards
+diverging by more than Tolerance percent are alerted with Mode warn, and the payout is also refused with Mode refuse.
+No Mode disables it.
+*/
+type CrossCheck struct {
+	Mode      string  `env:"TZPAY_CROSS_CHECK" validate:"omitempty,oneof=warn refuse"`
+	Tolerance float64 `env:"TZPAY_CROSS_CHECK_TOLERANCE" envDefault:"1" validate:"gte=0"`
+}
+
+// Overrides contains configurations for the delegator overrides fetched from a CSV document, e.g. a Google Sheet
+type Overrides struct {
+	URL      string        `env:"TZPAY_OVERRIDES_URL"`
+	Interval time.Duration `env:"TZPAY_OVERRIDES_INTERVAL"`
 }
 
 // Key contains sensitive information regarding
 type Key struct {
-	Esk      string `env:"TZPAY_WALLET_ESK" validate:"required"`
-	Password string `env:"TZPAY_WALLET_PASSWORD" validate:"required"`
+	// Esk may be left out on a machine that only forges and injects payouts signed offline, with Address set.
+	Esk string `env:"TZPAY_WALLET_ESK" validate:"required_without_all=Signer KMSKeyID PKCS11Module Address"`
+	// Password decrypts Esk, an edesk, spesk or p2esk key. It is read from PasswordFile if set, and else prompted for
+	// on the terminal by the commands signing with the wallet, so that it never has to be stored in the enviroment.
+	Password     string `env:"TZPAY_WALLET_PASSWORD"`
+	PasswordFile string `env:"TZPAY_WALLET_PASSWORD_FILE"`
+	// Address is the address of a payout wallet kept apart from the baker's key. When set, the key must match it
+	// and the wallet must hold enough to cover a payout before it is injected.
+	Address string `env:"TZPAY_WALLET_ADDRESS" validate:"required_with=Signer ExternalDir ExternalURL"`
+	// Signer is the URL of a remote signer speaking the tezos-signer HTTP protocol that holds the key of the payout
+	// wallet at Address, used instead of Esk and Password. SignerHeaders are sent with every request as "Name:Value".
+	Signer        string        `env:"TZPAY_WALLET_SIGNER"`
+	SignerHeaders []string      `env:"TZPAY_WALLET_SIGNER_HEADERS" envSeparator:","`
+	SignerTimeout time.Duration `env:"TZPAY_WALLET_SIGNER_TIMEOUT"`
+	// KMSKeyID is the ID, ARN or alias of an asymmetric secp256k1 key in AWS KMS holding the payout wallet, a tz2
+	// address, used instead of Esk and Password. SignerTimeout also bounds requests to KMS.
+	KMSKeyID    string `env:"TZPAY_WALLET_KMS_KEY_ID"`
+	KMSRegion   string `env:"TZPAY_WALLET_KMS_REGION"`
+	KMSEndpoint string `env:"TZPAY_WALLET_KMS_ENDPOINT"`
+	// PKCS11Module is the PKCS#11 module of a token such as a YubiHSM or SoftHSM holding the Ed25519 or secp256k1
+	// key pair of the payout wallet labeled PKCS11Label, used instead of Esk and Password through OpenSC's pkcs11-tool.
+	PKCS11Module string `env:"TZPAY_WALLET_PKCS11_MODULE"`
+	PKCS11Slot   string `env:"TZPAY_WALLET_PKCS11_SLOT"`
+	PKCS11PIN    string `env:"TZPAY_WALLET_PKCS11_PIN"`
+	PKCS11Label  string `env:"TZPAY_WALLET_PKCS11_LABEL" validate:"required_with=PKCS11Module"`
+	PKCS11Tool   string `env:"TZPAY_WALLET_PKCS11_TOOL"`
+	// ExternalDir or ExternalURL make tzpay watch-only: the payout wallet at Address is never held, and every operation
+	// is handed to separate signing infrastructure as a signing request, waiting up to ExternalWait for its signature.
+	// SignerHeaders are sent with every request to ExternalURL.
+	ExternalDir  string        `env:"TZPAY_WALLET_EXTERNAL_DIR"`
+	ExternalURL  string        `env:"TZPAY_WALLET_EXTERNAL_URL"`
+	ExternalWait time.Duration `env:"TZPAY_WALLET_EXTERNAL_WAIT"`
 }
 
 // Notifications contains the configurations for notification features
 type Notifications struct {
-	Twitter Twitter
-	Twilio  Twilio
+	Twitter      Twitter
+	Twilio       Twilio
+	Telegram     Telegram
+	Discord      Discord
+	Webhook      Webhook
+	Email        Email
+	Rights       Rights
+	Digest       Digest
+	RateLimit    RateLimit
+	Departures   Departures
+	Deactivation Deactivation
+	LowBalance   LowBalance
+	Templates    Templates
+	// Routes send a kind of notification or event to some notifiers only, e.g. alert:twilio+telegram,payout:twitter
+	Routes []string `env:"TZPAY_NOTIFY_ROUTES" envSeparator:","`
+}
+
+/*
+Deactivation contains configurations for warning that a baker is deactivated within Cycles cycles, 0 disables it.
+With Register set, the baker found deactivated is registered again as a delegate, signing with its own key in Esk
+and Password.
+*/
+type Deactivation struct {
+	Cycles   int    `env:"TZPAY_NOTIFY_DEACTIVATION_CYCLES" validate:"gte=0"`
+	Register bool   `env:"TZPAY_REGISTER_WHEN_DEACTIVATED"`
+	Esk      string `env:"TZPAY_BAKER_ESK" validate:"required_with=Register"`
+	Password string `env:"TZPAY_BAKER_PASSWORD" validate:"required_with=Register"`
+}
+
+/*
+Departures contains configurations for notifying of the delegators leaving the baker with their pending rewards.
+Departures are checked every Interval, and an Interval of 0 disables the notifications. Template is a Go template
+rendering a departure, see the README for the fields available.
+*/
+type Departures struct {
+	Interval time.Duration `env:"TZPAY_NOTIFY_DEPARTURES_INTERVAL"`
+	Template string        `env:"TZPAY_NOTIFY_DEPARTURES_TEMPLATE"`
+}
+
+/*
+LowBalance contains configurations for alerting when a payout wallet holds less than its next payout is projected to
+cost plus Buffer (mutez), checked before each payout and, in tzpay serv, every Interval if set.
+*/
+type LowBalance struct {
+	Enabled  bool          `env:"TZPAY_NOTIFY_LOW_BALANCE"`
+	Buffer   int           `env:"TZPAY_NOTIFY_LOW_BALANCE_BUFFER" validate:"gte=0"`
+	Interval time.Duration `env:"TZPAY_NOTIFY_LOW_BALANCE_INTERVAL"`
+}
+
+/*
+Templates contains the Go templates of notifications. Payout renders the notification of a payout from a
+payout.Summary, and Message renders every message sent through each notifier from a notifier.Message.
+*/
+type Templates struct {
+	Payout  string `env:"TZPAY_NOTIFY_PAYOUT_TEMPLATE"`
+	Message string `env:"TZPAY_NOTIFY_MESSAGE_TEMPLATE"`
+}
+
+// Digest contains configurations for rolling low severity notifications into a single message
+type Digest struct {
+	Interval time.Duration `env:"TZPAY_NOTIFY_DIGEST_INTERVAL"`
+}
+
+// RateLimit contains configurations for limiting the notifications sent through each service
+type RateLimit struct {
+	Max    int           `env:"TZPAY_NOTIFY_RATE_LIMIT" validate:"gte=0"`
+	Period time.Duration `env:"TZPAY_NOTIFY_RATE_LIMIT_PERIOD"`
+}
+
+// Rights contains configurations for notifying ahead of high priority baking rights, and of the rights missed
+type Rights struct {
+	Before      time.Duration `env:"TZPAY_NOTIFY_RIGHTS_BEFORE"`
+	MaxPriority int           `env:"TZPAY_NOTIFY_RIGHTS_MAX_PRIORITY"`
+	Missed      bool          `env:"TZPAY_NOTIFY_MISSED_RIGHTS"`
 }
 
 // Twitter contains twitter API information for automatic notifications
@@ -71,6 +607,76 @@ type Twilio struct {
 	To         []string `env:"TZPAY_TWILIO_TO" envSeparator:","`
 }
 
+/*
+Telegram contains telegram bot information for automatic notifications to the chat ChatID. With Confirm set, tzpay serv
+also asks the chat to reply confirm before it injects a payout, and waits up to ConfirmTimeout for the reply.
+*/
+type Telegram struct {
+	Token          string        `env:"TZPAY_TELEGRAM_TOKEN"`
+	ChatID         string        `env:"TZPAY_TELEGRAM_CHAT_ID" validate:"required_with=Token"`
+	Confirm        bool          `env:"TZPAY_TELEGRAM_CONFIRM"`
+	ConfirmTimeout time.Duration `env:"TZPAY_TELEGRAM_CONFIRM_TIMEOUT" envDefault:"1h"`
+}
+
+// Discord contains the webhook of the discord channel for automatic notifications, posted as Username if set
+type Discord struct {
+	WebhookURL string `env:"TZPAY_DISCORD_WEBHOOK_URL" validate:"omitempty,url"`
+	Username   string `env:"TZPAY_DISCORD_USERNAME"`
+}
+
+/*
+Webhook contains the configuration of a webhook posted the events of payouts and every notification as JSON. Template
+is a Go template rendering the payload of an event, see the README for the fields available. Headers are sent with
+every request as "Name:Value", and Events limits the events posted.
+*/
+type Webhook struct {
+	URL      string   `env:"TZPAY_WEBHOOK_URL" validate:"omitempty,url"`
+	Template string   `env:"TZPAY_WEBHOOK_TEMPLATE"`
+	Headers  []string `env:"TZPAY_WEBHOOK_HEADERS" envSeparator:","`
+	Events   []string `env:"TZPAY_WEBHOOK_EVENTS" envSeparator:","`
+}
+
+/*
+Email contains the SMTP server emails are sent through from From: notifications to the addresses of To, and with
+Receipts set, the receipt of every delegator paid once its payout is confirmed. Delegators lists the addresses
+receipts are sent to, e.g. tz1...:alice@example.com, on top of those set with tzpay delegator email.
+*/
+type Email struct {
+	Host       string   `env:"TZPAY_EMAIL_HOST"`
+	Port       int      `env:"TZPAY_EMAIL_PORT"`
+	Username   string   `env:"TZPAY_EMAIL_USERNAME"`
+	Password   string   `env:"TZPAY_EMAIL_PASSWORD"`
+	From       string   `env:"TZPAY_EMAIL_FROM" validate:"required_with=Host"`
+	To         []string `env:"TZPAY_EMAIL_TO" envSeparator:","`
+	Receipts   bool     `env:"TZPAY_EMAIL_RECEIPTS"`
+	Delegators []string `env:"TZPAY_EMAIL_DELEGATORS" envSeparator:","`
+}
+
+// DelegatorEmail returns the email address the configuration sends the receipts of address to, if any
+func (e Email) DelegatorEmail(address string) string {
+	return lookup(e.Delegators, address)
+}
+
+// NewStore loads the configuration of the store alone, for commands that run before the rest of tzpay is configured
+func NewStore() (Store, error) {
+	store := Store{}
+	if err := env.Parse(&store); err != nil {
+		return store, errors.Wrap(err, "failed to load enviroment variables")
+	}
+
+	return store, nil
+}
+
+// NewHTTP loads the configuration of the HTTP API alone, for commands calling a running tzpay serv
+func NewHTTP() (HTTP, error) {
+	http := HTTP{}
+	if err := env.Parse(&http); err != nil {
+		return http, errors.Wrap(err, "failed to load enviroment variables")
+	}
+
+	return http, nil
+}
+
 // New loads enviroment variables into a Config struct
 func New() (Config, error) {
 	config := Config{}
@@ -80,19 +686,216 @@ func New() (Config, error) {
 
 	config.Baker.Blacklist = cleanList(config.Baker.Blacklist)
 	config.Baker.DexterLiquidityContracts = cleanList(config.Baker.DexterLiquidityContracts)
+	config.Baker.PayoutSchedules = cleanList(config.Baker.PayoutSchedules)
+	config.Baker.PayoutAddresses = cleanList(config.Baker.PayoutAddresses)
+	config.Baker.Fees = cleanList(config.Baker.Fees)
+	config.Baker.BondPool = cleanList(config.Baker.BondPool)
+	config.Baker.Campaigns = cleanList(config.Baker.Campaigns)
+	config.Approval.Keys = cleanList(config.Approval.Keys)
+	config.Notifications.Routes = cleanList(config.Notifications.Routes)
+	config.Notifications.Email.To = cleanList(config.Notifications.Email.To)
+	config.Notifications.Email.Delegators = cleanList(config.Notifications.Email.Delegators)
+	config.Scheduling.Windows = cleanList(config.Scheduling.Windows)
 
 	if config.Notifications.Twilio.To != nil {
 		config.Notifications.Twilio.To = cleanList(config.Notifications.Twilio.To)
 	}
 
+	if config.DelegatesFile != "" {
+		delegates, err := loadDelegates(config.DelegatesFile, config.Baker)
+		if err != nil {
+			return config, errors.Wrap(err, "failed to load delegates")
+		}
+		config.Delegates = delegates
+	}
+
+	if err := readPasswordFile(&config.Key); err != nil {
+		return config, errors.Wrap(err, "invalid input")
+	}
+	for i := range config.Delegates {
+		if err := readPasswordFile(&config.Delegates[i].Key); err != nil {
+			return config, errors.Wrap(err, "invalid input")
+		}
+	}
+
 	err := validator.New().Struct(&config)
 	if err != nil {
 		return config, errors.Wrap(err, "invalid input")
 	}
 
+	for _, baker := range config.Bakers() {
+		if err := validateSchedules(baker.Baker.PayoutSchedules); err != nil {
+			return config, errors.Wrap(err, "invalid input")
+		}
+		if err := validatePayoutAddresses(baker.Baker.PayoutAddresses); err != nil {
+			return config, errors.Wrap(err, "invalid input")
+		}
+		if err := validateFees(baker.Baker.Fees); err != nil {
+			return config, errors.Wrap(err, "invalid input")
+		}
+		if err := validateBondPool(baker.Baker.BondPool); err != nil {
+			return config, errors.Wrap(err, "invalid input")
+		}
+		if _, err := ParseCampaigns(baker.Baker.Campaigns); err != nil {
+			return config, errors.Wrap(err, "invalid input")
+		}
+	}
+
+	if err := validateDelegatorEmails(config.Notifications.Email.Delegators); err != nil {
+		return config, errors.Wrap(err, "invalid input")
+	}
+	if _, err := schedule.New(config.Scheduling.Input()); err != nil {
+		return config, errors.Wrap(err, "invalid input")
+	}
+
 	return config, nil
 }
 
+// readPasswordFile sets the password of key to the first line of its password file, unless the password is set
+func readPasswordFile(key *Key) error {
+	if key.PasswordFile == "" || key.Password != "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(key.PasswordFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read password file '%s'", key.PasswordFile)
+	}
+
+	key.Password = strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r")
+	if key.Password == "" {
+		return errors.Errorf("password file '%s' is empty", key.PasswordFile)
+	}
+
+	return nil
+}
+
+/*
+LoadEnvFile sets the variables of the enviroment file found at path in the enviroment, replacing those already set,
+so that New loads them. The file holds a variable per line as written by tzpay setup, e.g:
+
+	TZPAY_BAKER=tz1...
+	export TZPAY_BAKER_FEE=0.05
+
+Blank lines and lines starting with # are ignored, and quotes around a value are removed.
+*/
+func LoadEnvFile(path string) error {
+	vars, err := ReadEnvFile(path)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range vars {
+		if err := os.Setenv(name, value); err != nil {
+			return errors.Wrapf(err, "failed to set '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// ReadEnvFile returns the variables of the enviroment file found at path, as LoadEnvFile reads them, without setting them
+func ReadEnvFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read enviroment file '%s'", path)
+	}
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, "=", 2)
+		name := strings.TrimSpace(strings.TrimPrefix(parts[0], "export "))
+		if len(parts) != 2 || name == "" {
+			return nil, errors.Errorf("failed to parse enviroment file '%s': invalid line %d", path, line)
+		}
+
+		value := strings.TrimSpace(parts[1])
+		if len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read enviroment file '%s'", path)
+	}
+
+	return vars, nil
+}
+
+// Bakers returns a Config for every baker tzpay pays out for, starting with the primary baker.
+func (c Config) Bakers() []Config {
+	primary := c
+	primary.Delegates = nil
+
+	configs := []Config{primary}
+	for _, delegate := range c.Delegates {
+		config := primary
+		config.Baker = delegate.Baker
+		config.Key = delegate.Key
+		config.Funding = Funding{}
+		configs = append(configs, config)
+	}
+
+	return configs
+}
+
+// ForBaker returns the Config of a single baker. An empty address returns the primary baker.
+func (c Config) ForBaker(address string) (Config, error) {
+	for _, config := range c.Bakers() {
+		if address == "" || config.Baker.Address == address {
+			return config, nil
+		}
+	}
+
+	return Config{}, errors.Errorf("baker '%s' is not configured", address)
+}
+
+func loadDelegates(path string, primary Baker) ([]Delegate, error) {
+	byts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read '%s'", path)
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(byts, &raw); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse '%s'", path)
+	}
+
+	var delegates []Delegate
+	for _, r := range raw {
+		delegate := Delegate{Baker: primary}
+		delegate.Baker.Address = ""
+		delegate.Baker.Blacklist = nil
+		delegate.Baker.DexterLiquidityContracts = nil
+		delegate.Baker.PayoutSchedules = nil
+		delegate.Baker.PayoutAddresses = nil
+		delegate.Baker.Fees = nil
+		delegate.Baker.BondPool = nil
+		delegate.Baker.SweepAddress = ""
+
+		if err := json.Unmarshal(r, &delegate); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse '%s'", path)
+		}
+
+		delegate.Baker.Blacklist = cleanList(delegate.Baker.Blacklist)
+		delegate.Baker.DexterLiquidityContracts = cleanList(delegate.Baker.DexterLiquidityContracts)
+		delegate.Baker.PayoutSchedules = cleanList(delegate.Baker.PayoutSchedules)
+		delegate.Baker.PayoutAddresses = cleanList(delegate.Baker.PayoutAddresses)
+		delegate.Baker.Fees = cleanList(delegate.Baker.Fees)
+		delegate.Baker.BondPool = cleanList(delegate.Baker.BondPool)
+		delegate.Baker.Campaigns = cleanList(delegate.Baker.Campaigns)
+		delegates = append(delegates, delegate)
+	}
+
+	return delegates, nil
+}
+
 func cleanList(list []string) []string {
 	var out []string
 	for _, element := range list {
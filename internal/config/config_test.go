@@ -1,8 +1,10 @@
 package config
 
 import (
+	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/goat-systems/tzpay/v3/internal/test"
 	"github.com/stretchr/testify/assert"
@@ -38,11 +40,15 @@ func Test_NewDryRunEnviroment(t *testing.T) {
 				false,
 				"",
 				Config{
-					API{
-						TZKT:  "https://api.tzkt.io",
-						Tezos: "https://tezos.giganode.io/",
+					API: API{
+						TZKT:        "https://api.tzkt.io",
+						Tezos:       "https://tezos.giganode.io/",
+						Indexer:     "tzkt",
+						TzStats:     "https://api.tzstats.com",
+						Timeout:     10 * time.Second,
+						Concurrency: 4,
 					},
-					Baker{
+					Baker: Baker{
 						Address:        "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
 						Fee:            0.05,
 						MinimumPayment: 1000,
@@ -55,17 +61,39 @@ func Test_NewDryRunEnviroment(t *testing.T) {
 							"some_contract",
 							"some_contract_2",
 						},
+						DenunciationPolicy: "pay",
+						Rounding:           "floor",
 					},
-					Key{
+					Key: Key{
 						Esk:      "some_esk",
 						Password: "some_pass",
 					},
-					Operations{
-						NetworkFee: 2941,
-						GasLimit:   26283,
-						BatchSize:  125,
+					Operations: Operations{
+						NetworkFee:     2941,
+						GasLimit:       26283,
+						BatchSize:      125,
+						DuplicateCheck: true,
+						EstimateMargin: 10,
+						BumpAttempts:   3,
+						BumpIncrement:  1000,
+						MaxAttempts:    60,
+					},
+					Notifications: Notifications{
+						Telegram: Telegram{ConfirmTimeout: time.Hour},
+					},
+					Store: Store{
+						Path: "tzpay.json",
+					},
+					Sync: Sync{
+						MaxCycles: 20,
+						Delay:     time.Second,
+					},
+					Catchup: Catchup{
+						MaxCycles: 3,
+					},
+					CrossCheck: CrossCheck{
+						Tolerance: 1,
 					},
-					Notifications{},
 				},
 			},
 		},
@@ -85,11 +113,15 @@ func Test_NewDryRunEnviroment(t *testing.T) {
 				true,
 				"invalid input",
 				Config{
-					API{
-						TZKT:  "https://api.tzkt.io",
-						Tezos: "https://tezos.giganode.io/",
+					API: API{
+						TZKT:        "https://api.tzkt.io",
+						Tezos:       "https://tezos.giganode.io/",
+						Indexer:     "tzkt",
+						TzStats:     "https://api.tzstats.com",
+						Timeout:     10 * time.Second,
+						Concurrency: 4,
 					},
-					Baker{
+					Baker: Baker{
 						Address:        "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
 						MinimumPayment: 1000,
 						EarningsOnly:   true,
@@ -101,17 +133,39 @@ func Test_NewDryRunEnviroment(t *testing.T) {
 							"some_contract",
 							"some_contract_2",
 						},
+						DenunciationPolicy: "pay",
+						Rounding:           "floor",
 					},
-					Key{
+					Key: Key{
 						Esk:      "some_esk",
 						Password: "some_pass",
 					},
-					Operations{
-						NetworkFee: 2941,
-						GasLimit:   26283,
-						BatchSize:  125,
+					Operations: Operations{
+						NetworkFee:     2941,
+						GasLimit:       26283,
+						BatchSize:      125,
+						DuplicateCheck: true,
+						EstimateMargin: 10,
+						BumpAttempts:   3,
+						BumpIncrement:  1000,
+						MaxAttempts:    60,
+					},
+					Notifications: Notifications{
+						Telegram: Telegram{ConfirmTimeout: time.Hour},
+					},
+					Store: Store{
+						Path: "tzpay.json",
+					},
+					Sync: Sync{
+						MaxCycles: 20,
+						Delay:     time.Second,
+					},
+					Catchup: Catchup{
+						MaxCycles: 3,
+					},
+					CrossCheck: CrossCheck{
+						Tolerance: 1,
 					},
-					Notifications{},
 				},
 			},
 		},
@@ -139,3 +193,207 @@ func unsetEnv(env map[string]string) {
 		os.Unsetenv(key)
 	}
 }
+
+func Test_loadDelegates(t *testing.T) {
+	type want struct {
+		err       bool
+		contains  string
+		delegates []Delegate
+	}
+
+	primary := Baker{
+		Address:        "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+		Fee:            0.05,
+		MinimumPayment: 1000,
+		Blacklist:      []string{"some_address"},
+	}
+
+	cases := []struct {
+		name  string
+		input string
+		want  want
+	}{
+		{
+			"is successful",
+			`[{"baker":{"address":"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV","fee":0.1,"blacklist":["some_address_2  "]},"key":{"esk":"some_esk","password":"some_pass"}}]`,
+			want{
+				false,
+				"",
+				[]Delegate{
+					{
+						Baker: Baker{
+							Address:        "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV",
+							Fee:            0.1,
+							MinimumPayment: 1000,
+							Blacklist:      []string{"some_address_2"},
+						},
+						Key: Key{
+							Esk:      "some_esk",
+							Password: "some_pass",
+						},
+					},
+				},
+			},
+		},
+		{
+			"handles invalid json",
+			`{"baker":`,
+			want{
+				true,
+				"failed to parse",
+				nil,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ioutil.TempFile("", "delegates")
+			assert.Nil(t, err)
+			defer os.Remove(file.Name())
+			file.WriteString(tt.input)
+			file.Close()
+
+			delegates, err := loadDelegates(file.Name(), primary)
+			test.CheckErr(t, tt.want.err, tt.want.contains, err)
+			assert.Equal(t, tt.want.delegates, delegates)
+		})
+	}
+}
+
+func Test_Bakers(t *testing.T) {
+	conf := Config{
+		Baker:   Baker{Address: "some_baker", Fee: 0.05},
+		Key:     Key{Esk: "some_esk", Password: "some_pass"},
+		Funding: Funding{Enabled: true, Esk: "some_baker_esk", Password: "some_baker_pass"},
+		Delegates: []Delegate{
+			{
+				Baker: Baker{Address: "some_other_baker", Fee: 0.1},
+				Key:   Key{Esk: "some_other_esk", Password: "some_other_pass"},
+			},
+		},
+	}
+
+	bakers := conf.Bakers()
+	assert.Len(t, bakers, 2)
+	assert.Equal(t, "some_baker", bakers[0].Baker.Address)
+	assert.Equal(t, "some_other_baker", bakers[1].Baker.Address)
+	assert.Equal(t, Key{Esk: "some_other_esk", Password: "some_other_pass"}, bakers[1].Key)
+	assert.Equal(t, Funding{Enabled: true, Esk: "some_baker_esk", Password: "some_baker_pass"}, bakers[0].Funding)
+	assert.Equal(t, Funding{}, bakers[1].Funding)
+	assert.Nil(t, bakers[1].Delegates)
+
+	c, err := conf.ForBaker("")
+	assert.Nil(t, err)
+	assert.Equal(t, "some_baker", c.Baker.Address)
+
+	c, err = conf.ForBaker("some_other_baker")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.1, c.Baker.Fee)
+
+	_, err = conf.ForBaker("unknown_baker")
+	test.CheckErr(t, true, "is not configured", err)
+}
+
+func Test_Schedule(t *testing.T) {
+	baker := Baker{PayoutSchedules: []string{"tz1a:weekly", "tz1b:monthly"}}
+	assert.Equal(t, ScheduleWeekly, baker.Schedule("tz1a"))
+	assert.Equal(t, ScheduleMonthly, baker.Schedule("tz1b"))
+	assert.Equal(t, "", baker.Schedule("tz1c"))
+
+	assert.Nil(t, validateSchedules(baker.PayoutSchedules))
+	test.CheckErr(t, true, "invalid payout schedule 'tz1a:daily'", validateSchedules([]string{"tz1a:daily"}))
+	test.CheckErr(t, true, "invalid payout schedule 'tz1a'", validateSchedules([]string{"tz1a"}))
+}
+
+func Test_PayoutAddress(t *testing.T) {
+	baker := Baker{PayoutAddresses: []string{"tz1a:tz1cold"}}
+	assert.Equal(t, "tz1cold", baker.PayoutAddress("tz1a"))
+	assert.Equal(t, "tz1b", baker.PayoutAddress("tz1b"))
+
+	assert.Nil(t, validatePayoutAddresses(baker.PayoutAddresses))
+	test.CheckErr(t, true, "invalid payout address 'tz1a:'", validatePayoutAddresses([]string{"tz1a:"}))
+}
+
+func Test_FeeFor(t *testing.T) {
+	baker := Baker{Fee: 0.05, Fees: []string{"tz1a:0.02"}}
+	assert.Equal(t, 0.02, baker.FeeFor("tz1a"))
+	assert.Equal(t, 0.05, baker.FeeFor("tz1b"))
+
+	assert.Nil(t, validateFees(baker.Fees))
+	test.CheckErr(t, true, "invalid fee 'tz1a'", validateFees([]string{"tz1a"}))
+	test.CheckErr(t, true, "invalid fee 'tz1a:5'", validateFees([]string{"tz1a:5"}))
+}
+
+func Test_validateBondPool(t *testing.T) {
+	assert.Nil(t, validateBondPool([]string{"tz1a:60", "tz1b:40.5"}))
+	test.CheckErr(t, true, "invalid bond pool member 'tz1a'", validateBondPool([]string{"tz1a"}))
+	test.CheckErr(t, true, "expected a positive weight", validateBondPool([]string{"tz1a:0"}))
+}
+
+func Test_ParseCampaigns(t *testing.T) {
+	campaigns, err := ParseCampaigns([]string{"300-310:0.02", "311-320:x1.1:20"})
+	assert.Nil(t, err)
+	assert.Equal(t, []Campaign{
+		{Spec: "300-310:0.02", First: 300, Last: 310, Fee: 0.02},
+		{Spec: "311-320:x1.1:20", First: 311, Last: 320, Bonus: 1.1, Cycles: 20},
+	}, campaigns)
+
+	_, err = ParseCampaigns([]string{"300:0.02"})
+	test.CheckErr(t, true, "expected a window of cycles", err)
+	_, err = ParseCampaigns([]string{"310-300:0.02"})
+	test.CheckErr(t, true, "expected a window of cycles", err)
+	_, err = ParseCampaigns([]string{"300-310:2"})
+	test.CheckErr(t, true, "expected a fee between 0 and 1", err)
+	_, err = ParseCampaigns([]string{"300-310:x0.9"})
+	test.CheckErr(t, true, "expected a bonus multiplier above 1", err)
+	_, err = ParseCampaigns([]string{"300-310:0.02:0"})
+	test.CheckErr(t, true, "expected a positive number of cycles", err)
+	_, err = ParseCampaigns([]string{"300-310"})
+	test.CheckErr(t, true, "invalid campaign '300-310'", err)
+}
+
+func Test_LoadEnvFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv("TZPAY_BAKER", "tz1env")
+	defer os.Unsetenv("TZPAY_BAKER")
+	defer os.Unsetenv("TZPAY_BAKER_FEE")
+	defer os.Unsetenv("TZPAY_WALLET_PASSWORD")
+
+	path := dir + "/tzpay.env"
+	assert.Nil(t, ioutil.WriteFile(path, []byte("# baker\nTZPAY_BAKER=tz1file\n\nexport TZPAY_BAKER_FEE=0.05\nTZPAY_WALLET_PASSWORD=\"pass=word\"\n"), 0600))
+	assert.Nil(t, LoadEnvFile(path))
+	assert.Equal(t, "tz1file", os.Getenv("TZPAY_BAKER"))
+	assert.Equal(t, "0.05", os.Getenv("TZPAY_BAKER_FEE"))
+	assert.Equal(t, "pass=word", os.Getenv("TZPAY_WALLET_PASSWORD"))
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("TZPAY_BAKER=tz1file\nnot a variable\n"), 0600))
+	test.CheckErr(t, true, "invalid line 2", LoadEnvFile(path))
+
+	test.CheckErr(t, true, "failed to read enviroment file", LoadEnvFile(dir+"/missing.env"))
+}
+
+func Test_readPasswordFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := dir + "/password"
+	assert.Nil(t, ioutil.WriteFile(path, []byte("pass word\r\n"), 0600))
+
+	key := Key{Esk: "edesk...", PasswordFile: path}
+	assert.Nil(t, readPasswordFile(&key))
+	assert.Equal(t, "pass word", key.Password)
+
+	key = Key{Esk: "edesk...", Password: "env", PasswordFile: path}
+	assert.Nil(t, readPasswordFile(&key))
+	assert.Equal(t, "env", key.Password)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("\n"), 0600))
+	test.CheckErr(t, true, "is empty", readPasswordFile(&Key{PasswordFile: path}))
+
+	test.CheckErr(t, true, "failed to read password file", readPasswordFile(&Key{PasswordFile: dir + "/missing"}))
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Reload loads the configuration again, as New does, from the enviroment with the variables of the enviroment file at
+path set, if path is set. The configuration is also validated by check, if set. A configuration that fails to load or
+to validate is returned as an error and the enviroment is left as it was, so that a bad edit is never picked up later.
+*/
+func Reload(path string, check func(Config) error) (Config, error) {
+	var vars map[string]string
+	if path != "" {
+		var err error
+		if vars, err = ReadEnvFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	previous := map[string]*string{}
+	restore := func() {
+		for name, value := range previous {
+			if value == nil {
+				os.Unsetenv(name)
+			} else {
+				os.Setenv(name, *value)
+			}
+		}
+	}
+
+	for name, value := range vars {
+		if old, ok := os.LookupEnv(name); ok {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		if err := os.Setenv(name, value); err != nil {
+			restore()
+			return Config{}, errors.Wrapf(err, "failed to set '%s'", name)
+		}
+	}
+
+	config, err := New()
+	if err == nil && check != nil {
+		err = check(config)
+	}
+	if err != nil {
+		restore()
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+/*
+KeepStartup returns loaded with the settings that tzpay serv sets up once when it starts taken from running, and the
+variables of those that loaded changes, which take a restart: the node and indexer, the store, the HTTP API, the
+history sync, the overrides, scheduling, approvals, catching up and the payout wallets. Bakers cannot be added or
+removed without a restart either, an error is returned if loaded does.
+*/
+func KeepStartup(running, loaded Config) (Config, []string, error) {
+	if before, after := addresses(running), addresses(loaded); !reflect.DeepEqual(before, after) {
+		return loaded, nil, errors.Errorf("bakers changed from %v to %v, which takes a restart", before, after)
+	}
+
+	var changed []string
+	keep := func(variables string, running, loaded interface{}) {
+		if !reflect.DeepEqual(running, loaded) {
+			changed = append(changed, variables)
+		}
+	}
+
+	keep("TZPAY_API_*", running.API, loaded.API)
+	keep("TZPAY_STORE_*", running.Store, loaded.Store)
+	keep("TZPAY_HTTP_*", running.HTTP, loaded.HTTP)
+	keep("TZPAY_SYNC_*", running.Sync, loaded.Sync)
+	keep("TZPAY_OVERRIDES_*", running.Overrides, loaded.Overrides)
+	keep("TZPAY_SCHEDULING_*", running.Scheduling, loaded.Scheduling)
+	keep("TZPAY_APPROVAL_*", running.Approval, loaded.Approval)
+	keep("TZPAY_CATCHUP_*", running.Catchup, loaded.Catchup)
+	loaded.API, loaded.Store, loaded.HTTP, loaded.Sync = running.API, running.Store, running.HTTP, running.Sync
+	loaded.Overrides, loaded.Scheduling, loaded.Approval = running.Overrides, running.Scheduling, running.Approval
+	loaded.Catchup = running.Catchup
+
+	// The keys of the running configuration hold the passwords unlocked when the server started
+	keys := map[string]Key{}
+	for _, config := range running.Bakers() {
+		keys[config.Baker.Address] = config.Key
+	}
+	keepKey := func(baker string, key *Key) {
+		if !sameKey(keys[baker], *key) {
+			changed = append(changed, "TZPAY_WALLET_* of "+baker)
+		}
+		*key = keys[baker]
+	}
+
+	keepKey(loaded.Baker.Address, &loaded.Key)
+	if loaded.Delegates != nil {
+		delegates := make([]Delegate, len(loaded.Delegates))
+		for i, delegate := range loaded.Delegates {
+			keepKey(delegate.Baker.Address, &delegate.Key)
+			delegates[i] = delegate
+		}
+		loaded.Delegates = delegates
+	}
+
+	return loaded, changed, nil
+}
+
+// addresses returns the addresses of the bakers of c, sorted
+func addresses(c Config) []string {
+	var addresses []string
+	for _, config := range c.Bakers() {
+		addresses = append(addresses, config.Baker.Address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// sameKey returns true if a and b are the same payout wallet, whether their password is unlocked or not
+func sameKey(a, b Key) bool {
+	a.Password, b.Password = "", ""
+	return reflect.DeepEqual(a, b)
+}
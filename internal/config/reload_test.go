@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-config")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv("TZPAY_BAKER", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc")
+	os.Setenv("TZPAY_BAKER_FEE", "0.05")
+	os.Setenv("TZPAY_WALLET_ESK", "some_esk")
+	os.Setenv("TZPAY_WALLET_PASSWORD", "some_pass")
+	for _, name := range []string{"TZPAY_BAKER", "TZPAY_BAKER_FEE", "TZPAY_WALLET_ESK", "TZPAY_WALLET_PASSWORD", "TZPAY_BAKER_MINIMUM_PAYMENT"} {
+		defer os.Unsetenv(name)
+	}
+
+	path := dir + "/tzpay.env"
+	assert.Nil(t, ioutil.WriteFile(path, []byte("TZPAY_BAKER_FEE=0.07\nTZPAY_BAKER_MINIMUM_PAYMENT=5000\n"), 0600))
+	cfg, err := Reload(path, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.07, cfg.Baker.Fee)
+	assert.Equal(t, 5000, cfg.Baker.MinimumPayment)
+
+	// A configuration that does not validate leaves the enviroment as it was
+	assert.Nil(t, ioutil.WriteFile(path, []byte("TZPAY_BAKER_FEE=0.08\nTZPAY_BAKER_MINIMUM_PAYMENT=not_a_number\n"), 0600))
+	_, err = Reload(path, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "0.07", os.Getenv("TZPAY_BAKER_FEE"))
+	assert.Equal(t, "5000", os.Getenv("TZPAY_BAKER_MINIMUM_PAYMENT"))
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("TZPAY_BAKER_FEE=0.08\n"), 0600))
+	_, err = Reload(path, func(Config) error { return errors.New("some_error") })
+	test.CheckErr(t, true, "some_error", err)
+	assert.Equal(t, "0.07", os.Getenv("TZPAY_BAKER_FEE"))
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("not a variable\n"), 0600))
+	_, err = Reload(path, nil)
+	test.CheckErr(t, true, "invalid line 1", err)
+}
+
+func Test_KeepStartup(t *testing.T) {
+	running := Config{
+		API:   API{Tezos: "https://tezos.giganode.io/"},
+		Baker: Baker{Address: "tz1a", Fee: 0.05},
+		Key:   Key{Esk: "some_esk", Password: "unlocked"},
+		Delegates: []Delegate{
+			{Baker: Baker{Address: "tz1b"}, Key: Key{Esk: "other_esk", Password: "unlocked"}},
+		},
+	}
+
+	loaded := running
+	loaded.Baker.Fee = 0.07
+	loaded.Key.Password = ""
+	loaded.Delegates = []Delegate{{Baker: Baker{Address: "tz1b"}, Key: Key{Esk: "other_esk"}}}
+	kept, changed, err := KeepStartup(running, loaded)
+	assert.Nil(t, err)
+	assert.Empty(t, changed)
+	assert.Equal(t, 0.07, kept.Baker.Fee)
+	assert.Equal(t, "unlocked", kept.Key.Password)
+	assert.Equal(t, "unlocked", kept.Delegates[0].Key.Password)
+
+	loaded.API.Tezos = "http://localhost:8732"
+	loaded.Delegates = []Delegate{{Baker: Baker{Address: "tz1b"}, Key: Key{Esk: "new_esk"}}}
+	kept, changed, err = KeepStartup(running, loaded)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"TZPAY_API_*", "TZPAY_WALLET_* of tz1b"}, changed)
+	assert.Equal(t, "https://tezos.giganode.io/", kept.API.Tezos)
+	assert.Equal(t, "other_esk", kept.Delegates[0].Key.Esk)
+	assert.Equal(t, "new_esk", loaded.Delegates[0].Key.Esk)
+
+	loaded.Delegates = nil
+	_, _, err = KeepStartup(running, loaded)
+	test.CheckErr(t, true, "bakers changed from [tz1a tz1b] to [tz1a], which takes a restart", err)
+}
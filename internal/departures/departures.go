@@ -0,0 +1,311 @@
+package departures
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"text/template"
+	"time"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const cursorBucket = "departures"
+
+// DefaultTemplate is the notification sent for a departure when no template is configured
+const DefaultTemplate = `[TZPAY] {{.Address}} left {{.Baker}} in cycle {{.Cycle}}. ` +
+	`Pending rewards: {{.PendingTez}} XTZ for cycles {{.FirstCycle}} to {{.LastCycle}}` +
+	`{{if .UnknownCycles}} ({{.UnknownCycles}} more cycles not known yet){{end}}, ` +
+	`last payout expected around {{.PaidAt.Format "2006-01-02 15:04 MST"}}.`
+
+/*
+Departure is a delegator that undelegated from the baker. Its snapshots still earn rewards up to LastCycle, and
+PendingRewards estimates what it will be paid for the cycles not paid yet whose rights are already known.
+*/
+type Departure struct {
+	Baker          string    `json:"baker"`
+	Address        string    `json:"address"`
+	Operation      string    `json:"operation"`
+	Level          int       `json:"level"`
+	Time           time.Time `json:"time"`
+	Cycle          int       `json:"cycle"`
+	FirstCycle     int       `json:"first_cycle"`
+	LastCycle      int       `json:"last_cycle"`
+	UnknownCycles  int       `json:"unknown_cycles,omitempty"`
+	PendingRewards int       `json:"pending_rewards"`
+	PaidAt         time.Time `json:"paid_at"`
+}
+
+// PendingTez returns the pending rewards in tez
+func (d Departure) PendingTez() string {
+	return fmt.Sprintf("%.6f", float64(d.PendingRewards)/float64(gotezos.MUTEZ))
+}
+
+// WatcherInput is the input for NewWatcher
+type WatcherInput struct {
+	RPC      rpc.IFace
+	Tzkt     tzkt.IFace
+	Store    store.IFace
+	Config   config.Config
+	Notifier *notifier.PayoutNotifier
+	Template string
+}
+
+/*
+Watcher notifies of the delegators leaving a baker, with their pending rewards and when they will be paid, and
+records every departure in the audit log. The last level checked is kept as a cursor in the store, so departures
+are reported once, and only those following the first check.
+*/
+type Watcher struct {
+	rpc      rpc.IFace
+	tzkt     tzkt.IFace
+	store    store.IFace
+	config   config.Config
+	notifier *notifier.PayoutNotifier
+	template *template.Template
+	estimate func(cycle int) (tzkt.RewardsSplit, error)
+}
+
+// NewWatcher returns a new Watcher, or an error if its template does not parse
+func NewWatcher(input WatcherInput) (*Watcher, error) {
+	text := input.Template
+	if text == "" {
+		text = DefaultTemplate
+	}
+
+	tmpl, err := template.New("departure").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse departure template")
+	}
+
+	w := &Watcher{
+		rpc:      input.RPC,
+		tzkt:     input.Tzkt,
+		store:    input.Store,
+		config:   input.Config,
+		notifier: input.Notifier,
+		template: tmpl,
+	}
+	w.estimate = w.estimatePayout
+
+	return w, nil
+}
+
+// Start checks for departures every interval until the process exits
+func (w *Watcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for {
+			if _, err := w.Check(); err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "baker": w.config.Baker.Address}).Error("Failed to check for departed delegators.")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Check reports the delegators that left the baker since the last check
+func (w *Watcher) Check() ([]Departure, error) {
+	head, err := w.rpc.Head()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get current level")
+	}
+
+	var cursor int
+	ok, err := w.store.Get(cursorBucket, w.config.Baker.Address, &cursor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load departures cursor")
+	}
+	if !ok {
+		return nil, w.saveCursor(head.Header.Level)
+	}
+
+	delegations, err := w.tzkt.GetDelegations(
+		tzkt.URLParameters{Key: "prevDelegate", Value: w.config.Baker.Address},
+		tzkt.URLParameters{Key: "level.gt", Value: strconv.Itoa(cursor)},
+		tzkt.URLParameters{Key: "level.le", Value: strconv.Itoa(head.Header.Level)},
+		tzkt.URLParameters{Key: "status", Value: "applied"},
+		tzkt.URLParameters{Key: "limit", Value: "10000"},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get departed delegators")
+	}
+
+	var departures []Departure
+	if len(delegations) > 0 {
+		constants, err := w.rpc.Constants(head.Hash)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get network constants")
+		}
+
+		for _, delegation := range delegations {
+			departure, err := w.departure(delegation, head, constants)
+			if err != nil {
+				return departures, err
+			}
+
+			if err := w.report(departure); err != nil {
+				return departures, err
+			}
+			departures = append(departures, departure)
+		}
+	}
+
+	return departures, w.saveCursor(head.Header.Level)
+}
+
+func (w *Watcher) saveCursor(level int) error {
+	if err := w.store.Put(cursorBucket, w.config.Baker.Address, level); err != nil {
+		return errors.Wrap(err, "failed to save departures cursor")
+	}
+
+	return nil
+}
+
+/*
+departure works out the cycles a departed delegator is still owed rewards for: rights are computed from a snapshot
+taken PreservedCycles+2 cycles earlier, so the snapshots of the cycle it left in earn rewards up to PreservedCycles+2
+cycles later. Rewards are estimated for the cycles not paid yet whose rights are known.
+*/
+func (w *Watcher) departure(delegation tzkt.Delegation, head *rpc.Block, constants rpc.Constants) (Departure, error) {
+	cycle, err := payout.LevelCycle(w.tzkt, delegation.Level)
+	if err != nil {
+		return Departure{}, errors.Wrap(err, "failed to get cycle of departure")
+	}
+
+	departure := Departure{
+		Baker:      w.config.Baker.Address,
+		Address:    delegation.Sender.Address,
+		Operation:  delegation.Hash,
+		Level:      delegation.Level,
+		Time:       delegation.Timestamp,
+		Cycle:      cycle,
+		FirstCycle: cycle,
+		LastCycle:  cycle + constants.PreservedCycles + 2,
+	}
+
+	records, err := payout.Records(w.store, w.config.Baker.Address)
+	if err != nil {
+		return departure, errors.Wrap(err, "failed to estimate pending rewards")
+	}
+	for _, record := range records {
+		if record.Cycle+1 > departure.FirstCycle && record.Cycle < departure.LastCycle {
+			departure.FirstCycle = record.Cycle + 1
+		}
+	}
+
+	known := head.Metadata.Level.Cycle + constants.PreservedCycles
+	for c := departure.FirstCycle; c <= departure.LastCycle; c++ {
+		if c > known {
+			departure.UnknownCycles++
+			continue
+		}
+
+		rewardsSplit, err := w.estimate(c)
+		if err != nil {
+			return departure, errors.Wrapf(err, "failed to estimate pending rewards for cycle %d", c)
+		}
+		for _, delegator := range rewardsSplit.Delegators {
+			if delegator.Address == departure.Address {
+				departure.PendingRewards += delegator.NetRewards
+			}
+		}
+	}
+
+	// The last cycle is paid once it ends, or once its rewards are unfrozen
+	paidCycle := departure.LastCycle + 1
+	if w.config.Baker.PayoutWhenRewardsUnfrozen {
+		paidCycle += constants.PreservedCycles
+	}
+	paidAt, err := w.firstLevel(paidCycle, cycle, constants)
+	if err != nil {
+		return departure, errors.Wrap(err, "failed to estimate last payout")
+	}
+	departure.PaidAt = estimateTime(head, constants, paidAt)
+
+	return departure, nil
+}
+
+/*
+firstLevel returns the first level of cycle, counted from the first level of known, a cycle the indexer has reached, with
+the number of blocks per cycle of the head, so that a cycle to come is placed after any change of it.
+*/
+func (w *Watcher) firstLevel(cycle, known int, constants rpc.Constants) (int, error) {
+	first, _, err := payout.CycleLevels(w.tzkt, known)
+	if err != nil {
+		return 0, err
+	}
+
+	return first + (cycle-known)*constants.BlocksPerCycle, nil
+}
+
+func (w *Watcher) estimatePayout(cycle int) (tzkt.RewardsSplit, error) {
+	p, err := payout.New(w.config, cycle, false, false)
+	if err != nil {
+		return tzkt.RewardsSplit{}, err
+	}
+	p.SetFutureRewards()
+
+	return p.Execute()
+}
+
+// estimateTime estimates when level is baked from the head and the minimal time between blocks
+func estimateTime(head *rpc.Block, constants rpc.Constants, level int) time.Time {
+	seconds := 60
+	if len(constants.TimeBetweenBlocks) > 0 {
+		if s, err := strconv.Atoi(constants.TimeBetweenBlocks[0]); err == nil {
+			seconds = s
+		}
+	}
+
+	return head.Header.Timestamp.Add(time.Duration(level-head.Header.Level) * time.Duration(seconds) * time.Second)
+}
+
+// report notifies of a departure and records it in the audit log
+func (w *Watcher) report(departure Departure) error {
+	var msg bytes.Buffer
+	if err := w.template.Execute(&msg, departure); err != nil {
+		return errors.Wrap(err, "failed to render departure notification")
+	}
+
+	log.WithFields(log.Fields{
+		"baker":           departure.Baker,
+		"delegator":       departure.Address,
+		"pending-rewards": departure.PendingRewards,
+		"last-cycle":      departure.LastCycle,
+	}).Info("Delegator left.")
+
+	if w.notifier != nil {
+		if err := w.notifier.Notify(msg.String()); err != nil {
+			log.WithField("error", err.Error()).Error("Failed to notify.")
+		}
+	}
+
+	err := audit.Record(w.store, audit.Event{
+		Action: "departure",
+		Baker:  departure.Baker,
+		Details: map[string]string{
+			"delegator":       departure.Address,
+			"operation":       departure.Operation,
+			"left_at":         departure.Time.UTC().Format(time.RFC3339),
+			"pending_rewards": strconv.Itoa(departure.PendingRewards),
+			"first_cycle":     strconv.Itoa(departure.FirstCycle),
+			"last_cycle":      strconv.Itoa(departure.LastCycle),
+			"paid_at":         departure.PaidAt.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to record departure")
+	}
+
+	return nil
+}
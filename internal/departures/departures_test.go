@@ -0,0 +1,237 @@
+package departures
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/audit"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+var headTime = time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+
+type rpcMock struct {
+	test.RPCMock
+	level int
+}
+
+func (r *rpcMock) Head() (*rpc.Block, error) {
+	block, err := r.RPCMock.Head()
+	block.Header.Level = r.level
+	block.Header.Timestamp = headTime
+	block.Metadata.Level.Cycle = (r.level - 1) / 2
+
+	return block, err
+}
+
+type tzktMock struct {
+	test.TzktMock
+	delegations []tzkt.Delegation
+	err         bool
+	params      []tzkt.URLParameters
+}
+
+func (t *tzktMock) GetDelegations(options ...tzkt.URLParameters) ([]tzkt.Delegation, error) {
+	if t.err {
+		return nil, errors.New("too many requests")
+	}
+	t.params = options
+
+	return t.delegations, nil
+}
+
+type notifierMock struct {
+	sent []string
+}
+
+func (n *notifierMock) Send(msg string) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func newNotifier(client notifier.ClientIFace) *notifier.PayoutNotifier {
+	payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{client}})
+	return &payoutNotifier
+}
+
+func newStore(t *testing.T) (store.IFace, func()) {
+	dir, err := ioutil.TempDir("", "tzpay-departures")
+	assert.Nil(t, err)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func newDelegation(address string, level int) tzkt.Delegation {
+	delegation := tzkt.Delegation{Level: level, Hash: "oo" + address, Timestamp: headTime}
+	delegation.Sender.Address = address
+
+	return delegation
+}
+
+func Test_Check(t *testing.T) {
+	s, cleanup := newStore(t)
+	defer cleanup()
+
+	// The payout of cycle 10 was injected already
+	assert.Nil(t, s.Put("payouts/tz1baker", "00000010/2020-10-01T00:00:00Z", payout.Record{Cycle: 10}))
+
+	client := &notifierMock{}
+	rpcClient := &rpcMock{level: 21} // cycle 10, with 2 blocks per cycle and 5 preserved cycles
+	indexer := &tzktMock{delegations: []tzkt.Delegation{newDelegation("tz1left", 21)}}
+	watcher, err := NewWatcher(WatcherInput{
+		RPC:      rpcClient,
+		Tzkt:     indexer,
+		Store:    s,
+		Config:   config.Config{Baker: config.Baker{Address: "tz1baker"}},
+		Notifier: newNotifier(client),
+	})
+	assert.Nil(t, err)
+
+	var estimated []int
+	watcher.estimate = func(cycle int) (tzkt.RewardsSplit, error) {
+		estimated = append(estimated, cycle)
+		return tzkt.RewardsSplit{Delegators: tzkt.Delegators{
+			{Address: "tz1left", NetRewards: 1000000},
+			{Address: "tz1stayed", NetRewards: 5000000},
+		}}, nil
+	}
+
+	departures, err := watcher.Check()
+	assert.Nil(t, err)
+	assert.Empty(t, departures, "the first check only sets the cursor")
+	assert.Empty(t, client.sent)
+
+	rpcClient.level = 23
+	departures, err = watcher.Check()
+	assert.Nil(t, err)
+	assert.Contains(t, indexer.params, tzkt.URLParameters{Key: "level.gt", Value: "21"})
+	assert.Equal(t, []Departure{{
+		Baker:          "tz1baker",
+		Address:        "tz1left",
+		Operation:      "ootz1left",
+		Level:          21,
+		Time:           headTime,
+		Cycle:          10,
+		FirstCycle:     11,
+		LastCycle:      17,
+		UnknownCycles:  1,
+		PendingRewards: 6000000,
+		PaidAt:         headTime.Add(14 * time.Minute),
+	}}, departures)
+	assert.Equal(t, []int{11, 12, 13, 14, 15, 16}, estimated)
+	assert.Len(t, client.sent, 1)
+	assert.Contains(t, client.sent[0], "tz1left left tz1baker in cycle 10. Pending rewards: 6.000000 XTZ for cycles 11 to 17 (1 more cycles not known yet)")
+
+	events, err := audit.List(s)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "departure", events[0].Action)
+	assert.Equal(t, "6000000", events[0].Details["pending_rewards"])
+
+	// Departures are reported once
+	rpcClient.level = 24
+	indexer.delegations = nil
+	departures, err = watcher.Check()
+	assert.Nil(t, err)
+	assert.Empty(t, departures)
+	assert.Contains(t, indexer.params, tzkt.URLParameters{Key: "level.gt", Value: "23"})
+	assert.Len(t, client.sent, 1)
+}
+
+func Test_CheckErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		rpc         rpcMock
+		tzkt        tzktMock
+		estimateErr bool
+		errContains string
+	}{
+		{"handles failure to get head", rpcMock{RPCMock: test.RPCMock{HeadErr: true}}, tzktMock{}, false, "failed to get current level"},
+		{"handles failure to get delegations", rpcMock{level: 30}, tzktMock{err: true}, false, "failed to get departed delegators"},
+		{"handles failure to get constants", rpcMock{RPCMock: test.RPCMock{ConstantsErr: true}, level: 30}, tzktMock{delegations: []tzkt.Delegation{newDelegation("tz1left", 25)}}, false, "failed to get network constants"},
+		{"handles failure to get cycle of departure", rpcMock{level: 30}, tzktMock{TzktMock: test.TzktMock{CycleErr: true}, delegations: []tzkt.Delegation{newDelegation("tz1left", 25)}}, false, "failed to get cycle of departure"},
+		{"handles failure to estimate rewards", rpcMock{level: 30}, tzktMock{delegations: []tzkt.Delegation{newDelegation("tz1left", 25)}}, true, "failed to estimate pending rewards for cycle 12"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, cleanup := newStore(t)
+			defer cleanup()
+
+			assert.Nil(t, s.Put(cursorBucket, "tz1baker", 20))
+
+			watcher, err := NewWatcher(WatcherInput{RPC: &tt.rpc, Tzkt: &tt.tzkt, Store: s, Config: config.Config{Baker: config.Baker{Address: "tz1baker"}}})
+			assert.Nil(t, err)
+			watcher.estimate = func(cycle int) (tzkt.RewardsSplit, error) {
+				if tt.estimateErr {
+					return tzkt.RewardsSplit{}, errors.New("rate limited")
+				}
+				return tzkt.RewardsSplit{}, nil
+			}
+
+			_, err = watcher.Check()
+			test.CheckErr(t, true, tt.errContains, err)
+		})
+	}
+}
+
+func Test_departure_CycleLevels(t *testing.T) {
+	s, cleanup := newStore(t)
+	defer cleanup()
+
+	// Cycle 8 spans 8 blocks, the cycles after it 2
+	indexer := &tzktMock{TzktMock: test.TzktMock{Cycles: map[int]tzkt.Cycle{8: {Index: 8, FirstLevel: 17, LastLevel: 24}}}}
+	watcher, err := NewWatcher(WatcherInput{Tzkt: indexer, Store: s, Config: config.Config{Baker: config.Baker{Address: "tz1baker"}}})
+	assert.Nil(t, err)
+	watcher.estimate = func(cycle int) (tzkt.RewardsSplit, error) { return tzkt.RewardsSplit{}, nil }
+
+	head, _ := (&rpcMock{level: 23}).Head()
+	constants, _ := (&test.RPCMock{}).Constants(head.Hash)
+	departure, err := watcher.departure(newDelegation("tz1left", 21), head, constants)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, departure.Cycle)
+	assert.Equal(t, 15, departure.LastCycle)
+	assert.Equal(t, headTime.Add(10*time.Minute), departure.PaidAt, "cycle 16 starts at level 17+8*2")
+}
+
+func Test_NewWatcher(t *testing.T) {
+	s, cleanup := newStore(t)
+	defer cleanup()
+
+	_, err := NewWatcher(WatcherInput{Template: "{{.Address"})
+	test.CheckErr(t, true, "failed to parse departure template", err)
+
+	watcher, err := NewWatcher(WatcherInput{
+		Store:    s,
+		Template: "{{.Address}} owed {{.PendingTez}} XTZ",
+	})
+	assert.Nil(t, err)
+
+	client := &notifierMock{}
+	watcher.notifier = newNotifier(client)
+	assert.Nil(t, watcher.report(Departure{Address: "tz1left", PendingRewards: 1500000}))
+	assert.Equal(t, []string{"tz1left owed 1.500000 XTZ"}, client.sent)
+}
+
+func Test_estimateTime(t *testing.T) {
+	head := &rpc.Block{}
+	head.Header.Level = 100
+	head.Header.Timestamp = headTime
+
+	assert.Equal(t, headTime.Add(20*time.Minute), estimateTime(head, rpc.Constants{}, 120))
+	assert.Equal(t, headTime.Add(10*time.Minute), estimateTime(head, rpc.Constants{TimeBetweenBlocks: []string{"30", "40"}}, 120))
+}
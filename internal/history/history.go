@@ -0,0 +1,171 @@
+package history
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const cursorBucket = "sync"
+
+// sleep is overridden in tests
+var sleep = time.Sleep
+
+// Cursor records how far the history of a baker was synced
+type Cursor struct {
+	Cycle   int       `json:"cycle"` // last cycle synced
+	Updated time.Time `json:"updated"`
+}
+
+// SyncerInput is the input for NewSyncer
+type SyncerInput struct {
+	RPC       rpc.IFace
+	Tzkt      tzkt.IFace
+	Store     store.IFace
+	Baker     string
+	FromCycle int
+	MaxCycles int
+	Delay     time.Duration
+}
+
+/*
+Syncer ingests the reward and delegation history of a baker from tzkt into the store, one cycle at a time.
+Only cycles whose rewards were unfrozen are synced, as their rewards can no longer change, so each cycle is fetched
+exactly once. The last cycle synced is kept as a cursor in the store, so an interrupted sync resumes where it stopped.
+*/
+type Syncer struct {
+	rpc       rpc.IFace
+	tzkt      tzkt.IFace
+	store     store.IFace
+	baker     string
+	fromCycle int
+	maxCycles int
+	delay     time.Duration
+}
+
+// NewSyncer returns a new Syncer
+func NewSyncer(input SyncerInput) *Syncer {
+	return &Syncer{
+		rpc:       input.RPC,
+		tzkt:      input.Tzkt,
+		store:     input.Store,
+		baker:     input.Baker,
+		fromCycle: input.FromCycle,
+		maxCycles: input.MaxCycles,
+		delay:     input.Delay,
+	}
+}
+
+// Start syncs the history every interval until the process exits
+func (s *Syncer) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for {
+			if synced, err := s.Sync(); err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "baker": s.baker}).Error("Failed to sync history.")
+			} else if synced > 0 {
+				log.WithFields(log.Fields{"cycles": synced, "baker": s.baker}).Info("Synced history.")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Sync ingests the cycles following the cursor, at most MaxCycles of them, and returns the number of cycles synced
+func (s *Syncer) Sync() (int, error) {
+	head, err := s.rpc.Head()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get current cycle")
+	}
+
+	constants, err := s.rpc.Constants(head.Hash)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get network constants")
+	}
+	last := head.Metadata.Level.Cycle - constants.PreservedCycles - 1
+
+	cursor, err := LoadCursor(s.store, s.baker)
+	if err != nil {
+		return 0, err
+	}
+
+	next := s.fromCycle
+	if cursor != nil && cursor.Cycle+1 > next {
+		next = cursor.Cycle + 1
+	}
+
+	var synced int
+	for cycle := next; cycle <= last; cycle++ {
+		if s.maxCycles > 0 && synced == s.maxCycles {
+			break
+		}
+
+		if synced > 0 {
+			sleep(s.delay)
+		}
+
+		rewardsSplit, err := s.tzkt.GetRewardsSplit(s.baker, cycle, tzkt.URLParameters{Key: "limit", Value: "10000"})
+		if err != nil {
+			return synced, errors.Wrapf(err, "failed to sync cycle %d", cycle)
+		}
+
+		if err := s.store.Put(Bucket(s.baker), key(cycle), rewardsSplit); err != nil {
+			return synced, errors.Wrapf(err, "failed to sync cycle %d", cycle)
+		}
+
+		if err := s.store.Put(cursorBucket, s.baker, Cursor{Cycle: cycle, Updated: time.Now().UTC()}); err != nil {
+			return synced, errors.Wrapf(err, "failed to save sync cursor for cycle %d", cycle)
+		}
+		synced++
+	}
+
+	return synced, nil
+}
+
+// Bucket returns the store bucket holding the history of baker
+func Bucket(baker string) string {
+	return "history/" + baker
+}
+
+// key zero pads cycles so that keys sort by cycle
+func key(cycle int) string {
+	return fmt.Sprintf("%08d", cycle)
+}
+
+// LoadCursor returns the sync cursor of baker, or nil if its history was never synced
+func LoadCursor(s store.IFace, baker string) (*Cursor, error) {
+	var cursor Cursor
+	ok, err := s.Get(cursorBucket, baker, &cursor)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load sync cursor")
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return &cursor, nil
+}
+
+// Cycles returns the synced history of baker, oldest cycle first
+func Cycles(s store.IFace, baker string) ([]tzkt.RewardsSplit, error) {
+	keys, err := s.Keys(Bucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list history")
+	}
+
+	cycles := []tzkt.RewardsSplit{}
+	for _, key := range keys {
+		var rewardsSplit tzkt.RewardsSplit
+		if _, err := s.Get(Bucket(baker), key, &rewardsSplit); err != nil {
+			return nil, errors.Wrap(err, "failed to list history")
+		}
+		cycles = append(cycles, rewardsSplit)
+	}
+
+	return cycles, nil
+}
@@ -0,0 +1,102 @@
+package history
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type tzktMock struct {
+	tzkt.IFace
+	failAt int
+	cycles []int
+}
+
+func (t *tzktMock) GetRewardsSplit(delegate string, cycle int, options ...tzkt.URLParameters) (tzkt.RewardsSplit, error) {
+	if cycle == t.failAt {
+		return tzkt.RewardsSplit{}, errors.New("too many requests")
+	}
+	t.cycles = append(t.cycles, cycle)
+
+	return tzkt.RewardsSplit{Cycle: cycle, NumDelegators: 1, Delegators: tzkt.Delegators{{Address: "tz1a", Balance: cycle}}}, nil
+}
+
+func Test_Sync(t *testing.T) {
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	dir, err := ioutil.TempDir("", "tzpay-history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	indexer := &tzktMock{failAt: 13}
+	syncer := NewSyncer(SyncerInput{
+		RPC:       &test.RPCMock{HeadCycle: 20}, // cycles up to 14 are unfrozen
+		Tzkt:      indexer,
+		Store:     s,
+		Baker:     "tz1baker",
+		FromCycle: 10,
+		MaxCycles: 2,
+		Delay:     time.Second,
+	})
+
+	synced, err := syncer.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, synced)
+	assert.Equal(t, []time.Duration{time.Second}, slept)
+
+	synced, err = syncer.Sync()
+	test.CheckErr(t, true, "failed to sync cycle 13", err)
+	assert.Equal(t, 1, synced)
+
+	cursor, err := LoadCursor(s, "tz1baker")
+	assert.Nil(t, err)
+	assert.Equal(t, 12, cursor.Cycle)
+
+	indexer.failAt = 0
+	synced, err = syncer.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, synced)
+
+	synced, err = syncer.Sync()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, synced)
+	assert.Equal(t, []int{10, 11, 12, 13, 14}, indexer.cycles)
+
+	cycles, err := Cycles(s, "tz1baker")
+	assert.Nil(t, err)
+	assert.Len(t, cycles, 5)
+	assert.Equal(t, 10, cycles[0].Cycle)
+	assert.Equal(t, 14, cycles[4].Delegators[0].Balance)
+}
+
+func Test_SyncErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		rpc         test.RPCMock
+		errContains string
+	}{
+		{"handles failure to get head", test.RPCMock{HeadErr: true}, "failed to get current cycle"},
+		{"handles failure to get constants", test.RPCMock{ConstantsErr: true}, "failed to get network constants"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			syncer := NewSyncer(SyncerInput{RPC: &tt.rpc, Baker: "tz1baker"})
+			_, err := syncer.Sync()
+			test.CheckErr(t, true, tt.errContains, err)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Package indexer returns the client of the indexer tzpay reads the rewards and the history of the chain from, tzkt or
+TzStats. Either is used through tzkt.IFace, TzStats responses being normalized into the structures of tzkt.
+*/
+package indexer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/goat-systems/tzpay/v3/internal/tzstats"
+)
+
+// Indexers
+const (
+	Tzkt    = "tzkt"
+	TzStats = "tzstats"
+)
+
+// New returns the client of the indexer of api, tzkt unless TzStats is configured
+func New(api config.API) tzkt.IFace {
+	return NewWithContext(context.Background(), api)
+}
+
+// NewWithContext returns the client of the indexer of api, whose every request is bound to ctx
+func NewWithContext(ctx context.Context, api config.API) tzkt.IFace {
+	if strings.ToLower(api.Indexer) == TzStats {
+		return tzstats.NewWithContext(ctx, api.TzStats)
+	}
+
+	return tzkt.NewTZKTWithContext(ctx, api.TZKT)
+}
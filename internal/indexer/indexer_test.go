@@ -0,0 +1,23 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/goat-systems/tzpay/v3/internal/tzstats"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	api := config.API{TZKT: "https://api.tzkt.io", TzStats: "https://api.tzstats.com"}
+	assert.IsType(t, &tzkt.Tzkt{}, New(api))
+
+	api.Indexer = Tzkt
+	assert.IsType(t, &tzkt.Tzkt{}, New(api))
+
+	api.Indexer = TzStats
+	client := New(api)
+	assert.IsType(t, &tzstats.TzStats{}, client)
+	assert.Equal(t, "https://api.tzstats.com", client.(*tzstats.TzStats).Host)
+}
@@ -0,0 +1,162 @@
+package lookup
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+// Page is the public page where a delegator enters their address to see their payment history from Baker. The
+// history of an address is fetched from Prefix + address + Suffix, relative to the page.
+type Page struct {
+	Baker  string
+	Prefix string
+	Suffix string
+}
+
+var page = template.Must(template.New("lookup").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Payments of {{.Baker}}</title>
+</head>
+<body>
+<h1>Payments of {{.Baker}}</h1>
+<form id="lookup">
+<label for="address">Your address</label>
+<input id="address" name="address" size="40" placeholder="tz1..." required>
+<button type="submit">Look up</button>
+</form>
+<p id="message"></p>
+<table id="payments" hidden>
+<thead><tr><th>Cycle</th><th>Date</th><th>Amount (XTZ)</th><th>Operations</th></tr></thead>
+<tbody></tbody>
+<tfoot><tr><th colspan="2">Total</th><td id="total"></td><td></td></tr></tfoot>
+</table>
+<script>
+var prefix = {{.Prefix}};
+var suffix = {{.Suffix}};
+
+function xtz(mutez) {
+	return (mutez / 1000000).toFixed(6);
+}
+
+function cell(row, text) {
+	row.insertCell().textContent = text;
+}
+
+document.getElementById("lookup").addEventListener("submit", function (event) {
+	event.preventDefault();
+	var address = document.getElementById("address").value.trim();
+	var message = document.getElementById("message");
+	var table = document.getElementById("payments");
+	var body = table.tBodies[0];
+	body.innerHTML = "";
+	table.hidden = true;
+	message.textContent = "Looking up " + address + "...";
+
+	fetch(prefix + encodeURIComponent(address) + suffix).then(function (response) {
+		if (response.status === 404) {
+			return {payments: []};
+		} else if (!response.ok) {
+			throw new Error(response.statusText);
+		}
+		return response.json();
+	}).then(function (history) {
+		if (history.payments.length === 0) {
+			message.textContent = "No payment to " + address + " yet.";
+			return;
+		}
+		history.payments.forEach(function (payment) {
+			var row = body.insertRow();
+			cell(row, payment.cycle);
+			cell(row, payment.time ? payment.time.substring(0, 10) : "");
+			cell(row, xtz(payment.amount));
+			var operations = row.insertCell();
+			(payment.operations || []).forEach(function (operation) {
+				var a = document.createElement("a");
+				a.href = operation.indexOf("http") === 0 ? operation : "https://tzkt.io/" + operation;
+				a.textContent = operation.replace(/^.*\//, "").substring(0, 12) + "... ";
+				operations.appendChild(a);
+			});
+		});
+		document.getElementById("total").textContent = xtz(history.total);
+		message.textContent = "";
+		table.hidden = false;
+	}).catch(function (error) {
+		message.textContent = "Failed to look up " + address + ": " + error.message;
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// HTML renders p as a standalone HTML page
+func HTML(p Page) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, p); err != nil {
+		return nil, errors.Wrap(err, "failed to render lookup page")
+	}
+
+	return buf.Bytes(), nil
+}
+
+/*
+Write exports the lookup page of baker as a static site to <dir>/<baker>: index.html, and the payment history of every
+delegator the store records as paid in <delegator>.json. Every file is replaced at once so a reader never sees it half
+written. Write returns the number of delegators exported.
+*/
+func Write(dir string, s store.IFace, baker string) (int, error) {
+	delegators, err := payout.PaidDelegators(s, baker)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to export lookup page")
+	}
+
+	dir = filepath.Join(dir, baker)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, errors.Wrap(err, "failed to export lookup page")
+	}
+
+	for _, delegator := range delegators {
+		history, err := payout.History(s, baker, delegator, 0)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to export lookup page")
+		}
+
+		js, err := json.Marshal(history)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to export lookup page")
+		}
+
+		if err := writeFile(filepath.Join(dir, delegator+".json"), js); err != nil {
+			return 0, errors.Wrap(err, "failed to export lookup page")
+		}
+	}
+
+	html, err := HTML(Page{Baker: baker, Suffix: ".json"})
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to export lookup page")
+	}
+
+	if err := writeFile(filepath.Join(dir, "index.html"), html); err != nil {
+		return 0, errors.Wrap(err, "failed to export lookup page")
+	}
+
+	return len(delegators), nil
+}
+
+func writeFile(path string, content []byte) error {
+	if err := ioutil.WriteFile(path+".tmp", content, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,62 @@
+package lookup
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const baker = "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+
+func Test_HTML(t *testing.T) {
+	html, err := HTML(Page{Baker: baker, Prefix: "delegators/", Suffix: "?baker=" + baker})
+	assert.Nil(t, err)
+	assert.Contains(t, string(html), "<title>Payments of "+baker+"</title>")
+	assert.Contains(t, string(html), `var prefix = "delegators/";`)
+	assert.Contains(t, string(html), `var suffix = "?baker=`+baker+`";`)
+}
+
+func Test_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-lookup")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	paidAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put("payouts/"+baker, "00000300/2021-03-01T12:00:00Z", payout.Record{Cycle: 300, Time: paidAt, Operations: []string{"oo1"}}))
+	assert.Nil(t, s.Put("paid/"+baker, "00000300/tz1a", payout.PaidEntry{Amount: 1000, Time: paidAt}))
+	assert.Nil(t, s.Put("paid/"+baker, "00000300/tz1b", payout.PaidEntry{Amount: 2000, Time: paidAt}))
+
+	out := filepath.Join(dir, "lookup")
+	exported, err := Write(out, s, baker)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, exported)
+
+	js, err := ioutil.ReadFile(filepath.Join(out, baker, "tz1a.json"))
+	assert.Nil(t, err)
+	var history payout.DelegatorHistory
+	assert.Nil(t, json.Unmarshal(js, &history))
+	assert.Equal(t, payout.DelegatorHistory{
+		Baker:     baker,
+		Delegator: "tz1a",
+		Payments:  []payout.Payment{{Cycle: 300, Amount: 1000, Time: paidAt, Operations: []string{"oo1"}}},
+		Total:     1000,
+	}, history)
+
+	html, err := ioutil.ReadFile(filepath.Join(out, baker, "index.html"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(html), `var suffix = ".json";`)
+
+	files, err := ioutil.ReadDir(filepath.Join(out, baker))
+	assert.Nil(t, err)
+	assert.Len(t, files, 3)
+}
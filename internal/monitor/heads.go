@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPoll is how often heads are polled for while the node cannot be monitored
+	DefaultPoll = 30 * time.Second
+	// DefaultStall is how long the monitor waits for a head before it takes the stream as stalled and reconnects
+	DefaultStall = 5 * time.Minute
+)
+
+// Head is a head of the main chain as announced by the node
+type Head struct {
+	Hash  string `json:"hash"`
+	Level int    `json:"level"`
+	// Polled is true for a tick sent while the node could not be monitored, whose head is to be fetched
+	Polled bool `json:"-"`
+}
+
+// HeadsInput is the input for NewHeads
+type HeadsInput struct {
+	Node  string        // the tezos node, e.g. https://mainnet-tezos.giganode.io
+	Poll  time.Duration // optional, DefaultPoll if 0
+	Stall time.Duration // optional, DefaultStall if 0
+}
+
+/*
+Heads follows the heads of the main chain of a node through its /monitor/heads/main stream, so that a new block is seen
+as soon as the node has it instead of at the next poll. When the stream breaks, or stalls, Heads reconnects, sending a
+polled tick every Poll until it does, so that a node that cannot be monitored is still polled.
+*/
+type Heads struct {
+	url    string
+	client *http.Client
+	poll   time.Duration
+	stall  time.Duration
+}
+
+// NewHeads returns a new Heads
+func NewHeads(input HeadsInput) *Heads {
+	node := strings.TrimSuffix(input.Node, "/")
+	if !strings.HasPrefix(node, "http://") && !strings.HasPrefix(node, "https://") {
+		node = "http://" + node
+	}
+
+	heads := &Heads{
+		url:    node + "/monitor/heads/main",
+		client: &http.Client{},
+		poll:   input.Poll,
+		stall:  input.Stall,
+	}
+	if heads.poll == 0 {
+		heads.poll = DefaultPoll
+	}
+	if heads.stall == 0 {
+		heads.stall = DefaultStall
+	}
+
+	return heads
+}
+
+// Start follows the heads of the node in the background, and returns the channel they are sent to
+func (h *Heads) Start() <-chan Head {
+	heads := make(chan Head)
+	go func() {
+		lost := true
+		for {
+			// Only the first failure of a series is warned of, the next ones being the attempts to reconnect
+			if connected, err := h.follow(heads); connected || lost {
+				log.WithFields(log.Fields{"error": err.Error(), "poll": h.poll}).Warn("Lost head monitor, polling until reconnected.")
+			} else {
+				log.WithField("error", err.Error()).Debug("Failed to reconnect head monitor.")
+			}
+			lost = false
+
+			// The monitor is gone for at least a poll, during which a block may have been missed
+			time.Sleep(h.poll)
+			heads <- Head{Polled: true}
+		}
+	}()
+
+	return heads
+}
+
+// follow sends the heads of the stream to heads until the stream breaks or stalls, and returns whether it was connected
+// and why it stopped
+func (h *Heads) follow(heads chan<- Head) (bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to monitor heads")
+	}
+
+	// A stream that sends nothing for too long is as good as broken
+	stalled := time.AfterFunc(h.stall, cancel)
+	defer stalled.Stop()
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to monitor heads")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, errors.Errorf("failed to monitor heads: unexpected response: %s", resp.Status)
+	}
+	log.WithField("node", h.url).Info("Monitoring heads.")
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var head Head
+		if err := decoder.Decode(&head); err != nil {
+			if ctx.Err() != nil {
+				return true, errors.Errorf("failed to monitor heads: no head for %s", h.stall)
+			}
+			return true, errors.Wrap(err, "failed to monitor heads")
+		}
+
+		// The time the head takes to be handled does not count as a stall
+		stalled.Stop()
+		log.WithFields(log.Fields{"level": head.Level, "hash": head.Hash}).Debug("Received head.")
+		heads <- head
+		stalled.Reset(h.stall)
+	}
+}
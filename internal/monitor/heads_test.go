@@ -0,0 +1,61 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Heads(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/monitor/heads/main", r.URL.Path)
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			// streams two heads, then breaks
+			for level := 100; level < 102; level++ {
+				fmt.Fprintf(w, "{\"hash\":\"BL%d\",\"level\":%d}\n", level, level)
+				w.(http.Flusher).Flush()
+			}
+		case 2:
+			// cannot be monitored
+			w.WriteHeader(http.StatusNotFound)
+		case 3:
+			// stalls
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			fmt.Fprint(w, `{"hash":"BL102","level":102}`)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	heads := NewHeads(HeadsInput{Node: server.URL + "/", Poll: 10 * time.Millisecond, Stall: 50 * time.Millisecond}).Start()
+
+	var received []Head
+	for len(received) < 6 {
+		select {
+		case head := <-heads:
+			received = append(received, head)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %v only", received)
+		}
+	}
+
+	assert.Equal(t, []Head{
+		{Hash: "BL100", Level: 100},
+		{Hash: "BL101", Level: 101},
+		{Polled: true}, // broken
+		{Polled: true}, // cannot be monitored
+		{Polled: true}, // stalled
+		{Hash: "BL102", Level: 102},
+	}, received)
+}
@@ -0,0 +1,117 @@
+/*
+Package node returns clients of the tezos node whose every RPC call is bound to a context and times out on its own, so
+that a node that hangs cannot stall tzpay, and cancelling the context, e.g. when tzpay is interrupted, cancels the calls
+in flight.
+*/
+package node
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout is how long an RPC call may take when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+/*
+New returns a go-tezos RPC client of the node of api, whose calls are bound to ctx and time out after the timeout of api.
+go-tezos fetches the head and constants of the node when the client is created, with timeouts of its own, which ctx
+being cancelled stops waiting for.
+*/
+func New(ctx context.Context, api config.API) (*rpc.Client, error) {
+	type result struct {
+		client *rpc.Client
+		err    error
+	}
+
+	connected := make(chan result, 1)
+	go func() {
+		client, err := rpc.New(api.Tezos)
+		connected <- result{client: client, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "failed to connect to tezos rpc")
+	case r := <-connected:
+		if r.err != nil {
+			return nil, r.err
+		}
+		r.client.SetClient(Client(ctx, api.Timeout))
+		return r.client, nil
+	}
+}
+
+// Client returns an http client whose every request is bound to ctx and times out after timeout, DefaultTimeout if 0
+func Client(ctx context.Context, timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &http.Client{
+		Transport: &transport{
+			ctx:     ctx,
+			timeout: timeout,
+			base: &http.Transport{
+				Dial: (&net.Dialer{
+					Timeout: 10 * time.Second,
+				}).Dial,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		},
+	}
+}
+
+/*
+transport sends every request with a context of its own derived from ctx, which times out after timeout and is
+released once the body of the response is read or closed. go-tezos sends its requests without a context, reading
+bodies to the end without closing them, so the context of a request is replaced rather than combined.
+*/
+type transport struct {
+	ctx     context.Context
+	timeout time.Duration
+	base    *http.Transport
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(t.ctx, t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &body{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the transport, as go-tezos does after every call
+func (t *transport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+// body releases the context of its request once read to the end or closed
+type body struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.cancel()
+	}
+	return n, err
+}
+
+func (b *body) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,76 @@
+package node
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Client(t *testing.T) {
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hang" {
+			select {
+			case <-hang:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+	defer close(hang)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := Client(ctx, 200*time.Millisecond)
+
+	resp, err := client.Get(server.URL + "/head")
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, `"ok"`, string(body))
+
+	// A node that hangs is given up on after the timeout of the call
+	start := time.Now()
+	_, err = client.Get(server.URL + "/hang")
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	// Cancelling the context cancels the calls in flight, and fails the next ones
+	time.AfterFunc(50*time.Millisecond, cancel)
+	client = Client(ctx, time.Minute)
+	start = time.Now()
+	_, err = client.Get(server.URL + "/hang")
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	_, err = client.Get(server.URL + "/head")
+	assert.NotNil(t, err)
+}
+
+func Test_New_cancelled(t *testing.T) {
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(hang)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := New(ctx, config.API{Tezos: server.URL})
+	assert.NotNil(t, err)
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber may lag behind before the events it misses are dropped
+const subscriberBuffer = 64
+
+/*
+Broadcaster is a client that sends the events of payouts, and notification messages as events, to every subscriber,
+e.g. the clients of the control API streaming events. A subscriber too slow to keep up misses events rather than
+holding up the payout they are about.
+*/
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBroadcaster returns a Broadcaster without subscribers
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: map[chan Event]struct{}{}}
+}
+
+// Send satisfies ClientIFace, broadcasting msg as an EventMessage
+func (b *Broadcaster) Send(msg string) error {
+	return b.SendEvent(Event{Name: EventMessage, Time: time.Now().UTC(), Message: msg})
+}
+
+// SendEvent satisfies EventClientIFace
+func (b *Broadcaster) SendEvent(event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for subscriber := range b.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel of the events sent from now on, and a function to call once done with it
+func (b *Broadcaster) Subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriber := make(chan Event, subscriberBuffer)
+	b.subscribers[subscriber] = struct{}{}
+
+	var once sync.Once
+	return subscriber, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, subscriber)
+			close(subscriber)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Broadcaster(t *testing.T) {
+	broadcaster := NewBroadcaster()
+	payoutNotifier := NewPayoutNotifier(PayoutNotifierInput{Notifiers: []ClientIFace{broadcaster}})
+
+	events, done := broadcaster.Subscribe()
+	other, otherDone := broadcaster.Subscribe()
+	otherDone()
+
+	payoutNotifier.Event(Event{Name: EventInjected, Baker: "tz1baker", Cycle: 300})
+	assert.Nil(t, payoutNotifier.NotifyKind(KindPayout, "some_message"))
+
+	event := <-events
+	assert.Equal(t, EventInjected, event.Name)
+	assert.Equal(t, 300, event.Cycle)
+	assert.False(t, event.Time.IsZero())
+
+	event = <-events
+	assert.Equal(t, EventMessage, event.Name)
+	assert.Equal(t, "some_message", event.Message)
+
+	_, ok := <-other
+	assert.False(t, ok, "a subscriber done is not sent events")
+
+	// a subscriber that does not keep up misses events instead of blocking the payout
+	for i := 0; i < subscriberBuffer+10; i++ {
+		assert.Nil(t, broadcaster.Send("some_message"))
+	}
+	assert.Len(t, events, subscriberBuffer)
+
+	done()
+	done()
+}
@@ -0,0 +1,152 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// States of a baker's registration as a delegate
+const (
+	DelegateActive      = "active"
+	DelegateGracePeriod = "grace period"
+	DelegateDeactivated = "deactivated"
+)
+
+/*
+DelegateStatus is the registration status of a baker. A baker that does not bake or endorse is deactivated at the end
+of the cycle GracePeriod, and stops getting rights until it registers again.
+*/
+type DelegateStatus struct {
+	Baker          string `json:"baker"`
+	Cycle          int    `json:"cycle"`
+	Deactivated    bool   `json:"deactivated"`
+	GracePeriod    int    `json:"grace_period"`
+	CyclesLeft     int    `json:"cycles_left"`
+	StakingBalance string `json:"staking_balance"`
+}
+
+// CheckDelegate returns the registration status of baker at the head of the chain
+func CheckDelegate(r rpc.IFace, baker string) (DelegateStatus, error) {
+	head, err := r.Head()
+	if err != nil {
+		return DelegateStatus{}, errors.Wrap(err, "failed to get current cycle")
+	}
+
+	delegate, err := r.Delegate(head.Hash, baker)
+	if err != nil {
+		return DelegateStatus{}, errors.Wrapf(err, "failed to get status of delegate '%s'", baker)
+	}
+
+	return DelegateStatus{
+		Baker:          baker,
+		Cycle:          head.Metadata.Level.Cycle,
+		Deactivated:    delegate.Deactivated,
+		GracePeriod:    delegate.GracePeriod,
+		CyclesLeft:     delegate.GracePeriod - head.Metadata.Level.Cycle,
+		StakingBalance: delegate.StakingBalance,
+	}, nil
+}
+
+// State returns the state of the baker, in its grace period when it is deactivated within cycles
+func (d DelegateStatus) State(cycles int) string {
+	switch {
+	case d.Deactivated:
+		return DelegateDeactivated
+	case d.CyclesLeft <= cycles:
+		return DelegateGracePeriod
+	default:
+		return DelegateActive
+	}
+}
+
+// DeactivationNotifierInput -
+type DeactivationNotifierInput struct {
+	Notifiers []ClientIFace
+	RPCClient rpc.IFace
+	Baker     string
+	Cycles    int
+	// Register registers the baker again as a delegate when it is found deactivated, if set
+	Register func() (string, error)
+}
+
+// DeactivationNotifier -
+type DeactivationNotifier struct {
+	notifiers []ClientIFace
+	rpcClient rpc.IFace
+	baker     string
+	cycles    int
+	register  func() (string, error)
+	notified  int
+}
+
+/*
+NewDeactivationNotifier -
+
+A notification process that warns you once per cycle when the baker is deactivated within cycles, or was deactivated,
+so that it can bake or endorse again before it loses its rights. A deactivated baker is registered again if the input
+holds a Register func.
+*/
+func NewDeactivationNotifier(input DeactivationNotifierInput) Notifier {
+	return &DeactivationNotifier{
+		notifiers: input.Notifiers,
+		rpcClient: input.RPCClient,
+		baker:     input.Baker,
+		cycles:    input.Cycles,
+		register:  input.Register,
+		notified:  -1,
+	}
+}
+
+// Start -
+func (d *DeactivationNotifier) Start() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		for range ticker.C {
+			if err := d.check(); err != nil {
+				log.WithField("error", err.Error()).Error("DeactivationNotifier failed to check delegate status")
+			}
+		}
+	}()
+}
+
+func (d *DeactivationNotifier) check() error {
+	status, err := CheckDelegate(d.rpcClient, d.baker)
+	if err != nil {
+		return err
+	}
+
+	if status.Cycle == d.notified {
+		return nil
+	}
+
+	var msg string
+	switch status.State(d.cycles) {
+	case DelegateDeactivated:
+		msg = fmt.Sprintf("[TZPAY]: %s is deactivated and gets no rights until it registers again", d.baker)
+		if d.register != nil {
+			if ophash, err := d.register(); err != nil {
+				log.WithFields(log.Fields{"error": err.Error(), "baker": d.baker}).Error("DeactivationNotifier failed to register baker again")
+				msg = fmt.Sprintf("[TZPAY]: %s is deactivated and failed to register again: %s", d.baker, err.Error())
+			} else {
+				msg = fmt.Sprintf("[TZPAY]: %s was deactivated and registered again: https://tzkt.io/%s", d.baker, ophash)
+			}
+		}
+	case DelegateGracePeriod:
+		msg = fmt.Sprintf("[TZPAY]: %s will be deactivated at the end of cycle %d (%d cycles left) unless it bakes or endorses", d.baker, status.GracePeriod, status.CyclesLeft)
+	default:
+		return nil
+	}
+
+	d.notified = status.Cycle
+	for _, notifier := range d.notifiers {
+		if err := notifier.Send(msg); err != nil {
+			return errors.Wrap(err, "failed to notify")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DigestInput -
+type DigestInput struct {
+	Notifiers []ClientIFace
+	Interval  time.Duration
+}
+
+/*
+Digest -
+
+Rolls low severity notifications into a single message sent every interval, so that a server paying out many
+cycles in a row does not send a notification for each of them.
+*/
+type Digest struct {
+	notifiers []ClientIFace
+	interval  time.Duration
+	messages  []string
+	mu        *sync.Mutex
+}
+
+// NewDigest returns a new Digest sending its messages to notifiers every interval
+func NewDigest(input DigestInput) *Digest {
+	return &Digest{
+		notifiers: input.Notifiers,
+		interval:  input.Interval,
+		mu:        &sync.Mutex{},
+	}
+}
+
+// Start -
+func (d *Digest) Start() {
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		for range ticker.C {
+			if err := d.Flush(); err != nil {
+				log.WithField("error", err.Error()).Error("Digest failed to notify")
+			}
+		}
+	}()
+}
+
+// Add holds msg until the next digest is sent
+func (d *Digest) Add(msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.messages = append(d.messages, msg)
+}
+
+// Flush sends the messages held so far as a single message, if any
+func (d *Digest) Flush() error {
+	d.mu.Lock()
+	messages := d.messages
+	d.messages = nil
+	d.mu.Unlock()
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	msg := messages[0]
+	if len(messages) > 1 {
+		msg = fmt.Sprintf("[TZPAY] digest of %d notifications:\n%s", len(messages), strings.Join(messages, "\n"))
+	}
+
+	for _, notifier := range d.notifiers {
+		if err := notifier.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+RateLimitedClient -
+
+Sends at most max messages every period through a client. Messages over the limit are held and sent along with
+the next message the limit allows, so nothing is lost, only delayed.
+*/
+type RateLimitedClient struct {
+	client ClientIFace
+	max    int
+	period time.Duration
+	sent   []time.Time
+	held   []string
+	mu     *sync.Mutex
+	now    func() time.Time
+}
+
+// NewRateLimitedClient returns client limited to max messages every period (an hour if 0), or client itself if max is 0
+func NewRateLimitedClient(client ClientIFace, max int, period time.Duration) ClientIFace {
+	if max <= 0 {
+		return client
+	}
+
+	if period <= 0 {
+		period = time.Hour
+	}
+
+	return &RateLimitedClient{
+		client: client,
+		max:    max,
+		period: period,
+		mu:     &sync.Mutex{},
+		now:    time.Now,
+	}
+}
+
+// Send satisfies ClientIFace
+func (r *RateLimitedClient) Send(msg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for len(r.sent) > 0 && !r.sent[0].After(now.Add(-r.period)) {
+		r.sent = r.sent[1:]
+	}
+
+	if len(r.sent) >= r.max {
+		r.held = append(r.held, msg)
+		log.WithField("held", len(r.held)).Warn("Notification held by rate limit.")
+		return nil
+	}
+
+	if len(r.held) > 0 {
+		msg = strings.Join(append(r.held, msg), "\n")
+	}
+
+	if err := r.client.Send(msg); err != nil {
+		return err
+	}
+	r.sent = append(r.sent, now)
+	r.held = nil
+
+	return nil
+}
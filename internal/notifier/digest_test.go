@@ -0,0 +1,81 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/redact"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Digest(t *testing.T) {
+	client := &MockClient{}
+	digest := NewDigest(DigestInput{
+		Notifiers: []ClientIFace{client},
+		Interval:  time.Hour,
+	})
+
+	assert.Nil(t, digest.Flush())
+	assert.Nil(t, client.Messages)
+
+	digest.Add("[TZPAY] payout for cycle 270")
+	assert.Nil(t, digest.Flush())
+	assert.Equal(t, []string{"[TZPAY] payout for cycle 270"}, client.Messages)
+
+	digest.Add("[TZPAY] payout for cycle 271")
+	digest.Add("[TZPAY] payout for cycle 272")
+	assert.Nil(t, digest.Flush())
+	assert.Equal(t, "[TZPAY] digest of 2 notifications:\n[TZPAY] payout for cycle 271\n[TZPAY] payout for cycle 272", client.Messages[1])
+
+	client.WantSendErr = true
+	digest.Add("[TZPAY] payout for cycle 273")
+	test.CheckErr(t, true, "failed to send message", digest.Flush())
+}
+
+func Test_PayoutNotifierNotifyLow(t *testing.T) {
+	client := &MockClient{}
+	notifier := NewPayoutNotifier(PayoutNotifierInput{
+		Notifiers: []ClientIFace{client},
+		Redactor:  redact.New(nil, true),
+	})
+
+	assert.Nil(t, notifier.NotifyLow("[TZPAY] paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"))
+	assert.Equal(t, []string{"[TZPAY] paid tz1…[REDACTED]"}, client.Messages)
+
+	digest := NewDigest(DigestInput{Notifiers: []ClientIFace{client}})
+	notifier = NewPayoutNotifier(PayoutNotifierInput{
+		Notifiers: []ClientIFace{client},
+		Redactor:  redact.New(nil, true),
+		Digest:    digest,
+	})
+
+	assert.Nil(t, notifier.NotifyLow("[TZPAY] paid tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"))
+	assert.Nil(t, notifier.Notify("[TZPAY] payout skipped"))
+	assert.Equal(t, []string{"[TZPAY] paid tz1…[REDACTED]", "[TZPAY] payout skipped"}, client.Messages)
+
+	assert.Nil(t, digest.Flush())
+	assert.Equal(t, "[TZPAY] paid tz1…[REDACTED]", client.Messages[2])
+}
+
+func Test_RateLimitedClient(t *testing.T) {
+	client := &MockClient{}
+	assert.Equal(t, client, NewRateLimitedClient(client, 0, time.Hour))
+
+	now := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+	limited := NewRateLimitedClient(client, 2, time.Hour).(*RateLimitedClient)
+	limited.now = func() time.Time { return now }
+
+	assert.Nil(t, limited.Send("first"))
+	assert.Nil(t, limited.Send("second"))
+	assert.Nil(t, limited.Send("third"))
+	assert.Nil(t, limited.Send("fourth"))
+	assert.Equal(t, []string{"first", "second"}, client.Messages)
+
+	now = now.Add(time.Hour)
+	assert.Nil(t, limited.Send("fifth"))
+	assert.Equal(t, []string{"first", "second", "third\nfourth\nfifth"}, client.Messages)
+
+	client.WantSendErr = true
+	test.CheckErr(t, true, "failed to send message", limited.Send("sixth"))
+}
@@ -0,0 +1,170 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// colors of the embed of a message by severity
+	colorSuccess = 0x2ecc71
+	colorWarning = 0xf1c40f
+	colorAlert   = 0xe74c3c
+
+	// maxTitle and maxDescription are the longest title and description Discord accepts in an embed
+	maxTitle       = 256
+	maxDescription = 4096
+)
+
+var (
+	payoutPattern = regexp.MustCompile(`payout for cycle (\d+)(?: \((\w+)\))?`)
+	linkPattern   = regexp.MustCompile(`https://tzkt\.io/(\w+)`)
+	linksPattern  = regexp.MustCompile(`\[(https://tzkt\.io/\w+\s*)*\]`)
+	hashtags      = regexp.MustCompile(`\s*#tezos #blockchain`)
+)
+
+// IFace is an interface to a client that posts messages to a Discord channel through a webhook
+type IFace interface {
+	Send(msg string) error
+}
+
+// Client posts messages as embeds to the Discord channel of the webhook WebhookURL, as Username
+type Client struct {
+	WebhookURL string
+	Username   string
+	client     *http.Client
+}
+
+// Embed is a Discord embed, the formatted card a message is posted as
+type Embed struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Color       int     `json:"color"`
+	Fields      []Field `json:"fields,omitempty"`
+	Timestamp   string  `json:"timestamp,omitempty"`
+	Footer      *Footer `json:"footer,omitempty"`
+}
+
+// Field is a name and value shown in an embed
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Footer is the text at the bottom of an embed
+type Footer struct {
+	Text string `json:"text"`
+}
+
+type webhook struct {
+	Username string  `json:"username,omitempty"`
+	Embeds   []Embed `json:"embeds"`
+}
+
+// New returns a new discord IFace
+func New(discord Client) IFace {
+	discord.client = &http.Client{Timeout: 10 * time.Second}
+	return &discord
+}
+
+// Send posts a message to the channel as an embed
+func (c *Client) Send(msg string) error {
+	body, err := json.Marshal(webhook{Username: c.Username, Embeds: []Embed{NewEmbed(msg, time.Now())}})
+	if err != nil {
+		return errors.Wrap(err, "failed to encode message for discord")
+	}
+
+	resp, err := c.client.Post(c.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The webhook url holds its token, which the error holds
+		return errors.New("failed to send message through discord webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		raw, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("failed to send message through discord webhook: response returned code %d with body %s", resp.StatusCode, string(raw))
+	}
+
+	return nil
+}
+
+/*
+NewEmbed formats a notification as an embed: its first line is the title and the rest its description. The cycle and
+baker of payout notifications are shown as fields, their operations as links to tzkt, and the color tells alerts and
+payouts held or skipped from payouts made.
+*/
+func NewEmbed(msg string, now time.Time) Embed {
+	msg = strings.TrimSpace(hashtags.ReplaceAllString(msg, ""))
+
+	lines := strings.SplitN(msg, "\n", 2)
+	title := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(lines[0], "[TZPAY]"), ":"))
+	title = strings.TrimSuffix(title, ":")
+	var description string
+	if len(lines) > 1 {
+		description = strings.TrimSpace(lines[1])
+	}
+
+	embed := Embed{
+		Title:     truncate(title, maxTitle),
+		Color:     color(title),
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Footer:    &Footer{Text: "tzpay"},
+	}
+
+	if match := payoutPattern.FindStringSubmatch(title); match != nil {
+		embed.Fields = append(embed.Fields, Field{Name: "Cycle", Value: match[1], Inline: true})
+		if match[2] != "" {
+			embed.Fields = append(embed.Fields, Field{Name: "Baker", Value: match[2], Inline: true})
+		}
+	}
+
+	if links := linkPattern.FindAllStringSubmatch(description, -1); len(links) > 0 {
+		embed.Fields = append(embed.Fields, Field{Name: "Operations", Value: fmt.Sprintf("%d", len(links)), Inline: true})
+
+		// Operation links come as go prints slices, one link per line reads better
+		operations := make([]string, len(links))
+		for i, link := range links {
+			operations[i] = fmt.Sprintf("[%s](%s)", link[1], link[0])
+		}
+		description = strings.TrimSpace(linksPattern.ReplaceAllString(description, ""))
+		if description != "" {
+			description += "\n"
+		}
+		description += strings.Join(operations, "\n")
+	}
+
+	embed.Description = truncate(description, maxDescription)
+
+	return embed
+}
+
+func color(title string) int {
+	lower := strings.ToLower(title)
+	switch {
+	case strings.Contains(lower, "alert") || strings.Contains(lower, "failed") || strings.Contains(lower, "missed"):
+		return colorAlert
+	case strings.Contains(lower, "skipped") || strings.Contains(lower, "awaits") || strings.Contains(lower, "digest") ||
+		strings.Contains(lower, "warning"):
+		return colorWarning
+	}
+
+	return colorSuccess
+}
+
+func truncate(s string, max int) string {
+	if len([]rune(s)) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max-1]) + "…"
+}
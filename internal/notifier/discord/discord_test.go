@@ -0,0 +1,101 @@
+package discord
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Send(t *testing.T) {
+	var posted []webhook
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/webhooks/1/token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Invalid Webhook Token", "code": 50027}`))
+			return
+		}
+
+		var body webhook
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		posted = append(posted, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := &Client{WebhookURL: server.URL + "/api/webhooks/1/token", Username: "tzpay", client: server.Client()}
+	assert.Nil(t, client.Send("[TZPAY] payout for cycle 300"))
+	assert.Len(t, posted, 1)
+	assert.Equal(t, "tzpay", posted[0].Username)
+	assert.Equal(t, "payout for cycle 300", posted[0].Embeds[0].Title)
+
+	client.WebhookURL = server.URL + "/api/webhooks/1/wrong"
+	test.CheckErr(t, true, "response returned code 401", client.Send("msg"))
+
+	client.WebhookURL = "http://127.0.0.1:0/api/webhooks/1/token"
+	err := client.Send("msg")
+	test.CheckErr(t, true, "failed to send message through discord webhook", err)
+	assert.NotContains(t, err.Error(), "token")
+}
+
+func Test_NewEmbed(t *testing.T) {
+	now := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		msg  string
+		want Embed
+	}{
+		{
+			name: "formats a payout with its cycle, baker and operations",
+			msg:  "[TZPAY] payout for cycle 300 (tz1baker): \n[https://tzkt.io/oo1 https://tzkt.io/oo2]\n #tezos #blockchain",
+			want: Embed{
+				Title:       "payout for cycle 300 (tz1baker)",
+				Description: "[oo1](https://tzkt.io/oo1)\n[oo2](https://tzkt.io/oo2)",
+				Color:       colorSuccess,
+				Fields: []Field{
+					{Name: "Cycle", Value: "300", Inline: true},
+					{Name: "Baker", Value: "tz1baker", Inline: true},
+					{Name: "Operations", Value: "2", Inline: true},
+				},
+				Timestamp: "2020-09-01T12:00:00Z",
+				Footer:    &Footer{Text: "tzpay"},
+			},
+		},
+		{
+			name: "colors a skipped payout as a warning",
+			msg:  "[TZPAY] payout for cycle 300 skipped: baker was denounced #tezos #blockchain",
+			want: Embed{
+				Title:     "payout for cycle 300 skipped: baker was denounced",
+				Color:     colorWarning,
+				Fields:    []Field{{Name: "Cycle", Value: "300", Inline: true}},
+				Timestamp: "2020-09-01T12:00:00Z",
+				Footer:    &Footer{Text: "tzpay"},
+			},
+		},
+		{
+			name: "colors an alert",
+			msg:  "[TZPAY]: Baking right missed at level: 1000",
+			want: Embed{
+				Title:     "Baking right missed at level: 1000",
+				Color:     colorAlert,
+				Timestamp: "2020-09-01T12:00:00Z",
+				Footer:    &Footer{Text: "tzpay"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewEmbed(tt.msg, now))
+		})
+	}
+
+	embed := NewEmbed("[TZPAY] "+strings.Repeat("a", maxTitle+10), now)
+	assert.Equal(t, maxTitle, len([]rune(embed.Title)))
+}
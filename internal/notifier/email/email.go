@@ -1,23 +1,62 @@
 package email
 
-import "gopkg.in/gomail.v2"
+import (
+	"strings"
 
+	"github.com/pkg/errors"
+	"gopkg.in/gomail.v2"
+)
+
+// IFace is an interface to a client that sends emails through an SMTP server
 type IFace interface {
 	Send(msg string) error
+	Mail(to, subject, body string) error
 }
 
+// Client sends emails from From through the SMTP server at Host, notifications going to To
 type Client struct {
-	dialer *gomail.Dialer
-	emails []string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	sender   func(m ...*gomail.Message) error
 }
 
-func NewEmailClient(username, password string, emails ...string) IFace {
-	return &Client{
-		dialer: gomail.NewDialer("smtp.example.com", 587, username, password),
-		emails: emails,
+// New returns a new email IFace, sending through port 587 unless Port is set
+func New(email Client) IFace {
+	if email.Port == 0 {
+		email.Port = 587
 	}
+	email.sender = gomail.NewDialer(email.Host, email.Port, email.Username, email.Password).DialAndSend
+
+	return &email
 }
 
+// Send emails a notification to every address of To, its first line being the subject
 func (c *Client) Send(msg string) error {
+	subject := strings.TrimSpace(strings.SplitN(msg, "\n", 2)[0])
+	for _, to := range c.To {
+		if err := c.Mail(to, subject, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Mail emails body to the address to
+func (c *Client) Mail(to, subject, body string) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", c.From)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/plain", body)
+
+	if err := c.sender(m); err != nil {
+		return errors.Wrapf(err, "failed to send email to '%s'", to)
+	}
+
 	return nil
 }
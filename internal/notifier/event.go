@@ -0,0 +1,61 @@
+package notifier
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Events of a payout, in the order a payout goes through them
+const (
+	EventComputed  = "computed"
+	EventInjected  = "injected"
+	EventConfirmed = "confirmed"
+	EventFailed    = "failed"
+	// EventMessage is a notification message sent to a client of events, see Event.Message
+	EventMessage = "message"
+)
+
+/*
+Event is something that happened to a payout, for clients that take structured events rather than messages. Amount and
+Transfers are what the payout pays, in mutez, and Operations the hashes of the operations injected so far.
+*/
+type Event struct {
+	Name       string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Baker      string    `json:"baker,omitempty"`
+	Cycle      int       `json:"cycle,omitempty"`
+	Transfers  int       `json:"transfers,omitempty"`
+	Amount     int       `json:"amount,omitempty"`
+	Operations []string  `json:"operations,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// EventClientIFace is a client that is also sent the events of payouts, e.g. a webhook
+type EventClientIFace interface {
+	ClientIFace
+	SendEvent(event Event) error
+}
+
+/*
+Event sends event to the notifiers that take events. It is not redacted, being meant for the operator's own
+automation, and a failure to send it is only logged so that it never fails the payout it is about.
+*/
+func (p *PayoutNotifier) Event(event Event) {
+	if p == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	for _, client := range p.registry.Route(event.Name) {
+		if client, ok := client.(EventClientIFace); ok {
+			if err := client.SendEvent(event); err != nil {
+				log.WithFields(log.Fields{"event": event.Name, "error": err.Error()}).Error("Failed to send payout event.")
+			}
+		}
+	}
+}
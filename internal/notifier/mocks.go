@@ -10,6 +10,7 @@ type ClientIFace interface {
 // MockClient mocks twilio.IFace
 type MockClient struct {
 	WantSendErr bool
+	Messages    []string
 }
 
 // Send satisfies twilio.IFace
@@ -17,6 +18,23 @@ func (m *MockClient) Send(msg string) error {
 	if m.WantSendErr {
 		return errors.New("failed to send message")
 	}
+	m.Messages = append(m.Messages, msg)
+
+	return nil
+}
+
+// MockEventClient mocks EventClientIFace
+type MockEventClient struct {
+	MockClient
+	Events []Event
+}
+
+// SendEvent satisfies EventClientIFace
+func (m *MockEventClient) SendEvent(event Event) error {
+	if m.WantSendErr {
+		return errors.New("failed to send event")
+	}
+	m.Events = append(m.Events, event)
 
 	return nil
 }
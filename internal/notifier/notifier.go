@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"time"
 
+	gotezos "github.com/goat-systems/go-tezos/v2"
 	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/redact"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,6 +21,10 @@ type MissedOpportunityNotifier struct {
 	notifiers []ClientIFace
 	rpcClient rpc.IFace
 	baker     string
+	cycle     int
+	level     int
+	rights    rights
+	constants rpc.Constants
 }
 
 // MissedOpportunityNotifierInput -
@@ -27,29 +34,39 @@ type MissedOpportunityNotifierInput struct {
 	Baker     string
 }
 
-// PayoutNotifierInput -
+// PayoutNotifierInput - Notifiers are only used without a Registry, all of them sent every notification
 type PayoutNotifierInput struct {
 	Notifiers []ClientIFace
+	Registry  *Registry
+	Redactor  *redact.Redactor
+	Digest    *Digest
 }
 
 // PayoutNotifier -
 type PayoutNotifier struct {
-	notifiers []ClientIFace
+	registry *Registry
+	redactor *redact.Redactor
+	digest   *Digest
 }
 
+// rights are the levels the baker has a baking right at, and its endorsing slots by level
 type rights struct {
-	baking    rpc.BakingRights
-	endorsing rpc.EndorsingRights
+	baking    map[int]bool
+	endorsing map[int]int
 }
 
 /*
 NewMissedOpportunityNotifier -
 
-A notification process that watches for missed endorsement and baking opportunities
-and notifies you via SMS (twilio) or Email.
+A notification process that checks every block for the baking and endorsing rights of the baker it missed, and
+notifies you of each with an estimate of the rewards lost.
 */
 func NewMissedOpportunityNotifier(input MissedOpportunityNotifierInput) Notifier {
-	return &MissedOpportunityNotifier{input.Notifiers, input.RPCClient, input.Baker}
+	return &MissedOpportunityNotifier{
+		notifiers: input.Notifiers,
+		rpcClient: input.RPCClient,
+		baker:     input.Baker,
+	}
 }
 
 /*
@@ -58,14 +75,27 @@ NewPayoutNotifier -
 A notification process that will automatically tweet, email, or text payout notifications.
 */
 func NewPayoutNotifier(input PayoutNotifierInput) PayoutNotifier {
+	registry := input.Registry
+	if registry == nil {
+		registry = newRegistryOf(input.Notifiers)
+	}
+
 	return PayoutNotifier{
-		input.Notifiers,
+		registry,
+		input.Redactor,
+		input.Digest,
 	}
 }
 
-// Notify -
+// Notify sends msg as a notice
 func (p *PayoutNotifier) Notify(msg string) error {
-	for _, notifier := range p.notifiers {
+	return p.NotifyKind(KindNotice, msg)
+}
+
+// NotifyKind sends msg to the clients the notifications of kind are routed to
+func (p *PayoutNotifier) NotifyKind(kind, msg string) error {
+	msg = p.redactor.String(msg)
+	for _, notifier := range p.registry.Route(kind) {
 		if err := notifier.Send(msg); err != nil {
 			return err
 		}
@@ -74,73 +104,141 @@ func (p *PayoutNotifier) Notify(msg string) error {
 	return nil
 }
 
+// NotifyLow adds the summary of a payout to the digest if one is configured, or sends it right away otherwise
+func (p *PayoutNotifier) NotifyLow(msg string) error {
+	if p.digest == nil {
+		return p.NotifyKind(KindPayout, msg)
+	}
+
+	p.digest.Add(p.redactor.String(msg))
+	return nil
+}
+
+// Start -
 func (m *MissedOpportunityNotifier) Start() {
-	currentCycle := 0
 	go func() {
 		ticker := time.NewTicker(time.Minute)
 		for range ticker.C {
-			block, err := m.rpcClient.Head()
-			if err != nil {
-				log.WithField("error", err.Error()).Error("MissedOpportunityNotifier failed to get current cycle")
-			}
-
-			if currentCycle < block.Metadata.Level.Cycle {
-				brights, erights, err := m.getRights(block.Metadata.Level.Cycle)
-				if err != nil {
-					log.WithField("error", err.Error()).Error("MissedOpportunityNotifier failed to get rights")
-				} else {
-					m.rightsWorker(rights{
-						baking:    *brights,
-						endorsing: *erights,
-					})
-				}
+			if err := m.check(); err != nil {
+				log.WithField("error", err.Error()).Error("MissedOpportunityNotifier failed to check rights")
 			}
 		}
 	}()
 }
 
-func (m *MissedOpportunityNotifier) rightsWorker(r rights) {
-	go func() {
-		ticker := time.NewTicker(time.Minute)
-		for range ticker.C {
-			head, err := m.rpcClient.Head()
-			if err != nil {
-				log.WithField("error", err.Error()).Error("MissedOpportunityNotifier failed to get current cycle")
-			}
+/*
+check compares the rights of the baker with every block baked since the last check, starting at the head. A block
+must be baked by the baker at a level it has a baking right for, and must include the baker's endorsement of the
+previous level at a level it has an endorsing right for.
+*/
+func (m *MissedOpportunityNotifier) check() error {
+	head, err := m.rpcClient.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to get current level")
+	}
+
+	if m.level == 0 {
+		m.level = head.Metadata.Level.Level - 1
+	}
 
-			if head.Metadata.Level.Level > r.endorsing[len(r.endorsing)-1].Level && head.Metadata.Level.Level > r.baking[len(r.baking)-1].Level {
-				ticker.Stop()
-				break
+	for level := m.level + 1; level <= head.Metadata.Level.Level; level++ {
+		block, err := m.rpcClient.Block(level)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get block at level %d", level)
+		}
+
+		if m.cycle < block.Metadata.Level.Cycle {
+			if err := m.refresh(block); err != nil {
+				return err
 			}
+		}
 
-			for _, right := range r.endorsing {
-				if head.Metadata.Level.Level == right.Level {
-					if ok := m.isEndorsementSuccessful(head); !ok {
-						if err := m.notify(fmt.Sprintf("[TZPAY]: Endorsement missed at level: %d", right.Level)); err != nil {
-							log.WithField("error", err.Error()).Error("MissedOpportunityNotifier failed to notify")
-						}
-					}
-				}
+		if m.rights.baking[level] && !m.isBakeSuccessful(block) {
+			msg := fmt.Sprintf("[TZPAY]: %s missed its baking right at level %d, losing an estimated %s XTZ", m.baker, level, tez(m.bakingReward()))
+			if err := m.notify(msg); err != nil {
+				return errors.Wrap(err, "failed to notify")
 			}
+		}
 
-			for _, right := range r.baking {
-				if head.Metadata.Level.Level == right.Level {
-					if ok := m.isBakeSuccessful(head); !ok {
-						if err := m.notify(fmt.Sprintf("[TZPAY]: Baking right missed at level: %d", right.Level)); err != nil {
-							log.WithField("error", err.Error()).Error("MissedOpportunityNotifier failed to notify")
-						}
-					}
-				}
+		// Endorsements of a level are included in the block of the next level
+		if slots := m.rights.endorsing[level-1]; slots > 0 && !m.isEndorsementSuccessful(block) {
+			msg := fmt.Sprintf("[TZPAY]: %s missed its endorsement of level %d (%d slots), losing an estimated %s XTZ", m.baker, level-1, slots, tez(m.endorsingReward(slots)))
+			if err := m.notify(msg); err != nil {
+				return errors.Wrap(err, "failed to notify")
 			}
 		}
-	}()
+
+		delete(m.rights.baking, level)
+		delete(m.rights.endorsing, level-1)
+		m.level = level
+	}
+
+	return nil
+}
+
+// refresh adds the rights of the cycle of block, and the constants its rewards are estimated with
+func (m *MissedOpportunityNotifier) refresh(block *rpc.Block) error {
+	brights, erights, err := m.getRights(block.Hash, block.Metadata.Level.Cycle)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get rights for cycle %d", block.Metadata.Level.Cycle)
+	}
+
+	constants, err := m.rpcClient.Constants(block.Hash)
+	if err != nil {
+		return errors.Wrap(err, "failed to get network constants")
+	}
+
+	if m.rights.baking == nil {
+		m.rights.baking, m.rights.endorsing = map[int]bool{}, map[int]int{}
+	}
+	// A right of lower priority is only missed by the bakers before it, not by the baker
+	for _, right := range *brights {
+		if right.Priority == 0 {
+			m.rights.baking[right.Level] = true
+		}
+	}
+	for _, right := range *erights {
+		m.rights.endorsing[right.Level] = len(right.Slots)
+	}
+	m.constants = constants
+	m.cycle = block.Metadata.Level.Cycle
+
+	return nil
+}
+
+// bakingReward estimates the reward of a block of priority 0 carrying every endorsement
+func (m *MissedOpportunityNotifier) bakingReward() int {
+	if len(m.constants.BlockReward) == 0 {
+		return 0
+	}
+
+	// Since Carthage, the reward of a block is paid per endorsement it carries
+	if len(m.constants.BlockReward) > 1 {
+		return m.constants.BlockReward[0] * m.constants.EndorsersPerBlock
+	}
+
+	return m.constants.BlockReward[0]
+}
+
+// endorsingReward estimates the reward of slots endorsements of a block of priority 0
+func (m *MissedOpportunityNotifier) endorsingReward(slots int) int {
+	if len(m.constants.EndorsementReward) == 0 {
+		return 0
+	}
+
+	return m.constants.EndorsementReward[0] * slots
+}
+
+func tez(mutez int) string {
+	return fmt.Sprintf("%.6f", float64(mutez)/float64(gotezos.MUTEZ))
 }
 
 func (m *MissedOpportunityNotifier) isEndorsementSuccessful(block *rpc.Block) bool {
+	// Only endorsements carry the delegate in their metadata
 	for _, operations := range block.Operations {
 		for _, op := range operations {
-			for _, endorsement := range op.Contents.Organize().Endorsements {
-				if endorsement.Metadata.Delegate == m.baker {
+			for _, content := range op.Contents {
+				if content.Metadata != nil && content.Metadata.Delegate == m.baker {
 					return true
 				}
 			}
@@ -158,8 +256,9 @@ func (m *MissedOpportunityNotifier) isBakeSuccessful(block *rpc.Block) bool {
 	return false
 }
 
-func (m *MissedOpportunityNotifier) getRights(cycle int) (*rpc.BakingRights, *rpc.EndorsingRights, error) {
+func (m *MissedOpportunityNotifier) getRights(blockhash string, cycle int) (*rpc.BakingRights, *rpc.EndorsingRights, error) {
 	brights, err := m.rpcClient.BakingRights(rpc.BakingRightsInput{
+		BlockHash:   blockhash,
 		Cycle:       cycle,
 		MaxPriority: 0,
 		Delegate:    m.baker,
@@ -169,8 +268,9 @@ func (m *MissedOpportunityNotifier) getRights(cycle int) (*rpc.BakingRights, *rp
 	}
 
 	erights, err := m.rpcClient.EndorsingRights(rpc.EndorsingRightsInput{
-		Cycle:    cycle,
-		Delegate: m.baker,
+		BlockHash: blockhash,
+		Cycle:     cycle,
+		Delegate:  m.baker,
 	})
 	if err != nil {
 		return &rpc.BakingRights{}, &rpc.EndorsingRights{}, err
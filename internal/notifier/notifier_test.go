@@ -1,9 +1,12 @@
 package notifier
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/redact"
 	"github.com/goat-systems/tzpay/v3/internal/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -143,7 +146,7 @@ func Test_getRights(t *testing.T) {
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			brights, erights, err := tt.input.m.getRights(0)
+			brights, erights, err := tt.input.m.getRights("some_hash", 0)
 			test.CheckErr(t, tt.want.err, tt.want.errContains, err)
 			assert.Equal(t, tt.want.erights, erights)
 			assert.Equal(t, tt.want.brights, brights)
@@ -152,6 +155,66 @@ func Test_getRights(t *testing.T) {
 
 }
 
+func Test_MissedOpportunityNotifier_check(t *testing.T) {
+	block := func(level int, baker string, endorsers ...string) *rpc.Block {
+		b := &rpc.Block{Hash: "some_hash", Operations: [][]rpc.Operations{{}}}
+		b.Metadata.Baker = baker
+		b.Metadata.Level.Level = level
+		b.Metadata.Level.Cycle = 1
+		for _, endorser := range endorsers {
+			b.Operations[0] = append(b.Operations[0], rpc.Operations{
+				Contents: rpc.Contents{{Kind: rpc.ENDORSEMENT, Metadata: &rpc.ContentsHelperMetadata{Delegate: endorser}}},
+			})
+		}
+		return b
+	}
+
+	cases := []struct {
+		name     string
+		blocks   map[int]*rpc.Block
+		err      bool
+		messages []string
+	}{
+		{
+			"does not notify of rights made",
+			map[int]*rpc.Block{100: block(100, "some_delegate"), 101: block(101, "some_other_delegate", "some_delegate")},
+			false,
+			nil,
+		},
+		{
+			"notifies of missed rights",
+			map[int]*rpc.Block{100: block(100, "some_other_delegate"), 101: block(101, "some_other_delegate")},
+			false,
+			[]string{
+				"[TZPAY]: some_delegate missed its baking right at level 100, losing an estimated 40.000000 XTZ",
+				"[TZPAY]: some_delegate missed its endorsement of level 100 (2 slots), losing an estimated 2.500000 XTZ",
+			},
+		},
+		{
+			"handles failure to get block",
+			nil,
+			true,
+			nil,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{}
+			rpcClient := &test.RPCMock{HeadLevel: 101, LevelBlocks: tt.blocks, BlockErr: tt.blocks == nil, EndorsingSlots: []int{3, 17}}
+			m := NewMissedOpportunityNotifier(MissedOpportunityNotifierInput{
+				Notifiers: []ClientIFace{client},
+				RPCClient: rpcClient,
+				Baker:     "some_delegate",
+			}).(*MissedOpportunityNotifier)
+			m.level = 99
+
+			test.CheckErr(t, tt.err, "failed to get block at level 100", m.check())
+			assert.Equal(t, tt.messages, client.Messages)
+		})
+	}
+}
+
 func Test_notify(t *testing.T) {
 	type input struct {
 		notifier ClientIFace
@@ -204,3 +267,164 @@ func Test_notify(t *testing.T) {
 		})
 	}
 }
+
+func Test_PayoutNotifierNotify(t *testing.T) {
+	cases := []struct {
+		name     string
+		redactor *redact.Redactor
+		want     string
+	}{
+		{
+			"is successful",
+			nil,
+			"[TZPAY] payout for cycle 270 (tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV): paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+		},
+		{
+			"is successful with redaction",
+			redact.New(nil, true, "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"),
+			"[TZPAY] payout for cycle 270 (tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV): paid tz1…[REDACTED]",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{}
+			notifier := NewPayoutNotifier(PayoutNotifierInput{
+				Notifiers: []ClientIFace{client},
+				Redactor:  tt.redactor,
+			})
+
+			err := notifier.Notify("[TZPAY] payout for cycle 270 (tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV): paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{tt.want}, client.Messages)
+		})
+	}
+}
+
+func Test_UpcomingRightsNotifier_check(t *testing.T) {
+	now := time.Date(2020, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		input    test.RPCMock
+		err      bool
+		messages []string
+	}{
+		{
+			"notifies of rights within notice period once",
+			test.RPCMock{RightsEstimatedTime: now.Add(20 * time.Minute)},
+			false,
+			[]string{"[TZPAY]: Baking right at level 100 (priority 0) in 20m0s"},
+		},
+		{
+			"does not notify of rights after notice period",
+			test.RPCMock{RightsEstimatedTime: now.Add(2 * time.Hour)},
+			false,
+			nil,
+		},
+		{
+			"handles failure to get rights",
+			test.RPCMock{BakingRightsErr: true},
+			true,
+			nil,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{}
+			u := NewUpcomingRightsNotifier(UpcomingRightsNotifierInput{
+				Notifiers: []ClientIFace{client},
+				RPCClient: &tt.input,
+				Baker:     "some_delegate",
+				Before:    time.Hour,
+			}).(*UpcomingRightsNotifier)
+
+			err := u.check(now)
+			test.CheckErr(t, tt.err, "failed to get upcoming rights", err)
+			if !tt.err {
+				assert.Nil(t, u.check(now))
+			}
+			assert.Equal(t, tt.messages, client.Messages)
+		})
+	}
+}
+
+func Test_DeactivationNotifier_check(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    test.RPCMock
+		register func() (string, error)
+		err      bool
+		messages []string
+	}{
+		{
+			"notifies of approaching deactivation once per cycle",
+			test.RPCMock{HeadCycle: 100, GracePeriod: 102},
+			nil,
+			false,
+			[]string{"[TZPAY]: some_delegate will be deactivated at the end of cycle 102 (2 cycles left) unless it bakes or endorses"},
+		},
+		{
+			"notifies of deactivation",
+			test.RPCMock{HeadCycle: 100, GracePeriod: 98, Deactivated: true},
+			nil,
+			false,
+			[]string{"[TZPAY]: some_delegate is deactivated and gets no rights until it registers again"},
+		},
+		{
+			"registers deactivated delegate",
+			test.RPCMock{HeadCycle: 100, GracePeriod: 98, Deactivated: true},
+			func() (string, error) { return "ooSomeHash", nil },
+			false,
+			[]string{"[TZPAY]: some_delegate was deactivated and registered again: https://tzkt.io/ooSomeHash"},
+		},
+		{
+			"notifies of failure to register",
+			test.RPCMock{HeadCycle: 100, GracePeriod: 98, Deactivated: true},
+			func() (string, error) { return "", errors.New("failed to register baker") },
+			false,
+			[]string{"[TZPAY]: some_delegate is deactivated and failed to register again: failed to register baker"},
+		},
+		{
+			"does not notify of active delegate",
+			test.RPCMock{HeadCycle: 100, GracePeriod: 105},
+			nil,
+			false,
+			nil,
+		},
+		{
+			"handles failure to get delegate",
+			test.RPCMock{DelegateErr: true},
+			nil,
+			true,
+			nil,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &MockClient{}
+			d := NewDeactivationNotifier(DeactivationNotifierInput{
+				Notifiers: []ClientIFace{client},
+				RPCClient: &tt.input,
+				Baker:     "some_delegate",
+				Cycles:    3,
+				Register:  tt.register,
+			}).(*DeactivationNotifier)
+
+			err := d.check()
+			test.CheckErr(t, tt.err, "failed to get status of delegate", err)
+			if !tt.err {
+				assert.Nil(t, d.check())
+			}
+			assert.Equal(t, tt.messages, client.Messages)
+		})
+	}
+}
+
+func Test_DelegateStatus_State(t *testing.T) {
+	assert.Equal(t, DelegateActive, DelegateStatus{CyclesLeft: 4}.State(3))
+	assert.Equal(t, DelegateGracePeriod, DelegateStatus{CyclesLeft: 3}.State(3))
+	assert.Equal(t, DelegateDeactivated, DelegateStatus{Deactivated: true, CyclesLeft: -2}.State(3))
+}
@@ -0,0 +1,157 @@
+package notifier
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of notifications, which routes send to some of the clients only. Routes also apply to the events of payouts.
+const (
+	// KindPayout is the summary of a payout made or skipped
+	KindPayout = "payout"
+	// KindAlert is a payout aborted or failing, a missed right, a deactivation, books that are off or a low balance
+	KindAlert = "alert"
+	// KindApproval is a payout awaiting approval
+	KindApproval = "approval"
+	// KindNotice is anything else: departures, overrides, upcoming rights
+	KindNotice = "notice"
+)
+
+// routable are the kinds of notifications and the events of payouts a route can be set for
+var routable = map[string]bool{
+	KindPayout: true, KindAlert: true, KindApproval: true, KindNotice: true,
+	EventComputed: true, EventInjected: true, EventConfirmed: true, EventFailed: true,
+}
+
+/*
+Registry holds the clients of the notification services by name, all of them sent every notification unless a route
+sends a kind of notification or event to some of them only.
+*/
+type Registry struct {
+	mu      sync.RWMutex
+	names   []string
+	clients map[string]ClientIFace
+	routes  map[string][]string
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		clients: map[string]ClientIFace{},
+		routes:  map[string][]string{},
+	}
+}
+
+// newRegistryOf returns a Registry of clients without routes, named by their index
+func newRegistryOf(clients []ClientIFace) *Registry {
+	registry := NewRegistry()
+	for i, client := range clients {
+		registry.Register(strconv.Itoa(i), client)
+	}
+
+	return registry
+}
+
+// Register adds client under name, replacing the client already registered under it
+func (r *Registry) Register(name string, client ClientIFace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.clients[name] = client
+}
+
+// Names returns the names of the clients in the order they were registered
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string{}, r.names...)
+}
+
+// Clients returns every client in the order they were registered
+func (r *Registry) Clients() []ClientIFace {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.byName(r.names)
+}
+
+// byName returns the clients named names
+func (r *Registry) byName(names []string) []ClientIFace {
+	clients := make([]ClientIFace, len(names))
+	for i, name := range names {
+		clients[i] = r.clients[name]
+	}
+
+	return clients
+}
+
+/*
+SetRoutes sets routes of the form <kind>:<name>[+<name>...], e.g. alert:twilio+telegram, sending notifications of kind
+to the clients named only. Every client named must be registered, and a kind without route goes to every client.
+*/
+func (r *Registry) SetRoutes(routes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, route := range routes {
+		parts := strings.SplitN(route, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			return errors.Errorf("invalid notification route '%s': expected '<kind>:<name>[+<name>...]'", route)
+		}
+
+		kind := strings.TrimSpace(parts[0])
+		if !routable[kind] {
+			return errors.Errorf("invalid notification route '%s': unknown kind '%s'", route, kind)
+		}
+
+		var names []string
+		for _, name := range strings.Split(parts[1], "+") {
+			name = strings.TrimSpace(name)
+			if _, ok := r.clients[name]; !ok {
+				return errors.Errorf("invalid notification route '%s': notifier '%s' is not configured", route, name)
+			}
+			names = append(names, name)
+		}
+		r.routes[kind] = names
+	}
+
+	return nil
+}
+
+// Route returns the clients sent the notifications of kind
+func (r *Registry) Route(kind string) []ClientIFace {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names, ok := r.routes[kind]
+	if !ok {
+		names = r.names
+	}
+
+	return r.byName(names)
+}
+
+/*
+Replace swaps the clients and routes of r for those of other at once, e.g. once the configuration is reloaded, so that
+every notification goes either to the clients before or to those after. other is not to be used afterwards.
+*/
+func (r *Registry) Replace(other *Registry) {
+	other.mu.RLock()
+	names, clients, routes := other.names, other.clients, other.routes
+	other.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names, r.clients, r.routes = names, clients, routes
+}
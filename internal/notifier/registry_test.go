@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Registry_SetRoutes(t *testing.T) {
+	cases := []struct {
+		name     string
+		routes   []string
+		err      bool
+		contains string
+	}{
+		{"is successful", []string{"alert:twilio+telegram", "payout: twitter", "failed:webhook"}, false, ""},
+		{"handles a route without notifiers", []string{"alert"}, true, "expected '<kind>:<name>[+<name>...]'"},
+		{"handles an unknown kind", []string{"errors:twilio"}, true, "unknown kind 'errors'"},
+		{"handles a notifier not configured", []string{"alert:pagerduty"}, true, "notifier 'pagerduty' is not configured"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			for _, name := range []string{"twilio", "twitter", "telegram", "webhook"} {
+				registry.Register(name, &MockClient{})
+			}
+
+			test.CheckErr(t, tt.err, tt.contains, registry.SetRoutes(tt.routes))
+		})
+	}
+}
+
+func Test_PayoutNotifier_Routes(t *testing.T) {
+	twilio, twitter, webhook := &MockClient{}, &MockClient{}, &MockEventClient{}
+	registry := NewRegistry()
+	registry.Register("twilio", twilio)
+	registry.Register("twitter", twitter)
+	registry.Register("webhook", webhook)
+	assert.Nil(t, registry.SetRoutes([]string{"alert:twilio", "payout:twitter+webhook", "computed:twilio"}))
+	assert.Equal(t, []string{"twilio", "twitter", "webhook"}, registry.Names())
+
+	notifier := NewPayoutNotifier(PayoutNotifierInput{Registry: registry})
+	assert.Nil(t, notifier.NotifyKind(KindAlert, "[TZPAY] ALERT payout for cycle 300 aborted"))
+	assert.Nil(t, notifier.NotifyLow("[TZPAY] payout for cycle 300"))
+	assert.Nil(t, notifier.Notify("[TZPAY] overrides changed"))
+	notifier.Event(Event{Name: EventComputed, Cycle: 300})
+	notifier.Event(Event{Name: EventInjected, Cycle: 300})
+
+	assert.Equal(t, []string{"[TZPAY] ALERT payout for cycle 300 aborted", "[TZPAY] overrides changed"}, twilio.Messages)
+	assert.Equal(t, []string{"[TZPAY] payout for cycle 300", "[TZPAY] overrides changed"}, twitter.Messages)
+	assert.Equal(t, []string{"[TZPAY] payout for cycle 300", "[TZPAY] overrides changed"}, webhook.Messages)
+	assert.Len(t, webhook.Events, 1)
+	assert.Equal(t, EventInjected, webhook.Events[0].Name)
+}
+
+func Test_Registry_Replace(t *testing.T) {
+	twilio, twitter, telegram := &MockClient{}, &MockClient{}, &MockClient{}
+	registry := NewRegistry()
+	registry.Register("twilio", twilio)
+	registry.Register("twitter", twitter)
+	assert.Nil(t, registry.SetRoutes([]string{"alert:twilio"}))
+
+	reloaded := NewRegistry()
+	reloaded.Register("twitter", twitter)
+	reloaded.Register("telegram", telegram)
+	assert.Nil(t, reloaded.SetRoutes([]string{"alert:telegram"}))
+
+	registry.Replace(reloaded)
+	assert.Equal(t, []string{"twitter", "telegram"}, registry.Names())
+	assert.Equal(t, []ClientIFace{telegram}, registry.Route(KindAlert))
+	assert.Equal(t, []ClientIFace{twitter, telegram}, registry.Route(KindPayout))
+}
@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/calendar"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// UpcomingRightsNotifierInput -
+type UpcomingRightsNotifierInput struct {
+	Notifiers   []ClientIFace
+	RPCClient   rpc.IFace
+	Baker       string
+	Before      time.Duration
+	MaxPriority int
+}
+
+// UpcomingRightsNotifier -
+type UpcomingRightsNotifier struct {
+	notifiers   []ClientIFace
+	rpcClient   rpc.IFace
+	baker       string
+	before      time.Duration
+	maxPriority int
+	cycle       int
+	rights      []calendar.Right
+	notified    map[int]bool
+}
+
+/*
+NewUpcomingRightsNotifier -
+
+A notification process that warns you ahead of high priority baking rights, so that
+maintenance can be planned around them.
+*/
+func NewUpcomingRightsNotifier(input UpcomingRightsNotifierInput) Notifier {
+	return &UpcomingRightsNotifier{
+		notifiers:   input.Notifiers,
+		rpcClient:   input.RPCClient,
+		baker:       input.Baker,
+		before:      input.Before,
+		maxPriority: input.MaxPriority,
+		cycle:       -1,
+		notified:    map[int]bool{},
+	}
+}
+
+// Start -
+func (u *UpcomingRightsNotifier) Start() {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		for range ticker.C {
+			if err := u.check(time.Now()); err != nil {
+				log.WithField("error", err.Error()).Error("UpcomingRightsNotifier failed to check upcoming rights")
+			}
+		}
+	}()
+}
+
+// check notifies of the baking rights estimated to happen before now plus the notice period, once per right
+func (u *UpcomingRightsNotifier) check(now time.Time) error {
+	head, err := u.rpcClient.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to get current cycle")
+	}
+
+	if u.cycle != head.Metadata.Level.Cycle {
+		rights, err := calendar.Rights(calendar.RightsInput{
+			RPC:         u.rpcClient,
+			Baker:       u.baker,
+			Cycles:      []int{head.Metadata.Level.Cycle, head.Metadata.Level.Cycle + 1},
+			MaxPriority: u.maxPriority,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to get upcoming rights")
+		}
+		u.cycle = head.Metadata.Level.Cycle
+		u.rights = rights
+	}
+
+	for _, right := range u.rights {
+		if right.Kind != calendar.Baking || right.Priority > u.maxPriority || u.notified[right.Level] {
+			continue
+		}
+
+		if right.EstimatedTime.After(now) && !right.EstimatedTime.After(now.Add(u.before)) {
+			u.notified[right.Level] = true
+			msg := fmt.Sprintf("[TZPAY]: %s in %s", right.Summary(), right.EstimatedTime.Sub(now).Round(time.Minute))
+			if err := u.notify(msg); err != nil {
+				return errors.Wrap(err, "failed to notify")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *UpcomingRightsNotifier) notify(msg string) error {
+	for _, notifier := range u.notifiers {
+		if err := notifier.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// pollTimeout is the longest a request for updates is held by telegram while no message comes in
+const pollTimeout = 30 * time.Second
+
+// IFace is an interface to a telegram bot that sends messages to a chat, asks it to confirm payouts and handles its commands
+type IFace interface {
+	Send(msg string) error
+	Confirm(question string) (bool, error)
+	Listen(handle Handler)
+}
+
+// Handler handles a message posted to the chat, returning the reply to it, or false if the message is not for the bot
+type Handler func(text string) (string, bool)
+
+// Client is a telegram bot posting to the chat ChatID, which waits up to Timeout for a reply to a confirmation
+type Client struct {
+	Token   string
+	ChatID  string
+	Timeout time.Duration
+	host    string
+	client  *http.Client
+	mu      *sync.Mutex
+	offset  int64
+}
+
+type message struct {
+	MessageID int64  `json:"message_id"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+}
+
+type update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *message `json:"message"`
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// New returns a new telegram IFace
+func New(telegram Client) IFace {
+	telegram.host = "https://api.telegram.org"
+	telegram.client = &http.Client{Timeout: pollTimeout + 10*time.Second}
+	telegram.mu = &sync.Mutex{}
+	return &telegram
+}
+
+// Send posts a message to the chat
+func (c *Client) Send(msg string) error {
+	if _, err := c.send(msg); err != nil {
+		return errors.Wrapf(err, "failed to send message through telegram to '%s'", c.ChatID)
+	}
+
+	return nil
+}
+
+/*
+Confirm posts question to the chat and waits for a reply of confirm or cancel from it, returning true if the payout is
+confirmed. Only replies posted after the question count, and one question is asked at a time, so that the replies of
+the chat go to the payout they are about. An error is returned if no one replies within Timeout.
+*/
+func (c *Client) Confirm(question string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	asked, err := c.send(fmt.Sprintf("%s\nReply confirm to inject the payout or cancel to abort it.", question))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to ask for confirmation through telegram")
+	}
+
+	deadline := time.Now().Add(c.Timeout)
+	for time.Now().Before(deadline) {
+		wait := time.Until(deadline)
+		if wait > pollTimeout {
+			wait = pollTimeout
+		}
+
+		updates, err := c.updates(wait)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to get confirmation through telegram")
+		}
+
+		for _, update := range updates {
+			c.offset = update.UpdateID + 1
+			if update.Message == nil || update.Message.MessageID <= asked.MessageID ||
+				strconv.FormatInt(update.Message.Chat.ID, 10) != c.ChatID {
+				continue
+			}
+
+			switch strings.ToLower(strings.TrimSpace(update.Message.Text)) {
+			case "confirm":
+				return true, nil
+			case "cancel":
+				return false, nil
+			}
+		}
+	}
+
+	return false, errors.Errorf("failed to get confirmation through telegram: no reply within %s", c.Timeout)
+}
+
+/*
+Listen handles the messages posted to the chat from now on with handle in the background, replying to those it handles.
+It stands aside while a confirmation is asked, so that the replies of the chat then go to the payout asked about.
+*/
+func (c *Client) Listen(handle Handler) {
+	since := time.Now().Unix()
+	go func() {
+		for {
+			if err := c.listen(handle, since); err != nil {
+				log.WithField("error", err.Error()).Warn("Failed to handle telegram messages.")
+				time.Sleep(pollTimeout)
+			}
+		}
+	}()
+}
+
+// listen handles the messages of the chat posted since, a unix time, that come in within a poll
+func (c *Client) listen(handle Handler, since int64) error {
+	c.mu.Lock()
+	updates, err := c.updates(pollTimeout)
+	var texts []string
+	for _, update := range updates {
+		c.offset = update.UpdateID + 1
+		if update.Message == nil || update.Message.Date < since || strconv.FormatInt(update.Message.Chat.ID, 10) != c.ChatID {
+			continue
+		}
+		texts = append(texts, update.Message.Text)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to get messages through telegram")
+	}
+
+	for _, text := range texts {
+		if reply, ok := handle(strings.TrimSpace(text)); ok {
+			if err := c.Send(reply); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) send(text string) (message, error) {
+	body, err := json.Marshal(map[string]string{"chat_id": c.ChatID, "text": text})
+	if err != nil {
+		return message{}, errors.Wrap(err, "failed to encode message")
+	}
+
+	var sent message
+	if err := c.call("sendMessage", body, &sent); err != nil {
+		return message{}, err
+	}
+
+	return sent, nil
+}
+
+func (c *Client) updates(wait time.Duration) ([]update, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"offset":          c.offset,
+		"timeout":         int(wait.Seconds()),
+		"allowed_updates": []string{"message"},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode request for updates")
+	}
+
+	var updates []update
+	if err := c.call("getUpdates", body, &updates); err != nil {
+		return nil, err
+	}
+
+	return updates, nil
+}
+
+func (c *Client) call(method string, body []byte, result interface{}) error {
+	resp, err := c.client.Post(fmt.Sprintf("%s/bot%s/%s", c.host, c.Token, method), "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The token is part of the url, which the error holds
+		return errors.Errorf("failed to call telegram method '%s'", method)
+	}
+	defer resp.Body.Close()
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "could not read response body")
+	}
+
+	var r response
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return errors.Wrapf(err, "failed to parse response of telegram method '%s'", method)
+	}
+
+	if !r.OK {
+		return errors.Errorf("telegram method '%s' failed with code %d: %s", method, resp.StatusCode, r.Description)
+	}
+
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return errors.Wrapf(err, "failed to parse result of telegram method '%s'", method)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// newBot returns a client of a bot whose chat replies with each of the updates in turn once asked a question
+func newBot(t *testing.T, updates ...string) (*Client, *[]string, func()) {
+	var sent []string
+	var polls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/botTOKEN/sendMessage":
+			assert.Equal(t, "-100", body["chat_id"])
+			sent = append(sent, body["text"].(string))
+			fmt.Fprint(w, `{"ok":true,"result":{"message_id":10,"chat":{"id":-100}}}`)
+		case "/botTOKEN/getUpdates":
+			if polls >= len(updates) {
+				fmt.Fprint(w, `{"ok":true,"result":[]}`)
+				return
+			}
+			polls++
+			fmt.Fprintf(w, `{"ok":true,"result":[%s]}`, updates[polls-1])
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"ok":false,"description":"Unauthorized"}`)
+		}
+	}))
+
+	return &Client{
+		Token:   "TOKEN",
+		ChatID:  "-100",
+		Timeout: 50 * time.Millisecond,
+		host:    server.URL,
+		client:  server.Client(),
+		mu:      &sync.Mutex{},
+	}, &sent, server.Close
+}
+
+func Test_Send(t *testing.T) {
+	client, sent, close := newBot(t)
+	defer close()
+
+	assert.Nil(t, client.Send("[TZPAY] payout for cycle 300"))
+	assert.Equal(t, []string{"[TZPAY] payout for cycle 300"}, *sent)
+
+	client.Token = "WRONG"
+	test.CheckErr(t, true, "telegram method 'sendMessage' failed with code 401: Unauthorized", client.Send("msg"))
+}
+
+func Test_Confirm(t *testing.T) {
+	cases := []struct {
+		name     string
+		updates  []string
+		want     bool
+		wantErr  bool
+		contains string
+	}{
+		{
+			name: "confirms on a reply of confirm",
+			updates: []string{
+				`{"update_id":1,"message":{"message_id":9,"text":"confirm","chat":{"id":-100}}}`,
+				`{"update_id":2,"message":{"message_id":11,"text":"Confirm ","chat":{"id":-100}}}`,
+			},
+			want: true,
+		},
+		{
+			name: "cancels on a reply of cancel",
+			updates: []string{
+				`{"update_id":1,"message":{"message_id":11,"text":"what is this?","chat":{"id":-100}}}`,
+				`{"update_id":2,"message":{"message_id":12,"text":"cancel","chat":{"id":-100}}}`,
+			},
+			want: false,
+		},
+		{
+			name: "ignores replies of other chats and replies before the question",
+			updates: []string{
+				`{"update_id":1,"message":{"message_id":11,"text":"confirm","chat":{"id":-200}}}`,
+				`{"update_id":2,"message":{"message_id":9,"text":"confirm","chat":{"id":-100}}}`,
+			},
+			wantErr:  true,
+			contains: "no reply within 50ms",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client, sent, close := newBot(t, tt.updates...)
+			defer close()
+
+			confirmed, err := client.Confirm("[TZPAY] confirm payout for cycle 300?")
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			assert.Equal(t, tt.want, confirmed)
+			assert.Equal(t, []string{"[TZPAY] confirm payout for cycle 300?\nReply confirm to inject the payout or cancel to abort it."}, *sent)
+			assert.Equal(t, int64(len(tt.updates)+1), client.offset)
+		})
+	}
+}
+
+func Test_listen(t *testing.T) {
+	client, sent, close := newBot(t,
+		`{"update_id":1,"message":{"message_id":9,"date":50,"text":"approve 299","chat":{"id":-100}}},`+
+			`{"update_id":2,"message":{"message_id":10,"date":150,"text":"approve 300","chat":{"id":-200}}},`+
+			`{"update_id":3,"message":{"message_id":11,"date":150,"text":"hello","chat":{"id":-100}}},`+
+			`{"update_id":4,"message":{"message_id":12,"date":150,"text":" approve 300 ","chat":{"id":-100}}}`,
+	)
+	defer close()
+
+	var handled []string
+	handle := func(text string) (string, bool) {
+		handled = append(handled, text)
+		return "approved " + strings.TrimPrefix(text, "approve "), strings.HasPrefix(text, "approve ")
+	}
+
+	// Only the messages of the chat posted since the bot listens are handled, and only those for the bot replied to
+	assert.Nil(t, client.listen(handle, 100))
+	assert.Equal(t, []string{"hello", "approve 300"}, handled)
+	assert.Equal(t, []string{"approved 300"}, *sent)
+	assert.Equal(t, int64(5), client.offset)
+
+	client.Token = "WRONG"
+	test.CheckErr(t, true, "failed to get messages through telegram", client.listen(handle, 100))
+}
@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Message is what the message template renders, Text being the message sent through the notifier named Notifier
+type Message struct {
+	Notifier string
+	Text     string
+}
+
+/*
+TemplatedClient -
+
+Renders every message sent through a client with a Go template, e.g. to brand the messages or to shorten those of a
+service with a length limit.
+*/
+type TemplatedClient struct {
+	client   ClientIFace
+	name     string
+	template *template.Template
+}
+
+// NewTemplatedClient returns client rendering its messages with text, or client itself if text is empty
+func NewTemplatedClient(client ClientIFace, name, text string) (ClientIFace, error) {
+	if text == "" {
+		return client, nil
+	}
+
+	tmpl, err := template.New("message").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse message template")
+	}
+
+	return &TemplatedClient{
+		client:   client,
+		name:     name,
+		template: tmpl,
+	}, nil
+}
+
+// Send satisfies ClientIFace
+func (t *TemplatedClient) Send(msg string) error {
+	var buf bytes.Buffer
+	if err := t.template.Execute(&buf, Message{Notifier: t.name, Text: msg}); err != nil {
+		return errors.Wrap(err, "failed to render message template")
+	}
+
+	return t.client.Send(buf.String())
+}
@@ -0,0 +1,23 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TemplatedClient(t *testing.T) {
+	client := &MockClient{}
+	same, err := NewTemplatedClient(client, "twitter", "")
+	assert.Nil(t, err)
+	assert.Equal(t, client, same)
+
+	_, err = NewTemplatedClient(client, "twitter", "{{.Text")
+	test.CheckErr(t, true, "failed to parse message template", err)
+
+	templated, err := NewTemplatedClient(client, "twitter", `{{.Text}}{{if eq .Notifier "twitter"}} #tezos{{end}} - Some Baker`)
+	assert.Nil(t, err)
+	assert.Nil(t, templated.Send("[TZPAY] payout for cycle 300"))
+	assert.Equal(t, []string{"[TZPAY] payout for cycle 300 #tezos - Some Baker"}, client.Messages)
+}
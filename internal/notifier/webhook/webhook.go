@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/pkg/errors"
+)
+
+// IFace is an interface to a client that posts the events of payouts and notification messages to a webhook
+type IFace interface {
+	Send(msg string) error
+	SendEvent(event notifier.Event) error
+}
+
+// Input is the input for New
+type Input struct {
+	URL      string
+	Template string   // Go template rendering the JSON payload of an event, the event as JSON if empty
+	Headers  []string // "Name:Value", e.g. an authorization header expected by the webhook
+	Events   []string // the events posted, all of them if empty
+}
+
+// Client posts the events of payouts to a webhook as JSON
+type Client struct {
+	url      string
+	template *template.Template
+	headers  http.Header
+	events   map[string]bool
+	client   *http.Client
+}
+
+// New returns a new webhook IFace, or an error if its template does not parse or a header is invalid
+func New(input Input) (IFace, error) {
+	client := &Client{
+		url:     input.URL,
+		headers: http.Header{},
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	for _, header := range input.Headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid webhook header '%s': expected 'Name:Value'", header)
+		}
+		client.headers.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	if len(input.Events) > 0 {
+		client.events = map[string]bool{}
+		for _, event := range input.Events {
+			client.events[strings.TrimSpace(event)] = true
+		}
+	}
+
+	if input.Template != "" {
+		var err error
+		client.template, err = template.New("webhook").Funcs(template.FuncMap{"json": toJSON}).Parse(input.Template)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse webhook template")
+		}
+	}
+
+	return client, nil
+}
+
+// Send posts msg as an event named message
+func (c *Client) Send(msg string) error {
+	return c.SendEvent(notifier.Event{Name: notifier.EventMessage, Time: time.Now().UTC(), Message: msg})
+}
+
+// SendEvent posts the payload of event, unless the webhook only takes other events
+func (c *Client) SendEvent(event notifier.Event) error {
+	if c.events != nil && !c.events[event.Name] {
+		return nil
+	}
+
+	payload, err := c.payload(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook request")
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to post %s event to webhook", event.Name)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		raw, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("failed to post %s event to webhook: response returned code %d with body %s", event.Name, resp.StatusCode, string(raw))
+	}
+
+	return nil
+}
+
+// payload renders event with the template, refusing a payload that is not JSON so that a broken template shows up early
+func (c *Client) payload(event notifier.Event) ([]byte, error) {
+	if c.template == nil {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode %s event", event.Name)
+		}
+		return payload, nil
+	}
+
+	var buf bytes.Buffer
+	if err := c.template.Execute(&buf, event); err != nil {
+		return nil, errors.Wrapf(err, "failed to render %s event with webhook template", event.Name)
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.Errorf("failed to render %s event with webhook template: payload is not valid JSON: %s", event.Name, buf.String())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// toJSON is the json function of templates, quoting a value so that messages with quotes or new lines stay valid JSON
+func toJSON(v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	return string(raw), err
+}
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SendEvent(t *testing.T) {
+	event := notifier.Event{
+		Name:       notifier.EventInjected,
+		Time:       time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC),
+		Baker:      "tz1baker",
+		Cycle:      300,
+		Transfers:  2,
+		Amount:     300,
+		Operations: []string{"oo1"},
+	}
+
+	cases := []struct {
+		name     string
+		input    Input
+		event    notifier.Event
+		want     []string
+		wantErr  bool
+		contains string
+	}{
+		{
+			name:  "posts the event as JSON",
+			input: Input{Headers: []string{"Authorization: Bearer secret"}},
+			event: event,
+			want: []string{
+				`{"event":"injected","time":"2020-09-01T12:00:00Z","baker":"tz1baker","cycle":300,"transfers":2,"amount":300,"operations":["oo1"]}`,
+			},
+		},
+		{
+			name:  "renders the event with the template",
+			input: Input{Template: `{"text": {{json (printf "%s payout for cycle %d" .Name .Cycle)}}, "ops": {{json .Operations}}}`},
+			event: event,
+			want:  []string{`{"text": "injected payout for cycle 300", "ops": ["oo1"]}`},
+		},
+		{
+			name:  "leaves out the events not configured",
+			input: Input{Events: []string{"failed", " confirmed"}},
+			event: event,
+		},
+		{
+			name:     "refuses a payload that is not JSON",
+			input:    Input{Template: `{"text": "{{.Message}}"}`},
+			event:    notifier.Event{Name: notifier.EventMessage, Message: `payout "300"`},
+			wantErr:  true,
+			contains: "payload is not valid JSON",
+		},
+		{
+			name:     "handles the webhook failing",
+			input:    Input{Headers: []string{"Authorization: Bearer wrong"}},
+			event:    event,
+			wantErr:  true,
+			contains: "response returned code 401",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if auth := r.Header.Get("Authorization"); auth != "" && auth != "Bearer secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+				body, err := ioutil.ReadAll(r.Body)
+				assert.Nil(t, err)
+				posted = append(posted, string(body))
+			}))
+			defer server.Close()
+
+			tt.input.URL = server.URL
+			client, err := New(tt.input)
+			assert.Nil(t, err)
+
+			err = client.SendEvent(tt.event)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			assert.Equal(t, tt.want, posted)
+		})
+	}
+}
+
+func Test_New(t *testing.T) {
+	_, err := New(Input{URL: "http://localhost", Headers: []string{"Bearer secret"}})
+	test.CheckErr(t, true, "invalid webhook header 'Bearer secret'", err)
+
+	_, err = New(Input{URL: "http://localhost", Template: `{{.Cycle`})
+	test.CheckErr(t, true, "failed to parse webhook template", err)
+}
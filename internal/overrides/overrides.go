@@ -0,0 +1,360 @@
+package overrides
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Columns of an overrides document, matched case insensitively. Other columns are ignored.
+const (
+	ColumnAddress       = "address"
+	ColumnFee           = "fee"
+	ColumnPayoutAddress = "payout_address"
+	ColumnSchedule      = "schedule"
+)
+
+// Override holds the settings of a delegator that replace the configured ones, an empty setting is left as configured
+type Override struct {
+	Address       string `json:"address"`
+	Fee           string `json:"fee,omitempty"`
+	PayoutAddress string `json:"payout_address,omitempty"`
+	Schedule      string `json:"schedule,omitempty"`
+}
+
+// Overrides is the content of an overrides document
+type Overrides []Override
+
+var googleSheet = regexp.MustCompile(`^(https://docs\.google\.com/spreadsheets/d/[^/]+)/(edit|view)[^#]*(#gid=(\d+))?`)
+
+/*
+Parse reads an overrides document from CSV, e.g:
+
+	address,fee,payout_address,schedule
+	tz1...,0.02,,
+	tz1...,,tz1...,weekly
+
+The first row names the columns and must include an address column. Rows without an address are skipped, so that
+blank rows and notes in a spreadsheet do not fail the document.
+*/
+func Parse(r io.Reader) (Overrides, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse overrides")
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("failed to parse overrides: missing header")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[ColumnAddress]; !ok {
+		return nil, errors.Errorf("failed to parse overrides: missing '%s' column", ColumnAddress)
+	}
+
+	value := func(row []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var overrides Overrides
+	seen := map[string]bool{}
+	for i, row := range rows[1:] {
+		override := Override{
+			Address:       value(row, ColumnAddress),
+			Fee:           value(row, ColumnFee),
+			PayoutAddress: value(row, ColumnPayoutAddress),
+			Schedule:      strings.ToLower(value(row, ColumnSchedule)),
+		}
+		if override.Address == "" {
+			continue
+		}
+
+		if err := override.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid overrides at row %d", i+2)
+		}
+		if seen[override.Address] {
+			return nil, errors.Errorf("invalid overrides at row %d: '%s' is overridden more than once", i+2, override.Address)
+		}
+		seen[override.Address] = true
+		overrides = append(overrides, override)
+	}
+
+	return overrides, nil
+}
+
+func (o Override) validate() error {
+	if !isAddress(o.Address) {
+		return errors.Errorf("invalid address '%s'", o.Address)
+	}
+
+	if o.Fee != "" {
+		if fee, err := strconv.ParseFloat(o.Fee, 64); err != nil || fee < 0 || fee > 1 {
+			return errors.Errorf("invalid fee '%s' for '%s': expected a fee between 0 and 1", o.Fee, o.Address)
+		}
+	}
+
+	if o.PayoutAddress != "" && !isAddress(o.PayoutAddress) {
+		return errors.Errorf("invalid payout address '%s' for '%s'", o.PayoutAddress, o.Address)
+	}
+
+	if o.Schedule != "" && o.Schedule != config.ScheduleWeekly && o.Schedule != config.ScheduleMonthly {
+		return errors.Errorf("invalid schedule '%s' for '%s': expected %s or %s", o.Schedule, o.Address, config.ScheduleWeekly, config.ScheduleMonthly)
+	}
+
+	return nil
+}
+
+func isAddress(address string) bool {
+	return len(address) == 36 && (strings.HasPrefix(address, "tz") || strings.HasPrefix(address, "KT"))
+}
+
+// exportURL returns the CSV export of a Google Sheet shared by its edit or view link, and any other url as is
+func exportURL(url string) string {
+	matches := googleSheet.FindStringSubmatch(url)
+	if matches == nil {
+		return url
+	}
+
+	export := matches[1] + "/export?format=csv"
+	if matches[4] != "" {
+		export += "&gid=" + matches[4]
+	}
+
+	return export
+}
+
+// Fetch downloads and parses the overrides document found at url
+func Fetch(client *http.Client, url string) (Overrides, error) {
+	resp, err := client.Get(exportURL(url))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch overrides")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		byts, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, errors.Errorf("failed to fetch overrides: response returned code %d with body %s", resp.StatusCode, string(byts))
+	}
+
+	return Parse(resp.Body)
+}
+
+// Apply returns cfg with the overrides taking precedence over the settings of every baker
+func (o Overrides) Apply(cfg config.Config) config.Config {
+	cfg.Baker = o.apply(cfg.Baker)
+
+	delegates := make([]config.Delegate, len(cfg.Delegates))
+	for i, delegate := range cfg.Delegates {
+		delegate.Baker = o.apply(delegate.Baker)
+		delegates[i] = delegate
+	}
+	if cfg.Delegates != nil {
+		cfg.Delegates = delegates
+	}
+
+	return cfg
+}
+
+func (o Overrides) apply(baker config.Baker) config.Baker {
+	var fees, payoutAddresses, schedules []string
+	for _, override := range o {
+		if override.Fee != "" {
+			fees = append(fees, override.Address+":"+override.Fee)
+		}
+		if override.PayoutAddress != "" {
+			payoutAddresses = append(payoutAddresses, override.Address+":"+override.PayoutAddress)
+		}
+		if override.Schedule != "" {
+			schedules = append(schedules, override.Address+":"+override.Schedule)
+		}
+	}
+
+	// The first setting found for a delegator is used, so the overrides go first
+	baker.Fees = append(fees, baker.Fees...)
+	baker.PayoutAddresses = append(payoutAddresses, baker.PayoutAddresses...)
+	baker.PayoutSchedules = append(schedules, baker.PayoutSchedules...)
+
+	return baker
+}
+
+// Changes describes the overrides added, removed or changed from previous to current
+func Changes(previous, current Overrides) []string {
+	before := map[string]Override{}
+	for _, override := range previous {
+		before[override.Address] = override
+	}
+
+	var changes []string
+	after := map[string]bool{}
+	for _, override := range current {
+		after[override.Address] = true
+		old, ok := before[override.Address]
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("added %s", override))
+		case old != override:
+			changes = append(changes, fmt.Sprintf("changed %s (was %s)", override, old.settings()))
+		}
+	}
+
+	for _, override := range previous {
+		if !after[override.Address] {
+			changes = append(changes, fmt.Sprintf("removed %s", override.Address))
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+func (o Override) String() string {
+	return fmt.Sprintf("%s: %s", o.Address, o.settings())
+}
+
+func (o Override) settings() string {
+	var settings []string
+	if o.Fee != "" {
+		settings = append(settings, "fee "+o.Fee)
+	}
+	if o.PayoutAddress != "" {
+		settings = append(settings, "paid to "+o.PayoutAddress)
+	}
+	if o.Schedule != "" {
+		settings = append(settings, "paid "+o.Schedule)
+	}
+	if len(settings) == 0 {
+		return "no settings"
+	}
+
+	return strings.Join(settings, ", ")
+}
+
+// ProviderInput is the input for NewProvider
+type ProviderInput struct {
+	URL      string
+	Notifier *notifier.PayoutNotifier
+}
+
+/*
+Provider keeps the overrides found at a url up to date. A document that fails to fetch or to validate is reported
+and the last valid overrides are kept, so a mistake in a spreadsheet never changes a payout.
+*/
+type Provider struct {
+	url       string
+	client    *http.Client
+	notifier  *notifier.PayoutNotifier
+	overrides Overrides
+	loaded    bool
+	lastErr   string
+	mu        *sync.Mutex
+}
+
+// NewProvider returns a new Provider
+func NewProvider(input ProviderInput) *Provider {
+	return &Provider{
+		url:      input.URL,
+		client:   newClient(),
+		notifier: input.Notifier,
+		mu:       &sync.Mutex{},
+	}
+}
+
+func newClient() *http.Client {
+	return &http.Client{
+		Timeout: time.Second * 30,
+		Transport: &http.Transport{
+			Dial: (&net.Dialer{
+				Timeout: 10 * time.Second,
+			}).Dial,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start refreshes the overrides every interval until the process exits
+func (p *Provider) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for range ticker.C {
+			if err := p.Refresh(); err != nil {
+				log.WithField("error", err.Error()).Error("Failed to refresh overrides, keeping the last valid overrides.")
+			}
+		}
+	}()
+}
+
+// Refresh fetches the overrides and notifies of their changes since the last refresh
+func (p *Provider) Refresh() error {
+	overrides, err := Fetch(p.client, p.url)
+
+	p.mu.Lock()
+	previous, loaded := p.overrides, p.loaded
+	if err != nil {
+		report := err.Error() != p.lastErr
+		p.lastErr = err.Error()
+		p.mu.Unlock()
+
+		if report && loaded {
+			p.notify(fmt.Sprintf("[TZPAY] overrides rejected, keeping the last valid overrides: %s", err.Error()))
+		}
+		return err
+	}
+	p.overrides, p.loaded, p.lastErr = overrides, true, ""
+	p.mu.Unlock()
+
+	if !loaded {
+		log.WithField("overrides", len(overrides)).Info("Loaded overrides.")
+		return nil
+	}
+
+	if changes := Changes(previous, overrides); len(changes) > 0 {
+		log.WithField("changes", changes).Info("Overrides changed.")
+		p.notify(fmt.Sprintf("[TZPAY] overrides changed:\n%s", strings.Join(changes, "\n")))
+	}
+
+	return nil
+}
+
+func (p *Provider) notify(msg string) {
+	if p.notifier == nil {
+		return
+	}
+
+	if err := p.notifier.Notify(msg); err != nil {
+		log.WithField("error", err.Error()).Error("Failed to notify.")
+	}
+}
+
+// Apply returns cfg with the current overrides taking precedence, or cfg as is if p is nil
+func (p *Provider) Apply(cfg config.Config) config.Config {
+	if p == nil {
+		return cfg
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.overrides.Apply(cfg)
+}
@@ -0,0 +1,168 @@
+package overrides
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	delegatorA = "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+	delegatorB = "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"
+	coldWallet = "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo"
+)
+
+func Test_Parse(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		want     Overrides
+		err      bool
+		contains string
+	}{
+		{
+			"is successful",
+			"Address,Fee,Payout_Address,Schedule,Notes\n" +
+				delegatorA + ",0.02,,,friend\n" +
+				",,,,\n" +
+				delegatorB + ",," + coldWallet + ",Weekly\n",
+			Overrides{
+				{Address: delegatorA, Fee: "0.02"},
+				{Address: delegatorB, PayoutAddress: coldWallet, Schedule: config.ScheduleWeekly},
+			},
+			false,
+			"",
+		},
+		{
+			"handles missing address column",
+			"delegator,fee\n" + delegatorA + ",0.02\n",
+			nil,
+			true,
+			"missing 'address' column",
+		},
+		{
+			"handles invalid fee",
+			"address,fee\n" + delegatorA + ",5%\n",
+			nil,
+			true,
+			"invalid overrides at row 2: invalid fee '5%'",
+		},
+		{
+			"handles invalid address",
+			"address,fee\ntz1short,0.02\n",
+			nil,
+			true,
+			"invalid address 'tz1short'",
+		},
+		{
+			"handles invalid schedule",
+			"address,schedule\n" + delegatorA + ",daily\n",
+			nil,
+			true,
+			"invalid schedule 'daily'",
+		},
+		{
+			"handles duplicate delegators",
+			"address,fee\n" + delegatorA + ",0.02\n" + delegatorA + ",0.03\n",
+			nil,
+			true,
+			"invalid overrides at row 3",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides, err := Parse(strings.NewReader(tt.input))
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.want, overrides)
+		})
+	}
+}
+
+func Test_exportURL(t *testing.T) {
+	assert.Equal(t, "https://docs.google.com/spreadsheets/d/abc123/export?format=csv&gid=42",
+		exportURL("https://docs.google.com/spreadsheets/d/abc123/edit#gid=42"))
+	assert.Equal(t, "https://docs.google.com/spreadsheets/d/abc123/export?format=csv",
+		exportURL("https://docs.google.com/spreadsheets/d/abc123/edit?usp=sharing"))
+	assert.Equal(t, "https://example.com/overrides.csv", exportURL("https://example.com/overrides.csv"))
+}
+
+func Test_Apply(t *testing.T) {
+	cfg := config.Config{
+		Baker: config.Baker{
+			Address:         "tz1baker",
+			Fee:             0.05,
+			PayoutAddresses: []string{delegatorB + ":tz1old"},
+		},
+		Delegates: []config.Delegate{{Baker: config.Baker{Address: "tz1other", Fee: 0.1}}},
+	}
+
+	applied := Overrides{
+		{Address: delegatorA, Fee: "0.02"},
+		{Address: delegatorB, PayoutAddress: coldWallet},
+	}.Apply(cfg)
+
+	assert.Equal(t, 0.02, applied.Baker.FeeFor(delegatorA))
+	assert.Equal(t, 0.05, applied.Baker.FeeFor(delegatorB))
+	assert.Equal(t, coldWallet, applied.Baker.PayoutAddress(delegatorB))
+	assert.Equal(t, 0.02, applied.Delegates[0].Baker.FeeFor(delegatorA))
+	assert.Equal(t, 0.1, applied.Delegates[0].Baker.FeeFor(delegatorB))
+
+	assert.Equal(t, []string{delegatorB + ":tz1old"}, cfg.Baker.PayoutAddresses)
+	assert.Nil(t, cfg.Delegates[0].Baker.Fees)
+}
+
+func Test_Changes(t *testing.T) {
+	previous := Overrides{
+		{Address: delegatorA, Fee: "0.02"},
+		{Address: delegatorB, PayoutAddress: coldWallet},
+	}
+	current := Overrides{
+		{Address: delegatorA, Fee: "0.03"},
+		{Address: coldWallet, Schedule: config.ScheduleMonthly},
+	}
+
+	assert.Equal(t, []string{
+		"added " + coldWallet + ": paid monthly",
+		"changed " + delegatorA + ": fee 0.03 (was fee 0.02)",
+		"removed " + delegatorB,
+	}, Changes(previous, current))
+	assert.Empty(t, Changes(current, current))
+}
+
+func Test_Provider_Refresh(t *testing.T) {
+	document := "address,fee\n" + delegatorA + ",0.02\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(document))
+	}))
+	defer server.Close()
+
+	client := &notifier.MockClient{}
+	payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{client}})
+	provider := NewProvider(ProviderInput{URL: server.URL, Notifier: &payoutNotifier})
+
+	assert.Nil(t, provider.Refresh())
+	assert.Equal(t, 0.02, provider.Apply(config.Config{}).Baker.FeeFor(delegatorA))
+	assert.Nil(t, client.Messages)
+
+	document = "address,fee\n" + delegatorA + ",2\n"
+	test.CheckErr(t, true, "invalid fee '2'", provider.Refresh())
+	assert.Equal(t, 0.02, provider.Apply(config.Config{}).Baker.FeeFor(delegatorA))
+	test.CheckErr(t, true, "invalid fee '2'", provider.Refresh())
+	assert.Len(t, client.Messages, 1)
+	assert.Contains(t, client.Messages[0], "overrides rejected")
+
+	document = "address,fee\n" + delegatorA + ",0.03\n"
+	assert.Nil(t, provider.Refresh())
+	assert.Equal(t, 0.03, provider.Apply(config.Config{}).Baker.FeeFor(delegatorA))
+	assert.Equal(t, "[TZPAY] overrides changed:\nchanged "+delegatorA+": fee 0.03 (was fee 0.02)", client.Messages[1])
+
+	var nilProvider *Provider
+	assert.Equal(t, config.Config{}, nilProvider.Apply(config.Config{}))
+}
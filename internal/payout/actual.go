@@ -0,0 +1,96 @@
+package payout
+
+import (
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+actualRewards walks every block of the cycle and sums the baking and endorsing rewards and fees frozen for the baker,
+or paid to it from Tenderbake on. Unlike the totals reported by the indexer, which assume ideal performance, this
+reflects missed and stolen bakes.
+*/
+func (p *Payout) actualRewards() (int, error) {
+	first, last, err := CycleLevels(p.tzkt, p.cycle)
+	if err != nil {
+		return 0, err
+	}
+
+	var rewards int64
+	for level := first; level <= last; level++ {
+		block, err := p.rpc.Block(level)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to get block '%d'", level)
+		}
+
+		blockRewards := p.frozenRewards
+		if RewardModel(block.Metadata.Protocol) == RewardModelTenderbake {
+			blockRewards = p.paidRewards
+		}
+
+		rewards += blockRewards(block.Metadata.BalanceUpdates)
+		for _, operations := range block.Operations {
+			for _, operation := range operations {
+				for _, content := range operation.Contents {
+					if content.Metadata != nil {
+						rewards += blockRewards(content.Metadata.BalanceUpdates)
+					}
+				}
+			}
+		}
+
+		if p.verbose && (level-first+1)%512 == 0 {
+			logrus.WithFields(logrus.Fields{
+				"cycle":  p.cycle,
+				"blocks": level - first + 1,
+				"total":  last - first + 1,
+			}).Info("Summing actual rewards.")
+		}
+	}
+
+	return int(rewards), nil
+}
+
+// frozenRewards sums the rewards and fees frozen for the baker in the cycle of the payout
+func (p *Payout) frozenRewards(updates []rpc.BalanceUpdates) int64 {
+	var rewards int64
+	for _, update := range updates {
+		if update.Kind != "freezer" || update.Delegate != p.config.Baker.Address || update.Cycle != p.cycle {
+			continue
+		}
+
+		if update.Category == "rewards" || update.Category == "fees" {
+			rewards += update.Change
+		}
+	}
+
+	return rewards
+}
+
+// tenderbakeRewards are the categories of the balance updates minting or moving rewards and fees to a Tenderbake baker
+var tenderbakeRewards = map[string]bool{
+	"baking rewards":    true,
+	"baking bonuses":    true,
+	"endorsing rewards": true,
+	"block fees":        true,
+}
+
+/*
+paidRewards sums the rewards and fees paid to the baker under Tenderbake. Each is a pair of balance updates: one
+debiting the rewards minted or the fees of the block, followed by one crediting the baker's balance.
+*/
+func (p *Payout) paidRewards(updates []rpc.BalanceUpdates) int64 {
+	var rewards int64
+	for i := 0; i+1 < len(updates); i++ {
+		source, credit := updates[i], updates[i+1]
+		if !tenderbakeRewards[source.Category] || credit.Kind != "contract" || credit.Contract != p.config.Baker.Address {
+			continue
+		}
+
+		rewards += credit.Change
+		i++
+	}
+
+	return rewards
+}
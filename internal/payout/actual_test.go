@@ -0,0 +1,128 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_actualRewards(t *testing.T) {
+	type input struct {
+		rpcClient rpc.IFace
+		tzkt      *test.TzktMock
+		cycle     int
+	}
+
+	type want struct {
+		err      bool
+		contains string
+		rewards  int
+	}
+
+	cases := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get levels of cycle",
+			input{
+				&test.RPCMock{},
+				&test.TzktMock{CycleErr: true},
+				10,
+			},
+			want{
+				true,
+				"failed to get levels of cycle 10",
+				0,
+			},
+		},
+		{
+			"handles failure to get block",
+			input{
+				&test.RPCMock{
+					BlockErr: true,
+				},
+				&test.TzktMock{},
+				10,
+			},
+			want{
+				true,
+				"failed to get block '21'",
+				0,
+			},
+		},
+		{
+			"is successful",
+			input{
+				&test.RPCMock{},
+				&test.TzktMock{},
+				10,
+			},
+			want{
+				false,
+				"",
+				2 * (40000000 + 3000 + 1250000),
+			},
+		},
+		{
+			"ignores rewards frozen for other cycles",
+			input{
+				&test.RPCMock{},
+				&test.TzktMock{},
+				11,
+			},
+			want{
+				false,
+				"",
+				0,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				rpc:   tt.input.rpcClient,
+				tzkt:  tt.input.tzkt,
+				cycle: tt.input.cycle,
+				config: config.Config{
+					Baker: config.Baker{
+						Address: "some_delegate",
+					},
+				},
+			}
+
+			rewards, err := payout.actualRewards()
+			test.CheckErr(t, tt.want.err, tt.want.contains, err)
+			assert.Equal(t, tt.want.rewards, rewards)
+		})
+	}
+}
+
+func Test_paidRewards(t *testing.T) {
+	payout := Payout{
+		config: config.Config{
+			Baker: config.Baker{
+				Address: "some_delegate",
+			},
+		},
+	}
+
+	rewards := payout.paidRewards([]rpc.BalanceUpdates{
+		{Kind: "accumulator", Category: "block fees", Change: -3000},
+		{Kind: "contract", Contract: "some_delegate", Change: 3000},
+		{Kind: "minted", Category: "baking rewards", Change: -10000000},
+		{Kind: "contract", Contract: "some_delegate", Change: 10000000},
+		{Kind: "minted", Category: "baking bonuses", Change: -4000000},
+		{Kind: "contract", Contract: "other_delegate", Change: 4000000},
+		{Kind: "minted", Category: "endorsing rewards", Change: -5000000},
+		{Kind: "contract", Contract: "some_delegate", Change: 5000000},
+		{Kind: "contract", Contract: "some_delegate", Change: -640000000},
+		{Kind: "freezer", Category: "deposits", Delegate: "some_delegate", Change: 640000000},
+	})
+	assert.Equal(t, int64(15003000), rewards)
+}
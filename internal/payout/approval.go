@@ -0,0 +1,195 @@
+package payout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/approval"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// errAwaitingApproval is returned for a payout forged that none of the approvers has approved yet
+var errAwaitingApproval = errors.New("awaiting approval")
+
+// errNotHeld is returned for the approval of a payout that is not held
+var errNotHeld = errors.New("no payout is held for the cycle")
+
+// AwaitingApproval returns true if err is the hold of a payout until one of the approvers approves it
+func AwaitingApproval(err error) bool {
+	return errors.Cause(err) == errAwaitingApproval
+}
+
+// NotHeld returns true if err is the approval of a payout that is not held
+func NotHeld(err error) bool {
+	return errors.Cause(err) == errNotHeld
+}
+
+/*
+Approval is a payout held after it was forged, until one of the approvers signs its payload, or the operator approves
+it when payouts are held without approval keys. The payload identifies
+the cycle, the baker, the payout wallet and every transfer of the payout, so that a payout recalculated differently
+needs another approval.
+*/
+type Approval struct {
+	Cycle     int       `json:"cycle"`
+	Baker     string    `json:"baker"`
+	Wallet    string    `json:"wallet"`
+	Transfers int       `json:"transfers"`
+	Amount    int64     `json:"amount"`
+	Payload   string    `json:"payload"`
+	Forged    time.Time `json:"forged"`
+	Token     string    `json:"token,omitempty"`
+	Approver  string    `json:"approver,omitempty"`
+}
+
+func approvalBucket(baker string) string {
+	return "approvals/" + baker
+}
+
+func approvalKey(cycle int) string {
+	return fmt.Sprintf("%08d", cycle)
+}
+
+// RequireApproval holds the payout once forged until the operator approves it, even without approval keys
+func (p *Payout) RequireApproval() {
+	p.approval = true
+}
+
+// usesApproval returns true if payouts are held until one of the approvers, or the operator, approves them
+func (p *Payout) usesApproval() bool {
+	return len(p.config.Approval.Keys) > 0 || p.approval
+}
+
+// fingerprint identifies a payout by the destination and amount of every transfer of each of its chunks
+func fingerprint(chunks [][]disperseTransfer) string {
+	h := sha256.New()
+	for _, chunk := range chunks {
+		for _, transfer := range chunk {
+			fmt.Fprintf(h, "%s:%d,", transfer.Destination, transfer.Amount)
+		}
+		fmt.Fprint(h, ";")
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/*
+checkApproval holds the payout made of chunks unless one of the approvers signed its payload, or without approval keys,
+unless the operator approved it. A payout held is stored for 'tzpay approve', replacing the payout held for the cycle if
+its transfers changed since.
+*/
+func (p *Payout) checkApproval(chunks [][]disperseTransfer) error {
+	if p.store == nil {
+		return errors.Errorf("failed to check approval of payout for cycle %d: approvals are kept in the store", p.cycle)
+	}
+
+	payload := approval.Payload(p.cycle, p.config.Baker.Address, p.Wallet(), fingerprint(chunks))
+
+	held := Approval{}
+	if _, err := p.store.Get(approvalBucket(p.config.Baker.Address), approvalKey(p.cycle), &held); err != nil {
+		return errors.Wrapf(err, "failed to check approval of payout for cycle %d", p.cycle)
+	}
+
+	if held.Payload == payload && len(p.config.Approval.Keys) == 0 && held.Approver != "" {
+		logrus.WithFields(logrus.Fields{"cycle": p.cycle, "approver": held.Approver}).Info("Payout approved.")
+		return nil
+	} else if held.Payload == payload && held.Token != "" {
+		// An approver removed from the configuration no longer approves payouts
+		if approver, err := approval.Verify(p.config.Approval.Keys, payload, held.Token); err == nil {
+			logrus.WithFields(logrus.Fields{"cycle": p.cycle, "approver": approver}).Info("Payout approved.")
+			return nil
+		}
+	}
+
+	held = Approval{
+		Cycle:   p.cycle,
+		Baker:   p.config.Baker.Address,
+		Wallet:  p.Wallet(),
+		Payload: payload,
+		Forged:  time.Now().UTC(),
+	}
+	for _, chunk := range chunks {
+		for _, transfer := range chunk {
+			held.Transfers++
+			held.Amount += transfer.Amount
+		}
+	}
+
+	if err := p.store.Put(approvalBucket(p.config.Baker.Address), approvalKey(p.cycle), held); err != nil {
+		return errors.Wrapf(err, "failed to hold payout for cycle %d", p.cycle)
+	}
+
+	if len(p.config.Approval.Keys) == 0 {
+		return errors.Wrapf(errAwaitingApproval, "payout for cycle %d of %d transfers of %d mutez is held until approved with 'tzpay approve --cycle %d'",
+			p.cycle, held.Transfers, held.Amount, p.cycle)
+	}
+
+	return errors.Wrapf(errAwaitingApproval, "payout for cycle %d of %d transfers of %d mutez is held until approved with 'tzpay approve %d' (payload %s)",
+		p.cycle, held.Transfers, held.Amount, p.cycle, payload)
+}
+
+// HeldApproval returns the payout of baker held for cycle, and false if none is
+func HeldApproval(s store.IFace, baker string, cycle int) (Approval, bool, error) {
+	held := Approval{}
+	found, err := s.Get(approvalBucket(baker), approvalKey(cycle), &held)
+	if err != nil {
+		return held, false, errors.Wrapf(err, "failed to get payout held for cycle %d", cycle)
+	}
+
+	return held, found, nil
+}
+
+/*
+Approve approves the payout of the baker of cfg held for cycle with token, the signature of its payload by one of the
+approvers. The payout is injected by the next run for the cycle, as long as its transfers are unchanged.
+*/
+func Approve(s store.IFace, cfg config.Config, cycle int, token string) (Approval, error) {
+	held, found, err := HeldApproval(s, cfg.Baker.Address, cycle)
+	if err != nil {
+		return held, err
+	} else if !found {
+		return held, errors.Wrapf(errNotHeld, "failed to approve payout for cycle %d of '%s'", cycle, cfg.Baker.Address)
+	}
+
+	approver, err := approval.Verify(cfg.Approval.Keys, held.Payload, token)
+	if err != nil {
+		return held, errors.Wrapf(err, "failed to approve payout for cycle %d", cycle)
+	}
+
+	held.Token, held.Approver = token, approver
+	if err := s.Put(approvalBucket(cfg.Baker.Address), approvalKey(cycle), held); err != nil {
+		return held, errors.Wrapf(err, "failed to approve payout for cycle %d", cycle)
+	}
+
+	return held, nil
+}
+
+/*
+ApproveHeld approves the payout of the baker of cfg held for cycle on behalf of approver, e.g. the operator approving it
+through the control API, when payouts are held without approval keys. The payout is injected by the next run for the
+cycle, as long as its transfers are unchanged.
+*/
+func ApproveHeld(s store.IFace, cfg config.Config, cycle int, approver string) (Approval, error) {
+	if len(cfg.Approval.Keys) > 0 {
+		return Approval{}, errors.Errorf("failed to approve payout for cycle %d: payouts need the signature of one of the approvers, approve it with 'tzpay approve %d --token'", cycle, cycle)
+	}
+
+	held, found, err := HeldApproval(s, cfg.Baker.Address, cycle)
+	if err != nil {
+		return held, err
+	} else if !found {
+		return held, errors.Wrapf(errNotHeld, "failed to approve payout for cycle %d of '%s'", cycle, cfg.Baker.Address)
+	}
+
+	held.Approver = approver
+	if err := s.Put(approvalBucket(cfg.Baker.Address), approvalKey(cycle), held); err != nil {
+		return held, errors.Wrapf(err, "failed to approve payout for cycle %d", cycle)
+	}
+
+	return held, nil
+}
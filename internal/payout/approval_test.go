@@ -0,0 +1,125 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/approval"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkApproval(t *testing.T) {
+	esk := "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2"
+	key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Esk: esk, Password: "password12345##"})
+	assert.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "tzpay-approval")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	cfg := config.Config{
+		Baker:    config.Baker{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"},
+		Approval: config.Approval{Keys: []string{key.PubKey.GetPublicKey()}},
+	}
+	p := &Payout{config: cfg, store: s, cycle: 300, signer: watchOnly("tz1wallet")}
+
+	chunks := [][]disperseTransfer{
+		{{Destination: "tz1a", Amount: 1000000}, {Destination: "tz1b", Amount: 250000}},
+		{{Destination: "tz1c", Amount: 100}},
+	}
+
+	_, err = Approve(s, cfg, 300, "edsig")
+	test.CheckErr(t, true, "failed to approve payout for cycle 300 of 'tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc': no payout is held for the cycle", err)
+	assert.True(t, NotHeld(err))
+
+	err = p.checkApproval(chunks)
+	assert.True(t, AwaitingApproval(err))
+	test.CheckErr(t, true, "payout for cycle 300 of 3 transfers of 1250100 mutez is held until approved with 'tzpay approve 300'", err)
+
+	held, found, err := HeldApproval(s, cfg.Baker.Address, 300)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "tz1wallet", held.Wallet)
+	assert.Equal(t, 3, held.Transfers)
+	assert.Equal(t, int64(1250100), held.Amount)
+	assert.Equal(t, "", held.Token)
+
+	// A token signed by someone else than the approvers is refused
+	other := cfg
+	other.Approval.Keys = []string{"edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP"}
+	token, err := approval.Sign(esk, "password12345##", held.Payload)
+	assert.Nil(t, err)
+	_, err = Approve(s, other, 300, token)
+	test.CheckErr(t, true, "not signed by any of the approvers", err)
+	assert.True(t, AwaitingApproval(p.checkApproval(chunks)))
+
+	approved, err := Approve(s, cfg, 300, token)
+	assert.Nil(t, err)
+	assert.Equal(t, key.PubKey.GetPublicKey(), approved.Approver)
+	assert.Nil(t, p.checkApproval(chunks))
+
+	// The approval is revoked with the approver
+	revoked := &Payout{config: other, store: s, cycle: 300, signer: watchOnly("tz1wallet")}
+	assert.True(t, AwaitingApproval(revoked.checkApproval(chunks)))
+
+	// A payout whose transfers changed needs another approval
+	_, err = Approve(s, cfg, 300, token)
+	assert.Nil(t, err)
+	chunks[1][0].Amount = 200
+	assert.True(t, AwaitingApproval(p.checkApproval(chunks)))
+	held, _, err = HeldApproval(s, cfg.Baker.Address, 300)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1250200), held.Amount)
+	assert.Equal(t, "", held.Token)
+}
+
+func Test_checkApproval_manual(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-approval")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	cfg := config.Config{Baker: config.Baker{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"}}
+	p := &Payout{config: cfg, store: s, cycle: 300, signer: watchOnly("tz1wallet")}
+	assert.False(t, p.usesApproval())
+	p.RequireApproval()
+	assert.True(t, p.usesApproval())
+
+	chunks := [][]disperseTransfer{{{Destination: "tz1a", Amount: 1000000}}}
+
+	_, err = ApproveHeld(s, cfg, 300, "operator")
+	assert.True(t, NotHeld(err))
+
+	err = p.checkApproval(chunks)
+	assert.True(t, AwaitingApproval(err))
+	test.CheckErr(t, true, "payout for cycle 300 of 1 transfers of 1000000 mutez is held until approved with 'tzpay approve --cycle 300'", err)
+
+	// Under dual control, the operator cannot approve without the signature of an approver
+	dual := cfg
+	dual.Approval.Keys = []string{"edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP"}
+	_, err = ApproveHeld(s, dual, 300, "operator")
+	test.CheckErr(t, true, "payouts need the signature of one of the approvers", err)
+
+	approved, err := ApproveHeld(s, cfg, 300, "operator")
+	assert.Nil(t, err)
+	assert.Equal(t, "operator", approved.Approver)
+	assert.Nil(t, p.checkApproval(chunks))
+
+	// A payout whose transfers changed needs another approval
+	chunks[0][0].Amount = 2000000
+	assert.True(t, AwaitingApproval(p.checkApproval(chunks)))
+	held, _, err := HeldApproval(s, cfg.Baker.Address, 300)
+	assert.Nil(t, err)
+	assert.Equal(t, "", held.Approver)
+}
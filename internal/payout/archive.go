@@ -0,0 +1,108 @@
+package payout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+Archive is the full computed payout of a cycle, every delegation included, whether it was paid or rejected as
+blacklisted, below the minimum payment or carried forward. Forged holds the bytes of every operation forged to
+inject it, and Error why it failed if it did.
+*/
+type Archive struct {
+	Baker   string            `json:"baker"`
+	Cycle   int               `json:"cycle"`
+	Time    time.Time         `json:"time"`
+	Wallet  string            `json:"wallet"`
+	Partial bool              `json:"partial,omitempty"`
+	Payout  tzkt.RewardsSplit `json:"payout"`
+	Forged  []string          `json:"forged,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// archivePath returns the file the payouts of cycle are archived to, one file per baker and cycle
+func archivePath(dir, baker string, cycle int) string {
+	return filepath.Join(dir, baker, fmt.Sprintf("%08d.json", cycle))
+}
+
+// keepForged remembers the bytes of an operation forged by the payout, for its archive
+func (p *Payout) keepForged(op string) {
+	if p.config.Archive.Dir != "" {
+		p.forged = append(p.forged, op)
+	}
+}
+
+/*
+archive adds the payout computed for the cycle to the archive of the cycle, after any payout of the cycle already
+archived, e.g. a partial payout or one that failed and was run again. Failing to archive is only logged, the payout
+being made.
+*/
+func (p *Payout) archive(payout tzkt.RewardsSplit, err error) {
+	archive := Archive{
+		Baker:   p.config.Baker.Address,
+		Cycle:   p.cycle,
+		Time:    now().UTC(),
+		Wallet:  p.Wallet(),
+		Partial: p.partial,
+		Payout:  payout,
+		Forged:  p.forged,
+	}
+	if err != nil {
+		archive.Error = err.Error()
+	}
+
+	if err := writeArchive(p.config.Archive.Dir, archive); err != nil {
+		logrus.WithFields(logrus.Fields{"cycle": p.cycle, "error": err.Error()}).Error("Failed to archive payout.")
+	}
+}
+
+func writeArchive(dir string, archive Archive) error {
+	archives, err := ReadArchive(dir, archive.Baker, archive.Cycle)
+	if err != nil {
+		return err
+	}
+
+	js, err := json.MarshalIndent(append(archives, archive), "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive payout for cycle %d", archive.Cycle)
+	}
+
+	path := archivePath(dir, archive.Baker, archive.Cycle)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrapf(err, "failed to archive payout for cycle %d", archive.Cycle)
+	}
+	if err := ioutil.WriteFile(path+".tmp", js, 0600); err != nil {
+		return errors.Wrapf(err, "failed to archive payout for cycle %d", archive.Cycle)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return errors.Wrapf(err, "failed to archive payout for cycle %d", archive.Cycle)
+	}
+
+	return nil
+}
+
+// ReadArchive returns the payouts of baker archived in dir for cycle, oldest first, or none if the cycle was not archived
+func ReadArchive(dir, baker string, cycle int) ([]Archive, error) {
+	byts, err := ioutil.ReadFile(archivePath(dir, baker, cycle))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read archive of cycle %d", cycle)
+	}
+
+	var archives []Archive
+	if err := json.Unmarshal(byts, &archives); err != nil {
+		return nil, errors.Wrapf(err, "failed to read archive of cycle %d", cycle)
+	}
+
+	return archives, nil
+}
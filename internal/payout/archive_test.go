@@ -0,0 +1,50 @@
+package payout
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_archive(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	dir, err := ioutil.TempDir("", "tzpay-archive")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	payout := tzkt.RewardsSplit{
+		Delegators: tzkt.Delegators{
+			{Address: "tz1a", NetRewards: 1000000},
+			{Address: "tz1b", NetRewards: 100, BlackListed: true},
+		},
+	}
+
+	p := &Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}, Archive: config.Archive{Dir: dir}},
+		cycle:  300,
+	}
+	p.keepForged("a75f1e")
+	p.archive(payout, errors.New("failed to inject operation"))
+	p.forged = nil
+	p.keepForged("b6c2d4")
+	p.archive(payout, nil)
+
+	archives, err := ReadArchive(dir, "tz1baker", 300)
+	assert.Nil(t, err)
+	assert.Equal(t, []Archive{
+		{Baker: "tz1baker", Cycle: 300, Time: now(), Payout: payout, Forged: []string{"a75f1e"}, Error: "failed to inject operation"},
+		{Baker: "tz1baker", Cycle: 300, Time: now(), Payout: payout, Forged: []string{"b6c2d4"}},
+	}, archives)
+
+	archives, err = ReadArchive(dir, "tz1baker", 301)
+	assert.Nil(t, err)
+	assert.Nil(t, archives)
+}
@@ -0,0 +1,214 @@
+package payout
+
+import (
+	"strings"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/signer"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/goat-systems/tzpay/v3/internal/wallet"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+CheckWallet imports the key of a baker's payout wallet and logs the address derived from it, so that the operator sees
+which wallet pays before anything is injected. The key is refused if it does not match the address pinned in
+TZPAY_WALLET_ADDRESS, e.g. after a mix-up between keystores.
+*/
+func CheckWallet(cfg config.Config) (string, error) {
+	p := &Payout{config: cfg}
+	if err := p.loadWallet(); err != nil {
+		return "", err
+	}
+	p.logWallet()
+
+	return p.Wallet(), p.checkWallet()
+}
+
+// WalletAddress returns the address of a baker's payout wallet, derived from its key or given by the signer holding it
+func WalletAddress(cfg config.Config) (string, error) {
+	p := &Payout{config: cfg}
+	if err := p.loadWallet(); err != nil {
+		return "", err
+	}
+
+	return p.Wallet(), nil
+}
+
+// loadWallet imports the Ed25519 or P-256 key of the payout wallet, or connects to the remote signer, AWS KMS key, PKCS#11 token or external signing holding it
+func (p *Payout) loadWallet() error {
+	var signers int
+	for _, setting := range []string{p.config.Key.Signer, p.config.Key.KMSKeyID, p.config.Key.PKCS11Module, p.config.Key.ExternalDir + p.config.Key.ExternalURL} {
+		if setting != "" {
+			signers++
+		}
+	}
+	if signers > 1 {
+		return errors.New("failed to load payout wallet: set only one of TZPAY_WALLET_SIGNER, TZPAY_WALLET_KMS_KEY_ID, TZPAY_WALLET_PKCS11_MODULE and TZPAY_WALLET_EXTERNAL_DIR or _URL")
+	}
+
+	if p.config.Key.ExternalDir != "" || p.config.Key.ExternalURL != "" {
+		external, err := signer.NewExternal(signer.ExternalInput{
+			Address: p.config.Key.Address,
+			Dir:     p.config.Key.ExternalDir,
+			URL:     p.config.Key.ExternalURL,
+			Headers: p.config.Key.SignerHeaders,
+			Wait:    p.config.Key.ExternalWait,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize external signer")
+		}
+		p.signer = external
+
+		return nil
+	}
+
+	if p.config.Key.PKCS11Module != "" {
+		token, err := signer.NewPKCS11(signer.PKCS11Input{
+			Module: p.config.Key.PKCS11Module,
+			Slot:   p.config.Key.PKCS11Slot,
+			PIN:    p.config.Key.PKCS11PIN,
+			Label:  p.config.Key.PKCS11Label,
+			Tool:   p.config.Key.PKCS11Tool,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize PKCS#11 signer")
+		}
+		p.signer = token
+
+		return nil
+	}
+
+	if p.config.Key.KMSKeyID != "" {
+		kms, err := signer.NewKMS(signer.KMSInput{
+			KeyID:    p.config.Key.KMSKeyID,
+			Region:   p.config.Key.KMSRegion,
+			Endpoint: p.config.Key.KMSEndpoint,
+			Timeout:  p.config.Key.SignerTimeout,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize KMS signer")
+		}
+		p.signer = kms
+
+		return nil
+	}
+
+	if p.config.Key.Signer != "" {
+		remote, err := signer.NewRemote(signer.RemoteInput{
+			URL:     p.config.Key.Signer,
+			Address: p.config.Key.Address,
+			Headers: p.config.Key.SignerHeaders,
+			Timeout: p.config.Key.SignerTimeout,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize remote signer")
+		}
+		p.signer = remote
+
+		return nil
+	}
+
+	// go-tezos only signs with Ed25519 keys, so a P-256 key is signed with by tzpay itself
+	if strings.HasPrefix(p.config.Key.Esk, "p2esk") {
+		_, secret, err := wallet.Decrypt(p.config.Key.Esk, p.config.Key.Password)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize import key")
+		}
+
+		key, err := signer.NewP256(secret)
+		if err != nil {
+			return errors.Wrap(err, "failed to initialize import key")
+		}
+		p.signer = key
+
+		return nil
+	}
+
+	var err error
+	p.key, err = keys.NewKey(keys.NewKeyInput{
+		Kind:     keys.Ed25519,
+		Esk:      p.config.Key.Esk,
+		Password: p.config.Key.Password,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize import key")
+	}
+
+	return nil
+}
+
+// sign signs with the remote signer of the payout wallet if there is one, or with the key imported
+func (p *Payout) sign(input keys.SignInput) (keys.Signature, error) {
+	if p.signer != nil {
+		return p.signer.Sign(input)
+	}
+
+	return p.key.Sign(input)
+}
+
+func (p *Payout) logWallet() {
+	logrus.WithFields(logrus.Fields{
+		"baker":  p.config.Baker.Address,
+		"wallet": p.Wallet(),
+		"pinned": p.config.Key.Address != "",
+	}).Info("Loaded payout wallet.")
+}
+
+// checkWallet refuses a payout wallet that is not the one configured, or that is the baker's own key
+func (p *Payout) checkWallet() error {
+	if p.config.Key.Address == "" {
+		return nil
+	}
+
+	if wallet := p.Wallet(); wallet != p.config.Key.Address {
+		return errors.Errorf("failed to check payout wallet: key of '%s' does not match the payout wallet '%s'", wallet, p.config.Key.Address)
+	}
+
+	if p.config.Key.Address == p.config.Baker.Address {
+		return errors.Errorf("failed to check payout wallet: payout wallet '%s' is the baker's own key", p.config.Key.Address)
+	}
+
+	return nil
+}
+
+/*
+checkBalance refuses a payout the payout wallet can not cover, before anything is injected. The cost of a payout
+is the amount of every transfer left to make plus the network fee of each, burn fees of emptied accounts and fee
+bumps aside.
+*/
+func (p *Payout) checkBalance(delegators tzkt.Delegators) error {
+	transfers, cost, err := p.cost(delegators)
+	if err != nil {
+		return errors.Wrap(err, "failed to check balance of payout wallet")
+	}
+
+	balance, err := p.rpc.Balance(rpc.BalanceInput{
+		Blockhash: "head",
+		Address:   p.Wallet(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to check balance of payout wallet")
+	}
+
+	if int64(balance) < cost {
+		return errors.Errorf("refusing to inject payout for cycle %d: payout wallet '%s' holds %d mutez but %d transfers cost %d mutez",
+			p.cycle, p.Wallet(), balance, transfers, cost)
+	}
+
+	return nil
+}
+
+// cost returns the number of transfers left to make to delegators and what they cost, their network fees included
+func (p *Payout) cost(delegators tzkt.Delegators) (int, int64, error) {
+	var cost int64
+	transfers := p.transfers(delegators)
+	for _, transfer := range transfers {
+		cost += transfer.Amount + int64(p.config.Operations.NetworkFee)
+	}
+
+	return len(transfers), cost, nil
+}
@@ -0,0 +1,204 @@
+package payout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/goat-systems/tzpay/v3/internal/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkWallet(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+	wallet := key.PubKey.GetPublicKeyHash()
+
+	cases := []struct {
+		name     string
+		baker    string
+		address  string
+		err      bool
+		contains string
+	}{
+		{"is successful without payout wallet", wallet, "", false, ""},
+		{"is successful", "tz1baker", wallet, false, ""},
+		{"handles key of another wallet", "tz1baker", "tz1other", true, "does not match the payout wallet 'tz1other'"},
+		{"handles baker key", wallet, wallet, true, "is the baker's own key"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Baker: config.Baker{Address: tt.baker},
+					Key:   config.Key{Address: tt.address},
+				},
+				key: key,
+			}
+
+			test.CheckErr(t, tt.err, tt.contains, payout.checkWallet())
+		})
+	}
+}
+
+func Test_CheckWallet(t *testing.T) {
+	esk := "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2"
+
+	wallet, err := CheckWallet(config.Config{
+		Baker: config.Baker{Address: "tz1baker"},
+		Key:   config.Key{Esk: esk, Password: "password12345##"},
+	})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(wallet, "tz1"))
+
+	_, err = CheckWallet(config.Config{
+		Baker: config.Baker{Address: "tz1baker"},
+		Key:   config.Key{Esk: esk, Password: "password12345##", Address: wallet},
+	})
+	assert.Nil(t, err)
+
+	_, err = CheckWallet(config.Config{
+		Baker: config.Baker{Address: "tz1baker"},
+		Key:   config.Key{Esk: esk, Password: "password12345##", Address: "tz1other"},
+	})
+	test.CheckErr(t, true, "does not match the payout wallet 'tz1other'", err)
+
+	_, err = CheckWallet(config.Config{Key: config.Key{Esk: esk, Password: "wrong"}})
+	test.CheckErr(t, true, "failed to initialize import key", err)
+
+	_, err = CheckWallet(config.Config{Key: config.Key{Signer: "http://signer:6732", KMSKeyID: "alias/tzpay"}})
+	test.CheckErr(t, true, "set only one of TZPAY_WALLET_SIGNER, TZPAY_WALLET_KMS_KEY_ID, TZPAY_WALLET_PKCS11_MODULE and TZPAY_WALLET_EXTERNAL_DIR or _URL", err)
+
+	_, err = CheckWallet(config.Config{Key: config.Key{KMSKeyID: "alias/tzpay", PKCS11Module: "/usr/lib/softhsm/libsofthsm2.so"}})
+	test.CheckErr(t, true, "set only one of TZPAY_WALLET_SIGNER, TZPAY_WALLET_KMS_KEY_ID, TZPAY_WALLET_PKCS11_MODULE and TZPAY_WALLET_EXTERNAL_DIR or _URL", err)
+
+	_, err = CheckWallet(config.Config{Key: config.Key{Signer: "http://signer:6732", ExternalURL: "http://custody/requests"}})
+	test.CheckErr(t, true, "set only one of TZPAY_WALLET_SIGNER, TZPAY_WALLET_KMS_KEY_ID, TZPAY_WALLET_PKCS11_MODULE and TZPAY_WALLET_EXTERNAL_DIR or _URL", err)
+
+	_, err = CheckWallet(config.Config{Key: config.Key{Address: "tz1wallet", ExternalDir: "requests", ExternalURL: "http://custody/requests"}})
+	test.CheckErr(t, true, "failed to initialize external signer", err)
+}
+
+func Test_CheckWallet_p256(t *testing.T) {
+	w, err := wallet.Generate(wallet.P256, "password12345##")
+	assert.Nil(t, err)
+
+	address, err := CheckWallet(config.Config{
+		Baker: config.Baker{Address: "tz1baker"},
+		Key:   config.Key{Esk: w.Esk, Password: "password12345##", Address: w.Address},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, w.Address, address)
+
+	_, err = CheckWallet(config.Config{Key: config.Key{Esk: w.Esk, Password: "wrong"}})
+	test.CheckErr(t, true, "invalid password", err)
+}
+
+type signerMock struct {
+	address string
+	key     keys.Key
+}
+
+func (s signerMock) Address() string {
+	return s.address
+}
+
+func (s signerMock) Sign(input keys.SignInput) (keys.Signature, error) {
+	return s.key.Sign(input)
+}
+
+func Test_sign_remote(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+	wallet := key.PubKey.GetPublicKeyHash()
+
+	payout := Payout{
+		config: config.Config{
+			Baker: config.Baker{Address: "tz1baker"},
+			Key:   config.Key{Address: wallet, Signer: "http://signer:6732"},
+		},
+		signer: signerMock{address: wallet, key: key},
+	}
+	assert.Equal(t, wallet, payout.Wallet())
+	assert.Nil(t, payout.checkWallet())
+
+	want, err := key.Sign(keys.SignInput{Message: "03a79ec80dba1f8ddb2cde90b8a7be5b32b3fcdf7ad8e4d0a4f6f3e0a7c6f0e1d2"})
+	assert.Nil(t, err)
+	signature, err := payout.sign(keys.SignInput{Message: "03a79ec80dba1f8ddb2cde90b8a7be5b32b3fcdf7ad8e4d0a4f6f3e0a7c6f0e1d2"})
+	assert.Nil(t, err)
+	assert.Equal(t, want.Bytes, signature.Bytes)
+}
+
+func Test_checkBalance(t *testing.T) {
+	cases := []struct {
+		name       string
+		rpc        test.RPCMock
+		delegators tzkt.Delegators
+		err        bool
+		contains   string
+	}{
+		{
+			"is successful",
+			test.RPCMock{},
+			tzkt.Delegators{
+				{Address: "tz1a", NetRewards: 2000000},
+				{Address: "tz1b", NetRewards: 2990000},
+			},
+			false,
+			"",
+		},
+		{
+			"ignores skipped delegators",
+			test.RPCMock{},
+			tzkt.Delegators{
+				{Address: "tz1a", NetRewards: 2000000},
+				{Address: "tz1b", NetRewards: 9000000, BlackListed: true},
+			},
+			false,
+			"",
+		},
+		{
+			"handles insufficient balance",
+			test.RPCMock{},
+			tzkt.Delegators{
+				{Address: "tz1a", NetRewards: 2000000},
+				{Address: "tz1b", NetRewards: 3000000},
+			},
+			true,
+			"holds 5000000 mutez but 2 transfers cost 5006000 mutez",
+		},
+		{
+			"handles failure to get balance",
+			test.RPCMock{BalanceErr: true},
+			tzkt.Delegators{{Address: "tz1a", NetRewards: 2000000}},
+			true,
+			"failed to check balance of payout wallet",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Operations: config.Operations{NetworkFee: 3000},
+				},
+				rpc:   &tt.rpc,
+				cycle: 270,
+			}
+
+			test.CheckErr(t, tt.err, tt.contains, payout.checkBalance(tt.delegators))
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package payout
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+const (
+	benchmarkBlockhash   = "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"
+	benchmarkBalance     = 1000000000 // mutez delegated by every synthetic delegator
+	benchmarkBakerRatio  = 10         // the baker's own balance relative to a delegator's
+	benchmarkRewardsRate = 100        // rewards are a hundredth of the staking balance
+)
+
+/*
+Performance budgets of tzpay bench, per delegator. A single server core computes a delegator in about 3µs and forges
+its transaction in about 70µs, budgets leave several times that so that only real regressions exceed them.
+*/
+const (
+	ComputeBudget = 20 * time.Microsecond
+	ForgeBudget   = 500 * time.Microsecond
+)
+
+// BenchmarkSizes are the synthetic delegator set sizes measured by default
+var BenchmarkSizes = []int{1000, 10000, 100000}
+
+// benchmarkConstants are the protocol limits operations are split by while benchmarking (mainnet values)
+var benchmarkConstants = rpc.Constants{
+	MaxOperationDataLength: 32768,
+	HardGasLimitPerBlock:   10400000,
+}
+
+// BenchmarkResult holds the time taken to compute and forge a payout for a synthetic delegator set
+type BenchmarkResult struct {
+	Delegators int
+	Compute    time.Duration
+	Forge      time.Duration
+	Operations int
+}
+
+// WithinBudget returns true if computing and forging both stayed within their performance budgets
+func (b BenchmarkResult) WithinBudget() bool {
+	return b.Compute <= ComputeBudget*time.Duration(b.Delegators) && b.Forge <= ForgeBudget*time.Duration(b.Delegators)
+}
+
+// benchmarkRPC answers the node queries made while computing and forging a payout, so that benchmarks run offline
+type benchmarkRPC struct {
+	rpc.IFace
+	bakerBalance int
+}
+
+func (b benchmarkRPC) Balance(input rpc.BalanceInput) (int, error) {
+	return b.bakerBalance, nil
+}
+
+func (b benchmarkRPC) Counter(blockhash, pkh string) (int, error) {
+	return 0, nil
+}
+
+func (b benchmarkRPC) Constants(blockhash string) (rpc.Constants, error) {
+	return rpc.Constants{BlocksPerCycle: 4096}, nil
+}
+
+// Head returns a head without protocol, so that the synthetic rewards split is computed as Emmy
+func (b benchmarkRPC) Head() (*rpc.Block, error) {
+	return &rpc.Block{Hash: benchmarkBlockhash}, nil
+}
+
+// Block returns a block without protocol, so that the synthetic rewards split is computed as Emmy
+func (b benchmarkRPC) Block(id interface{}) (*rpc.Block, error) {
+	return &rpc.Block{}, nil
+}
+
+// benchmarkTzkt serves a synthetic rewards split, so that benchmarks run offline
+type benchmarkTzkt struct {
+	tzkt.IFace
+	rewardsSplit tzkt.RewardsSplit
+}
+
+func (b benchmarkTzkt) GetRewardsSplit(delegate string, cycle int, options ...tzkt.URLParameters) (tzkt.RewardsSplit, error) {
+	return b.rewardsSplit, nil
+}
+
+func (b benchmarkTzkt) GetCycle(index int) (tzkt.Cycle, error) {
+	return tzkt.Cycle{Index: index, FirstLevel: index*4096 + 1, LastLevel: (index + 1) * 4096}, nil
+}
+
+/*
+Benchmark computes and forges the payout of a synthetic set of delegators, without contacting a node or an
+indexer, and returns the time taken by each step.
+*/
+func Benchmark(delegators int) (BenchmarkResult, error) {
+	p, err := newBenchmarkPayout(delegators)
+	if err != nil {
+		return BenchmarkResult{}, errors.Wrap(err, "failed to benchmark payout")
+	}
+
+	start := time.Now()
+	rewardsSplit, err := p.constructPayout()
+	if err != nil {
+		return BenchmarkResult{}, errors.Wrap(err, "failed to benchmark payout")
+	}
+	computed := time.Now()
+
+	operations, err := p.forgeBenchmark(rewardsSplit.Delegators)
+	if err != nil {
+		return BenchmarkResult{}, errors.Wrap(err, "failed to benchmark payout")
+	}
+
+	return BenchmarkResult{
+		Delegators: delegators,
+		Compute:    computed.Sub(start),
+		Forge:      time.Since(computed),
+		Operations: len(operations),
+	}, nil
+}
+
+// forgeBenchmark forges the transactions paying delegators the way apply does, without injecting them
+func (p *Payout) forgeBenchmark(delegators tzkt.Delegators) ([]string, error) {
+	head := &rpc.Block{Hash: benchmarkBlockhash}
+	transactionBatches, err := p.constructTransactionBatches(head.Hash, delegators)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []string
+	for _, batch := range transactionBatches {
+		split, err := p.operationsWithinLimits(head, batch, benchmarkConstants)
+		if err != nil {
+			return nil, err
+		}
+
+		forged, err := forgeOperations(head.Hash, split)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, forged...)
+	}
+
+	return operations, nil
+}
+
+func newBenchmarkPayout(delegators int) (*Payout, error) {
+	seed := sha256.Sum256([]byte("tzpay benchmark"))
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Bytes: seed[:],
+		Kind:  keys.Ed25519,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate benchmark key")
+	}
+
+	baker, err := syntheticAddress(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	rewardsSplit := tzkt.RewardsSplit{
+		StakingBalance: benchmarkBalance * (delegators + benchmarkBakerRatio),
+		NumDelegators:  delegators,
+	}
+	rewardsSplit.OwnBlockRewards = rewardsSplit.StakingBalance / benchmarkRewardsRate
+	for i := 0; i < delegators; i++ {
+		address, err := syntheticAddress(i)
+		if err != nil {
+			return nil, err
+		}
+		rewardsSplit.Delegators = append(rewardsSplit.Delegators, tzkt.Delegator{
+			Address: address,
+			Balance: benchmarkBalance,
+		})
+	}
+
+	return &Payout{
+		config: config.Config{
+			Baker: config.Baker{
+				Address:            baker,
+				Fee:                0.05,
+				BakerPaysBurnFees:  true,
+				DenunciationPolicy: config.DenunciationPolicyPay,
+				Rounding:           config.RoundingFloor,
+			},
+			Operations: config.Operations{
+				NetworkFee: 2941,
+				GasLimit:   26283,
+				BatchSize:  125,
+			},
+		},
+		rpc:  benchmarkRPC{bakerBalance: benchmarkBalance * benchmarkBakerRatio},
+		tzkt: benchmarkTzkt{rewardsSplit: rewardsSplit},
+		key:  key,
+	}, nil
+}
+
+// syntheticAddress returns a deterministic tz1 address for the i-th synthetic delegator
+func syntheticAddress(i int) (string, error) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(i))
+	hash := sha256.Sum256(b[:])
+
+	address, ok := encodeKeyHash(0, hash[:20])
+	if !ok {
+		return "", errors.New("failed to generate synthetic address")
+	}
+
+	return address, nil
+}
@@ -0,0 +1,58 @@
+package payout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Benchmark(t *testing.T) {
+	result, err := Benchmark(300)
+	assert.Nil(t, err)
+	assert.Equal(t, 300, result.Delegators)
+	assert.Equal(t, 3, result.Operations)
+	assert.True(t, result.Compute > 0)
+	assert.True(t, result.Forge > 0)
+}
+
+func Benchmark_constructPayout(b *testing.B) {
+	for _, size := range BenchmarkSizes {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			p, err := newBenchmarkPayout(size)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.constructPayout(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_forge(b *testing.B) {
+	for _, size := range BenchmarkSizes {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			p, err := newBenchmarkPayout(size)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			rewardsSplit, err := p.constructPayout()
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.forgeBenchmark(rewardsSplit.Delegators); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
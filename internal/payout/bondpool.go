@@ -0,0 +1,66 @@
+package payout
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+/*
+applyBondPool splits what the baker keeps of a cycle, its own rewards and the fees it collected less the donation,
+among the members of the bond pool according to their weights. Each member's rewards are rounded with the configured
+policy, and what rounding leaves over stays with the baker.
+*/
+func (p *Payout) applyBondPool(rewardsSplit *tzkt.RewardsSplit) {
+	if len(p.config.Baker.BondPool) == 0 {
+		return
+	}
+
+	var members tzkt.BondPool
+	total := new(big.Rat)
+	for _, member := range p.config.Baker.BondPool {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		weight, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || weight <= 0 {
+			continue
+		}
+
+		members = append(members, tzkt.BondPoolMember{Address: parts[0], Weight: weight})
+		total.Add(total, rat(weight))
+	}
+	if total.Sign() == 0 {
+		return
+	}
+
+	kept := big.NewRat(int64(rewardsSplit.BakerRewards+rewardsSplit.BakerCollectedFees-rewardsSplit.Donation-rewardsSplit.Campaigns.Bonuses()), 1)
+	if kept.Sign() <= 0 {
+		return
+	}
+
+	for i := range members {
+		share := new(big.Rat).Quo(rat(members[i].Weight), total)
+		members[i].Share, _ = share.Float64()
+		members[i].Rewards = p.round(share.Mul(share, kept))
+	}
+
+	rewardsSplit.BondPool = members
+}
+
+// withBondPool appends the members of the bond pool to the delegators paid, so that they are paid in the same batch
+func withBondPool(rewardsSplit tzkt.RewardsSplit, delegators tzkt.Delegators) tzkt.Delegators {
+	for _, member := range rewardsSplit.BondPool {
+		if member.Rewards > 0 {
+			delegators = append(delegators, tzkt.Delegator{
+				Address:    member.Address,
+				NetRewards: member.Rewards,
+			})
+		}
+	}
+
+	return delegators
+}
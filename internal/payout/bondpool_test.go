@@ -0,0 +1,58 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applyBondPool(t *testing.T) {
+	cases := []struct {
+		name  string
+		baker config.Baker
+		want  tzkt.BondPool
+	}{
+		{
+			"splits the baker's share by weight",
+			config.Baker{BondPool: []string{"tz1a:60", "tz1b:40"}},
+			tzkt.BondPool{
+				{Address: "tz1a", Weight: 60, Share: 0.6, Rewards: 1080000},
+				{Address: "tz1b", Weight: 40, Share: 0.4, Rewards: 720000},
+			},
+		},
+		{
+			"rounds with the configured policy",
+			config.Baker{BondPool: []string{"tz1a:1", "tz1b:1", "tz1c:1"}, Rounding: config.RoundingFloor},
+			tzkt.BondPool{
+				{Address: "tz1a", Weight: 1, Share: 1.0 / 3, Rewards: 600000},
+				{Address: "tz1b", Weight: 1, Share: 1.0 / 3, Rewards: 600000},
+				{Address: "tz1c", Weight: 1, Share: 1.0 / 3, Rewards: 600000},
+			},
+		},
+		{
+			"does nothing if disabled",
+			config.Baker{},
+			nil,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{config: config.Config{Baker: tt.baker}}
+			rewardsSplit := tzkt.RewardsSplit{BakerRewards: 1000000, BakerCollectedFees: 1000000, Donation: 200000}
+			payout.applyBondPool(&rewardsSplit)
+			assert.Equal(t, tt.want, rewardsSplit.BondPool)
+		})
+	}
+}
+
+func Test_withBondPool(t *testing.T) {
+	delegators := tzkt.Delegators{{Address: "tz1a", NetRewards: 100}}
+	assert.Equal(t, delegators, withBondPool(tzkt.RewardsSplit{}, delegators))
+	assert.Equal(t, tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 100},
+		{Address: "tz1member", NetRewards: 10},
+	}, withBondPool(tzkt.RewardsSplit{BondPool: tzkt.BondPool{{Address: "tz1member", Rewards: 10}, {Address: "tz1empty"}}}, delegators))
+}
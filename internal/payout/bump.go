@@ -0,0 +1,143 @@
+package payout
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+injectContents forges and injects operations in order, waiting for each to be included before the next, and records
+each included operation in progress, as pending before injecting it. If fee bumps are configured, operations not included in time are re-injected
+with a higher fee.
+*/
+func (p *Payout) injectContents(branch string, operations []rpc.Contents, progress *Progress) ([]string, error) {
+	forged, err := forgeOperations(branch, operations)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to forge operation")
+	}
+
+	var level int
+	if progress != nil {
+		head, err := p.rpc.Head()
+		if err != nil {
+			return []string{}, errors.Wrap(err, "failed to inject operation")
+		}
+		level = head.Header.Level
+		defer p.untrackPending()
+	}
+
+	ophashes := []string{}
+	for i, contents := range operations {
+		p.trackPending(progress, contentsTransfers([]rpc.Contents{contents})[0], contents, level)
+
+		var ophash string
+		if p.config.Operations.BumpAfterBlocks == 0 {
+			injected, err := p.injectOperations(forged[i : i+1])
+			if err != nil {
+				return append(ophashes, injected...), err
+			}
+			ophash = injected[0]
+		} else if ophash, err = p.injectWithFeeBumps(contents); err != nil {
+			return ophashes, err
+		}
+		ophashes = append(ophashes, ophash)
+
+		if err := p.saveProgress(progress, contentsTransfers([]rpc.Contents{contents})[0], ophash); err != nil {
+			return ophashes, err
+		}
+
+		if p.verbose && p.config.Operations.BumpAfterBlocks > 0 {
+			logrus.WithFields(logrus.Fields{
+				"hash":      ophash,
+				"operation": fmt.Sprintf("%d/%d", (i + 1), len(operations)),
+			}).Info("Injection confirmed.")
+		}
+	}
+
+	return ophashes, nil
+}
+
+/*
+injectWithFeeBumps injects contents and waits BumpAfterBlocks blocks for the operation to be included. Operations
+that are not are re-forged against the current head with each fee raised by BumpIncrement and re-injected, at
+most BumpAttempts times. Every version shares the same counters, so at most one of them can ever be included, and
+an earlier version included while waiting on a later one confirms the operation. A payout given a watcher leaves the
+waiting and bumping to it.
+*/
+func (p *Payout) injectWithFeeBumps(contents rpc.Contents) (string, error) {
+	if p.watcher != nil {
+		return p.watchInclusion(contents)
+	}
+
+	var ophashes []string
+	for attempt := 0; ; attempt++ {
+		head, err := p.rpc.Head()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to inject operation")
+		}
+
+		ophash, err := p.signAndInject(head.Hash, contents)
+		if err != nil && attempt == 0 {
+			return "", errors.Wrap(err, "failed to inject operation")
+		} else if err != nil {
+			logrus.WithFields(logrus.Fields{"error": err.Error(), "attempt": attempt}).Warn("Failed to inject operation with bumped fee, still waiting on previous injections.")
+		} else {
+			ophashes = append(ophashes, ophash)
+		}
+
+		if included, ok := p.waitForInclusion(ophashes, head.Header.Level+p.config.Operations.BumpAfterBlocks); ok {
+			return included, nil
+		}
+
+		if attempt == p.config.Operations.BumpAttempts {
+			return "", errors.Errorf("failed to inject operation: operation not included after %d fee bumps", attempt)
+		}
+
+		contents = bumpFees(contents, int64(p.config.Operations.BumpIncrement))
+		logrus.WithFields(logrus.Fields{
+			"hashes":  ophashes,
+			"attempt": attempt + 1,
+			"blocks":  p.config.Operations.BumpAfterBlocks,
+		}).Warn("Operation not included in time, re-injecting with a higher fee.")
+	}
+}
+
+// waitForInclusion returns the first of ophashes found in the head block, or false once the head passed level
+func (p *Payout) waitForInclusion(ophashes []string, level int) (string, bool) {
+	ticker := time.NewTicker(confirmationDurationInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		head, err := p.rpc.Head()
+		if err != nil {
+			continue
+		}
+
+		if included, err := p.rpc.OperationHashes(head.Hash); err == nil {
+			if ophash, ok := includes(included, ophashes); ok {
+				return ophash, true
+			}
+		}
+
+		if head.Header.Level >= level {
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// bumpFees returns a copy of contents with the fee of each content raised by increment
+func bumpFees(contents rpc.Contents, increment int64) rpc.Contents {
+	bumped := make(rpc.Contents, len(contents))
+	copy(bumped, contents)
+	for i := range bumped {
+		bumped[i].Fee += increment
+	}
+
+	return bumped
+}
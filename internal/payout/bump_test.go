@@ -0,0 +1,98 @@
+package payout
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// bumpRPCMock advances a block on every head request and includes the n-th injection once it was injected
+type bumpRPCMock struct {
+	test.RPCMock
+	level     int
+	injected  []string
+	includeAt int
+}
+
+func (b *bumpRPCMock) Head() (*rpc.Block, error) {
+	b.level++
+	block := &rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}
+	block.Header.Level = b.level
+	return block, nil
+}
+
+func (b *bumpRPCMock) InjectionOperation(input rpc.InjectionOperationInput) (string, error) {
+	b.injected = append(b.injected, input.Operation)
+	return fmt.Sprintf("oo%d", len(b.injected)), nil
+}
+
+func (b *bumpRPCMock) OperationHashes(blockhash string) ([][]string, error) {
+	if b.includeAt > 0 && len(b.injected) >= b.includeAt {
+		return [][]string{{fmt.Sprintf("oo%d", b.includeAt)}}, nil
+	}
+	return [][]string{}, nil
+}
+
+func Test_injectWithFeeBumps(t *testing.T) {
+	confirmationDurationInterval = time.Millisecond
+	defer func() { confirmationDurationInterval = time.Second * 1 }()
+
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	contents := rpc.Contents{
+		{Kind: rpc.TRANSACTION, Source: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000, Counter: 101, Fee: 2941, GasLimit: 26283},
+	}
+
+	cases := []struct {
+		name        string
+		includeAt   int
+		err         bool
+		errContains string
+		ophash      string
+		injections  int
+	}{
+		{"is successful without bump", 1, false, "", "oo1", 1},
+		{"is successful after bump", 2, false, "", "oo2", 2},
+		{"handles operation never included", 0, true, "operation not included after 2 fee bumps", "", 3},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &bumpRPCMock{includeAt: tt.includeAt}
+			payout := Payout{
+				config: config.Config{
+					Operations: config.Operations{BumpAfterBlocks: 3, BumpAttempts: 2, BumpIncrement: 1000},
+				},
+				rpc: mock,
+				key: key,
+			}
+
+			ophash, err := payout.injectWithFeeBumps(contents)
+			test.CheckErr(t, tt.err, tt.errContains, err)
+			assert.Equal(t, tt.ophash, ophash)
+			assert.Len(t, mock.injected, tt.injections)
+			if tt.injections > 1 {
+				assert.NotEqual(t, mock.injected[0], mock.injected[1])
+			}
+		})
+	}
+}
+
+func Test_bumpFees(t *testing.T) {
+	contents := rpc.Contents{{Fee: 2941}, {Fee: 1000}}
+	bumped := bumpFees(contents, 500)
+	assert.Equal(t, int64(3441), bumped[0].Fee)
+	assert.Equal(t, int64(1500), bumped[1].Fee)
+	assert.Equal(t, int64(2941), contents[0].Fee)
+}
@@ -0,0 +1,175 @@
+package payout
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// campaignChunk is how many delegators are looked up at once for the delegations that made them join the baker
+const campaignChunk = 100
+
+/*
+loadCampaigns finds the delegators of the payout who joined the baker within the window of a campaign, and keeps the
+campaign that still applies to each of them in the cycle paid. A delegator joined in the cycle of its first delegation
+to the baker, so that leaving and coming back during a campaign does not make a delegator new.
+*/
+func (p *Payout) loadCampaigns(delegators tzkt.Delegators) error {
+	p.campaigns = nil
+
+	campaigns, err := config.ParseCampaigns(p.config.Baker.Campaigns)
+	if err != nil {
+		return errors.Wrap(err, "failed to load campaigns")
+	}
+
+	var started []config.Campaign
+	for _, campaign := range campaigns {
+		if campaign.First <= p.cycle {
+			started = append(started, campaign)
+		}
+	}
+	if len(started) == 0 || len(delegators) == 0 {
+		return nil
+	}
+
+	windows := make([]campaignWindow, len(started))
+	for i, campaign := range started {
+		if windows[i], err = p.campaignWindow(campaign); err != nil {
+			return errors.Wrap(err, "failed to load campaigns")
+		}
+	}
+
+	joined := map[string]int{}
+	for i := 0; i < len(delegators); i += campaignChunk {
+		end := i + campaignChunk
+		if end > len(delegators) {
+			end = len(delegators)
+		}
+
+		var addresses []string
+		for _, delegator := range delegators[i:end] {
+			addresses = append(addresses, delegator.Address)
+		}
+
+		delegations, err := p.tzkt.GetDelegations(
+			tzkt.URLParameters{Key: "newDelegate", Value: p.config.Baker.Address},
+			tzkt.URLParameters{Key: "sender.in", Value: strings.Join(addresses, ",")},
+			tzkt.URLParameters{Key: "status", Value: "applied"},
+			tzkt.URLParameters{Key: "limit", Value: "10000"},
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to load campaigns")
+		}
+
+		for _, delegation := range delegations {
+			if level, ok := joined[delegation.Sender.Address]; !ok || delegation.Level < level {
+				joined[delegation.Sender.Address] = delegation.Level
+			}
+		}
+	}
+
+	p.campaigns = map[string]config.Campaign{}
+	for address, level := range joined {
+		for i, campaign := range started {
+			if level < windows[i].first || level > windows[i].last {
+				continue
+			}
+			if level >= windows[i].applies {
+				p.campaigns[address] = campaign
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+// campaignWindow holds the levels a delegator joined within for a campaign, and from which the campaign still applies
+type campaignWindow struct {
+	first, last, applies int
+}
+
+/*
+campaignWindow returns the levels of the window of campaign, as the indexer knows them. Delegators of the cycle paid
+joined before it ends, so the window is cut there. A campaign lasting Cycles still applies to delegators who joined
+from the cycle Cycles before the cycle paid.
+*/
+func (p *Payout) campaignWindow(campaign config.Campaign) (campaignWindow, error) {
+	var window campaignWindow
+	var err error
+	if window.first, _, err = CycleLevels(p.tzkt, campaign.First); err != nil {
+		return window, err
+	}
+
+	last := campaign.Last
+	if last > p.cycle {
+		last = p.cycle
+	}
+	if _, window.last, err = CycleLevels(p.tzkt, last); err != nil {
+		return window, err
+	}
+
+	window.applies = window.first
+	if campaign.Cycles > 0 && p.cycle-campaign.Cycles > campaign.First {
+		if window.applies, _, err = CycleLevels(p.tzkt, p.cycle-campaign.Cycles); err != nil {
+			return window, err
+		}
+	}
+
+	return window, nil
+}
+
+/*
+applyCampaign charges a delegator the fee of its campaign when it is lower than the fee it was charged, or adds the
+bonus of its campaign to its net rewards, paid by the baker. What the campaign costs the baker is kept on the delegator.
+*/
+func (p *Payout) applyCampaign(delegator *tzkt.Delegator) {
+	campaign, ok := p.campaigns[delegator.Address]
+	if !ok {
+		return
+	}
+
+	if campaign.Bonus > 0 {
+		bonus := new(big.Rat).SetInt64(int64(delegator.NetRewards))
+		bonus.Mul(bonus, new(big.Rat).Sub(rat(campaign.Bonus), big.NewRat(1, 1)))
+		delegator.CampaignBonus = p.round(bonus)
+		delegator.NetRewards += delegator.CampaignBonus
+	} else if fee := p.round(new(big.Rat).Mul(new(big.Rat).SetInt64(int64(delegator.GrossRewards)), rat(campaign.Fee))); fee < delegator.Fee {
+		delegator.CampaignRebate = delegator.Fee - fee
+		delegator.Fee = fee
+		delegator.NetRewards = delegator.GrossRewards - fee
+	}
+
+	if delegator.CampaignBonus > 0 || delegator.CampaignRebate > 0 {
+		delegator.Campaign = campaign.Spec
+	}
+}
+
+/*
+summarizeCampaigns reports what each campaign cost the baker in the payout: the fees rebated to the delegators of the
+campaign and the bonuses paid to them, leaving out the delegators who are not paid.
+*/
+func summarizeCampaigns(rewardsSplit *tzkt.RewardsSplit) {
+	rewardsSplit.Campaigns = nil
+
+	index := map[string]int{}
+	for _, delegator := range rewardsSplit.Delegators {
+		if delegator.Campaign == "" || delegator.BlackListed {
+			continue
+		}
+
+		i, ok := index[delegator.Campaign]
+		if !ok {
+			i = len(rewardsSplit.Campaigns)
+			index[delegator.Campaign] = i
+			rewardsSplit.Campaigns = append(rewardsSplit.Campaigns, tzkt.Campaign{Spec: delegator.Campaign})
+		}
+
+		rewardsSplit.Campaigns[i].Delegators++
+		rewardsSplit.Campaigns[i].Rebates += delegator.CampaignRebate
+		rewardsSplit.Campaigns[i].Bonuses += delegator.CampaignBonus
+	}
+}
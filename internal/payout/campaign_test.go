@@ -0,0 +1,260 @@
+package payout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type campaignTzktMock struct {
+	test.TzktMock
+	levels  map[string][]int
+	senders []string
+	err     bool
+}
+
+func (c *campaignTzktMock) GetDelegations(options ...tzkt.URLParameters) ([]tzkt.Delegation, error) {
+	if c.err {
+		return nil, errors.New("failed to get delegations")
+	}
+
+	var delegations []tzkt.Delegation
+	for _, option := range options {
+		if option.Key != "sender.in" {
+			continue
+		}
+
+		c.senders = append(c.senders, option.Value)
+		for _, sender := range strings.Split(option.Value, ",") {
+			for _, level := range c.levels[sender] {
+				var delegation tzkt.Delegation
+				delegation.Sender.Address = sender
+				delegation.Level = level
+				delegations = append(delegations, delegation)
+			}
+		}
+	}
+
+	return delegations, nil
+}
+
+func Test_loadCampaigns(t *testing.T) {
+	// TzktMock has 2 blocks per cycle: level 601 is the first block of cycle 300
+	delegators := tzkt.Delegators{{Address: "tz1new"}, {Address: "tz1old"}, {Address: "tz1late"}, {Address: "tz1bonus"}, {Address: "tz1contract"}}
+	levels := map[string][]int{
+		"tz1new":   {601},
+		"tz1old":   {641, 401},
+		"tz1late":  {643},
+		"tz1bonus": {625},
+	}
+	campaigns := []string{"300-310:0.02", "311-320:x1.1:5"}
+
+	cases := []struct {
+		name     string
+		cycle    int
+		tzkt     *campaignTzktMock
+		want     map[string]string
+		err      bool
+		contains string
+	}{
+		{
+			"keeps the campaign of new delegators",
+			315,
+			&campaignTzktMock{levels: levels},
+			map[string]string{"tz1new": "300-310:0.02", "tz1bonus": "311-320:x1.1:5"},
+			false,
+			"",
+		},
+		{
+			"ends campaign after its cycles",
+			318,
+			&campaignTzktMock{levels: levels},
+			map[string]string{"tz1new": "300-310:0.02"},
+			false,
+			"",
+		},
+		{
+			"skips campaigns not started",
+			299,
+			&campaignTzktMock{levels: levels},
+			nil,
+			false,
+			"",
+		},
+		{
+			"takes the window of campaigns from the levels of their cycles",
+			315,
+			&campaignTzktMock{TzktMock: test.TzktMock{Cycles: map[int]tzkt.Cycle{300: {Index: 300, FirstLevel: 597, LastLevel: 602}}}, levels: map[string][]int{"tz1new": {598}}},
+			map[string]string{"tz1new": "300-310:0.02"},
+			false,
+			"",
+		},
+		{
+			"handles failure to get levels of campaigns",
+			315,
+			&campaignTzktMock{TzktMock: test.TzktMock{CycleErr: true}, levels: levels},
+			nil,
+			true,
+			"failed to load campaigns: failed to get levels of cycle 300",
+		},
+		{
+			"handles failure to get delegations",
+			315,
+			&campaignTzktMock{err: true},
+			nil,
+			true,
+			"failed to load campaigns",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				rpc:    &test.RPCMock{},
+				tzkt:   tt.tzkt,
+				cycle:  tt.cycle,
+				config: config.Config{Baker: config.Baker{Address: "tz1baker", Campaigns: campaigns}},
+			}
+
+			err := payout.loadCampaigns(delegators)
+			test.CheckErr(t, tt.err, tt.contains, err)
+
+			var got map[string]string
+			for address, campaign := range payout.campaigns {
+				if got == nil {
+					got = map[string]string{}
+				}
+				got[address] = campaign.Spec
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_loadCampaigns_chunks(t *testing.T) {
+	var delegators tzkt.Delegators
+	for i := 0; i < campaignChunk+1; i++ {
+		delegators = append(delegators, tzkt.Delegator{Address: "tz1delegator"})
+	}
+
+	mock := &campaignTzktMock{}
+	payout := Payout{
+		rpc:    &test.RPCMock{},
+		tzkt:   mock,
+		cycle:  300,
+		config: config.Config{Baker: config.Baker{Address: "tz1baker", Campaigns: []string{"300-310:0.02"}}},
+	}
+
+	assert.Nil(t, payout.loadCampaigns(delegators))
+	assert.Len(t, mock.senders, 2)
+	assert.Equal(t, "tz1delegator", mock.senders[1])
+}
+
+func Test_applyCampaign(t *testing.T) {
+	cases := []struct {
+		name      string
+		campaign  config.Campaign
+		delegator tzkt.Delegator
+		want      tzkt.Delegator
+	}{
+		{
+			"rebates fee",
+			config.Campaign{Spec: "300-310:0.02", Fee: 0.02},
+			tzkt.Delegator{Address: "tz1a", GrossRewards: 1000000, Fee: 50000, NetRewards: 950000},
+			tzkt.Delegator{Address: "tz1a", GrossRewards: 1000000, Fee: 20000, NetRewards: 980000, Campaign: "300-310:0.02", CampaignRebate: 30000},
+		},
+		{
+			"keeps lower fee",
+			config.Campaign{Spec: "300-310:0.02", Fee: 0.02},
+			tzkt.Delegator{Address: "tz1a", GrossRewards: 1000000, Fee: 10000, NetRewards: 990000},
+			tzkt.Delegator{Address: "tz1a", GrossRewards: 1000000, Fee: 10000, NetRewards: 990000},
+		},
+		{
+			"pays bonus",
+			config.Campaign{Spec: "311-320:x1.1", Bonus: 1.1},
+			tzkt.Delegator{Address: "tz1a", GrossRewards: 1000000, Fee: 50000, NetRewards: 950000},
+			tzkt.Delegator{Address: "tz1a", GrossRewards: 1000000, Fee: 50000, NetRewards: 1045000, Campaign: "311-320:x1.1", CampaignBonus: 95000},
+		},
+		{
+			"skips delegator without campaign",
+			config.Campaign{Spec: "300-310:0.02", Fee: 0.02},
+			tzkt.Delegator{Address: "tz1b", GrossRewards: 1000000, Fee: 50000, NetRewards: 950000},
+			tzkt.Delegator{Address: "tz1b", GrossRewards: 1000000, Fee: 50000, NetRewards: 950000},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{campaigns: map[string]config.Campaign{"tz1a": tt.campaign}}
+
+			delegator := tt.delegator
+			payout.applyCampaign(&delegator)
+			assert.Equal(t, tt.want, delegator)
+		})
+	}
+}
+
+func Test_distributeRemainder_Campaigns(t *testing.T) {
+	cases := []struct {
+		name     string
+		campaign config.Campaign
+		total    int
+		want     tzkt.Delegator
+	}{
+		{
+			"pays the bonus of the mutez given",
+			config.Campaign{Spec: "300-310:x2", Bonus: 2},
+			100,
+			tzkt.Delegator{Address: "tz1a", Balance: 1, GrossRewards: 34, Fee: 3, NetRewards: 62, Campaign: "300-310:x2", CampaignBonus: 31},
+		},
+		{
+			"rebates the fee of the mutez taken",
+			config.Campaign{Spec: "300-310:0.05", Fee: 0.05},
+			3014,
+			tzkt.Delegator{Address: "tz1a", Balance: 1, GrossRewards: 1004, Fee: 50, NetRewards: 954, Campaign: "300-310:0.05", CampaignRebate: 50},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config:    config.Config{Baker: config.Baker{Fee: 0.1, Rounding: config.RoundingRound}},
+				campaigns: map[string]config.Campaign{"tz1a": tt.campaign},
+			}
+			delegators := tzkt.Delegators{{Address: "tz1a", Balance: 1}, {Address: "tz1b", Balance: 1}, {Address: "tz1c", Balance: 1}}
+			for i := range delegators {
+				delegators[i].GrossRewards = payout.round(exactRewards(delegators[i].Balance, 3, tt.total))
+				delegators[i].Fee = payout.fee(delegators[i].Address, delegators[i].GrossRewards)
+				delegators[i].NetRewards = delegators[i].GrossRewards - delegators[i].Fee
+				payout.applyCampaign(&delegators[i])
+			}
+
+			assert.Equal(t, 0, payout.distributeRemainder(delegators, tt.total, 3))
+			assert.Equal(t, tt.want, delegators[0])
+		})
+	}
+}
+
+func Test_summarizeCampaigns(t *testing.T) {
+	rewardsSplit := tzkt.RewardsSplit{
+		Delegators: tzkt.Delegators{
+			{Address: "tz1a", Campaign: "300-310:0.02", CampaignRebate: 30000},
+			{Address: "tz1b", Campaign: "311-320:x1.1", CampaignBonus: 95000},
+			{Address: "tz1c", Campaign: "300-310:0.02", CampaignRebate: 10000},
+			{Address: "tz1d", Campaign: "300-310:0.02", CampaignRebate: 500, BlackListed: true},
+			{Address: "tz1e"},
+		},
+	}
+
+	summarizeCampaigns(&rewardsSplit)
+	assert.Equal(t, tzkt.Campaigns{
+		{Spec: "300-310:0.02", Delegators: 2, Rebates: 40000},
+		{Spec: "311-320:x1.1", Delegators: 1, Bonuses: 95000},
+	}, rewardsSplit.Campaigns)
+	assert.Equal(t, 95000, rewardsSplit.Campaigns.Bonuses())
+}
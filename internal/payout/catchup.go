@@ -0,0 +1,81 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+/*
+UnpaidCycles returns the cycles from first to last, oldest first, that baker has no payout recorded for in s, partial
+payouts aside, and that are neither waiting in the queue nor in the dead letters. Only the cycles after the first one
+recorded are returned, as tzpay cannot know whether the cycles before it were paid, e.g. by another payout tool.
+*/
+func UnpaidCycles(s store.IFace, baker string, first, last int) ([]int, error) {
+	records, err := Records(s, baker)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find unpaid cycles of '%s'", baker)
+	}
+
+	paid := map[int]bool{}
+	earliest := -1
+	for _, record := range records {
+		if record.Partial {
+			continue
+		}
+		paid[record.Cycle] = true
+		if earliest < 0 || record.Cycle < earliest {
+			earliest = record.Cycle
+		}
+	}
+	if earliest < 0 {
+		return nil, nil
+	}
+
+	pending, err := pendingCycles(s, baker)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find unpaid cycles of '%s'", baker)
+	}
+
+	if first <= earliest {
+		first = earliest + 1
+	}
+
+	var unpaid []int
+	for cycle := first; cycle <= last; cycle++ {
+		if !paid[cycle] && !pending[cycle] {
+			unpaid = append(unpaid, cycle)
+		}
+	}
+
+	return unpaid, nil
+}
+
+// pendingCycles returns the cycles of baker that a payout, not a partial one, waits for in the queue or the dead letters
+func pendingCycles(s store.IFace, baker string) (map[int]bool, error) {
+	pending := map[int]bool{}
+	keys, err := s.Keys(QueueBucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list payout queue")
+	}
+	for _, key := range keys {
+		var queued Queued
+		if _, err := s.Get(QueueBucket, key, &queued); err != nil {
+			return nil, errors.Wrap(err, "failed to list payout queue")
+		}
+		if queued.Baker == baker && !queued.Partial {
+			pending[queued.Cycle] = true
+		}
+	}
+
+	letters, err := DeadLetters(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, letter := range letters {
+		if letter.Baker == baker && !letter.Partial {
+			pending[letter.Cycle] = true
+		}
+	}
+
+	return pending, nil
+}
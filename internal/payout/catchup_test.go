@@ -0,0 +1,38 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_UnpaidCycles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-catchup")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	// Nothing recorded, e.g. a new install, catches nothing up
+	unpaid, err := UnpaidCycles(s, "tz1baker", 100, 105)
+	assert.Nil(t, err)
+	assert.Empty(t, unpaid)
+
+	payouts := store.NewPayouts(s)
+	assert.Nil(t, payouts.SavePayout("tz1baker", store.Payout{Cycle: 99}))
+	assert.Nil(t, payouts.SavePayout("tz1baker", store.Payout{Cycle: 101}))
+	assert.Nil(t, payouts.SavePayout("tz1baker", store.Payout{Cycle: 103, Partial: true}))
+	assert.Nil(t, payouts.SavePayout("tz1other", store.Payout{Cycle: 104}))
+	assert.Nil(t, s.Put(QueueBucket, "00000000000000000001", Queued{Baker: "tz1baker", Cycle: 102}))
+	assert.Nil(t, s.Put(QueueBucket, "00000000000000000002", Queued{Baker: "tz1baker", Cycle: 104, Partial: true}))
+	assert.Nil(t, s.Put(DeadLetterBucket, "00000000000000000003", DeadLetter{Queued: Queued{Baker: "tz1baker", Cycle: 105}}))
+
+	unpaid, err = UnpaidCycles(s, "tz1baker", 97, 106)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{100, 103, 104, 106}, unpaid)
+}
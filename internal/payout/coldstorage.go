@@ -0,0 +1,38 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+/*
+applySweep sets aside what the baker keeps of a cycle to be swept to cold storage: its own rewards and the fees it
+collected, less the donation, what the bond pool is paid, and the working float left in the payout wallet to pay
+network fees with.
+*/
+func (p *Payout) applySweep(rewardsSplit *tzkt.RewardsSplit) {
+	if p.config.Baker.SweepAddress == "" {
+		return
+	}
+
+	kept := rewardsSplit.BakerRewards + rewardsSplit.BakerCollectedFees - rewardsSplit.Donation - rewardsSplit.Campaigns.Bonuses()
+	for _, member := range rewardsSplit.BondPool {
+		kept -= member.Rewards
+	}
+
+	if sweep := kept - p.config.Baker.SweepFloat; sweep > 0 {
+		rewardsSplit.Sweep = sweep
+		rewardsSplit.SweepAddress = p.config.Baker.SweepAddress
+	}
+}
+
+// withSweep appends the sweep to the delegators paid, so that it is sent with the last batch
+func withSweep(rewardsSplit tzkt.RewardsSplit, delegators tzkt.Delegators) tzkt.Delegators {
+	if rewardsSplit.Sweep <= 0 {
+		return delegators
+	}
+
+	return append(delegators, tzkt.Delegator{
+		Address:    rewardsSplit.SweepAddress,
+		NetRewards: rewardsSplit.Sweep,
+	})
+}
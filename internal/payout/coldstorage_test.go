@@ -0,0 +1,67 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applySweep(t *testing.T) {
+	type want struct {
+		sweep   int
+		address string
+	}
+
+	cases := []struct {
+		name     string
+		baker    config.Baker
+		bondPool tzkt.BondPool
+		want     want
+	}{
+		{
+			"sweeps the baker's cut less the donation and the float",
+			config.Baker{SweepAddress: "tz1cold", SweepFloat: 100000},
+			nil,
+			want{1700000, "tz1cold"},
+		},
+		{
+			"leaves what the bond pool is paid",
+			config.Baker{SweepAddress: "tz1cold"},
+			tzkt.BondPool{{Address: "tz1member", Rewards: 900000}, {Address: "tz1other", Rewards: 600000}},
+			want{300000, "tz1cold"},
+		},
+		{
+			"sweeps nothing below the float",
+			config.Baker{SweepAddress: "tz1cold", SweepFloat: 2000000},
+			nil,
+			want{},
+		},
+		{
+			"does nothing if disabled",
+			config.Baker{SweepFloat: 100000},
+			nil,
+			want{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{config: config.Config{Baker: tt.baker}}
+			rewardsSplit := tzkt.RewardsSplit{BakerRewards: 1000000, BakerCollectedFees: 1000000, Donation: 200000, BondPool: tt.bondPool}
+			payout.applySweep(&rewardsSplit)
+			assert.Equal(t, tt.want.sweep, rewardsSplit.Sweep)
+			assert.Equal(t, tt.want.address, rewardsSplit.SweepAddress)
+		})
+	}
+}
+
+func Test_withSweep(t *testing.T) {
+	delegators := tzkt.Delegators{{Address: "tz1a", NetRewards: 100}}
+	assert.Equal(t, delegators, withSweep(tzkt.RewardsSplit{}, delegators))
+	assert.Equal(t, tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 100},
+		{Address: "tz1cold", NetRewards: 10},
+	}, withSweep(tzkt.RewardsSplit{Sweep: 10, SweepAddress: "tz1cold"}, delegators))
+}
@@ -0,0 +1,64 @@
+package payout
+
+import (
+	"sync"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// concurrency returns how many delegations of the payout are constructed at once, TZPAY_API_CONCURRENCY
+func (p *Payout) concurrency() int {
+	if p.config.API.Concurrency < 1 {
+		return 1
+	}
+
+	return p.config.API.Concurrency
+}
+
+/*
+constructDelegations constructs every delegation of delegations, up to concurrency() at once as each one may take
+calls to the node and the indexer, e.g. to check whether its account is empty. The delegations constructed are
+returned in the order of delegations, whatever the order they were done in, so that the payout is the same as one
+constructed a delegation at a time. No delegation is started once one has failed, and the error returned is that of
+the first delegation failing in the order of delegations.
+*/
+func (p *Payout) constructDelegations(delegations tzkt.Delegators, totalRewards, stakingBalance int) (tzkt.Delegators, error) {
+	constructed := make(tzkt.Delegators, len(delegations))
+	errs := make([]error, len(delegations))
+
+	jobs := make(chan int)
+	failed := make(chan struct{})
+	var fail sync.Once
+	var wg sync.WaitGroup
+	for w := 0; w < p.concurrency() && w < len(delegations); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				constructed[i], errs[i] = p.constructDelegation(delegations[i], totalRewards, stakingBalance)
+				if errs[i] != nil {
+					fail.Do(func() { close(failed) })
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range delegations {
+		select {
+		case jobs <- i:
+		case <-failed:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return constructed, nil
+}
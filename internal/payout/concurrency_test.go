@@ -0,0 +1,81 @@
+package payout
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// balanceRPC answers balance lookups after a delay shorter for later delegators, so that they finish out of order
+type balanceRPC struct {
+	test.RPCMock
+	mu       sync.Mutex
+	inFlight int
+	max      int
+	fail     string
+}
+
+func (b *balanceRPC) Balance(input rpc.BalanceInput) (int, error) {
+	b.mu.Lock()
+	b.inFlight++
+	if b.inFlight > b.max {
+		b.max = b.inFlight
+	}
+	b.mu.Unlock()
+
+	var i int
+	fmt.Sscanf(input.Address, "tz1delegator%d", &i)
+	time.Sleep(time.Duration(10-i%10) * time.Millisecond)
+
+	b.mu.Lock()
+	b.inFlight--
+	b.mu.Unlock()
+
+	if input.Address == b.fail {
+		return 0, errors.Errorf("failed to get balance of '%s'", input.Address)
+	}
+	if i%3 == 0 {
+		return 0, nil
+	}
+	return 5000000, nil
+}
+
+func Test_constructDelegations(t *testing.T) {
+	var delegations tzkt.Delegators
+	for i := 0; i < 20; i++ {
+		delegations = append(delegations, tzkt.Delegator{Address: fmt.Sprintf("tz1delegator%d", i), Balance: 5000000 + i})
+	}
+
+	sequential := &Payout{config: config.Config{Baker: config.Baker{Fee: 0.05}}, rpc: &balanceRPC{}}
+	want, err := sequential.constructDelegations(delegations, 10000000, 1000000000)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, sequential.rpc.(*balanceRPC).max)
+
+	node := &balanceRPC{}
+	concurrent := &Payout{config: config.Config{Baker: config.Baker{Fee: 0.05}, API: config.API{Concurrency: 4}}, rpc: node}
+	got, err := concurrent.constructDelegations(delegations, 10000000, 1000000000)
+	assert.Nil(t, err)
+	assert.Equal(t, want, got, "delegations are constructed the same as one at a time, in order")
+	assert.Equal(t, 4, node.max)
+	for i, delegation := range got {
+		assert.Equal(t, delegations[i].Address, delegation.Address)
+		assert.Equal(t, i%3 == 0, delegation.SkipReason == SkipReasonEmptyAccount)
+	}
+
+	failing := &Payout{config: config.Config{API: config.API{Concurrency: 4}}, rpc: &balanceRPC{fail: "tz1delegator7"}}
+	_, err = failing.constructDelegations(delegations, 10000000, 1000000000)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "tz1delegator7")
+
+	none, err := concurrent.constructDelegations(tzkt.Delegators{}, 10000000, 1000000000)
+	assert.Nil(t, err)
+	assert.Empty(t, none)
+}
@@ -0,0 +1,50 @@
+package payout
+
+import (
+	"fmt"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/pkg/errors"
+)
+
+// Confirmer asks an operator to confirm a payout before it is injected, e.g. through a chat bot
+type Confirmer interface {
+	Confirm(question string) (bool, error)
+}
+
+// errCancelled is returned for a payout the operator refused to confirm
+var errCancelled = errors.New("payout cancelled")
+
+// Cancelled returns true if err is the refusal of a payout by the operator asked to confirm it
+func Cancelled(err error) bool {
+	return errors.Cause(err) == errCancelled
+}
+
+// SetConfirmer sets the confirmer asked to confirm the payout once forged, before it is injected
+func (p *Payout) SetConfirmer(confirmer Confirmer) {
+	p.confirmer = confirmer
+}
+
+// checkConfirmation asks the confirmer to confirm the payout made of chunks, and refuses it unless confirmed
+func (p *Payout) checkConfirmation(chunks [][]disperseTransfer) error {
+	var transfers int
+	var amount int64
+	for _, chunk := range chunks {
+		for _, transfer := range chunk {
+			transfers++
+			amount += transfer.Amount
+		}
+	}
+
+	question := fmt.Sprintf("[TZPAY] confirm payout for cycle %d (%s): %d transfers of %.6f XTZ in %d operations from %s?",
+		p.cycle, p.config.Baker.Address, transfers, float64(amount)/float64(gotezos.MUTEZ), len(chunks), p.Wallet())
+
+	confirmed, err := p.confirmer.Confirm(question)
+	if err != nil {
+		return errors.Wrapf(err, "failed to confirm payout for cycle %d", p.cycle)
+	} else if !confirmed {
+		return errors.Wrapf(errCancelled, "payout for cycle %d was cancelled by the operator", p.cycle)
+	}
+
+	return nil
+}
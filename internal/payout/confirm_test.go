@@ -0,0 +1,97 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type confirmerMock struct {
+	confirmed bool
+	err       bool
+	questions []string
+}
+
+func (c *confirmerMock) Confirm(question string) (bool, error) {
+	c.questions = append(c.questions, question)
+	if c.err {
+		return false, errors.New("no reply within 1h")
+	}
+
+	return c.confirmed, nil
+}
+
+func Test_checkConfirmation(t *testing.T) {
+	chunks := [][]disperseTransfer{
+		{{Destination: "tz1a", Amount: 1000000}, {Destination: "tz1b", Amount: 250000}},
+		{{Destination: "tz1c", Amount: 100}},
+	}
+
+	cases := []struct {
+		name      string
+		confirmer *confirmerMock
+		cancelled bool
+		wantErr   bool
+		contains  string
+	}{
+		{
+			name:      "injects a payout confirmed",
+			confirmer: &confirmerMock{confirmed: true},
+		},
+		{
+			name:      "refuses a payout cancelled",
+			confirmer: &confirmerMock{},
+			cancelled: true,
+			wantErr:   true,
+			contains:  "payout for cycle 300 was cancelled by the operator",
+		},
+		{
+			name:      "handles no reply",
+			confirmer: &confirmerMock{err: true},
+			wantErr:   true,
+			contains:  "failed to confirm payout for cycle 300: no reply within 1h",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Payout{
+				config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+				cycle:  300,
+				signer: watchOnly("tz1wallet"),
+			}
+			p.SetConfirmer(tt.confirmer)
+
+			err := p.checkConfirmation(chunks)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			assert.Equal(t, tt.cancelled, Cancelled(err))
+			assert.Equal(t, []string{"[TZPAY] confirm payout for cycle 300 (tz1baker): 3 transfers of 1.250100 XTZ in 2 operations from tz1wallet?"}, tt.confirmer.questions)
+		})
+	}
+}
+
+func Test_process_cancelled(t *testing.T) {
+	queue := NewQueue(nil)
+	logger, _ := logtest.NewNullLogger()
+	queue.logger = logger
+	queue.SetConfirmer(&confirmerMock{})
+
+	queue.process(Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+		cycle:  10,
+		inject: true,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			return tzkt.RewardsSplit{Cycle: 10, Delegators: tzkt.Delegators{{Address: "tz1a", NetRewards: 5000000}}}, nil
+		},
+		applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+			return []string{}, errors.Wrap(errCancelled, "payout for cycle 10 was cancelled by the operator")
+		},
+	})
+
+	assert.Equal(t, 0, queue.Size(), "a payout cancelled is not retried")
+}
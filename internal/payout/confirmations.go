@@ -0,0 +1,108 @@
+package payout
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// maxOperationsTTL is the number of blocks an operation's branch stays valid, it cannot be included any older
+const maxOperationsTTL = 60
+
+// errOrphaned is returned once an operation is no longer included in the chain
+var errOrphaned = errors.New("operation orphaned by a chain reorganization")
+
+/*
+awaitConfirmations waits until Confirmations blocks were baked on top of the block including each operation. The
+inclusion of every operation is re-checked on each new block, so that an operation whose block was orphaned by a
+reorganization fails with errOrphaned instead of being marked paid. Re-injecting the payout is then safe: the
+orphaned operations share their counters with the new ones, so at most one of them can ever be included.
+*/
+func (p *Payout) awaitConfirmations(ophashes []string) error {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to confirm operations")
+	}
+
+	levels := map[string]int{}
+	for _, ophash := range ophashes {
+		level, ok, err := p.inclusionLevel(ophash, head.Header.Level)
+		if err != nil {
+			return errors.Wrap(err, "failed to confirm operations")
+		} else if !ok {
+			return errors.Wrapf(errOrphaned, "operation '%s' not found in the last %d blocks", ophash, maxOperationsTTL)
+		}
+		levels[ophash] = level
+	}
+
+	ticker := time.NewTicker(confirmationDurationInterval)
+	defer ticker.Stop()
+
+	checked := -1
+	for ; ; <-ticker.C {
+		head, err := p.rpc.Head()
+		if err != nil || head.Header.Level == checked {
+			continue
+		}
+
+		confirmed, failed := true, false
+		for ophash, level := range levels {
+			included, err := p.includedAt(ophash, level)
+			if err != nil {
+				confirmed, failed = false, true
+				continue
+			} else if !included {
+				return errors.Wrapf(errOrphaned, "operation '%s' no longer included at level %d", ophash, level)
+			}
+
+			if head.Header.Level-level < p.config.Operations.Confirmations {
+				confirmed = false
+			}
+		}
+
+		if confirmed {
+			return nil
+		} else if failed {
+			continue
+		}
+		checked = head.Header.Level
+
+		if p.verbose {
+			logrus.WithFields(logrus.Fields{"level": checked, "confirmations": p.config.Operations.Confirmations}).Info("Waiting for confirmations.")
+		}
+	}
+}
+
+// inclusionLevel searches the blocks an operation could have been included in, from level down, for ophash
+func (p *Payout) inclusionLevel(ophash string, level int) (int, bool, error) {
+	for l := level; l > 0 && l > level-maxOperationsTTL; l-- {
+		included, err := p.includedAt(ophash, l)
+		if err != nil {
+			return 0, false, err
+		} else if included {
+			return l, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// includedAt returns true if ophash is in the block of the main chain at level
+func (p *Payout) includedAt(ophash string, level int) (bool, error) {
+	included, err := p.rpc.OperationHashes(strconv.Itoa(level))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get operations at level %d", level)
+	}
+
+	for _, pass := range included {
+		for _, in := range pass {
+			if in == ophash {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,96 @@
+package payout
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// reorgRPCMock advances a block on every head request, and drops the operations of a block once the head reaches orphanAt
+type reorgRPCMock struct {
+	test.RPCMock
+	level    int
+	included map[int][]string
+	orphan   int
+	orphanAt int
+}
+
+func (r *reorgRPCMock) Head() (*rpc.Block, error) {
+	r.level++
+	if r.orphanAt > 0 && r.level >= r.orphanAt {
+		delete(r.included, r.orphan)
+	}
+
+	block := &rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}
+	block.Header.Level = r.level
+	return block, nil
+}
+
+func (r *reorgRPCMock) OperationHashes(blockhash string) ([][]string, error) {
+	level, err := strconv.Atoi(blockhash)
+	if err != nil {
+		return nil, err
+	}
+	return [][]string{r.included[level]}, nil
+}
+
+func Test_awaitConfirmations(t *testing.T) {
+	confirmationDurationInterval = time.Millisecond
+	defer func() { confirmationDurationInterval = time.Second * 1 }()
+
+	cases := []struct {
+		name        string
+		mock        *reorgRPCMock
+		ophashes    []string
+		orphaned    bool
+		errContains string
+		level       int
+	}{
+		{
+			"is successful",
+			&reorgRPCMock{level: 10, included: map[int][]string{9: {"oo1"}, 11: {"oo2"}}},
+			[]string{"oo1", "oo2"},
+			false,
+			"",
+			14,
+		},
+		{
+			"handles operation not found",
+			&reorgRPCMock{level: 10, included: map[int][]string{}},
+			[]string{"oo1"},
+			true,
+			"operation 'oo1' not found in the last 60 blocks",
+			11,
+		},
+		{
+			"handles orphaned operation",
+			&reorgRPCMock{level: 10, included: map[int][]string{11: {"oo1"}}, orphan: 11, orphanAt: 13},
+			[]string{"oo1"},
+			true,
+			"operation 'oo1' no longer included at level 11",
+			13,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Operations: config.Operations{Confirmations: 3},
+				},
+				rpc: tt.mock,
+			}
+
+			err := payout.awaitConfirmations(tt.ophashes)
+			test.CheckErr(t, tt.orphaned, tt.errContains, err)
+			assert.Equal(t, tt.orphaned, errors.Cause(err) == errOrphaned)
+			assert.Equal(t, tt.level, tt.mock.level)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package payout
+
+import (
+	"fmt"
+
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Modes of the cross-check of the rewards of the indexer against the node
+const (
+	CrossCheckWarn   = "warn"   // alert when the rewards diverge
+	CrossCheckRefuse = "refuse" // alert and refuse to inject the payout when the rewards diverge
+)
+
+var errCrossCheck = errors.New("rewards diverge between the node and the indexer")
+
+// CrossCheckFailed returns true if err is a payout refused because the rewards of the node and the indexer diverge
+func CrossCheckFailed(err error) bool {
+	return errors.Cause(err) == errCrossCheck
+}
+
+// crossChecks returns true if the rewards of the cycle are checked against the node before the payout is made
+func (p *Payout) crossChecks() bool {
+	return p.config.CrossCheck.Mode != "" && !p.future && !p.partial
+}
+
+// earnedRewards returns the rewards and fees the indexer reports the baker earned in the cycle, as the blocks of the
+// node credit them
+func earnedRewards(rewards tzkt.RewardsSplit) int {
+	if rewards.RewardModel == RewardModelTenderbake {
+		return rewards.BlockRewards + rewards.BlockFees + rewards.EndorsementRewards + rewards.RevelationRewards
+	}
+
+	return rewards.OwnBlockRewards +
+		rewards.OwnBlockFees +
+		rewards.ExtraBlockRewards +
+		rewards.ExtraBlockFees +
+		rewards.EndorsementRewards +
+		rewards.RevelationRewards
+}
+
+/*
+crossCheck compares the rewards the indexer reports the baker earned in the cycle to node, the rewards summed from the
+blocks of the node. A divergence above the tolerance configured, in percent of the larger of both, is alerted, and
+refuses the payout to be injected in refuse mode, as either the indexer or the node is wrong about the cycle.
+*/
+func (p *Payout) crossCheck(rewardsSplit tzkt.RewardsSplit, node int) error {
+	indexer := earnedRewards(rewardsSplit)
+	diff, larger := node-indexer, node
+	if diff < 0 {
+		diff = -diff
+	}
+	if indexer > larger {
+		larger = indexer
+	}
+
+	logger := logrus.WithFields(logrus.Fields{"cycle": p.cycle, "baker": p.config.Baker.Address, "node": node, "indexer": indexer})
+	if float64(diff)*100 <= p.config.CrossCheck.Tolerance*float64(larger) {
+		logger.Debug("Rewards of the node and the indexer agree.")
+		return nil
+	}
+
+	divergence := fmt.Sprintf("the node credits %s XTZ, the indexer reports %s XTZ", tez(int64(node)), tez(int64(indexer)))
+	refuse := p.config.CrossCheck.Mode == CrossCheckRefuse && p.inject
+	logger.WithField("tolerance", p.config.CrossCheck.Tolerance).Warn("Rewards of the node and the indexer diverge.")
+	if p.notifier != nil {
+		action := "continuing"
+		if refuse {
+			action = "refusing to inject it"
+		}
+		msg := fmt.Sprintf("[TZPAY] ALERT rewards of cycle %d (%s) diverge beyond %g%%, %s: %s",
+			p.cycle, p.config.Baker.Address, p.config.CrossCheck.Tolerance, action, divergence)
+		if err := p.notifier.NotifyKind(notifier.KindAlert, msg); err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to notify.")
+		}
+	}
+
+	if refuse {
+		return errors.Wrapf(errCrossCheck, "%s", divergence)
+	}
+
+	return nil
+}
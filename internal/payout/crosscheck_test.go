@@ -0,0 +1,75 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_crossCheck(t *testing.T) {
+	rewardsSplit := tzkt.RewardsSplit{
+		OwnBlockRewards:    80000000,
+		OwnBlockFees:       6000,
+		EndorsementRewards: 2500000,
+	}
+
+	cases := []struct {
+		name    string
+		mode    string
+		inject  bool
+		node    int
+		refused bool
+		alerted bool
+	}{
+		{"agrees", CrossCheckRefuse, true, 82506000, false, false},
+		{"diverges within the tolerance", CrossCheckRefuse, true, 82000000, false, false},
+		{"warns", CrossCheckWarn, true, 80000000, false, true},
+		{"refuses", CrossCheckRefuse, true, 80000000, true, true},
+		{"only warns without injecting", CrossCheckRefuse, false, 80000000, false, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			messenger := &notifier.MockClient{}
+			payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{messenger}})
+			payout := Payout{
+				config: config.Config{
+					Baker:      config.Baker{Address: "tz1baker"},
+					CrossCheck: config.CrossCheck{Mode: tt.mode, Tolerance: 1},
+				},
+				cycle:    300,
+				inject:   tt.inject,
+				notifier: &payoutNotifier,
+			}
+
+			err := payout.crossCheck(rewardsSplit, tt.node)
+			assert.Equal(t, tt.refused, CrossCheckFailed(err))
+			if tt.refused {
+				assert.Contains(t, err.Error(), "the node credits 80.000000 XTZ, the indexer reports 82.506000 XTZ")
+			} else {
+				assert.Nil(t, err)
+			}
+
+			if tt.alerted {
+				assert.Len(t, messenger.Messages, 1)
+				assert.Contains(t, messenger.Messages[0], "[TZPAY] ALERT rewards of cycle 300 (tz1baker) diverge beyond 1%")
+			} else {
+				assert.Empty(t, messenger.Messages)
+			}
+		})
+	}
+}
+
+func Test_crossChecks(t *testing.T) {
+	payout := Payout{config: config.Config{CrossCheck: config.CrossCheck{Mode: CrossCheckWarn}}}
+	assert.True(t, payout.crossChecks())
+
+	payout.SetPartial()
+	assert.False(t, payout.crossChecks(), "a cycle in progress has no rewards to check yet")
+
+	payout = Payout{}
+	assert.False(t, payout.crossChecks())
+}
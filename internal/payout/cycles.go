@@ -0,0 +1,39 @@
+package payout
+
+import (
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+/*
+CycleLevels returns the first and last levels of cycle as the indexer knows them. The levels are never derived from the
+number of blocks per cycle of the head, which protocols change, so a cycle the indexer can't bound is an error.
+*/
+func CycleLevels(indexer tzkt.IFace, cycle int) (int, int, error) {
+	c, err := indexer.GetCycle(cycle)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to get levels of cycle %d", cycle)
+	}
+
+	if c.Index != cycle || c.FirstLevel <= 0 || c.LastLevel < c.FirstLevel {
+		return 0, 0, errors.Errorf("failed to get levels of cycle %d: indexer returned cycle %d from level %d to %d", cycle, c.Index, c.FirstLevel, c.LastLevel)
+	}
+
+	return c.FirstLevel, c.LastLevel, nil
+}
+
+// LevelCycle returns the cycle of level as the indexer knows it, for the same reason
+func LevelCycle(indexer tzkt.IFace, level int) (int, error) {
+	blocks, err := indexer.GetBlocks(tzkt.URLParameters{Key: "level", Value: strconv.Itoa(level)})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get cycle of level %d", level)
+	}
+
+	if len(blocks) == 0 || blocks[0].Level != level {
+		return 0, errors.Errorf("failed to get cycle of level %d: level not indexed", level)
+	}
+
+	return blocks[0].Cycle, nil
+}
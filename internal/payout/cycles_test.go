@@ -0,0 +1,55 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CycleLevels(t *testing.T) {
+	cases := []struct {
+		name        string
+		tzkt        *test.TzktMock
+		first, last int
+		err         bool
+		contains    string
+	}{
+		{"returns levels of cycle", &test.TzktMock{}, 21, 22, false, ""},
+		{"returns levels of cycle after a change of blocks per cycle", &test.TzktMock{Cycles: map[int]tzkt.Cycle{10: {Index: 10, FirstLevel: 17, LastLevel: 24}}}, 17, 24, false, ""},
+		{"handles failure to get cycle", &test.TzktMock{CycleErr: true}, 0, 0, true, "failed to get levels of cycle 10"},
+		{"refuses unknown cycle", &test.TzktMock{Cycles: map[int]tzkt.Cycle{10: {}}}, 0, 0, true, "indexer returned cycle 0 from level 0 to 0"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			first, last, err := CycleLevels(tt.tzkt, 10)
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.first, first)
+			assert.Equal(t, tt.last, last)
+		})
+	}
+}
+
+func Test_LevelCycle(t *testing.T) {
+	cases := []struct {
+		name     string
+		tzkt     *test.TzktMock
+		cycle    int
+		err      bool
+		contains string
+	}{
+		{"returns cycle of level", &test.TzktMock{}, 10, false, ""},
+		{"returns cycle of level after a change of blocks per cycle", &test.TzktMock{Cycles: map[int]tzkt.Cycle{8: {Index: 8, FirstLevel: 17, LastLevel: 24}}}, 8, false, ""},
+		{"handles failure to get block", &test.TzktMock{CycleErr: true}, 0, true, "failed to get cycle of level 21"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle, err := LevelCycle(tt.tzkt, 21)
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.cycle, cycle)
+		})
+	}
+}
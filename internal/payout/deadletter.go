@@ -0,0 +1,103 @@
+package payout
+
+import (
+	"sort"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+// DeadLetterBucket is the bucket of the store the payouts that failed too many times in the queue are moved to
+const DeadLetterBucket = "deadletter"
+
+// DeadLetter is a payout of the queue given up on after failing TZPAY_OPERATIONS_MAX_ATTEMPTS times in a row
+type DeadLetter struct {
+	ID string `json:"id"`
+	Queued
+	Error  string    `json:"error"`
+	Failed time.Time `json:"failed"`
+}
+
+// DeadLetters returns the payouts moved to the dead letters, oldest queued first
+func DeadLetters(s store.IFace) ([]DeadLetter, error) {
+	keys, err := s.Keys(DeadLetterBucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list dead letters")
+	}
+	sort.Strings(keys)
+
+	letters := []DeadLetter{}
+	for _, key := range keys {
+		var letter DeadLetter
+		if _, err := s.Get(DeadLetterBucket, key, &letter); err != nil {
+			return nil, errors.Wrapf(err, "failed to get dead letter '%s'", key)
+		}
+		letter.ID = key
+		letters = append(letters, letter)
+	}
+
+	return letters, nil
+}
+
+// GetDeadLetter returns the dead letter id
+func GetDeadLetter(s store.IFace, id string) (DeadLetter, error) {
+	var letter DeadLetter
+	ok, err := s.Get(DeadLetterBucket, id, &letter)
+	if err != nil {
+		return letter, errors.Wrapf(err, "failed to get dead letter '%s'", id)
+	} else if !ok {
+		return letter, errors.Errorf("failed to get dead letter '%s': no such dead letter", id)
+	}
+	letter.ID = id
+
+	return letter, nil
+}
+
+// DiscardDeadLetter removes the dead letter id, which is then never retried
+func DiscardDeadLetter(s store.IFace, id string) error {
+	if _, err := GetDeadLetter(s, id); err != nil {
+		return err
+	}
+
+	if err := s.Delete(DeadLetterBucket, id); err != nil {
+		return errors.Wrapf(err, "failed to discard dead letter '%s'", id)
+	}
+
+	return nil
+}
+
+/*
+deadLetter records that p failed with err, and moves it from the queue of the store to the dead letters once it failed
+TZPAY_OPERATIONS_MAX_ATTEMPTS times in a row. It returns true if p was moved, and is then not to be put back in the
+queue. A payout that is not persisted is retried forever, as there is nowhere to keep it.
+*/
+func (q *Queue) deadLetter(p *Payout, err error) (bool, error) {
+	p.attempts++
+	if q.store == nil || p.queued == "" {
+		return false, nil
+	}
+
+	var queued Queued
+	if _, err := q.store.Get(QueueBucket, p.queued, &queued); err != nil {
+		return false, errors.Wrap(err, "failed to count failed attempt")
+	}
+	queued.Attempts = p.attempts
+
+	if max := p.config.Operations.MaxAttempts; max == 0 || p.attempts < max {
+		if err := q.store.Put(QueueBucket, p.queued, queued); err != nil {
+			return false, errors.Wrap(err, "failed to count failed attempt")
+		}
+		return false, nil
+	}
+
+	letter := DeadLetter{ID: p.queued, Queued: queued, Error: err.Error(), Failed: time.Now().UTC()}
+	if err := q.store.Put(DeadLetterBucket, p.queued, letter); err != nil {
+		return false, errors.Wrap(err, "failed to move payout to dead letters")
+	}
+	if err := q.store.Delete(QueueBucket, p.queued); err != nil {
+		return true, errors.Wrap(err, "failed to move payout to dead letters")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deadLetter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-deadletter")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	messenger := &notifier.MockClient{}
+	payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{messenger}})
+
+	queue := NewQueue(&payoutNotifier)
+	queue.logger, _ = logtest.NewNullLogger()
+	queue.SetStore(s)
+	queue.Enqueue(Payout{
+		config: config.Config{
+			Baker:      config.Baker{Address: "tz1baker"},
+			Operations: config.Operations{MaxAttempts: 2},
+		},
+		cycle: 10,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			return tzkt.RewardsSplit{}, errors.New("storage exhausted")
+		},
+	})
+
+	queue.process(queue.dequeuePerBaker()[0])
+	assert.Equal(t, 1, queue.Size(), "a payout is retried until it failed too many times")
+
+	var queued Queued
+	ok, err := s.Get(QueueBucket, queue.payouts[0].queued, &queued)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, queued.Attempts)
+
+	queue.process(queue.dequeuePerBaker()[0])
+	assert.True(t, queue.Empty())
+	assert.Len(t, messenger.Messages, 1)
+	assert.Contains(t, messenger.Messages[0], "[TZPAY] ALERT payout for cycle 10 (tz1baker) failed 2 times and was moved to the dead letters")
+
+	keys, err := s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.Empty(t, keys)
+
+	letters, err := DeadLetters(s)
+	assert.Nil(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "tz1baker", letters[0].Baker)
+	assert.Equal(t, 10, letters[0].Cycle)
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Contains(t, letters[0].Error, "storage exhausted")
+
+	letter, err := GetDeadLetter(s, letters[0].ID)
+	assert.Nil(t, err)
+	assert.Equal(t, letters[0].ID, letter.ID)
+
+	assert.Nil(t, DiscardDeadLetter(s, letter.ID))
+	letters, err = DeadLetters(s)
+	assert.Nil(t, err)
+	assert.Empty(t, letters)
+
+	test.CheckErr(t, true, "no such dead letter", DiscardDeadLetter(s, letter.ID))
+}
@@ -0,0 +1,47 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/sirupsen/logrus"
+)
+
+// denunciationLosses returns the rewards and fees the baker lost to double baking and double endorsing denunciations
+func denunciationLosses(rewardsSplit tzkt.RewardsSplit) int {
+	return rewardsSplit.DoubleBakingLostRewards +
+		rewardsSplit.DoubleBakingLostFees +
+		rewardsSplit.DoubleEndorsingLostRewards +
+		rewardsSplit.DoubleEndorsingLostFees
+}
+
+/*
+applyDenunciationPolicy applies the configured policy if the baker was denounced during the cycle. It returns the
+total rewards to share between delegators, and false if the cycle should not be paid out at all. Lost deposits
+are always left to the baker.
+*/
+func (p *Payout) applyDenunciationPolicy(rewardsSplit *tzkt.RewardsSplit, totalRewards int) (int, bool) {
+	losses := denunciationLosses(*rewardsSplit)
+	if losses == 0 && rewardsSplit.DoubleBakingLostDeposits == 0 && rewardsSplit.DoubleEndorsingLostDeposits == 0 {
+		return totalRewards, true
+	}
+	rewardsSplit.DenunciationLosses = losses
+
+	logrus.WithFields(logrus.Fields{
+		"baker":  p.config.Baker.Address,
+		"cycle":  p.cycle,
+		"losses": losses,
+		"policy": p.config.Baker.DenunciationPolicy,
+	}).Warn("Baker was denounced during the cycle.")
+
+	switch p.config.Baker.DenunciationPolicy {
+	case config.DenunciationPolicySkip:
+		rewardsSplit.Skipped = true
+		return 0, false
+	case config.DenunciationPolicyReduce:
+		if totalRewards -= losses; totalRewards < 0 {
+			totalRewards = 0
+		}
+	}
+
+	return totalRewards, true
+}
@@ -0,0 +1,103 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applyDenunciationPolicy(t *testing.T) {
+	type input struct {
+		policy       string
+		rewardsSplit tzkt.RewardsSplit
+	}
+
+	type want struct {
+		totalRewards int
+		pay          bool
+		losses       int
+		skipped      bool
+	}
+
+	denounced := tzkt.RewardsSplit{
+		DoubleEndorsingLostDeposits: 64000000,
+		DoubleEndorsingLostRewards:  2500000,
+		DoubleEndorsingLostFees:     500000,
+	}
+
+	cases := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"does nothing if the baker was not denounced",
+			input{
+				config.DenunciationPolicySkip,
+				tzkt.RewardsSplit{},
+			},
+			want{
+				totalRewards: 10000000,
+				pay:          true,
+			},
+		},
+		{
+			"pays in full",
+			input{
+				config.DenunciationPolicyPay,
+				denounced,
+			},
+			want{
+				totalRewards: 10000000,
+				pay:          true,
+				losses:       3000000,
+			},
+		},
+		{
+			"reduces rewards by losses",
+			input{
+				config.DenunciationPolicyReduce,
+				denounced,
+			},
+			want{
+				totalRewards: 7000000,
+				pay:          true,
+				losses:       3000000,
+			},
+		},
+		{
+			"skips cycle",
+			input{
+				config.DenunciationPolicySkip,
+				denounced,
+			},
+			want{
+				totalRewards: 0,
+				pay:          false,
+				losses:       3000000,
+				skipped:      true,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Baker: config.Baker{
+						DenunciationPolicy: tt.input.policy,
+					},
+				},
+			}
+
+			rewardsSplit := tt.input.rewardsSplit
+			totalRewards, pay := payout.applyDenunciationPolicy(&rewardsSplit, 10000000)
+			assert.Equal(t, tt.want.totalRewards, totalRewards)
+			assert.Equal(t, tt.want.pay, pay)
+			assert.Equal(t, tt.want.losses, rewardsSplit.DenunciationLosses)
+			assert.Equal(t, tt.want.skipped, rewardsSplit.Skipped)
+		})
+	}
+}
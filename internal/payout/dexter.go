@@ -104,25 +104,32 @@ func (p *Payout) getLiquidityProvidersEarnings(contract tzkt.Delegator) (tzkt.De
 				Share:   float64(balance) / float64(totalLiquidity),
 			}
 
-			lp.GrossRewards = int(lp.Share * float64(contract.GrossRewards))
-			lp.Fee = int(float64(lp.GrossRewards) * p.config.Baker.Fee)
+			lp.GrossRewards = p.round(exactRewards(balance, totalLiquidity, contract.GrossRewards))
+			lp.Fee = p.fee(lp.Address, lp.GrossRewards)
 			lp.NetRewards = lp.GrossRewards - lp.Fee
 
-			if lp.NetRewards < p.config.Baker.MinimumPayment {
-				lp.BlackListed = true
+			if payoutAddress := p.config.Baker.PayoutAddress(lp.Address); payoutAddress != lp.Address {
+				lp.PayoutAddress = payoutAddress
 			}
 
 			if p.isInBlacklist(lp.Address) {
 				lp.BlackListed = true
+				lp.SkipReason = skipReason(lp.SkipReason, SkipReasonBlacklist)
+			}
+
+			if lp.NetRewards < p.config.Baker.MinimumPayment {
+				lp.BlackListed = true
+				lp.SkipReason = skipReason(lp.SkipReason, SkipReasonMinimumPayment)
 			}
 
 			if !p.config.Baker.BakerPaysBurnFees {
-				requiresBurnFee, err := p.requiresBurnFee(lp.Address)
+				requiresBurnFee, err := p.requiresBurnFee(p.config.Baker.PayoutAddress(lp.Address))
 				if err != nil {
 					return contract, errors.Wrapf(err, "failed to get earnings for liquidity providers for contract '%s'", contract.Address)
 				}
 				if requiresBurnFee {
 					lp.BlackListed = true
+					lp.SkipReason = skipReason(lp.SkipReason, SkipReasonEmptyAccount)
 				}
 			}
 
@@ -0,0 +1,207 @@
+package payout
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+// Issue is an inconsistent state left in the store by an interrupted payout, with the action that repairs it
+type Issue struct {
+	Cycle       int    `json:"cycle"`
+	Problem     string `json:"problem"`
+	Explanation string `json:"explanation"`
+	Action      string `json:"action"`
+	repair      func() error
+}
+
+// Repairable returns true if the issue can be repaired without injecting anything
+func (i Issue) Repairable() bool {
+	return i.repair != nil
+}
+
+// Repair applies the repair action of the issue
+func (i Issue) Repair() error {
+	if i.repair == nil {
+		return errors.Errorf("issue with cycle %d must be repaired by hand: %s", i.Cycle, i.Action)
+	}
+
+	return i.repair()
+}
+
+/*
+Diagnose inspects the state tzpay keeps in s for baker after a crash or a manual intervention: payouts interrupted
+between two chunks, progress left behind by a payout that completed, records without operations, delegators paid
+without a record of their payout, and cycles missing between recorded payouts.
+*/
+func Diagnose(s store.IFace, baker string) ([]Issue, error) {
+	records, err := Records(s, baker)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diagnose store")
+	}
+
+	recorded := map[int]bool{}
+	for _, record := range records {
+		recorded[record.Cycle] = recorded[record.Cycle] || len(record.Operations) > 0
+	}
+
+	paid, err := cycleKeys(s, paidBucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diagnose store")
+	}
+
+	var issues []Issue
+	progress, err := cycleKeys(s, progressBucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diagnose store")
+	}
+	for cycle, keys := range progress {
+		issue, err := progressIssue(s, baker, cycle, keys[0], len(paid[cycle]) > 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to diagnose store")
+		}
+		issues = append(issues, issue)
+	}
+
+	recordKeys, err := cycleKeys(s, recordsBucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diagnose store")
+	}
+	for cycle, keys := range recordKeys {
+		if !recorded[cycle] && len(paid[cycle]) == 0 {
+			issues = append(issues, emptyRecordIssue(s, baker, cycle, keys))
+		}
+	}
+
+	for cycle, keys := range paid {
+		if _, ok := recordKeys[cycle]; !ok {
+			issues = append(issues, Issue{
+				Cycle:   cycle,
+				Problem: fmt.Sprintf("%d delegators recorded as paid without a payout record", len(keys)),
+				Explanation: "the payout was injected but stopped before it was recorded, e.g. while waiting for confirmations, " +
+					"and its operations are only known to the indexer",
+				Action: fmt.Sprintf("check the payout wallet on the indexer, then run 'tzpay run %d' to pay anyone left and record the payout", cycle),
+			})
+		}
+	}
+
+	for _, cycle := range missingCycles(recordKeys) {
+		if len(paid[cycle]) > 0 {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Cycle:       cycle,
+			Problem:     "no payout recorded between two recorded cycles",
+			Explanation: "the payout was lost from the queue of 'tzpay serv', which is kept in memory, or failed until the server stopped",
+			Action:      fmt.Sprintf("run 'tzpay dryrun %d' to check the cycle, then 'tzpay run %d' if it was not paid", cycle, cycle),
+		})
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Cycle == issues[j].Cycle {
+			return issues[i].Problem < issues[j].Problem
+		}
+		return issues[i].Cycle < issues[j].Cycle
+	})
+
+	return issues, nil
+}
+
+func progressIssue(s store.IFace, baker string, cycle int, key string, paid bool) (Issue, error) {
+	var progress Progress
+	if _, err := s.Get(progressBucket(baker), key, &progress); err != nil {
+		return Issue{}, err
+	}
+
+	clear := func() error {
+		if err := s.Delete(progressBucket(baker), key); err != nil {
+			return errors.Wrapf(err, "failed to clear progress of payout for cycle %d", cycle)
+		}
+		return nil
+	}
+
+	if paid {
+		return Issue{
+			Cycle:       cycle,
+			Problem:     "progress left behind by a completed payout",
+			Explanation: "the payout recorded its delegators as paid but stopped before clearing its progress",
+			Action:      "clear the progress",
+			repair:      clear,
+		}, nil
+	}
+
+	return Issue{
+		Cycle:   cycle,
+		Problem: fmt.Sprintf("payout interrupted after %d confirmed chunks (%s)", progress.Chunks, strings.Join(progress.Operations, ", ")),
+		Explanation: "the payout stopped between two chunks, running it again resumes after the last confirmed chunk " +
+			"as long as it is made of the same transfers",
+		Action: fmt.Sprintf("run 'tzpay run %d' to resume the payout", cycle),
+	}, nil
+}
+
+func emptyRecordIssue(s store.IFace, baker string, cycle int, keys []string) Issue {
+	return Issue{
+		Cycle:       cycle,
+		Problem:     "payout recorded as injected without an operation hash",
+		Explanation: "no delegator of the cycle is recorded as paid either, so the record does not prove the cycle was paid",
+		Action:      "remove the record, then check the cycle with 'tzpay dryrun'",
+		repair: func() error {
+			for _, key := range keys {
+				if err := s.Delete(recordsBucket(baker), key); err != nil {
+					return errors.Wrapf(err, "failed to remove record of payout for cycle %d", cycle)
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// cycleKeys groups the keys of a bucket by the cycle they start with
+func cycleKeys(s store.IFace, bucket string) (map[int][]string, error) {
+	keys, err := s.Keys(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cycles := map[int][]string{}
+	for _, key := range keys {
+		cycle, err := strconv.Atoi(strings.SplitN(key, "/", 2)[0])
+		if err != nil {
+			continue
+		}
+		cycles[cycle] = append(cycles[cycle], key)
+	}
+
+	return cycles, nil
+}
+
+// missingCycles returns the cycles without a key between the first and last cycle with one
+func missingCycles(cycles map[int][]string) []int {
+	if len(cycles) == 0 {
+		return nil
+	}
+
+	first, last := -1, -1
+	for cycle := range cycles {
+		if first == -1 || cycle < first {
+			first = cycle
+		}
+		if cycle > last {
+			last = cycle
+		}
+	}
+
+	var missing []int
+	for cycle := first + 1; cycle < last; cycle++ {
+		if _, ok := cycles[cycle]; !ok {
+			missing = append(missing, cycle)
+		}
+	}
+
+	return missing
+}
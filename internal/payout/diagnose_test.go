@@ -0,0 +1,69 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diagnose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-diagnose")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	issues, err := Diagnose(s, "tz1baker")
+	assert.Nil(t, err)
+	assert.Empty(t, issues)
+
+	baker := "tz1baker"
+	assert.Nil(t, s.Put(progressBucket(baker), "00000270", Progress{Chunks: 2, Operations: []string{"oo1", "oo2"}}))
+	assert.Nil(t, s.Put(progressBucket(baker), "00000271", Progress{Chunks: 1, Operations: []string{"oo3"}}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(271, "tz1delegator"), PaidEntry{Amount: 1}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000271/2020-09-01T10:00:00Z", Record{Cycle: 271, Operations: []string{"oo3"}}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000272/2020-09-04T10:00:00Z", Record{Cycle: 272}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(274, "tz1delegator"), PaidEntry{Amount: 1}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000275/2020-09-13T10:00:00Z", Record{Cycle: 275, Operations: []string{"oo4"}}))
+
+	issues, err = Diagnose(s, baker)
+	assert.Nil(t, err)
+
+	type summary struct {
+		cycle      int
+		problem    string
+		repairable bool
+	}
+	var got []summary
+	for _, issue := range issues {
+		got = append(got, summary{issue.Cycle, issue.Problem, issue.Repairable()})
+	}
+	assert.Equal(t, []summary{
+		{270, "payout interrupted after 2 confirmed chunks (oo1, oo2)", false},
+		{271, "progress left behind by a completed payout", true},
+		{272, "payout recorded as injected without an operation hash", true},
+		{273, "no payout recorded between two recorded cycles", false},
+		{274, "1 delegators recorded as paid without a payout record", false},
+	}, got)
+
+	for _, issue := range issues {
+		if issue.Repairable() {
+			assert.Nil(t, issue.Repair())
+		} else {
+			assert.NotNil(t, issue.Repair())
+		}
+	}
+
+	ok, err := s.Get(progressBucket(baker), "00000271", &Progress{})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	records, err := Records(s, baker)
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+}
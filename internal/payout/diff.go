@@ -0,0 +1,96 @@
+package payout
+
+import (
+	"math"
+	"sort"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// Kinds of changes between the payouts of two cycles
+const (
+	ChangeNew      = "new delegator"
+	ChangeDeparted = "departed delegator"
+	ChangeShare    = "share swing"
+	ChangeAmount   = "amount swing"
+)
+
+// DelegatorChange is a change in a delegator's payout from the previous cycle worth checking before injecting
+type DelegatorChange struct {
+	Address          string  `json:"address"`
+	Change           string  `json:"change"`
+	PreviousShare    float64 `json:"previous_share"`
+	Share            float64 `json:"share"`
+	PreviousRewards  int     `json:"previous_net_rewards"`
+	NetRewards       int     `json:"net_rewards"`
+	RelativeVariance float64 `json:"relative_variance,omitempty"`
+}
+
+/*
+Diff compares the payout of a cycle to the payout of the previous cycle, and returns the delegators that joined or
+left, and those whose share or net rewards varied by more than swing (e.g. 0.25 for 25%). Swings in share are
+reported before swings in rewards, as they point to a wrong snapshot rather than to a cycle with fewer rights.
+*/
+func Diff(previous, current tzkt.RewardsSplit, swing float64) []DelegatorChange {
+	previousDelegators := map[string]tzkt.Delegator{}
+	for _, delegator := range previous.Delegators {
+		previousDelegators[delegator.Address] = delegator
+	}
+
+	var changes []DelegatorChange
+	seen := map[string]bool{}
+	for _, delegator := range current.Delegators {
+		seen[delegator.Address] = true
+		change := DelegatorChange{
+			Address:    delegator.Address,
+			Share:      delegator.Share,
+			NetRewards: delegator.NetRewards,
+		}
+
+		before, ok := previousDelegators[delegator.Address]
+		if !ok {
+			change.Change = ChangeNew
+			changes = append(changes, change)
+			continue
+		}
+		change.PreviousShare, change.PreviousRewards = before.Share, before.NetRewards
+
+		if variance := relativeVariance(before.Share, delegator.Share); variance > swing {
+			change.Change, change.RelativeVariance = ChangeShare, variance
+			changes = append(changes, change)
+		} else if variance := relativeVariance(float64(before.NetRewards), float64(delegator.NetRewards)); variance > swing {
+			change.Change, change.RelativeVariance = ChangeAmount, variance
+			changes = append(changes, change)
+		}
+	}
+
+	for _, delegator := range previous.Delegators {
+		if !seen[delegator.Address] {
+			changes = append(changes, DelegatorChange{
+				Address:         delegator.Address,
+				Change:          ChangeDeparted,
+				PreviousShare:   delegator.Share,
+				PreviousRewards: delegator.NetRewards,
+			})
+		}
+	}
+
+	order := map[string]int{ChangeNew: 0, ChangeDeparted: 1, ChangeShare: 2, ChangeAmount: 3}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return order[changes[i].Change] < order[changes[j].Change]
+	})
+
+	return changes
+}
+
+// relativeVariance returns how much current varies from previous relative to previous, or 1 if previous is 0
+func relativeVariance(previous, current float64) float64 {
+	if previous == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	return math.Abs(current-previous) / math.Abs(previous)
+}
@@ -0,0 +1,44 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diff(t *testing.T) {
+	previous := tzkt.RewardsSplit{
+		Delegators: tzkt.Delegators{
+			{Address: "tz1a", Share: 0.5, NetRewards: 1000},
+			{Address: "tz1b", Share: 0.2, NetRewards: 400},
+			{Address: "tz1c", Share: 0.2, NetRewards: 400},
+			{Address: "tz1d", Share: 0.1, NetRewards: 200},
+		},
+	}
+
+	current := tzkt.RewardsSplit{
+		Delegators: tzkt.Delegators{
+			{Address: "tz1a", Share: 0.55, NetRewards: 600},
+			{Address: "tz1b", Share: 0.1, NetRewards: 300},
+			{Address: "tz1c", Share: 0.21, NetRewards: 420},
+			{Address: "tz1e", Share: 0.14, NetRewards: 280},
+		},
+	}
+
+	assert.Equal(t, []DelegatorChange{
+		{Address: "tz1e", Change: ChangeNew, Share: 0.14, NetRewards: 280},
+		{Address: "tz1d", Change: ChangeDeparted, PreviousShare: 0.1, PreviousRewards: 200},
+		{Address: "tz1b", Change: ChangeShare, PreviousShare: 0.2, Share: 0.1, PreviousRewards: 400, NetRewards: 300, RelativeVariance: 0.5},
+		{Address: "tz1a", Change: ChangeAmount, PreviousShare: 0.5, Share: 0.55, PreviousRewards: 1000, NetRewards: 600, RelativeVariance: 0.4},
+	}, Diff(previous, current, 0.25))
+
+	assert.Empty(t, Diff(current, current, 0.25))
+}
+
+func Test_relativeVariance(t *testing.T) {
+	assert.Equal(t, 0.0, relativeVariance(0, 0))
+	assert.Equal(t, 1.0, relativeVariance(0, 10))
+	assert.Equal(t, 0.5, relativeVariance(10, 5))
+	assert.Equal(t, 0.5, relativeVariance(10, 15))
+}
@@ -0,0 +1,363 @@
+package payout
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+disperseCode is the micheline representation of the disperse contract. The contract takes a list of
+(address, mutez) pairs and emits an internal transfer for each one, so that a whole batch of payouts
+can be sent as a single contract call:
+
+	parameter (list (pair address mutez));
+	storage unit;
+	code { CAR ; NIL operation ; SWAP ;
+	       ITER { DUP ; CAR ; CONTRACT unit ;
+	              IF_NONE { PUSH string "invalid destination" ; FAILWITH } {} ;
+	              SWAP ; CDR ; UNIT ; TRANSFER_TOKENS ; CONS } ;
+	       UNIT ; SWAP ; PAIR }
+*/
+const disperseCode = `[{"prim":"parameter","args":[{"prim":"list","args":[{"prim":"pair","args":[{"prim":"address"},{"prim":"mutez"}]}]}]},{"prim":"storage","args":[{"prim":"unit"}]},{"prim":"code","args":[[{"prim":"CAR"},{"prim":"NIL","args":[{"prim":"operation"}]},{"prim":"SWAP"},{"prim":"ITER","args":[[{"prim":"DUP"},{"prim":"CAR"},{"prim":"CONTRACT","args":[{"prim":"unit"}]},{"prim":"IF_NONE","args":[[{"prim":"PUSH","args":[{"prim":"string"},{"string":"invalid destination"}]},{"prim":"FAILWITH"}],[]]},{"prim":"SWAP"},{"prim":"CDR"},{"prim":"UNIT"},{"prim":"TRANSFER_TOKENS"},{"prim":"CONS"}]]},{"prim":"UNIT"},{"prim":"SWAP"},{"prim":"PAIR"}]]}]`
+
+const disperseStorage = `{"prim":"Unit"}`
+
+const (
+	disperseBaseGas        = 15000
+	disperseGasPerTransfer = 2500
+	disperseBurnPerAccount = 257
+	originationGasLimit    = 20000
+	originationBurn        = 257
+	minimalFeeBase         = 100
+	signatureSize          = 64
+	forgedSizeMargin       = 100
+)
+
+type disperseTransfer struct {
+	Destination string
+	Amount      int64
+}
+
+// applyDisperse pays out each batch of delegators with a single call to the disperse contract. If the node
+// rejects the call, the batch is sent as plain transactions instead.
+func (p *Payout) applyDisperse(delegators tzkt.Delegators) ([]string, error) {
+	batches := p.batch(delegators)
+	chunks := make([][]disperseTransfer, len(batches))
+	for i, batch := range batches {
+		chunks[i] = p.transfers(batch)
+	}
+
+	progress, err := p.loadProgress()
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to apply payout")
+	}
+
+	if p.usesApproval() {
+		if err := p.checkApproval(chunks); err != nil {
+			return []string{}, err
+		}
+	}
+
+	if p.confirmer != nil {
+		if err := p.checkConfirmation(chunks); err != nil {
+			return []string{}, err
+		}
+	}
+
+	ophashes := []string{}
+	if progress != nil {
+		ophashes = append(ophashes, progress.Operations...)
+	}
+
+	for i := range batches {
+		batch := batches[i]
+		head, err := p.rpc.Head()
+		if err != nil {
+			return ophashes, errors.Wrap(err, "failed to apply payout")
+		}
+
+		transactionBatches, err := p.constructTransactionBatches(head.Hash, batch)
+		if err != nil {
+			return ophashes, errors.Wrap(err, "failed to contruct batch transactions")
+		}
+
+		transactions := transactionBatches[0]
+		if len(transactions) == 0 {
+			if err := p.saveProgress(progress, chunks[i]); err != nil {
+				return ophashes, err
+			}
+			continue
+		}
+
+		call, err := p.constructDisperseCall(head, transactions)
+		if err == nil {
+			var operations []rpc.Contents
+			if operations, err = p.withReveal(head.Hash, []rpc.Contents{{call}}); err != nil {
+				return ophashes, errors.Wrap(err, "failed to apply payout")
+			}
+
+			var ophash string
+			p.trackPending(progress, chunks[i], operations[0], head.Header.Level)
+			ophash, err = p.signAndInject(head.Hash, operations[0])
+			p.untrackPending()
+			if err == nil {
+				if !p.confirmOperation(ophash) {
+					return ophashes, errors.Errorf("failed to inject operation: failed to confirm operation '%s'", ophash)
+				}
+				ophashes = append(ophashes, ophash)
+				if err := p.saveProgress(progress, chunks[i], ophash); err != nil {
+					return ophashes, err
+				}
+				continue
+			}
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"error": err.Error(),
+			"batch": fmt.Sprintf("%d", i+1),
+		}).Warn("Failed to payout through disperse contract, falling back to batch transactions.")
+
+		constants, err := p.rpc.Constants(head.Hash)
+		if err != nil {
+			return ophashes, errors.Wrap(err, "failed to get protocol limits")
+		}
+
+		operations, err := p.operationsWithinLimits(head, transactions, constants)
+		if err != nil {
+			return ophashes, errors.Wrap(err, "failed to forge operation")
+		}
+
+		hashes, err := p.injectContents(head.Hash, operations, nil)
+		ophashes = append(ophashes, hashes...)
+		if err != nil {
+			return ophashes, err
+		}
+
+		if err := p.saveProgress(progress, chunks[i], hashes...); err != nil {
+			return ophashes, err
+		}
+	}
+
+	return ophashes, nil
+}
+
+// constructDisperseCall folds a batch of transactions into a single call to the disperse contract
+func (p *Payout) constructDisperseCall(head *rpc.Block, transactions rpc.Contents) (rpc.Content, error) {
+	var transfers []disperseTransfer
+	var amount int64
+	for _, transaction := range transactions {
+		transfers = append(transfers, disperseTransfer{
+			Destination: transaction.Destination,
+			Amount:      transaction.Amount,
+		})
+		amount += transaction.Amount
+	}
+
+	parameters, err := disperseParameters(transfers)
+	if err != nil {
+		return rpc.Content{}, errors.Wrap(err, "failed to construct disperse parameters")
+	}
+
+	var storageLimit int64
+	if p.config.Baker.BakerPaysBurnFees {
+		storageLimit = int64(disperseBurnPerAccount * len(transfers))
+	}
+
+	call := rpc.Content{
+		Kind:         rpc.TRANSACTION,
+		Source:       p.Wallet(),
+		Destination:  p.config.Operations.DisperseContract,
+		Amount:       amount,
+		GasLimit:     int64(disperseBaseGas + disperseGasPerTransfer*len(transfers)),
+		StorageLimit: storageLimit,
+		Counter:      transactions[0].Counter,
+		Parameters: &rpc.ContentsHelperParameters{
+			Entrypoint: "default",
+			Value:      &parameters,
+		},
+	}
+
+	if p.config.Operations.Estimate {
+		constants, err := p.rpc.Constants(head.Hash)
+		if err != nil {
+			return rpc.Content{}, errors.Wrap(err, "failed to get protocol limits")
+		}
+
+		estimated, err := p.estimate(head, rpc.Contents{call}, constants)
+		if err != nil {
+			return rpc.Content{}, errors.Wrap(err, "failed to estimate disperse call")
+		}
+
+		return estimated[0], nil
+	}
+
+	if call.Fee, err = minimalFee(head.Hash, call); err != nil {
+		return rpc.Content{}, errors.Wrap(err, "failed to estimate disperse fee")
+	}
+
+	return call, nil
+}
+
+// OriginateDisperseContract originates the disperse contract from the payout wallet and returns its address
+func (p *Payout) OriginateDisperseContract() (string, error) {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to originate disperse contract")
+	}
+
+	counter, err := p.rpc.Counter(head.Hash, p.Wallet())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to originate disperse contract")
+	}
+
+	origination, err := constructDisperseOrigination(head.Hash, p.Wallet(), counter+1)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to originate disperse contract")
+	}
+
+	ophash, err := p.signAndInject(head.Hash, rpc.Contents{origination})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to originate disperse contract")
+	}
+
+	if !p.confirmOperation(ophash) {
+		return "", errors.Errorf("failed to originate disperse contract: failed to confirm operation '%s'", ophash)
+	}
+
+	contract, err := p.originatedContract(ophash)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to originate disperse contract")
+	}
+
+	return contract, nil
+}
+
+func constructDisperseOrigination(blockhash, source string, counter int) (rpc.Content, error) {
+	code := json.RawMessage(disperseCode)
+	storage := json.RawMessage(disperseStorage)
+
+	origination := rpc.Content{
+		Kind:     rpc.ORIGINATION,
+		Source:   source,
+		Counter:  counter,
+		GasLimit: originationGasLimit,
+		Script: rpc.Script{
+			Code:    &code,
+			Storage: &storage,
+		},
+	}
+	origination.StorageLimit = int64(originationBurn + len(disperseCode))
+
+	var err error
+	if origination.Fee, err = minimalFee(blockhash, origination); err != nil {
+		return rpc.Content{}, err
+	}
+
+	return origination, nil
+}
+
+// originatedContract looks up the contract originated by an operation in the most recent blocks
+func (p *Payout) originatedContract(ophash string) (string, error) {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get head")
+	}
+
+	for level := head.Header.Level; level > head.Header.Level-5 && level > 0; level-- {
+		block, err := p.rpc.Block(level)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get block '%d'", level)
+		}
+
+		for _, operations := range block.Operations {
+			for _, operation := range operations {
+				if operation.Hash != ophash {
+					continue
+				}
+				for _, content := range operation.Contents {
+					if content.Metadata == nil || content.Metadata.OperationResults == nil {
+						continue
+					}
+					if contracts := content.Metadata.OperationResults.OriginatedContracts; len(contracts) > 0 {
+						return contracts[0], nil
+					}
+				}
+			}
+		}
+	}
+
+	return "", errors.Errorf("failed to find originated contract for operation '%s'", ophash)
+}
+
+// signAndInject forges, signs and injects contents without waiting for them to be included
+func (p *Payout) signAndInject(blockhash string, contents rpc.Contents) (string, error) {
+	op, err := forge.Encode(blockhash, contents...)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to forge operation")
+	}
+	p.keepForged(op)
+
+	signedop, err := p.sign(keys.SignInput{
+		Message: op,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign operation")
+	}
+
+	signed := fmt.Sprintf("%s%s", op, hex.EncodeToString(signedop.Bytes))
+	if err := p.savePending(signed); err != nil {
+		return "", err
+	}
+
+	ophash, err := p.rpc.InjectionOperation(rpc.InjectionOperationInput{
+		Operation: signed,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to inject operation")
+	}
+
+	return ophash, nil
+}
+
+func disperseParameters(transfers []disperseTransfer) (json.RawMessage, error) {
+	type micheline struct {
+		Prim   string        `json:"prim,omitempty"`
+		Args   []interface{} `json:"args,omitempty"`
+		String string        `json:"string,omitempty"`
+		Int    string        `json:"int,omitempty"`
+	}
+
+	list := []micheline{}
+	for _, transfer := range transfers {
+		list = append(list, micheline{
+			Prim: "Pair",
+			Args: []interface{}{
+				micheline{String: transfer.Destination},
+				micheline{Int: strconv.FormatInt(transfer.Amount, 10)},
+			},
+		})
+	}
+
+	return json.Marshal(list)
+}
+
+// minimalFee returns the fee required by the default node mempool filter for a single content operation
+func minimalFee(blockhash string, content rpc.Content) (int64, error) {
+	content.Fee = 1 // placeholder, the size of the real fee is covered by forgedSizeMargin
+	op, err := forge.Encode(blockhash, content)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to forge operation")
+	}
+
+	size := int64(len(op)/2 + signatureSize + forgedSizeMargin)
+	return minimalFeeBase + content.GasLimit/10 + size, nil
+}
@@ -0,0 +1,243 @@
+package payout
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_disperseParameters(t *testing.T) {
+	cases := []struct {
+		name  string
+		input []disperseTransfer
+		want  string
+	}{
+		{
+			"is successful",
+			[]disperseTransfer{
+				{Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000},
+				{Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Amount: 1},
+			},
+			`[{"prim":"Pair","args":[{"string":"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"},{"int":"900000"}]},{"prim":"Pair","args":[{"string":"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"},{"int":"1"}]}]`,
+		},
+		{
+			"is successful with no transfers",
+			[]disperseTransfer{},
+			`[]`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			parameters, err := disperseParameters(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, string(parameters))
+		})
+	}
+}
+
+func Test_constructDisperseCall(t *testing.T) {
+	type want struct {
+		amount       int64
+		counter      int
+		gasLimit     int64
+		storageLimit int64
+	}
+
+	cases := []struct {
+		name              string
+		bakerPaysBurnFees bool
+		input             rpc.Contents
+		want              want
+	}{
+		{
+			"is successful",
+			false,
+			rpc.Contents{
+				{Kind: rpc.TRANSACTION, Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000, Counter: 101},
+				{Kind: rpc.TRANSACTION, Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Amount: 950000, Counter: 102},
+			},
+			want{
+				amount:   1850000,
+				counter:  101,
+				gasLimit: disperseBaseGas + 2*disperseGasPerTransfer,
+			},
+		},
+		{
+			"is successful with baker paying burn fees",
+			true,
+			rpc.Contents{
+				{Kind: rpc.TRANSACTION, Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000, Counter: 101},
+			},
+			want{
+				amount:       900000,
+				counter:      101,
+				gasLimit:     disperseBaseGas + disperseGasPerTransfer,
+				storageLimit: disperseBurnPerAccount,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.NewKey(keys.NewKeyInput{
+				Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+				Password: "password12345##",
+				Kind:     keys.Ed25519,
+			})
+			assert.Nil(t, err)
+
+			payout := Payout{
+				config: config.Config{
+					Baker: config.Baker{
+						BakerPaysBurnFees: tt.bakerPaysBurnFees,
+					},
+					Operations: config.Operations{
+						DisperseContract: "KT1GQcLae1ve1ZEPNfD9z1dyv5ev9ki39SNW",
+					},
+				},
+				key: key,
+			}
+
+			call, err := payout.constructDisperseCall(&rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}, tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, "KT1GQcLae1ve1ZEPNfD9z1dyv5ev9ki39SNW", call.Destination)
+			assert.Equal(t, tt.want.amount, call.Amount)
+			assert.Equal(t, tt.want.counter, call.Counter)
+			assert.Equal(t, tt.want.gasLimit, call.GasLimit)
+			assert.Equal(t, tt.want.storageLimit, call.StorageLimit)
+			assert.True(t, call.Fee > minimalFeeBase+call.GasLimit/10)
+
+			_, err = forge.Encode("BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", call)
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func Test_constructDisperseOrigination(t *testing.T) {
+	origination, err := constructDisperseOrigination("BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", 101)
+	assert.Nil(t, err)
+	assert.Equal(t, rpc.ORIGINATION, origination.Kind)
+	assert.Equal(t, 101, origination.Counter)
+	assert.True(t, json.Valid(*origination.Script.Code))
+	assert.True(t, origination.Fee > minimalFeeBase+origination.GasLimit/10)
+
+	_, err = forge.Encode("BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", origination)
+	assert.Nil(t, err)
+}
+
+func Test_applyDisperse(t *testing.T) {
+	type input struct {
+		rpcClient  rpc.IFace
+		delegators tzkt.Delegators
+	}
+
+	type want struct {
+		err        bool
+		contains   string
+		operations []string
+	}
+
+	cases := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get head",
+			input{
+				rpcClient: &test.RPCMock{
+					HeadErr: true,
+				},
+				delegators: tzkt.Delegators{
+					{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000},
+				},
+			},
+			want{
+				true,
+				"failed to get block",
+				[]string{},
+			},
+		},
+		{
+			"handles failure of fallback after failure to inject",
+			input{
+				rpcClient: &test.RPCMock{
+					InjectionOperationErr: true,
+				},
+				delegators: tzkt.Delegators{
+					{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000},
+				},
+			},
+			want{
+				true,
+				"failed to inject operation",
+				[]string{},
+			},
+		},
+		{
+			"skips batches without transfers",
+			input{
+				rpcClient: &test.RPCMock{},
+				delegators: tzkt.Delegators{
+					{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000, BlackListed: true},
+				},
+			},
+			want{
+				false,
+				"",
+				[]string{},
+			},
+		},
+		{
+			"is successful",
+			input{
+				rpcClient: &test.RPCMock{},
+				delegators: tzkt.Delegators{
+					{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000},
+					{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", NetRewards: 950000},
+				},
+			},
+			want{
+				false,
+				"",
+				[]string{"ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.NewKey(keys.NewKeyInput{
+				Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+				Password: "password12345##",
+				Kind:     keys.Ed25519,
+			})
+			assert.Nil(t, err)
+
+			payout := Payout{
+				rpc: tt.input.rpcClient,
+				config: config.Config{
+					Operations: config.Operations{
+						GasLimit:         10000,
+						NetworkFee:       3000,
+						BatchSize:        100,
+						DisperseContract: "KT1GQcLae1ve1ZEPNfD9z1dyv5ev9ki39SNW",
+					},
+				},
+				key: key,
+			}
+
+			ops, err := payout.apply(tt.input.delegators)
+			test.CheckErr(t, tt.want.err, tt.want.contains, err)
+			assert.Equal(t, tt.want.operations, ops)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package payout
+
+import (
+	"math/big"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// applyDonation sets aside the configured percentage of the fees collected by the baker as a donation
+func (p *Payout) applyDonation(rewardsSplit *tzkt.RewardsSplit) {
+	if p.config.Baker.DonationPercentage <= 0 || p.config.Baker.DonationAddress == "" {
+		return
+	}
+
+	donation := new(big.Rat).SetInt64(int64(rewardsSplit.BakerCollectedFees))
+	donation.Mul(donation, rat(p.config.Baker.DonationPercentage))
+	donation.Quo(donation, big.NewRat(100, 1))
+
+	rewardsSplit.Donation = p.round(donation)
+	rewardsSplit.DonationAddress = p.config.Baker.DonationAddress
+}
+
+// withDonation appends the donation to the delegators paid, so that it is sent with the last batch
+func withDonation(rewardsSplit tzkt.RewardsSplit, delegators tzkt.Delegators) tzkt.Delegators {
+	if rewardsSplit.Donation <= 0 {
+		return delegators
+	}
+
+	return append(delegators, tzkt.Delegator{
+		Address:    rewardsSplit.DonationAddress,
+		NetRewards: rewardsSplit.Donation,
+	})
+}
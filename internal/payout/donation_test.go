@@ -0,0 +1,57 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_applyDonation(t *testing.T) {
+	type want struct {
+		donation int
+		address  string
+	}
+
+	cases := []struct {
+		name  string
+		baker config.Baker
+		want  want
+	}{
+		{
+			"donates a percentage of collected fees",
+			config.Baker{DonationPercentage: 12.5, DonationAddress: "tz1charity"},
+			want{125000, "tz1charity"},
+		},
+		{
+			"rounds with the configured policy",
+			config.Baker{DonationPercentage: 0.00001, DonationAddress: "tz1charity", Rounding: config.RoundingCeil},
+			want{1, "tz1charity"},
+		},
+		{
+			"does nothing if disabled",
+			config.Baker{},
+			want{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{config: config.Config{Baker: tt.baker}}
+			rewardsSplit := tzkt.RewardsSplit{BakerCollectedFees: 1000000}
+			payout.applyDonation(&rewardsSplit)
+			assert.Equal(t, tt.want.donation, rewardsSplit.Donation)
+			assert.Equal(t, tt.want.address, rewardsSplit.DonationAddress)
+		})
+	}
+}
+
+func Test_withDonation(t *testing.T) {
+	delegators := tzkt.Delegators{{Address: "tz1a", NetRewards: 100}}
+	assert.Equal(t, delegators, withDonation(tzkt.RewardsSplit{}, delegators))
+	assert.Equal(t, tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 100},
+		{Address: "tz1charity", NetRewards: 10},
+	}, withDonation(tzkt.RewardsSplit{Donation: 10, DonationAddress: "tz1charity"}, delegators))
+}
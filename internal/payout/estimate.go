@@ -0,0 +1,140 @@
+package payout
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	statusApplied  = "applied"
+	allocationSize = 257 // bytes of storage paid for allocating a new account
+)
+
+/*
+estimate simulates contents against the head block with the node's preapply RPC, and sets the gas limit, storage limit
+and fee of each content to what the simulation consumed plus the configured margin. Contents without a storage limit
+are simulated without one, so that estimation never makes the baker pay burn fees it was not configured to pay.
+*/
+func (p *Payout) estimate(head *rpc.Block, contents rpc.Contents, constants rpc.Constants) (rpc.Contents, error) {
+	if len(contents) == 0 {
+		return contents, nil
+	}
+
+	gasLimit := int64(constants.HardGasLimitPerOperation)
+	if constants.HardGasLimitPerBlock > 0 && gasLimit*int64(len(contents)) > int64(constants.HardGasLimitPerBlock) {
+		gasLimit = int64(constants.HardGasLimitPerBlock / len(contents))
+	}
+
+	simulation := make(rpc.Contents, len(contents))
+	copy(simulation, contents)
+	for i := range simulation {
+		simulation[i].GasLimit = gasLimit
+		if simulation[i].StorageLimit > 0 {
+			simulation[i].StorageLimit = int64(constants.HardStorageLimitPerOperation)
+		}
+	}
+
+	op, err := forge.Encode(head.Hash, simulation...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to forge simulation")
+	}
+
+	signature, err := p.sign(keys.SignInput{
+		Message: op,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign simulation")
+	}
+
+	results, err := p.rpc.PreapplyOperations(rpc.PreapplyOperationsInput{
+		Blockhash: head.Hash,
+		Operations: []rpc.Operations{
+			{
+				Protocol:  head.Protocol,
+				Branch:    head.Hash,
+				Contents:  simulation,
+				Signature: signature.ToBase58(),
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to simulate operation")
+	}
+
+	if len(results) != 1 || len(results[0].Contents) != len(contents) {
+		return nil, errors.New("failed to simulate operation: unexpected number of results")
+	}
+
+	estimated := make(rpc.Contents, len(contents))
+	copy(estimated, contents)
+	for i, result := range results[0].Contents {
+		gas, storage, err := consumed(result)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to simulate transaction to '%s'", contents[i].Destination)
+		}
+
+		estimated[i].GasLimit = p.withMargin(gas)
+		if contents[i].StorageLimit > 0 {
+			estimated[i].StorageLimit = p.withMargin(storage)
+		}
+
+		if estimated[i].Fee, err = minimalFee(head.Hash, estimated[i]); err != nil {
+			return nil, errors.Wrapf(err, "failed to estimate fee of transaction to '%s'", contents[i].Destination)
+		}
+	}
+
+	return estimated, nil
+}
+
+// withMargin adds the configured estimation margin to a simulated amount of gas or storage
+func (p *Payout) withMargin(amount int64) int64 {
+	return amount + int64(math.Ceil(float64(amount)*p.config.Operations.EstimateMargin/100))
+}
+
+// consumed returns the gas and storage consumed by a simulated content, including its internal operations
+func consumed(content rpc.Content) (int64, int64, error) {
+	if content.Metadata == nil || content.Metadata.OperationResults == nil {
+		return 0, 0, errors.New("missing operation result")
+	}
+
+	result := content.Metadata.OperationResults
+	if result.Status != statusApplied {
+		return 0, 0, errors.Errorf("operation %s: %v", result.Status, result.Errors)
+	}
+
+	gas, storage := result.ConsumedGas, result.PaidStorageSizeDiff
+	if result.AllocatedDestinationContract {
+		storage += allocationSize
+	}
+
+	for _, internal := range content.Metadata.InternalOperationResult {
+		fields, ok := internal.Result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if status, _ := fields["status"].(string); status != statusApplied {
+			return 0, 0, errors.Errorf("internal %s to '%s' %s", internal.Kind, internal.Destination, status)
+		}
+
+		gas += resultInt(fields, "consumed_gas")
+		storage += resultInt(fields, "paid_storage_size_diff")
+		if allocated, _ := fields["allocated_destination_contract"].(bool); allocated {
+			storage += allocationSize
+		}
+	}
+
+	return gas, storage, nil
+}
+
+// resultInt reads a numeric field of an operation result, which the node encodes as a string
+func resultInt(fields map[string]interface{}, key string) int64 {
+	value, _ := fields[key].(string)
+	i, _ := strconv.ParseInt(value, 10, 64)
+	return i
+}
@@ -0,0 +1,82 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_estimate(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	head := &rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}
+	contents := rpc.Contents{
+		{Kind: rpc.TRANSACTION, Source: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000, Counter: 101, Fee: 2941, GasLimit: 26283},
+		{Kind: rpc.TRANSACTION, Source: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Amount: 950000, Counter: 102, Fee: 2941, GasLimit: 26283, StorageLimit: 257},
+	}
+
+	cases := []struct {
+		name        string
+		rpc         test.RPCMock
+		err         bool
+		errContains string
+	}{
+		{
+			"is successful",
+			test.RPCMock{},
+			false,
+			"",
+		},
+		{
+			"handles failure to simulate",
+			test.RPCMock{PreapplyErr: true},
+			true,
+			"failed to simulate operation",
+		},
+		{
+			"handles failed simulation",
+			test.RPCMock{PreapplyStatus: "backtracked"},
+			true,
+			"failed to simulate transaction to 'tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc': operation backtracked",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{Operations: config.Operations{EstimateMargin: 10}},
+				rpc:    &tt.rpc,
+				key:    key,
+			}
+
+			estimated, err := payout.estimate(head, contents, rpc.Constants{HardGasLimitPerOperation: 1040000, HardStorageLimitPerOperation: 60000})
+			test.CheckErr(t, tt.err, tt.errContains, err)
+			if tt.err {
+				return
+			}
+
+			assert.Len(t, estimated, 2)
+			for i, content := range estimated {
+				assert.Equal(t, int64(1570), content.GasLimit)
+				assert.Equal(t, contents[i].Counter, content.Counter)
+				assert.Nil(t, content.Metadata)
+
+				fee, err := minimalFee(head.Hash, content)
+				assert.Nil(t, err)
+				assert.Equal(t, fee, content.Fee)
+			}
+			assert.Equal(t, int64(0), estimated[0].StorageLimit)
+			assert.Equal(t, int64(74), estimated[1].StorageLimit)
+			assert.Equal(t, int64(2941), contents[0].Fee)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// SetNotifier sets the notifier sent the events of the payout as it executes
+func (p *Payout) SetNotifier(notifier *notifier.PayoutNotifier) {
+	p.notifier = notifier
+}
+
+// event sends the event name of the payout of delegators, with the operations injected so far and err if it failed
+func (p *Payout) event(name string, delegators tzkt.Delegators, operations []string, err error) {
+	if p.notifier == nil {
+		return
+	}
+
+	event := notifier.Event{
+		Name:       name,
+		Baker:      p.config.Baker.Address,
+		Cycle:      p.cycle,
+		Operations: operations,
+	}
+	for _, transfer := range p.transfers(delegators) {
+		event.Transfers++
+		event.Amount += int(transfer.Amount)
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	p.notifier.Event(event)
+}
@@ -0,0 +1,74 @@
+package payout
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Execute_Events(t *testing.T) {
+	delegators := tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 100},
+		{Address: "tz1b", NetRewards: 200},
+		{Address: "tz1c", NetRewards: 300, BlackListed: true},
+	}
+
+	cases := []struct {
+		name  string
+		apply func(delegators tzkt.Delegators) ([]string, error)
+		want  []notifier.Event
+	}{
+		{
+			name: "sends the payout computed and injected",
+			apply: func(delegators tzkt.Delegators) ([]string, error) {
+				return []string{"oo1"}, nil
+			},
+			want: []notifier.Event{
+				{Name: notifier.EventComputed, Baker: "tz1baker", Cycle: 300, Transfers: 2, Amount: 300},
+				{Name: notifier.EventInjected, Baker: "tz1baker", Cycle: 300, Transfers: 2, Amount: 300, Operations: []string{"oo1"}},
+			},
+		},
+		{
+			name: "sends the payout failed",
+			apply: func(delegators tzkt.Delegators) ([]string, error) {
+				return nil, errors.New("failed to apply")
+			},
+			want: []notifier.Event{
+				{Name: notifier.EventComputed, Baker: "tz1baker", Cycle: 300, Transfers: 2, Amount: 300},
+				{Name: notifier.EventFailed, Baker: "tz1baker", Cycle: 300, Transfers: 2, Amount: 300,
+					Error: "failed to execute payout for cycle 300: failed to apply"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &notifier.MockEventClient{}
+			payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{client}})
+
+			payout := Payout{
+				config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+				cycle:  300,
+				inject: true,
+				constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+					return tzkt.RewardsSplit{Delegators: delegators}, nil
+				},
+				applyFunc: tt.apply,
+			}
+			payout.SetNotifier(&payoutNotifier)
+			payout.Execute()
+
+			for i := range client.Events {
+				assert.False(t, client.Events[i].Time.IsZero())
+				client.Events[i].Time = time.Time{}
+			}
+			assert.Equal(t, tt.want, client.Events)
+			assert.Empty(t, client.Messages)
+		})
+	}
+}
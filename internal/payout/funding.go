@@ -0,0 +1,137 @@
+package payout
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// FundingEntry is the record that the payout wallet was funded from the baker for a cycle
+type FundingEntry struct {
+	Amount    int       `json:"amount"`
+	Operation string    `json:"operation"`
+	Time      time.Time `json:"time"`
+}
+
+func fundingBucket(baker string) string {
+	return "funding/" + baker
+}
+
+func fundingKey(cycle int) string {
+	return fmt.Sprintf("%08d", cycle)
+}
+
+// funds reports whether the payout wallet is funded from the baker before the payout is injected
+func (p *Payout) funds() bool {
+	return p.config.Funding.Enabled
+}
+
+/*
+fund transfers the rewards the baker earned in the cycle, unfrozen by the time the cycle is paid out, from the baker
+to the payout wallet and waits for the transfer to be confirmed, so that the wallet holds the payout before it is
+injected. The transfer is capped to the balance of the baker and recorded in the store, so that a cycle is only
+funded once. It returns the hash of the transfer and the amount transferred, or no hash if there was nothing to do.
+*/
+func (p *Payout) fund(rewardsSplit tzkt.RewardsSplit) (string, int, error) {
+	baker := p.bakerKey.PubKey.GetPublicKeyHash()
+	if baker != p.config.Baker.Address {
+		return "", 0, errors.Errorf("failed to fund payout wallet: key of '%s' is not the key of baker '%s'", baker, p.config.Baker.Address)
+	}
+
+	wallet := p.Wallet()
+	if wallet == baker {
+		return "", 0, nil
+	}
+
+	if p.store != nil {
+		var entry FundingEntry
+		ok, err := p.store.Get(fundingBucket(baker), fundingKey(p.cycle), &entry)
+		if err != nil {
+			return "", 0, errors.Wrap(err, "failed to fund payout wallet")
+		} else if ok {
+			logrus.WithFields(logrus.Fields{"cycle": p.cycle, "operation": entry.Operation}).Info("Payout wallet already funded for cycle.")
+			return "", 0, nil
+		}
+	}
+
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to fund payout wallet")
+	}
+
+	balance, err := p.rpc.Balance(rpc.BalanceInput{
+		Blockhash: head.Hash,
+		Address:   baker,
+	})
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to fund payout wallet")
+	}
+
+	counter, err := p.rpc.Counter(head.Hash, baker)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to fund payout wallet")
+	}
+
+	transfer := rpc.Content{
+		Kind:         rpc.TRANSACTION,
+		Source:       baker,
+		Destination:  wallet,
+		Amount:       int64(p.earnings(rewardsSplit)),
+		GasLimit:     int64(p.config.Operations.GasLimit),
+		StorageLimit: 257,
+		Counter:      counter + 1,
+	}
+
+	if transfer.Fee, err = minimalFee(head.Hash, transfer); err != nil {
+		return "", 0, errors.Wrap(err, "failed to fund payout wallet")
+	}
+
+	if available := int64(balance) - transfer.Fee; transfer.Amount > available {
+		logrus.WithFields(logrus.Fields{"cycle": p.cycle, "earned": transfer.Amount, "balance": balance}).Warn("Baker holds less than it earned in cycle, funding payout wallet with its balance.")
+		transfer.Amount = available
+	}
+	if transfer.Amount <= 0 {
+		logrus.WithFields(logrus.Fields{"cycle": p.cycle, "balance": balance}).Warn("Nothing to fund payout wallet with.")
+		return "", 0, nil
+	}
+
+	key, signer := p.key, p.signer
+	p.key, p.signer = p.bakerKey, nil
+	ophash, err := p.signAndInject(head.Hash, rpc.Contents{transfer})
+	p.key, p.signer = key, signer
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to fund payout wallet")
+	}
+
+	if !p.confirmOperation(ophash) {
+		return ophash, 0, errors.Errorf("failed to fund payout wallet: failed to confirm operation '%s'", ophash)
+	}
+
+	if p.config.Operations.Confirmations > 0 {
+		if err := p.awaitConfirmations([]string{ophash}); err != nil {
+			return ophash, 0, errors.Wrap(err, "failed to fund payout wallet")
+		}
+	}
+
+	if p.store != nil {
+		entry := FundingEntry{Amount: int(transfer.Amount), Operation: ophash, Time: time.Now().UTC()}
+		if err := p.store.Put(fundingBucket(baker), fundingKey(p.cycle), entry); err != nil {
+			return ophash, 0, errors.Wrap(err, "failed to record funding of payout wallet")
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{"cycle": p.cycle, "amount": transfer.Amount, "operation": ophash}).Info("Funded payout wallet from baker.")
+	return ophash, int(transfer.Amount), nil
+}
+
+// earnings returns what the baker earned in the cycle, without the rewards of missed rights
+func (p *Payout) earnings(rewardsSplit tzkt.RewardsSplit) int {
+	earned := Payout{config: p.config}
+	earned.config.Baker.EarningsOnly = true
+
+	return earned.calculateTotals(rewardsSplit)
+}
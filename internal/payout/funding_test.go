@@ -0,0 +1,167 @@
+package payout
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_fund(t *testing.T) {
+	bakerKey, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	seed := sha256.Sum256([]byte("tzpay wallet"))
+	walletKey, err := keys.NewKey(keys.NewKeyInput{Bytes: seed[:], Kind: keys.Ed25519})
+	assert.Nil(t, err)
+
+	baker := bakerKey.PubKey.GetPublicKeyHash()
+	earned := tzkt.RewardsSplit{OwnBlockRewards: 1000000, OwnBlockFees: 3000, MissedOwnBlockRewards: 2000000}
+
+	type want struct {
+		err       bool
+		contains  string
+		operation string
+		funded    int
+	}
+
+	cases := []struct {
+		name         string
+		baker        string
+		walletKey    keys.Key
+		rpc          rpc.IFace
+		rewardsSplit tzkt.RewardsSplit
+		want         want
+	}{
+		{
+			"handles key of another baker",
+			"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+			walletKey,
+			&test.RPCMock{},
+			earned,
+			want{true, "is not the key of baker 'tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc'", "", 0},
+		},
+		{
+			"skips payout wallet that is the baker",
+			baker,
+			bakerKey,
+			&test.RPCMock{},
+			earned,
+			want{false, "", "", 0},
+		},
+		{
+			"handles failure to get balance",
+			baker,
+			walletKey,
+			&test.RPCMock{BalanceErr: true},
+			earned,
+			want{true, "failed to get balance", "", 0},
+		},
+		{
+			"handles failure to inject",
+			baker,
+			walletKey,
+			&test.RPCMock{InjectionOperationErr: true},
+			earned,
+			want{true, "failed to inject operation", "", 0},
+		},
+		{
+			"transfers earnings of cycle",
+			baker,
+			walletKey,
+			&test.RPCMock{},
+			earned,
+			want{false, "", "ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M", 1003000},
+		},
+		{
+			"caps transfer to balance of baker",
+			baker,
+			walletKey,
+			&test.RPCMock{},
+			tzkt.RewardsSplit{OwnBlockRewards: 9000000},
+			want{false, "", "ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M", 4997020},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				rpc: tt.rpc,
+				config: config.Config{
+					Baker:      config.Baker{Address: tt.baker},
+					Operations: config.Operations{GasLimit: 26283},
+				},
+				key:      tt.walletKey,
+				bakerKey: bakerKey,
+				cycle:    10,
+			}
+
+			operation, funded, err := payout.fund(tt.rewardsSplit)
+			test.CheckErr(t, tt.want.err, tt.want.contains, err)
+			assert.Equal(t, tt.want.operation, operation)
+			assert.Equal(t, tt.want.funded, funded)
+			assert.Equal(t, tt.walletKey, payout.key, "the payout wallet key is restored")
+		})
+	}
+}
+
+func Test_fund_once(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-funding")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	bakerKey, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	seed := sha256.Sum256([]byte("tzpay wallet"))
+	walletKey, err := keys.NewKey(keys.NewKeyInput{Bytes: seed[:], Kind: keys.Ed25519})
+	assert.Nil(t, err)
+
+	payout := Payout{
+		rpc:   &test.RPCMock{},
+		store: s,
+		config: config.Config{
+			Baker:      config.Baker{Address: bakerKey.PubKey.GetPublicKeyHash()},
+			Operations: config.Operations{GasLimit: 26283},
+		},
+		key:      walletKey,
+		bakerKey: bakerKey,
+		cycle:    10,
+	}
+
+	operation, funded, err := payout.fund(tzkt.RewardsSplit{OwnBlockRewards: 1000000})
+	assert.Nil(t, err)
+	assert.Equal(t, "ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M", operation)
+	assert.Equal(t, 1000000, funded)
+
+	var entry FundingEntry
+	ok, err := s.Get(fundingBucket(payout.config.Baker.Address), fundingKey(10), &entry)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1000000, entry.Amount)
+
+	operation, funded, err = payout.fund(tzkt.RewardsSplit{OwnBlockRewards: 1000000})
+	assert.Nil(t, err)
+	assert.Equal(t, "", operation)
+	assert.Equal(t, 0, funded)
+}
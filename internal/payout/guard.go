@@ -0,0 +1,128 @@
+package payout
+
+import (
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+/*
+checkDuplicates refuses a payout if the payout wallet already sent any of its transfers since the end of the cycle,
+as seen by the indexer. Sending the same amount to the same destination is taken as proof the cycle was already
+paid, so that a wiped store or a restarted server can never pay a cycle twice. Transfers emitted by the disperse
+contract are matched through their initiator. The delegators paid by the chunks an interrupted payout confirmed are
+skipped before, as paid. The payout is refused as well while the indexer has not reached the end of the cycle, as it can't tell then. Partial
+payouts pay a cycle still in progress, and are not checked.
+*/
+func (p *Payout) checkDuplicates(delegators tzkt.Delegators) error {
+	if p.partial {
+		return nil
+	}
+
+	transfers := p.transfers(delegators)
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	_, last, err := CycleLevels(p.tzkt, p.cycle)
+	if err != nil {
+		return errors.Wrap(err, "failed to check for duplicate payouts")
+	}
+
+	head, err := p.tzkt.GetHead()
+	if err != nil {
+		return errors.Wrap(err, "failed to check for duplicate payouts")
+	}
+
+	if head.Level <= last {
+		return errors.Errorf("refusing to inject payout for cycle %d: the indexer is at level %d, before the end of the cycle at level %d",
+			p.cycle, head.Level, last)
+	}
+
+	wallet := p.Wallet()
+	start := strconv.Itoa(last + 1)
+
+	sent := map[disperseTransfer]string{}
+	for _, role := range []string{"sender", "initiator"} {
+		transactions, err := p.tzkt.GetTransactions(
+			tzkt.URLParameters{Key: role, Value: wallet},
+			tzkt.URLParameters{Key: "level.ge", Value: start},
+			tzkt.URLParameters{Key: "status", Value: "applied"},
+			tzkt.URLParameters{Key: "limit", Value: "10000"},
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to check for duplicate payouts")
+		}
+
+		for _, transaction := range transactions {
+			sent[disperseTransfer{Destination: transaction.Target.Address, Amount: int64(transaction.Amount)}] = transaction.Hash
+		}
+	}
+
+	var duplicates int
+	var example string
+	for _, transfer := range transfers {
+		if hash, ok := sent[transfer]; ok {
+			duplicates++
+			example = hash
+		}
+	}
+
+	if duplicates > 0 {
+		return errors.Errorf("refusing to inject payout for cycle %d: %d of %d transfers were already sent by '%s' (e.g. operation '%s')",
+			p.cycle, duplicates, len(transfers), wallet, example)
+	}
+
+	return nil
+}
+
+// errSpendingCap is returned for a payout that would inject more than the spending cap
+var errSpendingCap = errors.New("spending cap exceeded")
+
+// SpendingCapExceeded returns true if err is the refusal of a payout above the spending cap
+func SpendingCapExceeded(err error) bool {
+	return errors.Cause(err) == errSpendingCap
+}
+
+/*
+checkSpendingCap refuses a payout whose transfers, network fees included, would inject more than SpendingCap mutez
+in a single run, as a guard against a calculation bug or a tampered configuration draining the payout wallet.
+*/
+func (p *Payout) checkSpendingCap(delegators tzkt.Delegators) error {
+	transfers, cost, err := p.cost(delegators)
+	if err != nil {
+		return errors.Wrap(err, "failed to check spending cap")
+	}
+
+	if cost > p.config.Operations.SpendingCap {
+		return errors.Wrapf(errSpendingCap, "refusing to inject payout for cycle %d: %d transfers cost %d mutez, above the cap of %d mutez",
+			p.cycle, transfers, cost, p.config.Operations.SpendingCap)
+	}
+
+	return nil
+}
+
+// transfers returns the transfers a payout makes to delegators and liquidity providers
+func (p *Payout) transfers(delegators tzkt.Delegators) []disperseTransfer {
+	var transfers []disperseTransfer
+	for _, delegation := range delegators {
+		if delegation.LiquidityProviders != nil {
+			for _, liquidityProvider := range delegation.LiquidityProviders {
+				if !liquidityProvider.BlackListed {
+					transfers = append(transfers, disperseTransfer{
+						Destination: p.config.Baker.PayoutAddress(liquidityProvider.Address),
+						Amount:      int64(liquidityProvider.NetRewards),
+					})
+				}
+			}
+		} else if !delegation.BlackListed && !delegation.Accumulated {
+			transfers = append(transfers, disperseTransfer{
+				Destination: p.destination(delegation),
+				Amount:      int64(delegation.NetRewards),
+			})
+		}
+	}
+
+	return transfers
+}
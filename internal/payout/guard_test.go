@@ -0,0 +1,150 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkDuplicates(t *testing.T) {
+	sent := tzkt.Transaction{Hash: "ooSentBefore", Amount: 900000}
+	sent.Target.Address = "tz1cold"
+
+	other := tzkt.Transaction{Hash: "ooOther", Amount: 900001}
+	other.Target.Address = "tz1cold"
+
+	type input struct {
+		rpc     test.RPCMock
+		tzkt    test.TzktMock
+		partial bool
+	}
+
+	cases := []struct {
+		name        string
+		input       input
+		err         bool
+		errContains string
+	}{
+		{
+			"refuses transfers already sent",
+			input{tzkt: test.TzktMock{Transactions: []tzkt.Transaction{other, sent}, HeadLevel: 30}},
+			true,
+			"refusing to inject payout for cycle 10: 1 of 2 transfers were already sent by 'tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo' (e.g. operation 'ooSentBefore')",
+		},
+		{
+			"allows new transfers",
+			input{tzkt: test.TzktMock{Transactions: []tzkt.Transaction{other}, HeadLevel: 30}},
+			false,
+			"",
+		},
+		{
+			"handles failure to get transactions",
+			input{tzkt: test.TzktMock{TransactionsErr: true, HeadLevel: 30}},
+			true,
+			"failed to check for duplicate payouts",
+		},
+		{
+			"handles failure to get levels of cycle",
+			input{tzkt: test.TzktMock{CycleErr: true, HeadLevel: 30}},
+			true,
+			"failed to check for duplicate payouts: failed to get levels of cycle 10",
+		},
+		{
+			"handles failure to get head of indexer",
+			input{tzkt: test.TzktMock{HeadErr: true}},
+			true,
+			"failed to check for duplicate payouts",
+		},
+		{
+			"refuses a cycle the indexer has not seen the end of",
+			input{tzkt: test.TzktMock{HeadLevel: 22}},
+			true,
+			"refusing to inject payout for cycle 10: the indexer is at level 22, before the end of the cycle at level 22",
+		},
+		{
+			"skips partial payouts of the cycle in progress",
+			input{tzkt: test.TzktMock{Transactions: []tzkt.Transaction{sent}, HeadLevel: 22}, partial: true},
+			false,
+			"",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.NewKey(keys.NewKeyInput{
+				Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+				Password: "password12345##",
+				Kind:     keys.Ed25519,
+			})
+			assert.Nil(t, err)
+
+			payout := Payout{
+				config: config.Config{
+					Baker: config.Baker{PayoutAddresses: []string{"tz1a:tz1cold"}},
+				},
+				rpc:     &tt.input.rpc,
+				tzkt:    &tt.input.tzkt,
+				key:     key,
+				cycle:   10,
+				partial: tt.input.partial,
+			}
+
+			err = payout.checkDuplicates(tzkt.Delegators{
+				{Address: "tz1a", NetRewards: 900000},
+				{Address: "tz1b", NetRewards: 500000},
+				{Address: "tz1c", NetRewards: 100, BlackListed: true},
+			})
+			test.CheckErr(t, tt.err, tt.errContains, err)
+		})
+	}
+}
+
+func Test_checkSpendingCap(t *testing.T) {
+	delegators := tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 900000},
+		{Address: "tz1b", NetRewards: 500000},
+		{Address: "tz1c", NetRewards: 100, BlackListed: true},
+	}
+
+	cases := []struct {
+		name        string
+		cap         int64
+		err         bool
+		errContains string
+	}{
+		{
+			"allows a payout up to the cap",
+			1402000,
+			false,
+			"",
+		},
+		{
+			"refuses a payout above the cap",
+			1401999,
+			true,
+			"refusing to inject payout for cycle 10: 2 transfers cost 1402000 mutez, above the cap of 1401999 mutez",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Operations: config.Operations{NetworkFee: 1000, SpendingCap: tt.cap},
+				},
+				cycle: 10,
+			}
+
+			err := payout.checkSpendingCap(delegators)
+			test.CheckErr(t, tt.err, tt.errContains, err)
+			assert.Equal(t, tt.err, SpendingCapExceeded(err))
+
+			_, err = payout.prepareTransfers(tzkt.RewardsSplit{Delegators: delegators})
+			assert.Equal(t, tt.err, SpendingCapExceeded(err))
+		})
+	}
+}
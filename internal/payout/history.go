@@ -0,0 +1,145 @@
+package payout
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+// Payment is what a delegator was paid for a cycle, with the operations of the payout of the cycle
+type Payment struct {
+	Cycle      int       `json:"cycle"`
+	Amount     int       `json:"amount"`
+	Partial    int       `json:"partial,omitempty"` // paid by partial payouts, included in Amount
+	Time       time.Time `json:"time,omitempty"`
+	Operations []string  `json:"operations,omitempty"`
+}
+
+// DelegatorHistory is every payment made by a baker to a delegator, oldest first, and their total
+type DelegatorHistory struct {
+	Baker     string    `json:"baker"`
+	Delegator string    `json:"delegator"`
+	Payments  []Payment `json:"payments"`
+	Total     int       `json:"total"`
+}
+
+/*
+History returns every payment the paid ledger and the partial payouts in s record baker made to delegator from
+fromCycle on. The operations of a payment are those of the payout recorded for its cycle, which may include operations
+that paid other delegators.
+*/
+func History(s store.IFace, baker, delegator string, fromCycle int) (DelegatorHistory, error) {
+	history := DelegatorHistory{Baker: baker, Delegator: delegator, Payments: []Payment{}}
+
+	payments := map[int]*Payment{}
+	payment := func(cycle int) *Payment {
+		if _, ok := payments[cycle]; !ok {
+			payments[cycle] = &Payment{Cycle: cycle}
+		}
+		return payments[cycle]
+	}
+
+	paid, err := delegatorKeys(s, paidBucket(baker), delegator, fromCycle)
+	if err != nil {
+		return history, errors.Wrapf(err, "failed to get payment history of '%s'", delegator)
+	}
+	for cycle, key := range paid {
+		var entry PaidEntry
+		if _, err := s.Get(paidBucket(baker), key, &entry); err != nil {
+			return history, errors.Wrapf(err, "failed to get payment history of '%s'", delegator)
+		}
+		payment(cycle).Amount += entry.Amount
+		payment(cycle).Time = entry.Time
+	}
+
+	partials, err := delegatorKeys(s, partialBucket(baker), delegator, fromCycle)
+	if err != nil {
+		return history, errors.Wrapf(err, "failed to get payment history of '%s'", delegator)
+	}
+	for cycle, key := range partials {
+		var entry PartialEntry
+		if _, err := s.Get(partialBucket(baker), key, &entry); err != nil {
+			return history, errors.Wrapf(err, "failed to get payment history of '%s'", delegator)
+		}
+		if entry.Paid != 0 {
+			payment(cycle).Amount += entry.Paid
+			payment(cycle).Partial = entry.Paid
+		}
+	}
+
+	records, err := Records(s, baker)
+	if err != nil {
+		return history, errors.Wrapf(err, "failed to get payment history of '%s'", delegator)
+	}
+	for _, record := range records {
+		if p, ok := payments[record.Cycle]; ok && len(record.Operations) > 0 {
+			p.Operations = append(p.Operations, record.Operations...)
+			if p.Time.IsZero() {
+				p.Time = record.Time
+			}
+		}
+	}
+
+	for _, p := range payments {
+		history.Payments = append(history.Payments, *p)
+		history.Total += p.Amount
+	}
+	sort.Slice(history.Payments, func(i, j int) bool {
+		return history.Payments[i].Cycle < history.Payments[j].Cycle
+	})
+
+	return history, nil
+}
+
+// PaidDelegators returns every delegator the paid ledger and the partial payouts in s record baker paid, sorted
+func PaidDelegators(s store.IFace, baker string) ([]string, error) {
+	delegators := map[string]bool{}
+	for _, bucket := range []string{paidBucket(baker), partialBucket(baker)} {
+		keys, err := s.Keys(bucket)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get delegators paid")
+		}
+
+		for _, key := range keys {
+			if parts := strings.SplitN(key, "/", 2); len(parts) == 2 {
+				delegators[parts[1]] = true
+			}
+		}
+	}
+
+	paid := []string{}
+	for delegator := range delegators {
+		paid = append(paid, delegator)
+	}
+	sort.Strings(paid)
+
+	return paid, nil
+}
+
+// delegatorKeys returns the keys of bucket for delegator by cycle, from fromCycle on
+func delegatorKeys(s store.IFace, bucket, delegator string, fromCycle int) (map[int]string, error) {
+	keys, err := s.Keys(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	cycles := map[int]string{}
+	for _, key := range keys {
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) != 2 || parts[1] != delegator {
+			continue
+		}
+
+		cycle, err := strconv.Atoi(parts[0])
+		if err != nil || cycle < fromCycle {
+			continue
+		}
+		cycles[cycle] = key
+	}
+
+	return cycles, nil
+}
@@ -0,0 +1,73 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_History(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	baker := "tz1baker"
+	history, err := History(s, baker, "tz1delegator", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, DelegatorHistory{Baker: baker, Delegator: "tz1delegator", Payments: []Payment{}}, history)
+
+	paidAt := time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1delegator"), PaidEntry{Amount: 1000, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1other"), PaidEntry{Amount: 5000, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(271, "tz1delegator"), PaidEntry{Amount: 2000, Time: paidAt.Add(72 * time.Hour)}))
+	assert.Nil(t, s.Put(partialBucket(baker), paidKey(271, "tz1delegator"), PartialEntry{Paid: 500}))
+	assert.Nil(t, s.Put(partialBucket(baker), paidKey(272, "tz1delegator"), PartialEntry{Paid: 300}))
+	assert.Nil(t, s.Put(paidBucket("tz1otherbaker"), paidKey(272, "tz1delegator"), PaidEntry{Amount: 7000}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000270/2020-09-01T10:00:00Z", Record{Cycle: 270, Time: paidAt, Operations: []string{"oo1"}}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000272/2020-09-07T10:00:00Z", Record{Cycle: 272, Time: paidAt.Add(144 * time.Hour), Operations: []string{"oo2"}}))
+
+	history, err = History(s, baker, "tz1delegator", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []Payment{
+		{Cycle: 270, Amount: 1000, Time: paidAt, Operations: []string{"oo1"}},
+		{Cycle: 271, Amount: 2500, Partial: 500, Time: paidAt.Add(72 * time.Hour)},
+		{Cycle: 272, Amount: 300, Partial: 300, Time: paidAt.Add(144 * time.Hour), Operations: []string{"oo2"}},
+	}, history.Payments)
+	assert.Equal(t, 3800, history.Total)
+
+	history, err = History(s, baker, "tz1delegator", 271)
+	assert.Nil(t, err)
+	assert.Len(t, history.Payments, 2)
+	assert.Equal(t, 2800, history.Total)
+}
+
+func Test_PaidDelegators(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-history")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	baker := "tz1baker"
+	delegators, err := PaidDelegators(s, baker)
+	assert.Nil(t, err)
+	assert.Empty(t, delegators)
+
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1b"), PaidEntry{Amount: 1000}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(271, "tz1b"), PaidEntry{Amount: 1000}))
+	assert.Nil(t, s.Put(partialBucket(baker), paidKey(272, "tz1a"), PartialEntry{Paid: 300}))
+	assert.Nil(t, s.Put(paidBucket("tz1otherbaker"), paidKey(272, "tz1c"), PaidEntry{Amount: 7000}))
+
+	delegators, err = PaidDelegators(s, baker)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tz1a", "tz1b"}, delegators)
+}
@@ -0,0 +1,209 @@
+package payout
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+InclusionWatcher follows the operations injected by payouts on every block the server handles, rather than each payout polling
+the node for its own. An operation not included within BumpAfterBlocks blocks of its last injection is re-forged
+against the head with each fee raised by BumpIncrement and re-injected, at most BumpAttempts times. Operations stay
+watched whatever the cycle the server is in, so that a payout stuck in the mempool keeps being bumped while the
+payouts of the next cycles are queued.
+*/
+type InclusionWatcher struct {
+	rpc     rpc.IFace
+	mu      sync.Mutex
+	watched []*watched
+	// level is the last level checked for the operations watched
+	level int
+}
+
+// watched is an operation of a payout waiting to be included, and every version of it injected so far
+type watched struct {
+	payout   *Payout
+	contents rpc.Contents
+	ophashes []string
+	// level is the level of the head the last version was injected at
+	level    int
+	attempts int
+	done     chan watchResult
+}
+
+type watchResult struct {
+	ophash string
+	err    error
+}
+
+// NewInclusionWatcher returns a watcher looking for the operations watched in the blocks of rpc
+func NewInclusionWatcher(rpc rpc.IFace) *InclusionWatcher {
+	return &InclusionWatcher{rpc: rpc}
+}
+
+// SetInclusionWatcher hands the operations of the payout to watcher to wait for their inclusion and bump their fees
+func (p *Payout) SetInclusionWatcher(watcher *InclusionWatcher) {
+	p.watcher = watcher
+}
+
+// watch follows contents, injected as ophash with the head at level, until one of its versions is included
+func (w *InclusionWatcher) watch(p *Payout, contents rpc.Contents, ophash string, level int) <-chan watchResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.level == 0 || w.level > level {
+		w.level = level
+	}
+
+	done := make(chan watchResult, 1)
+	w.watched = append(w.watched, &watched{
+		payout:   p,
+		contents: contents,
+		ophashes: []string{ophash},
+		level:    level,
+		done:     done,
+	})
+
+	return done
+}
+
+// unwatch stops following the operation that reports to done, e.g. once its payout was interrupted
+func (w *InclusionWatcher) unwatch(done <-chan watchResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, o := range w.watched {
+		if o.done == done {
+			w.watched = append(w.watched[:i], w.watched[i+1:]...)
+			return
+		}
+	}
+}
+
+// Watching returns the number of operations waiting to be included
+func (w *InclusionWatcher) Watching() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.watched)
+}
+
+/*
+Block checks the operations watched against the blocks baked since the last call, up to block, the new head. Blocks
+are checked by level so that a head the server missed does not hide an inclusion. Operations left out of every block
+are then bumped once BumpAfterBlocks blocks passed since they were last injected, or given up on after BumpAttempts.
+*/
+func (w *InclusionWatcher) Block(block *rpc.Block) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.watched) == 0 {
+		w.level = block.Header.Level
+		return
+	}
+
+	from := w.level + 1
+	if from <= block.Header.Level-maxOperationsTTL {
+		from = block.Header.Level - maxOperationsTTL + 1
+	}
+	for level := from; level <= block.Header.Level; level++ {
+		included, err := w.rpc.OperationHashes(strconv.Itoa(level))
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"error": err.Error(), "level": level}).Warn("Failed to check block for watched operations.")
+			return
+		}
+		w.level = level
+		w.included(included)
+	}
+
+	var watching []*watched
+	for _, o := range w.watched {
+		if block.Header.Level-o.level < o.payout.config.Operations.BumpAfterBlocks {
+			watching = append(watching, o)
+			continue
+		}
+
+		if o.attempts == o.payout.config.Operations.BumpAttempts {
+			o.done <- watchResult{err: errors.Errorf("failed to inject operation: operation not included after %d fee bumps", o.attempts)}
+			continue
+		}
+
+		o.attempts++
+		o.level = block.Header.Level
+		o.contents = bumpFees(o.contents, int64(o.payout.config.Operations.BumpIncrement))
+		logrus.WithFields(logrus.Fields{
+			"cycle":   o.payout.cycle,
+			"baker":   o.payout.config.Baker.Address,
+			"hashes":  o.ophashes,
+			"attempt": o.attempts,
+			"blocks":  o.payout.config.Operations.BumpAfterBlocks,
+		}).Warn("Operation not included in time, re-injecting with a higher fee.")
+
+		if ophash, err := o.payout.signAndInject(block.Hash, o.contents); err != nil {
+			logrus.WithFields(logrus.Fields{"error": err.Error(), "attempt": o.attempts}).Warn("Failed to inject operation with bumped fee, still waiting on previous injections.")
+		} else {
+			o.ophashes = append(o.ophashes, ophash)
+		}
+		watching = append(watching, o)
+	}
+	w.watched = watching
+}
+
+// included completes the operations watched with a version among the operation hashes of a block
+func (w *InclusionWatcher) included(block [][]string) {
+	var watching []*watched
+	for _, o := range w.watched {
+		if ophash, ok := includes(block, o.ophashes); ok {
+			o.done <- watchResult{ophash: ophash}
+			continue
+		}
+		watching = append(watching, o)
+	}
+	w.watched = watching
+}
+
+// includes returns the first of ophashes among the operation hashes of a block
+func includes(block [][]string, ophashes []string) (string, bool) {
+	for _, pass := range block {
+		for _, in := range pass {
+			for _, ophash := range ophashes {
+				if in == ophash {
+					return ophash, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// watchInclusion injects contents and waits for the watcher of the payout to see one of its versions included
+func (p *Payout) watchInclusion(contents rpc.Contents) (string, error) {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to inject operation")
+	}
+
+	ophash, err := p.signAndInject(head.Hash, contents)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to inject operation")
+	}
+
+	var cancelled <-chan struct{}
+	if p.ctx != nil {
+		cancelled = p.ctx.Done()
+	}
+
+	done := p.watcher.watch(p, contents, ophash, head.Header.Level)
+	select {
+	case result := <-done:
+		return result.ophash, result.err
+	case <-cancelled:
+		p.watcher.unwatch(done)
+		return "", errors.Wrap(p.ctx.Err(), "failed to inject operation")
+	}
+}
@@ -0,0 +1,126 @@
+package payout
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// inclusionRPCMock includes the n-th injection in the block at level includeAt
+type inclusionRPCMock struct {
+	test.RPCMock
+	mu        sync.Mutex
+	injected  []string
+	include   int
+	includeAt int
+}
+
+func (i *inclusionRPCMock) Head() (*rpc.Block, error) {
+	block := &rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}
+	block.Header.Level = 100
+	return block, nil
+}
+
+func (i *inclusionRPCMock) InjectionOperation(input rpc.InjectionOperationInput) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.injected = append(i.injected, input.Operation)
+	return fmt.Sprintf("oo%d", len(i.injected)), nil
+}
+
+func (i *inclusionRPCMock) OperationHashes(blockhash string) ([][]string, error) {
+	if level, _ := strconv.Atoi(blockhash); i.include > 0 && level == i.includeAt {
+		return [][]string{{}, {}, {}, {"oo0", fmt.Sprintf("oo%d", i.include)}}, nil
+	}
+	return [][]string{}, nil
+}
+
+func Test_InclusionWatcher(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	contents := rpc.Contents{
+		{Kind: rpc.TRANSACTION, Source: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000, Counter: 101, Fee: 2941, GasLimit: 26283},
+	}
+
+	cases := []struct {
+		name        string
+		include     int
+		includeAt   int
+		err         bool
+		errContains string
+		ophash      string
+		injections  int
+	}{
+		{"is successful without bump", 1, 101, false, "", "oo1", 1},
+		{"is successful after bump", 2, 104, false, "", "oo2", 2},
+		{"finds an inclusion in a block missed", 1, 102, false, "", "oo1", 1},
+		{"handles operation never included", 0, 0, true, "operation not included after 2 fee bumps", "", 3},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &inclusionRPCMock{include: tt.include, includeAt: tt.includeAt}
+			watcher := NewInclusionWatcher(mock)
+			payout := &Payout{
+				config: config.Config{
+					Operations: config.Operations{BumpAfterBlocks: 2, BumpAttempts: 2, BumpIncrement: 1000},
+				},
+				rpc:     mock,
+				key:     key,
+				watcher: watcher,
+			}
+
+			type result struct {
+				ophash string
+				err    error
+			}
+			done := make(chan result, 1)
+			go func() {
+				ophash, err := payout.injectWithFeeBumps(contents)
+				done <- result{ophash, err}
+			}()
+			for watcher.Watching() == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			var got result
+		blocks:
+			for level := 101; level < 120; level++ {
+				// The server may miss the head of a block, the next one still checking it
+				if level == 102 {
+					continue
+				}
+				block := &rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}
+				block.Header.Level = level
+				watcher.Block(block)
+
+				select {
+				case got = <-done:
+					break blocks
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+
+			test.CheckErr(t, tt.err, tt.errContains, got.err)
+			assert.Equal(t, tt.ophash, got.ophash)
+			assert.Len(t, mock.injected, tt.injections)
+			assert.Equal(t, 0, watcher.Watching())
+			if tt.injections > 1 {
+				assert.NotEqual(t, mock.injected[0], mock.injected[1])
+			}
+		})
+	}
+}
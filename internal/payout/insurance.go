@@ -0,0 +1,95 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// missedRights returns the percentage of the baking and endorsing rights of the cycle the baker missed
+func missedRights(rewardsSplit tzkt.RewardsSplit) float64 {
+	blocks, missedBlocks := rewardsSplit.OwnBlocks, rewardsSplit.MissedOwnBlocks
+	if rewardsSplit.RewardModel == RewardModelTenderbake {
+		blocks, missedBlocks = rewardsSplit.Blocks, rewardsSplit.MissedBlocks
+	}
+
+	rights := blocks + missedBlocks + rewardsSplit.Endorsements + rewardsSplit.MissedEndorsements
+	if rights == 0 {
+		return 0
+	}
+
+	return float64(missedBlocks+rewardsSplit.MissedEndorsements) / float64(rights) * 100
+}
+
+// shortfall returns the rewards and fees lost to missed rights that are not already paid to delegators
+func (p *Payout) shortfall(rewardsSplit tzkt.RewardsSplit) int {
+	if !p.config.Baker.EarningsOnly && !p.config.Baker.ActualRewards {
+		return 0 // missed rewards are already paid as if they were earned
+	}
+
+	if rewardsSplit.RewardModel == RewardModelTenderbake {
+		return rewardsSplit.MissedBlockRewards + rewardsSplit.MissedBlockFees + rewardsSplit.MissedEndorsementRewards
+	}
+
+	return rewardsSplit.MissedOwnBlockRewards + rewardsSplit.MissedOwnBlockFees + rewardsSplit.MissedEndorsementRewards
+}
+
+/*
+applyInsurance tops up the payouts of delegators to what they would have earned had the baker not missed any
+rights, if the baker missed more than the insured percentage of its rights. Only delegators paid this cycle are
+insured: blacklisted and accumulated delegations are left out.
+*/
+func (p *Payout) applyInsurance(rewardsSplit *tzkt.RewardsSplit, totalRewards int) {
+	if p.config.Insurance.Threshold <= 0 {
+		return
+	}
+
+	rewardsSplit.MissedRights = missedRights(*rewardsSplit)
+	if rewardsSplit.MissedRights <= p.config.Insurance.Threshold {
+		return
+	}
+
+	shortfall := p.shortfall(*rewardsSplit)
+	if shortfall == 0 {
+		return
+	}
+
+	for i, delegator := range rewardsSplit.Delegators {
+		if delegator.BlackListed || delegator.Accumulated || delegator.LiquidityProviders != nil {
+			continue
+		}
+
+		expected := p.round(exactRewards(delegator.Balance, rewardsSplit.StakingBalance, totalRewards+shortfall))
+		if insurance := (expected - p.fee(delegator.Address, expected)) - (delegator.GrossRewards - delegator.Fee); insurance > 0 {
+			rewardsSplit.Delegators[i].Insurance = insurance
+			rewardsSplit.InsurancePaid += insurance
+		}
+	}
+}
+
+// insured returns the delegators owed an insurance top up, with the top up as their rewards
+func insured(delegators tzkt.Delegators) tzkt.Delegators {
+	var topUps tzkt.Delegators
+	for _, delegator := range delegators {
+		if delegator.Insurance > 0 {
+			topUps = append(topUps, tzkt.Delegator{
+				Address:    delegator.Address,
+				NetRewards: delegator.Insurance,
+			})
+		}
+	}
+
+	return topUps
+}
+
+// payInsurance pays the insurance top ups from the insurance wallet
+func (p *Payout) payInsurance(delegators tzkt.Delegators) ([]string, error) {
+	topUps := insured(delegators)
+	if len(topUps) == 0 {
+		return []string{}, nil
+	}
+
+	key, signer := p.key, p.signer
+	p.key, p.signer = p.insuranceKey, nil
+	defer func() { p.key, p.signer = key, signer }()
+
+	return p.applyFunc(topUps)
+}
@@ -0,0 +1,132 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_missedRights(t *testing.T) {
+	assert.Equal(t, float64(0), missedRights(tzkt.RewardsSplit{}))
+	assert.Equal(t, float64(25), missedRights(tzkt.RewardsSplit{
+		OwnBlocks:          2,
+		MissedOwnBlocks:    1,
+		Endorsements:       28,
+		MissedEndorsements: 9,
+	}))
+	assert.Equal(t, float64(10), missedRights(tzkt.RewardsSplit{
+		RewardModel:        RewardModelTenderbake,
+		OwnBlocks:          2,
+		Blocks:             4,
+		MissedBlocks:       1,
+		Endorsements:       41,
+		MissedEndorsements: 4,
+	}))
+}
+
+func Test_applyInsurance(t *testing.T) {
+	type want struct {
+		insurance     []int
+		insurancePaid int
+	}
+
+	missed := tzkt.RewardsSplit{
+		StakingBalance:           100,
+		OwnBlocks:                1,
+		MissedOwnBlocks:          1,
+		MissedOwnBlockRewards:    1000,
+		MissedEndorsementRewards: 0,
+	}
+
+	cases := []struct {
+		name         string
+		insurance    config.Insurance
+		earningsOnly bool
+		rewardsSplit tzkt.RewardsSplit
+		want         want
+	}{
+		{
+			"tops up delegators to expected rewards",
+			config.Insurance{Threshold: 10},
+			true,
+			missed,
+			want{
+				insurance:     []int{450, 0},
+				insurancePaid: 450,
+			},
+		},
+		{
+			"does nothing below threshold",
+			config.Insurance{Threshold: 60},
+			true,
+			missed,
+			want{
+				insurance: []int{0, 0},
+			},
+		},
+		{
+			"does nothing if missed rewards are already paid",
+			config.Insurance{Threshold: 10},
+			false,
+			missed,
+			want{
+				insurance: []int{0, 0},
+			},
+		},
+		{
+			"does nothing if disabled",
+			config.Insurance{},
+			true,
+			missed,
+			want{
+				insurance: []int{0, 0},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Baker: config.Baker{
+						Fee:          0.1,
+						EarningsOnly: tt.earningsOnly,
+					},
+					Insurance: tt.insurance,
+				},
+			}
+
+			rewardsSplit := tt.rewardsSplit
+			rewardsSplit.Delegators = tzkt.Delegators{
+				{Address: "tz1a", Balance: 50, GrossRewards: 500, Fee: 50, NetRewards: 450},
+				{Address: "tz1b", Balance: 50, GrossRewards: 500, Fee: 50, NetRewards: 450, BlackListed: true},
+			}
+
+			payout.applyInsurance(&rewardsSplit, 1000)
+			for i, delegator := range rewardsSplit.Delegators {
+				assert.Equal(t, tt.want.insurance[i], delegator.Insurance)
+			}
+			assert.Equal(t, tt.want.insurancePaid, rewardsSplit.InsurancePaid)
+		})
+	}
+}
+
+func Test_payInsurance(t *testing.T) {
+	var paid tzkt.Delegators
+	payout := Payout{
+		applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+			paid = delegators
+			return []string{"ophash"}, nil
+		},
+	}
+
+	operations, err := payout.payInsurance(tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 450, Insurance: 450},
+		{Address: "tz1b", NetRewards: 450},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ophash"}, operations)
+	assert.Equal(t, tzkt.Delegators{{Address: "tz1a", NetRewards: 450}}, paid)
+}
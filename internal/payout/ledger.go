@@ -0,0 +1,107 @@
+package payout
+
+import (
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// now is overridden in tests
+var now = time.Now
+
+// LedgerEntry holds the rewards of a delegator carried forward because they were below the accumulation threshold,
+// or because the payout schedule of the delegator has not elapsed yet
+type LedgerEntry struct {
+	Rewards map[int]int `json:"rewards"`         // net rewards by cycle
+	Since   time.Time   `json:"since,omitempty"` // when rewards were first carried forward
+}
+
+// total returns the rewards carried forward, excluding those of the cycle passed
+func (l LedgerEntry) total(exclude int) int {
+	var total int
+	for cycle, rewards := range l.Rewards {
+		if cycle != exclude {
+			total += rewards
+		}
+	}
+
+	return total
+}
+
+// usesLedger returns true if rewards of some delegators may be carried forward, including those held for contracts
+// refusing transfers
+func (p *Payout) usesLedger() bool {
+	return p.config.Baker.AccumulateThreshold > 0 || len(p.config.Baker.PayoutSchedules) > 0 || p.checksContracts()
+}
+
+// scheduleElapsed returns true if the window of a payout schedule started at since has elapsed
+func scheduleElapsed(schedule string, since time.Time) bool {
+	if since.IsZero() {
+		return false // the window starts with the first rewards carried forward
+	}
+
+	switch schedule {
+	case config.ScheduleWeekly:
+		return !now().Before(since.AddDate(0, 0, 7))
+	case config.ScheduleMonthly:
+		return !now().Before(since.AddDate(0, 1, 0))
+	}
+
+	return true
+}
+
+func ledgerBucket(baker string) string {
+	return "ledger/" + baker
+}
+
+// accumulate adds the rewards carried forward for the delegator and marks it accumulated if the total is still below the threshold
+func (p *Payout) accumulate(delegator tzkt.Delegator) (tzkt.Delegator, error) {
+	var entry LedgerEntry
+	if _, err := p.store.Get(ledgerBucket(p.config.Baker.Address), delegator.Address, &entry); err != nil {
+		return delegator, errors.Wrapf(err, "failed to get ledger entry for '%s'", delegator.Address)
+	}
+
+	delegator.CarriedRewards = entry.total(p.cycle)
+	delegator.NetRewards += delegator.CarriedRewards
+	if delegator.NetRewards < p.config.Baker.AccumulateThreshold {
+		delegator.Accumulated = true
+	}
+
+	if schedule := p.config.Baker.Schedule(delegator.Address); schedule != "" && !scheduleElapsed(schedule, entry.Since) {
+		delegator.Accumulated = true
+	}
+
+	return delegator, nil
+}
+
+// updateLedger records the rewards of accumulated delegators and clears the ledger of those paid
+func (p *Payout) updateLedger(delegators tzkt.Delegators) error {
+	bucket := ledgerBucket(p.config.Baker.Address)
+	for _, delegator := range delegators {
+		if delegator.Accumulated {
+			var entry LedgerEntry
+			if _, err := p.store.Get(bucket, delegator.Address, &entry); err != nil {
+				return errors.Wrapf(err, "failed to get ledger entry for '%s'", delegator.Address)
+			}
+			if entry.Rewards == nil {
+				entry.Rewards = map[int]int{}
+			}
+			if entry.Since.IsZero() {
+				entry.Since = now().UTC()
+			}
+			entry.Rewards[p.cycle] = delegator.NetRewards - delegator.CarriedRewards
+
+			if err := p.store.Put(bucket, delegator.Address, entry); err != nil {
+				return errors.Wrapf(err, "failed to update ledger entry for '%s'", delegator.Address)
+			}
+		} else if delegator.CarriedRewards > 0 && !delegator.BlackListed {
+			if err := p.store.Delete(bucket, delegator.Address); err != nil {
+				return errors.Wrapf(err, "failed to clear ledger entry for '%s'", delegator.Address)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_accumulate(t *testing.T) {
+	type input struct {
+		ledger    *LedgerEntry
+		delegator tzkt.Delegator
+	}
+
+	cases := []struct {
+		name  string
+		input input
+		want  tzkt.Delegator
+	}{
+		{
+			"accumulates rewards below threshold",
+			input{
+				nil,
+				tzkt.Delegator{Address: "tz1a", NetRewards: 400},
+			},
+			tzkt.Delegator{Address: "tz1a", NetRewards: 400, Accumulated: true},
+		},
+		{
+			"pays carried rewards crossing threshold",
+			input{
+				&LedgerEntry{Rewards: map[int]int{8: 400, 9: 300}},
+				tzkt.Delegator{Address: "tz1a", NetRewards: 400},
+			},
+			tzkt.Delegator{Address: "tz1a", NetRewards: 1100, CarriedRewards: 700},
+		},
+		{
+			"does not carry rewards of the same cycle twice",
+			input{
+				&LedgerEntry{Rewards: map[int]int{9: 300, 10: 400}},
+				tzkt.Delegator{Address: "tz1a", NetRewards: 400},
+			},
+			tzkt.Delegator{Address: "tz1a", NetRewards: 700, CarriedRewards: 300, Accumulated: true},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tzpay-ledger")
+			assert.Nil(t, err)
+			defer os.RemoveAll(dir)
+
+			payout := newLedgerPayout(t, dir)
+			if tt.input.ledger != nil {
+				assert.Nil(t, payout.store.Put(ledgerBucket("tz1baker"), tt.input.delegator.Address, tt.input.ledger))
+			}
+
+			delegator, err := payout.accumulate(tt.input.delegator)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, delegator)
+		})
+	}
+}
+
+func Test_updateLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-ledger")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	payout := newLedgerPayout(t, dir)
+	bucket := ledgerBucket("tz1baker")
+	assert.Nil(t, payout.store.Put(bucket, "tz1paid", LedgerEntry{Rewards: map[int]int{9: 700}}))
+	assert.Nil(t, payout.store.Put(bucket, "tz1accumulated", LedgerEntry{Rewards: map[int]int{9: 100}}))
+
+	err = payout.updateLedger(tzkt.Delegators{
+		{Address: "tz1paid", NetRewards: 1100, CarriedRewards: 700},
+		{Address: "tz1accumulated", NetRewards: 300, CarriedRewards: 100, Accumulated: true},
+		{Address: "tz1new", NetRewards: 50, Accumulated: true},
+	})
+	assert.Nil(t, err)
+
+	keys, err := payout.store.Keys(bucket)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tz1accumulated", "tz1new"}, keys)
+
+	var entry LedgerEntry
+	_, err = payout.store.Get(bucket, "tz1accumulated", &entry)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int]int{9: 100, 10: 200}, entry.Rewards)
+}
+
+func newLedgerPayout(t *testing.T, dir string) *Payout {
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	return &Payout{
+		config: config.Config{
+			Baker: config.Baker{
+				Address:             "tz1baker",
+				AccumulateThreshold: 1000,
+			},
+		},
+		store: s,
+		cycle: 10,
+	}
+}
+
+func Test_scheduleElapsed(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name     string
+		schedule string
+		since    time.Time
+		want     bool
+	}{
+		{"starts window on first rewards", config.ScheduleWeekly, time.Time{}, false},
+		{"weekly within window", config.ScheduleWeekly, time.Date(2020, 3, 10, 0, 0, 0, 0, time.UTC), false},
+		{"weekly elapsed", config.ScheduleWeekly, time.Date(2020, 3, 8, 0, 0, 0, 0, time.UTC), true},
+		{"monthly within window", config.ScheduleMonthly, time.Date(2020, 2, 20, 0, 0, 0, 0, time.UTC), false},
+		{"monthly elapsed", config.ScheduleMonthly, time.Date(2020, 2, 15, 0, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, scheduleElapsed(tt.schedule, tt.since))
+		})
+	}
+}
+
+func Test_accumulateSchedule(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC) }
+
+	dir, err := ioutil.TempDir("", "tzpay-ledger")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	payout := newLedgerPayout(t, dir)
+	payout.config.Baker.AccumulateThreshold = 0
+	payout.config.Baker.PayoutSchedules = []string{"tz1weekly:weekly", "tz1monthly:monthly"}
+
+	bucket := ledgerBucket("tz1baker")
+	since := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, payout.store.Put(bucket, "tz1weekly", LedgerEntry{Rewards: map[int]int{9: 100}, Since: since}))
+	assert.Nil(t, payout.store.Put(bucket, "tz1monthly", LedgerEntry{Rewards: map[int]int{9: 100}, Since: since}))
+
+	delegator, err := payout.accumulate(tzkt.Delegator{Address: "tz1weekly", NetRewards: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, tzkt.Delegator{Address: "tz1weekly", NetRewards: 200, CarriedRewards: 100}, delegator)
+
+	delegator, err = payout.accumulate(tzkt.Delegator{Address: "tz1monthly", NetRewards: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, tzkt.Delegator{Address: "tz1monthly", NetRewards: 200, CarriedRewards: 100, Accumulated: true}, delegator)
+
+	delegator, err = payout.accumulate(tzkt.Delegator{Address: "tz1other", NetRewards: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, tzkt.Delegator{Address: "tz1other", NetRewards: 100}, delegator)
+
+	assert.Nil(t, payout.updateLedger(tzkt.Delegators{{Address: "tz1new", NetRewards: 50, Accumulated: true}}))
+	var entry LedgerEntry
+	_, err = payout.store.Get(bucket, "tz1new", &entry)
+	assert.Nil(t, err)
+	assert.Equal(t, now(), entry.Since)
+}
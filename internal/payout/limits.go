@@ -0,0 +1,96 @@
+package payout
+
+import (
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+)
+
+const branchSize = 32
+
+/*
+splitWithinLimits splits a batch of transactions into as many operations as needed for each of them to fit the
+protocol's max_operation_data_length and hard_gas_limit_per_block. Transactions keep their order, so the counters
+stay sequential as long as the operations are injected in order. A limit of 0 is not enforced.
+*/
+func splitWithinLimits(blockhash string, transactions rpc.Contents, constants rpc.Constants) ([]rpc.Contents, error) {
+	if len(transactions) == 0 {
+		return []rpc.Contents{transactions}, nil
+	}
+
+	var operations []rpc.Contents
+	var operation rpc.Contents
+	var size, gas int
+	for _, transaction := range transactions {
+		forged, err := forge.Encode(blockhash, transaction)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to forge transaction")
+		}
+		transactionSize := len(forged)/2 - branchSize
+
+		if constants.MaxOperationDataLength > 0 && branchSize+transactionSize+signatureSize > constants.MaxOperationDataLength {
+			return nil, errors.Errorf("failed to split batch: transaction to '%s' exceeds max_operation_data_length", transaction.Destination)
+		}
+		if constants.HardGasLimitPerBlock > 0 && int(transaction.GasLimit) > constants.HardGasLimitPerBlock {
+			return nil, errors.Errorf("failed to split batch: transaction to '%s' exceeds hard_gas_limit_per_block", transaction.Destination)
+		}
+
+		exceedsSize := constants.MaxOperationDataLength > 0 && branchSize+size+transactionSize+signatureSize > constants.MaxOperationDataLength
+		exceedsGas := constants.HardGasLimitPerBlock > 0 && gas+int(transaction.GasLimit) > constants.HardGasLimitPerBlock
+		if len(operation) > 0 && (exceedsSize || exceedsGas) {
+			operations = append(operations, operation)
+			operation, size, gas = nil, 0, 0
+		}
+
+		operation = append(operation, transaction)
+		size += transactionSize
+		gas += int(transaction.GasLimit)
+	}
+
+	return append(operations, operation), nil
+}
+
+// operationsWithinLimits splits transactions into as many operations as the protocol limits require, estimating their
+// gas, storage and fees if configured to
+func (p *Payout) operationsWithinLimits(head *rpc.Block, transactions rpc.Contents, constants rpc.Constants) ([]rpc.Contents, error) {
+	operations, err := splitWithinLimits(head.Hash, transactions, constants)
+	if err != nil {
+		return nil, err
+	}
+
+	if operations, err = p.withReveal(head.Hash, operations); err != nil {
+		return nil, err
+	}
+
+	return p.estimateOperations(head, operations, constants)
+}
+
+// estimateOperations estimates each operation if estimation is enabled
+func (p *Payout) estimateOperations(head *rpc.Block, operations []rpc.Contents, constants rpc.Constants) ([]rpc.Contents, error) {
+	if !p.config.Operations.Estimate {
+		return operations, nil
+	}
+
+	var err error
+	for i := range operations {
+		if operations[i], err = p.estimate(head, operations[i], constants); err != nil {
+			return nil, err
+		}
+	}
+
+	return operations, nil
+}
+
+// forgeOperations forges every operation against branch
+func forgeOperations(branch string, operations []rpc.Contents) ([]string, error) {
+	var forged []string
+	for _, contents := range operations {
+		operation, err := forge.Encode(branch, contents...)
+		if err != nil {
+			return nil, err
+		}
+		forged = append(forged, operation)
+	}
+
+	return forged, nil
+}
@@ -0,0 +1,93 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_splitWithinLimits(t *testing.T) {
+	branch := "BLzGD63HA4RP8Fh5xEtvdQSMKa2WzJMZjQPNVUc4Rqy8Lh5BEY1"
+	transactions := rpc.Contents{}
+	for i := 0; i < 5; i++ {
+		transactions = append(transactions, rpc.Content{
+			Kind:         rpc.TRANSACTION,
+			Source:       "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo",
+			Fee:          2941,
+			Counter:      101 + i,
+			GasLimit:     26283,
+			StorageLimit: 0,
+			Amount:       900000,
+			Destination:  "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+		})
+	}
+
+	forged, err := forge.Encode(branch, transactions[0])
+	assert.Nil(t, err)
+	size := len(forged)/2 - branchSize
+
+	cases := []struct {
+		name        string
+		constants   rpc.Constants
+		err         bool
+		errContains string
+		sizes       []int
+	}{
+		{
+			"does not split without limits",
+			rpc.Constants{},
+			false,
+			"",
+			[]int{5},
+		},
+		{
+			"splits by max_operation_data_length",
+			rpc.Constants{MaxOperationDataLength: branchSize + 2*size + signatureSize},
+			false,
+			"",
+			[]int{2, 2, 1},
+		},
+		{
+			"splits by hard_gas_limit_per_block",
+			rpc.Constants{HardGasLimitPerBlock: 3 * 26283},
+			false,
+			"",
+			[]int{3, 2},
+		},
+		{
+			"handles transaction over max_operation_data_length",
+			rpc.Constants{MaxOperationDataLength: branchSize + size},
+			true,
+			"exceeds max_operation_data_length",
+			nil,
+		},
+		{
+			"handles transaction over hard_gas_limit_per_block",
+			rpc.Constants{HardGasLimitPerBlock: 100},
+			true,
+			"exceeds hard_gas_limit_per_block",
+			nil,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			operations, err := splitWithinLimits(branch, transactions, tt.constants)
+			test.CheckErr(t, tt.err, tt.errContains, err)
+
+			var sizes []int
+			counter := 101
+			for _, operation := range operations {
+				sizes = append(sizes, len(operation))
+				for _, content := range operation {
+					assert.Equal(t, counter, content.Counter)
+					counter++
+				}
+			}
+			assert.Equal(t, tt.sizes, sizes)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package payout
+
+import (
+	"fmt"
+	"time"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+LowBalance is a payout wallet holding less than its next payout is projected to cost plus a buffer. Balance is what
+the wallet holds once the payout under way, if any, is made, and the next payout is projected to cost what the last
+one did.
+*/
+type LowBalance struct {
+	Baker     string `json:"baker"`
+	Wallet    string `json:"wallet"`
+	Balance   int64  `json:"balance"`
+	Projected int64  `json:"projected"`
+	Buffer    int64  `json:"buffer"`
+}
+
+// String returns the alert sent for the low balance
+func (l LowBalance) String() string {
+	return fmt.Sprintf("[TZPAY] ALERT payout wallet %s of %s holds %s XTZ, less than the %s XTZ its next payout is projected to cost plus a buffer of %s XTZ",
+		l.Wallet, l.Baker, tez(l.Balance), tez(l.Projected), tez(l.Buffer))
+}
+
+func tez(mutez int64) string {
+	return fmt.Sprintf("%.6f", float64(mutez)/float64(gotezos.MUTEZ))
+}
+
+// lowBalance returns the low balance of wallet if balance is below projected plus the buffer configured, or nil
+func lowBalance(cfg config.Config, wallet string, balance, projected int64) *LowBalance {
+	buffer := int64(cfg.Notifications.LowBalance.Buffer)
+	if balance >= projected+buffer {
+		return nil
+	}
+
+	return &LowBalance{
+		Baker:     cfg.Baker.Address,
+		Wallet:    wallet,
+		Balance:   balance,
+		Projected: projected,
+		Buffer:    buffer,
+	}
+}
+
+/*
+checkLowBalance alerts before the payout of delegators is injected if the payout wallet will be left with less than
+the payout costs plus the buffer, the next payout being projected to cost as much. It does not stop the payout, which
+checkBalance refuses if the wallet can not cover it, and failing to check is only logged.
+*/
+func (p *Payout) checkLowBalance(delegators tzkt.Delegators) {
+	logger := logrus.WithFields(logrus.Fields{"cycle": p.cycle, "baker": p.config.Baker.Address})
+
+	_, cost, err := p.cost(delegators)
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to check for low balance of payout wallet.")
+		return
+	}
+
+	balance, err := p.rpc.Balance(rpc.BalanceInput{
+		Blockhash: "head",
+		Address:   p.Wallet(),
+	})
+	if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to check for low balance of payout wallet.")
+		return
+	}
+
+	low := lowBalance(p.config, p.Wallet(), int64(balance)-cost, cost)
+	if low == nil {
+		return
+	}
+
+	logger.WithFields(logrus.Fields{"balance": low.Balance, "projected": low.Projected}).Warn("Payout wallet is low on funds for the next payout.")
+	if p.notifier != nil {
+		if err := p.notifier.NotifyKind(notifier.KindAlert, low.String()); err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to notify of low balance of payout wallet.")
+		}
+	}
+}
+
+// BalanceWatcherInput is the input for NewBalanceWatcher
+type BalanceWatcherInput struct {
+	RPC      rpc.IFace
+	Store    store.IFace
+	Config   config.Config
+	Wallet   string
+	Notifier *notifier.PayoutNotifier
+}
+
+/*
+BalanceWatcher alerts when the payout wallet of a baker holds less than its next payout is projected to cost plus the
+buffer configured, the projection being the amount of the last payout recorded. It alerts once, and again only
+after the balance recovered.
+*/
+type BalanceWatcher struct {
+	rpc      rpc.IFace
+	store    store.IFace
+	config   config.Config
+	wallet   string
+	notifier *notifier.PayoutNotifier
+	low      bool
+}
+
+// NewBalanceWatcher returns a new BalanceWatcher
+func NewBalanceWatcher(input BalanceWatcherInput) *BalanceWatcher {
+	return &BalanceWatcher{
+		rpc:      input.RPC,
+		store:    input.Store,
+		config:   input.Config,
+		wallet:   input.Wallet,
+		notifier: input.Notifier,
+	}
+}
+
+// Start checks the balance of the payout wallet every interval until the process exits
+func (w *BalanceWatcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for {
+			if _, err := w.Check(); err != nil {
+				logrus.WithFields(logrus.Fields{"error": err.Error(), "baker": w.config.Baker.Address}).Error("Failed to check balance of payout wallet.")
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// Check returns the low balance of the payout wallet, alerting of it unless it already did, or nil if it is not low
+func (w *BalanceWatcher) Check() (*LowBalance, error) {
+	records, err := Records(w.store, w.config.Baker.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var projected int64
+	for i := len(records) - 1; i >= 0 && projected == 0; i-- {
+		projected = records[i].Amount
+	}
+
+	balance, err := w.rpc.Balance(rpc.BalanceInput{
+		Blockhash: "head",
+		Address:   w.wallet,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get balance of payout wallet")
+	}
+
+	low := lowBalance(w.config, w.wallet, int64(balance), projected)
+	if low == nil || w.low {
+		w.low = low != nil
+		return low, nil
+	}
+
+	w.low = true
+	if err := w.notifier.NotifyKind(notifier.KindAlert, low.String()); err != nil {
+		w.low = false
+		return low, errors.Wrap(err, "failed to notify of low balance of payout wallet")
+	}
+
+	return low, nil
+}
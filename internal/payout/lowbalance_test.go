@@ -0,0 +1,64 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BalanceWatcher_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-lowbalance")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, s.Put(recordsBucket("tz1baker"), "00000270/a", Record{Cycle: 270, Amount: 4000000}))
+	assert.Nil(t, s.Put(recordsBucket("tz1baker"), "00000271/a", Record{Cycle: 271}))
+
+	client := &notifier.MockClient{}
+	payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{client}})
+	cfg := config.Config{
+		Baker:         config.Baker{Address: "tz1baker"},
+		Notifications: config.Notifications{LowBalance: config.LowBalance{Enabled: true, Buffer: 2000000}},
+	}
+	watcher := NewBalanceWatcher(BalanceWatcherInput{
+		RPC:      &test.RPCMock{},
+		Store:    s,
+		Config:   cfg,
+		Wallet:   "tz1wallet",
+		Notifier: &payoutNotifier,
+	})
+
+	// The wallet holds 5 XTZ, below the 4 XTZ of the last payout plus the 2 XTZ buffer
+	low, err := watcher.Check()
+	assert.Nil(t, err)
+	assert.Equal(t, &LowBalance{Baker: "tz1baker", Wallet: "tz1wallet", Balance: 5000000, Projected: 4000000, Buffer: 2000000}, low)
+
+	_, err = watcher.Check()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"[TZPAY] ALERT payout wallet tz1wallet of tz1baker holds 5.000000 XTZ, less than the 4.000000 XTZ its next payout is projected to cost plus a buffer of 2.000000 XTZ",
+	}, client.Messages)
+
+	// The alert is sent again once the balance recovered and fell again
+	watcher.config.Notifications.LowBalance.Buffer = 0
+	low, err = watcher.Check()
+	assert.Nil(t, err)
+	assert.Nil(t, low)
+	watcher.config.Notifications.LowBalance.Buffer = 2000000
+	_, err = watcher.Check()
+	assert.Nil(t, err)
+	assert.Len(t, client.Messages, 2)
+
+	watcher.rpc = &test.RPCMock{BalanceErr: true}
+	_, err = watcher.Check()
+	test.CheckErr(t, true, "failed to get balance of payout wallet", err)
+}
@@ -0,0 +1,134 @@
+package payout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// keyHashPrefixes maps the tag of a binary key_hash to its base58 prefix (tz1, tz2, tz3)
+var keyHashPrefixes = map[byte][]byte{
+	0: {6, 161, 159},
+	1: {6, 161, 161},
+	2: {6, 161, 164},
+}
+
+/*
+detectManagers looks up the manager of every KT1 delegation and records it on the delegations that share their
+manager with at least one other KT1, so that their payouts can be consolidated into a single transfer to the manager.
+Contracts whose storage is not a manager key hash (anything but the manager.tz script) are ignored.
+*/
+func (p *Payout) detectManagers(delegators tzkt.Delegators) error {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to detect managers")
+	}
+
+	contracts := map[string][]int{}
+	for i, delegator := range delegators {
+		if !strings.HasPrefix(delegator.Address, "KT1") {
+			continue
+		}
+
+		storage, err := p.rpc.ContractStorage(head.Hash, delegator.Address)
+		if err != nil {
+			return errors.Wrapf(err, "failed to detect manager of '%s'", delegator.Address)
+		}
+
+		if manager, ok := managerFromStorage(storage); ok {
+			contracts[manager] = append(contracts[manager], i)
+		}
+	}
+
+	for manager, indexes := range contracts {
+		if len(indexes) < 2 {
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"manager":     manager,
+			"delegations": len(indexes),
+		}).Info("Found delegations controlled by the same manager.")
+		for _, i := range indexes {
+			delegators[i].Manager = manager
+		}
+	}
+
+	return nil
+}
+
+/*
+consolidate folds the payable delegations that share a manager into a single delegation to the manager, taking the
+place of the first of them. Blacklisted and accumulated delegations are left as they are.
+*/
+func (p *Payout) consolidate(delegators tzkt.Delegators) tzkt.Delegators {
+	var consolidated tzkt.Delegators
+	managers := map[string]int{}
+	for _, delegator := range delegators {
+		if delegator.Manager == "" || delegator.BlackListed || delegator.Accumulated || delegator.LiquidityProviders != nil {
+			consolidated = append(consolidated, delegator)
+			continue
+		}
+
+		if i, ok := managers[delegator.Manager]; ok {
+			consolidated[i].Balance += delegator.Balance
+			consolidated[i].GrossRewards += delegator.GrossRewards
+			consolidated[i].Fee += delegator.Fee
+			consolidated[i].NetRewards += delegator.NetRewards
+			continue
+		}
+
+		managers[delegator.Manager] = len(consolidated)
+		consolidated = append(consolidated, tzkt.Delegator{
+			Address:      delegator.Manager,
+			Balance:      delegator.Balance,
+			GrossRewards: delegator.GrossRewards,
+			Fee:          delegator.Fee,
+			NetRewards:   delegator.NetRewards,
+		})
+	}
+
+	return consolidated
+}
+
+// managerFromStorage returns the manager of a manager.tz contract from its storage
+func managerFromStorage(storage []byte) (string, bool) {
+	var keyHash struct {
+		String string `json:"string"`
+		Bytes  string `json:"bytes"`
+	}
+	if err := json.Unmarshal(storage, &keyHash); err != nil {
+		return "", false
+	}
+
+	if strings.HasPrefix(keyHash.String, "tz") {
+		return keyHash.String, true
+	}
+
+	raw, err := hex.DecodeString(keyHash.Bytes)
+	if err != nil || len(raw) != 21 {
+		return "", false
+	}
+
+	return encodeKeyHash(raw[0], raw[1:])
+}
+
+// encodeKeyHash returns the base58check address of a 20 byte public key hash with the tag passed
+func encodeKeyHash(tag byte, hash []byte) (string, bool) {
+	prefix, ok := keyHashPrefixes[tag]
+	if !ok {
+		return "", false
+	}
+
+	payload := append(append([]byte{}, prefix...), hash...)
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+
+	return base58.Encode(append(payload, second[:4]...)), true
+}
@@ -0,0 +1,90 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_detectManagers(t *testing.T) {
+	type want struct {
+		err         bool
+		errContains string
+		managers    []string
+	}
+
+	delegators := tzkt.Delegators{
+		{Address: "KT1A"},
+		{Address: "KT1B"},
+		{Address: "KT1C"},
+		{Address: "KT1D"},
+		{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"},
+	}
+
+	storages := map[string]string{
+		"KT1A": `{"string":"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"}`,
+		"KT1B": `{"bytes":"00471c8882bcf12586e640b7efa46c6ea1e0f4da9e"}`,
+		"KT1C": `{"string":"tz1W3HW533csCBLor4NPtU79R2TT2sbKfJDH"}`,
+		"KT1D": `{"prim":"Unit"}`,
+	}
+
+	cases := []struct {
+		name  string
+		input test.RPCMock
+		want  want
+	}{
+		{
+			"records managers shared by several contracts",
+			test.RPCMock{ContractStorages: storages},
+			want{
+				managers: []string{
+					"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV",
+					"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV",
+					"",
+					"",
+					"",
+				},
+			},
+		},
+		{
+			"handles failure to get contract storage",
+			test.RPCMock{ContractStorageErr: true},
+			want{
+				err:         true,
+				errContains: "failed to detect manager of 'KT1A'",
+				managers:    []string{"", "", "", "", ""},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{rpc: &tt.input}
+
+			input := append(tzkt.Delegators{}, delegators...)
+			err := payout.detectManagers(input)
+			test.CheckErr(t, tt.want.err, tt.want.errContains, err)
+			for i, delegator := range input {
+				assert.Equal(t, tt.want.managers[i], delegator.Manager)
+			}
+		})
+	}
+}
+
+func Test_consolidate(t *testing.T) {
+	delegators := tzkt.Delegators{
+		{Address: "KT1A", Manager: "tz1M", Balance: 100, GrossRewards: 10, Fee: 1, NetRewards: 9},
+		{Address: "tz1X", Balance: 50, GrossRewards: 5, NetRewards: 5},
+		{Address: "KT1B", Manager: "tz1M", Balance: 200, GrossRewards: 20, Fee: 2, NetRewards: 18},
+		{Address: "KT1C", Manager: "tz1M", BlackListed: true, NetRewards: 1},
+	}
+
+	payout := Payout{}
+	assert.Equal(t, tzkt.Delegators{
+		{Address: "tz1M", Balance: 300, GrossRewards: 30, Fee: 3, NetRewards: 27},
+		{Address: "tz1X", Balance: 50, GrossRewards: 5, NetRewards: 5},
+		{Address: "KT1C", Manager: "tz1M", BlackListed: true, NetRewards: 1},
+	}, payout.consolidate(delegators))
+}
@@ -0,0 +1,74 @@
+package payout
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+/*
+Summary is what the notification of a payout is rendered from with the payout template. Operations are the hashes of
+the operations injected, Transfers and Amount what they paid, and Payout holds every field of the payout report.
+*/
+type Summary struct {
+	Baker      string
+	Wallet     string
+	Cycle      int
+	Partial    bool
+	Skipped    bool
+	Memo       string
+	Transfers  int
+	Amount     int
+	Operations []string
+	Payout     tzkt.RewardsSplit
+}
+
+// ParseNotificationTemplate parses text, the Go template of the notification of a payout
+func ParseNotificationTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("payout").Funcs(template.FuncMap{
+		"tez":  func(mutez int) string { return tez(int64(mutez)) },
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse payout notification template")
+	}
+
+	return tmpl, nil
+}
+
+// Notification renders the notification of the payout rewardsSplit with the payout template, or returns false without one
+func (p *Payout) Notification(rewardsSplit tzkt.RewardsSplit) (string, bool, error) {
+	if p.config.Notifications.Templates.Payout == "" {
+		return "", false, nil
+	}
+
+	tmpl, err := ParseNotificationTemplate(p.config.Notifications.Templates.Payout)
+	if err != nil {
+		return "", false, err
+	}
+
+	summary := Summary{
+		Baker:      p.config.Baker.Address,
+		Wallet:     p.Wallet(),
+		Cycle:      p.cycle,
+		Partial:    p.partial,
+		Skipped:    rewardsSplit.Skipped,
+		Memo:       rewardsSplit.Memo,
+		Operations: operationHashes(rewardsSplit.OperationLink),
+		Payout:     rewardsSplit,
+	}
+	for _, transfer := range p.transfers(rewardsSplit.Delegators) {
+		summary.Transfers++
+		summary.Amount += int(transfer.Amount)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, summary); err != nil {
+		return "", false, errors.Wrap(err, "failed to render payout notification")
+	}
+
+	return buf.String(), true, nil
+}
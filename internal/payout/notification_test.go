@@ -0,0 +1,54 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Notification(t *testing.T) {
+	rewardsSplit := tzkt.RewardsSplit{
+		Delegators: tzkt.Delegators{
+			{Address: "tz1a", NetRewards: 1500000},
+			{Address: "tz1b", NetRewards: 500000},
+			{Address: "tz1c", NetRewards: 100000, BlackListed: true},
+		},
+		OperationLink: []string{"https://tzkt.io/oo1", "https://tzkt.io/oo2"},
+		BakerRewards:  250000,
+	}
+
+	cases := []struct {
+		name     string
+		template string
+		err      bool
+		contains string
+		ok       bool
+		want     string
+	}{
+		{"is successful", `{{.Baker}} paid cycle {{.Cycle}}: {{.Transfers}} transfers, {{tez .Amount}} XTZ, kept {{tez .Payout.BakerRewards}} XTZ{{range .Operations}} https://explorer.example.com/{{.}}{{end}}`, false, "", true,
+			"tz1baker paid cycle 300: 2 transfers, 2.000000 XTZ, kept 0.250000 XTZ https://explorer.example.com/oo1 https://explorer.example.com/oo2"},
+		{"is successful without template", "", false, "", false, ""},
+		{"handles invalid template", "{{.Baker", true, "failed to parse payout notification template", false, ""},
+		{"handles template failing to render", "{{.Unknown}}", true, "failed to render payout notification", false, ""},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Payout{
+				config: config.Config{
+					Baker:         config.Baker{Address: "tz1baker"},
+					Notifications: config.Notifications{Templates: config.Templates{Payout: tt.template}},
+				},
+				cycle: 300,
+			}
+
+			msg, ok, err := p.Notification(rewardsSplit)
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, msg)
+		})
+	}
+}
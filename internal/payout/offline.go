@@ -0,0 +1,333 @@
+package payout
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// OfflinePayout is a payout forged on a machine without the key of the payout wallet, to be signed on one that holds it
+type OfflinePayout struct {
+	Cycle        int                `json:"cycle"`
+	Baker        string             `json:"baker"`
+	Wallet       string             `json:"wallet"`
+	Branch       string             `json:"branch"`
+	Summary      OfflineSummary     `json:"summary"`
+	Operations   []OfflineOperation `json:"operations"`
+	Delegators   tzkt.Delegators    `json:"delegators"`
+	RewardsSplit tzkt.RewardsSplit  `json:"rewards_split"`
+}
+
+// OfflineOperation is an operation of an offline payout, forged and, once signed, with its hex encoded signature
+type OfflineOperation struct {
+	Contents  rpc.Contents `json:"contents"`
+	Forged    string       `json:"forged"`
+	Signature string       `json:"signature,omitempty"`
+}
+
+// OfflineSummary sums up the transfers of an offline payout, to check them before signing
+type OfflineSummary struct {
+	Operations int   `json:"operations"`
+	Transfers  int   `json:"transfers"`
+	Amount     int64 `json:"amount"`
+	Fees       int64 `json:"fees"`
+}
+
+// watchOnly is the payout wallet of a payout forged without its key, which it can not sign for
+type watchOnly string
+
+func (w watchOnly) Address() string {
+	return string(w)
+}
+
+func (w watchOnly) Sign(input keys.SignInput) (keys.Signature, error) {
+	return keys.Signature{}, errors.Errorf("failed to sign: key of payout wallet '%s' is held offline", string(w))
+}
+
+/*
+NewOffline returns a payout to forge, or to inject once signed, without the key of the payout wallet. The wallet is
+the one pinned in TZPAY_WALLET_ADDRESS.
+*/
+func NewOffline(cfg config.Config, cycle int, verbose bool) (*Payout, error) {
+	if cfg.Key.Address == "" {
+		return nil, errors.New("failed to initialize offline payout: TZPAY_WALLET_ADDRESS is required without the key of the payout wallet")
+	}
+
+	payout, err := New(cfg, cycle, false, verbose)
+	if err != nil {
+		return nil, err
+	}
+	payout.signer = watchOnly(cfg.Key.Address)
+
+	if payout.store == nil {
+		if payout.store, err = store.New(cfg.Store.Path, cfg.Store.Key); err != nil {
+			return nil, errors.Wrap(err, "failed to initialize store")
+		}
+	}
+
+	if err := payout.checkWallet(); err != nil {
+		return nil, err
+	}
+
+	if err := payout.validateProtocol(); err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+/*
+Forge constructs the payout and forges its operations against the current head, without signing them. Funding the
+payout wallet and paying insurance take other keys, so they are left to the operator, and payouts through a disperse
+contract are refused.
+*/
+func (p *Payout) Forge() (OfflinePayout, error) {
+	payout, err := p.constructPayoutFunc()
+	if err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+	payout.Memo, payout.MemoHash = p.memo, MemoHash(p.memo)
+
+	if payout.Skipped {
+		return OfflinePayout{}, errors.Errorf("failed to forge payout for cycle %d: payout is skipped", p.cycle)
+	}
+	if p.config.Operations.DisperseContract != "" {
+		return OfflinePayout{}, errors.Errorf("failed to forge payout for cycle %d: payouts through a disperse contract can not be forged offline", p.cycle)
+	}
+
+	if p.usesPartials() {
+		if payout.Delegators, err = p.deductPartials(payout.Delegators); err != nil {
+			return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+		}
+	}
+
+	if p.funds() {
+		logrus.WithField("cycle", p.cycle).Warn("Funding of the payout wallet is skipped offline, fund it before injecting.")
+	}
+	if payout.InsurancePaid > 0 {
+		logrus.WithFields(logrus.Fields{"cycle": p.cycle, "insurance": payout.InsurancePaid}).Warn("Insurance is not paid offline, pay it separately.")
+	}
+
+	delegators, err := p.prepareTransfers(payout)
+	if err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	head, err := p.rpc.Head()
+	if err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	transactionBatches, err := p.constructTransactionBatches(head.Hash, delegators)
+	if err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	constants, err := p.rpc.Constants(head.Hash)
+	if err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	var operations []rpc.Contents
+	for _, batch := range transactionBatches {
+		split, err := splitWithinLimits(head.Hash, batch, constants)
+		if err != nil {
+			return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+		}
+		operations = append(operations, split...)
+	}
+
+	if operations, err = p.withReveal(head.Hash, operations); err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	if operations, err = p.estimateOperations(head, operations, constants); err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	forged, err := forgeOperations(head.Hash, operations)
+	if err != nil {
+		return OfflinePayout{}, errors.Wrapf(err, "failed to forge payout for cycle %d", p.cycle)
+	}
+
+	offline := OfflinePayout{
+		Cycle:        p.cycle,
+		Baker:        p.config.Baker.Address,
+		Wallet:       p.Wallet(),
+		Branch:       head.Hash,
+		Delegators:   delegators,
+		RewardsSplit: payout,
+	}
+	for i, contents := range operations {
+		offline.Operations = append(offline.Operations, OfflineOperation{Contents: contents, Forged: forged[i]})
+	}
+	offline.Summary = summarizeOffline(offline.Operations)
+
+	return offline, nil
+}
+
+/*
+SignOffline signs the operations of an offline payout with the payout wallet of cfg. The contents of each operation
+are forged again and compared with the bytes signed, so that the summary checked is what is signed.
+*/
+func SignOffline(cfg config.Config, offline OfflinePayout) (OfflinePayout, error) {
+	// The node is out of reach offline, so the protocol is left to the online machine
+	if err := CheckVectors(""); err != nil {
+		return offline, err
+	}
+
+	p := &Payout{config: cfg}
+	if err := p.loadWallet(); err != nil {
+		return offline, err
+	}
+	if err := p.checkWallet(); err != nil {
+		return offline, err
+	}
+
+	if offline.Wallet != p.Wallet() {
+		return offline, errors.Errorf("failed to sign payout for cycle %d: payout was forged for wallet '%s', not '%s'", offline.Cycle, offline.Wallet, p.Wallet())
+	}
+
+	operations := make([]OfflineOperation, len(offline.Operations))
+	for i, operation := range offline.Operations {
+		if err := checkOfflineOperation(offline.Branch, offline.Wallet, operation); err != nil {
+			return offline, errors.Wrapf(err, "failed to sign operation %d of payout for cycle %d", i+1, offline.Cycle)
+		}
+
+		signature, err := p.sign(keys.SignInput{Message: operation.Forged})
+		if err != nil {
+			return offline, errors.Wrapf(err, "failed to sign operation %d of payout for cycle %d", i+1, offline.Cycle)
+		}
+
+		operation.Signature = hex.EncodeToString(signature.Bytes)
+		operations[i] = operation
+	}
+	offline.Operations = operations
+	offline.Summary = summarizeOffline(offline.Operations)
+
+	return offline, nil
+}
+
+// checkOfflineOperation refuses an operation whose forged bytes are not its contents, or that spends from another wallet
+func checkOfflineOperation(branch, wallet string, operation OfflineOperation) error {
+	for _, content := range operation.Contents {
+		if content.Source != wallet {
+			return errors.Errorf("content spends from '%s', not the payout wallet '%s'", content.Source, wallet)
+		}
+	}
+
+	forged, err := forge.Encode(branch, operation.Contents...)
+	if err != nil {
+		return errors.Wrap(err, "failed to forge contents")
+	}
+
+	if forged != operation.Forged {
+		return errors.New("forged bytes do not match the contents of the operation")
+	}
+
+	return nil
+}
+
+/*
+InjectOffline injects the signed operations of an offline payout and records it, as the payout would have been had
+it been injected by tzpay run. The branch of the operations expires some 60 blocks after it was forged.
+*/
+func (p *Payout) InjectOffline(offline OfflinePayout) (tzkt.RewardsSplit, error) {
+	payout := offline.RewardsSplit
+	if offline.Baker != p.config.Baker.Address || offline.Wallet != p.Wallet() || offline.Cycle != p.cycle {
+		return payout, errors.Errorf("failed to inject payout for cycle %d: payout was forged for cycle %d of baker '%s' from wallet '%s'",
+			p.cycle, offline.Cycle, offline.Baker, offline.Wallet)
+	}
+
+	for i, operation := range offline.Operations {
+		if operation.Signature == "" {
+			return payout, errors.Errorf("failed to inject payout for cycle %d: operation %d is not signed", p.cycle, i+1)
+		}
+		if err := checkOfflineOperation(offline.Branch, offline.Wallet, operation); err != nil {
+			return payout, errors.Wrapf(err, "failed to inject operation %d of payout for cycle %d", i+1, p.cycle)
+		}
+	}
+
+	if p.usesApproval() {
+		contents := make([]rpc.Contents, len(offline.Operations))
+		for i, operation := range offline.Operations {
+			contents[i] = operation.Contents
+		}
+		if err := p.checkApproval(contentsTransfers(contents)); err != nil {
+			return payout, errors.Wrapf(err, "failed to inject payout for cycle %d", p.cycle)
+		}
+	}
+
+	var operations []string
+	for i, operation := range offline.Operations {
+		ophash, err := p.rpc.InjectionOperation(rpc.InjectionOperationInput{
+			Operation: fmt.Sprintf("%s%s", operation.Forged, operation.Signature),
+		})
+		if err != nil {
+			return payout, errors.Wrapf(err, "failed to inject operation %d of payout for cycle %d", i+1, p.cycle)
+		}
+		payout.OperationLink = append(payout.OperationLink, fmt.Sprintf("https://tzkt.io/%s", ophash))
+		operations = append(operations, ophash)
+
+		if !p.confirmOperation(ophash) {
+			return payout, errors.Errorf("failed to inject operation %d of payout for cycle %d: failed to confirm operation", i+1, p.cycle)
+		}
+
+		if p.verbose {
+			logrus.WithFields(logrus.Fields{
+				"hash":      ophash,
+				"operation": fmt.Sprintf("%d/%d", (i + 1), len(offline.Operations)),
+			}).Info("Injection confirmed.")
+		}
+	}
+
+	if err := p.recordPaid(offline.Delegators); err != nil {
+		return payout, errors.Wrapf(err, "failed to inject payout for cycle %d", p.cycle)
+	}
+
+	if p.config.Operations.Confirmations > 0 {
+		if err := p.awaitConfirmations(operations); err != nil {
+			if errors.Cause(err) == errOrphaned {
+				if err := p.clearOrphaned(payout.Delegators, offline.Delegators); err != nil {
+					logrus.WithField("error", err.Error()).Error("Failed to clear paid records of orphaned payout.")
+				}
+			}
+			return payout, errors.Wrapf(err, "failed to inject payout for cycle %d", p.cycle)
+		}
+	}
+
+	if p.usesLedger() {
+		if err := p.updateLedger(payout.Delegators); err != nil {
+			return payout, errors.Wrapf(err, "failed to inject payout for cycle %d", p.cycle)
+		}
+	}
+
+	if err := p.saveRecord(payout); err != nil {
+		return payout, errors.Wrapf(err, "failed to inject payout for cycle %d", p.cycle)
+	}
+
+	return payout, nil
+}
+
+func summarizeOffline(operations []OfflineOperation) OfflineSummary {
+	summary := OfflineSummary{Operations: len(operations)}
+	for _, operation := range operations {
+		for _, content := range operation.Contents {
+			if content.Kind == rpc.TRANSACTION {
+				summary.Transfers++
+				summary.Amount += content.Amount
+			}
+			summary.Fees += content.Fee
+		}
+	}
+
+	return summary
+}
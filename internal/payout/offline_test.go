@@ -0,0 +1,175 @@
+package payout
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Offline(t *testing.T) {
+	confirmationDurationInterval = time.Millisecond
+	defer func() { confirmationDurationInterval = time.Second }()
+
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Kind:     keys.Ed25519,
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+	})
+	assert.Nil(t, err)
+	wallet := key.PubKey.GetPublicKeyHash()
+
+	dir, err := ioutil.TempDir("", "tzpay-offline")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	cfg := config.Config{
+		Baker: config.Baker{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"},
+		Key:   config.Key{Address: wallet},
+		Operations: config.Operations{
+			NetworkFee: 2941,
+			GasLimit:   26283,
+			BatchSize:  100,
+		},
+	}
+	rewardsSplit := tzkt.RewardsSplit{
+		Cycle: 270,
+		Delegators: tzkt.Delegators{
+			{Address: "tz1icdoLr8vof5oXiEKCFSyrVoouGiKDQ3Gd", NetRewards: 1000000},
+			{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", NetRewards: 250000},
+			{Address: "tz1LFEVYR7YHQFubZ7h5ioJH9aNu5TrmRG2e", NetRewards: 100, BlackListed: true},
+		},
+	}
+
+	newPayout := func() *Payout {
+		return &Payout{
+			config: cfg,
+			rpc:    &test.RPCMock{},
+			store:  s,
+			signer: watchOnly(wallet),
+			cycle:  270,
+			constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+				return rewardsSplit, nil
+			},
+		}
+	}
+
+	offline, err := newPayout().Forge()
+	assert.Nil(t, err)
+	assert.Equal(t, wallet, offline.Wallet)
+	assert.Equal(t, "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", offline.Branch)
+	assert.Equal(t, OfflineSummary{Operations: 1, Transfers: 2, Amount: 1250000, Fees: 2 * 2941}, offline.Summary)
+	assert.Len(t, offline.Operations, 1)
+	assert.Equal(t, "", offline.Operations[0].Signature)
+
+	_, err = newPayout().InjectOffline(offline)
+	test.CheckErr(t, true, "operation 1 is not signed", err)
+
+	// The payout travels between machines as JSON
+	raw, err := json.Marshal(offline)
+	assert.Nil(t, err)
+	var unsigned OfflinePayout
+	assert.Nil(t, json.Unmarshal(raw, &unsigned))
+
+	signingConfig := cfg
+	signingConfig.Key.Esk = "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2"
+	signingConfig.Key.Password = "password12345##"
+
+	tampered := unsigned
+	tampered.Operations = []OfflineOperation{unsigned.Operations[0]}
+	tampered.Operations[0].Contents = append(tampered.Operations[0].Contents[:0:0], unsigned.Operations[0].Contents...)
+	tampered.Operations[0].Contents[1].Amount = 2500000
+	_, err = SignOffline(signingConfig, tampered)
+	test.CheckErr(t, true, "forged bytes do not match the contents of the operation", err)
+
+	otherWallet := signingConfig
+	otherWallet.Key.Address = ""
+	unsignedOther := unsigned
+	unsignedOther.Wallet = "tz1LFEVYR7YHQFubZ7h5ioJH9aNu5TrmRG2e"
+	_, err = SignOffline(otherWallet, unsignedOther)
+	test.CheckErr(t, true, "payout was forged for wallet", err)
+
+	signed, err := SignOffline(signingConfig, unsigned)
+	assert.Nil(t, err)
+	signature, err := key.Sign(keys.SignInput{Message: offline.Operations[0].Forged})
+	assert.Nil(t, err)
+	assert.Equal(t, hex.EncodeToString(signature.Bytes), signed.Operations[0].Signature)
+	assert.Equal(t, offline.Summary, signed.Summary)
+
+	other := newPayout()
+	other.cycle = 271
+	_, err = other.InjectOffline(signed)
+	test.CheckErr(t, true, "payout was forged for cycle 270", err)
+
+	injected, err := newPayout().InjectOffline(signed)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://tzkt.io/ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M"}, injected.OperationLink)
+
+	records, err := Records(s, cfg.Baker.Address)
+	assert.Nil(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, injected.OperationLink, records[0].Operations)
+
+	// Transfers paid are left out of a payout forged again
+	again, err := newPayout().Forge()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, again.Summary.Transfers)
+}
+
+func Test_Forge(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  config.Config
+		split   tzkt.RewardsSplit
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "refuses a skipped payout",
+			split:   tzkt.RewardsSplit{Skipped: true},
+			wantErr: true,
+			errMsg:  "payout is skipped",
+		},
+		{
+			name:    "refuses a disperse contract",
+			config:  config.Config{Operations: config.Operations{DisperseContract: "KT1disperse"}},
+			wantErr: true,
+			errMsg:  "can not be forged offline",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Payout{
+				config: tt.config,
+				rpc:    &test.RPCMock{},
+				signer: watchOnly("tz1wallet"),
+				constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+					return tt.split, nil
+				},
+			}
+
+			_, err := p.Forge()
+			test.CheckErr(t, tt.wantErr, tt.errMsg, err)
+		})
+	}
+
+	_, err := NewOffline(config.Config{}, 270, false)
+	test.CheckErr(t, true, "TZPAY_WALLET_ADDRESS is required", err)
+
+	_, err = watchOnly("tz1wallet").Sign(keys.SignInput{Message: "00"})
+	test.CheckErr(t, true, "is held offline", err)
+}
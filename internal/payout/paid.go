@@ -0,0 +1,178 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// SkipReasonPaid is the reason a delegator the paid ledger shows was already paid for the cycle is skipped
+const SkipReasonPaid = "already paid"
+
+// PaidEntry is the record that a delegator, or a liquidity provider of a contract, was paid for a cycle
+type PaidEntry = store.Paid
+
+func paidBucket(baker string) string {
+	return store.PaidBucket(baker)
+}
+
+func paidKey(cycle int, address string) string {
+	return store.PaidKey(cycle, address)
+}
+
+/*
+skipPaid marks the delegators and liquidity providers the paid ledger records as paid for the cycle, so that a
+payout retried after a crash or by hand never pays anyone twice. It returns the number of transfers skipped.
+*/
+func (p *Payout) skipPaid(delegators tzkt.Delegators) (tzkt.Delegators, int, error) {
+	addresses := []string{}
+	p.eachTransfer(delegators, func(address string, amount int) error {
+		addresses = append(addresses, address)
+		return nil
+	})
+
+	unpaid, err := p.payouts().ListUnpaid(p.config.Baker.Address, p.cycle, addresses)
+	if err != nil {
+		return delegators, 0, err
+	}
+	isUnpaid := map[string]bool{}
+	for _, address := range unpaid {
+		isUnpaid[address] = true
+	}
+
+	var skipped int
+	marked := make(tzkt.Delegators, len(delegators))
+	for i, delegator := range delegators {
+		if delegator.LiquidityProviders != nil {
+			liquidityProviders := make([]tzkt.LiquidityProvider, len(delegator.LiquidityProviders))
+			for j, lp := range delegator.LiquidityProviders {
+				if !lp.BlackListed && !isUnpaid[lp.Address] {
+					lp.BlackListed = true
+					lp.SkipReason = skipReason(lp.SkipReason, SkipReasonPaid)
+					skipped++
+				}
+				liquidityProviders[j] = lp
+			}
+			delegator.LiquidityProviders = liquidityProviders
+		} else if !delegator.BlackListed && !delegator.Accumulated && !isUnpaid[delegator.Address] {
+			delegator.BlackListed = true
+			delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonPaid)
+			skipped++
+		}
+		marked[i] = delegator
+	}
+
+	return marked, skipped, nil
+}
+
+// payouts returns the payouts persisted in the store of the payout
+func (p *Payout) payouts() store.Payouts {
+	return store.NewPayouts(p.store)
+}
+
+// recordPaid records every delegator and liquidity provider sent a transfer as paid for the cycle
+func (p *Payout) recordPaid(delegators tzkt.Delegators) error {
+	return p.eachTransfer(delegators, func(address string, amount int) error {
+		return p.payouts().MarkPaid(p.config.Baker.Address, p.cycle, address, PaidEntry{Amount: amount, Time: now().UTC()})
+	})
+}
+
+/*
+payees maps each transfer paying delegators to the delegators and liquidity providers it pays, in order. A partial
+payout records what it paid each delegation a manager consolidates, which are taken from unconsolidated.
+*/
+func (p *Payout) payees(delegators, unconsolidated tzkt.Delegators) map[disperseTransfer][]tzkt.Delegators {
+	managed := map[string]tzkt.Delegators{}
+	if p.partial {
+		for _, delegator := range unconsolidated {
+			if delegator.Manager != "" && !delegator.BlackListed && !delegator.Accumulated && delegator.LiquidityProviders == nil {
+				managed[delegator.Manager] = append(managed[delegator.Manager], delegator)
+			}
+		}
+	}
+
+	payees := map[disperseTransfer][]tzkt.Delegators{}
+	for _, delegation := range delegators {
+		if delegation.LiquidityProviders != nil {
+			for _, liquidityProvider := range delegation.LiquidityProviders {
+				if !liquidityProvider.BlackListed {
+					transfer := disperseTransfer{Destination: p.config.Baker.PayoutAddress(liquidityProvider.Address), Amount: int64(liquidityProvider.NetRewards)}
+					payee := tzkt.Delegator{Address: delegation.Address, LiquidityProviders: []tzkt.LiquidityProvider{liquidityProvider}}
+					payees[transfer] = append(payees[transfer], tzkt.Delegators{payee})
+				}
+			}
+		} else if !delegation.BlackListed && !delegation.Accumulated {
+			transfer := disperseTransfer{Destination: p.destination(delegation), Amount: int64(delegation.NetRewards)}
+			if delegations, ok := managed[delegation.Address]; ok {
+				payees[transfer] = append(payees[transfer], delegations)
+			} else {
+				payees[transfer] = append(payees[transfer], tzkt.Delegators{delegation})
+			}
+		}
+	}
+
+	return payees
+}
+
+/*
+recordTransfersPaid records the delegators and liquidity providers a confirmed chunk of transfers paid as paid for the
+cycle, or what it paid them for a partial payout, as soon as the chunk is confirmed, so that a payout interrupted in a
+later chunk and run again skips them instead of paying them twice. Transfers paying none of the delegators being paid,
+e.g. insurance top-ups, are ignored.
+*/
+func (p *Payout) recordTransfersPaid(transfers []disperseTransfer) error {
+	for _, transfer := range transfers {
+		payees := p.paying[transfer]
+		if len(payees) == 0 {
+			continue
+		}
+		p.paying[transfer] = payees[1:]
+
+		record := p.recordPaid
+		if p.partial {
+			record = func(delegators tzkt.Delegators) error { return p.addPartials(delegators, 1) }
+		}
+		if err := record(payees[0]); err != nil {
+			return errors.Wrapf(err, "failed to record transfer to '%s' as paid", transfer.Destination)
+		}
+	}
+
+	return nil
+}
+
+// clearOrphaned removes what a payout whose operations were orphaned recorded as paid, so that it pays them again
+func (p *Payout) clearOrphaned(delegators, paid tzkt.Delegators) error {
+	if p.partial {
+		return p.addPartials(delegators, -1)
+	}
+
+	return p.clearPaid(paid)
+}
+
+// clearPaid removes the paid records of delegators
+func (p *Payout) clearPaid(delegators tzkt.Delegators) error {
+	return p.eachTransfer(delegators, func(address string, amount int) error {
+		return p.payouts().UnmarkPaid(p.config.Baker.Address, p.cycle, address)
+	})
+}
+
+// eachTransfer calls fn with the address and amount of every delegator and liquidity provider sent a transfer
+func (p *Payout) eachTransfer(delegators tzkt.Delegators, fn func(address string, amount int) error) error {
+	for _, delegator := range delegators {
+		if delegator.LiquidityProviders != nil {
+			for _, lp := range delegator.LiquidityProviders {
+				if !lp.BlackListed {
+					if err := fn(lp.Address, lp.NetRewards); err != nil {
+						return err
+					}
+				}
+			}
+		} else if !delegator.BlackListed && !delegator.Accumulated {
+			if err := fn(delegator.Address, delegator.NetRewards); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Execute_PaidLedger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-paid")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	var applied []tzkt.Delegators
+	payout := Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+		store:  s,
+		cycle:  270,
+		inject: true,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			return tzkt.RewardsSplit{
+				Delegators: tzkt.Delegators{
+					{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000},
+					{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", NetRewards: 950000},
+					{Address: "tz1blacklisted", NetRewards: 1000, BlackListed: true},
+					{
+						Address: "KT1dexter",
+						LiquidityProviders: []tzkt.LiquidityProvider{
+							{Address: "tz1provider", NetRewards: 5000},
+						},
+					},
+				},
+			}, nil
+		},
+		applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+			applied = append(applied, delegators)
+			return []string{"ooSomeOperation"}, nil
+		},
+	}
+
+	_, err = payout.Execute()
+	assert.Nil(t, err)
+
+	var entry PaidEntry
+	ok, err := s.Get(paidBucket("tz1baker"), paidKey(270, "tz1provider"), &entry)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 5000, entry.Amount)
+
+	ok, err = s.Get(paidBucket("tz1baker"), paidKey(270, "tz1blacklisted"), &entry)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = payout.Execute()
+	assert.Nil(t, err)
+	assert.Len(t, applied, 2)
+	assert.Empty(t, payout.transfers(applied[1]))
+	assert.Equal(t, SkipReasonPaid, applied[1][0].SkipReason)
+	assert.Equal(t, SkipReasonPaid, applied[1][3].LiquidityProviders[0].SkipReason)
+	assert.Empty(t, applied[1][2].SkipReason)
+
+	payout.cycle = 271
+	_, err = payout.Execute()
+	assert.Nil(t, err)
+	assert.Len(t, payout.transfers(applied[2]), 3)
+}
@@ -0,0 +1,87 @@
+package payout
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// Reasons a delegator is not paid by a partial payout, or by the payout of a cycle already paid by partial payouts
+const (
+	SkipReasonPartiallyPaid = "paid by partial payouts"
+	SkipReasonEndOfCycle    = "paid at the end of the cycle"
+)
+
+// PartialEntry holds what the partial payouts of a cycle paid a delegator so far
+type PartialEntry struct {
+	Paid int `json:"paid"`
+}
+
+func partialBucket(baker string) string {
+	return "partial/" + baker
+}
+
+// SetPartial makes the payout a partial payout of the rewards accrued so far in a cycle still in progress
+func (p *Payout) SetPartial() {
+	p.partial = true
+}
+
+// usesPartials returns true if cycles may be paid in part while they are in progress
+func (p *Payout) usesPartials() bool {
+	return p.config.Baker.PartialPayouts > 0
+}
+
+/*
+deductPartials subtracts what the partial payouts of the cycle already paid each delegator from its rewards, so that
+the payout of a cycle only pays what is left. Liquidity providers are left to the payout at the end of the cycle, and
+partial payouts skip delegators whose remaining rewards are below the minimum payment.
+*/
+func (p *Payout) deductPartials(delegators tzkt.Delegators) (tzkt.Delegators, error) {
+	deducted := make(tzkt.Delegators, len(delegators))
+	for i, delegator := range delegators {
+		if delegator.LiquidityProviders != nil && p.partial {
+			liquidityProviders := make([]tzkt.LiquidityProvider, len(delegator.LiquidityProviders))
+			for j, lp := range delegator.LiquidityProviders {
+				lp.BlackListed = true
+				lp.SkipReason = skipReason(lp.SkipReason, SkipReasonEndOfCycle)
+				liquidityProviders[j] = lp
+			}
+			delegator.LiquidityProviders = liquidityProviders
+		} else if delegator.LiquidityProviders == nil && !delegator.BlackListed && !delegator.Accumulated {
+			var entry PartialEntry
+			if _, err := p.store.Get(partialBucket(p.config.Baker.Address), paidKey(p.cycle, delegator.Address), &entry); err != nil {
+				return delegators, errors.Wrapf(err, "failed to get partial payouts of '%s'", delegator.Address)
+			}
+
+			delegator.PartialRewards = entry.Paid
+			delegator.NetRewards -= entry.Paid
+			if delegator.NetRewards <= 0 {
+				delegator.NetRewards = 0
+				delegator.BlackListed = true
+				delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonPartiallyPaid)
+			} else if p.partial && delegator.NetRewards < p.config.Baker.MinimumPayment {
+				delegator.BlackListed = true
+				delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonMinimumPayment)
+			}
+		}
+		deducted[i] = delegator
+	}
+
+	return deducted, nil
+}
+
+// addPartials adds what a partial payout paid each delegator, times sign, to what the partial payouts of the cycle paid
+func (p *Payout) addPartials(delegators tzkt.Delegators, sign int) error {
+	bucket := partialBucket(p.config.Baker.Address)
+	return p.eachTransfer(delegators, func(address string, amount int) error {
+		var entry PartialEntry
+		if _, err := p.store.Get(bucket, paidKey(p.cycle, address), &entry); err != nil {
+			return errors.Wrapf(err, "failed to get partial payouts of '%s'", address)
+		}
+
+		entry.Paid += sign * amount
+		if err := p.store.Put(bucket, paidKey(p.cycle, address), entry); err != nil {
+			return errors.Wrapf(err, "failed to record partial payout of '%s'", address)
+		}
+		return nil
+	})
+}
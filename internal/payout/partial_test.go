@@ -0,0 +1,92 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Execute_Partial(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-partial")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	// rewards accrued by each delegator, as seen by each of the payouts of the cycle
+	accrued := [][]int{{400000, 100}, {700000, 200}, {1000000, 300}}
+
+	var run int
+	var applied []tzkt.Delegators
+	newPayout := func(partial bool) Payout {
+		payout := Payout{
+			config: config.Config{Baker: config.Baker{Address: "tz1baker", MinimumPayment: 150, PartialPayouts: 2}},
+			store:  s,
+			cycle:  270,
+			inject: true,
+			constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+				return tzkt.RewardsSplit{
+					Delegators: tzkt.Delegators{
+						{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: accrued[run][0]},
+						{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", NetRewards: accrued[run][1]},
+						{
+							Address: "KT1dexter",
+							LiquidityProviders: []tzkt.LiquidityProvider{
+								{Address: "tz1provider", NetRewards: 5000},
+							},
+						},
+					},
+				}, nil
+			},
+			applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+				applied = append(applied, delegators)
+				return []string{"ooSomeOperation"}, nil
+			},
+		}
+		if partial {
+			payout.SetPartial()
+		}
+		return payout
+	}
+
+	payout := newPayout(true)
+	_, err = payout.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []disperseTransfer{{Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 400000}}, payout.transfers(applied[0]))
+	assert.Equal(t, SkipReasonMinimumPayment, applied[0][1].SkipReason)
+	assert.Equal(t, SkipReasonEndOfCycle, applied[0][2].LiquidityProviders[0].SkipReason)
+
+	run++
+	payout = newPayout(true)
+	_, err = payout.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []disperseTransfer{
+		{Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 300000},
+		{Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Amount: 200},
+	}, payout.transfers(applied[1]))
+
+	run++
+	payout = newPayout(false)
+	rewardsSplit, err := payout.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []disperseTransfer{
+		{Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 300000},
+		{Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Amount: 100},
+		{Destination: "tz1provider", Amount: 5000},
+	}, payout.transfers(applied[2]))
+	assert.Equal(t, 700000, rewardsSplit.Delegators[0].PartialRewards)
+
+	payout = newPayout(false)
+	payout.config.Baker.PartialPayouts = 0
+	payout.SetPartial()
+	_, err = payout.Execute()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "partial payouts are not enabled")
+}
@@ -1,14 +1,21 @@
 package payout
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/goat-systems/go-tezos/v3/forge"
 	"github.com/goat-systems/go-tezos/v3/keys"
 	"github.com/goat-systems/go-tezos/v3/rpc"
 	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/indexer"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/notifier/email"
+	"github.com/goat-systems/tzpay/v3/internal/price"
+	"github.com/goat-systems/tzpay/v3/internal/signer"
+	"github.com/goat-systems/tzpay/v3/internal/store"
 	"github.com/goat-systems/tzpay/v3/internal/tzkt"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -27,12 +34,34 @@ var (
 // Payout represents a payout and payout operations.
 type Payout struct {
 	config                            config.Config
+	ctx                               context.Context // the context the RPC calls of the payout are bound to
 	rpc                               rpc.IFace
 	tzkt                              tzkt.IFace
+	store                             store.IFace
 	key                               keys.Key
+	signer                            signer.IFace
+	insuranceKey                      keys.Key
+	bakerKey                          keys.Key
+	campaigns                         map[string]config.Campaign
 	cycle                             int
 	inject                            bool
 	verbose                           bool
+	memo                              string
+	partial                           bool
+	queued                            string // the key the payout is persisted to the queue under
+	attempts                          int    // the attempts of the queue to execute the payout that failed in a row
+	enqueued                          time.Time
+	future                            bool
+	confirmer                         Confirmer
+	watcher                           *InclusionWatcher
+	approval                          bool // held until an operator approves it, even without approval keys
+	notifier                          *notifier.PayoutNotifier
+	mailer                            Mailer
+	price                             price.IFace
+	forged                            []string
+	operations                        map[string][]string
+	paying                            map[disperseTransfer][]tzkt.Delegators
+	pending                           *Progress
 	constructDexterContractPayoutFunc func(delegator tzkt.Delegator) (tzkt.Delegator, error)
 	applyFunc                         func(delegators tzkt.Delegators) ([]string, error)
 	constructPayoutFunc               func() (tzkt.RewardsSplit, error)
@@ -40,9 +69,18 @@ type Payout struct {
 
 // New returns a pointer to a new Baker
 func New(config config.Config, cycle int, inject, verbose bool) (*Payout, error) {
+	return NewWithContext(context.Background(), config, cycle, inject, verbose)
+}
+
+/*
+NewWithContext returns a pointer to a new Baker whose every call to the tezos node is bound to ctx, from those checking
+the payout wallet here on, so that cancelling ctx cancels the calls in flight and fails the payout.
+*/
+func NewWithContext(ctx context.Context, config config.Config, cycle int, inject, verbose bool) (*Payout, error) {
 	payout := &Payout{
 		config:  config,
-		tzkt:    tzkt.NewTZKT(config.API.TZKT),
+		ctx:     ctx,
+		tzkt:    indexer.NewWithContext(ctx, config.API),
 		cycle:   cycle,
 		inject:  inject,
 		verbose: verbose,
@@ -52,56 +90,332 @@ func New(config config.Config, cycle int, inject, verbose bool) (*Payout, error)
 	payout.applyFunc = payout.apply
 
 	var err error
-	payout.rpc, err = rpc.New(config.API.Tezos)
+	payout.rpc, err = newNodeRPC(ctx, config.API)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to initialize tezos rpc client")
 	}
 
-	if inject {
-		payout.key, err = keys.NewKey(keys.NewKeyInput{
-			Kind:     keys.Ed25519,
-			Esk:      config.Key.Esk,
-			Password: config.Key.Password,
-		})
+	if payout.usesLedger() || payout.usesPartials() || inject {
+		payout.store, err = store.New(config.Store.Path, config.Store.Key)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to initialize import key")
+			return nil, errors.Wrap(err, "failed to initialize store")
+		}
+	}
+
+	if inject {
+		if err := payout.validateProtocol(); err != nil {
+			return nil, err
+		}
+
+		if err := payout.loadWallet(); err != nil {
+			return nil, err
+		}
+
+		payout.logWallet()
+		if err := payout.checkWallet(); err != nil {
+			return nil, err
 		}
 
 		config.Key.Esk = ""
 		config.Key.Password = ""
+
+		if config.Insurance.Threshold > 0 {
+			payout.insuranceKey, err = keys.NewKey(keys.NewKeyInput{
+				Kind:     keys.Ed25519,
+				Esk:      config.Insurance.Esk,
+				Password: config.Insurance.Password,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to initialize import insurance key")
+			}
+
+			config.Insurance.Esk = ""
+			config.Insurance.Password = ""
+		}
+
+		if payout.funds() || (config.Receipts.Sign && config.Funding.Esk != "") {
+			payout.bakerKey, err = keys.NewKey(keys.NewKeyInput{
+				Kind:     keys.Ed25519,
+				Esk:      config.Funding.Esk,
+				Password: config.Funding.Password,
+			})
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to initialize import baker key")
+			}
+
+			if address := payout.bakerKey.PubKey.GetPublicKeyHash(); config.Receipts.Sign && address != config.Baker.Address {
+				return nil, errors.Errorf("failed to initialize receipt signing: baker key is the key of '%s', not of baker '%s'", address, config.Baker.Address)
+			}
+
+			config.Funding.Esk = ""
+			config.Funding.Password = ""
+		}
+
+		if config.Receipts.Sign && payout.bakerKey.GetBytes() == nil {
+			logrus.WithField("baker", config.Baker.Address).Warn("Receipts are not signed without the baker's key in TZPAY_BAKER_ESK.")
+		}
+
+		if config.Notifications.Email.Receipts && config.Notifications.Email.Host != "" {
+			payout.mailer = email.New(email.Client{
+				Host:     config.Notifications.Email.Host,
+				Port:     config.Notifications.Email.Port,
+				Username: config.Notifications.Email.Username,
+				Password: config.Notifications.Email.Password,
+				From:     config.Notifications.Email.From,
+			})
+		}
+
+		if config.Price.Currency != "" {
+			if payout.price, err = price.New(price.Input{Source: config.Price.Source, URL: config.Price.URL}); err != nil {
+				return nil, errors.Wrap(err, "failed to initialize price source")
+			}
+		}
 	}
 
 	return payout, nil
 }
 
-// Execute will execute a payout based off the Payout configuration
+// operationHashes returns the hashes of the operations of links to tzkt
+func operationHashes(links []string) []string {
+	var hashes []string
+	for _, link := range links {
+		hashes = append(hashes, strings.TrimPrefix(link, "https://tzkt.io/"))
+	}
+
+	return hashes
+}
+
+// Baker returns the address of the baker the payout is for
+func (p *Payout) Baker() string {
+	return p.config.Baker.Address
+}
+
+// Wallet returns the address of the payout wallet
+func (p *Payout) Wallet() string {
+	if p.signer != nil {
+		return p.signer.Address()
+	}
+
+	return p.key.PubKey.GetPublicKeyHash()
+}
+
+/*
+Execute will execute a payout based off the Payout configuration. The notifier, if set, is sent an event once the
+payout is computed, injected and confirmed, or once it failed; payouts held for approval or cancelled did not fail.
+Payouts injected or failing are also archived if an archive is configured.
+*/
 func (p *Payout) Execute() (tzkt.RewardsSplit, error) {
+	payout, err := p.execute()
+	if p.config.Archive.Dir != "" && p.inject && payout.Delegators != nil && !AwaitingApproval(err) && !Cancelled(err) {
+		p.archive(payout, err)
+	}
+	if err != nil && !AwaitingApproval(err) && !Cancelled(err) {
+		p.event(notifier.EventFailed, payout.Delegators, operationHashes(payout.OperationLink), err)
+	}
+
+	return payout, err
+}
+
+func (p *Payout) execute() (tzkt.RewardsSplit, error) {
 	payout, err := p.constructPayoutFunc()
 	if err != nil {
 		return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
 	}
+	payout.Memo, payout.MemoHash = p.memo, MemoHash(p.memo)
+
+	if p.partial && !p.usesPartials() {
+		return payout, errors.Errorf("failed to execute partial payout for cycle %d: partial payouts are not enabled", p.cycle)
+	} else if p.usesPartials() && !payout.Skipped {
+		if payout.Delegators, err = p.deductPartials(payout.Delegators); err != nil {
+			return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+		}
+	}
+
+	if !payout.Skipped {
+		p.event(notifier.EventComputed, payout.Delegators, nil, nil)
+	}
 
-	if p.inject {
-		operations, err := p.applyFunc(payout.Delegators)
+	if p.inject && !payout.Skipped {
+		if p.funds() && !p.partial {
+			operation, funded, err := p.fund(payout)
+			if err != nil {
+				return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+			} else if operation != "" {
+				payout.Funded = funded
+				payout.OperationLink = append(payout.OperationLink, fmt.Sprintf("https://tzkt.io/%s", operation))
+			}
+		}
+
+		delegators, err := p.prepareTransfers(payout)
 		if err != nil {
 			return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
 		}
 
+		if p.config.Notifications.LowBalance.Enabled && !p.partial {
+			p.checkLowBalance(delegators)
+		}
+
+		p.paying = p.payees(delegators, payout.Delegators)
+		operations, err := p.applyFunc(delegators)
+		if err != nil {
+			p.paying = nil
+			return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+		}
+
 		for _, op := range operations {
 			payout.OperationLink = append(payout.OperationLink, fmt.Sprintf("https://tzkt.io/%s", op))
 		}
+		p.event(notifier.EventInjected, delegators, operations, nil)
+
+		// Chunks record what they paid once confirmed, partial payouts record what no chunk did
+		if p.partial {
+			err = p.recordTransfersPaid(p.transfers(delegators))
+		} else if p.store != nil {
+			err = p.recordPaid(delegators)
+		}
+		p.paying = nil
+		if err != nil {
+			return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+		}
+
+		if p.store != nil {
+			if err := p.clearProgress(); err != nil {
+				return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+			}
+		}
+
+		var insurance []string
+		if payout.InsurancePaid > 0 && !p.partial {
+			insurance, err = p.payInsurance(payout.Delegators)
+			for _, op := range insurance {
+				payout.OperationLink = append(payout.OperationLink, fmt.Sprintf("https://tzkt.io/%s", op))
+			}
+			if err != nil {
+				return payout, errors.Wrapf(err, "failed to pay insurance for cycle %d", p.cycle)
+			}
+		}
+
+		if p.config.Operations.Confirmations > 0 {
+			if err := p.awaitConfirmations(append(operations, insurance...)); err != nil {
+				if errors.Cause(err) == errOrphaned && p.store != nil {
+					if err := p.clearOrphaned(payout.Delegators, delegators); err != nil {
+						logrus.WithField("error", err.Error()).Error("Failed to clear paid records of orphaned payout.")
+					}
+				}
+				return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+			}
+			p.event(notifier.EventConfirmed, delegators, append(operations, insurance...), nil)
+		}
+
+		if p.usesLedger() && !p.partial {
+			if err := p.updateLedger(payout.Delegators); err != nil {
+				return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+			}
+		}
+
+		if p.store != nil {
+			if err := p.saveRecord(payout); err != nil {
+				return payout, errors.Wrapf(err, "failed to execute payout for cycle %d", p.cycle)
+			}
+		}
+
+		if p.signsReceipts() && p.store != nil && !p.partial {
+			p.signReceipts(delegators, operations)
+		}
+
+		if p.mailer != nil && !p.partial {
+			p.sendReceipts(payout.Delegators, operations)
+		}
 	}
 
 	return payout, err
 }
 
+/*
+prepareTransfers returns the delegators a payout transfers to, with the transfers of managers consolidated, the
+donation, bond pool and sweep added, and the transfers already paid left out. It refuses duplicate transfers, transfers
+above the spending cap and transfers the payout wallet can not cover when those checks are enabled.
+*/
+func (p *Payout) prepareTransfers(payout tzkt.RewardsSplit) (tzkt.Delegators, error) {
+	delegators := p.consolidate(payout.Delegators)
+	if !p.partial {
+		delegators = withSweep(payout, withBondPool(payout, withDonation(payout, delegators)))
+	}
+	if p.store != nil {
+		if err := p.resolvePending(delegators, payout.Delegators); err != nil {
+			return nil, err
+		}
+
+		var paid int
+		var err error
+		if delegators, paid, err = p.skipPaid(delegators); err != nil {
+			return nil, err
+		} else if paid > 0 {
+			logrus.WithFields(logrus.Fields{"cycle": p.cycle, "transfers": paid}).Warn("Skipping transfers already paid for cycle.")
+		}
+	}
+
+	if p.config.Operations.DuplicateCheck {
+		if err := p.checkDuplicates(delegators); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.config.Operations.SpendingCap > 0 {
+		if err := p.checkSpendingCap(delegators); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.config.Key.Address != "" {
+		if err := p.checkBalance(delegators); err != nil {
+			return nil, err
+		}
+	}
+
+	return delegators, nil
+}
+
 func (p *Payout) constructPayout() (tzkt.RewardsSplit, error) {
 	rewardsSplit, err := p.tzkt.GetRewardsSplit(p.config.Baker.Address, p.cycle)
 	if err != nil {
 		return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
 	}
 
+	if rewardsSplit.Protocol, err = p.protocol(); err != nil {
+		return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
+	}
+	rewardsSplit.RewardModel = RewardModel(rewardsSplit.Protocol)
+
 	totalRewards := p.calculateTotals(rewardsSplit)
+	if p.future {
+		totalRewards += rewardsSplit.FutureBlockRewards + rewardsSplit.FutureEndorsementRewards
+	}
+	if p.config.Baker.ActualRewards || p.crossChecks() {
+		// Tenderbake freezes no rewards, which are only found in the balance updates of the blocks
+		actualRewards := p.frozenBalance
+		if rewardsSplit.RewardModel == RewardModelTenderbake {
+			actualRewards = p.actualRewards
+		}
+		actual, err := actualRewards()
+		if err != nil {
+			return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
+		}
+		if p.crossChecks() {
+			if err := p.crossCheck(rewardsSplit, actual); err != nil {
+				return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
+			}
+		}
+		if p.config.Baker.ActualRewards {
+			totalRewards = actual
+		}
+	}
+
+	var pay bool
+	if totalRewards, pay = p.applyDenunciationPolicy(&rewardsSplit, totalRewards); !pay {
+		rewardsSplit.Delegators = tzkt.Delegators{}
+		return rewardsSplit, nil
+	}
 
 	bakerBalance, err := p.rpc.Balance(rpc.BalanceInput{
 		Cycle:   p.cycle,
@@ -112,22 +426,38 @@ func (p *Payout) constructPayout() (tzkt.RewardsSplit, error) {
 	}
 
 	rewardsSplit.BakerShare = float64(bakerBalance) / float64(rewardsSplit.StakingBalance)
-	rewardsSplit.BakerRewards = int(rewardsSplit.BakerShare * float64(totalRewards))
+	rewardsSplit.BakerRewards = p.round(exactRewards(bakerBalance, rewardsSplit.StakingBalance, totalRewards))
 
 	delegations, dexterContracts := p.splitDelegationsAndDexterContracts(rewardsSplit)
 	rewardsSplit.Delegators = tzkt.Delegators{}
 
 	if !p.config.Baker.DexterLiquidityContractsOnly {
-		for _, delegation := range delegations {
-			delegation, err = p.constructDelegation(delegation, totalRewards, rewardsSplit.StakingBalance)
-			if err != nil {
-				return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
-			}
+		if err := p.loadCampaigns(delegations); err != nil {
+			return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
+		}
+
+		constructed, err := p.constructDelegations(delegations, totalRewards, rewardsSplit.StakingBalance)
+		if err != nil {
+			return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
+		}
+		for _, delegation := range constructed {
 			rewardsSplit.BakerCollectedFees += delegation.Fee
 			rewardsSplit.Delegators = append(rewardsSplit.Delegators, delegation)
 		}
+
+		if p.config.Baker.DistributeRemainder {
+			rewardsSplit.BakerCollectedFees += p.distributeRemainder(rewardsSplit.Delegators, totalRewards, rewardsSplit.StakingBalance)
+		}
+
+		if p.config.Baker.ConsolidateManagers {
+			if err := p.detectManagers(rewardsSplit.Delegators); err != nil {
+				return rewardsSplit, errors.Wrap(err, "failed to contruct payout")
+			}
+		}
 	}
 
+	p.applyInsurance(&rewardsSplit, totalRewards)
+
 	for _, contract := range dexterContracts {
 		contract, err = p.constructDelegation(contract, totalRewards, rewardsSplit.StakingBalance)
 		if err != nil {
@@ -143,6 +473,11 @@ func (p *Payout) constructPayout() (tzkt.RewardsSplit, error) {
 		rewardsSplit.Delegators = append(rewardsSplit.Delegators, contract)
 	}
 
+	summarizeCampaigns(&rewardsSplit)
+	p.applyDonation(&rewardsSplit)
+	p.applyBondPool(&rewardsSplit)
+	p.applySweep(&rewardsSplit)
+
 	return rewardsSplit, nil
 }
 
@@ -162,36 +497,84 @@ func (p *Payout) splitDelegationsAndDexterContracts(rewardsSplit tzkt.RewardsSpl
 
 func (p *Payout) constructDelegation(delegator tzkt.Delegator, totalRewards, stakingBalance int) (tzkt.Delegator, error) {
 	delegator.Share = float64(delegator.Balance) / float64(stakingBalance)
-	if p.config.Baker.EarningsOnly {
-		delegator.GrossRewards = int(delegator.Share * float64(totalRewards))
-	} else {
-		delegator.GrossRewards = int(delegator.Share * float64(totalRewards))
-	}
-	delegator.Fee = int(float64(delegator.GrossRewards) * p.config.Baker.Fee)
-	delegator.NetRewards = int(delegator.GrossRewards - delegator.Fee)
+	delegator.GrossRewards = p.round(exactRewards(delegator.Balance, stakingBalance, totalRewards))
+	delegator.Fee = p.fee(delegator.Address, delegator.GrossRewards)
+	delegator.NetRewards = delegator.GrossRewards - delegator.Fee
+	p.applyCampaign(&delegator)
 
 	if p.isInBlacklist(delegator.Address) {
 		delegator.BlackListed = true
+		delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonBlacklist)
+	}
+
+	if p.isExcludedContract(delegator.Address) {
+		delegator.BlackListed = true
+		delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonContract)
+	}
+
+	if payoutAddress := p.config.Baker.PayoutAddress(delegator.Address); payoutAddress != delegator.Address {
+		delegator.PayoutAddress = payoutAddress
+	}
+
+	var held bool
+	if !delegator.BlackListed {
+		refuses, err := p.refusesTransfers(delegator.Address)
+		if err != nil {
+			return delegator, errors.Wrap(err, "failed to contruct delegation")
+		}
+
+		// Rewards of a contract that can not take them go to the fallback address, or are held in the ledger until one is set
+		if refuses && p.config.Baker.ContractFallbackAddress != "" {
+			delegator.PayoutAddress = p.config.Baker.ContractFallbackAddress
+		} else if refuses {
+			held = true
+		}
 	}
 
 	if !p.config.Baker.BakerPaysBurnFees {
-		requiresBurnFee, err := p.requiresBurnFee(delegator.Address)
+		requiresBurnFee, err := p.requiresBurnFee(p.destination(delegator))
 		if err != nil {
 			return delegator, errors.Wrap(err, "failed to contruct delegation")
 		}
 		if requiresBurnFee {
 			delegator.BlackListed = true
+			delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonEmptyAccount)
 		}
 	}
 
-	if delegator.NetRewards < p.config.Baker.MinimumPayment {
+	if !delegator.BlackListed && p.usesLedger() && !p.isDexterContract(delegator.Address) {
+		var err error
+		if delegator, err = p.accumulate(delegator); err != nil {
+			return delegator, errors.Wrap(err, "failed to contruct delegation")
+		}
+	}
+
+	if held {
+		delegator.Accumulated = true
+		delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonHeld)
+	} else if delegator.Accumulated && p.config.Baker.Schedule(delegator.Address) != "" {
+		delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonScheduled)
+	} else if delegator.Accumulated {
+		delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonAccumulated)
+	} else if delegator.NetRewards < p.config.Baker.MinimumPayment {
 		delegator.BlackListed = true
+		delegator.SkipReason = skipReason(delegator.SkipReason, SkipReasonMinimumPayment)
 	}
 
 	return delegator, nil
 }
 
+// SetFutureRewards counts the rewards expected from the future rights of the cycle, to estimate a cycle in progress
+func (p *Payout) SetFutureRewards() {
+	p.future = true
+}
+
+// calculateTotals returns the rewards to share for the cycle, with the math of the reward model of the cycle
 func (p *Payout) calculateTotals(rewards tzkt.RewardsSplit) int {
+	if rewards.RewardModel == RewardModelTenderbake {
+		return p.calculateTenderbakeTotals(rewards)
+	}
+
 	if p.config.Baker.EarningsOnly {
 		return rewards.EndorsementRewards +
 			rewards.RevelationRewards +
@@ -212,37 +595,105 @@ func (p *Payout) calculateTotals(rewards tzkt.RewardsSplit) int {
 		rewards.ExtraBlockRewards
 }
 
+/*
+calculateTenderbakeTotals sums the rewards of a Tenderbake cycle, where block rewards and bonuses go to the baker
+proposing or baking a block and endorsing rewards are paid at the end of the cycle, lost if the baker did not endorse
+enough.
+*/
+func (p *Payout) calculateTenderbakeTotals(rewards tzkt.RewardsSplit) int {
+	if p.config.Baker.EarningsOnly {
+		return rewards.BlockRewards +
+			rewards.BlockFees +
+			rewards.EndorsementRewards +
+			rewards.RevelationRewards
+	}
+
+	return rewards.BlockRewards +
+		rewards.MissedBlockRewards +
+		rewards.BlockFees +
+		rewards.MissedBlockFees +
+		rewards.EndorsementRewards +
+		rewards.MissedEndorsementRewards +
+		rewards.RevelationRewards
+}
+
 func (p *Payout) apply(delegators tzkt.Delegators) ([]string, error) {
+	if p.config.Operations.DisperseContract != "" {
+		return p.applyDisperse(delegators)
+	}
+
 	head, err := p.rpc.Head()
 	if err != nil {
 		return []string{}, errors.Wrap(err, "failed to apply payout")
 	}
 
-	var operationStrings []string
+	var operations []rpc.Contents
 	transactionBatches, err := p.constructTransactionBatches(head.Hash, delegators)
 	if err != nil {
 		return []string{}, errors.Wrap(err, "failed to contruct batch transactions")
 	}
-	for _, transactions := range transactionBatches {
-		if operation, err := forge.Encode(head.Hash, transactions...); err == nil {
-			operationStrings = append(operationStrings, operation)
-		} else {
+
+	constants, err := p.rpc.Constants(head.Hash)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to get protocol limits")
+	}
+
+	for _, batch := range transactionBatches {
+		// Batches of delegators skipped, e.g. paid by the chunks of an interrupted payout, are left out
+		if len(batch) == 0 {
+			continue
+		}
+
+		split, err := splitWithinLimits(head.Hash, batch, constants)
+		if err != nil {
 			return []string{}, errors.Wrap(err, "failed to forge operation")
 		}
+		operations = append(operations, split...)
 	}
 
-	operationHashes, err := p.injectOperations(operationStrings)
+	chunks := contentsTransfers(operations)
+	progress, err := p.loadProgress()
 	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to apply payout")
+	}
+
+	if operations, err = p.withReveal(head.Hash, operations); err != nil {
+		return []string{}, errors.Wrap(err, "failed to apply payout")
+	}
+
+	if operations, err = p.estimateOperations(head, operations, constants); err != nil {
 		return []string{}, errors.Wrap(err, "failed to forge operation")
 	}
 
+	// A resumed payout pays the delegators left in new chunks, which need an approval of their own
+	if p.usesApproval() {
+		if err := p.checkApproval(chunks); err != nil {
+			return []string{}, err
+		}
+	}
+
+	if p.confirmer != nil {
+		if err := p.checkConfirmation(chunks); err != nil {
+			return []string{}, err
+		}
+	}
+
+	operationHashes, err := p.injectContents(head.Hash, operations, progress)
+	if err != nil {
+		return []string{}, errors.Wrap(err, "failed to forge operation")
+	}
+
+	if progress != nil {
+		return progress.Operations, nil
+	}
+
 	return operationHashes, nil
 }
 
 func (p *Payout) constructTransactionBatches(blockhash string, delegators tzkt.Delegators) ([]rpc.Contents, error) {
 	var transactionBatches []rpc.Contents
 
-	counter, err := p.rpc.Counter(blockhash, p.key.PubKey.GetPublicKeyHash())
+	counter, err := p.rpc.Counter(blockhash, p.Wallet())
 	if err != nil {
 		return nil, err
 	}
@@ -261,8 +712,8 @@ func (p *Payout) constructTransactionBatches(blockhash string, delegators tzkt.D
 						counter++
 						transactions = append(transactions, rpc.Content{
 							Kind:         rpc.TRANSACTION,
-							Source:       p.key.PubKey.GetPublicKeyHash(),
-							Destination:  liquidityProvider.Address,
+							Source:       p.Wallet(),
+							Destination:  p.config.Baker.PayoutAddress(liquidityProvider.Address),
 							Amount:       int64(liquidityProvider.NetRewards),
 							Fee:          int64(p.config.Operations.NetworkFee),
 							GasLimit:     int64(p.config.Operations.GasLimit),
@@ -272,12 +723,12 @@ func (p *Payout) constructTransactionBatches(blockhash string, delegators tzkt.D
 					}
 				}
 			} else {
-				if !delegation.BlackListed { // don't payout to rewards smaller than minimal payment or that are blacklisted
+				if !delegation.BlackListed && !delegation.Accumulated { // don't payout to rewards smaller than minimal payment, blacklisted, or carried forward
 					counter++
 					transactions = append(transactions, rpc.Content{
 						Kind:         rpc.TRANSACTION,
-						Source:       p.key.PubKey.GetPublicKeyHash(),
-						Destination:  delegation.Address,
+						Source:       p.Wallet(),
+						Destination:  p.destination(delegation),
 						Amount:       int64(delegation.NetRewards),
 						Fee:          int64(p.config.Operations.NetworkFee),
 						GasLimit:     int64(p.config.Operations.GasLimit),
@@ -315,15 +766,21 @@ func (p *Payout) batch(delegators tzkt.Delegators) []tzkt.Delegators {
 func (p *Payout) injectOperations(operations []string) ([]string, error) {
 	ophashes := []string{}
 	for i, op := range operations {
-		signedop, err := p.key.Sign(keys.SignInput{
+		p.keepForged(op)
+		signedop, err := p.sign(keys.SignInput{
 			Message: op,
 		})
 		if err != nil {
 			return ophashes, errors.Wrap(err, "failed to inject operation")
 		}
 
+		signed := fmt.Sprintf("%s%s", op, hex.EncodeToString(signedop.Bytes))
+		if err := p.savePending(signed); err != nil {
+			return ophashes, errors.Wrap(err, "failed to inject operation")
+		}
+
 		ophash, err := p.rpc.InjectionOperation(rpc.InjectionOperationInput{
-			Operation: fmt.Sprintf("%s%s", op, hex.EncodeToString(signedop.Bytes)),
+			Operation: signed,
 		})
 		if err != nil {
 			return ophashes, errors.Wrap(err, "failed to inject operation")
@@ -338,7 +795,7 @@ func (p *Payout) injectOperations(operations []string) ([]string, error) {
 		}
 
 		if !p.confirmOperation(ophash) {
-			return ophashes, errors.Wrap(err, "failed to inject operation: failed to confirm operation")
+			return ophashes, errors.New("failed to inject operation: failed to confirm operation")
 		}
 
 		if p.verbose {
@@ -355,6 +812,10 @@ func (p *Payout) injectOperations(operations []string) ([]string, error) {
 func (p *Payout) confirmOperation(operation string) bool {
 	timer := time.After(confirmationTimoutInterval)
 	ticker := time.Tick(confirmationDurationInterval)
+	var cancelled <-chan struct{}
+	if p.ctx != nil {
+		cancelled = p.ctx.Done()
+	}
 	for {
 		select {
 		case <-ticker:
@@ -371,10 +832,17 @@ func (p *Payout) confirmOperation(operation string) bool {
 			}
 		case <-timer:
 			return false
+		case <-cancelled:
+			return false
 		}
 	}
 }
 
+// interrupted returns true once the context of the payout is done, e.g. tzpay was interrupted while executing it
+func (p *Payout) interrupted() bool {
+	return p.ctx != nil && p.ctx.Err() != nil
+}
+
 func (p *Payout) isInBlacklist(delegation string) bool {
 	for _, b := range p.config.Baker.Blacklist {
 		if b == delegation {
@@ -385,6 +853,11 @@ func (p *Payout) isInBlacklist(delegation string) bool {
 	return false
 }
 
+// isExcludedContract checks if a delegator is a smart contract left out of payouts, liquidity contracts aside
+func (p *Payout) isExcludedContract(delegation string) bool {
+	return p.config.Baker.ExcludeContracts && strings.HasPrefix(delegation, "KT1") && !p.isDexterContract(delegation)
+}
+
 // checks if the account needs a burn fee - accounts that do will be skipped
 func (p *Payout) requiresBurnFee(delegation string) (bool, error) {
 	balance, err := p.rpc.Balance(rpc.BalanceInput{
@@ -1,6 +1,7 @@
 package payout
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -164,6 +165,7 @@ func Test_constructPayout(t *testing.T) {
 					EndorsementDeposits:      8064000000,
 					OwnBlockFees:             47180,
 					MissedOwnBlockFees:       54607,
+					RewardModel:              RewardModelEmmy,
 					Delegators: tzkt.Delegators{
 						tzkt.Delegator{
 							Address:        "tz1icdoLr8vof5oXiEKCFSyrVoouGiKDQ3Gd",
@@ -234,6 +236,7 @@ func Test_constructPayout(t *testing.T) {
 					EndorsementDeposits:      8064000000,
 					OwnBlockFees:             47180,
 					MissedOwnBlockFees:       54607,
+					RewardModel:              RewardModelEmmy,
 					Delegators: tzkt.Delegators{
 						tzkt.Delegator{
 							Address:        "tz1icdoLr8vof5oXiEKCFSyrVoouGiKDQ3Gd",
@@ -306,6 +309,7 @@ func Test_constructPayout(t *testing.T) {
 					EndorsementDeposits:      8064000000,
 					OwnBlockFees:             47180,
 					MissedOwnBlockFees:       54607,
+					RewardModel:              RewardModelEmmy,
 					Delegators: tzkt.Delegators{
 						tzkt.Delegator{
 							Address:        "KT1LgkGigaMrnim3TonQWfwDHnM3fHkF1jMv",
@@ -474,6 +478,7 @@ func Test_constructDelegation(t *testing.T) {
 					Share:        0.005,
 					Fee:          2500,
 					BlackListed:  true,
+					SkipReason:   SkipReasonBlacklist,
 				},
 				false,
 				"",
@@ -575,6 +580,42 @@ func Test_calculateTotals(t *testing.T) {
 			},
 			29976187,
 		},
+		{
+			"handles tenderbake earnings only",
+			input{
+				true,
+				tzkt.RewardsSplit{
+					RewardModel:              RewardModelTenderbake,
+					BlockRewards:             20000000,
+					BlockFees:                3000,
+					EndorsementRewards:       10000000,
+					RevelationRewards:        1000,
+					MissedBlockRewards:       5000000,
+					MissedBlockFees:          2000,
+					MissedEndorsementRewards: 4000000,
+					OwnBlockRewards:          24124321,
+				},
+			},
+			30004000,
+		},
+		{
+			"handles tenderbake earnings only false",
+			input{
+				false,
+				tzkt.RewardsSplit{
+					RewardModel:              RewardModelTenderbake,
+					BlockRewards:             20000000,
+					BlockFees:                3000,
+					EndorsementRewards:       10000000,
+					RevelationRewards:        1000,
+					MissedBlockRewards:       5000000,
+					MissedBlockFees:          2000,
+					MissedEndorsementRewards: 4000000,
+					OwnBlockRewards:          24124321,
+				},
+			},
+			39006000,
+		},
 	}
 
 	for _, tt := range cases {
@@ -937,6 +978,46 @@ func Test_constructTransactionBatches(t *testing.T) {
 	}
 }
 
+func Test_constructTransactionBatchesPayoutAddresses(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	payout := &Payout{
+		config: config.Config{
+			Baker: config.Baker{
+				PayoutAddresses: []string{"somedelegation:somecoldwallet", "liquidity_provider:someothercoldwallet"},
+			},
+			Operations: config.Operations{
+				BatchSize: 100,
+			},
+		},
+		rpc: &test.RPCMock{},
+		key: key,
+	}
+
+	contents, err := payout.constructTransactionBatches("some_hash", tzkt.Delegators{
+		{Address: "somedelegation", NetRewards: 900000},
+		{Address: "someotherdelegation", NetRewards: 950000},
+		{
+			Address: "delegation_dexter",
+			LiquidityProviders: []tzkt.LiquidityProvider{
+				{Address: "liquidity_provider", NetRewards: 950000},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	var destinations []string
+	for _, content := range contents[0] {
+		destinations = append(destinations, content.Destination)
+	}
+	assert.Equal(t, []string{"somecoldwallet", "someotherdelegation", "someothercoldwallet"}, destinations)
+}
+
 func Test_batch(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -996,9 +1077,13 @@ func Test_batch(t *testing.T) {
 }
 
 func Test_injectOperations(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
 	type input struct {
 		rpcClient  rpc.IFace
 		operations []string
+		ctx        context.Context
 	}
 
 	type want struct {
@@ -1056,6 +1141,21 @@ func Test_injectOperations(t *testing.T) {
 				[]string{"ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M"},
 			},
 		},
+		{
+			"handles operation not confirmed",
+			input{
+				rpcClient: &test.RPCMock{},
+				operations: []string{
+					"5aff622d53d32a8bae591627718c60a35b16737e301c57a13b6f1765483d88ff6c007fd82c06cf5a203f18faaf562447ed1efcc6c010830a07c350008090dfc04a0000a31e81ac3425310e3274a4698a793b2839dc0afa00",
+				},
+				ctx: cancelled,
+			},
+			want{
+				true,
+				"failed to inject operation: failed to confirm operation",
+				[]string{"ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M"},
+			},
+		},
 	}
 
 	for _, tt := range cases {
@@ -1070,6 +1170,7 @@ func Test_injectOperations(t *testing.T) {
 			payout := Payout{
 				rpc: tt.input.rpcClient,
 				key: key,
+				ctx: tt.input.ctx,
 			}
 
 			ophashes, err := payout.injectOperations(tt.input.operations)
@@ -1160,6 +1261,35 @@ func Test_isInBlacklist(t *testing.T) {
 	}
 }
 
+func Test_isExcludedContract(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		exclude bool
+		want    bool
+	}{
+		{"excludes contract", "KT1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", true, true},
+		{"keeps implicit account", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", true, false},
+		{"keeps liquidity contract", "KT1dexter", true, false},
+		{"keeps contract if disabled", "KT1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", false, false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{
+				config: config.Config{
+					Baker: config.Baker{
+						ExcludeContracts:         tt.exclude,
+						DexterLiquidityContracts: []string{"KT1dexter"},
+					},
+				},
+			}
+
+			assert.Equal(t, tt.want, payout.isExcludedContract(tt.input))
+		})
+	}
+}
+
 func Test_isDexterContract(t *testing.T) {
 	cases := []struct {
 		name  string
@@ -0,0 +1,150 @@
+package payout
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
+)
+
+// operationHashPrefix is the base58 prefix of an operation hash ("o...")
+var operationHashPrefix = []byte{5, 116}
+
+/*
+Pending is the chunk of a payout being injected, saved with the hash of its operation before the operation is injected,
+so that a payout interrupted before seeing the chunk included finds out whether it was on resuming, instead of forging
+it again and paying its delegators twice. Every fee bump of the operation adds its hash, the versions sharing counters.
+*/
+type Pending struct {
+	Hashes    []string           `json:"hashes"`
+	Level     int                `json:"level"`    // the level of the head when the chunk was first injected
+	Counters  [2]int             `json:"counters"` // the first and last counters of the operation
+	Transfers []disperseTransfer `json:"transfers"`
+}
+
+// operationHash returns the hash of a signed operation, hex encoded, as the node returns it on injecting it
+func operationHash(signed string) (string, error) {
+	byts, err := hex.DecodeString(signed)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to hex decode signed operation")
+	}
+
+	hash := blake2b.Sum256(byts)
+	return base58check.Encode(operationHashPrefix, hash[:]), nil
+}
+
+// trackPending makes contents, paying the transfers of the next chunk of progress, the chunk pending its injection
+func (p *Payout) trackPending(progress *Progress, transfers []disperseTransfer, contents rpc.Contents, level int) {
+	if progress == nil || len(contents) == 0 {
+		return
+	}
+
+	progress.Pending = &Pending{
+		Level:     level,
+		Counters:  [2]int{contents[0].Counter, contents[len(contents)-1].Counter},
+		Transfers: transfers,
+	}
+	p.pending = progress
+}
+
+// untrackPending stops saving the operations injected as the pending chunk of the payout
+func (p *Payout) untrackPending() {
+	p.pending = nil
+}
+
+// savePending saves the hash of the signed operation about to be injected to the pending chunk, if one is tracked
+func (p *Payout) savePending(signed string) error {
+	if p.pending == nil || p.pending.Pending == nil {
+		return nil
+	}
+
+	ophash, err := operationHash(signed)
+	if err != nil {
+		return errors.Wrap(err, "failed to save pending operation")
+	}
+
+	p.pending.Pending.Hashes = append(p.pending.Pending.Hashes, ophash)
+	if err := p.store.Put(progressBucket(p.config.Baker.Address), p.progressKey(), p.pending); err != nil {
+		return errors.Wrapf(err, "failed to save pending operation of payout for cycle %d", p.cycle)
+	}
+
+	return nil
+}
+
+/*
+resolvePending looks up on chain the operation of the chunk an interrupted payout of the cycle was injecting when it
+stopped, before anything is forged again. A chunk found included is recorded as confirmed, and its delegators as paid,
+so that the payout skips them. A chunk that is not is dropped, unless its operation may still be included, in which case
+the payout fails until the operation expired or the wallet used its counters.
+*/
+func (p *Payout) resolvePending(delegators, unconsolidated tzkt.Delegators) error {
+	progress := &Progress{}
+	if ok, err := p.store.Get(progressBucket(p.config.Baker.Address), p.progressKey(), progress); err != nil {
+		return errors.Wrapf(err, "failed to get progress of payout for cycle %d", p.cycle)
+	} else if !ok || progress.Pending == nil {
+		return nil
+	}
+	pending := progress.Pending
+	progress.Pending = nil
+
+	ophash, err := p.pendingIncluded(pending)
+	if err != nil {
+		return errors.Wrapf(err, "failed to resolve pending operation of payout for cycle %d", p.cycle)
+	}
+
+	if ophash == "" {
+		logrus.WithFields(logrus.Fields{"cycle": p.cycle, "operations": pending.Hashes}).Warn("Pending operation of interrupted payout was not included, forging its chunk again.")
+		if err := p.store.Put(progressBucket(p.config.Baker.Address), p.progressKey(), progress); err != nil {
+			return errors.Wrapf(err, "failed to save progress of payout for cycle %d", p.cycle)
+		}
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{"cycle": p.cycle, "operation": ophash}).Warn("Pending operation of interrupted payout was included, resuming after its chunk.")
+	p.paying = p.payees(delegators, unconsolidated)
+	defer func() { p.paying = nil }()
+
+	return p.saveProgress(progress, pending.Transfers, ophash)
+}
+
+// pendingIncluded returns the hash of the version of the pending operation included, or none if it was not and can't be
+func (p *Payout) pendingIncluded(pending *Pending) (string, error) {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", err
+	}
+
+	// The last version is injected at most BumpAttempts times BumpAfterBlocks after the first and expires after the TTL
+	expires := pending.Level + maxOperationsTTL + p.config.Operations.BumpAttempts*p.config.Operations.BumpAfterBlocks
+	last := expires
+	if head.Header.Level < last {
+		last = head.Header.Level
+	}
+
+	for level := last; level >= pending.Level && level > 0; level-- {
+		included, err := p.rpc.OperationHashes(strconv.Itoa(level))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get operations at level %d", level)
+		}
+
+		if ophash, ok := includes(included, pending.Hashes); ok {
+			return ophash, nil
+		}
+	}
+
+	counter, err := p.rpc.Counter(head.Hash, p.Wallet())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get counter")
+	}
+
+	if counter < pending.Counters[1] && head.Header.Level < expires {
+		return "", errors.Errorf("operations %v may still be included until level %d", pending.Hashes, expires)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,150 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+// pendingRPC is a node at level head whose block at includeAt includes ophash, and whose payout wallet is at counter
+type pendingRPC struct {
+	test.RPCMock
+	head      int
+	includeAt int
+	ophash    string
+	counter   int
+}
+
+func (p *pendingRPC) Head() (*rpc.Block, error) {
+	block := &rpc.Block{Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p"}
+	block.Header.Level = p.head
+	return block, nil
+}
+
+func (p *pendingRPC) OperationHashes(blockhash string) ([][]string, error) {
+	if level, _ := strconv.Atoi(blockhash); level == p.includeAt {
+		return [][]string{{}, {}, {}, {p.ophash}}, nil
+	}
+	return [][]string{}, nil
+}
+
+func (p *pendingRPC) Counter(blockhash, pkh string) (int, error) {
+	return p.counter, nil
+}
+
+func Test_operationHash(t *testing.T) {
+	ophash, err := operationHash("a732d3520eeaa3de98d78e5e5cb6c85f72204fd46feb9f76853841d4a701add36c0008ba0cb2fad622697145cf1665124096d25bc31ef44e0af44e00b960000008ba0cb2fad622697145cf1665124096d25bc31e00")
+	assert.Nil(t, err)
+	assert.Equal(t, "o", ophash[:1])
+	assert.Len(t, ophash, 51)
+
+	_, err = operationHash("not hex")
+	test.CheckErr(t, true, "failed to hex decode signed operation", err)
+}
+
+func Test_injectContents_Pending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-pending")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	payout := &Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+		rpc:    &resumeRPCMock{failAt: 1},
+		store:  s,
+		key:    key,
+		cycle:  270,
+	}
+
+	contents := rpc.Contents{
+		{Kind: rpc.TRANSACTION, Source: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000, Counter: 101, Fee: 2941, GasLimit: 26283},
+		{Kind: rpc.TRANSACTION, Source: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Amount: 950000, Counter: 102, Fee: 2941, GasLimit: 26283},
+	}
+	_, err = payout.injectContents("BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", []rpc.Contents{contents}, &Progress{})
+	test.CheckErr(t, true, "failed to inject operation", err)
+	assert.Nil(t, payout.pending)
+
+	// The operation is saved as pending before the node refused it
+	var progress Progress
+	ok, err := s.Get(progressBucket("tz1baker"), payout.progressKey(), &progress)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0, progress.Chunks)
+	assert.NotNil(t, progress.Pending)
+	assert.Len(t, progress.Pending.Hashes, 1)
+	assert.Equal(t, [2]int{101, 102}, progress.Pending.Counters)
+	assert.Equal(t, contentsTransfers([]rpc.Contents{contents})[0], progress.Pending.Transfers)
+}
+
+func Test_resolvePending(t *testing.T) {
+	delegators := tzkt.Delegators{
+		{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000},
+		{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", NetRewards: 950000},
+	}
+	pending := &Pending{
+		Hashes:    []string{"ooFirst", "ooBumped"},
+		Level:     1000,
+		Counters:  [2]int{101, 101},
+		Transfers: []disperseTransfer{{Destination: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Amount: 900000}},
+	}
+
+	cases := []struct {
+		name     string
+		rpc      *pendingRPC
+		err      bool
+		contains string
+		chunks   int
+		unpaid   []string
+	}{
+		{"records an included operation as confirmed", &pendingRPC{head: 1010, includeAt: 1003, ophash: "ooBumped", counter: 101}, false, "", 1, []string{"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"}},
+		{"drops an expired operation", &pendingRPC{head: 1100, counter: 100}, false, "", 0, []string{"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"}},
+		{"drops an operation whose counters were used by another", &pendingRPC{head: 1010, counter: 101}, false, "", 0, []string{"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"}},
+		{"refuses an operation that may still be included", &pendingRPC{head: 1010, counter: 100}, true, "may still be included until level 1060", 0, []string{"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tzpay-pending")
+			assert.Nil(t, err)
+			defer os.RemoveAll(dir)
+
+			s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+			assert.Nil(t, err)
+
+			payout := &Payout{config: config.Config{Baker: config.Baker{Address: "tz1baker"}}, rpc: tt.rpc, store: s, cycle: 270}
+			assert.Nil(t, s.Put(progressBucket("tz1baker"), payout.progressKey(), &Progress{Pending: pending}))
+
+			err = payout.resolvePending(delegators, delegators)
+			test.CheckErr(t, tt.err, tt.contains, err)
+
+			var progress Progress
+			_, err = s.Get(progressBucket("tz1baker"), payout.progressKey(), &progress)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.chunks, progress.Chunks)
+			assert.Equal(t, tt.err, progress.Pending != nil)
+
+			unpaid, err := payout.payouts().ListUnpaid("tz1baker", 270, []string{delegators[0].Address, delegators[1].Address})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.unpaid, unpaid)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package payout
+
+import (
+	"fmt"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+Progress holds the operations of the chunks of a payout confirmed so far, and the chunk pending its injection. The
+delegators of a confirmed chunk are recorded as paid as soon as it is confirmed, so that a payout interrupted between
+two chunks and run again skips them, and pays the rest in new chunks. The operations of the chunks confirmed before it
+was interrupted are reported along with those of the new chunks.
+*/
+type Progress struct {
+	Chunks     int      `json:"chunks"`
+	Operations []string `json:"operations"`
+	Pending    *Pending `json:"pending,omitempty"`
+}
+
+func progressBucket(baker string) string {
+	return "progress/" + baker
+}
+
+func (p *Payout) progressKey() string {
+	return fmt.Sprintf("%08d", p.cycle)
+}
+
+// contentsTransfers returns the transfers made by each chunk of operations
+func contentsTransfers(operations []rpc.Contents) [][]disperseTransfer {
+	chunks := make([][]disperseTransfer, len(operations))
+	for i, contents := range operations {
+		for _, content := range contents {
+			if content.Kind == rpc.REVEAL {
+				continue
+			}
+			chunks[i] = append(chunks[i], disperseTransfer{Destination: content.Destination, Amount: content.Amount})
+		}
+	}
+
+	return chunks
+}
+
+// loadProgress returns the progress of the payout, or nil if progress is not persisted
+func (p *Payout) loadProgress() (*Progress, error) {
+	if p.store == nil {
+		return nil, nil
+	}
+
+	progress := &Progress{}
+	if _, err := p.store.Get(progressBucket(p.config.Baker.Address), p.progressKey(), progress); err != nil {
+		return nil, errors.Wrapf(err, "failed to get progress of payout for cycle %d", p.cycle)
+	}
+
+	if progress.Chunks > 0 {
+		logrus.WithFields(logrus.Fields{
+			"cycle":      p.cycle,
+			"chunks":     progress.Chunks,
+			"operations": progress.Operations,
+		}).Warn("Resuming interrupted payout, skipping the delegators of its confirmed chunks.")
+	}
+
+	return progress, nil
+}
+
+// saveProgress records a chunk of transfers as confirmed by operations, and the delegators it paid as paid
+func (p *Payout) saveProgress(progress *Progress, transfers []disperseTransfer, ophashes ...string) error {
+	p.recordOperations(transfers, ophashes)
+	if progress == nil {
+		return nil
+	}
+
+	if err := p.recordTransfersPaid(transfers); err != nil {
+		return errors.Wrapf(err, "failed to save progress of payout for cycle %d", p.cycle)
+	}
+
+	progress.Chunks++
+	progress.Pending = nil
+	progress.Operations = append(progress.Operations, ophashes...)
+	if err := p.store.Put(progressBucket(p.config.Baker.Address), p.progressKey(), progress); err != nil {
+		return errors.Wrapf(err, "failed to save progress of payout for cycle %d", p.cycle)
+	}
+
+	return nil
+}
+
+// clearProgress removes the progress of a payout once all of its chunks were confirmed
+func (p *Payout) clearProgress() error {
+	if err := p.store.Delete(progressBucket(p.config.Baker.Address), p.progressKey()); err != nil {
+		return errors.Wrapf(err, "failed to clear progress of payout for cycle %d", p.cycle)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package payout
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+// resumeRPCMock fails the injection passed as failAt, and counts the injections made
+type resumeRPCMock struct {
+	test.RPCMock
+	injections int
+	failAt     int
+}
+
+func (r *resumeRPCMock) InjectionOperation(input rpc.InjectionOperationInput) (string, error) {
+	r.injections++
+	if r.injections == r.failAt {
+		return "", errors.New("failed to inject operation")
+	}
+
+	return r.RPCMock.InjectionOperation(input)
+}
+
+func Test_apply_Resume(t *testing.T) {
+	defer func(interval time.Duration) { confirmationDurationInterval = interval }(confirmationDurationInterval)
+	confirmationDurationInterval = time.Millisecond
+
+	dir, err := ioutil.TempDir("", "tzpay-progress")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	delegators := tzkt.Delegators{
+		{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900000},
+		{Address: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", NetRewards: 950000},
+		{Address: "tz1L8fUQLuwRuywTZUP5JUw9LL3kJa8LMfoo", NetRewards: 970000},
+	}
+
+	mock := &resumeRPCMock{failAt: 2}
+	payout := Payout{
+		config: config.Config{
+			Baker: config.Baker{Address: "tz1baker"},
+			Operations: config.Operations{
+				GasLimit:   10000,
+				NetworkFee: 3000,
+				BatchSize:  1,
+			},
+		},
+		rpc:   mock,
+		store: s,
+		key:   key,
+		cycle: 270,
+	}
+
+	payout.paying = payout.payees(delegators, delegators)
+	_, err = payout.apply(delegators)
+	test.CheckErr(t, true, "failed to inject operation", err)
+
+	var progress Progress
+	_, err = s.Get(progressBucket("tz1baker"), payout.progressKey(), &progress)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, progress.Chunks)
+	assert.Len(t, progress.Operations, 1)
+
+	// The delegator of the confirmed chunk is recorded as paid before the payout completes, the others are not
+	unpaid, err := payout.payouts().ListUnpaid("tz1baker", 270, []string{delegators[0].Address, delegators[1].Address, delegators[2].Address})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{delegators[1].Address, delegators[2].Address}, unpaid)
+
+	// Run again, the payout skips the paid delegator and reports the operation of the confirmed chunk with its own
+	left, skipped, err := payout.skipPaid(delegators)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, skipped)
+
+	mock.injections, mock.failAt = 0, 0
+	ophashes, err := payout.apply(left)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, mock.injections)
+	assert.Len(t, ophashes, 3)
+	assert.Equal(t, progress.Operations[0], ophashes[0])
+
+	assert.Nil(t, payout.clearProgress())
+	mock.injections = 0
+	_, err = payout.apply(delegators)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, mock.injections)
+}
+
+func Test_recordTransfersPaid_Partial(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-progress")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	unconsolidated := tzkt.Delegators{
+		{Address: "KT1first", Manager: "tz1manager", NetRewards: 300},
+		{Address: "KT1second", Manager: "tz1manager", NetRewards: 200},
+		{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", NetRewards: 900},
+	}
+
+	payout := Payout{config: config.Config{Baker: config.Baker{Address: "tz1baker"}}, store: s, cycle: 270}
+	payout.SetPartial()
+	delegators := payout.consolidate(unconsolidated)
+	payout.paying = payout.payees(delegators, unconsolidated)
+
+	// A chunk paying a manager records what it paid each of the delegations it consolidates
+	assert.Nil(t, payout.recordTransfersPaid(payout.transfers(delegators[:1])))
+	assert.Nil(t, payout.recordTransfersPaid(payout.transfers(delegators)))
+
+	for address, paid := range map[string]int{"KT1first": 300, "KT1second": 200, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc": 900} {
+		var entry PartialEntry
+		_, err := s.Get(partialBucket("tz1baker"), paidKey(270, address), &entry)
+		assert.Nil(t, err)
+		assert.Equal(t, paid, entry.Paid, address)
+	}
+
+	// Partial payouts do not record the cycle as paid
+	unpaid, err := payout.payouts().ListUnpaid("tz1baker", 270, []string{"tz1manager"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tz1manager"}, unpaid)
+}
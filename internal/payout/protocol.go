@@ -0,0 +1,69 @@
+package payout
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Reward models of the Tezos protocols
+const (
+	RewardModelEmmy       = "emmy"
+	RewardModelTenderbake = "tenderbake"
+)
+
+// emmyProtocols are the protocols that froze baking and endorsing rewards for the preserved cycles, up to Hangzhou
+var emmyProtocols = map[string]bool{
+	"PtCJ7pwoxe8JasnHY8YonnLYjcVHmhiARPJvqcC6VfHT5s8k8sY": true,
+	"PsYLVpVvgbLhAhoqAkMFUo6gudkJ9weNXhUYCiLDzcUpFpkk8Wt": true,
+	"PsddFKi32cMJ2qPjf43Qv5GDWLDPZb3T3bF6fLKiF5HtvHNU7aP": true,
+	"Pt24m4xiPbLDhVgVfABUjirbmda3yohdN82Sp1FeuXjiC7iXQNu": true,
+	"PsBabyM1eUXZseaJdmXFApDSBqj8YBfwELoxZHHW77EMcAbbwAS": true,
+	"PsBABY5HQTSkA4297zNHfsZNKtxULfL18y95qb3m53QJiXGmrbU": true,
+	"PsCARTHAGazKbHtnKfLzQg3kms52kSRpgnDY982a9oYsSXRLQEb": true,
+	"PsDELPH1Kxsxt8f9eWbxQeRxkjfbxoqM52jvs5Y5fBxWWh4ifpo": true,
+	"PtEdoTezd3RHSC31mpxxo1npxFjoWWcFgQtxapi51Z8TLu6v6Uq": true,
+	"PtEdo2ZkT9oKpimTah6x2embF25oss54njMuPzkJTEi5RqfdZFA": true,
+	"PsFLorenaUUuikDWvMDr6fGBRG8kt3e3D3fHoXK1j1BFRxeSH4i": true,
+	"PtGRANADsDU8R9daYKAgWnQYAJ64omN1o3KMGVCykShA97vQbvV": true,
+	"PtHangzHogokSuiMHemCuowEavgYTP8J5qQ9fQS793MHYFpCY3r": true,
+	"PtHangz2aRngywmSRGGvrcTyMbbdpWdpFKuS4uMWxg2RaH9i1qx": true,
+}
+
+/*
+RewardModel returns the reward model of a protocol. Every protocol since Ithaca uses Tenderbake, which pays rewards
+straight to the baker's balance, so a protocol tzpay does not know of is assumed to be Tenderbake. A block without a
+protocol is assumed to be Emmy.
+*/
+func RewardModel(protocol string) string {
+	if protocol == "" || emmyProtocols[protocol] {
+		return RewardModelEmmy
+	}
+
+	return RewardModelTenderbake
+}
+
+/*
+protocol returns the protocol of the first block of the cycle of the payout, or the protocol of the head if the cycle
+has not started yet.
+*/
+func (p *Payout) protocol() (string, error) {
+	first, _, err := CycleLevels(p.tzkt, p.cycle)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get protocol of cycle")
+	}
+
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get protocol of cycle")
+	}
+
+	if first > head.Header.Level {
+		return head.Metadata.Protocol, nil
+	}
+
+	block, err := p.rpc.Block(first)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get protocol of cycle from block '%d'", first)
+	}
+
+	return block.Metadata.Protocol, nil
+}
@@ -0,0 +1,122 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type protocolRPCMock struct {
+	test.RPCMock
+	protocols map[int]string
+	head      string
+	level     int
+}
+
+func (p *protocolRPCMock) Block(id interface{}) (*rpc.Block, error) {
+	protocol, ok := p.protocols[id.(int)]
+	if !ok {
+		return &rpc.Block{}, errors.New("block not found")
+	}
+
+	block := &rpc.Block{}
+	block.Metadata.Protocol = protocol
+	return block, nil
+}
+
+func (p *protocolRPCMock) Head() (*rpc.Block, error) {
+	head, err := p.RPCMock.Head()
+	if err != nil {
+		return head, err
+	}
+	head.Metadata.Protocol = p.head
+	head.Header.Level = p.level
+
+	return head, nil
+}
+
+func Test_RewardModel(t *testing.T) {
+	assert.Equal(t, RewardModelEmmy, RewardModel(""))
+	assert.Equal(t, RewardModelEmmy, RewardModel("PtHangz2aRngywmSRGGvrcTyMbbdpWdpFKuS4uMWxg2RaH9i1qx"))
+	assert.Equal(t, RewardModelTenderbake, RewardModel("Psithaca2MLRFYargivpo7YvUr7wUDqyxrdhC5CQq78mRvimz6A"))
+}
+
+func Test_protocol(t *testing.T) {
+	cases := []struct {
+		name     string
+		rpc      rpc.IFace
+		tzkt     *test.TzktMock
+		cycle    int
+		err      bool
+		contains string
+		want     string
+	}{
+		{
+			"handles failure to get levels of cycle",
+			&protocolRPCMock{},
+			&test.TzktMock{CycleErr: true},
+			10,
+			true,
+			"failed to get protocol of cycle: failed to get levels of cycle 10",
+			"",
+		},
+		{
+			"returns protocol of first block of cycle",
+			&protocolRPCMock{protocols: map[int]string{21: "Psithaca2MLRFYargivpo7YvUr7wUDqyxrdhC5CQq78mRvimz6A"}, head: "PtJakart2xVj7pYXJBXrqHgd82rdkLey5ZeeGikDRP7nkMqKXT", level: 30},
+			&test.TzktMock{},
+			10,
+			false,
+			"",
+			"Psithaca2MLRFYargivpo7YvUr7wUDqyxrdhC5CQq78mRvimz6A",
+		},
+		{
+			"returns protocol of first block of cycle after a change of blocks per cycle",
+			&protocolRPCMock{protocols: map[int]string{17: "Psithaca2MLRFYargivpo7YvUr7wUDqyxrdhC5CQq78mRvimz6A"}, head: "PtJakart2xVj7pYXJBXrqHgd82rdkLey5ZeeGikDRP7nkMqKXT", level: 30},
+			&test.TzktMock{Cycles: map[int]tzkt.Cycle{10: {Index: 10, FirstLevel: 17, LastLevel: 24}}},
+			10,
+			false,
+			"",
+			"Psithaca2MLRFYargivpo7YvUr7wUDqyxrdhC5CQq78mRvimz6A",
+		},
+		{
+			"returns protocol of head for cycle not started",
+			&protocolRPCMock{head: "PtJakart2xVj7pYXJBXrqHgd82rdkLey5ZeeGikDRP7nkMqKXT", level: 24},
+			&test.TzktMock{},
+			12,
+			false,
+			"",
+			"PtJakart2xVj7pYXJBXrqHgd82rdkLey5ZeeGikDRP7nkMqKXT",
+		},
+		{
+			"handles failure to get first block of cycle",
+			&protocolRPCMock{head: "PtJakart2xVj7pYXJBXrqHgd82rdkLey5ZeeGikDRP7nkMqKXT", level: 30},
+			&test.TzktMock{},
+			11,
+			true,
+			"failed to get protocol of cycle from block '23'",
+			"",
+		},
+		{
+			"handles failure to get head",
+			&protocolRPCMock{RPCMock: test.RPCMock{HeadErr: true}},
+			&test.TzktMock{},
+			12,
+			true,
+			"failed to get protocol of cycle",
+			"",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{rpc: tt.rpc, tzkt: tt.tzkt, cycle: tt.cycle}
+			protocol, err := payout.protocol()
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.want, protocol)
+		})
+	}
+}
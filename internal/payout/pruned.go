@@ -0,0 +1,51 @@
+package payout
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// contextNotFound are the errors of a node asked to read a context it no longer keeps, as rolling and full nodes do
+// for blocks older than their history
+var contextNotFound = []string{
+	"cannot_checkout_context",
+	"failed to checkout the context",
+	"context_not_found",
+	"context not found",
+}
+
+// prunedContext returns true if err is the node refusing to read the context of a block it pruned
+func prunedContext(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(errors.Cause(err).Error())
+	for _, notFound := range contextNotFound {
+		if strings.Contains(msg, notFound) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+frozenBalance returns the rewards and fees frozen for the baker in the cycle of the payout, as the node reads them from
+the raw context of the first block of the next cycle in a single call. A node pruned of that context refuses the query,
+in which case they are summed from the balance updates of every block of the cycle instead, so that actual rewards
+still work without an archive node.
+*/
+func (p *Payout) frozenBalance() (int, error) {
+	balance, err := p.rpc.FrozenBalance(p.cycle, p.config.Baker.Address)
+	if err == nil {
+		return balance.Rewards + balance.Fees, nil
+	} else if !prunedContext(err) {
+		return 0, errors.Wrap(err, "failed to get frozen balance")
+	}
+
+	logrus.WithFields(logrus.Fields{"cycle": p.cycle, "error": err.Error()}).Warn("Node has no context for the cycle, summing rewards from its blocks.")
+	return p.actualRewards()
+}
@@ -0,0 +1,56 @@
+package payout
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// prunedRPC is a node that fails to read the frozen balance of the baker with err
+type prunedRPC struct {
+	test.RPCMock
+	err error
+}
+
+func (p *prunedRPC) FrozenBalance(cycle int, delegate string) (rpc.FrozenBalance, error) {
+	return rpc.FrozenBalance{}, errors.Wrapf(p.err, "failed to get frozen balance at cycle '%d' for delegate '%s'", cycle, delegate)
+}
+
+func Test_prunedContext(t *testing.T) {
+	assert.True(t, prunedContext(errors.Wrap(&rpc.RPCError{Kind: "permanent", Err: "store.cannot_checkout_context"}, "failed")))
+	assert.True(t, prunedContext(fmt.Errorf("response returned code 500 with body Failed to checkout the context of block BLx")))
+	assert.False(t, prunedContext(fmt.Errorf("response returned code 404 with body Not Found")))
+	assert.False(t, prunedContext(errors.New("failed to complete request")))
+	assert.False(t, prunedContext(nil))
+}
+
+func Test_frozenBalance(t *testing.T) {
+	pruned := &rpc.RPCError{Kind: "permanent", Err: "store.cannot_checkout_context"}
+
+	cases := []struct {
+		name     string
+		rpc      rpc.IFace
+		want     int
+		err      bool
+		contains string
+	}{
+		{"is successful", &test.RPCMock{}, 70000000 + 3000, false, ""},
+		{"sums the blocks of the cycle when the context is pruned", &prunedRPC{err: pruned}, 2 * (40000000 + 3000 + 1250000), false, ""},
+		{"handles failure of the node", &prunedRPC{err: errors.New("failed to complete request")}, 0, true, "failed to get frozen balance"},
+		{"handles a missing balance", &prunedRPC{err: fmt.Errorf("response returned code 404 with body Not Found")}, 0, true, "failed to get frozen balance"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Payout{config: config.Config{Baker: config.Baker{Address: "some_delegate"}}, cycle: 10, rpc: tt.rpc, tzkt: &test.TzktMock{}}
+			balance, err := p.frozenBalance()
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.want, balance)
+		})
+	}
+}
@@ -2,47 +2,219 @@ package payout
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	gotezos "github.com/goat-systems/go-tezos/v2"
 	"github.com/goat-systems/tzpay/v3/internal/notifier"
 	"github.com/goat-systems/tzpay/v3/internal/print"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// QueueBucket is the bucket of the store the payouts waiting in the queue are persisted to
+const QueueBucket = "queue"
+
+// Queued is a payout waiting in the queue as persisted to the store, from which it is rebuilt when the queue is loaded
+type Queued struct {
+	ID       string    `json:"id,omitempty"` // the key it is persisted under, identifying it in the queue
+	Baker    string    `json:"baker"`
+	Cycle    int       `json:"cycle"`
+	Partial  bool      `json:"partial,omitempty"`
+	Enqueued time.Time `json:"enqueued"`
+	Attempts int       `json:"attempts,omitempty"`
+	Approval bool      `json:"approval,omitempty"` // held until the operator approves it, see RequireApproval
+}
+
+// Queue holds payouts waiting to be executed. Payouts are tagged by the baker they belong to,
+// and each baker's payouts are processed independently of the others.
 type Queue struct {
 	notifier       *notifier.PayoutNotifier
 	payouts        []Payout
 	mu             *sync.Mutex
 	logger         *logrus.Logger
 	tickerDuration time.Duration
+	// held is the hold of every payout held for approval that was notified, by baker and cycle
+	held      map[string]string
+	confirmer Confirmer
+	// watcher waits for the operations of every payout of the queue to be included, nil to leave it to each payout
+	watcher *InclusionWatcher
+	// approval holds every payout until the operator approves it
+	approval bool
+	// store persists the payouts of the queue until they are executed, so that they survive a restart
+	store store.IFace
+	last  int64
+	// paused stops the queue from executing payouts, which are still queued
+	paused  bool
+	running []Payout
+	// idle is signaled when the payouts being executed are done
+	idle *sync.Cond
+	// scheduler holds payouts until they may start, and waiting is why each payout held waits, by its key
+	scheduler Scheduler
+	waiting   map[string]string
+}
+
+// Scheduler decides when the payouts of the queue may start
+type Scheduler interface {
+	// Ready returns nil if a payout queued at enqueued may start at now, or why it waits
+	Ready(enqueued, now time.Time) error
+}
+
+// QueueStatus is the state of the queue: the payouts being executed and the payouts waiting, oldest first
+type QueueStatus struct {
+	Paused  bool     `json:"paused"`
+	Running []Queued `json:"running"`
+	Queued  []Queued `json:"queued"`
 }
 
 func NewQueue(notifier *notifier.PayoutNotifier) *Queue {
+	mu := &sync.Mutex{}
 	return &Queue{
 		notifier:       notifier,
-		mu:             &sync.Mutex{},
+		mu:             mu,
 		tickerDuration: time.Minute,
 		logger:         logrus.New(),
+		idle:           sync.NewCond(mu),
 	}
 }
 
-func (q *Queue) Enqueue(p Payout) {
+// SetScheduler sets the scheduler deciding when the payouts of the queue may start
+func (q *Queue) SetScheduler(scheduler Scheduler) {
+	q.scheduler = scheduler
+}
+
+// RequireApproval holds every payout of the queue once forged until the operator approves it, e.g. with 'tzpay approve'
+func (q *Queue) RequireApproval() {
+	q.approval = true
+}
+
+// SetConfirmer sets the confirmer asked to confirm every payout of the queue before it is injected
+func (q *Queue) SetConfirmer(confirmer Confirmer) {
+	q.confirmer = confirmer
+}
+
+// SetInclusionWatcher sets the watcher waiting for the operations of every payout of the queue to be included
+func (q *Queue) SetInclusionWatcher(watcher *InclusionWatcher) {
+	q.watcher = watcher
+}
+
+/*
+SetStore sets the store the payouts of the queue are persisted to. A payout stays in the store from the time it is
+enqueued until it is executed or given up on, including while it is being executed, so that a payout interrupted by a
+restart is executed again once loaded, the payouts recorded as paid preventing anyone from being paid twice.
+*/
+func (q *Queue) SetStore(s store.IFace) {
+	q.store = s
+}
+
+// Enqueue adds p to the queue, and returns it as queued
+func (q *Queue) Enqueue(p Payout) Queued {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	if p.enqueued.IsZero() {
+		p.enqueued = time.Now().UTC()
+	}
+	q.persist(&p)
 	q.payouts = append(q.payouts, p)
+
+	return p.Queued()
 }
 
 func (q *Queue) Dequeue() error {
 	if len(q.payouts) > 0 {
 		q.mu.Lock()
 		defer q.mu.Unlock()
+		q.forget(q.payouts[0])
 		q.payouts = q.payouts[1:]
 		return nil
 	}
 	return fmt.Errorf("Pop Error: Queue is empty")
 }
 
+/*
+Load adds the payouts persisted to the store back to the queue, in the order they were enqueued, rebuilt by build
+from what was persisted of them. A payout that cannot be rebuilt, e.g. of a baker no longer configured, is dropped
+from the store. Load returns the number of payouts added to the queue.
+*/
+func (q *Queue) Load(build func(queued Queued) (*Payout, error)) (int, error) {
+	if q.store == nil {
+		return 0, nil
+	}
+
+	keys, err := q.store.Keys(QueueBucket)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to load payout queue")
+	}
+	sort.Strings(keys)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var loaded int
+	for _, key := range keys {
+		var queued Queued
+		if _, err := q.store.Get(QueueBucket, key, &queued); err != nil {
+			return loaded, errors.Wrap(err, "failed to load payout queue")
+		}
+
+		logger := q.logger.WithFields(logrus.Fields{"payout-cycle": queued.Cycle, "baker": queued.Baker, "enqueued": queued.Enqueued})
+		payout, err := build(queued)
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to rebuild payout in queue, dropping it.")
+			if err := q.store.Delete(QueueBucket, key); err != nil {
+				return loaded, errors.Wrap(err, "failed to load payout queue")
+			}
+			continue
+		}
+
+		payout.queued, payout.attempts, payout.enqueued = key, queued.Attempts, queued.Enqueued
+		if n, err := strconv.ParseInt(key, 10, 64); err == nil && n > q.last {
+			q.last = n
+		}
+		q.payouts = append(q.payouts, *payout)
+		loaded++
+	}
+
+	return loaded, nil
+}
+
+// persist identifies p by a key ordered after every payout enqueued before it, and writes p to the store under it,
+// unless it already is, e.g. when a payout is put back in the queue
+func (q *Queue) persist(p *Payout) {
+	if p.queued != "" {
+		return
+	}
+
+	n := p.enqueued.UnixNano()
+	if n <= q.last {
+		n = q.last + 1
+	}
+	q.last = n
+
+	key := fmt.Sprintf("%020d", n)
+	if q.store != nil {
+		if err := q.store.Put(QueueBucket, key, p.Queued()); err != nil {
+			q.logger.WithFields(logrus.Fields{"error": err.Error(), "payout-cycle": p.cycle, "baker": p.Baker()}).Error("Failed to persist payout in queue.")
+			return
+		}
+	}
+	p.queued = key
+}
+
+// forget removes p from the store once it left the queue for good
+func (q *Queue) forget(p Payout) {
+	if q.store == nil || p.queued == "" {
+		return
+	}
+
+	if err := q.store.Delete(QueueBucket, p.queued); err != nil {
+		q.logger.WithFields(logrus.Fields{"error": err.Error(), "payout-cycle": p.cycle, "baker": p.Baker()}).Error("Failed to remove payout from persisted queue.")
+	}
+}
+
 func (q *Queue) Front() (Payout, error) {
 	if len(q.payouts) > 0 {
 		q.mu.Lock()
@@ -60,46 +232,330 @@ func (q *Queue) Empty() bool {
 	return len(q.payouts) == 0
 }
 
+// Queued returns the payout as persisted to the queue
+func (p *Payout) Queued() Queued {
+	return Queued{ID: p.queued, Baker: p.Baker(), Cycle: p.cycle, Partial: p.partial, Enqueued: p.enqueued, Attempts: p.attempts,
+		Approval: p.approval}
+}
+
+// Pause stops the queue from executing payouts until resumed. Payouts being executed are not interrupted.
+func (q *Queue) Pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = true
+}
+
+// Resume lets the queue execute payouts again once paused
+func (q *Queue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = false
+}
+
+/*
+Drain pauses the queue and waits for the payouts being executed to be done. The payouts waiting stay in the queue, and
+in the store, to be executed once resumed or loaded again after a restart.
+*/
+func (q *Queue) Drain() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.paused = true
+	for len(q.running) > 0 {
+		q.idle.Wait()
+	}
+}
+
+// Remove removes the payout id from the queue without executing it, and returns it. A payout being executed cannot be
+// removed.
+func (q *Queue) Remove(id string) (Queued, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, payout := range q.running {
+		if payout.queued == id {
+			return Queued{}, errors.Wrapf(errRunning, "failed to remove payout '%s'", id)
+		}
+	}
+
+	for i, payout := range q.payouts {
+		if payout.queued != id {
+			continue
+		}
+
+		q.forget(payout)
+		q.payouts = append(q.payouts[:i:i], q.payouts[i+1:]...)
+		return payout.Queued(), nil
+	}
+
+	return Queued{}, errors.Wrapf(errNotQueued, "failed to remove payout '%s'", id)
+}
+
+// errNotQueued is returned for a payout that is not in the queue
+var errNotQueued = errors.New("no such payout in queue")
+
+// NotQueued returns true if err is about a payout that is not in the queue
+func NotQueued(err error) bool {
+	return errors.Cause(err) == errNotQueued
+}
+
+// errRunning is returned for a payout of the queue that is being executed
+var errRunning = errors.New("payout is being executed")
+
+// Running returns true if err is about a payout of the queue that is being executed
+func Running(err error) bool {
+	return errors.Cause(err) == errRunning
+}
+
+// Status returns the state of the queue
+func (q *Queue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := QueueStatus{Paused: q.paused, Running: []Queued{}, Queued: []Queued{}}
+	for _, payout := range q.running {
+		status.Running = append(status.Running, payout.Queued())
+	}
+	for _, payout := range q.payouts {
+		status.Queued = append(status.Queued, payout.Queued())
+	}
+
+	return status
+}
+
+// Bakers returns the bakers that currently have payouts in the queue
+func (q *Queue) Bakers() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var bakers []string
+	seen := map[string]struct{}{}
+	for _, payout := range q.payouts {
+		if _, ok := seen[payout.Baker()]; !ok {
+			seen[payout.Baker()] = struct{}{}
+			bakers = append(bakers, payout.Baker())
+		}
+	}
+
+	return bakers
+}
+
+// dequeuePerBaker removes and returns the oldest payout of every baker in the queue, now being executed, unless it is
+// paused or the scheduler holds it
+func (q *Queue) dequeuePerBaker() []Payout {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.paused {
+		return nil
+	}
+
+	var fronts, rest []Payout
+	seen := map[string]struct{}{}
+	for _, payout := range q.payouts {
+		if _, ok := seen[payout.Baker()]; ok {
+			rest = append(rest, payout)
+			continue
+		}
+		seen[payout.Baker()] = struct{}{}
+		if q.scheduled(payout) {
+			rest = append(rest, payout)
+			continue
+		}
+		fronts = append(fronts, payout)
+	}
+	q.payouts = rest
+	q.running = fronts
+
+	return fronts
+}
+
 func (q *Queue) Start() {
 	q.logger.Info("Starting payout queue.")
 	go func() {
 		ticker := time.NewTicker(q.tickerDuration)
 		for range ticker.C {
 			q.logger.Debug("Popping off payout queue.")
-			payout, err := q.Front()
-			if err != nil {
+			payouts := q.dequeuePerBaker()
+			if len(payouts) == 0 {
 				q.logger.Debug("Payout Queue is empty.")
 				continue
 			}
 
-			q.logger.WithField("payout-cycle", payout.cycle).Info("Found payout in queue.")
-			err = q.Dequeue()
-			if err != nil {
-				q.logger.WithFields(logrus.Fields{"error": err.Error(), "payout-cycle": payout.cycle}).Error("Failed to dequeue payout in queue.")
-				continue
-			}
-			rewardsSplit, err := payout.Execute()
-			if err != nil {
-				q.logger.WithFields(logrus.Fields{"error": err.Error(), "payout-cycle": payout.cycle}).Error("Failed to execute payout in queue.")
-				q.logger.WithField("payout-cycle", payout.cycle).Info("Adding payout back in queue.")
-				q.Enqueue(payout)
-				continue
+			var wg sync.WaitGroup
+			for _, payout := range payouts {
+				wg.Add(1)
+				go func(payout Payout) {
+					defer wg.Done()
+					q.process(payout)
+				}(payout)
 			}
+			wg.Wait()
+			q.done()
+		}
+	}()
+}
+
+// done marks the payouts being executed as done
+func (q *Queue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running = nil
+	q.idle.Broadcast()
+}
 
-			q.logger.WithField("payout-cycle", payout.cycle).Info("Payout successfully executed.")
+// scheduled returns true if the scheduler holds payout, logging why the first time it does for a reason
+func (q *Queue) scheduled(payout Payout) bool {
+	if q.scheduler == nil {
+		return false
+	}
 
+	err := q.scheduler.Ready(payout.enqueued, time.Now())
+	if q.waiting == nil {
+		q.waiting = map[string]string{}
+	}
+	if err == nil {
+		delete(q.waiting, payout.queued)
+		return false
+	}
+
+	if q.waiting[payout.queued] != err.Error() {
+		q.waiting[payout.queued] = err.Error()
+		q.logger.WithFields(logrus.Fields{"payout-cycle": payout.cycle, "baker": payout.Baker(), "reason": err.Error()}).Info("Payout held by schedule.")
+	}
+
+	return true
+}
+
+// firstHold returns true the first time a payout is held with hold, so that approvers are not notified at every retry
+func (q *Queue) firstHold(payout Payout, hold string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.held == nil {
+		q.held = map[string]string{}
+	}
+
+	key := fmt.Sprintf("%s/%d", payout.Baker(), payout.cycle)
+	if q.held[key] == hold {
+		return false
+	}
+	q.held[key] = hold
+
+	return true
+}
+
+func (q *Queue) process(payout Payout) {
+	logger := q.logger.WithFields(logrus.Fields{"payout-cycle": payout.cycle, "baker": payout.Baker()})
+	logger.Info("Found payout in queue.")
+
+	if q.confirmer != nil {
+		payout.SetConfirmer(q.confirmer)
+	}
+	if q.watcher != nil {
+		payout.SetInclusionWatcher(q.watcher)
+	}
+	if q.approval {
+		payout.RequireApproval()
+	}
+	payout.SetNotifier(q.notifier)
+
+	rewardsSplit, err := payout.Execute()
+	if err != nil && payout.interrupted() {
+		// The payout stays in the store, to be executed again once the queue is loaded after a restart
+		logger.WithField("error", err.Error()).Warn("Payout interrupted.")
+		return
+	} else if SpendingCapExceeded(err) {
+		// Retrying would be refused again, the payout waits for the operator
+		logger.WithField("error", err.Error()).Error("Payout aborted above the spending cap.")
+		if q.notifier != nil {
+			msg := fmt.Sprintf("[TZPAY] ALERT payout for cycle %d (%s) aborted: %s", payout.cycle, payout.Baker(), err.Error())
+			if err := q.notifier.NotifyKind(notifier.KindAlert, msg); err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to notify.")
+			}
+		}
+		q.forget(payout)
+		return
+	} else if Cancelled(err) {
+		// The operator cancelled the payout, it waits for them to run it by hand
+		logger.WithField("error", err.Error()).Warn("Payout cancelled by the operator.")
+		q.forget(payout)
+		return
+	} else if AwaitingApproval(err) {
+		logger.WithField("error", err.Error()).Warn("Payout held until approved.")
+		if q.notifier != nil && q.firstHold(payout, err.Error()) {
+			msg := fmt.Sprintf("[TZPAY] payout for cycle %d (%s) awaits approval: %s", payout.cycle, payout.Baker(), err.Error())
+			if err := q.notifier.NotifyKind(notifier.KindApproval, msg); err != nil {
+				logger.WithField("error", err.Error()).Error("Failed to notify.")
+			}
+		}
+		logger.Info("Adding payout back in queue.")
+		q.Enqueue(payout)
+		return
+	} else if errors.Cause(err) == errOrphaned {
+		logger.WithField("error", err.Error()).Warn("Payout orphaned by a chain reorganization.")
+		logger.Info("Adding payout back in queue.")
+		q.Enqueue(payout)
+		return
+	} else if err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to execute payout in queue.")
+		moved, derr := q.deadLetter(&payout, err)
+		if derr != nil {
+			logger.WithField("error", derr.Error()).Error("Failed to record failed attempt.")
+		}
+		if moved {
+			// Retrying would most likely fail again, the payout waits for the operator
+			logger.WithField("attempts", payout.attempts).Error("Payout moved to the dead letters after failing too many times.")
 			if q.notifier != nil {
-				err = q.notifier.Notify(fmt.Sprintf("[TZPAY] payout for cycle %d: \n%s\n #tezos #blockchain", payout.cycle, rewardsSplit.OperationLink))
-				if err != nil {
-					q.logger.WithField("error", err.Error()).Error("Failed to notify.")
+				msg := fmt.Sprintf("[TZPAY] ALERT payout for cycle %d (%s) failed %d times and was moved to the dead letters: %s", payout.cycle, payout.Baker(), payout.attempts, err.Error())
+				if err := q.notifier.NotifyKind(notifier.KindAlert, msg); err != nil {
+					logger.WithField("error", err.Error()).Error("Failed to notify.")
 				}
 			}
+			return
+		}
+		logger.Info("Adding payout back in queue.")
+		q.Enqueue(payout)
+		return
+	}
 
-			err = print.JSON(rewardsSplit)
-			if err != nil {
-				q.logger.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
-			}
+	logger.Info("Payout successfully executed.")
+	q.forget(payout)
+
+	var sweep string
+	if rewardsSplit.Sweep > 0 {
+		sweep = fmt.Sprintf("swept %.6f XTZ to %s\n", float64(rewardsSplit.Sweep)/float64(gotezos.MUTEZ), rewardsSplit.SweepAddress)
+	}
 
+	msg := fmt.Sprintf("[TZPAY] payout for cycle %d (%s): \n%s\n%s #tezos #blockchain", payout.cycle, payout.Baker(), rewardsSplit.OperationLink, sweep)
+	if payout.partial {
+		msg = fmt.Sprintf("[TZPAY] partial payout for cycle %d (%s): \n%s\n #tezos #blockchain", payout.cycle, payout.Baker(), rewardsSplit.OperationLink)
+	}
+	if rewardsSplit.Skipped {
+		logger.Warn("Payout skipped because the baker was denounced.")
+		msg = fmt.Sprintf("[TZPAY] payout for cycle %d (%s) skipped: baker was denounced #tezos #blockchain", payout.cycle, payout.Baker())
+	}
+
+	if text, ok, err := payout.Notification(rewardsSplit); err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to render payout notification.")
+	} else if ok {
+		msg = text
+	}
+
+	if q.notifier != nil {
+		// Denunciations are sent right away, payouts can wait for the digest
+		if rewardsSplit.Skipped {
+			err = q.notifier.NotifyKind(notifier.KindPayout, msg)
+		} else {
+			err = q.notifier.NotifyLow(msg)
 		}
-	}()
+		if err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to notify.")
+		}
+	}
+
+	err = print.JSON(rewardsSplit)
+	if err != nil {
+		logger.WithField("error", err.Error()).Fatal("Failed to print JSON report.")
+	}
 }
@@ -1,12 +1,20 @@
 package payout
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/notifier"
+	"github.com/goat-systems/tzpay/v3/internal/store"
 	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -111,6 +119,101 @@ func Test_Start(t *testing.T) {
 	}
 }
 
+func Test_process_spendingCap(t *testing.T) {
+	messenger := &notifier.MockClient{}
+	payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{messenger}})
+
+	queue := NewQueue(&payoutNotifier)
+	logger, _ := test.NewNullLogger()
+	queue.logger = logger
+
+	var applied bool
+	queue.process(Payout{
+		config: config.Config{
+			Baker:      config.Baker{Address: "tz1baker"},
+			Operations: config.Operations{NetworkFee: 1000, SpendingCap: 1000000},
+		},
+		cycle:  10,
+		inject: true,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			return tzkt.RewardsSplit{Cycle: 10, Delegators: tzkt.Delegators{{Address: "tz1a", NetRewards: 5000000}}}, nil
+		},
+		applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+			applied = true
+			return []string{}, nil
+		},
+	})
+
+	assert.False(t, applied)
+	assert.Equal(t, 0, queue.Size(), "a payout above the spending cap is not retried")
+	assert.Len(t, messenger.Messages, 1)
+	assert.Contains(t, messenger.Messages[0], "[TZPAY] ALERT payout for cycle 10 (tz1baker) aborted")
+}
+
+func Test_process_awaitingApproval(t *testing.T) {
+	messenger := &notifier.MockClient{}
+	payoutNotifier := notifier.NewPayoutNotifier(notifier.PayoutNotifierInput{Notifiers: []notifier.ClientIFace{messenger}})
+
+	queue := NewQueue(&payoutNotifier)
+	logger, _ := test.NewNullLogger()
+	queue.logger = logger
+
+	payout := Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+		cycle:  10,
+		inject: true,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			return tzkt.RewardsSplit{Cycle: 10, Delegators: tzkt.Delegators{{Address: "tz1a", NetRewards: 5000000}}}, nil
+		},
+		applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+			return []string{}, errors.Wrap(errAwaitingApproval, "payout for cycle 10 is held")
+		},
+	}
+
+	queue.process(payout)
+	queue.process(payout)
+
+	assert.Equal(t, 2, queue.Size(), "a payout held is retried until approved")
+	assert.Len(t, messenger.Messages, 1, "approvers are notified once per payout held")
+	assert.Contains(t, messenger.Messages[0], "[TZPAY] payout for cycle 10 (tz1baker) awaits approval")
+}
+
+func Test_process_interrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	queue := NewQueue(nil)
+	queue.logger, _ = test.NewNullLogger()
+	queue.SetStore(s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queue.Enqueue(Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}},
+		ctx:    ctx,
+		cycle:  10,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			cancel()
+			return tzkt.RewardsSplit{}, errors.Wrap(ctx.Err(), "failed to get block '1'")
+		},
+	})
+
+	for _, p := range queue.dequeuePerBaker() {
+		queue.process(p)
+	}
+
+	assert.True(t, queue.Empty(), "a payout interrupted is not retried")
+	keys, err := s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 1, "a payout interrupted stays in the store to be loaded after a restart")
+	letters, err := DeadLetters(s)
+	assert.Nil(t, err)
+	assert.Empty(t, letters)
+}
+
 func Test_Front(t *testing.T) {
 	q := Queue{
 		mu: &sync.Mutex{},
@@ -162,3 +265,196 @@ func Test_Enqueue(t *testing.T) {
 	q.Enqueue(Payout{})
 	assert.Equal(t, 1, q.Size())
 }
+
+func Test_dequeuePerBaker(t *testing.T) {
+	q := Queue{
+		mu: &sync.Mutex{},
+		payouts: []Payout{
+			{cycle: 10, config: config.Config{Baker: config.Baker{Address: "some_baker"}}},
+			{cycle: 11, config: config.Config{Baker: config.Baker{Address: "some_baker"}}},
+			{cycle: 10, config: config.Config{Baker: config.Baker{Address: "some_other_baker"}}},
+		},
+	}
+
+	assert.Equal(t, []string{"some_baker", "some_other_baker"}, q.Bakers())
+
+	payouts := q.dequeuePerBaker()
+	assert.Len(t, payouts, 2)
+	assert.Equal(t, "some_baker", payouts[0].Baker())
+	assert.Equal(t, 10, payouts[0].cycle)
+	assert.Equal(t, "some_other_baker", payouts[1].Baker())
+	assert.Equal(t, 1, q.Size())
+
+	payouts = q.dequeuePerBaker()
+	assert.Len(t, payouts, 1)
+	assert.Equal(t, 11, payouts[0].cycle)
+	assert.True(t, q.Empty())
+}
+
+func Test_Queue_persisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	payout := func(baker string, cycle int, err error) Payout {
+		return Payout{
+			config: config.Config{Baker: config.Baker{Address: baker}},
+			cycle:  cycle,
+			constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+				return tzkt.RewardsSplit{Cycle: cycle}, err
+			},
+			applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+				return []string{}, nil
+			},
+		}
+	}
+
+	queue := NewQueue(nil)
+	queue.logger, _ = test.NewNullLogger()
+	queue.SetStore(s)
+	queue.Enqueue(payout("some_baker", 10, nil))
+	partial := payout("some_other_baker", 11, nil)
+	partial.SetPartial()
+	queue.Enqueue(partial)
+	queue.Enqueue(payout("unknown_baker", 12, nil))
+
+	keys, err := s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 3)
+
+	// the server restarts before any payout is executed
+	restarted := NewQueue(nil)
+	restarted.logger, _ = test.NewNullLogger()
+	restarted.SetStore(s)
+
+	var rebuilt []Queued
+	loaded, err := restarted.Load(func(queued Queued) (*Payout, error) {
+		if queued.Baker == "unknown_baker" {
+			return nil, errors.New("baker 'unknown_baker' is no longer configured")
+		}
+		rebuilt = append(rebuilt, queued)
+		p := payout(queued.Baker, queued.Cycle, nil)
+		if queued.Cycle == 10 {
+			p = payout(queued.Baker, queued.Cycle, errors.New("some_error"))
+		}
+		return &p, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, loaded)
+	assert.Equal(t, 2, restarted.Size())
+	assert.Len(t, rebuilt, 2)
+	assert.Equal(t, "some_baker", rebuilt[0].Baker)
+	assert.Equal(t, 10, rebuilt[0].Cycle)
+	assert.Equal(t, "some_other_baker", rebuilt[1].Baker)
+	assert.True(t, rebuilt[1].Partial)
+
+	keys, err = s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 2, "a payout that cannot be rebuilt is dropped")
+
+	// a payout executed leaves the store, a payout put back in the queue stays in it under the same key
+	for _, p := range restarted.dequeuePerBaker() {
+		restarted.process(p)
+	}
+	assert.Equal(t, 1, restarted.Size())
+
+	remaining, err := s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.Equal(t, keys[:1], remaining)
+
+	restarted.Enqueue(payout("some_baker", 13, nil))
+	keys, err = s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.Len(t, keys, 2)
+	assert.True(t, keys[0] < keys[1], "payouts are persisted in the order they are enqueued")
+}
+
+func Test_Queue_pause(t *testing.T) {
+	queue := NewQueue(nil)
+	queue.Enqueue(Payout{cycle: 10, config: config.Config{Baker: config.Baker{Address: "some_baker"}}})
+	queue.Enqueue(Payout{cycle: 10, partial: true, config: config.Config{Baker: config.Baker{Address: "some_other_baker"}}})
+
+	queue.Pause()
+	assert.Empty(t, queue.dequeuePerBaker(), "a paused queue executes nothing")
+
+	status := queue.Status()
+	assert.True(t, status.Paused)
+	assert.Empty(t, status.Running)
+	assert.Len(t, status.Queued, 2)
+	assert.Equal(t, "some_baker", status.Queued[0].Baker)
+	assert.Equal(t, 10, status.Queued[0].Cycle)
+	assert.False(t, status.Queued[0].Enqueued.IsZero())
+	assert.True(t, status.Queued[1].Partial)
+
+	queue.Resume()
+	assert.Len(t, queue.dequeuePerBaker(), 2)
+
+	status = queue.Status()
+	assert.False(t, status.Paused)
+	assert.Len(t, status.Running, 2)
+	assert.Empty(t, status.Queued)
+
+	queue.done()
+	assert.Empty(t, queue.Status().Running)
+}
+
+func Test_Queue_remove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-queue")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	queue := NewQueue(nil)
+	queue.SetStore(s)
+	running := queue.Enqueue(Payout{cycle: 10, config: config.Config{Baker: config.Baker{Address: "some_baker"}}})
+	queued := queue.Enqueue(Payout{cycle: 11, config: config.Config{Baker: config.Baker{Address: "some_baker"}}})
+	kept := queue.Enqueue(Payout{cycle: 12, config: config.Config{Baker: config.Baker{Address: "some_baker"}}})
+	assert.NotEmpty(t, queued.ID)
+	assert.Len(t, queue.dequeuePerBaker(), 1)
+
+	_, err = queue.Remove(running.ID)
+	assert.True(t, Running(err), "a payout being executed cannot be removed")
+
+	removed, err := queue.Remove(queued.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, queued, removed)
+
+	_, err = queue.Remove(queued.ID)
+	assert.True(t, NotQueued(err))
+
+	status := queue.Status()
+	assert.Equal(t, []Queued{kept}, status.Queued)
+	keys, err := s.Keys(QueueBucket)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{running.ID, kept.ID}, keys, "a payout removed is removed from the store")
+}
+
+func Test_Queue_drain(t *testing.T) {
+	queue := NewQueue(nil)
+	queue.Enqueue(Payout{cycle: 10, config: config.Config{Baker: config.Baker{Address: "some_baker"}}})
+	queue.Enqueue(Payout{cycle: 11, config: config.Config{Baker: config.Baker{Address: "some_baker"}}})
+	assert.Len(t, queue.dequeuePerBaker(), 1)
+
+	drained := make(chan struct{})
+	go func() {
+		queue.Drain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("drained while a payout was being executed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	queue.done()
+	<-drained
+	status := queue.Status()
+	assert.True(t, status.Paused)
+	assert.Len(t, status.Queued, 1, "the payouts waiting stay in the queue")
+}
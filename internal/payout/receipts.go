@@ -0,0 +1,282 @@
+package payout
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/receipt"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// emailBucket holds the email address receipts are sent to by delegator
+const emailBucket = "emails"
+
+func receiptBucket(baker string) string {
+	return "receipts/" + baker
+}
+
+func signedBucket(baker string) string {
+	return "signed/" + baker
+}
+
+// Mailer emails a delegator, e.g. through an SMTP server
+type Mailer interface {
+	Mail(to, subject, body string) error
+}
+
+// Receipt is what a delegator was paid for a cycle, and the operations that paid it
+type Receipt struct {
+	Baker      string          `json:"baker"`
+	Delegator  string          `json:"delegator"`
+	Cycle      int             `json:"cycle"`
+	Share      float64         `json:"share"`
+	Gross      int             `json:"gross"`
+	Fee        int             `json:"fee"`
+	Net        int             `json:"net"`
+	Operations []string        `json:"operations"`
+	Signed     *receipt.Signed `json:"signed,omitempty"`
+}
+
+// Subject returns the subject of the email of the receipt
+func (r Receipt) Subject() string {
+	return fmt.Sprintf("Payout receipt for cycle %d from %s", r.Cycle, r.Baker)
+}
+
+// String returns the receipt as the body of an email
+func (r Receipt) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Baker:     %s\n", r.Baker)
+	fmt.Fprintf(&sb, "Delegator: %s\n", r.Delegator)
+	fmt.Fprintf(&sb, "Cycle:     %d\n", r.Cycle)
+	fmt.Fprintf(&sb, "Share:     %.4f%%\n", r.Share*100)
+	fmt.Fprintf(&sb, "Gross:     %.6f XTZ\n", float64(r.Gross)/float64(gotezos.MUTEZ))
+	fmt.Fprintf(&sb, "Fee:       %.6f XTZ\n", float64(r.Fee)/float64(gotezos.MUTEZ))
+	fmt.Fprintf(&sb, "Net:       %.6f XTZ\n", float64(r.Net)/float64(gotezos.MUTEZ))
+	for _, operation := range r.Operations {
+		fmt.Fprintf(&sb, "Operation: %s (https://tzkt.io/%s)\n", operation, operation)
+	}
+	if r.Signed != nil {
+		signed, _ := json.Marshal(r.Signed)
+		fmt.Fprintf(&sb, "\nSigned by the baker, verify with 'tzpay receipt verify':\n%s\n", signed)
+	}
+
+	return sb.String()
+}
+
+// SetDelegatorEmail sets the email address the receipts of delegator are sent to, or removes it if email is empty
+func SetDelegatorEmail(s store.IFace, delegator, email string) error {
+	if email == "" {
+		if err := s.Delete(emailBucket, delegator); err != nil {
+			return errors.Wrapf(err, "failed to remove email of '%s'", delegator)
+		}
+		return nil
+	}
+
+	if !strings.Contains(email, "@") {
+		return errors.Errorf("failed to set email of '%s': invalid email address '%s'", delegator, email)
+	}
+
+	if err := s.Put(emailBucket, delegator, email); err != nil {
+		return errors.Wrapf(err, "failed to set email of '%s'", delegator)
+	}
+
+	return nil
+}
+
+// DelegatorEmail returns the email address the receipts of delegator are sent to, from cfg or else from the store
+func DelegatorEmail(s store.IFace, cfg config.Email, delegator string) (string, error) {
+	if email := cfg.DelegatorEmail(delegator); email != "" || s == nil {
+		return email, nil
+	}
+
+	var email string
+	if _, err := s.Get(emailBucket, delegator, &email); err != nil {
+		return "", errors.Wrapf(err, "failed to get email of '%s'", delegator)
+	}
+
+	return email, nil
+}
+
+// SetMailer sets the mailer sending delegators their receipts once the payout is confirmed
+func (p *Payout) SetMailer(mailer Mailer) {
+	p.mailer = mailer
+}
+
+// recordOperations remembers the operations that paid each destination of transfers, for their receipts
+func (p *Payout) recordOperations(transfers []disperseTransfer, ophashes []string) {
+	if p.operations == nil {
+		p.operations = map[string][]string{}
+	}
+
+	for _, transfer := range transfers {
+		// The operations of a chunk are recorded once per operation first, then for the whole chunk
+		if _, ok := p.operations[transfer.Destination]; !ok && len(ophashes) > 0 {
+			p.operations[transfer.Destination] = ophashes
+		}
+	}
+}
+
+/*
+sendReceipts mails every delegator paid that has an email address its receipt, once per cycle. The operations of a
+receipt are those that paid the delegator, or every operation of the payout if they are not known, e.g. for the chunks
+of a resumed payout. A receipt failing to send is only logged, the payout being made.
+*/
+func (p *Payout) sendReceipts(delegators tzkt.Delegators, operations []string) {
+	for _, delegator := range delegators {
+		if delegator.LiquidityProviders != nil || delegator.BlackListed || delegator.Accumulated {
+			continue
+		}
+
+		logger := logrus.WithFields(logrus.Fields{"cycle": p.cycle, "delegator": delegator.Address})
+		if err := p.sendReceipt(delegator, operations); err != nil {
+			logger.WithField("error", err.Error()).Error("Failed to send receipt.")
+		}
+	}
+}
+
+func (p *Payout) sendReceipt(delegator tzkt.Delegator, operations []string) error {
+	email, err := DelegatorEmail(p.store, p.config.Notifications.Email, delegator.Address)
+	if err != nil || email == "" {
+		return err
+	}
+
+	key := paidKey(p.cycle, delegator.Address)
+	if p.store != nil {
+		var sent time.Time
+		if ok, err := p.store.Get(receiptBucket(p.config.Baker.Address), key, &sent); err != nil {
+			return errors.Wrap(err, "failed to check receipt was sent")
+		} else if ok {
+			return nil
+		}
+	}
+
+	receipt := Receipt{
+		Baker:      p.config.Baker.Address,
+		Delegator:  delegator.Address,
+		Cycle:      p.cycle,
+		Share:      delegator.Share,
+		Gross:      delegator.GrossRewards,
+		Fee:        delegator.Fee,
+		Net:        delegator.NetRewards,
+		Operations: operations,
+	}
+	if paidBy, ok := p.operations[p.destination(delegator)]; ok {
+		receipt.Operations = paidBy
+	}
+	if p.store != nil {
+		if receipt.Signed, err = SignedReceipt(p.store, p.config.Baker.Address, delegator.Address, p.cycle); err != nil {
+			return err
+		}
+	}
+
+	if err := p.mailer.Mail(email, receipt.Subject(), receipt.String()); err != nil {
+		return err
+	}
+
+	if p.store != nil {
+		if err := p.store.Put(receiptBucket(p.config.Baker.Address), key, now().UTC()); err != nil {
+			return errors.Wrap(err, "failed to record receipt was sent")
+		}
+	}
+
+	return nil
+}
+
+// signsReceipts returns true if the payments of the payout are signed with the baker's key
+func (p *Payout) signsReceipts() bool {
+	return p.config.Receipts.Sign && p.bakerKey.GetBytes() != nil
+}
+
+/*
+signReceipts signs a receipt of every transfer of delegators with the baker's key, once per cycle, and stores it for
+delegators to verify. The operations of a receipt are those that paid the destination, or every operation of the
+payout if they are not known. A receipt failing to be signed is only logged, the payout being made.
+*/
+func (p *Payout) signReceipts(delegators tzkt.Delegators, operations []string) {
+	for _, delegator := range delegators {
+		if delegator.LiquidityProviders != nil {
+			for _, lp := range delegator.LiquidityProviders {
+				if !lp.BlackListed {
+					p.signReceipt(lp.Address, lp.Address, lp.NetRewards, operations)
+				}
+			}
+		} else if !delegator.BlackListed && !delegator.Accumulated {
+			p.signReceipt(delegator.Address, p.destination(delegator), delegator.NetRewards, operations)
+		}
+	}
+}
+
+func (p *Payout) signReceipt(address, destination string, amount int, operations []string) {
+	logger := logrus.WithFields(logrus.Fields{"cycle": p.cycle, "delegator": address})
+
+	var signed receipt.Signed
+	if ok, err := p.store.Get(signedBucket(p.config.Baker.Address), paidKey(p.cycle, address), &signed); err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to sign receipt.")
+		return
+	} else if ok {
+		return
+	}
+
+	r := receipt.Receipt{
+		Baker:      p.config.Baker.Address,
+		Delegator:  address,
+		Cycle:      p.cycle,
+		Amount:     amount,
+		Operations: operations,
+	}
+	if paidBy, ok := p.operations[destination]; ok {
+		r.Operations = paidBy
+	}
+
+	signed = receipt.Sign(ed25519.PrivateKey(p.bakerKey.GetBytes()), r)
+	if err := p.store.Put(signedBucket(p.config.Baker.Address), paidKey(p.cycle, address), signed); err != nil {
+		logger.WithField("error", err.Error()).Error("Failed to sign receipt.")
+	}
+}
+
+// SignedReceipt returns the signed receipt of what baker paid delegator for cycle, or nil if none was signed
+func SignedReceipt(s store.IFace, baker, delegator string, cycle int) (*receipt.Signed, error) {
+	var signed receipt.Signed
+	ok, err := s.Get(signedBucket(baker), paidKey(cycle, delegator), &signed)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get signed receipt of '%s' for cycle %d", delegator, cycle)
+	} else if !ok {
+		return nil, nil
+	}
+
+	return &signed, nil
+}
+
+// SignedReceipts returns every signed receipt of what baker paid delegator, oldest cycle first
+func SignedReceipts(s store.IFace, baker, delegator string) ([]receipt.Signed, error) {
+	keys, err := delegatorKeys(s, signedBucket(baker), delegator, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list signed receipts of '%s'", delegator)
+	}
+
+	cycles := make([]int, 0, len(keys))
+	for cycle := range keys {
+		cycles = append(cycles, cycle)
+	}
+	sort.Ints(cycles)
+
+	receipts := []receipt.Signed{}
+	for _, cycle := range cycles {
+		var signed receipt.Signed
+		if _, err := s.Get(signedBucket(baker), keys[cycle], &signed); err != nil {
+			return nil, errors.Wrapf(err, "failed to list signed receipts of '%s'", delegator)
+		}
+		receipts = append(receipts, signed)
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,165 @@
+package payout
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/receipt"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type mail struct {
+	to, subject, body string
+}
+
+type mockMailer struct {
+	mails   []mail
+	failFor string
+}
+
+func (m *mockMailer) Mail(to, subject, body string) error {
+	if to == m.failFor {
+		return errors.New("failed to send email")
+	}
+	m.mails = append(m.mails, mail{to, subject, body})
+	return nil
+}
+
+func Test_sendReceipts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-receipts")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, SetDelegatorEmail(s, "tz1b", "bob@example.com"))
+	assert.Nil(t, SetDelegatorEmail(s, "tz1c", "carol@example.com"))
+	assert.Nil(t, SetDelegatorEmail(s, "tz1d", "dave@example.com"))
+	test.CheckErr(t, true, "invalid email address 'dave'", SetDelegatorEmail(s, "tz1d", "dave"))
+
+	mailer := &mockMailer{failFor: "dave@example.com"}
+	p := &Payout{
+		config: config.Config{
+			Baker:         config.Baker{Address: "tz1baker"},
+			Notifications: config.Notifications{Email: config.Email{Delegators: []string{"tz1a:alice@example.com"}}},
+		},
+		store:  s,
+		cycle:  300,
+		mailer: mailer,
+	}
+	p.recordOperations([]disperseTransfer{{Destination: "tz1a"}}, []string{"oo1"})
+	p.recordOperations([]disperseTransfer{{Destination: "tz1a"}, {Destination: "tz1b"}}, []string{"oo1", "oo2"})
+
+	delegators := tzkt.Delegators{
+		{Address: "tz1a", Share: 0.25, GrossRewards: 1000000, Fee: 50000, NetRewards: 950000},
+		{Address: "tz1b", Share: 0.5, GrossRewards: 2000000, Fee: 100000, NetRewards: 1900000},
+		{Address: "tz1c", Share: 0.1, GrossRewards: 400000, Fee: 20000, NetRewards: 380000, BlackListed: true},
+		{Address: "tz1d", Share: 0.1, GrossRewards: 400000, Fee: 20000, NetRewards: 380000},
+		{Address: "tz1e", Share: 0.05, GrossRewards: 200000, Fee: 10000, NetRewards: 190000},
+	}
+	p.sendReceipts(delegators, []string{"oo1", "oo2", "oo3"})
+
+	assert.Equal(t, []mail{
+		{
+			to:      "alice@example.com",
+			subject: "Payout receipt for cycle 300 from tz1baker",
+			body: "Baker:     tz1baker\nDelegator: tz1a\nCycle:     300\nShare:     25.0000%\n" +
+				"Gross:     1.000000 XTZ\nFee:       0.050000 XTZ\nNet:       0.950000 XTZ\n" +
+				"Operation: oo1 (https://tzkt.io/oo1)\n",
+		},
+		{
+			to:      "bob@example.com",
+			subject: "Payout receipt for cycle 300 from tz1baker",
+			body: "Baker:     tz1baker\nDelegator: tz1b\nCycle:     300\nShare:     50.0000%\n" +
+				"Gross:     2.000000 XTZ\nFee:       0.100000 XTZ\nNet:       1.900000 XTZ\n" +
+				"Operation: oo1 (https://tzkt.io/oo1)\nOperation: oo2 (https://tzkt.io/oo2)\n",
+		},
+	}, mailer.mails)
+
+	// Receipts are sent once, a receipt that failed is sent again
+	mailer.mails, mailer.failFor = nil, ""
+	p.sendReceipts(delegators, []string{"oo1", "oo2", "oo3"})
+	assert.Len(t, mailer.mails, 1)
+	assert.Equal(t, "dave@example.com", mailer.mails[0].to)
+	assert.Contains(t, mailer.mails[0].body, "Operation: oo3 (https://tzkt.io/oo3)\n")
+
+	assert.Nil(t, SetDelegatorEmail(s, "tz1b", ""))
+	email, err := DelegatorEmail(s, p.config.Notifications.Email, "tz1b")
+	assert.Nil(t, err)
+	assert.Equal(t, "", email)
+}
+
+func Test_signReceipts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-signed-receipts")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	bakerKey, err := keys.NewKey(keys.NewKeyInput{
+		Kind:     keys.Ed25519,
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+	})
+	assert.Nil(t, err)
+	baker := bakerKey.PubKey.GetPublicKeyHash()
+
+	mailer := &mockMailer{}
+	p := &Payout{
+		config: config.Config{
+			Baker:         config.Baker{Address: baker},
+			Receipts:      config.Receipts{Sign: true},
+			Notifications: config.Notifications{Email: config.Email{Delegators: []string{"tz1a:alice@example.com"}}},
+		},
+		store:    s,
+		cycle:    300,
+		bakerKey: bakerKey,
+		mailer:   mailer,
+	}
+	assert.True(t, p.signsReceipts())
+	p.recordOperations([]disperseTransfer{{Destination: "tz1a"}}, []string{"oo1"})
+
+	delegators := tzkt.Delegators{
+		{Address: "tz1a", NetRewards: 950000},
+		{Address: "tz1b", NetRewards: 380000, BlackListed: true},
+		{Address: "KT1dexter", LiquidityProviders: []tzkt.LiquidityProvider{{Address: "tz1lp", NetRewards: 1000}}},
+	}
+	p.signReceipts(delegators, []string{"oo1", "oo2"})
+
+	signed, err := SignedReceipts(s, baker, "tz1a")
+	assert.Nil(t, err)
+	assert.Len(t, signed, 1)
+	assert.Equal(t, receipt.Receipt{Baker: baker, Delegator: "tz1a", Cycle: 300, Amount: 950000, Operations: []string{"oo1"}}, signed[0].Receipt)
+	assert.Nil(t, receipt.Verify(signed[0], bakerKey.PubKey.GetPublicKey()))
+
+	lp, err := SignedReceipt(s, baker, "tz1lp", 300)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"oo1", "oo2"}, lp.Operations)
+
+	none, err := SignedReceipt(s, baker, "tz1b", 300)
+	assert.Nil(t, err)
+	assert.Nil(t, none)
+
+	// A receipt is signed once per cycle
+	p.cycle = 301
+	p.signReceipts(delegators, []string{"oo3"})
+	p.signReceipts(tzkt.Delegators{{Address: "tz1a", NetRewards: 1}}, []string{"oo4"})
+	signed, err = SignedReceipts(s, baker, "tz1a")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{300, 301}, []int{signed[0].Cycle, signed[1].Cycle})
+	assert.Equal(t, 950000, signed[1].Amount)
+
+	p.sendReceipts(delegators, []string{"oo3"})
+	assert.Len(t, mailer.mails, 1)
+	assert.Contains(t, mailer.mails[0].body, "Signed by the baker, verify with 'tzpay receipt verify':\n{\"baker\":\""+baker)
+	assert.Contains(t, mailer.mails[0].body, signed[1].Signature)
+}
@@ -0,0 +1,109 @@
+package payout
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Record is the persisted record of an injected payout, with the price of XTZ when it was made if a currency is configured
+type Record = store.Payout
+
+// SetMemo attaches an operator memo, e.g. a compliance annotation, to the payout and its record
+func (p *Payout) SetMemo(memo string) {
+	p.memo = memo
+}
+
+// MemoHash returns the hex encoded SHA-256 of memo, so that a memo can be checked against the record it was stored with
+func MemoHash(memo string) string {
+	if memo == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(memo))
+	return hex.EncodeToString(sum[:])
+}
+
+func recordsBucket(baker string) string {
+	return store.PayoutsBucket(baker)
+}
+
+// saveRecord records an injected payout. Records are keyed by cycle and time, so that a cycle paid again keeps both.
+func (p *Payout) saveRecord(rewardsSplit tzkt.RewardsSplit) error {
+	record := Record{
+		Cycle:      p.cycle,
+		Time:       now().UTC(),
+		Operations: rewardsSplit.OperationLink,
+		Memo:       rewardsSplit.Memo,
+		MemoHash:   rewardsSplit.MemoHash,
+		Partial:    p.partial,
+	}
+	for _, transfer := range p.transfers(rewardsSplit.Delegators) {
+		record.Amount += transfer.Amount
+	}
+	for _, delegator := range rewardsSplit.Delegators {
+		record.Fees += int64(delegator.Fee)
+	}
+
+	// The rate is only for reports, a payout made is recorded without it
+	if p.price != nil {
+		rate, err := p.price.Rate(p.config.Price.Currency, record.Time)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{"cycle": p.cycle, "error": err.Error()}).Warn("Failed to record price of XTZ with payout.")
+		} else {
+			record.Currency, record.Rate = strings.ToUpper(p.config.Price.Currency), rate
+		}
+	}
+
+	if err := p.payouts().SavePayout(p.config.Baker.Address, record); err != nil {
+		return errors.Wrapf(err, "failed to save record of payout for cycle %d", p.cycle)
+	}
+
+	return nil
+}
+
+// Records returns the records of the payouts injected for baker, oldest cycle first
+func Records(s store.IFace, baker string) ([]Record, error) {
+	records, err := store.NewPayouts(s).ListPayouts(baker)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list payout records")
+	}
+
+	return records, nil
+}
+
+// CycleRecord is every payout recorded for a cycle and what each delegator was paid by them, partial payouts included
+type CycleRecord struct {
+	Baker      string         `json:"baker"`
+	Cycle      int            `json:"cycle"`
+	Payouts    []Record       `json:"payouts"`
+	Delegators map[string]int `json:"delegators"`
+	Total      int            `json:"total"`
+}
+
+// CycleRecords returns the payouts recorded for cycle and the delegators they paid. ok is false if nothing was recorded.
+func CycleRecords(s store.IFace, baker string, cycle int) (record CycleRecord, ok bool, err error) {
+	record = CycleRecord{Baker: baker, Cycle: cycle, Payouts: []Record{}, Delegators: map[string]int{}}
+
+	payouts, err := store.NewPayouts(s).GetPayout(baker, cycle)
+	if err != nil {
+		return record, false, errors.Wrapf(err, "failed to get records of cycle %d", cycle)
+	}
+	record.Payouts = append(record.Payouts, payouts...)
+
+	paid, err := paidInCycle(s, baker, cycle)
+	if err != nil {
+		return record, false, errors.Wrapf(err, "failed to get records of cycle %d", cycle)
+	}
+	for address, amount := range paid {
+		record.Delegators[address] = amount
+		record.Total += amount
+	}
+
+	return record, len(record.Payouts) > 0 || len(record.Delegators) > 0, nil
+}
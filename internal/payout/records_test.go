@@ -0,0 +1,100 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedPrice float64
+
+func (f fixedPrice) Rate(currency string, at time.Time) (float64, error) {
+	return float64(f), nil
+}
+
+func Test_Records(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	dir, err := ioutil.TempDir("", "tzpay-records")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	payout := Payout{
+		config: config.Config{Baker: config.Baker{Address: "tz1baker"}, Price: config.Price{Currency: "eur"}},
+		store:  s,
+		price:  fixedPrice(3.5),
+		cycle:  270,
+		inject: true,
+		constructPayoutFunc: func() (tzkt.RewardsSplit, error) {
+			return tzkt.RewardsSplit{}, nil
+		},
+		applyFunc: func(delegators tzkt.Delegators) ([]string, error) {
+			return []string{"ooSomeOperation"}, nil
+		},
+	}
+	payout.SetMemo("correction for ticket 42")
+
+	rewardsSplit, err := payout.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, "correction for ticket 42", rewardsSplit.Memo)
+	assert.Equal(t, MemoHash("correction for ticket 42"), rewardsSplit.MemoHash)
+
+	records, err := Records(s, "tz1baker")
+	assert.Nil(t, err)
+	assert.Equal(t, []Record{
+		{
+			Cycle:      270,
+			Time:       now(),
+			Operations: []string{"https://tzkt.io/ooSomeOperation"},
+			Currency:   "EUR",
+			Rate:       3.5,
+			Memo:       "correction for ticket 42",
+			MemoHash:   MemoHash("correction for ticket 42"),
+		},
+	}, records)
+
+	assert.Equal(t, "", MemoHash(""))
+	assert.Len(t, MemoHash("memo"), 64)
+}
+
+func Test_CycleRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-records")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	paidAt := time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put(recordsBucket("tz1baker"), "00000270/2020-09-01T10:00:00Z", Record{Cycle: 270, Time: paidAt, Operations: []string{"oo1"}}))
+	assert.Nil(t, s.Put(paidBucket("tz1baker"), paidKey(270, "tz1a"), PaidEntry{Amount: 700, Time: paidAt}))
+	assert.Nil(t, s.Put(partialBucket("tz1baker"), paidKey(270, "tz1a"), PartialEntry{Paid: 300}))
+	assert.Nil(t, s.Put(paidBucket("tz1baker"), paidKey(270, "tz1b"), PaidEntry{Amount: 500, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket("tz1baker"), paidKey(271, "tz1a"), PaidEntry{Amount: 900, Time: paidAt}))
+
+	record, ok, err := CycleRecords(s, "tz1baker", 270)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, CycleRecord{
+		Baker:      "tz1baker",
+		Cycle:      270,
+		Payouts:    []Record{{Cycle: 270, Time: paidAt, Operations: []string{"oo1"}}},
+		Delegators: map[string]int{"tz1a": 1000, "tz1b": 500},
+		Total:      1500,
+	}, record)
+
+	_, ok, err = CycleRecords(s, "tz1baker", 269)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package payout
+
+import (
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/pkg/errors"
+)
+
+// registrationGasLimit covers the gas of a delegation, which stores nothing but can not be forged with a storage limit of 0
+const (
+	registrationGasLimit     = 10000
+	registrationStorageLimit = 1
+)
+
+/*
+Register imports the baker's own key and sends the delegation of the baker to itself, which registers it again as a
+delegate after it was deactivated. It returns the hash of the operation once it is included.
+*/
+func Register(cfg config.Config, r rpc.IFace) (string, error) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Kind:     keys.Ed25519,
+		Esk:      cfg.Notifications.Deactivation.Esk,
+		Password: cfg.Notifications.Deactivation.Password,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to import baker key")
+	}
+
+	p := &Payout{config: cfg, rpc: r, key: key}
+	return p.register()
+}
+
+func (p *Payout) register() (string, error) {
+	baker := p.key.PubKey.GetPublicKeyHash()
+	if baker != p.config.Baker.Address {
+		return "", errors.Errorf("failed to register baker: key of '%s' is not the key of baker '%s'", baker, p.config.Baker.Address)
+	}
+
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to register baker")
+	}
+
+	counter, err := p.rpc.Counter(head.Hash, baker)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to register baker")
+	}
+
+	delegation := rpc.Content{
+		Kind:         rpc.DELEGATION,
+		Source:       baker,
+		Delegate:     baker,
+		GasLimit:     registrationGasLimit,
+		StorageLimit: registrationStorageLimit,
+		Counter:      counter + 1,
+	}
+
+	if delegation.Fee, err = minimalFee(head.Hash, delegation); err != nil {
+		return "", errors.Wrap(err, "failed to register baker")
+	}
+
+	ophash, err := p.signAndInject(head.Hash, rpc.Contents{delegation})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to register baker")
+	}
+
+	if !p.confirmOperation(ophash) {
+		return ophash, errors.Errorf("failed to register baker: failed to confirm operation '%s'", ophash)
+	}
+
+	return ophash, nil
+}
@@ -0,0 +1,141 @@
+package payout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/node"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// revealFee and revealGasLimit are those of a reveal when operations are not estimated
+	revealFee      = 1420
+	revealGasLimit = 10000
+)
+
+// managerKeyRPC is a tezos RPC client that tells whether the public key of an address is revealed
+type managerKeyRPC interface {
+	ManagerKey(blockhash, address string) (string, error)
+}
+
+// nodeRPC is the go-tezos RPC client, with the RPCs payouts need that it lacks
+type nodeRPC struct {
+	rpc.IFace
+	host   string
+	client *http.Client
+}
+
+func newNodeRPC(ctx context.Context, api config.API) (nodeRPC, error) {
+	client, err := node.New(ctx, api)
+	if err != nil {
+		return nodeRPC{}, err
+	}
+
+	return nodeRPC{
+		IFace:  client,
+		host:   strings.TrimSuffix(api.Tezos, "/"),
+		client: node.Client(ctx, api.Timeout),
+	}, nil
+}
+
+// ManagerKey returns the public key revealed by address at blockhash, or "" while it is unrevealed
+func (n nodeRPC) ManagerKey(blockhash, address string) (string, error) {
+	resp, err := n.client.Get(fmt.Sprintf("%s/chains/main/blocks/%s/context/contracts/%s/manager_key", n.host, blockhash, address))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get manager key of '%s'", address)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Wrap(err, "could not read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get manager key of '%s': response returned code %d with body %s", address, resp.StatusCode, string(body))
+	}
+
+	var key *string
+	if err := json.Unmarshal(body, &key); err != nil {
+		return "", errors.Wrapf(err, "failed to parse manager key of '%s'", address)
+	}
+
+	if key == nil {
+		return "", nil
+	}
+
+	return *key, nil
+}
+
+// publicKeyer is a signer that knows the public key of the payout wallet, to reveal it
+type publicKeyer interface {
+	PublicKey() string
+}
+
+// publicKey returns the public key of the payout wallet, from its key or the signer holding it
+func (p *Payout) publicKey() (string, error) {
+	if p.signer == nil {
+		return p.key.PubKey.GetPublicKey(), nil
+	}
+
+	if signer, ok := p.signer.(publicKeyer); ok && signer.PublicKey() != "" {
+		return signer.PublicKey(), nil
+	}
+
+	return "", errors.Errorf("public key of payout wallet '%s' is unknown to its signer, reveal it before the payout", p.Wallet())
+}
+
+/*
+withReveal prepends a reveal of the public key of the payout wallet to the first operation if the wallet has never
+revealed it, as is the case of a fresh wallet, since the node refuses its transactions otherwise. The counters of
+every content after the reveal are moved up by one.
+*/
+func (p *Payout) withReveal(blockhash string, operations []rpc.Contents) ([]rpc.Contents, error) {
+	node, ok := p.rpc.(managerKeyRPC)
+	if !ok || len(operations) == 0 || len(operations[0]) == 0 {
+		return operations, nil
+	}
+
+	managerKey, err := node.ManagerKey(blockhash, p.Wallet())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to check reveal of payout wallet")
+	} else if managerKey != "" {
+		return operations, nil
+	}
+
+	publicKey, err := p.publicKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reveal payout wallet")
+	}
+
+	revealed := make([]rpc.Contents, len(operations))
+	for i, contents := range operations {
+		revealed[i] = make(rpc.Contents, len(contents))
+		copy(revealed[i], contents)
+		for j := range revealed[i] {
+			revealed[i][j].Counter++
+		}
+	}
+
+	reveal := rpc.Content{
+		Kind:      rpc.REVEAL,
+		Source:    p.Wallet(),
+		Fee:       revealFee,
+		Counter:   operations[0][0].Counter,
+		GasLimit:  revealGasLimit,
+		PublicKey: publicKey,
+	}
+	revealed[0] = append(rpc.Contents{reveal}, revealed[0]...)
+
+	logrus.WithFields(logrus.Fields{"wallet": p.Wallet(), "public_key": publicKey}).Info("Revealing public key of payout wallet with the first operation.")
+
+	return revealed, nil
+}
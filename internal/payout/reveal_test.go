@@ -0,0 +1,131 @@
+package payout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/signer"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_withReveal(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Kind:     keys.Ed25519,
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+	})
+	assert.Nil(t, err)
+	wallet := key.PubKey.GetPublicKeyHash()
+
+	transfer := func(counter int) rpc.Content {
+		return rpc.Content{
+			Kind:        rpc.TRANSACTION,
+			Source:      wallet,
+			Destination: "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV",
+			Amount:      1000000,
+			Fee:         2941,
+			GasLimit:    26283,
+			Counter:     counter,
+		}
+	}
+	operations := []rpc.Contents{{transfer(101), transfer(102)}, {transfer(103)}}
+
+	cases := []struct {
+		name     string
+		rpc      rpc.IFace
+		signer   signer.IFace
+		want     []rpc.Contents
+		wantErr  bool
+		contains string
+	}{
+		{
+			name: "leaves a revealed wallet",
+			rpc:  &test.RPCMock{},
+			want: operations,
+		},
+		{
+			name: "leaves operations if the node can not tell",
+			rpc:  benchmarkRPC{},
+			want: operations,
+		},
+		{
+			name: "reveals an unrevealed wallet with the first operation",
+			rpc:  &test.RPCMock{Unrevealed: true},
+			want: []rpc.Contents{
+				{
+					{Kind: rpc.REVEAL, Source: wallet, Fee: revealFee, Counter: 101, GasLimit: revealGasLimit, PublicKey: key.PubKey.GetPublicKey()},
+					transfer(102),
+					transfer(103),
+				},
+				{transfer(104)},
+			},
+		},
+		{
+			name:     "handles failure to get manager key",
+			rpc:      &test.RPCMock{ManagerKeyErr: true},
+			wantErr:  true,
+			contains: "failed to check reveal of payout wallet",
+		},
+		{
+			name:     "handles a signer without public key",
+			rpc:      &test.RPCMock{Unrevealed: true},
+			signer:   watchOnly(wallet),
+			wantErr:  true,
+			contains: "is unknown to its signer",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Payout{rpc: tt.rpc, key: key, signer: tt.signer}
+
+			revealed, err := p.withReveal("BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", operations)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, revealed)
+
+			_, err = forgeOperations("BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p", revealed)
+			assert.Nil(t, err)
+		})
+	}
+
+	// The reveal is not a transfer of the payout
+	assert.Equal(t, contentsTransfers(operations[:1]), contentsTransfers([]rpc.Contents{{
+		{Kind: rpc.REVEAL, Source: wallet, PublicKey: key.PubKey.GetPublicKey()},
+		transfer(102),
+		transfer(103),
+	}})[:1])
+}
+
+func Test_ManagerKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chains/main/blocks/head/context/contracts/tz1revealed/manager_key":
+			w.Write([]byte(`"edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP"`))
+		case "/chains/main/blocks/head/context/contracts/tz1unrevealed/manager_key":
+			w.Write([]byte("null\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	node := nodeRPC{host: server.URL, client: server.Client()}
+
+	managerKey, err := node.ManagerKey("head", "tz1revealed")
+	assert.Nil(t, err)
+	assert.Equal(t, "edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP", managerKey)
+
+	managerKey, err = node.ManagerKey("head", "tz1unrevealed")
+	assert.Nil(t, err)
+	assert.Equal(t, "", managerKey)
+
+	_, err = node.ManagerKey("head", "tz1unknown")
+	test.CheckErr(t, true, "response returned code 404", err)
+}
@@ -0,0 +1,135 @@
+package payout
+
+import (
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// exactRewards returns the share of total owed for balance out of stakingBalance, without loss of precision
+func exactRewards(balance, stakingBalance, total int) *big.Rat {
+	if stakingBalance == 0 {
+		return new(big.Rat)
+	}
+
+	rewards := new(big.Rat).SetFrac(big.NewInt(int64(balance)), big.NewInt(int64(stakingBalance)))
+	return rewards.Mul(rewards, new(big.Rat).SetInt64(int64(total)))
+}
+
+// feeRate returns the fee charged to a delegator as an exact rational, e.g. 0.05 as 1/20
+func (p *Payout) feeRate(address string) *big.Rat {
+	return rat(p.config.Baker.FeeFor(address))
+}
+
+// rat returns the rational a configured decimal stands for, rather than the binary approximation of the float
+func rat(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat).SetFloat64(f)
+	}
+
+	return r
+}
+
+// fee returns the fee taken by the baker on the gross rewards of a delegator, rounded with the configured policy
+func (p *Payout) fee(address string, gross int) int {
+	return p.round(new(big.Rat).Mul(new(big.Rat).SetInt64(int64(gross)), p.feeRate(address)))
+}
+
+// round rounds an amount of mutez to an integer with the configured rounding policy
+func (p *Payout) round(amount *big.Rat) int {
+	quo, rem := new(big.Int).DivMod(amount.Num(), amount.Denom(), new(big.Int))
+	switch p.config.Baker.Rounding {
+	case config.RoundingCeil:
+		if rem.Sign() != 0 {
+			quo.Add(quo, big.NewInt(1))
+		}
+	case config.RoundingRound:
+		if rem.Mul(rem, big.NewInt(2)).Cmp(amount.Denom()) >= 0 {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	return int(quo.Int64())
+}
+
+/*
+charge sets the gross rewards of delegator to gross and takes its fee again, applying its campaign again so that the
+rebate or bonus follows the new rewards. It returns the change of the fee.
+*/
+func (p *Payout) charge(delegator *tzkt.Delegator, gross int) int {
+	charged := tzkt.Delegator{Address: delegator.Address, GrossRewards: gross, Fee: p.fee(delegator.Address, gross)}
+	charged.NetRewards = charged.GrossRewards - charged.Fee
+	p.applyCampaign(&charged)
+	delegator.NetRewards += charged.NetRewards - (delegator.GrossRewards - delegator.Fee + delegator.CampaignBonus)
+
+	change := charged.Fee - delegator.Fee
+	delegator.GrossRewards, delegator.Fee = charged.GrossRewards, charged.Fee
+	delegator.CampaignRebate, delegator.CampaignBonus, delegator.Campaign = charged.CampaignRebate, charged.CampaignBonus, charged.Campaign
+	return change
+}
+
+/*
+distributeRemainder corrects the rounding of the rewards of delegators so that together they receive exactly the
+floor of what they are owed. The leftover mutez are given one by one to the delegators whose rewards were rounded
+down the most (or taken from those rounded up the most), breaking ties by address so that the result is deterministic.
+The fee of each delegator adjusted is taken again on its new gross rewards, and the change of the fees collected by
+the baker is returned. Blacklisted delegators are left out. Rewards rounded down are never given more than the floor
+of their share, so nothing is distributed with the floor policy.
+*/
+func (p *Payout) distributeRemainder(delegators tzkt.Delegators, totalRewards, stakingBalance int) int {
+	if p.config.Baker.Rounding != config.RoundingRound && p.config.Baker.Rounding != config.RoundingCeil {
+		return 0
+	}
+
+	type candidate struct {
+		index    int
+		fraction *big.Rat
+	}
+
+	exactTotal := new(big.Rat)
+	var roundedTotal int64
+	var candidates []candidate
+	for i, delegator := range delegators {
+		if delegator.BlackListed {
+			continue
+		}
+
+		exact := exactRewards(delegator.Balance, stakingBalance, totalRewards)
+		exactTotal.Add(exactTotal, exact)
+		roundedTotal += int64(delegator.GrossRewards)
+		candidates = append(candidates, candidate{
+			index:    i,
+			fraction: exact.Sub(exact, new(big.Rat).SetInt64(int64(delegator.GrossRewards))),
+		})
+	}
+
+	target := new(big.Int).Div(exactTotal.Num(), exactTotal.Denom())
+	remainder := target.Int64() - roundedTotal
+	if remainder == 0 {
+		return 0
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if c := candidates[i].fraction.Cmp(candidates[j].fraction); c != 0 {
+			return (c > 0) == (remainder > 0)
+		}
+		return delegators[candidates[i].index].Address < delegators[candidates[j].index].Address
+	})
+
+	step := 1
+	if remainder < 0 {
+		step, remainder = -1, -remainder
+	}
+
+	var fees int
+	for i := 0; i < int(remainder) && i < len(candidates); i++ {
+		delegator := &delegators[candidates[i].index]
+		fees += p.charge(delegator, delegator.GrossRewards+step)
+	}
+
+	return fees
+}
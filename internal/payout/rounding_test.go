@@ -0,0 +1,142 @@
+package payout
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_round(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+		amount *big.Rat
+		want   int
+	}{
+		{"floor rounds down", config.RoundingFloor, big.NewRat(7, 2), 3},
+		{"round rounds half up", config.RoundingRound, big.NewRat(7, 2), 4},
+		{"round rounds down below half", config.RoundingRound, big.NewRat(10, 3), 3},
+		{"ceil rounds up", config.RoundingCeil, big.NewRat(10, 3), 4},
+		{"ceil keeps whole amounts", config.RoundingCeil, big.NewRat(9, 3), 3},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{config: config.Config{Baker: config.Baker{Rounding: tt.policy}}}
+			assert.Equal(t, tt.want, payout.round(tt.amount))
+		})
+	}
+}
+
+func Test_fee(t *testing.T) {
+	// 100*0.29 is 28.999999999999996 as a float64, so truncating float math takes 28 instead of 29
+	payout := Payout{config: config.Config{Baker: config.Baker{Fee: 0.29, Rounding: config.RoundingFloor, Fees: []string{"tz1a:0.02"}}}}
+	assert.Equal(t, 29, payout.fee("tz1b", 100))
+	assert.Equal(t, 2, payout.fee("tz1a", 100))
+}
+
+func Test_distributeRemainder(t *testing.T) {
+	cases := []struct {
+		name       string
+		policy     string
+		delegators tzkt.Delegators
+		want       []int
+	}{
+		{
+			"gives leftover mutez to the largest remainders",
+			config.RoundingRound,
+			tzkt.Delegators{
+				{Address: "tz1a", Balance: 1},
+				{Address: "tz1b", Balance: 1},
+				{Address: "tz1c", Balance: 1},
+			},
+			[]int{4, 3, 3},
+		},
+		{
+			"takes over-paid mutez back",
+			config.RoundingCeil,
+			tzkt.Delegators{
+				{Address: "tz1a", Balance: 1},
+				{Address: "tz1b", Balance: 1},
+				{Address: "tz1c", Balance: 1},
+			},
+			[]int{3, 3, 4},
+		},
+		{
+			"leaves rewards rounded down with the floor policy",
+			config.RoundingFloor,
+			tzkt.Delegators{
+				{Address: "tz1a", Balance: 1},
+				{Address: "tz1b", Balance: 1},
+				{Address: "tz1c", Balance: 1},
+			},
+			[]int{3, 3, 3},
+		},
+		{
+			"skips blacklisted delegators",
+			config.RoundingRound,
+			tzkt.Delegators{
+				{Address: "tz1a", Balance: 1, BlackListed: true},
+				{Address: "tz1b", Balance: 1},
+				{Address: "tz1c", Balance: 1},
+			},
+			[]int{3, 3, 3},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			payout := Payout{config: config.Config{Baker: config.Baker{Rounding: tt.policy}}}
+			for i := range tt.delegators {
+				tt.delegators[i].GrossRewards = payout.round(exactRewards(tt.delegators[i].Balance, 3, 10))
+				tt.delegators[i].NetRewards = tt.delegators[i].GrossRewards
+			}
+
+			assert.Equal(t, 0, payout.distributeRemainder(tt.delegators, 10, 3))
+			for i, delegator := range tt.delegators {
+				assert.Equal(t, tt.want[i], delegator.GrossRewards)
+				assert.Equal(t, tt.want[i], delegator.NetRewards)
+			}
+		})
+	}
+}
+
+func Test_distributeRemainder_fees(t *testing.T) {
+	payout := Payout{config: config.Config{Baker: config.Baker{Fee: 0.1, Rounding: config.RoundingRound}}}
+	delegators := tzkt.Delegators{
+		{Address: "tz1a", Balance: 1},
+		{Address: "tz1b", Balance: 1},
+		{Address: "tz1c", Balance: 1},
+	}
+	var fees int
+	for i := range delegators {
+		delegators[i].GrossRewards = payout.round(exactRewards(delegators[i].Balance, 3, 100))
+		delegators[i].Fee = payout.fee(delegators[i].Address, delegators[i].GrossRewards)
+		delegators[i].NetRewards = delegators[i].GrossRewards - delegators[i].Fee
+		fees += delegators[i].Fee
+	}
+
+	// 33 mutez each, with a fee of 3, leave 1 mutez to tz1a whose fee is then 3 of 34
+	fees += payout.distributeRemainder(delegators, 100, 3)
+	assert.Equal(t, []int{34, 33, 33}, []int{delegators[0].GrossRewards, delegators[1].GrossRewards, delegators[2].GrossRewards})
+	for _, delegator := range delegators {
+		assert.Equal(t, payout.fee(delegator.Address, delegator.GrossRewards), delegator.Fee)
+		assert.Equal(t, delegator.GrossRewards-delegator.Fee, delegator.NetRewards)
+	}
+	assert.Equal(t, 9, fees)
+
+	// 1005 mutez each, with a fee of 101, take 1 mutez back from tz1a whose fee is then 100 of 1004
+	delegators = tzkt.Delegators{{Address: "tz1a", Balance: 1}, {Address: "tz1b", Balance: 1}, {Address: "tz1c", Balance: 1}}
+	for i := range delegators {
+		delegators[i].GrossRewards = payout.round(exactRewards(delegators[i].Balance, 3, 3014))
+		delegators[i].Fee = payout.fee(delegators[i].Address, delegators[i].GrossRewards)
+		delegators[i].NetRewards = delegators[i].GrossRewards - delegators[i].Fee
+	}
+	assert.Equal(t, -1, payout.distributeRemainder(delegators, 3014, 3))
+	assert.Equal(t, 1004, delegators[0].GrossRewards)
+	assert.Equal(t, 100, delegators[0].Fee)
+	assert.Equal(t, 904, delegators[0].NetRewards)
+}
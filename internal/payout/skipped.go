@@ -0,0 +1,61 @@
+package payout
+
+import "github.com/goat-systems/tzpay/v3/internal/tzkt"
+
+// Reasons a delegator is not paid for a cycle
+const (
+	SkipReasonBlacklist      = "blacklisted"
+	SkipReasonContract       = "smart contract excluded"
+	SkipReasonHeld           = "held, contract refuses transfers"
+	SkipReasonEmptyAccount   = "empty account requires a burn fee"
+	SkipReasonMinimumPayment = "below minimum payment"
+	SkipReasonAccumulated    = "accumulated below threshold"
+	SkipReasonScheduled      = "waiting for payout schedule"
+)
+
+// SkippedDelegator is a delegator, or a liquidity provider of a contract, that will not be paid for a cycle
+type SkippedDelegator struct {
+	Address    string `json:"address"`
+	Contract   string `json:"contract,omitempty"`
+	NetRewards int    `json:"net_rewards"`
+	Reason     string `json:"reason"`
+}
+
+// skipReason keeps the first reason found for skipping a delegator
+func skipReason(current, reason string) string {
+	if current != "" {
+		return current
+	}
+
+	return reason
+}
+
+// Skipped returns every delegator and liquidity provider of a payout that will not be paid, with the reason why
+func Skipped(rewardsSplit tzkt.RewardsSplit) []SkippedDelegator {
+	var skipped []SkippedDelegator
+	for _, delegator := range rewardsSplit.Delegators {
+		if delegator.LiquidityProviders != nil {
+			for _, lp := range delegator.LiquidityProviders {
+				if lp.SkipReason != "" {
+					skipped = append(skipped, SkippedDelegator{
+						Address:    lp.Address,
+						Contract:   delegator.Address,
+						NetRewards: lp.NetRewards,
+						Reason:     lp.SkipReason,
+					})
+				}
+			}
+			continue
+		}
+
+		if delegator.SkipReason != "" {
+			skipped = append(skipped, SkippedDelegator{
+				Address:    delegator.Address,
+				NetRewards: delegator.NetRewards,
+				Reason:     delegator.SkipReason,
+			})
+		}
+	}
+
+	return skipped
+}
@@ -0,0 +1,37 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Skipped(t *testing.T) {
+	rewardsSplit := tzkt.RewardsSplit{
+		Delegators: tzkt.Delegators{
+			{Address: "tz1a", NetRewards: 100},
+			{Address: "tz1b", NetRewards: 10, BlackListed: true, SkipReason: SkipReasonMinimumPayment},
+			{Address: "tz1c", NetRewards: 50, Accumulated: true, SkipReason: SkipReasonAccumulated},
+			{
+				Address:    "KT1a",
+				NetRewards: 1000,
+				LiquidityProviders: []tzkt.LiquidityProvider{
+					{Address: "tz1d", NetRewards: 900},
+					{Address: "tz1e", NetRewards: 100, BlackListed: true, SkipReason: SkipReasonBlacklist},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []SkippedDelegator{
+		{Address: "tz1b", NetRewards: 10, Reason: SkipReasonMinimumPayment},
+		{Address: "tz1c", NetRewards: 50, Reason: SkipReasonAccumulated},
+		{Address: "tz1e", Contract: "KT1a", NetRewards: 100, Reason: SkipReasonBlacklist},
+	}, Skipped(rewardsSplit))
+}
+
+func Test_skipReason(t *testing.T) {
+	assert.Equal(t, SkipReasonBlacklist, skipReason("", SkipReasonBlacklist))
+	assert.Equal(t, SkipReasonBlacklist, skipReason(SkipReasonBlacklist, SkipReasonMinimumPayment))
+}
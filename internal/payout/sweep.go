@@ -0,0 +1,61 @@
+package payout
+
+import (
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+)
+
+// allocationBurn is the burn paid for allocating a new implicit account (257 bytes at 250 mutez per byte)
+const allocationBurn = 257 * 250
+
+// Sweep transfers the entire balance of the payout wallet, less fees, to destination and waits for its inclusion
+func (p *Payout) Sweep(destination string) (string, error) {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sweep wallet")
+	}
+
+	source := p.Wallet()
+	balance, err := p.rpc.Balance(rpc.BalanceInput{
+		Blockhash: head.Hash,
+		Address:   source,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sweep wallet")
+	}
+
+	counter, err := p.rpc.Counter(head.Hash, source)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sweep wallet")
+	}
+
+	transfer := rpc.Content{
+		Kind:         rpc.TRANSACTION,
+		Source:       source,
+		Destination:  destination,
+		Amount:       1,
+		GasLimit:     int64(p.config.Operations.GasLimit),
+		StorageLimit: 257,
+		Counter:      counter + 1,
+	}
+
+	if transfer.Fee, err = minimalFee(head.Hash, transfer); err != nil {
+		return "", errors.Wrap(err, "failed to sweep wallet")
+	}
+
+	transfer.Amount = int64(balance) - transfer.Fee - allocationBurn
+	if transfer.Amount <= 0 {
+		return "", errors.Errorf("failed to sweep wallet: balance of %d mutez does not cover fees", balance)
+	}
+
+	ophash, err := p.signAndInject(head.Hash, rpc.Contents{transfer})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sweep wallet")
+	}
+
+	if !p.confirmOperation(ophash) {
+		return ophash, errors.Errorf("failed to sweep wallet: failed to confirm operation '%s'", ophash)
+	}
+
+	return ophash, nil
+}
@@ -0,0 +1,82 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sweep(t *testing.T) {
+	type want struct {
+		err      bool
+		contains string
+		ophash   string
+	}
+
+	cases := []struct {
+		name  string
+		input rpc.IFace
+		want  want
+	}{
+		{
+			"handles failure to get balance",
+			&test.RPCMock{
+				BalanceErr: true,
+			},
+			want{
+				true,
+				"failed to get balance",
+				"",
+			},
+		},
+		{
+			"handles failure to inject",
+			&test.RPCMock{
+				InjectionOperationErr: true,
+			},
+			want{
+				true,
+				"failed to inject operation",
+				"",
+			},
+		},
+		{
+			"is successful",
+			&test.RPCMock{},
+			want{
+				false,
+				"",
+				"ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := keys.NewKey(keys.NewKeyInput{
+				Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+				Password: "password12345##",
+				Kind:     keys.Ed25519,
+			})
+			assert.Nil(t, err)
+
+			payout := Payout{
+				rpc: tt.input,
+				config: config.Config{
+					Operations: config.Operations{
+						GasLimit: 10300,
+					},
+				},
+				key: key,
+			}
+
+			ophash, err := payout.Sweep("tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc")
+			test.CheckErr(t, tt.want.err, tt.want.contains, err)
+			assert.Equal(t, tt.want.ophash, ophash)
+		})
+	}
+}
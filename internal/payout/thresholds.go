@@ -0,0 +1,60 @@
+package payout
+
+import (
+	"sort"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// ThresholdAnalysis is what a minimum payment would have skipped over the payouts of the cycles analyzed
+type ThresholdAnalysis struct {
+	MinimumPayment int     `json:"minimum_payment"`
+	Cycles         int     `json:"cycles"`
+	Delegators     int     `json:"delegators"`
+	Skipped        int     `json:"skipped"`
+	SkippedRatio   float64 `json:"skipped_ratio"`
+	Withheld       int     `json:"withheld"`
+	FeeSavings     int     `json:"fee_savings"`
+}
+
+/*
+AnalyzeThresholds replays the payouts of rewardsSplits, computed without a minimum payment, under every minimum
+payment of thresholds. For each, it reports the distinct delegators skipped at least once, the transfers skipped
+over all cycles and their ratio to the transfers made, the rewards left unpaid, and the networkFee of every transfer
+skipped saved. Delegators skipped for another reason, such as the blacklist, are left out.
+*/
+func AnalyzeThresholds(rewardsSplits []tzkt.RewardsSplit, thresholds []int, networkFee int) []ThresholdAnalysis {
+	thresholds = append([]int{}, thresholds...)
+	sort.Ints(thresholds)
+
+	var analyses []ThresholdAnalysis
+	for _, threshold := range thresholds {
+		analysis := ThresholdAnalysis{MinimumPayment: threshold, Cycles: len(rewardsSplits)}
+		skipped := map[string]bool{}
+
+		var transfers int
+		for _, rewardsSplit := range rewardsSplits {
+			for _, delegator := range rewardsSplit.Delegators {
+				if delegator.BlackListed {
+					continue
+				}
+				transfers++
+
+				if delegator.NetRewards < threshold {
+					skipped[delegator.Address] = true
+					analysis.Skipped++
+					analysis.Withheld += delegator.NetRewards
+					analysis.FeeSavings += networkFee
+				}
+			}
+		}
+
+		analysis.Delegators = len(skipped)
+		if transfers > 0 {
+			analysis.SkippedRatio = float64(analysis.Skipped) / float64(transfers)
+		}
+		analyses = append(analyses, analysis)
+	}
+
+	return analyses
+}
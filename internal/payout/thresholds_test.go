@@ -0,0 +1,37 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AnalyzeThresholds(t *testing.T) {
+	rewardsSplits := []tzkt.RewardsSplit{
+		{
+			Cycle: 300,
+			Delegators: tzkt.Delegators{
+				{Address: "tz1a", NetRewards: 500000},
+				{Address: "tz1b", NetRewards: 8000},
+				{Address: "tz1c", NetRewards: 40000},
+				{Address: "tz1d", NetRewards: 100, BlackListed: true},
+			},
+		},
+		{
+			Cycle: 301,
+			Delegators: tzkt.Delegators{
+				{Address: "tz1a", NetRewards: 450000},
+				{Address: "tz1b", NetRewards: 12000},
+			},
+		},
+	}
+
+	assert.Equal(t, []ThresholdAnalysis{
+		{MinimumPayment: 0, Cycles: 2},
+		{MinimumPayment: 10000, Cycles: 2, Delegators: 1, Skipped: 1, SkippedRatio: 0.2, Withheld: 8000, FeeSavings: 2941},
+		{MinimumPayment: 50000, Cycles: 2, Delegators: 2, Skipped: 3, SkippedRatio: 0.6, Withheld: 60000, FeeSavings: 3 * 2941},
+	}, AnalyzeThresholds(rewardsSplits, []int{50000, 0, 10000}, 2941))
+
+	assert.Equal(t, []ThresholdAnalysis{{MinimumPayment: 10000}}, AnalyzeThresholds(nil, []int{10000}, 2941))
+}
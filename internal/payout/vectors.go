@@ -0,0 +1,190 @@
+package payout
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+
+	"github.com/goat-systems/go-tezos/v3/forge"
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// forgeVector is an operation and the bytes the protocol encodes it to
+type forgeVector struct {
+	name     string
+	branch   string
+	contents rpc.Contents
+	forged   string
+}
+
+// signVector is a message and its signature by the Ed25519 key of seed, the first test vector of RFC 8032
+type signVector struct {
+	name      string
+	seed      string
+	publicKey string
+	message   string
+	signature string
+}
+
+// legacyProtocols encode manager operations as they were before Babylon, with tags and sources tzpay does not forge
+var legacyProtocols = map[string]bool{
+	"PtCJ7pwoxe8JasnHY8YonnLYjcVHmhiARPJvqcC6VfHT5s8k8sY": true,
+	"PsYLVpVvgbLhAhoqAkMFUo6gudkJ9weNXhUYCiLDzcUpFpkk8Wt": true,
+	"PsddFKi32cMJ2qPjf43Qv5GDWLDPZb3T3bF6fLKiF5HtvHNU7aP": true,
+	"Pt24m4xiPbLDhVgVfABUjirbmda3yohdN82Sp1FeuXjiC7iXQNu": true,
+}
+
+var forgeVectors = []forgeVector{
+	{
+		name:   "transaction from tz1 to tz1",
+		branch: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p",
+		contents: rpc.Contents{
+			{
+				Kind:        rpc.TRANSACTION,
+				Source:      "tz1N7tYGMGs3GGjeJAJKtbycAWcvoPNSUYgu",
+				Destination: "tz1icdoLr8vof5oXiEKCFSyrVoouGiKDQ3Gd",
+				Amount:      1000000,
+				Fee:         2941,
+				GasLimit:    26283,
+				Counter:     2015603,
+			},
+		},
+		forged: "7cc601d2729c90b267e6a79d902f8b048d37fd990f2f7447efefb0cfb2f8e8a46c001b3517cf5af0ac86b8efe88452908c45f5c7e079fd16f3827babcd0100c0843d0000fc075e014f7f8f619e60f00b314c2b8297f2b72400",
+	},
+	{
+		name:   "batch of transactions from tz2 to KT1 and tz2 with storage limits",
+		branch: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p",
+		contents: rpc.Contents{
+			{
+				Kind:         rpc.TRANSACTION,
+				Source:       "tz2EgTtCdYoDiuJhxMLPBSgh3UsfSprudoiv",
+				Destination:  "KT1FPyY6mAhnzyVGP8ApGvuRyF7SKcT9TDWy",
+				Amount:       250000,
+				Fee:          2941,
+				GasLimit:     26283,
+				StorageLimit: 257,
+				Counter:      7,
+			},
+			{
+				Kind:         rpc.TRANSACTION,
+				Source:       "tz2EgTtCdYoDiuJhxMLPBSgh3UsfSprudoiv",
+				Destination:  "tz2EgTtCdYoDiuJhxMLPBSgh3UsfSprudoiv",
+				Amount:       150,
+				Fee:          2941,
+				GasLimit:     26283,
+				StorageLimit: 257,
+				Counter:      8,
+			},
+		},
+		forged: "7cc601d2729c90b267e6a79d902f8b048d37fd990f2f7447efefb0cfb2f8e8a46c0145d486e3a7bfe4cb6d0e22cfc27bc98ef3f5c83cfd1607abcd01810290a10f014abe4c4c0b891997c489bbe616beb915ee77b20000006c0145d486e3a7bfe4cb6d0e22cfc27bc98ef3f5c83cfd1608abcd0181029601000145d486e3a7bfe4cb6d0e22cfc27bc98ef3f5c83c00",
+	},
+}
+
+var signVectors = []signVector{
+	{
+		name:      "signature of the transaction from tz1 to tz1",
+		seed:      "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60",
+		publicKey: "edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP",
+		message:   "7cc601d2729c90b267e6a79d902f8b048d37fd990f2f7447efefb0cfb2f8e8a46c001b3517cf5af0ac86b8efe88452908c45f5c7e079fd16f3827babcd0100c0843d0000fc075e014f7f8f619e60f00b314c2b8297f2b72400",
+		signature: "c09923134fbbb45e7a0295430f48d004cd893ca82d7097db35dbe6734efad301873a452abae47463766974f48c5784591c72b2aba545a236099141ba008dfd0a",
+	},
+}
+
+/*
+CheckVectors forges and signs known operations and compares the result with the bytes and signatures expected, so
+that a build of tzpay forging or signing differently from the protocol is refused before any funds move. A protocol
+encoding operations as before Babylon is refused as well. The protocol is unchecked if empty, e.g. offline.
+*/
+func CheckVectors(protocol string) error {
+	return checkVectors(protocol, forgeVectors, signVectors)
+}
+
+// validateProtocol checks the forging and signing of tzpay against the test vectors for the protocol of the head
+func (p *Payout) validateProtocol() error {
+	head, err := p.rpc.Head()
+	if err != nil {
+		return errors.Wrap(err, "failed to check test vectors")
+	}
+
+	return CheckVectors(head.Metadata.Protocol)
+}
+
+func checkVectors(protocol string, forgeVectors []forgeVector, signVectors []signVector) error {
+	if legacyProtocols[protocol] {
+		return errors.Errorf("failed to check test vectors: protocol '%s' encodes operations as before Babylon, which tzpay does not forge", protocol)
+	}
+
+	for _, vector := range forgeVectors {
+		forged, err := forge.Encode(vector.branch, vector.contents...)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check test vectors: failed to forge %s", vector.name)
+		}
+
+		if forged != vector.forged {
+			return errors.Errorf("failed to check test vectors: %s forged to '%s', expected '%s'", vector.name, forged, vector.forged)
+		}
+	}
+
+	for _, vector := range signVectors {
+		if err := checkSignVector(vector); err != nil {
+			return errors.Wrapf(err, "failed to check test vectors: %s", vector.name)
+		}
+	}
+
+	return nil
+}
+
+/*
+checkSignVector imports the key of vector and signs its message, and verifies the signature expected for the message
+and not for another. Signatures are verified with the standard library, against the public key derived.
+*/
+func checkSignVector(vector signVector) error {
+	seed, err := hex.DecodeString(vector.seed)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode seed")
+	}
+
+	key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Bytes: seed})
+	if err != nil {
+		return errors.Wrap(err, "failed to import key")
+	}
+
+	if publicKey := key.PubKey.GetPublicKey(); publicKey != vector.publicKey {
+		return errors.Errorf("derived public key '%s', expected '%s'", publicKey, vector.publicKey)
+	}
+
+	signature, err := key.Sign(keys.SignInput{Message: vector.message})
+	if err != nil {
+		return errors.Wrap(err, "failed to sign")
+	}
+
+	if hex.EncodeToString(signature.Bytes) != vector.signature {
+		return errors.Errorf("signed '%s', expected '%s'", hex.EncodeToString(signature.Bytes), vector.signature)
+	}
+
+	message, err := hex.DecodeString(vector.message)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode message")
+	}
+	expected, err := hex.DecodeString(vector.signature)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode signature")
+	}
+
+	// Operations are signed with the generic watermark before them
+	watermarked := append([]byte{3}, message...)
+	digest := blake2b.Sum256(watermarked)
+	if !ed25519.Verify(ed25519.PublicKey(key.PubKey.GetBytes()), digest[:], expected) {
+		return errors.New("signature expected did not verify")
+	}
+
+	watermarked[len(watermarked)-1] ^= 1
+	digest = blake2b.Sum256(watermarked)
+	if ed25519.Verify(ed25519.PublicKey(key.PubKey.GetBytes()), digest[:], expected) {
+		return errors.New("signature verified for another message")
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package payout
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+)
+
+func Test_CheckVectors(t *testing.T) {
+	forged := forgeVectors[0]
+	forged.forged = forged.forged[:len(forged.forged)-2] + "01"
+
+	signed := signVectors[0]
+	signed.message = forgeVectors[1].forged
+
+	cases := []struct {
+		name         string
+		protocol     string
+		forgeVectors []forgeVector
+		signVectors  []signVector
+		wantErr      bool
+		errMsg       string
+	}{
+		{
+			name:         "is successful",
+			protocol:     "PtHangz2aRngywmSRGGvrcTyMbbdpWdpFKuS4uMWxg2RaH9i1qx",
+			forgeVectors: forgeVectors,
+			signVectors:  signVectors,
+		},
+		{
+			name:         "is successful offline",
+			forgeVectors: forgeVectors,
+			signVectors:  signVectors,
+		},
+		{
+			name:     "refuses a protocol before Babylon",
+			protocol: "Pt24m4xiPbLDhVgVfABUjirbmda3yohdN82Sp1FeuXjiC7iXQNu",
+			wantErr:  true,
+			errMsg:   "encodes operations as before Babylon",
+		},
+		{
+			name:         "refuses different forged bytes",
+			forgeVectors: []forgeVector{forged},
+			wantErr:      true,
+			errMsg:       "transaction from tz1 to tz1 forged to",
+		},
+		{
+			name:        "refuses a different signature",
+			signVectors: []signVector{signed},
+			wantErr:     true,
+			errMsg:      "signature of the transaction from tz1 to tz1: signed",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVectors(tt.protocol, tt.forgeVectors, tt.signVectors)
+			test.CheckErr(t, tt.wantErr, tt.errMsg, err)
+		})
+	}
+
+	test.CheckErr(t, false, "", CheckVectors(""))
+}
+
+func Test_validateProtocol(t *testing.T) {
+	p := &Payout{rpc: &test.RPCMock{}}
+	test.CheckErr(t, false, "", p.validateProtocol())
+
+	p = &Payout{rpc: &test.RPCMock{HeadErr: true}}
+	test.CheckErr(t, true, "failed to check test vectors", p.validateProtocol())
+}
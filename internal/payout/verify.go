@@ -0,0 +1,251 @@
+package payout
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// Kinds of mismatches between the store and the chain
+const (
+	// MismatchMissing is a delegator recorded as paid to whom no transaction was found on chain
+	MismatchMissing = "missing"
+	// MismatchAmount is a delegator recorded as paid an amount other than the transaction found on chain
+	MismatchAmount = "amount"
+	// MismatchUnrecorded is a transaction found on chain to an address the store does not record as paid
+	MismatchUnrecorded = "unrecorded"
+	// MismatchOperation is an operation recorded for the cycle that was not found applied on chain
+	MismatchOperation = "operation"
+)
+
+// VerifyInput is the input for Verify
+type VerifyInput struct {
+	Store  store.IFace
+	Tzkt   tzkt.IFace
+	Config config.Config
+	Wallet string // the payout wallet whose transactions are scanned
+	Cycle  int
+}
+
+// Mismatch is a difference between what the store records as paid for a cycle and the transactions on chain
+type Mismatch struct {
+	Kind      string `json:"kind"`
+	Address   string `json:"address,omitempty"`
+	Recorded  int    `json:"recorded,omitempty"`
+	Chain     int    `json:"chain,omitempty"`
+	Operation string `json:"operation,omitempty"`
+}
+
+// Verification is the result of checking the payout of a cycle against the chain
+type Verification struct {
+	Baker      string     `json:"baker"`
+	Wallet     string     `json:"wallet"`
+	Cycle      int        `json:"cycle"`
+	Operations []string   `json:"operations"`
+	Paid       int        `json:"paid"`
+	Found      int        `json:"found"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// OK returns true if the store and the chain agree on the payout of the cycle
+func (v Verification) OK() bool {
+	return len(v.Mismatches) == 0
+}
+
+/*
+Verify cross-checks what the store records as paid for a cycle against the transactions the payout wallet sent, as
+seen by the indexer, directly or through the disperse contract. Transactions of the operations recorded for the cycle
+belong to it, and so do transactions of operations the store does not know about sent during the cycle after it, when
+a cycle is paid. Every delegator recorded as paid without a matching transaction is reported, and so is every
+transaction of the cycle to an address not recorded as paid.
+*/
+func Verify(input VerifyInput) (Verification, error) {
+	baker := input.Config.Baker.Address
+	verification := Verification{Baker: baker, Wallet: input.Wallet, Cycle: input.Cycle, Operations: []string{}, Mismatches: []Mismatch{}}
+
+	payouts := store.NewPayouts(input.Store)
+	records, err := payouts.GetPayout(baker, input.Cycle)
+	if err != nil {
+		return verification, errors.Wrapf(err, "failed to verify cycle %d", input.Cycle)
+	}
+
+	operations, others := map[string]bool{}, map[string]bool{}
+	for _, record := range records {
+		for _, operation := range record.Operations {
+			if !operations[operation] {
+				verification.Operations = append(verification.Operations, operation)
+			}
+			operations[operation] = true
+		}
+	}
+
+	all, err := payouts.ListPayouts(baker)
+	if err != nil {
+		return verification, errors.Wrapf(err, "failed to verify cycle %d", input.Cycle)
+	}
+	for _, record := range all {
+		for _, operation := range record.Operations {
+			if !operations[operation] {
+				others[operation] = true
+			}
+		}
+	}
+
+	paid, err := paidInCycle(input.Store, baker, input.Cycle)
+	if err != nil {
+		return verification, errors.Wrapf(err, "failed to verify cycle %d", input.Cycle)
+	}
+	verification.Paid = len(paid)
+
+	// partial payouts are made while the cycle is in progress, and the transactions the store does not know about are
+	// bounded to the cycle after it
+	first, _, err := CycleLevels(input.Tzkt, input.Cycle)
+	if err != nil {
+		return verification, errors.Wrapf(err, "failed to verify cycle %d", input.Cycle)
+	}
+	start, end, err := CycleLevels(input.Tzkt, input.Cycle+1)
+	if err != nil {
+		return verification, errors.Wrapf(err, "failed to verify cycle %d", input.Cycle)
+	}
+
+	transactions, err := walletTransactions(input.Tzkt, input.Wallet, first)
+	if err != nil {
+		return verification, errors.Wrapf(err, "failed to verify cycle %d", input.Cycle)
+	}
+
+	found := map[string]bool{}
+	chain := map[string][]tzkt.Transaction{}
+	for _, transaction := range transactions {
+		// the call of the disperse contract is not a transfer to a delegator, its internal transfers are
+		if transaction.Parameters != "" || others[transaction.Hash] {
+			continue
+		}
+		if operations[transaction.Hash] {
+			found[transaction.Hash] = true
+		} else if transaction.Level < start || transaction.Level > end {
+			continue
+		}
+		chain[transaction.Target.Address] = append(chain[transaction.Target.Address], transaction)
+	}
+
+	addresses := []string{}
+	for address := range paid {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		amount := paid[address]
+		destination := input.Config.Baker.PayoutAddress(address)
+		if len(chain[destination]) == 0 {
+			destination = address
+		}
+
+		// a delegator is paid by a transaction per partial payout and one for the rest of the cycle
+		transactions := chain[destination]
+		delete(chain, destination)
+		if len(transactions) == 0 {
+			verification.Mismatches = append(verification.Mismatches, Mismatch{Kind: MismatchMissing, Address: address, Recorded: amount})
+			continue
+		}
+
+		verification.Found++
+		var sent int
+		for _, transaction := range transactions {
+			sent += transaction.Amount
+		}
+		if sent != amount {
+			verification.Mismatches = append(verification.Mismatches, Mismatch{
+				Kind:      MismatchAmount,
+				Address:   address,
+				Recorded:  amount,
+				Chain:     sent,
+				Operation: transactions[0].Hash,
+			})
+		}
+	}
+
+	targets := []string{}
+	for target := range chain {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		for _, transaction := range chain[target] {
+			verification.Mismatches = append(verification.Mismatches, Mismatch{
+				Kind:      MismatchUnrecorded,
+				Address:   target,
+				Chain:     transaction.Amount,
+				Operation: transaction.Hash,
+			})
+		}
+	}
+
+	for _, operation := range verification.Operations {
+		if !found[operation] {
+			verification.Mismatches = append(verification.Mismatches, Mismatch{Kind: MismatchOperation, Operation: operation})
+		}
+	}
+
+	return verification, nil
+}
+
+// paidInCycle returns the amount the store records as paid to each delegator for cycle, partial payouts included
+func paidInCycle(s store.IFace, baker string, cycle int) (map[string]int, error) {
+	paid := map[string]int{}
+	for _, bucket := range []string{paidBucket(baker), partialBucket(baker)} {
+		keys, err := s.Keys(bucket)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := paidKey(cycle, "")
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			var entry struct {
+				Amount int `json:"amount"`
+				Paid   int `json:"paid"`
+			}
+			if _, err := s.Get(bucket, key, &entry); err != nil {
+				return nil, err
+			}
+			paid[strings.TrimPrefix(key, prefix)] += entry.Amount + entry.Paid
+		}
+	}
+
+	return paid, nil
+}
+
+// walletTransactions returns the applied transactions sent by wallet, or by a contract it called, from level on
+func walletTransactions(client tzkt.IFace, wallet string, level int) ([]tzkt.Transaction, error) {
+	seen := map[int]bool{}
+	transactions := []tzkt.Transaction{}
+	for _, role := range []string{"sender", "initiator"} {
+		page, err := client.GetTransactions(
+			tzkt.URLParameters{Key: role, Value: wallet},
+			tzkt.URLParameters{Key: "level.ge", Value: strconv.Itoa(level)},
+			tzkt.URLParameters{Key: "status", Value: "applied"},
+			tzkt.URLParameters{Key: "limit", Value: "10000"},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, transaction := range page {
+			if !seen[transaction.ID] {
+				seen[transaction.ID] = true
+				transactions = append(transactions, transaction)
+			}
+		}
+	}
+
+	return transactions, nil
+}
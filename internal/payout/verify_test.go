@@ -0,0 +1,87 @@
+package payout
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-verify")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	baker, wallet := "tz1baker", "tz1wallet"
+	paidAt := time.Date(2020, 9, 1, 10, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1ok"), PaidEntry{Amount: 1000, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1partial"), PaidEntry{Amount: 700, Time: paidAt}))
+	assert.Nil(t, s.Put(partialBucket(baker), paidKey(270, "tz1partial"), PartialEntry{Paid: 300}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1redirected"), PaidEntry{Amount: 400, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1missing"), PaidEntry{Amount: 500, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(270, "tz1short"), PaidEntry{Amount: 600, Time: paidAt}))
+	assert.Nil(t, s.Put(paidBucket(baker), paidKey(269, "tz1earlier"), PaidEntry{Amount: 900, Time: paidAt}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000270/2020-09-01T10:00:00Z", Record{Cycle: 270, Time: paidAt, Operations: []string{"oo1", "oo2", "ooLost"}}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000270/2020-08-31T10:00:00Z", Record{Cycle: 270, Time: paidAt.Add(-24 * time.Hour), Operations: []string{"ooPartial"}}))
+	assert.Nil(t, s.Put(recordsBucket(baker), "00000269/2020-08-29T10:00:00Z", Record{Cycle: 269, Time: paidAt, Operations: []string{"oo269"}}))
+
+	transaction := func(id, level int, hash, target string, amount int, parameters string) tzkt.Transaction {
+		var tx tzkt.Transaction
+		tx.ID, tx.Level, tx.Hash, tx.Amount, tx.Parameters = id, level, hash, amount, parameters
+		tx.Target.Address = target
+		return tx
+	}
+
+	// cycles are 10 blocks long: cycle 270 ends at level 2710, its partial payout is made during it and its payout in cycle 271
+	client := &test.TzktMock{Cycles: map[int]tzkt.Cycle{
+		270: {Index: 270, FirstLevel: 2701, LastLevel: 2710},
+		271: {Index: 271, FirstLevel: 2711, LastLevel: 2720},
+	}, Transactions: []tzkt.Transaction{
+		transaction(1, 2705, "ooPartial", "tz1partial", 300, ""),
+		transaction(2, 2712, "oo1", "tz1ok", 1000, ""),
+		transaction(3, 2712, "oo1", "KT1disperse", 2700, `{"entrypoint":"disperse"}`),
+		transaction(4, 2712, "oo1", "tz1partial", 700, ""),
+		transaction(5, 2712, "oo2", "tz1payout", 400, ""),
+		transaction(6, 2712, "oo2", "tz1short", 550, ""),
+		transaction(7, 2713, "ooUnknown", "tz1stranger", 800, ""),
+		transaction(8, 2713, "oo269", "tz1earlier", 900, ""),
+		transaction(9, 2725, "ooLater", "tz1later", 100, ""),
+		transaction(10, 2706, "ooEarly", "tz1early", 100, ""),
+	}}
+
+	cfg := config.Config{Baker: config.Baker{Address: baker, PayoutAddresses: []string{"tz1redirected:tz1payout"}}}
+	verification, err := Verify(VerifyInput{Store: s, Tzkt: client, Config: cfg, Wallet: wallet, Cycle: 270})
+	assert.Nil(t, err)
+
+	assert.Equal(t, Verification{
+		Baker:      baker,
+		Wallet:     wallet,
+		Cycle:      270,
+		Operations: []string{"ooPartial", "oo1", "oo2", "ooLost"},
+		Paid:       5,
+		Found:      4,
+		Mismatches: []Mismatch{
+			{Kind: MismatchMissing, Address: "tz1missing", Recorded: 500},
+			{Kind: MismatchAmount, Address: "tz1short", Recorded: 600, Chain: 550, Operation: "oo2"},
+			{Kind: MismatchUnrecorded, Address: "tz1stranger", Chain: 800, Operation: "ooUnknown"},
+			{Kind: MismatchOperation, Operation: "ooLost"},
+		},
+	}, verification)
+	assert.False(t, verification.OK())
+
+	_, err = Verify(VerifyInput{Store: s, Tzkt: &test.TzktMock{TransactionsErr: true}, Config: cfg, Wallet: wallet, Cycle: 270})
+	test.CheckErr(t, true, "failed to verify cycle 270", err)
+
+	_, err = Verify(VerifyInput{Store: s, Tzkt: &test.TzktMock{CycleErr: true}, Config: cfg, Wallet: wallet, Cycle: 270})
+	test.CheckErr(t, true, "failed to verify cycle 270: failed to get levels of cycle 270", err)
+}
@@ -0,0 +1,70 @@
+package payout
+
+import (
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// checksContracts returns true if the scripts of contract delegators are inspected before they are paid
+func (p *Payout) checksContracts() bool {
+	return p.config.Baker.CheckContracts || p.config.Baker.ContractFallbackAddress != ""
+}
+
+/*
+refusesTransfers inspects the script of a contract delegator and returns true if it can not take a plain transfer,
+as with the vesting contracts of the fundraiser: a transfer to them fails, or burns storage for nothing. Implicit
+accounts and liquidity contracts are never inspected.
+*/
+func (p *Payout) refusesTransfers(address string) (bool, error) {
+	if !p.checksContracts() || !strings.HasPrefix(address, "KT1") || p.isDexterContract(address) {
+		return false, nil
+	}
+
+	script, err := p.tzkt.GetContractScript(address)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to inspect contract '%s'", address)
+	}
+
+	return !acceptsTransfers(script.Parameter), nil
+}
+
+// acceptsTransfers returns true if a contract of the parameter type passed takes a transfer without parameters,
+// which calls its default entrypoint: either the parameter itself or the branch annotated %default
+func acceptsTransfers(parameter tzkt.Micheline) bool {
+	if entrypoint, ok := defaultEntrypoint(parameter); ok {
+		return entrypoint.Prim == "unit"
+	}
+
+	return parameter.Prim == "unit"
+}
+
+func defaultEntrypoint(parameter tzkt.Micheline) (tzkt.Micheline, bool) {
+	for _, annot := range parameter.Annots {
+		if annot == "%default" {
+			return parameter, true
+		}
+	}
+
+	if parameter.Prim != "or" {
+		return tzkt.Micheline{}, false
+	}
+
+	for _, arg := range parameter.Args {
+		if entrypoint, ok := defaultEntrypoint(arg); ok {
+			return entrypoint, true
+		}
+	}
+
+	return tzkt.Micheline{}, false
+}
+
+// destination returns the address the rewards of a delegation are sent to
+func (p *Payout) destination(delegator tzkt.Delegator) string {
+	if delegator.PayoutAddress != "" {
+		return delegator.PayoutAddress
+	}
+
+	return p.config.Baker.PayoutAddress(delegator.Address)
+}
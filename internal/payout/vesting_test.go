@@ -0,0 +1,126 @@
+package payout
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type scriptTzktMock struct {
+	tzkt.IFace
+	scripts map[string]tzkt.Script
+}
+
+func (s *scriptTzktMock) GetContractScript(address string) (tzkt.Script, error) {
+	script, ok := s.scripts[address]
+	if !ok {
+		return tzkt.Script{}, errors.New("failed to get script")
+	}
+
+	return script, nil
+}
+
+var (
+	managerParameter = tzkt.Micheline{Prim: "or", Args: []tzkt.Micheline{
+		{Prim: "lambda", Annots: []string{"%do"}, Args: []tzkt.Micheline{{Prim: "unit"}, {Prim: "list", Args: []tzkt.Micheline{{Prim: "operation"}}}}},
+		{Prim: "unit", Annots: []string{"%default"}},
+	}}
+	vestingParameter = tzkt.Micheline{Prim: "pair", Args: []tzkt.Micheline{
+		{Prim: "option", Annots: []string{"%action"}, Args: []tzkt.Micheline{{Prim: "key_hash"}}},
+		{Prim: "signature"},
+	}}
+)
+
+func Test_acceptsTransfers(t *testing.T) {
+	cases := []struct {
+		name      string
+		parameter tzkt.Micheline
+		want      bool
+	}{
+		{"accepts unit parameter", tzkt.Micheline{Prim: "unit"}, true},
+		{"accepts unit default entrypoint", managerParameter, true},
+		{"refuses vesting contract", vestingParameter, false},
+		{"refuses default entrypoint taking a parameter", tzkt.Micheline{Prim: "or", Args: []tzkt.Micheline{
+			{Prim: "nat", Annots: []string{"%default"}},
+			{Prim: "unit", Annots: []string{"%other"}},
+		}}, false},
+		{"refuses root without default entrypoint", tzkt.Micheline{Prim: "or", Args: []tzkt.Micheline{
+			{Prim: "unit", Annots: []string{"%deposit"}},
+			{Prim: "nat", Annots: []string{"%withdraw"}},
+		}}, false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, acceptsTransfers(tt.parameter))
+		})
+	}
+}
+
+func Test_constructDelegation_Contracts(t *testing.T) {
+	cases := []struct {
+		name        string
+		address     string
+		fallback    string
+		want        tzkt.Delegator
+		errContains string
+	}{
+		{
+			"holds rewards of vesting contract",
+			"KT1vesting",
+			"",
+			tzkt.Delegator{Address: "KT1vesting", Balance: 5000000, Share: 0.005, GrossRewards: 50000, NetRewards: 50000, Accumulated: true, SkipReason: SkipReasonHeld},
+			"",
+		},
+		{
+			"routes rewards of vesting contract to fallback",
+			"KT1vesting",
+			"tz1fallback",
+			tzkt.Delegator{Address: "KT1vesting", Balance: 5000000, Share: 0.005, GrossRewards: 50000, NetRewards: 50000, PayoutAddress: "tz1fallback"},
+			"",
+		},
+		{
+			"pays contract accepting transfers",
+			"KT1manager",
+			"",
+			tzkt.Delegator{Address: "KT1manager", Balance: 5000000, Share: 0.005, GrossRewards: 50000, NetRewards: 50000},
+			"",
+		},
+		{
+			"handles script error",
+			"KT1unknown",
+			"",
+			tzkt.Delegator{},
+			"failed to inspect contract 'KT1unknown'",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tzpay-vesting")
+			assert.Nil(t, err)
+			defer os.RemoveAll(dir)
+
+			payout := newLedgerPayout(t, dir)
+			payout.config.Baker.AccumulateThreshold = 0
+			payout.config.Baker.BakerPaysBurnFees = true
+			payout.config.Baker.CheckContracts = true
+			payout.config.Baker.ContractFallbackAddress = tt.fallback
+			payout.tzkt = &scriptTzktMock{scripts: map[string]tzkt.Script{
+				"KT1vesting": {Parameter: vestingParameter},
+				"KT1manager": {Parameter: managerParameter},
+			}}
+
+			delegator, err := payout.constructDelegation(tzkt.Delegator{Address: tt.address, Balance: 5000000}, 10000000, 1000000000)
+			test.CheckErr(t, tt.errContains != "", tt.errContains, err)
+			if tt.errContains == "" {
+				assert.Equal(t, tt.want, delegator)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package price
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sources of prices
+const (
+	CoinGecko = "coingecko"
+	Coinbase  = "coinbase"
+)
+
+// IFace is an interface to a source of the price of XTZ in a fiat currency
+type IFace interface {
+	Rate(currency string, at time.Time) (float64, error)
+}
+
+// Input is the input for New
+type Input struct {
+	Source string // coingecko (default) or coinbase
+	URL    string // the API of the source, its public API if empty
+}
+
+// New returns the price source named in input, or an error if it is unknown
+func New(input Input) (IFace, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch strings.ToLower(input.Source) {
+	case "", CoinGecko:
+		url := input.URL
+		if url == "" {
+			url = "https://api.coingecko.com"
+		}
+		return &coinGecko{url: strings.TrimSuffix(url, "/"), client: client}, nil
+	case Coinbase:
+		url := input.URL
+		if url == "" {
+			url = "https://api.coinbase.com"
+		}
+		return &coinbase{url: strings.TrimSuffix(url, "/"), client: client}, nil
+	default:
+		return nil, errors.Errorf("unknown price source '%s': expected '%s' or '%s'", input.Source, CoinGecko, Coinbase)
+	}
+}
+
+// coinGecko returns the daily price of XTZ from the CoinGecko API
+type coinGecko struct {
+	url    string
+	client *http.Client
+}
+
+// Rate returns the price of XTZ in currency on the day of at
+func (c *coinGecko) Rate(currency string, at time.Time) (float64, error) {
+	var history struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}
+
+	url := fmt.Sprintf("%s/api/v3/coins/tezos/history?date=%s&localization=false", c.url, at.UTC().Format("02-01-2006"))
+	if err := get(c.client, url, &history); err != nil {
+		return 0, err
+	}
+
+	rate, ok := history.MarketData.CurrentPrice[strings.ToLower(currency)]
+	if !ok {
+		return 0, errors.Errorf("failed to get price of XTZ in %s on %s: no price", currency, at.UTC().Format("2006-01-02"))
+	}
+
+	return rate, nil
+}
+
+// coinbase returns the daily spot price of XTZ from the Coinbase API
+type coinbase struct {
+	url    string
+	client *http.Client
+}
+
+// Rate returns the price of XTZ in currency on the day of at
+func (c *coinbase) Rate(currency string, at time.Time) (float64, error) {
+	var spot struct {
+		Data struct {
+			Amount string `json:"amount"`
+		} `json:"data"`
+	}
+
+	url := fmt.Sprintf("%s/v2/prices/XTZ-%s/spot?date=%s", c.url, strings.ToUpper(currency), at.UTC().Format("2006-01-02"))
+	if err := get(c.client, url, &spot); err != nil {
+		return 0, err
+	}
+
+	rate, err := strconv.ParseFloat(spot.Data.Amount, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get price of XTZ in %s on %s", currency, at.UTC().Format("2006-01-02"))
+	}
+
+	return rate, nil
+}
+
+func get(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return errors.Wrap(err, "failed to get price of XTZ")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to get price of XTZ")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to get price of XTZ: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrap(err, "failed to get price of XTZ")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package price
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Rate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/coins/tezos/history":
+			assert.Equal(t, "15-03-2021", r.URL.Query().Get("date"))
+			w.Write([]byte(`{"market_data":{"current_price":{"eur":3.52,"usd":4.2}}}`))
+		case "/v2/prices/XTZ-EUR/spot":
+			assert.Equal(t, "2021-03-15", r.URL.Query().Get("date"))
+			w.Write([]byte(`{"data":{"base":"XTZ","currency":"EUR","amount":"3.51"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`not found`))
+		}
+	}))
+	defer server.Close()
+
+	at := time.Date(2021, 3, 15, 18, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		input    Input
+		currency string
+		err      bool
+		contains string
+		want     float64
+	}{
+		{"is successful with coingecko", Input{URL: server.URL}, "EUR", false, "", 3.52},
+		{"is successful with coinbase", Input{Source: Coinbase, URL: server.URL}, "eur", false, "", 3.51},
+		{"handles currency without price", Input{Source: CoinGecko, URL: server.URL}, "JPY", true, "failed to get price of XTZ in JPY on 2021-03-15", 0},
+		{"handles failure to get price", Input{Source: Coinbase, URL: server.URL}, "JPY", true, "404 Not Found: not found", 0},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := New(tt.input)
+			assert.Nil(t, err)
+
+			rate, err := source.Rate(tt.currency, at)
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.want, rate)
+		})
+	}
+
+	_, err := New(Input{Source: "kraken"})
+	test.CheckErr(t, true, "unknown price source 'kraken'", err)
+}
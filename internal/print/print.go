@@ -17,21 +17,25 @@ import (
 // Table prints a payout in table format
 func Table(cycle int, delegate string, rewards tzkt.RewardsSplit) {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Cylce", "Baker", "Share", "Rewards", "Fees", "Total", "Operations"})
+	table.SetHeader([]string{"Cylce", "Baker", "Share", "Rewards", "Fees", "Donation", "Total", "Operations"})
 	table.Append([]string{
 		strconv.Itoa(cycle),
 		delegate,
 		fmt.Sprintf("%.6f", rewards.BakerShare),
 		fmt.Sprintf("%.6f", float64(rewards.BakerRewards)/float64(gotezos.MUTEZ)),
 		fmt.Sprintf("%.6f", float64(rewards.BakerCollectedFees)/float64(gotezos.MUTEZ)),
-		fmt.Sprintf("%.6f", float64(rewards.BakerRewards+rewards.BakerCollectedFees)/float64(gotezos.MUTEZ)),
+		fmt.Sprintf("%.6f", float64(rewards.Donation)/float64(gotezos.MUTEZ)),
+		fmt.Sprintf("%.6f", float64(rewards.BakerRewards+rewards.BakerCollectedFees-rewards.Donation-rewards.Campaigns.Bonuses())/float64(gotezos.MUTEZ)),
 		groomOperations(rewards.OperationLink...),
 	})
+	if rewards.Memo != "" {
+		table.SetCaption(true, fmt.Sprintf("Memo: %s (sha256 %s)", rewards.Memo, rewards.MemoHash))
+	}
 
 	table.Render()
 
 	table = tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Delegation", "Blacklisted", "Share", "Gross", "Net", "Fee"})
+	table.SetHeader([]string{"Delegation", "Blacklisted", "Accumulated", "Share", "Gross", "Net", "Fee"})
 
 	liquidityProviderTable := tablewriter.NewWriter(os.Stdout)
 	liquidityProviderTable.SetHeader([]string{"Liquidiy Provider", "Contract", "Blacklisted", "Share", "Gross", "Net", "Fee"})
@@ -59,6 +63,7 @@ func Table(cycle int, delegate string, rewards tzkt.RewardsSplit) {
 		table.Append([]string{
 			delegation.Address,
 			fmt.Sprintf("%v", delegation.BlackListed),
+			fmt.Sprintf("%v", delegation.Accumulated),
 			fmt.Sprintf("%.6f", delegation.Share),
 			fmt.Sprintf("%.6f", float64(delegation.GrossRewards)/float64(gotezos.MUTEZ)),
 			fmt.Sprintf("%.6f", float64(delegation.NetRewards)/float64(gotezos.MUTEZ)),
@@ -68,7 +73,7 @@ func Table(cycle int, delegate string, rewards tzkt.RewardsSplit) {
 		fee += float64(delegation.Fee) / float64(gotezos.MUTEZ)
 	}
 
-	table.SetFooter([]string{"", "", "", "TOTAL", fmt.Sprintf("%.6f", net), fmt.Sprintf("%.6f", fee)}) // Add Footer
+	table.SetFooter([]string{"", "", "", "", "TOTAL", fmt.Sprintf("%.6f", net), fmt.Sprintf("%.6f", fee)}) // Add Footer
 	liquidityProviderTable.SetFooter([]string{"", "", "TOTAL", fmt.Sprintf("%.6f", share), fmt.Sprintf("%.6f", gross), fmt.Sprintf("%.6f", liquidityNet), fmt.Sprintf("%.6f", liquidityFee)})
 
 	table.Render()
@@ -76,6 +81,52 @@ func Table(cycle int, delegate string, rewards tzkt.RewardsSplit) {
 	if liquidityProviderTable.NumLines() > 0 {
 		liquidityProviderTable.Render()
 	}
+
+	if len(rewards.BondPool) > 0 {
+		bondPoolTable := tablewriter.NewWriter(os.Stdout)
+		bondPoolTable.SetHeader([]string{"Bond Pool Member", "Weight", "Share", "Rewards"})
+
+		var bondPoolRewards float64
+		for _, member := range rewards.BondPool {
+			bondPoolTable.Append([]string{
+				member.Address,
+				strconv.FormatFloat(member.Weight, 'f', -1, 64),
+				fmt.Sprintf("%.6f", member.Share),
+				fmt.Sprintf("%.6f", float64(member.Rewards)/float64(gotezos.MUTEZ)),
+			})
+			bondPoolRewards += float64(member.Rewards) / float64(gotezos.MUTEZ)
+		}
+
+		bondPoolTable.SetFooter([]string{"", "", "TOTAL", fmt.Sprintf("%.6f", bondPoolRewards)})
+		bondPoolTable.Render()
+	}
+
+	if len(rewards.Campaigns) > 0 {
+		campaignTable := tablewriter.NewWriter(os.Stdout)
+		campaignTable.SetHeader([]string{"Campaign", "Delegators", "Rebates", "Bonuses", "Cost"})
+
+		var cost float64
+		for _, campaign := range rewards.Campaigns {
+			campaignTable.Append([]string{
+				campaign.Spec,
+				strconv.Itoa(campaign.Delegators),
+				fmt.Sprintf("%.6f", float64(campaign.Rebates)/float64(gotezos.MUTEZ)),
+				fmt.Sprintf("%.6f", float64(campaign.Bonuses)/float64(gotezos.MUTEZ)),
+				fmt.Sprintf("%.6f", float64(campaign.Rebates+campaign.Bonuses)/float64(gotezos.MUTEZ)),
+			})
+			cost += float64(campaign.Rebates+campaign.Bonuses) / float64(gotezos.MUTEZ)
+		}
+
+		campaignTable.SetFooter([]string{"", "", "", "TOTAL", fmt.Sprintf("%.6f", cost)})
+		campaignTable.Render()
+	}
+
+	if rewards.Sweep > 0 {
+		sweepTable := tablewriter.NewWriter(os.Stdout)
+		sweepTable.SetHeader([]string{"Sweep", "Amount"})
+		sweepTable.Append([]string{rewards.SweepAddress, fmt.Sprintf("%.6f", float64(rewards.Sweep)/float64(gotezos.MUTEZ))})
+		sweepTable.Render()
+	}
 }
 
 // JSON prints a payout to json
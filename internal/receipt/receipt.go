@@ -0,0 +1,109 @@
+/*
+Package receipt signs and verifies the receipts of the payments a baker makes to its delegators.
+
+A signed receipt carries the ed25519 signature, by the baker's own key, of the blake2b hash of its payload, like an
+approval token. It is the signature 'octez-client sign bytes 0x<payload> for <baker>' prints, so that a delegator may
+check it with 'octez-client check that bytes 0x<payload> were signed by <baker> to produce <signature>' as well as
+with tzpay.
+*/
+package receipt
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// edpkPrefix, edsigPrefix and tz1Prefix are the base58 prefixes of edpk..., edsig... and tz1...
+	edpkPrefix  = []byte{13, 15, 37, 217}
+	edsigPrefix = []byte{9, 245, 205, 134, 18}
+	tz1Prefix   = []byte{6, 161, 159}
+)
+
+// Receipt is a payment a baker made to a delegator for a cycle, and the operations that paid it
+type Receipt struct {
+	Baker      string   `json:"baker"`
+	Delegator  string   `json:"delegator"`
+	Cycle      int      `json:"cycle"`
+	Amount     int      `json:"amount"`
+	Operations []string `json:"operations"`
+}
+
+// Signed is a receipt signed by the baker, with the payload signed and the public key of the baker
+type Signed struct {
+	Receipt
+	Payload   string `json:"payload"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// Payload returns the hex encoded bytes the baker signs for the receipt
+func Payload(r Receipt) string {
+	digest := blake2b.Sum256([]byte(fmt.Sprintf("tzpay receipt:%s:%s:%d:%d:%s", r.Baker, r.Delegator, r.Cycle, r.Amount, strings.Join(r.Operations, ","))))
+	return hex.EncodeToString(digest[:])
+}
+
+// Sign returns the receipt signed with the ed25519 key of the baker
+func Sign(key ed25519.PrivateKey, r Receipt) Signed {
+	payload := Payload(r)
+	message, _ := hex.DecodeString(payload)
+	digest := blake2b.Sum256(message)
+
+	return Signed{
+		Receipt:   r,
+		Payload:   payload,
+		PublicKey: base58check.Encode(edpkPrefix, key.Public().(ed25519.PublicKey)),
+		Signature: base58check.Encode(edsigPrefix, ed25519.Sign(key, digest[:])),
+	}
+}
+
+/*
+Verify returns an error unless the signed receipt was signed by the key of its baker. The key is publicKey, or the
+public key carried by the receipt if publicKey is empty, and must be the key of the baker's address either way, so
+that a receipt can not be signed by anyone but the baker.
+*/
+func Verify(s Signed, publicKey string) error {
+	if publicKey == "" {
+		publicKey = s.PublicKey
+	}
+
+	key, err := base58check.Decode(edpkPrefix, publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.Errorf("invalid public key '%s': expected an edpk public key", publicKey)
+	}
+
+	if address := address(key); address != s.Baker {
+		return errors.Errorf("public key '%s' is the key of '%s', not of baker '%s'", publicKey, address, s.Baker)
+	}
+
+	if payload := Payload(s.Receipt); payload != s.Payload {
+		return errors.Errorf("receipt does not match its payload '%s'", s.Payload)
+	}
+
+	signature, err := base58check.Decode(edsigPrefix, s.Signature)
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return errors.Errorf("invalid signature '%s': expected an edsig signature", s.Signature)
+	}
+
+	message, _ := hex.DecodeString(s.Payload)
+	digest := blake2b.Sum256(message)
+	if !ed25519.Verify(key, digest[:], signature) {
+		return errors.New("receipt is not signed by the baker")
+	}
+
+	return nil
+}
+
+// address returns the tz1 address of an ed25519 public key
+func address(key ed25519.PublicKey) string {
+	hash, _ := blake2b.New(20, nil)
+	hash.Write(key)
+
+	return base58check.Encode(tz1Prefix, hash.Sum(nil))
+}
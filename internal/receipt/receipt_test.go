@@ -0,0 +1,64 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Receipt(t *testing.T) {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Kind:     keys.Ed25519,
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+	})
+	assert.Nil(t, err)
+	other := "edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP"
+
+	r := Receipt{
+		Baker:      key.PubKey.GetPublicKeyHash(),
+		Delegator:  "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+		Cycle:      300,
+		Amount:     1250000,
+		Operations: []string{"ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M"},
+	}
+	signed := Sign(ed25519.PrivateKey(key.GetBytes()), r)
+	assert.Equal(t, key.PubKey.GetPublicKey(), signed.PublicKey)
+	assert.Equal(t, Payload(r), signed.Payload)
+	assert.Len(t, signed.Payload, 64)
+	assert.Equal(t, "edsig", signed.Signature[:5])
+
+	tampered := signed
+	tampered.Amount = 2500000
+
+	otherBaker := signed
+	otherBaker.Baker = "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+
+	forged := signed
+	forged.Signature = Sign(ed25519.NewKeyFromSeed(make([]byte, 32)), r).Signature
+
+	cases := []struct {
+		name      string
+		signed    Signed
+		publicKey string
+		err       bool
+		contains  string
+	}{
+		{"verifies a receipt with its public key", signed, "", false, ""},
+		{"verifies a receipt with the baker's public key", signed, key.PubKey.GetPublicKey(), false, ""},
+		{"refuses the key of someone else", signed, other, true, "not of baker"},
+		{"refuses an invalid key", signed, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", true, "expected an edpk public key"},
+		{"refuses a receipt changed", tampered, "", true, "receipt does not match its payload"},
+		{"refuses a receipt of another baker", otherBaker, "", true, "not of baker"},
+		{"refuses a signature of another key", forged, "", true, "receipt is not signed by the baker"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			test.CheckErr(t, tt.err, tt.contains, Verify(tt.signed, tt.publicKey))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the values of redacted fields
+const Redacted = "[REDACTED]"
+
+var addressRegex = regexp.MustCompile(`\b(tz1|tz2|tz3|KT1)[1-9A-HJ-NP-Za-km-z]{33}\b`)
+
+/*
+Redactor removes delegator identifying data from logs and notifications. Configured log fields are replaced
+entirely, and tezos addresses found in messages or field values are masked, except for the addresses tzpay
+itself operates (e.g. the bakers).
+*/
+type Redactor struct {
+	fields    map[string]bool
+	addresses bool
+	keep      map[string]bool
+}
+
+// New returns a Redactor for the log fields passed. Addresses are masked if addresses is true, except for those in keep.
+func New(fields []string, addresses bool, keep ...string) *Redactor {
+	r := &Redactor{
+		fields:    map[string]bool{},
+		addresses: addresses,
+		keep:      map[string]bool{},
+	}
+
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			r.fields[field] = true
+		}
+	}
+
+	for _, address := range keep {
+		r.keep[address] = true
+	}
+
+	return r
+}
+
+// Enabled reports whether the Redactor redacts anything
+func (r *Redactor) Enabled() bool {
+	return r != nil && (r.addresses || len(r.fields) > 0)
+}
+
+// String masks the addresses found in s
+func (r *Redactor) String(s string) string {
+	if r == nil || !r.addresses {
+		return s
+	}
+
+	return addressRegex.ReplaceAllStringFunc(s, func(address string) string {
+		if r.keep[address] {
+			return address
+		}
+		return address[:3] + "…" + Redacted
+	})
+}
+
+// Levels implements logrus.Hook
+func (r *Redactor) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook, redacting the entry before it is written
+func (r *Redactor) Fire(entry *logrus.Entry) error {
+	entry.Message = r.String(entry.Message)
+	for key, value := range entry.Data {
+		if r.fields[key] {
+			entry.Data[key] = Redacted
+			continue
+		}
+
+		if s, ok := value.(string); ok {
+			entry.Data[key] = r.String(s)
+		}
+	}
+
+	return nil
+}
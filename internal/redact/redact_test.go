@@ -0,0 +1,60 @@
+package redact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_String(t *testing.T) {
+	cases := []struct {
+		name      string
+		addresses bool
+		input     string
+		want      string
+	}{
+		{
+			"masks addresses",
+			true,
+			"paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc and KT1GQcLae1ve1ZEPNfD9z1dyv5ev9ki39SNW",
+			"paid tz1…[REDACTED] and KT1…[REDACTED]",
+		},
+		{
+			"keeps baker addresses",
+			true,
+			"baker tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+			"baker tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV paid tz1…[REDACTED]",
+		},
+		{
+			"does nothing when disabled",
+			false,
+			"paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+			"paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(nil, tt.addresses, "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV")
+			assert.Equal(t, tt.want, r.String(tt.input))
+		})
+	}
+}
+
+func Test_Fire(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{DisableTimestamp: true})
+	logger.AddHook(New([]string{"amount"}, true))
+
+	logger.WithFields(logrus.Fields{
+		"amount":    1000,
+		"delegator": "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc",
+		"cycle":     270,
+	}).Info("Paid tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc.")
+
+	assert.JSONEq(t, `{"amount":"[REDACTED]","delegator":"tz1…[REDACTED]","cycle":270,"level":"info","msg":"Paid tz1…[REDACTED]."}`, buf.String())
+}
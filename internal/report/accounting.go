@@ -0,0 +1,231 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/pkg/errors"
+)
+
+// Accounting formats
+const (
+	FormatQIF    = "qif"
+	FormatOFX    = "ofx"
+	FormatLedger = "ledger"
+)
+
+// Accounts the transactions of the payouts are booked to
+const (
+	AccountDistributions = "Expenses:Tezos:Distributions"
+	AccountFees          = "Income:Tezos:Fees"
+)
+
+// AccountingInput is the input for Accounting
+type AccountingInput struct {
+	Baker   string
+	Records []payout.Record
+	Year    int    // only the payouts made in Year are exported, every payout if 0
+	Account string // the asset account holding the baker's XTZ, Assets:Tezos:<baker> if empty
+	Now     time.Time
+}
+
+// Transaction is a movement of the baker's XTZ: the amount distributed by a payout, or the fees it earned
+type Transaction struct {
+	ID         string
+	Time       time.Time
+	Payee      string
+	Amount     int64 // in mutez, negative for an amount distributed
+	Category   string
+	Operations []string
+}
+
+// Transactions returns the transactions of the payouts recorded, oldest first. Each payout distributes its amount and earns its fees.
+func Transactions(records []payout.Record, year int) []Transaction {
+	transactions := []Transaction{}
+	for _, record := range records {
+		if year != 0 && record.Time.UTC().Year() != year {
+			continue
+		}
+
+		id := fmt.Sprintf("%d-%d", record.Cycle, record.Time.Unix())
+		if record.Amount != 0 {
+			transactions = append(transactions, Transaction{
+				ID:         id + "-payout",
+				Time:       record.Time,
+				Payee:      fmt.Sprintf("Payout of cycle %d", record.Cycle),
+				Amount:     -record.Amount,
+				Category:   AccountDistributions,
+				Operations: record.Operations,
+			})
+		}
+		if record.Fees != 0 {
+			transactions = append(transactions, Transaction{
+				ID:         id + "-fees",
+				Time:       record.Time,
+				Payee:      fmt.Sprintf("Fees of cycle %d", record.Cycle),
+				Amount:     record.Fees,
+				Category:   AccountFees,
+				Operations: record.Operations,
+			})
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Time.Before(transactions[j].Time) })
+
+	return transactions
+}
+
+// Accounting exports the payouts recorded in format, qif, ofx or ledger, for accounting software to import
+func Accounting(format string, input AccountingInput) ([]byte, error) {
+	if input.Account == "" {
+		input.Account = "Assets:Tezos:" + input.Baker
+	}
+
+	transactions := Transactions(input.Records, input.Year)
+	switch strings.ToLower(format) {
+	case FormatQIF:
+		return qif(transactions), nil
+	case FormatOFX:
+		return ofx(input, transactions)
+	case FormatLedger:
+		return ledger(input.Account, transactions), nil
+	default:
+		return nil, errors.Errorf("failed to export payouts: unknown format '%s', expected qif, ofx or ledger", format)
+	}
+}
+
+// qif exports transactions as a bank account in the Quicken Interchange Format
+func qif(transactions []Transaction) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("!Type:Bank\n")
+	for _, transaction := range transactions {
+		fmt.Fprintf(&buf, "D%s\n", transaction.Time.UTC().Format("01/02/2006"))
+		fmt.Fprintf(&buf, "T%s\n", xtz(transaction.Amount))
+		fmt.Fprintf(&buf, "P%s\n", transaction.Payee)
+		if len(transaction.Operations) > 0 {
+			fmt.Fprintf(&buf, "M%s\n", strings.Join(transaction.Operations, " "))
+		}
+		fmt.Fprintf(&buf, "L%s\n", transaction.Category)
+		buf.WriteString("^\n")
+	}
+
+	return buf.Bytes()
+}
+
+// ledger exports transactions as a ledger-cli journal, booked against account
+func ledger(account string, transactions []Transaction) []byte {
+	var buf bytes.Buffer
+	for i, transaction := range transactions {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+
+		fmt.Fprintf(&buf, "%s * %s\n", transaction.Time.UTC().Format("2006/01/02"), transaction.Payee)
+		if len(transaction.Operations) > 0 {
+			fmt.Fprintf(&buf, "    ; operations: %s\n", strings.Join(transaction.Operations, ", "))
+		}
+		fmt.Fprintf(&buf, "    %-40s  %s XTZ\n", account, xtz(transaction.Amount))
+		fmt.Fprintf(&buf, "    %s\n", transaction.Category)
+	}
+
+	return buf.Bytes()
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	ID     string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	Signon  struct {
+		Status   ofxStatus `xml:"SONRS>STATUS"`
+		Server   string    `xml:"SONRS>DTSERVER"`
+		Language string    `xml:"SONRS>LANGUAGE"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Statement struct {
+		UID       string           `xml:"TRNUID"`
+		Status    ofxStatus        `xml:"STATUS"`
+		Currency  string           `xml:"STMTRS>CURDEF"`
+		BankID    string           `xml:"STMTRS>BANKACCTFROM>BANKID"`
+		AccountID string           `xml:"STMTRS>BANKACCTFROM>ACCTID"`
+		Type      string           `xml:"STMTRS>BANKACCTFROM>ACCTTYPE"`
+		Start     string           `xml:"STMTRS>BANKTRANLIST>DTSTART"`
+		End       string           `xml:"STMTRS>BANKTRANLIST>DTEND"`
+		List      []ofxTransaction `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+		Balance   string           `xml:"STMTRS>LEDGERBAL>BALAMT"`
+		AsOf      string           `xml:"STMTRS>LEDGERBAL>DTASOF"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+const ofxTime = "20060102150405"
+
+/*
+ofx exports transactions as a bank statement of the baker in OFX 2.2. The balance of the statement is the net of the
+transactions exported, not the balance of the baker, which the payouts recorded do not tell.
+*/
+func ofx(input AccountingInput, transactions []Transaction) ([]byte, error) {
+	var doc ofxDocument
+	doc.Signon.Status = ofxStatus{Severity: "INFO"}
+	doc.Signon.Server = input.Now.UTC().Format(ofxTime)
+	doc.Signon.Language = "ENG"
+	doc.Statement.UID = "1"
+	doc.Statement.Status = ofxStatus{Severity: "INFO"}
+	doc.Statement.Currency = "XTZ"
+	doc.Statement.BankID = "TEZOS"
+	doc.Statement.AccountID = input.Baker
+	doc.Statement.Type = "CHECKING"
+	doc.Statement.Start = input.Now.UTC().Format(ofxTime)
+	doc.Statement.End = input.Now.UTC().Format(ofxTime)
+	doc.Statement.AsOf = input.Now.UTC().Format(ofxTime)
+	doc.Statement.List = []ofxTransaction{}
+
+	var balance int64
+	for i, transaction := range transactions {
+		if i == 0 {
+			doc.Statement.Start = transaction.Time.UTC().Format(ofxTime)
+		}
+		doc.Statement.End = transaction.Time.UTC().Format(ofxTime)
+
+		trnType := "CREDIT"
+		if transaction.Amount < 0 {
+			trnType = "DEBIT"
+		}
+		doc.Statement.List = append(doc.Statement.List, ofxTransaction{
+			Type:   trnType,
+			Posted: transaction.Time.UTC().Format(ofxTime),
+			Amount: xtz(transaction.Amount),
+			ID:     transaction.ID,
+			Name:   transaction.Payee,
+			Memo:   strings.Join(transaction.Operations, " "),
+		})
+		balance += transaction.Amount
+	}
+	doc.Statement.Balance = xtz(balance)
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export payouts")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	buf.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	buf.Write(body)
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
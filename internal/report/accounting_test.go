@@ -0,0 +1,82 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Accounting(t *testing.T) {
+	records := []payout.Record{
+		{Cycle: 280, Time: time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC), Fees: 1000000, Amount: 9000000, Operations: []string{"oo0"}},
+		{Cycle: 281, Time: time.Date(2021, 1, 2, 10, 30, 0, 0, time.UTC), Fees: 62500000, Amount: 1187500000, Operations: []string{"oo1", "oo2"}},
+		{Cycle: 282, Time: time.Date(2021, 1, 5, 10, 30, 0, 0, time.UTC), Amount: 4500000},
+	}
+	input := AccountingInput{
+		Baker:   "tz1baker",
+		Records: records,
+		Year:    2021,
+		Now:     time.Date(2021, 1, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	cases := []struct {
+		name     string
+		format   string
+		err      bool
+		contains string
+		want     string
+	}{
+		{
+			name:   "exports qif",
+			format: "qif",
+			want: "!Type:Bank\n" +
+				"D01/02/2021\nT-1187.500000\nPPayout of cycle 281\nMoo1 oo2\nLExpenses:Tezos:Distributions\n^\n" +
+				"D01/02/2021\nT62.500000\nPFees of cycle 281\nMoo1 oo2\nLIncome:Tezos:Fees\n^\n" +
+				"D01/05/2021\nT-4.500000\nPPayout of cycle 282\nLExpenses:Tezos:Distributions\n^\n",
+		},
+		{
+			name:   "exports ledger",
+			format: "LEDGER",
+			want: "2021/01/02 * Payout of cycle 281\n" +
+				"    ; operations: oo1, oo2\n" +
+				"    Assets:Tezos:tz1baker                     -1187.500000 XTZ\n" +
+				"    Expenses:Tezos:Distributions\n" +
+				"\n" +
+				"2021/01/02 * Fees of cycle 281\n" +
+				"    ; operations: oo1, oo2\n" +
+				"    Assets:Tezos:tz1baker                     62.500000 XTZ\n" +
+				"    Income:Tezos:Fees\n" +
+				"\n" +
+				"2021/01/05 * Payout of cycle 282\n" +
+				"    Assets:Tezos:tz1baker                     -4.500000 XTZ\n" +
+				"    Expenses:Tezos:Distributions\n",
+		},
+		{name: "handles an unknown format", format: "csv", err: true, contains: "unknown format 'csv'"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Accounting(tt.format, input)
+			test.CheckErr(t, tt.err, tt.contains, err)
+			if !tt.err {
+				assert.Equal(t, tt.want, string(out))
+			}
+		})
+	}
+
+	out, err := Accounting("ofx", input)
+	assert.Nil(t, err)
+	ofx := string(out)
+	assert.Contains(t, ofx, `<?OFX OFXHEADER="200" VERSION="220"`)
+	assert.Contains(t, ofx, "<ACCTID>tz1baker</ACCTID>")
+	assert.Contains(t, ofx, "<DTSTART>20210102103000</DTSTART>")
+	assert.Contains(t, ofx, "<DTEND>20210105103000</DTEND>")
+	assert.Contains(t, ofx, "<TRNTYPE>DEBIT</TRNTYPE>\n            <DTPOSTED>20210102103000</DTPOSTED>\n            <TRNAMT>-1187.500000</TRNAMT>\n            <FITID>281-1609583400-payout</FITID>")
+	assert.Contains(t, ofx, "<TRNTYPE>CREDIT</TRNTYPE>")
+	assert.Contains(t, ofx, "<BALAMT>-1129.500000</BALAMT>")
+
+	assert.Len(t, Transactions(records, 0), 5)
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+)
+
+// EfficiencyInput is the input for Efficiency
+type EfficiencyInput struct {
+	Baker  string
+	Cycles []tzkt.RewardsSplit
+}
+
+// EfficiencyEntry is what a baker earned in a cycle against what its rights would have earned it
+type EfficiencyEntry struct {
+	Cycle              int     `json:"cycle"`
+	Ideal              int     `json:"ideal"`
+	Actual             int     `json:"actual"`
+	Efficiency         float64 `json:"efficiency"`
+	Blocks             int     `json:"blocks"`
+	MissedBlocks       int     `json:"missed_blocks"`
+	Endorsements       int     `json:"endorsements"`
+	MissedEndorsements int     `json:"missed_endorsements"`
+}
+
+// EfficiencyReport is the efficiency of a baker cycle by cycle and over every cycle reported, in percent
+type EfficiencyReport struct {
+	Baker      string            `json:"baker"`
+	Entries    []EfficiencyEntry `json:"entries"`
+	Ideal      int               `json:"ideal"`
+	Actual     int               `json:"actual"`
+	Efficiency float64           `json:"efficiency"`
+}
+
+/*
+Efficiency returns the efficiency report of cycles. The ideal rewards of a cycle are those of the baking rights of
+priority 0 and of the endorsing rights of the baker, whether it used, missed or could not cover them, and the actual
+rewards those it earned, blocks baked on the rights of others included, so that a cycle may exceed 100%. Fees and the
+rights of a cycle still to come are left out, and cycles without rights are not reported.
+*/
+func Efficiency(input EfficiencyInput) EfficiencyReport {
+	report := EfficiencyReport{
+		Baker:   input.Baker,
+		Entries: []EfficiencyEntry{},
+	}
+
+	for _, cycle := range input.Cycles {
+		ideal := cycle.OwnBlockRewards + cycle.MissedOwnBlockRewards + cycle.UncoveredOwnBlockRewards +
+			cycle.EndorsementRewards + cycle.MissedEndorsementRewards + cycle.UncoveredEndorsementRewards
+		if ideal == 0 {
+			continue
+		}
+
+		entry := EfficiencyEntry{
+			Cycle:              cycle.Cycle,
+			Ideal:              ideal,
+			Actual:             cycle.OwnBlockRewards + cycle.ExtraBlockRewards + cycle.EndorsementRewards,
+			Blocks:             cycle.OwnBlocks + cycle.ExtraBlocks,
+			MissedBlocks:       cycle.MissedOwnBlocks + cycle.UncoveredOwnBlocks,
+			Endorsements:       cycle.Endorsements,
+			MissedEndorsements: cycle.MissedEndorsements + cycle.UncoveredEndorsements,
+		}
+		entry.Efficiency = percent(entry.Actual, entry.Ideal)
+
+		report.Entries = append(report.Entries, entry)
+		report.Ideal += entry.Ideal
+		report.Actual += entry.Actual
+	}
+	report.Efficiency = percent(report.Actual, report.Ideal)
+
+	return report
+}
+
+// percent returns actual as a percentage of ideal, or 0 without ideal
+func percent(actual, ideal int) float64 {
+	if ideal == 0 {
+		return 0
+	}
+
+	return float64(actual) / float64(ideal) * 100
+}
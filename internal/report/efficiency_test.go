@@ -0,0 +1,60 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Efficiency(t *testing.T) {
+	cycles := []tzkt.RewardsSplit{
+		{
+			Cycle:              300,
+			OwnBlocks:          4,
+			OwnBlockRewards:    4000000,
+			EndorsementRewards: 6000000,
+			Endorsements:       48,
+		},
+		{
+			Cycle:                    301,
+			OwnBlocks:                3,
+			OwnBlockRewards:          3000000,
+			MissedOwnBlocks:          1,
+			MissedOwnBlockRewards:    1000000,
+			ExtraBlocks:              1,
+			ExtraBlockRewards:        500000,
+			EndorsementRewards:       5000000,
+			Endorsements:             40,
+			MissedEndorsements:       8,
+			MissedEndorsementRewards: 1000000,
+			OwnBlockFees:             40000,
+		},
+		{Cycle: 302},
+		{
+			Cycle:                       303,
+			OwnBlocks:                   2,
+			OwnBlockRewards:             2000000,
+			ExtraBlocks:                 1,
+			ExtraBlockRewards:           1000000,
+			UncoveredEndorsements:       2,
+			UncoveredEndorsementRewards: 500000,
+			EndorsementRewards:          1500000,
+			Endorsements:                6,
+			FutureBlockRewards:          8000000,
+		},
+	}
+
+	report := Efficiency(EfficiencyInput{Baker: "tz1baker", Cycles: cycles})
+	assert.Equal(t, []EfficiencyEntry{
+		{Cycle: 300, Ideal: 10000000, Actual: 10000000, Efficiency: 100, Blocks: 4, Endorsements: 48},
+		{Cycle: 301, Ideal: 10000000, Actual: 8500000, Efficiency: 85, Blocks: 4, MissedBlocks: 1, Endorsements: 40, MissedEndorsements: 8},
+		{Cycle: 303, Ideal: 4000000, Actual: 4500000, Efficiency: 112.5, Blocks: 3, Endorsements: 6, MissedEndorsements: 2},
+	}, report.Entries)
+	assert.Equal(t, 24000000, report.Ideal)
+	assert.Equal(t, 23000000, report.Actual)
+	assert.InDelta(t, 95.8333, report.Efficiency, 0.0001)
+
+	report = Efficiency(EfficiencyInput{Baker: "tz1baker"})
+	assert.Equal(t, EfficiencyReport{Baker: "tz1baker", Entries: []EfficiencyEntry{}}, report)
+}
@@ -0,0 +1,255 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// Size of the charts of the HTML report, in pixels
+const (
+	chartWidth  = 720
+	chartHeight = 180
+)
+
+// HTMLInput is the input for HTML
+type HTMLInput struct {
+	Baker   string
+	Cycles  []tzkt.RewardsSplit // the synced history of the baker
+	Records []payout.Record
+	Now     time.Time
+}
+
+// HTMLCycle is what the HTML report shows of a cycle: its rewards from the history synced and its payouts from the records
+type HTMLCycle struct {
+	Cycle            int
+	StakingBalance   int64
+	DelegatedBalance int64
+	Delegators       int
+	Blocks           int
+	Endorsements     int
+	Rewards          int64
+	BlockFees        int64
+	Fees             int64
+	Distributed      int64
+	Paid             time.Time
+	Operations       []string
+}
+
+// htmlPage is what the templates of the HTML report are executed with
+type htmlPage struct {
+	Baker   string
+	Cycles  []HTMLCycle
+	Cycle   HTMLCycle
+	Charts  []template.HTML
+	Updated time.Time
+}
+
+/*
+HTML renders the static HTML report of a baker, to publish on its website: index.html, with charts of the staking
+balance, rewards and fees of every cycle over time and a table linking to the page of each cycle, and
+cycle-<cycle>.html per cycle. Pages are returned by file name.
+*/
+func HTML(input HTMLInput) (map[string][]byte, error) {
+	cycles := htmlCycles(input.Cycles, input.Records)
+	page := htmlPage{Baker: input.Baker, Cycles: cycles, Updated: input.Now.UTC()}
+
+	staking, rewards, fees := make([]int64, len(cycles)), make([]int64, len(cycles)), make([]int64, len(cycles))
+	for i, cycle := range cycles {
+		staking[i], rewards[i], fees[i] = cycle.StakingBalance, cycle.Rewards, cycle.Fees
+	}
+	page.Charts = []template.HTML{
+		chart("Staking balance (XTZ)", cycles, staking),
+		chart("Rewards (XTZ)", cycles, rewards),
+		chart("Fees earned (XTZ)", cycles, fees),
+	}
+
+	pages := map[string][]byte{}
+	index, err := render(indexTemplate, page)
+	if err != nil {
+		return nil, err
+	}
+	pages["index.html"] = index
+
+	for _, cycle := range cycles {
+		cyclePage := htmlPage{Baker: input.Baker, Cycle: cycle, Updated: page.Updated}
+		content, err := render(cycleTemplate, cyclePage)
+		if err != nil {
+			return nil, err
+		}
+		pages[cyclePath(cycle.Cycle)] = content
+	}
+
+	return pages, nil
+}
+
+// WriteHTML writes pages to dir, replacing the files at once so a reader never sees them half written
+func WriteHTML(dir string, pages map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to write HTML report")
+	}
+
+	for name, content := range pages {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path+".tmp", content, 0644); err != nil {
+			return errors.Wrap(err, "failed to write HTML report")
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return errors.Wrap(err, "failed to write HTML report")
+		}
+	}
+
+	return nil
+}
+
+// htmlCycles merges the history and the payouts recorded of every cycle, oldest cycle first
+func htmlCycles(history []tzkt.RewardsSplit, records []payout.Record) []HTMLCycle {
+	byCycle := map[int]*HTMLCycle{}
+	get := func(cycle int) *HTMLCycle {
+		if _, ok := byCycle[cycle]; !ok {
+			byCycle[cycle] = &HTMLCycle{Cycle: cycle}
+		}
+		return byCycle[cycle]
+	}
+
+	for _, rewardsSplit := range history {
+		cycle := get(rewardsSplit.Cycle)
+		cycle.StakingBalance = int64(rewardsSplit.StakingBalance)
+		cycle.DelegatedBalance = int64(rewardsSplit.DelegatedBalance)
+		cycle.Delegators = rewardsSplit.NumDelegators
+		cycle.Blocks = rewardsSplit.OwnBlocks + rewardsSplit.ExtraBlocks
+		cycle.Endorsements = rewardsSplit.Endorsements
+		cycle.Rewards = int64(rewardsSplit.OwnBlockRewards + rewardsSplit.ExtraBlockRewards + rewardsSplit.EndorsementRewards)
+		cycle.BlockFees = int64(rewardsSplit.OwnBlockFees + rewardsSplit.ExtraBlockFees)
+	}
+
+	for _, record := range records {
+		cycle := get(record.Cycle)
+		cycle.Fees += record.Fees
+		cycle.Distributed += record.Amount
+		cycle.Operations = append(cycle.Operations, record.Operations...)
+		if record.Time.After(cycle.Paid) {
+			cycle.Paid = record.Time
+		}
+	}
+
+	cycles := []HTMLCycle{}
+	for _, cycle := range byCycle {
+		cycles = append(cycles, *cycle)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Cycle < cycles[j].Cycle })
+
+	return cycles
+}
+
+// chart renders values, one per cycle, as an SVG bar chart
+func chart(title string, cycles []HTMLCycle, values []int64) template.HTML {
+	var max int64
+	for _, value := range values {
+		if value > max {
+			max = value
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<figure><figcaption>%s</figcaption>`, template.HTMLEscapeString(title))
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, chartWidth, chartHeight, chartWidth, chartHeight)
+	if len(values) > 0 && max > 0 {
+		width := float64(chartWidth) / float64(len(values))
+		for i, value := range values {
+			height := float64(value) / float64(max) * float64(chartHeight)
+			fmt.Fprintf(&sb, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#2c7be5"><title>Cycle %d: %s</title></rect>`,
+				float64(i)*width, float64(chartHeight)-height, width*0.8, height, cycles[i].Cycle, xtz(value))
+		}
+		fmt.Fprintf(&sb, `<text x="4" y="14" font-size="12">%s</text>`, xtz(max))
+	}
+	sb.WriteString(`</svg></figure>`)
+
+	return template.HTML(sb.String())
+}
+
+func cyclePath(cycle int) string {
+	return fmt.Sprintf("cycle-%d.html", cycle)
+}
+
+func xtz(mutez int64) string {
+	return fmt.Sprintf("%.6f", float64(mutez)/float64(gotezos.MUTEZ))
+}
+
+func render(t *template.Template, page htmlPage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, page); err != nil {
+		return nil, errors.Wrap(err, "failed to render HTML report")
+	}
+
+	return buf.Bytes(), nil
+}
+
+var htmlFuncs = template.FuncMap{
+	"xtz":   xtz,
+	"cycle": cyclePath,
+}
+
+var indexTemplate = template.Must(template.New("index").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Rewards of {{.Baker}}</title>
+</head>
+<body>
+<h1>Rewards of {{.Baker}}</h1>
+{{- range .Charts}}
+{{.}}
+{{- end}}
+<table>
+<tr><th>Cycle</th><th>Staking balance (XTZ)</th><th>Delegators</th><th>Rewards (XTZ)</th><th>Fees earned (XTZ)</th><th>Distributed (XTZ)</th></tr>
+{{- range .Cycles}}
+<tr><td><a href="{{cycle .Cycle}}">{{.Cycle}}</a></td><td>{{xtz .StakingBalance}}</td><td>{{.Delegators}}</td><td>{{xtz .Rewards}}</td><td>{{xtz .Fees}}</td><td>{{xtz .Distributed}}</td></tr>
+{{- end}}
+</table>
+<p>Updated {{.Updated.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
+
+var cycleTemplate = template.Must(template.New("cycle").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Rewards of {{.Baker}} for cycle {{.Cycle.Cycle}}</title>
+</head>
+<body>
+<h1>Rewards of {{.Baker}} for cycle {{.Cycle.Cycle}}</h1>
+<p><a href="index.html">Every cycle</a></p>
+<table>
+<tr><th>Staking balance</th><td>{{xtz .Cycle.StakingBalance}} XTZ</td></tr>
+<tr><th>Delegated balance</th><td>{{xtz .Cycle.DelegatedBalance}} XTZ</td></tr>
+<tr><th>Delegators</th><td>{{.Cycle.Delegators}}</td></tr>
+<tr><th>Blocks</th><td>{{.Cycle.Blocks}}</td></tr>
+<tr><th>Endorsements</th><td>{{.Cycle.Endorsements}}</td></tr>
+<tr><th>Rewards</th><td>{{xtz .Cycle.Rewards}} XTZ</td></tr>
+<tr><th>Block fees</th><td>{{xtz .Cycle.BlockFees}} XTZ</td></tr>
+<tr><th>Fees earned</th><td>{{xtz .Cycle.Fees}} XTZ</td></tr>
+<tr><th>Distributed</th><td>{{xtz .Cycle.Distributed}} XTZ</td></tr>
+{{- if not .Cycle.Paid.IsZero}}
+<tr><th>Paid</th><td>{{.Cycle.Paid.UTC.Format "2006-01-02 15:04 MST"}}</td></tr>
+{{- end}}
+{{- range .Cycle.Operations}}
+<tr><th>Operation</th><td><a href="https://tzkt.io/{{.}}">{{.}}</a></td></tr>
+{{- end}}
+</table>
+<p>Updated {{.Updated.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTML(t *testing.T) {
+	now := time.Date(2021, 1, 10, 12, 0, 0, 0, time.UTC)
+	pages, err := HTML(HTMLInput{
+		Baker: "tz1baker",
+		Cycles: []tzkt.RewardsSplit{
+			{Cycle: 300, StakingBalance: 100000000000, NumDelegators: 10, OwnBlocks: 2, OwnBlockRewards: 2000000, EndorsementRewards: 1000000},
+			{Cycle: 301, StakingBalance: 200000000000, NumDelegators: 12, OwnBlocks: 4, OwnBlockRewards: 4000000, EndorsementRewards: 2000000},
+		},
+		Records: []payout.Record{
+			{Cycle: 301, Time: now.Add(-time.Hour), Operations: []string{"oo1"}, Amount: 5000000, Fees: 300000},
+			{Cycle: 301, Time: now, Operations: []string{"oo2"}, Amount: 500000, Fees: 30000},
+			{Cycle: 302, Time: now, Operations: []string{"<script>"}, Amount: 1000000, Fees: 50000},
+		},
+		Now: now,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, pages, 4)
+
+	index := string(pages["index.html"])
+	assert.Contains(t, index, "<title>Rewards of tz1baker</title>")
+	assert.Contains(t, index, `<figcaption>Staking balance (XTZ)</figcaption>`)
+	assert.Contains(t, index, `<title>Cycle 301: 200000.000000</title>`)
+	assert.Contains(t, index, `<td><a href="cycle-301.html">301</a></td><td>200000.000000</td><td>12</td><td>6.000000</td><td>0.330000</td><td>5.500000</td>`)
+	assert.Contains(t, index, "Updated 2021-01-10 12:00 UTC")
+
+	cycle := string(pages["cycle-301.html"])
+	assert.Contains(t, cycle, "<tr><th>Blocks</th><td>4</td></tr>")
+	assert.Contains(t, cycle, "<tr><th>Distributed</th><td>5.500000 XTZ</td></tr>")
+	assert.Contains(t, cycle, `<a href="https://tzkt.io/oo1">oo1</a>`)
+	assert.Contains(t, cycle, `<a href="https://tzkt.io/oo2">oo2</a>`)
+	assert.Contains(t, cycle, "<tr><th>Paid</th><td>2021-01-10 12:00 UTC</td></tr>")
+	assert.NotContains(t, string(pages["cycle-302.html"]), "<script>")
+	assert.Contains(t, string(pages["cycle-300.html"]), "<tr><th>Distributed</th><td>0.000000 XTZ</td></tr>")
+
+	dir, err := ioutil.TempDir("", "tzpay-html")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.Nil(t, WriteHTML(filepath.Join(dir, "site"), pages))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "site", "cycle-301.html"))
+	assert.Nil(t, err)
+	assert.Equal(t, cycle, string(content))
+}
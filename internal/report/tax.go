@@ -0,0 +1,96 @@
+package report
+
+import (
+	"strings"
+	"time"
+
+	gotezos "github.com/goat-systems/go-tezos/v2"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/price"
+	"github.com/pkg/errors"
+)
+
+// TaxInput is the input for Tax
+type TaxInput struct {
+	Baker    string
+	Records  []payout.Record
+	Year     int
+	Currency string
+	Price    price.IFace // the rates not recorded with a payout are fetched from it, optional
+}
+
+// TaxEntry is a payout of the year, the fees earned and the amount distributed valued at the rate of XTZ when it was made
+type TaxEntry struct {
+	Cycle      int       `json:"cycle"`
+	Time       time.Time `json:"time"`
+	Fees       int64     `json:"fees"`
+	Amount     int64     `json:"amount"`
+	Rate       float64   `json:"rate"`
+	FeesFiat   float64   `json:"fees_fiat"`
+	AmountFiat float64   `json:"amount_fiat"`
+}
+
+// TaxReport is the yearly report of the fees a baker earned and the amounts it distributed, in mutez and in Currency
+type TaxReport struct {
+	Baker      string     `json:"baker"`
+	Year       int        `json:"year"`
+	Currency   string     `json:"currency"`
+	Entries    []TaxEntry `json:"entries"`
+	Fees       int64      `json:"fees"`
+	Amount     int64      `json:"amount"`
+	FeesFiat   float64    `json:"fees_fiat"`
+	AmountFiat float64    `json:"amount_fiat"`
+}
+
+/*
+Tax returns the tax report of the payouts recorded in Year. A payout is valued at the rate recorded with it if it
+was recorded in Currency, or else at the rate of its day from the price source.
+*/
+func Tax(input TaxInput) (TaxReport, error) {
+	report := TaxReport{
+		Baker:    input.Baker,
+		Year:     input.Year,
+		Currency: strings.ToUpper(input.Currency),
+		Entries:  []TaxEntry{},
+	}
+
+	for _, record := range input.Records {
+		if record.Time.UTC().Year() != input.Year {
+			continue
+		}
+
+		rate := record.Rate
+		if !strings.EqualFold(record.Currency, input.Currency) || rate == 0 {
+			if input.Price == nil {
+				return report, errors.Errorf("failed to value payout for cycle %d: no price of XTZ in %s recorded", record.Cycle, report.Currency)
+			}
+
+			var err error
+			if rate, err = input.Price.Rate(input.Currency, record.Time); err != nil {
+				return report, errors.Wrapf(err, "failed to value payout for cycle %d", record.Cycle)
+			}
+		}
+
+		entry := TaxEntry{
+			Cycle:      record.Cycle,
+			Time:       record.Time,
+			Fees:       record.Fees,
+			Amount:     record.Amount,
+			Rate:       rate,
+			FeesFiat:   fiat(record.Fees, rate),
+			AmountFiat: fiat(record.Amount, rate),
+		}
+		report.Entries = append(report.Entries, entry)
+		report.Fees += entry.Fees
+		report.Amount += entry.Amount
+		report.FeesFiat += entry.FeesFiat
+		report.AmountFiat += entry.AmountFiat
+	}
+
+	return report, nil
+}
+
+// fiat returns mutez valued at rate, the price of one XTZ
+func fiat(mutez int64, rate float64) float64 {
+	return float64(mutez) / float64(gotezos.MUTEZ) * rate
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPrice struct {
+	err bool
+}
+
+func (m *mockPrice) Rate(currency string, at time.Time) (float64, error) {
+	if m.err {
+		return 0, errors.New("failed to get price of XTZ")
+	}
+	return 2, nil
+}
+
+func Test_Tax(t *testing.T) {
+	records := []payout.Record{
+		{Cycle: 280, Time: time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC), Fees: 1000000, Amount: 9000000, Currency: "EUR", Rate: 2.5},
+		{Cycle: 281, Time: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC), Fees: 1000000, Amount: 9000000, Currency: "EUR", Rate: 2.5},
+		{Cycle: 282, Time: time.Date(2021, 1, 5, 0, 0, 0, 0, time.UTC), Fees: 500000, Amount: 4500000},
+	}
+
+	cases := []struct {
+		name     string
+		price    *mockPrice
+		err      bool
+		contains string
+	}{
+		{"is successful", &mockPrice{}, false, ""},
+		{"handles failure to get price", &mockPrice{err: true}, true, "failed to value payout for cycle 282"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Tax(TaxInput{Baker: "tz1baker", Records: records, Year: 2021, Currency: "eur", Price: tt.price})
+			test.CheckErr(t, tt.err, tt.contains, err)
+			if tt.err {
+				return
+			}
+
+			assert.Equal(t, TaxReport{
+				Baker:    "tz1baker",
+				Year:     2021,
+				Currency: "EUR",
+				Entries: []TaxEntry{
+					{Cycle: 281, Time: records[1].Time, Fees: 1000000, Amount: 9000000, Rate: 2.5, FeesFiat: 2.5, AmountFiat: 22.5},
+					{Cycle: 282, Time: records[2].Time, Fees: 500000, Amount: 4500000, Rate: 2, FeesFiat: 1, AmountFiat: 9},
+				},
+				Fees:       1500000,
+				Amount:     13500000,
+				FeesFiat:   3.5,
+				AmountFiat: 31.5,
+			}, report)
+		})
+	}
+
+	_, err := Tax(TaxInput{Records: records, Year: 2021, Currency: "USD"})
+	test.CheckErr(t, true, "no price of XTZ in USD recorded", err)
+}
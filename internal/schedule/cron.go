@@ -0,0 +1,116 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cron is a cron expression of five fields: minute, hour, day of month, month and day of week (0 or 7 is Sunday). A
+// field is *, a value, a range a-b, or a list of those, each optionally stepped with /n.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are true for a day field that is *, as a day then matches if the other day field does
+	domAny, dowAny bool
+}
+
+// ParseCron parses a cron expression, e.g. "0 3 * * *" for every day at 03:00
+func ParseCron(expr string) (Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Cron{}, errors.Errorf("invalid cron expression '%s': expected 5 fields", expr)
+	}
+
+	var cron Cron
+	var err error
+	for i, f := range []struct {
+		bits     *uint64
+		min, max int
+	}{
+		{&cron.minute, 0, 59},
+		{&cron.hour, 0, 23},
+		{&cron.dom, 1, 31},
+		{&cron.month, 1, 12},
+		{&cron.dow, 0, 7},
+	} {
+		if *f.bits, err = parseField(fields[i], f.min, f.max); err != nil {
+			return Cron{}, errors.Wrapf(err, "invalid cron expression '%s'", expr)
+		}
+	}
+	if cron.dow&(1<<7) != 0 {
+		cron.dow |= 1
+	}
+	cron.domAny, cron.dowAny = fields[2] == "*", fields[4] == "*"
+
+	return cron, nil
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step in '%s'", part)
+			}
+			part = part[:i]
+		}
+
+		from, to := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, errors.Errorf("invalid value '%s'", part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, errors.Errorf("invalid value '%s'", part)
+				}
+			} else if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, errors.Errorf("value '%s' out of range %d-%d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Match returns true if the minute of t, in UTC, matches the expression
+func (c Cron) Match(t time.Time) bool {
+	t = t.UTC()
+	if c.minute&(1<<uint(t.Minute())) == 0 || c.hour&(1<<uint(t.Hour())) == 0 || c.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+
+	// As in cron, a day matches either day field when both are restricted
+	return dom || dow
+}
+
+// Between returns true if the expression matches a minute after from, up to and including to
+func (c Cron) Between(from, to time.Time) bool {
+	for t := from.Truncate(time.Minute).Add(time.Minute); !t.After(to); t = t.Add(time.Minute) {
+		if c.Match(t) {
+			return true
+		}
+	}
+
+	return false
+}
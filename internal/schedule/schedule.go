@@ -0,0 +1,128 @@
+package schedule
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Window is a daily time range in UTC, which may wrap around midnight, e.g. 22:00-02:00
+type Window struct {
+	From, To time.Duration // since midnight
+}
+
+// ParseWindow parses a window of the form HH:MM-HH:MM
+func ParseWindow(window string) (Window, error) {
+	bounds := strings.SplitN(strings.TrimSpace(window), "-", 2)
+	if len(bounds) != 2 {
+		return Window{}, errors.Errorf("invalid window '%s': expected HH:MM-HH:MM", window)
+	}
+
+	var w Window
+	for i, bound := range []*time.Duration{&w.From, &w.To} {
+		t, err := time.Parse("15:04", strings.TrimSpace(bounds[i]))
+		if err != nil {
+			return Window{}, errors.Errorf("invalid window '%s': expected HH:MM-HH:MM", window)
+		}
+		*bound = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+
+	return w, nil
+}
+
+// Contains returns true if t is in the window, its start included and its end excluded
+func (w Window) Contains(t time.Time) bool {
+	t = t.UTC()
+	at := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.From <= w.To {
+		return at >= w.From && at < w.To
+	}
+
+	return at >= w.From || at < w.To
+}
+
+// Input is the input for New
+type Input struct {
+	Cron       string   // optional, payouts start at the first time matching it after they are queued
+	Windows    []string // optional, payouts only start within one of them, e.g. 02:00-06:00
+	SkipBlocks int      // optional, payouts never start in the first blocks of a cycle
+}
+
+/*
+Schedule decides when the payouts of the queue of tzpay serv start: at the first time matching Cron after a payout is
+queued, within one of the Windows, and past the first SkipBlocks blocks of the cycle in progress. A payout whose time
+came but that is held by a window or the blocks skipped starts as soon as they let it.
+*/
+type Schedule struct {
+	cron       *Cron
+	windows    []Window
+	skipBlocks int
+	mu         sync.Mutex
+	position   int
+}
+
+// New returns a new Schedule, or an error if input does not parse
+func New(input Input) (*Schedule, error) {
+	schedule := &Schedule{skipBlocks: input.SkipBlocks}
+	if input.Cron != "" {
+		cron, err := ParseCron(input.Cron)
+		if err != nil {
+			return nil, err
+		}
+		schedule.cron = &cron
+	}
+
+	for _, w := range input.Windows {
+		window, err := ParseWindow(w)
+		if err != nil {
+			return nil, err
+		}
+		schedule.windows = append(schedule.windows, window)
+	}
+
+	if input.SkipBlocks < 0 {
+		return nil, errors.Errorf("invalid blocks to skip %d", input.SkipBlocks)
+	}
+
+	return schedule, nil
+}
+
+// Enabled returns true if the schedule holds payouts at any time
+func (s *Schedule) Enabled() bool {
+	return s.cron != nil || len(s.windows) > 0 || s.skipBlocks > 0
+}
+
+// SetPosition sets the position in the cycle in progress of the head of the chain
+func (s *Schedule) SetPosition(position int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.position = position
+}
+
+// Ready returns nil if a payout queued at enqueued may start at now, or why it waits
+func (s *Schedule) Ready(enqueued, now time.Time) error {
+	if s.cron != nil && !s.cron.Between(enqueued, now) {
+		return errors.New("waiting for the time scheduled")
+	}
+
+	if len(s.windows) > 0 {
+		var open bool
+		for _, window := range s.windows {
+			open = open || window.Contains(now)
+		}
+		if !open {
+			return errors.New("waiting for an injection window")
+		}
+	}
+
+	s.mu.Lock()
+	position := s.position
+	s.mu.Unlock()
+	if position < s.skipBlocks {
+		return errors.Errorf("waiting for the first %d blocks of the cycle to pass", s.skipBlocks)
+	}
+
+	return nil
+}
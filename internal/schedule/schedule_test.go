@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseCron(t *testing.T) {
+	cases := []struct {
+		name  string
+		expr  string
+		match []string
+		miss  []string
+		err   bool
+	}{
+		{"is every day at 03:00", "0 3 * * *", []string{"2021-03-01 03:00"}, []string{"2021-03-01 03:01", "2021-03-01 04:00"}, false},
+		{"is stepped", "*/15 2-5 * * *", []string{"2021-03-01 02:00", "2021-03-01 05:45"}, []string{"2021-03-01 02:10", "2021-03-01 06:00"}, false},
+		{"is a list", "0,30 * * * *", []string{"2021-03-01 12:30"}, []string{"2021-03-01 12:15"}, false},
+		{"is on sunday as 7", "0 0 * * 7", []string{"2021-02-28 00:00"}, []string{"2021-03-01 00:00"}, false},
+		{"is on either day", "0 0 1 * 0", []string{"2021-03-01 00:00", "2021-03-07 00:00"}, []string{"2021-03-02 00:00"}, false},
+		{"handles missing fields", "0 3 * *", nil, nil, true},
+		{"handles out of range", "60 3 * * *", nil, nil, true},
+		{"handles invalid step", "*/0 3 * * *", nil, nil, true},
+		{"handles invalid value", "a 3 * * *", nil, nil, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			assert.Equal(t, tt.err, err != nil)
+			for _, m := range tt.match {
+				assert.True(t, cron.Match(at(t, m)), m)
+			}
+			for _, m := range tt.miss {
+				assert.False(t, cron.Match(at(t, m)), m)
+			}
+		})
+	}
+}
+
+func Test_Window(t *testing.T) {
+	window, err := ParseWindow("02:00-06:00")
+	assert.Nil(t, err)
+	assert.True(t, window.Contains(at(t, "2021-03-01 02:00")))
+	assert.True(t, window.Contains(at(t, "2021-03-01 05:59")))
+	assert.False(t, window.Contains(at(t, "2021-03-01 06:00")))
+
+	window, err = ParseWindow("22:00-02:00")
+	assert.Nil(t, err)
+	assert.True(t, window.Contains(at(t, "2021-03-01 23:00")))
+	assert.True(t, window.Contains(at(t, "2021-03-01 01:00")))
+	assert.False(t, window.Contains(at(t, "2021-03-01 12:00")))
+
+	_, err = ParseWindow("02:00")
+	assert.Error(t, err)
+	_, err = ParseWindow("02:00-25:00")
+	assert.Error(t, err)
+}
+
+func Test_Schedule(t *testing.T) {
+	schedule, err := New(Input{})
+	assert.Nil(t, err)
+	assert.False(t, schedule.Enabled())
+	assert.Nil(t, schedule.Ready(at(t, "2021-03-01 12:00"), at(t, "2021-03-01 12:00")))
+
+	_, err = New(Input{Cron: "0 3 * *"})
+	assert.Error(t, err)
+	_, err = New(Input{Windows: []string{"02:00"}})
+	assert.Error(t, err)
+
+	schedule, err = New(Input{Cron: "0 3 * * *", Windows: []string{"02:00-04:00", "12:00-13:00"}, SkipBlocks: 10})
+	assert.Nil(t, err)
+	assert.True(t, schedule.Enabled())
+	schedule.SetPosition(100)
+
+	enqueued := at(t, "2021-03-01 12:30")
+	assert.EqualError(t, schedule.Ready(enqueued, at(t, "2021-03-01 12:45")), "waiting for the time scheduled")
+	assert.Nil(t, schedule.Ready(enqueued, at(t, "2021-03-02 03:00")))
+	assert.EqualError(t, schedule.Ready(enqueued, at(t, "2021-03-02 05:00")), "waiting for an injection window", "a payout held past its window waits for the next")
+	assert.Nil(t, schedule.Ready(enqueued, at(t, "2021-03-02 12:00")))
+
+	schedule.SetPosition(9)
+	assert.EqualError(t, schedule.Ready(enqueued, at(t, "2021-03-02 03:00")), "waiting for the first 10 blocks of the cycle to pass")
+}
+
+func at(t *testing.T, value string) time.Time {
+	v, err := time.Parse("2006-01-02 15:04", value)
+	assert.Nil(t, err)
+	return v
+}
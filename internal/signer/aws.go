@@ -0,0 +1,194 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// instanceMetadata is the endpoint of the EC2 instance metadata service serving the credentials of the instance role
+var instanceMetadata = "http://169.254.169.254"
+
+// credentialsExpiryWindow is how long before they expire credentials of the instance role are refreshed
+const credentialsExpiryWindow = 5 * time.Minute
+
+type awsCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+/*
+awsCredentialsProvider returns the credentials of the standard AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+AWS_SESSION_TOKEN environment variables, or else those of the role of the EC2 instance, which are cached until
+shortly before they expire.
+*/
+type awsCredentialsProvider struct {
+	client *http.Client
+	mu     sync.Mutex
+	cached awsCredentials
+}
+
+func (a *awsCredentialsProvider) get() (awsCredentials, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return awsCredentials{
+			AccessKeyID:     id,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			Token:           os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cached.AccessKeyID != "" && time.Now().Add(credentialsExpiryWindow).Before(a.cached.Expiration) {
+		return a.cached, nil
+	}
+
+	credentials, err := a.instanceRole()
+	if err != nil {
+		return awsCredentials{}, errors.Wrap(err, "failed to get credentials of instance role")
+	}
+	a.cached = credentials
+
+	return credentials, nil
+}
+
+// instanceRole fetches the credentials of the role of the EC2 instance with a session of the metadata service (IMDSv2)
+func (a *awsCredentialsProvider) instanceRole() (awsCredentials, error) {
+	req, err := http.NewRequest(http.MethodPut, instanceMetadata+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, errors.Wrap(err, "failed to construct request")
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := a.metadata(req)
+	if err != nil {
+		return awsCredentials{}, errors.Wrap(err, "failed to get metadata token")
+	}
+
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, instanceMetadata+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to construct request")
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		return a.metadata(req)
+	}
+
+	roles, err := get("")
+	if err != nil {
+		return awsCredentials{}, errors.Wrap(err, "failed to get instance role")
+	}
+	role := strings.TrimSpace(strings.Split(string(roles), "\n")[0])
+	if role == "" {
+		return awsCredentials{}, errors.New("no role attached to instance")
+	}
+
+	byts, err := get(role)
+	if err != nil {
+		return awsCredentials{}, errors.Wrapf(err, "failed to get credentials of role '%s'", role)
+	}
+
+	var credentials awsCredentials
+	if err := json.Unmarshal(byts, &credentials); err != nil {
+		return awsCredentials{}, errors.Wrap(err, "failed to parse credentials")
+	}
+
+	return credentials, nil
+}
+
+func (a *awsCredentialsProvider) metadata(req *http.Request) ([]byte, error) {
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to complete request")
+	}
+	defer resp.Body.Close()
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response returned code %d with body %s", resp.StatusCode, string(byts))
+	}
+
+	return byts, nil
+}
+
+// signV4 signs a request to an AWS service with the Signature Version 4 of its headers and payload
+func signV4(req *http.Request, payload []byte, credentials awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.Token != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.Token)
+	}
+
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		headers.WriteString(fmt.Sprintf("%s:%s\n", name, strings.TrimSpace(req.Header.Get(name))))
+	}
+	signed := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonical := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		headers.String(),
+		signed,
+		hexSHA256(payload),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	toSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonical))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(signingKey(credentials.SecretAccessKey, date, region, service), []byte(toSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signed, signature,
+	))
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
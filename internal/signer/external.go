@@ -0,0 +1,259 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// defaultWait bounds the wait for an external signature when no wait is configured
+	defaultWait = time.Hour
+	// defaultInterval is how often a signature is looked for
+	defaultInterval = 5 * time.Second
+)
+
+// ExternalInput is the input for NewExternal
+type ExternalInput struct {
+	Address  string
+	Dir      string   // directory signing requests are written to, and signatures read from
+	URL      string   // signing API signing requests are posted to, and signatures polled from
+	Headers  []string // "Name:Value", sent with every request to URL
+	Wait     time.Duration
+	Interval time.Duration
+}
+
+// SigningRequest is an operation of the payout wallet waiting to be signed outside of tzpay
+type SigningRequest struct {
+	ID        string    `json:"id"`
+	Address   string    `json:"address"`
+	Operation string    `json:"operation"` // hex encoded, watermark included
+	Digest    string    `json:"digest"`    // hex encoded blake2b-256 of the operation, what the key signs
+	Time      time.Time `json:"time"`
+}
+
+/*
+External never holds the key of the payout wallet. Every operation to sign is written as a SigningRequest to Dir, or
+posted to URL, and tzpay waits for a signature produced by separate signing infrastructure, e.g. a custodian: the
+file <id>.signature in Dir, or the signature returned for GET URL/<id>.
+*/
+type External struct {
+	address  string
+	dir      string
+	url      string
+	client   *http.Client
+	headers  http.Header
+	wait     time.Duration
+	interval time.Duration
+}
+
+// NewExternal returns a new External, once the directory or API of signing requests is set
+func NewExternal(input ExternalInput) (*External, error) {
+	if (input.Dir == "") == (input.URL == "") {
+		return nil, errors.New("invalid external signer: set either a directory or a URL for signing requests")
+	}
+
+	headers := http.Header{}
+	for _, header := range input.Headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid external signer header '%s': expected 'Name:Value'", header)
+		}
+		headers.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	external := &External{
+		address:  input.Address,
+		dir:      input.Dir,
+		url:      strings.TrimSuffix(input.URL, "/"),
+		client:   &http.Client{Timeout: defaultTimeout},
+		headers:  headers,
+		wait:     input.Wait,
+		interval: input.Interval,
+	}
+	if external.wait <= 0 {
+		external.wait = defaultWait
+	}
+	if external.interval <= 0 {
+		external.interval = defaultInterval
+	}
+
+	if external.dir != "" {
+		if err := os.MkdirAll(external.dir, 0700); err != nil {
+			return nil, errors.Wrap(err, "failed to create directory of signing requests")
+		}
+	}
+
+	return external, nil
+}
+
+// Address returns the address of the payout wallet
+func (e *External) Address() string {
+	return e.address
+}
+
+// Sign hands a hex encoded message or bytes, prefixed with the operation watermark, to be signed and waits for its signature
+func (e *External) Sign(input keys.SignInput) (keys.Signature, error) {
+	message, err := watermarked(input)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign externally")
+	}
+
+	digest := blake2b.Sum256(message)
+	request := SigningRequest{
+		ID:        hex.EncodeToString(digest[:]),
+		Address:   e.address,
+		Operation: hex.EncodeToString(message),
+		Digest:    hex.EncodeToString(digest[:]),
+		Time:      time.Now().UTC(),
+	}
+
+	if err := e.request(request); err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign externally")
+	}
+	logrus.WithFields(logrus.Fields{"wallet": e.address, "request": request.ID}).Info("Waiting for external signature.")
+
+	timer := time.After(e.wait)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		signature, err := e.signature(request.ID)
+		if err != nil {
+			return keys.Signature{}, errors.Wrap(err, "failed to sign externally")
+		} else if signature != nil {
+			if e.dir != "" {
+				os.Remove(e.requestPath(request.ID))
+			}
+			return keys.Signature{Bytes: signature, Prefix: genericSignaturePrefix}, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timer:
+			return keys.Signature{}, errors.Errorf("failed to sign externally: no signature for request '%s' after %s", request.ID, e.wait)
+		}
+	}
+}
+
+func (e *External) requestPath(id string) string {
+	return filepath.Join(e.dir, id+".request.json")
+}
+
+func (e *External) signaturePath(id string) string {
+	return filepath.Join(e.dir, id+".signature")
+}
+
+// request writes the signing request to the directory, or posts it to the API
+func (e *External) request(request SigningRequest) error {
+	byts, err := json.MarshalIndent(request, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to construct signing request")
+	}
+
+	if e.dir != "" {
+		if err := ioutil.WriteFile(e.requestPath(request.ID), byts, 0600); err != nil {
+			return errors.Wrap(err, "failed to write signing request")
+		}
+
+		return nil
+	}
+
+	resp, err := e.do(http.MethodPost, e.url, byts)
+	if err != nil {
+		return errors.Wrap(err, "failed to post signing request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("failed to post signing request: response returned code %d with body %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// signature returns the signature of the request once available, or nil while it is pending
+func (e *External) signature(id string) ([]byte, error) {
+	if e.dir != "" {
+		byts, err := ioutil.ReadFile(e.signaturePath(id))
+		if os.IsNotExist(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to read signature")
+		}
+
+		return parseSignature(strings.TrimSpace(string(byts)))
+	}
+
+	resp, err := e.do(http.MethodGet, fmt.Sprintf("%s/%s", e.url, id), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get signature")
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read response body")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusAccepted, http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to get signature: response returned code %d with body %s", resp.StatusCode, string(body))
+	}
+
+	var signed struct {
+		Signature string `json:"signature"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &signed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse response")
+	}
+
+	if signed.Error != "" {
+		return nil, errors.Errorf("signing request '%s' was refused: %s", id, signed.Error)
+	} else if signed.Signature == "" {
+		return nil, nil
+	}
+
+	return parseSignature(signed.Signature)
+}
+
+func (e *External) do(method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to construct request")
+	}
+	for name := range e.headers {
+		req.Header.Set(name, e.headers.Get(name))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return e.client.Do(req)
+}
+
+// parseSignature returns the 64 bytes of a signature, hex encoded or base58check encoded with the prefix of any curve
+func parseSignature(signature string) ([]byte, error) {
+	if byts, err := hex.DecodeString(signature); err == nil {
+		if len(byts) != 64 {
+			return nil, errors.Errorf("invalid signature '%s': expected 64 bytes", signature)
+		}
+		return byts, nil
+	}
+
+	return decodeSignature(signature)
+}
@@ -0,0 +1,195 @@
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+// signRequest signs the operation of a signing request as a custodian would
+func signRequest(t *testing.T, key keys.Key, request SigningRequest) keys.Signature {
+	assert.Equal(t, key.PubKey.GetPublicKeyHash(), request.Address)
+	assert.Equal(t, "03", request.Operation[:2], "the operation is requested watermarked")
+
+	signature, err := key.Sign(keys.SignInput{Message: request.Operation})
+	assert.Nil(t, err)
+
+	return signature
+}
+
+func Test_External_Dir(t *testing.T) {
+	key := newKey(t)
+
+	dir, err := ioutil.TempDir("", "tzpay-external")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	external, err := NewExternal(ExternalInput{
+		Address:  key.PubKey.GetPublicKeyHash(),
+		Dir:      filepath.Join(dir, "requests"),
+		Interval: time.Millisecond,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, key.PubKey.GetPublicKeyHash(), external.Address())
+
+	// The custodian picks up the request and drops its signature next to it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			paths, _ := filepath.Glob(filepath.Join(dir, "requests", "*.request.json"))
+			if len(paths) == 0 {
+				time.Sleep(time.Millisecond)
+				continue
+			}
+
+			byts, err := ioutil.ReadFile(paths[0])
+			assert.Nil(t, err)
+			var request SigningRequest
+			assert.Nil(t, json.Unmarshal(byts, &request))
+
+			signature := signRequest(t, key, request)
+			path := strings.TrimSuffix(paths[0], ".request.json") + ".signature"
+			assert.Nil(t, ioutil.WriteFile(path, []byte(signature.ToBase58()+"\n"), 0600))
+			return
+		}
+	}()
+
+	want, err := key.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+
+	signature, err := external.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+	assert.Equal(t, want.Bytes, signature.Bytes)
+	<-done
+
+	paths, err := filepath.Glob(filepath.Join(dir, "requests", "*.request.json"))
+	assert.Nil(t, err)
+	assert.Empty(t, paths, "requests signed are removed")
+
+	waiting, err := NewExternal(ExternalInput{
+		Address:  key.PubKey.GetPublicKeyHash(),
+		Dir:      filepath.Join(dir, "waiting"),
+		Wait:     10 * time.Millisecond,
+		Interval: time.Millisecond,
+	})
+	assert.Nil(t, err)
+	_, err = waiting.Sign(keys.SignInput{Message: "6c00"})
+	test.CheckErr(t, true, "no signature for request", err)
+}
+
+func Test_External_URL(t *testing.T) {
+	key := newKey(t)
+
+	var mu sync.Mutex
+	requests := map[string]SigningRequest{}
+	polls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodPost && r.URL.Path == "/requests" {
+			var request SigningRequest
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&request))
+			requests[request.ID] = request
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, "/requests/")
+		request, ok := requests[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// The first poll finds the request pending
+		if polls[id]++; polls[id] == 1 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		if request.Operation == "036c00" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "refused by policy"})
+			return
+		}
+
+		signature := signRequest(t, key, request)
+		json.NewEncoder(w).Encode(map[string]string{"signature": hex.EncodeToString(signature.Bytes)})
+	}))
+	defer server.Close()
+
+	external, err := NewExternal(ExternalInput{
+		Address:  key.PubKey.GetPublicKeyHash(),
+		URL:      server.URL + "/requests/",
+		Headers:  []string{"Authorization: Bearer secret"},
+		Interval: time.Millisecond,
+	})
+	assert.Nil(t, err)
+
+	want, err := key.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+
+	signature, err := external.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+	assert.Equal(t, want.Bytes, signature.Bytes)
+
+	_, err = external.Sign(keys.SignInput{Message: "6c00"})
+	test.CheckErr(t, true, "was refused: refused by policy", err)
+
+	unauthorized, err := NewExternal(ExternalInput{Address: key.PubKey.GetPublicKeyHash(), URL: server.URL + "/requests"})
+	assert.Nil(t, err)
+	_, err = unauthorized.Sign(keys.SignInput{Message: forgedOperation})
+	test.CheckErr(t, true, "response returned code 401", err)
+}
+
+func Test_NewExternal(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    ExternalInput
+		contains string
+	}{
+		{
+			"handles neither directory nor URL",
+			ExternalInput{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"},
+			"set either a directory or a URL",
+		},
+		{
+			"handles both directory and URL",
+			ExternalInput{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Dir: "requests", URL: "http://localhost"},
+			"set either a directory or a URL",
+		},
+		{
+			"handles invalid header",
+			ExternalInput{Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", URL: "http://localhost", Headers: []string{"Bearer secret"}},
+			"invalid external signer header 'Bearer secret'",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewExternal(tt.input)
+			test.CheckErr(t, true, tt.contains, err)
+		})
+	}
+
+	_, err := parseSignature("abcd")
+	test.CheckErr(t, true, "expected 64 bytes", err)
+}
@@ -0,0 +1,263 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// tz2AddressPrefix, secp256k1PublicKeyPrefix and secp256k1SignaturePrefix are the base58 prefixes of tz2..., sppk... and spsig1...
+	tz2AddressPrefix         = []byte{6, 161, 161}
+	secp256k1PublicKeyPrefix = []byte{3, 254, 226, 86}
+	secp256k1SignaturePrefix = []byte{13, 115, 101, 19, 63}
+
+	secp256k1OID   = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	secp256k1Order = mustBigInt("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141")
+)
+
+// kmsKeySpec is the key spec of an asymmetric secp256k1 key in KMS
+const kmsKeySpec = "ECC_SECG_P256K1"
+
+// KMSInput is the input for NewKMS
+type KMSInput struct {
+	KeyID    string // ID, ARN or alias of the key
+	Region   string // defaults to AWS_REGION or AWS_DEFAULT_REGION
+	Endpoint string // defaults to https://kms.<Region>.amazonaws.com, e.g. a VPC endpoint
+	Timeout  time.Duration
+}
+
+/*
+KMS signs with an asymmetric secp256k1 key stored in AWS KMS, so that the secret key never leaves KMS. The payout
+wallet is the tz2 address of the key. Credentials are those of the standard AWS environment variables or else of
+the role of the EC2 instance tzpay runs on.
+*/
+type KMS struct {
+	client      *http.Client
+	credentials *awsCredentialsProvider
+	keyID       string
+	region      string
+	endpoint    string
+	address     string
+	publicKey   string
+}
+
+// NewKMS returns a new KMS, once the key was fetched from KMS and checked to be a secp256k1 signing key
+func NewKMS(input KMSInput) (*KMS, error) {
+	timeout := input.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	region := input.Region
+	if region == "" {
+		if region = os.Getenv("AWS_REGION"); region == "" {
+			region = os.Getenv("AWS_DEFAULT_REGION")
+		}
+	}
+	if region == "" {
+		return nil, errors.New("failed to initialize KMS signer: missing region")
+	}
+
+	endpoint := input.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", region)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	k := &KMS{
+		client:      client,
+		credentials: &awsCredentialsProvider{client: client},
+		keyID:       input.KeyID,
+		region:      region,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+	}
+
+	var resp struct {
+		KeySpec   string `json:"KeySpec"`
+		KeyUsage  string `json:"KeyUsage"`
+		PublicKey []byte `json:"PublicKey"`
+	}
+	if err := k.do("GetPublicKey", map[string]string{"KeyId": input.KeyID}, &resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to get key '%s' from KMS", input.KeyID)
+	}
+
+	if resp.KeySpec != "" && resp.KeySpec != kmsKeySpec {
+		return nil, errors.Errorf("failed to initialize KMS signer: key '%s' is a %s key, expected %s", input.KeyID, resp.KeySpec, kmsKeySpec)
+	}
+	if resp.KeyUsage != "" && resp.KeyUsage != "SIGN_VERIFY" {
+		return nil, errors.Errorf("failed to initialize KMS signer: key '%s' is not a signing key", input.KeyID)
+	}
+
+	publicKey, err := compressedPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to initialize KMS signer with key '%s'", input.KeyID)
+	}
+
+	hash, err := blake2b.New(20, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize KMS signer")
+	}
+	hash.Write(publicKey)
+
+	k.address = base58check.Encode(tz2AddressPrefix, hash.Sum(nil))
+	k.publicKey = base58check.Encode(secp256k1PublicKeyPrefix, publicKey)
+
+	return k, nil
+}
+
+// Address returns the tz2 address of the key
+func (k *KMS) Address() string {
+	return k.address
+}
+
+// PublicKey returns the sppk public key of the key
+func (k *KMS) PublicKey() string {
+	return k.publicKey
+}
+
+// Sign asks KMS to sign the blake2b digest of a hex encoded message or bytes, prefixed with the operation watermark
+func (k *KMS) Sign(input keys.SignInput) (keys.Signature, error) {
+	message, err := watermarked(input)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with KMS")
+	}
+	digest := blake2b.Sum256(message)
+
+	var resp struct {
+		Signature []byte `json:"Signature"`
+	}
+	err = k.do("Sign", map[string]interface{}{
+		"KeyId":            k.keyID,
+		"Message":          digest[:],
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}, &resp)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with KMS")
+	}
+
+	signature, err := tezosSignature(resp.Signature)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with KMS")
+	}
+
+	return keys.Signature{Bytes: signature, Prefix: secp256k1SignaturePrefix}, nil
+}
+
+func (k *KMS) do(action string, body interface{}, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to construct request")
+	}
+
+	credentials, err := k.credentials.get()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, k.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to construct request")
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	signV4(req, payload, credentials, k.region, "kms", time.Now())
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to complete request")
+	}
+	defer resp.Body.Close()
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "could not read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response returned code %d with body %s", resp.StatusCode, string(byts))
+	}
+
+	if err := json.Unmarshal(byts, v); err != nil {
+		return errors.Wrap(err, "failed to parse response")
+	}
+
+	return nil
+}
+
+// compressedPublicKey returns the 33 byte compressed point of a DER encoded secp256k1 SubjectPublicKeyInfo
+func compressedPublicKey(der []byte) ([]byte, error) {
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, errors.Wrap(err, "failed to parse public key")
+	} else if len(rest) != 0 {
+		return nil, errors.New("failed to parse public key: trailing data")
+	}
+
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &curve); err != nil || !curve.Equal(secp256k1OID) {
+		return nil, errors.New("public key is not a secp256k1 key")
+	}
+
+	point := info.PublicKey.RightAlign()
+	if len(point) != 65 || point[0] != 4 {
+		return nil, errors.New("public key is not an uncompressed secp256k1 point")
+	}
+
+	return append([]byte{2 + point[64]&1}, point[1:33]...), nil
+}
+
+/*
+tezosSignature returns the 64 byte r || s signature Tezos expects of a DER encoded ECDSA signature, with s
+normalized to the lower half of the curve order since Tezos refuses the equivalent high s signature.
+*/
+func tezosSignature(der []byte) ([]byte, error) {
+	var signature struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(der, &signature); err != nil {
+		return nil, errors.Wrap(err, "failed to parse signature")
+	} else if len(rest) != 0 {
+		return nil, errors.New("failed to parse signature: trailing data")
+	}
+
+	if signature.R.Sign() <= 0 || signature.S.Sign() <= 0 || signature.R.Cmp(secp256k1Order) >= 0 || signature.S.Cmp(secp256k1Order) >= 0 {
+		return nil, errors.New("invalid signature")
+	}
+
+	if signature.S.Cmp(new(big.Int).Rsh(secp256k1Order, 1)) > 0 {
+		signature.S = new(big.Int).Sub(secp256k1Order, signature.S)
+	}
+
+	byts := make([]byte, 64)
+	rb, sb := signature.R.Bytes(), signature.S.Bytes()
+	copy(byts[32-len(rb):32], rb)
+	copy(byts[64-len(sb):], sb)
+
+	return byts, nil
+}
+
+func mustBigInt(hex string) *big.Int {
+	n, ok := new(big.Int).SetString(hex, 16)
+	if !ok {
+		panic(fmt.Sprintf("invalid hex integer '%s'", hex))
+	}
+	return n
+}
@@ -0,0 +1,171 @@
+package signer
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	kmsPublicKey = "MFYwEAYHKoZIzj0CAQYFK4EEAAoDQgAEBPefXIMD7ZinpiHVy+Us7qTxlpqU97l084hkpU56e/rtuEell582pq850IUO/5UOyR+BvfSOxEIg8gIthqn/RA=="
+	// kmsSignature and kmsHighSignature are the same signature, with s in the lower and the upper half of the curve order
+	kmsSignature     = "MEQCIGgQfyF/UJVM93Y+FaOqBGNoeafpe9FLG8zf54Zh3kLOAiAoO4rkhxtixaY64hWH5W2MLSR1TRGC6P60my/YFH56ZA=="
+	kmsHighSignature = "MEUCIGgQfyF/UJVM93Y+FaOqBGNoeafpe9FLG8zf54Zh3kLOAiEA18R1G3jknTpZxR3qeBqSco2KZ5mdxbc9CzcutLu3xt0="
+	kmsRawSignature  = "68107f217f50954cf7763e15a3aa04636879a7e97bd14b1bccdfe78661de42ce283b8ae4871b62c5a63ae21587e56d8c2d24754d1182e8feb49b2fd8147e7a64"
+)
+
+// newKMS serves the GetPublicKey and Sign actions of KMS for the key "payout", refusing unsigned requests
+func newKMS(t *testing.T, signature string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			KeyID       string `json:"KeyId"`
+			Message     []byte `json:"Message"`
+			MessageType string `json:"MessageType"`
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Nil(t, json.Unmarshal(body, &req))
+
+		if req.KeyID != "payout" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"NotFoundException"}`))
+			return
+		}
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			json.NewEncoder(w).Encode(map[string]string{"KeySpec": kmsKeySpec, "KeyUsage": "SIGN_VERIFY", "PublicKey": kmsPublicKey})
+		case "TrentService.Sign":
+			message, err := hex.DecodeString("03" + forgedOperation)
+			assert.Nil(t, err)
+			digest := blake2b.Sum256(message)
+			assert.Equal(t, "DIGEST", req.MessageType)
+			assert.Equal(t, digest[:], req.Message, "the watermarked operation is hashed before it is signed")
+
+			json.NewEncoder(w).Encode(map[string]string{"Signature": signature})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func Test_KMS(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	for _, signature := range []string{kmsSignature, kmsHighSignature} {
+		server := newKMS(t, signature)
+
+		k, err := NewKMS(KMSInput{KeyID: "payout", Region: "us-east-1", Endpoint: server.URL})
+		assert.Nil(t, err)
+		assert.Equal(t, "tz2EgTtCdYoDiuJhxMLPBSgh3UsfSprudoiv", k.Address())
+		assert.Equal(t, "sppk7ZLpjkPij7x7HBV2zF1QFH8dgtcVSWCusu63VyUVG3wkgxDJdFZ", k.PublicKey())
+
+		sig, err := k.Sign(keys.SignInput{Message: forgedOperation})
+		assert.Nil(t, err)
+		assert.Equal(t, kmsRawSignature, hex.EncodeToString(sig.Bytes))
+		assert.Equal(t, "spsig1KRcsXm46Wn5TbdxjS3B2y3YWb16z5xpqJqYgS9GQUqhGg8DKRTCX1k5EKM9BdtzMjXdrhnY4fnHoZgY2iEisMA9JfzcUh", sig.ToBase58())
+
+		_, err = NewKMS(KMSInput{KeyID: "other", Region: "us-east-1", Endpoint: server.URL})
+		test.CheckErr(t, true, "failed to get key 'other' from KMS", err)
+
+		server.Close()
+	}
+
+	_, err := NewKMS(KMSInput{KeyID: "payout"})
+	test.CheckErr(t, true, "missing region", err)
+}
+
+func Test_awsCredentialsProvider(t *testing.T) {
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("session"))
+		case r.Header.Get("X-aws-ec2-metadata-token") != "session":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("tzpay\n"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/tzpay":
+			json.NewEncoder(w).Encode(awsCredentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", Token: "token", Expiration: expiration})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	metadata := instanceMetadata
+	instanceMetadata = server.URL
+	defer func() { instanceMetadata = metadata }()
+
+	provider := &awsCredentialsProvider{client: server.Client()}
+	credentials, err := provider.get()
+	assert.Nil(t, err)
+	assert.Equal(t, awsCredentials{AccessKeyID: "ASIA", SecretAccessKey: "secret", Token: "token", Expiration: expiration}, credentials)
+
+	_, err = provider.get()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, requests, "credentials are cached until they expire")
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	credentials, err = provider.get()
+	assert.Nil(t, err)
+	assert.Equal(t, "AKID", credentials.AccessKeyID)
+}
+
+func Test_signingKey(t *testing.T) {
+	// Example of the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	assert.Equal(t, "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+}
+
+func Test_tezosSignature(t *testing.T) {
+	for _, signature := range []string{kmsSignature, kmsHighSignature} {
+		der, err := base64.StdEncoding.DecodeString(signature)
+		assert.Nil(t, err)
+
+		byts, err := tezosSignature(der)
+		assert.Nil(t, err)
+		assert.Equal(t, kmsRawSignature, hex.EncodeToString(byts))
+	}
+
+	_, err := tezosSignature([]byte{0x30, 0x00})
+	test.CheckErr(t, true, "failed to parse signature", err)
+}
+
+func Test_compressedPublicKey(t *testing.T) {
+	der, err := base64.StdEncoding.DecodeString(kmsPublicKey)
+	assert.Nil(t, err)
+
+	key, err := compressedPublicKey(der)
+	assert.Nil(t, err)
+	assert.Equal(t, "0204f79f5c8303ed98a7a621d5cbe52ceea4f1969a94f7b974f38864a54e7a7bfa", hex.EncodeToString(key))
+
+	// P-256 key
+	p256, err := base64.StdEncoding.DecodeString("MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEas8lpginMYMF2LUL+MmpF9Lm9SZcQZkdi4wx3zYMZiC/cY5bTUg05f4uL6fYzC+DMuBNk24Z2E17/pb+uFN5Ag==")
+	assert.Nil(t, err)
+	_, err = compressedPublicKey(p256)
+	test.CheckErr(t, true, "public key is not a secp256k1 key", err)
+}
@@ -0,0 +1,97 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// tz3AddressPrefix, p256PublicKeyPrefix and p256SignaturePrefix are the base58 prefixes of tz3..., p2pk... and p2sig...
+	tz3AddressPrefix    = []byte{6, 161, 164}
+	p256PublicKeyPrefix = []byte{3, 178, 139, 127}
+	p256SignaturePrefix = []byte{54, 240, 44, 52}
+)
+
+/*
+P256 signs with a NIST P-256 secret key held by tzpay, a tz3 payout wallet. The key is kept encrypted at rest as a
+p2esk key like an Ed25519 one, but go-tezos only signs with Ed25519 keys.
+*/
+type P256 struct {
+	key       *ecdsa.PrivateKey
+	address   string
+	publicKey string
+}
+
+// NewP256 returns a new P256 for the 32 byte secret key passed
+func NewP256(secret []byte) (*P256, error) {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(secret)
+	if len(secret) != 32 || d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, errors.New("invalid P-256 secret key")
+	}
+
+	x, y := curve.ScalarBaseMult(secret)
+	publicKey := make([]byte, 33)
+	publicKey[0] = byte(2 + y.Bit(0))
+	xb := x.Bytes()
+	copy(publicKey[33-len(xb):], xb)
+
+	hash, err := blake2b.New(20, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize P-256 signer")
+	}
+	hash.Write(publicKey)
+
+	return &P256{
+		key: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+			D:         d,
+		},
+		address:   base58check.Encode(tz3AddressPrefix, hash.Sum(nil)),
+		publicKey: base58check.Encode(p256PublicKeyPrefix, publicKey),
+	}, nil
+}
+
+// Address returns the tz3 address of the key
+func (p *P256) Address() string {
+	return p.address
+}
+
+// PublicKey returns the p2pk public key of the key
+func (p *P256) PublicKey() string {
+	return p.publicKey
+}
+
+// Sign signs the blake2b digest of a hex encoded message or bytes, prefixed with the operation watermark
+func (p *P256) Sign(input keys.SignInput) (keys.Signature, error) {
+	message, err := watermarked(input)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with P-256 key")
+	}
+	digest := blake2b.Sum256(message)
+
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, digest[:])
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with P-256 key")
+	}
+
+	// Tezos refuses the equivalent high s signature
+	order := p.key.Curve.Params().N
+	if s.Cmp(new(big.Int).Rsh(order, 1)) > 0 {
+		s = new(big.Int).Sub(order, s)
+	}
+
+	signature := make([]byte, 64)
+	rb, sb := r.Bytes(), s.Bytes()
+	copy(signature[32-len(rb):32], rb)
+	copy(signature[64-len(sb):], sb)
+
+	return keys.Signature{Bytes: signature, Prefix: p256SignaturePrefix}, nil
+}
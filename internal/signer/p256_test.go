@@ -0,0 +1,55 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+func Test_P256(t *testing.T) {
+	// The secret key 1, whose public key is the generator of the curve
+	secret := make([]byte, 32)
+	secret[31] = 1
+
+	p, err := NewP256(secret)
+	assert.Nil(t, err)
+	assert.Equal(t, "tz3bqAfFRnSA6dfPRG8XR6MBMmo6HZTTG44V", p.Address())
+	assert.Equal(t, "p2pk67L57Q7vcgLkMrKXctFRKs5JSLR6qjiw1riJaFyakWpTv9QSkRf", p.PublicKey())
+
+	message, err := hex.DecodeString("03" + forgedOperation)
+	assert.Nil(t, err)
+	digest := blake2b.Sum256(message)
+
+	for i := 0; i < 8; i++ {
+		signature, err := p.Sign(keys.SignInput{Message: forgedOperation})
+		assert.Nil(t, err)
+		assert.Equal(t, p256SignaturePrefix, signature.Prefix)
+		assert.Len(t, signature.Bytes, 64)
+
+		r, s := new(big.Int).SetBytes(signature.Bytes[:32]), new(big.Int).SetBytes(signature.Bytes[32:])
+		assert.True(t, ecdsa.Verify(&p.key.PublicKey, digest[:], r, s), "the watermarked operation is signed")
+		assert.True(t, s.Cmp(new(big.Int).Rsh(p.key.Curve.Params().N, 1)) <= 0, "s is in the lower half of the curve order")
+	}
+
+	cases := []struct {
+		name   string
+		secret []byte
+	}{
+		{"refuses a short secret", []byte{1}},
+		{"refuses a zero secret", make([]byte, 32)},
+		{"refuses a secret above the curve order", p.key.Curve.Params().N.Bytes()},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewP256(tt.secret)
+			test.CheckErr(t, true, "invalid P-256 secret key", err)
+		})
+	}
+}
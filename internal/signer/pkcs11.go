@@ -0,0 +1,169 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"os/exec"
+	"strings"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+var (
+	// tz1AddressPrefix, ed25519PublicKeyPrefix and ed25519SignaturePrefix are the base58 prefixes of tz1..., edpk... and edsig...
+	tz1AddressPrefix       = []byte{6, 161, 159}
+	ed25519PublicKeyPrefix = []byte{13, 15, 37, 217}
+	ed25519SignaturePrefix = []byte{9, 245, 205, 134, 18}
+
+	ed25519OID = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// defaultPKCS11Tool is the pkcs11-tool of OpenSC, looked up in PATH
+const defaultPKCS11Tool = "pkcs11-tool"
+
+// PKCS11Input is the input for NewPKCS11
+type PKCS11Input struct {
+	Module string // path of the PKCS#11 module of the token, e.g. /usr/lib/softhsm/libsofthsm2.so
+	Slot   string // ID of the slot of the token, defaults to the first slot with a token
+	PIN    string // user PIN of the token
+	Label  string // label of the key pair
+	Tool   string // defaults to pkcs11-tool in PATH
+}
+
+/*
+PKCS11 signs with an Ed25519 or secp256k1 key pair stored on a PKCS#11 token, such as a YubiHSM or SoftHSM, so
+that the secret key never leaves the token. The payout wallet is the tz1 or tz2 address of the key. The token is
+driven through the pkcs11-tool of OpenSC, which loads its PKCS#11 module.
+*/
+type PKCS11 struct {
+	run       func(stdin []byte, args ...string) ([]byte, error)
+	args      []string
+	ed25519   bool
+	address   string
+	publicKey string
+}
+
+// NewPKCS11 returns a new PKCS11, once the public key labeled Label was read from the token
+func NewPKCS11(input PKCS11Input) (*PKCS11, error) {
+	tool := input.Tool
+	if tool == "" {
+		tool = defaultPKCS11Tool
+	}
+
+	return newPKCS11(input, func(stdin []byte, args ...string) ([]byte, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command(tool, args...)
+		cmd.Stdin = bytes.NewReader(stdin)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			return nil, errors.Wrapf(err, "%s failed: %s", tool, strings.TrimSpace(stderr.String()))
+		}
+
+		return stdout.Bytes(), nil
+	})
+}
+
+func newPKCS11(input PKCS11Input, run func(stdin []byte, args ...string) ([]byte, error)) (*PKCS11, error) {
+	if input.Module == "" || input.Label == "" {
+		return nil, errors.New("failed to initialize PKCS#11 signer: missing module or key label")
+	}
+
+	p := &PKCS11{
+		run:  run,
+		args: []string{"--module", input.Module},
+	}
+	if input.Slot != "" {
+		p.args = append(p.args, "--slot", input.Slot)
+	}
+	if input.PIN != "" {
+		p.args = append(p.args, "--login", "--pin", input.PIN)
+	}
+	p.args = append(p.args, "--label", input.Label)
+
+	der, err := p.run(nil, append(p.args, "--read-object", "--type", "pubkey")...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read public key '%s' from PKCS#11 token", input.Label)
+	}
+
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public key '%s' from PKCS#11 token", input.Label)
+	}
+
+	var publicKey, addressPrefix, publicKeyPrefix []byte
+	if info.Algorithm.Algorithm.Equal(ed25519OID) {
+		if publicKey = info.PublicKey.RightAlign(); len(publicKey) != 32 {
+			return nil, errors.Errorf("failed to initialize PKCS#11 signer: invalid Ed25519 public key '%s'", input.Label)
+		}
+		p.ed25519 = true
+		addressPrefix, publicKeyPrefix = tz1AddressPrefix, ed25519PublicKeyPrefix
+	} else {
+		if publicKey, err = compressedPublicKey(der); err != nil {
+			return nil, errors.Wrapf(err, "failed to initialize PKCS#11 signer with key '%s'", input.Label)
+		}
+		addressPrefix, publicKeyPrefix = tz2AddressPrefix, secp256k1PublicKeyPrefix
+	}
+
+	hash, err := blake2b.New(20, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize PKCS#11 signer")
+	}
+	hash.Write(publicKey)
+
+	p.address = base58check.Encode(addressPrefix, hash.Sum(nil))
+	p.publicKey = base58check.Encode(publicKeyPrefix, publicKey)
+
+	return p, nil
+}
+
+// Address returns the tz1 or tz2 address of the key
+func (p *PKCS11) Address() string {
+	return p.address
+}
+
+// PublicKey returns the edpk or sppk public key of the key
+func (p *PKCS11) PublicKey() string {
+	return p.publicKey
+}
+
+// Sign asks the token to sign the blake2b digest of a hex encoded message or bytes, prefixed with the operation watermark
+func (p *PKCS11) Sign(input keys.SignInput) (keys.Signature, error) {
+	message, err := watermarked(input)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with PKCS#11 token")
+	}
+	digest := blake2b.Sum256(message)
+
+	mechanism := []string{"--sign", "--mechanism", "ECDSA", "--signature-format", "openssl"}
+	if p.ed25519 {
+		mechanism = []string{"--sign", "--mechanism", "EDDSA"}
+	}
+
+	out, err := p.run(digest[:], append(p.args, mechanism...)...)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with PKCS#11 token")
+	}
+
+	if p.ed25519 {
+		if len(out) != 64 {
+			return keys.Signature{}, errors.Errorf("failed to sign with PKCS#11 token: invalid Ed25519 signature of %d bytes", len(out))
+		}
+		return keys.Signature{Bytes: out, Prefix: ed25519SignaturePrefix}, nil
+	}
+
+	signature, err := tezosSignature(out)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with PKCS#11 token")
+	}
+
+	return keys.Signature{Bytes: signature, Prefix: secp256k1SignaturePrefix}, nil
+}
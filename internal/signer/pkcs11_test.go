@@ -0,0 +1,127 @@
+package signer
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+)
+
+// newToken emulates pkcs11-tool for the key pair labeled "payout" and the user PIN 1234, signing with sign
+func newToken(t *testing.T, publicKey []byte, sign func(digest []byte) []byte) func(stdin []byte, args ...string) ([]byte, error) {
+	return func(stdin []byte, args ...string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		if !strings.HasPrefix(command, "--module /usr/lib/softhsm/libsofthsm2.so --slot 0 --login --pin 1234 ") {
+			return nil, errors.New("CKR_PIN_INCORRECT")
+		}
+		if !strings.Contains(command, "--label payout ") {
+			return nil, errors.New("object not found")
+		}
+
+		switch {
+		case strings.HasSuffix(command, "--read-object --type pubkey"):
+			return publicKey, nil
+		case strings.Contains(command, "--sign"):
+			message, err := hex.DecodeString("03" + forgedOperation)
+			assert.Nil(t, err)
+			digest := blake2b.Sum256(message)
+			assert.Equal(t, digest[:], stdin, "the watermarked operation is hashed before it is signed")
+
+			return sign(stdin), nil
+		}
+
+		return nil, errors.New("unexpected command")
+	}
+}
+
+func Test_PKCS11_ed25519(t *testing.T) {
+	key := newKey(t)
+	publicKey, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: ed25519OID},
+		PublicKey: asn1.BitString{Bytes: key.PubKey.GetBytes(), BitLength: 256},
+	})
+	assert.Nil(t, err)
+
+	run := newToken(t, publicKey, func(digest []byte) []byte {
+		return ed25519.Sign(ed25519.PrivateKey(key.GetBytes()), digest)
+	})
+
+	p, err := newPKCS11(PKCS11Input{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: "0", PIN: "1234", Label: "payout"}, run)
+	assert.Nil(t, err)
+	assert.Equal(t, key.PubKey.GetPublicKeyHash(), p.Address())
+	assert.Equal(t, key.PubKey.GetPublicKey(), p.PublicKey())
+
+	sig, err := p.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+	want, err := key.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+	assert.Equal(t, want.ToBase58(), sig.ToBase58())
+}
+
+func Test_PKCS11_secp256k1(t *testing.T) {
+	publicKey, err := base64.StdEncoding.DecodeString(kmsPublicKey)
+	assert.Nil(t, err)
+
+	for _, signature := range []string{kmsSignature, kmsHighSignature} {
+		der, err := base64.StdEncoding.DecodeString(signature)
+		assert.Nil(t, err)
+		run := newToken(t, publicKey, func([]byte) []byte { return der })
+
+		p, err := newPKCS11(PKCS11Input{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: "0", PIN: "1234", Label: "payout"}, run)
+		assert.Nil(t, err)
+		assert.Equal(t, "tz2EgTtCdYoDiuJhxMLPBSgh3UsfSprudoiv", p.Address())
+		assert.Equal(t, "sppk7ZLpjkPij7x7HBV2zF1QFH8dgtcVSWCusu63VyUVG3wkgxDJdFZ", p.PublicKey())
+
+		sig, err := p.Sign(keys.SignInput{Message: forgedOperation})
+		assert.Nil(t, err)
+		assert.Equal(t, kmsRawSignature, hex.EncodeToString(sig.Bytes))
+	}
+}
+
+func Test_NewPKCS11(t *testing.T) {
+	run := newToken(t, nil, nil)
+
+	cases := []struct {
+		name     string
+		input    PKCS11Input
+		contains string
+	}{
+		{
+			"handles missing module",
+			PKCS11Input{Label: "payout"},
+			"missing module or key label",
+		},
+		{
+			"handles wrong PIN",
+			PKCS11Input{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: "0", PIN: "0000", Label: "payout"},
+			"CKR_PIN_INCORRECT",
+		},
+		{
+			"handles missing key",
+			PKCS11Input{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: "0", PIN: "1234", Label: "other"},
+			"failed to read public key 'other' from PKCS#11 token",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPKCS11(tt.input, run)
+			test.CheckErr(t, true, tt.contains, err)
+		})
+	}
+
+	_, err := NewPKCS11(PKCS11Input{Module: "/usr/lib/softhsm/libsofthsm2.so", Label: "payout", Tool: "/nonexistent/pkcs11-tool"})
+	test.CheckErr(t, true, "/nonexistent/pkcs11-tool failed", err)
+}
@@ -0,0 +1,144 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/pkg/errors"
+)
+
+// defaultTimeout bounds every request to a remote signer when no timeout is configured
+const defaultTimeout = 10 * time.Second
+
+// RemoteInput is the input for NewRemote
+type RemoteInput struct {
+	URL     string
+	Address string
+	Headers []string // "Name:Value", e.g. an authorization header expected by a proxy in front of the signer
+	Timeout time.Duration
+}
+
+/*
+Remote signs with a key held by a remote signer speaking the tezos-signer HTTP protocol, e.g. tezos-signer or signatory,
+so that the secret key never lives on the payout host.
+*/
+type Remote struct {
+	client    *http.Client
+	url       string
+	address   string
+	headers   http.Header
+	publicKey string
+}
+
+// NewRemote returns a new Remote, once the signer confirmed it holds the key of Address
+func NewRemote(input RemoteInput) (*Remote, error) {
+	timeout := input.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	headers := http.Header{}
+	for _, header := range input.Headers {
+		parts := strings.SplitN(header, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, errors.Errorf("invalid remote signer header '%s': expected 'Name:Value'", header)
+		}
+		headers.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+	}
+
+	remote := &Remote{
+		client:  &http.Client{Timeout: timeout},
+		url:     strings.TrimSuffix(input.URL, "/"),
+		address: input.Address,
+		headers: headers,
+	}
+
+	var resp struct {
+		PublicKey string `json:"public_key"`
+	}
+	if err := remote.do(http.MethodGet, nil, &resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to get key of '%s' from remote signer", input.Address)
+	}
+	remote.publicKey = resp.PublicKey
+
+	return remote, nil
+}
+
+// Address returns the address of the key held by the signer
+func (r *Remote) Address() string {
+	return r.address
+}
+
+// PublicKey returns the public key the signer returned for the address
+func (r *Remote) PublicKey() string {
+	return r.publicKey
+}
+
+// Sign asks the signer to sign a hex encoded message or bytes, prefixed with the operation watermark
+func (r *Remote) Sign(input keys.SignInput) (keys.Signature, error) {
+	message, err := watermarked(input)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with remote signer")
+	}
+
+	var resp struct {
+		Signature string `json:"signature"`
+	}
+	if err := r.do(http.MethodPost, hex.EncodeToString(message), &resp); err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with remote signer")
+	}
+
+	signature, err := decodeSignature(resp.Signature)
+	if err != nil {
+		return keys.Signature{}, errors.Wrap(err, "failed to sign with remote signer")
+	}
+
+	return keys.Signature{Bytes: signature, Prefix: genericSignaturePrefix}, nil
+}
+
+func (r *Remote) do(method string, body interface{}, v interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return errors.Wrap(err, "failed to construct request")
+		}
+	}
+
+	req, err := http.NewRequest(method, fmt.Sprintf("%s/keys/%s", r.url, r.address), bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "failed to construct request")
+	}
+	for name := range r.headers {
+		req.Header.Set(name, r.headers.Get(name))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to complete request")
+	}
+	defer resp.Body.Close()
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "could not read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response returned code %d with body %s", resp.StatusCode, string(byts))
+	}
+
+	if err := json.Unmarshal(byts, v); err != nil {
+		return errors.Wrap(err, "failed to parse response")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const forgedOperation = "a79ec80dba1f8ddb2cde90b8a7be5b32b3fcdf7ad8e4d0a4f6f3e0a7c6f0e1d26c0053d64eed2b6b9d6c7bd5ae3e8ea5e7e4d1c1b5a88c0d9601f4ce0380ea30e0d403c0843d0000b8ca987edb57a85de7dacd5fc8f643d2f4a0c2dad9699234dc52c1bd56004beb00"
+
+func newKey(t *testing.T) keys.Key {
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Esk:      "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2",
+		Password: "password12345##",
+		Kind:     keys.Ed25519,
+	})
+	assert.Nil(t, err)
+
+	return key
+}
+
+// newSigner serves the tezos-signer HTTP protocol for key, refusing requests without the authorization header
+func newSigner(t *testing.T, key keys.Key) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path != "/keys/"+key.PubKey.GetPublicKeyHash() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(map[string]string{"public_key": key.PubKey.GetPublicKey()})
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+
+		var message string
+		assert.Nil(t, json.Unmarshal(body, &message))
+		assert.Equal(t, "03", message[:2], "the operation is sent watermarked")
+
+		signature, err := key.Sign(keys.SignInput{Message: message})
+		assert.Nil(t, err)
+		json.NewEncoder(w).Encode(map[string]string{"signature": signature.ToBase58()})
+	}))
+}
+
+func Test_Remote(t *testing.T) {
+	key := newKey(t)
+	server := newSigner(t, key)
+	defer server.Close()
+
+	remote, err := NewRemote(RemoteInput{
+		URL:     server.URL + "/",
+		Address: key.PubKey.GetPublicKeyHash(),
+		Headers: []string{"Authorization: Bearer secret"},
+		Timeout: time.Second,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, key.PubKey.GetPublicKeyHash(), remote.Address())
+	assert.Equal(t, key.PubKey.GetPublicKey(), remote.PublicKey())
+
+	want, err := key.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+
+	signature, err := remote.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+	assert.Equal(t, want.Bytes, signature.Bytes)
+
+	bytes, err := hex.DecodeString(forgedOperation)
+	assert.Nil(t, err)
+	signature, err = remote.Sign(keys.SignInput{Bytes: bytes})
+	assert.Nil(t, err)
+	assert.Equal(t, want.Bytes, signature.Bytes)
+}
+
+func Test_NewRemote(t *testing.T) {
+	key := newKey(t)
+	server := newSigner(t, key)
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		input    RemoteInput
+		contains string
+	}{
+		{
+			"handles invalid header",
+			RemoteInput{URL: server.URL, Address: key.PubKey.GetPublicKeyHash(), Headers: []string{"Bearer secret"}},
+			"invalid remote signer header 'Bearer secret'",
+		},
+		{
+			"handles missing authorization",
+			RemoteInput{URL: server.URL, Address: key.PubKey.GetPublicKeyHash()},
+			"response returned code 401",
+		},
+		{
+			"handles unknown key",
+			RemoteInput{URL: server.URL, Address: "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", Headers: []string{"Authorization:Bearer secret"}},
+			"failed to get key of 'tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc' from remote signer",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRemote(tt.input)
+			test.CheckErr(t, true, tt.contains, err)
+		})
+	}
+}
+
+func Test_decodeSignature(t *testing.T) {
+	key := newKey(t)
+	signature, err := key.Sign(keys.SignInput{Message: forgedOperation})
+	assert.Nil(t, err)
+
+	decoded, err := decodeSignature(signature.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, signature.Bytes, decoded)
+
+	generic := keys.Signature{Bytes: signature.Bytes, Prefix: genericSignaturePrefix}
+	decoded, err = decodeSignature(generic.ToBase58())
+	assert.Nil(t, err)
+	assert.Equal(t, signature.Bytes, decoded)
+
+	_, err = decodeSignature("edsig")
+	test.CheckErr(t, true, "invalid signature", err)
+}
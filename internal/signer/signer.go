@@ -0,0 +1,52 @@
+package signer
+
+import (
+	"encoding/hex"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/base58check"
+	"github.com/pkg/errors"
+)
+
+// genericSignaturePrefix is the base58 prefix of a signature of any curve ("sig...")
+var genericSignaturePrefix = []byte{4, 130, 43}
+
+// operationWatermark is the magic byte prefixed to a forged operation before it is signed
+const operationWatermark = byte(3)
+
+// IFace signs operations for the payout wallet at Address with a key held outside of tzpay
+type IFace interface {
+	Address() string
+	Sign(input keys.SignInput) (keys.Signature, error)
+}
+
+// watermarked returns the bytes of the message to sign, prefixed with the operation watermark
+func watermarked(input keys.SignInput) ([]byte, error) {
+	message := input.Bytes
+	if message == nil {
+		var err error
+		if message, err = hex.DecodeString(input.Message); err != nil {
+			return nil, errors.Wrap(err, "failed to hex decode message")
+		}
+	}
+
+	if len(message) == 0 {
+		return nil, errors.New("missing Bytes or Message in input")
+	}
+
+	if message[0] != operationWatermark {
+		message = append([]byte{operationWatermark}, message...)
+	}
+
+	return message, nil
+}
+
+// decodeSignature returns the 64 bytes of a base58check encoded signature, whatever its curve
+func decodeSignature(signature string) ([]byte, error) {
+	payload, err := base58check.Decode(nil, signature)
+	if err != nil || len(payload) < 64 {
+		return nil, errors.Errorf("invalid signature '%s'", signature)
+	}
+
+	return payload[len(payload)-64:], nil
+}
@@ -0,0 +1,178 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+)
+
+// defaultBlockDelay estimates the time between blocks when the constants of the protocol do not give it
+const defaultBlockDelay = 30 * time.Second
+
+// Status is the public status of the payouts of a baker, to be hosted on its website
+type Status struct {
+	Baker           string     `json:"baker"`
+	Fee             float64    `json:"fee"`
+	MinimumPayment  int        `json:"minimum_payment"`
+	Delegators      int        `json:"delegators"`
+	LastPaidCycle   int        `json:"last_paid_cycle,omitempty"`
+	LastPaidTime    *time.Time `json:"last_paid_time,omitempty"`
+	NextPayoutCycle int        `json:"next_payout_cycle"`
+	NextPayoutETA   time.Time  `json:"next_payout_eta"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// GenerateInput is the input for Generate
+type GenerateInput struct {
+	RPC    rpc.IFace
+	Store  store.IFace // optional, the last paid cycle is left out without it
+	Config config.Config
+	Now    time.Time
+}
+
+/*
+Generate returns the status of the payouts of the baker in Config. The next payout is the one serv injects once the
+current cycle ends, estimated from the blocks left in the cycle, and the last paid cycle is the latest one recorded
+in the store.
+*/
+func Generate(input GenerateInput) (Status, error) {
+	status := Status{
+		Baker:          input.Config.Baker.Address,
+		Fee:            input.Config.Baker.Fee,
+		MinimumPayment: input.Config.Baker.MinimumPayment,
+		UpdatedAt:      input.Now.UTC(),
+	}
+
+	head, err := input.RPC.Head()
+	if err != nil {
+		return status, errors.Wrap(err, "failed to generate status")
+	}
+
+	constants, err := input.RPC.Constants(head.Hash)
+	if err != nil {
+		return status, errors.Wrap(err, "failed to generate status")
+	}
+
+	delegate, err := input.RPC.Delegate(head.Hash, status.Baker)
+	if err != nil {
+		return status, errors.Wrap(err, "failed to generate status")
+	}
+	for _, delegator := range delegate.DelegateContracts {
+		if delegator != status.Baker {
+			status.Delegators++
+		}
+	}
+
+	cycle := head.Metadata.Level.Cycle
+	status.NextPayoutCycle = cycle
+	if input.Config.Baker.PayoutWhenRewardsUnfrozen {
+		status.NextPayoutCycle = cycle + 1 - constants.PreservedCycles
+	}
+
+	left := constants.BlocksPerCycle - head.Metadata.Level.CyclePosition
+	if left < 0 {
+		left = 0
+	}
+	status.NextPayoutETA = input.Now.UTC().Add(time.Duration(left) * blockDelay(constants)).Truncate(time.Second)
+
+	if input.Store != nil {
+		records, err := payout.Records(input.Store, status.Baker)
+		if err != nil {
+			return status, errors.Wrap(err, "failed to generate status")
+		}
+
+		// Records are ordered oldest cycle first
+		if len(records) > 0 {
+			last := records[len(records)-1]
+			status.LastPaidCycle = last.Cycle
+			status.LastPaidTime = &last.Time
+		}
+	}
+
+	return status, nil
+}
+
+func blockDelay(constants rpc.Constants) time.Duration {
+	if len(constants.TimeBetweenBlocks) > 0 {
+		if seconds, err := strconv.Atoi(constants.TimeBetweenBlocks[0]); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	return defaultBlockDelay
+}
+
+var page = template.Must(template.New("status").Funcs(template.FuncMap{
+	"percent": func(fee float64) string { return strconv.FormatFloat(fee*100, 'f', -1, 64) },
+	"xtz":     func(mutez int) string { return strconv.FormatFloat(float64(mutez)/1000000, 'f', -1, 64) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Payouts of {{.Baker}}</title>
+</head>
+<body>
+<h1>Payouts of {{.Baker}}</h1>
+<table>
+<tr><th>Fee</th><td>{{percent .Fee}}%</td></tr>
+<tr><th>Minimum payment</th><td>{{xtz .MinimumPayment}} XTZ</td></tr>
+<tr><th>Delegators</th><td>{{.Delegators}}</td></tr>
+{{- if .LastPaidTime}}
+<tr><th>Last paid cycle</th><td>{{.LastPaidCycle}} ({{.LastPaidTime.UTC.Format "2006-01-02 15:04 MST"}})</td></tr>
+{{- end}}
+<tr><th>Next payout</th><td>cycle {{.NextPayoutCycle}}, around {{.NextPayoutETA.UTC.Format "2006-01-02 15:04 MST"}}</td></tr>
+</table>
+<p>Updated {{.UpdatedAt.UTC.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
+
+// HTML renders status as a standalone HTML page
+func HTML(status Status) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, status); err != nil {
+		return nil, errors.Wrap(err, "failed to render status page")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Write writes status to <baker>.json and <baker>.html in dir, replacing the files at once so a reader never sees them half written
+func Write(dir string, status Status) error {
+	js, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to write status")
+	}
+
+	html, err := HTML(status)
+	if err != nil {
+		return errors.Wrap(err, "failed to write status")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to write status")
+	}
+
+	for name, content := range map[string][]byte{status.Baker + ".json": js, status.Baker + ".html": html} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path+".tmp", content, 0644); err != nil {
+			return errors.Wrap(err, "failed to write status")
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return errors.Wrap(err, "failed to write status")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package status
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/payout"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const baker = "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+
+type statusRPCMock struct {
+	test.RPCMock
+	position int
+}
+
+func (s *statusRPCMock) Head() (*rpc.Block, error) {
+	head, err := s.RPCMock.Head()
+	if err != nil {
+		return head, err
+	}
+	head.Metadata.Level.CyclePosition = s.position
+
+	return head, nil
+}
+
+func (s *statusRPCMock) Constants(blockhash string) (rpc.Constants, error) {
+	constants, err := s.RPCMock.Constants(blockhash)
+	constants.BlocksPerCycle = 4096
+	constants.TimeBetweenBlocks = []string{"30"}
+
+	return constants, err
+}
+
+func (s *statusRPCMock) Delegate(blockhash, delegate string) (rpc.Delegate, error) {
+	d, err := s.RPCMock.Delegate(blockhash, delegate)
+	d.DelegateContracts = []string{baker, "tz1a", "KT1b"}
+
+	return d, err
+}
+
+func Test_Generate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-status")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	paid := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put("payouts/"+baker, "00000299/a", payout.Record{Cycle: 299, Time: paid.Add(-72 * time.Hour)}))
+	assert.Nil(t, s.Put("payouts/"+baker, "00000300/a", payout.Record{Cycle: 300, Time: paid}))
+
+	now := time.Date(2021, 3, 2, 12, 0, 0, 0, time.UTC)
+	cfg := config.Config{Baker: config.Baker{Address: baker, Fee: 0.05, MinimumPayment: 10000}}
+
+	cases := []struct {
+		name     string
+		rpc      rpc.IFace
+		store    store.IFace
+		unfrozen bool
+		want     Status
+		err      bool
+		contains string
+	}{
+		{
+			"is successful",
+			&statusRPCMock{RPCMock: test.RPCMock{HeadCycle: 301}, position: 4000},
+			s,
+			false,
+			Status{
+				Baker:           baker,
+				Fee:             0.05,
+				MinimumPayment:  10000,
+				Delegators:      2,
+				LastPaidCycle:   300,
+				LastPaidTime:    &paid,
+				NextPayoutCycle: 301,
+				NextPayoutETA:   now.Add(96 * 30 * time.Second),
+				UpdatedAt:       now,
+			},
+			false,
+			"",
+		},
+		{
+			"is successful with payouts of unfrozen rewards and no store",
+			&statusRPCMock{RPCMock: test.RPCMock{HeadCycle: 301}, position: 96},
+			nil,
+			true,
+			Status{
+				Baker:           baker,
+				Fee:             0.05,
+				MinimumPayment:  10000,
+				Delegators:      2,
+				NextPayoutCycle: 297,
+				NextPayoutETA:   now.Add(4000 * 30 * time.Second),
+				UpdatedAt:       now,
+			},
+			false,
+			"",
+		},
+		{
+			"handles failure to get delegate",
+			&statusRPCMock{RPCMock: test.RPCMock{DelegateErr: true}},
+			s,
+			false,
+			Status{Baker: baker, Fee: 0.05, MinimumPayment: 10000, UpdatedAt: now},
+			true,
+			"failed to get delegate",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			cfg.Baker.PayoutWhenRewardsUnfrozen = tt.unfrozen
+
+			status, err := Generate(GenerateInput{RPC: tt.rpc, Store: tt.store, Config: cfg, Now: now})
+			test.CheckErr(t, tt.err, tt.contains, err)
+			assert.Equal(t, tt.want, status)
+		})
+	}
+}
+
+func Test_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-status")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	paid := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	status := Status{
+		Baker:           baker,
+		Fee:             0.05,
+		MinimumPayment:  10000,
+		Delegators:      2,
+		LastPaidCycle:   300,
+		LastPaidTime:    &paid,
+		NextPayoutCycle: 301,
+		NextPayoutETA:   paid.Add(72 * time.Hour),
+		UpdatedAt:       paid,
+	}
+	assert.Nil(t, Write(filepath.Join(dir, "public"), status))
+
+	js, err := ioutil.ReadFile(filepath.Join(dir, "public", baker+".json"))
+	assert.Nil(t, err)
+	var written Status
+	assert.Nil(t, json.Unmarshal(js, &written))
+	assert.Equal(t, status, written)
+
+	html, err := ioutil.ReadFile(filepath.Join(dir, "public", baker+".html"))
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(html), "<td>5%</td>"))
+	assert.True(t, strings.Contains(string(html), "<td>0.01 XTZ</td>"))
+	assert.True(t, strings.Contains(string(html), "<td>300 (2021-03-01 12:00 UTC)</td>"))
+	assert.True(t, strings.Contains(string(html), "cycle 301, around 2021-03-04 12:00 UTC"))
+
+	_, err = os.Stat(filepath.Join(dir, "public", baker+".json.tmp"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BackupVersion is the version of the format of backups, which restore refuses if newer than it knows
+const BackupVersion = 1
+
+/*
+backup is the on disk format of a backup: every record of the store, whatever its backend, with the SHA-256 of the
+records encoded so that a backup corrupted or cut short is refused before anything is restored. The records of an
+encrypted store are encrypted with its passphrase, and the checksum is of the records before encryption.
+*/
+type backup struct {
+	Version    int             `json:"version"`
+	Created    time.Time       `json:"created"`
+	Count      int             `json:"count"`
+	Checksum   string          `json:"checksum"`
+	Cipher     string          `json:"cipher,omitempty"`
+	Salt       []byte          `json:"salt,omitempty"`
+	Records    json.RawMessage `json:"records,omitempty"`
+	Ciphertext []byte          `json:"ciphertext,omitempty"`
+}
+
+// BackupInfo describes a backup written or verified
+type BackupInfo struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Count    int       `json:"count"`
+	Checksum string    `json:"checksum"`
+}
+
+/*
+Backup writes every record of s to the file at path, encrypted with passphrase unless it is empty. The file is
+written to a temporary file first and renamed into place, so that an interrupted backup never leaves a partial file
+at path.
+*/
+func Backup(s IFace, path, passphrase string, now time.Time) (BackupInfo, error) {
+	records := []Record{}
+	if err := walk(s, func(record Record) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		return BackupInfo{}, errors.Wrap(err, "failed to back up store")
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return BackupInfo{}, errors.Wrap(err, "failed to back up store")
+	}
+	sum := sha256.Sum256(data)
+
+	b := backup{
+		Version:  BackupVersion,
+		Created:  now.UTC(),
+		Count:    len(records),
+		Checksum: hex.EncodeToString(sum[:]),
+		Records:  data,
+	}
+
+	if passphrase != "" {
+		b.Cipher = encryptionCipher
+		b.Salt = make([]byte, 16)
+		if _, err := rand.Read(b.Salt); err != nil {
+			return BackupInfo{}, errors.Wrap(err, "failed to generate salt")
+		}
+		if b.Ciphertext, err = seal(deriveSecret(passphrase, b.Salt), data); err != nil {
+			return BackupInfo{}, errors.Wrap(err, "failed to encrypt backup")
+		}
+		b.Records = nil
+	}
+
+	out, err := json.Marshal(b)
+	if err != nil {
+		return BackupInfo{}, errors.Wrap(err, "failed to back up store")
+	}
+
+	if err := writeFile(path, out); err != nil {
+		return BackupInfo{}, errors.Wrapf(err, "failed to write backup '%s'", path)
+	}
+
+	return b.info(), nil
+}
+
+/*
+VerifyBackup reads the backup at path and checks its version and checksum, and returns its records. A backup
+encrypted can only be read with the passphrase it was written with.
+*/
+func VerifyBackup(path, passphrase string) (BackupInfo, []Record, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return BackupInfo{}, nil, errors.Wrapf(err, "failed to read backup '%s'", path)
+	}
+
+	var b backup
+	if err := json.Unmarshal(data, &b); err != nil {
+		return BackupInfo{}, nil, errors.Wrapf(err, "failed to parse backup '%s'", path)
+	}
+
+	if b.Version < 1 || b.Version > BackupVersion {
+		return b.info(), nil, errors.Errorf("failed to read backup '%s': unsupported version %d, expected at most %d", path, b.Version, BackupVersion)
+	}
+
+	records := []byte(b.Records)
+	if b.Cipher != "" {
+		if passphrase == "" {
+			return b.info(), nil, errors.Errorf("failed to read backup '%s': backup is encrypted but no key is configured", path)
+		}
+		if b.Cipher != encryptionCipher {
+			return b.info(), nil, errors.Errorf("failed to read backup '%s': unsupported cipher '%s'", path, b.Cipher)
+		}
+		if records, err = unseal(deriveSecret(passphrase, b.Salt), b.Ciphertext); err != nil {
+			return b.info(), nil, errors.Wrapf(err, "failed to decrypt backup '%s'", path)
+		}
+	}
+
+	sum := sha256.Sum256(records)
+	if checksum := hex.EncodeToString(sum[:]); checksum != b.Checksum {
+		return b.info(), nil, errors.Errorf("failed to verify backup '%s': checksum %s does not match %s", path, checksum, b.Checksum)
+	}
+
+	var decoded []Record
+	if err := json.Unmarshal(records, &decoded); err != nil {
+		return b.info(), nil, errors.Wrapf(err, "failed to parse records of backup '%s'", path)
+	}
+	if len(decoded) != b.Count {
+		return b.info(), nil, errors.Errorf("failed to verify backup '%s': %d records, expected %d", path, len(decoded), b.Count)
+	}
+
+	return b.info(), decoded, nil
+}
+
+/*
+Restore replaces the store at path, of the backend its extension names, with the records of the backup at
+backupPath once verified. The store replaced is first backed up to keep, unless keep is empty. The records are
+written to a new store next to path, which is then renamed over path, so that the store is either left as it was or
+fully restored. The store must not be open, e.g. by 'tzpay serv'.
+*/
+func Restore(path, passphrase, backupPath, keep string, now time.Time) (BackupInfo, error) {
+	info, records, err := VerifyBackup(backupPath, passphrase)
+	if err != nil {
+		return info, err
+	}
+
+	if _, err := os.Stat(path); err == nil && keep != "" {
+		current, err := openPath(path, passphrase)
+		if err != nil {
+			return info, errors.Wrap(err, "failed to back up store before restoring")
+		}
+		_, err = Backup(current, keep, passphrase, now)
+		closePath(current)
+		if err != nil {
+			return info, errors.Wrap(err, "failed to back up store before restoring")
+		}
+	}
+
+	// the temporary store keeps the extension of path, so that it is of the same backend
+	tmp := filepath.Join(filepath.Dir(path), ".restore-"+filepath.Base(path))
+	removeStore(tmp)
+	defer removeStore(tmp)
+
+	s, err := openPath(tmp, passphrase)
+	if err != nil {
+		return info, errors.Wrap(err, "failed to restore store")
+	}
+
+	for _, record := range records {
+		if err = s.Put(record.Bucket, record.Key, record.Value); err != nil {
+			break
+		}
+	}
+	if cerr := closePath(s); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return info, errors.Wrap(err, "failed to restore store")
+	}
+
+	// a JSON store is only written once it holds a record, and an empty backup restores an empty store
+	if _, err := os.Stat(tmp); os.IsNotExist(err) {
+		removeStore(path)
+		return info, nil
+	}
+
+	// a write ahead log left by the store replaced would otherwise be replayed into the store restored
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	if err := os.Rename(tmp, path); err != nil {
+		return info, errors.Wrap(err, "failed to restore store")
+	}
+
+	return info, nil
+}
+
+func (b backup) info() BackupInfo {
+	return BackupInfo{Version: b.Version, Created: b.Created, Count: b.Count, Checksum: b.Checksum}
+}
+
+// removeStore removes the file of a store and those SQLite keeps beside it
+func removeStore(path string) {
+	os.Remove(path)
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+	os.Remove(path + "-journal")
+}
+
+// writeFile writes data to path atomically, through a temporary file synced before it is renamed into place
+func writeFile(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BackupRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	for _, passphrase := range []string{"", "some_passphrase"} {
+		src, err := openPath(filepath.Join(dir, passphrase+"src.json"), passphrase)
+		assert.Nil(t, err)
+		assert.Nil(t, src.Put("paid/tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "00000300/tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", Paid{Amount: 100, Time: now}))
+		assert.Nil(t, src.Put("ledger", "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", 200))
+
+		backupPath := filepath.Join(dir, passphrase+"tzpay.backup")
+		info, err := Backup(src, backupPath, passphrase, now)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, info.Count)
+		assert.Equal(t, BackupVersion, info.Version)
+
+		data, err := ioutil.ReadFile(backupPath)
+		assert.Nil(t, err)
+		if passphrase != "" {
+			assert.NotContains(t, string(data), "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV")
+		}
+
+		for _, name := range []string{"dst.json", "dst.db", "dst.bolt"} {
+			path := filepath.Join(dir, passphrase+name)
+			dst, err := openPath(path, passphrase)
+			assert.Nil(t, err)
+			assert.Nil(t, dst.Put("stale", "key", 1))
+			assert.Nil(t, closePath(dst))
+
+			keep := path + ".before-restore"
+			restored, err := Restore(path, passphrase, backupPath, keep, now)
+			assert.Nil(t, err, name)
+			assert.Equal(t, info, restored)
+
+			dst, err = openPath(path, passphrase)
+			assert.Nil(t, err)
+			buckets, err := dst.Buckets()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"ledger", "paid/tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"}, buckets)
+
+			var paid Paid
+			ok, err := dst.Get("paid/tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "00000300/tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", &paid)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, 100, paid.Amount)
+			assert.Nil(t, closePath(dst))
+
+			kept, records, err := VerifyBackup(keep, passphrase)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, kept.Count)
+			assert.Equal(t, "stale", records[0].Bucket)
+		}
+	}
+}
+
+func Test_VerifyBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	src, err := openPath(filepath.Join(dir, "src.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, src.Put("ledger", "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", 200))
+
+	valid := filepath.Join(dir, "valid.backup")
+	_, err = Backup(src, valid, "", now)
+	assert.Nil(t, err)
+	encrypted := filepath.Join(dir, "encrypted.backup")
+	_, err = Backup(src, encrypted, "some_passphrase", now)
+	assert.Nil(t, err)
+
+	tamper := func(name string, fn func(b *backup)) string {
+		data, err := ioutil.ReadFile(valid)
+		assert.Nil(t, err)
+		var b backup
+		assert.Nil(t, json.Unmarshal(data, &b))
+		fn(&b)
+		data, err = json.Marshal(b)
+		assert.Nil(t, err)
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, data, 0600))
+		return path
+	}
+
+	cases := []struct {
+		name       string
+		path       string
+		passphrase string
+		wantErr    bool
+		contains   string
+	}{
+		{
+			"is successful",
+			valid,
+			"",
+			false,
+			"",
+		},
+		{
+			"is successful with encrypted backup",
+			encrypted,
+			"some_passphrase",
+			false,
+			"",
+		},
+		{
+			"handles missing key",
+			encrypted,
+			"",
+			true,
+			"backup is encrypted but no key is configured",
+		},
+		{
+			"handles invalid key",
+			encrypted,
+			"wrong_passphrase",
+			true,
+			"invalid key",
+		},
+		{
+			"handles corrupted records",
+			tamper("corrupted.backup", func(b *backup) {
+				b.Records = json.RawMessage(`[{"bucket":"ledger","key":"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV","value":300}]`)
+			}),
+			"",
+			true,
+			"does not match",
+		},
+		{
+			"handles newer version",
+			tamper("newer.backup", func(b *backup) { b.Version = BackupVersion + 1 }),
+			"",
+			true,
+			"unsupported version",
+		},
+		{
+			"handles missing backup",
+			filepath.Join(dir, "missing.backup"),
+			"",
+			true,
+			"failed to read backup",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			info, records, err := VerifyBackup(tt.path, tt.passphrase)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, 1, info.Count)
+			assert.Len(t, records, 1)
+		})
+	}
+
+	path := filepath.Join(dir, "dst.json")
+	dst, err := openPath(path, "")
+	assert.Nil(t, err)
+	assert.Nil(t, dst.Put("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", 100))
+	_, err = Restore(path, "", filepath.Join(dir, "corrupted.backup"), "", now)
+	test.CheckErr(t, true, "does not match", err)
+
+	dst, err = openPath(path, "")
+	assert.Nil(t, err)
+	keys, err := dst.Keys("ledger")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"}, keys)
+}
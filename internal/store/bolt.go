@@ -0,0 +1,457 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltExtensions are the extensions of a store path that open a bbolt store
+var boltExtensions = []string{".bolt", ".bbolt"}
+
+// Root buckets of a bbolt store: the buckets of the store are nested in boltData, the salt of an encrypted store is kept in boltMeta
+var (
+	boltData = []byte("buckets")
+	boltMeta = []byte("meta")
+	boltSalt = []byte("salt")
+)
+
+/*
+Bolt is a persistent key value store backed by a bbolt database, an embedded B+tree in a single file that needs no
+database server, for single binary deployments. Buckets of the store are bbolt buckets, and every write is a
+transaction of its own. If a passphrase is set, values are encrypted at rest with AES-256-GCM using a key derived
+from the passphrase, while buckets and keys are left in the clear to be looked up.
+*/
+type Bolt struct {
+	path   string
+	mu     sync.RWMutex // guards db, which Compact replaces
+	db     *bolt.DB
+	secret []byte
+}
+
+// Compacter is implemented by stores whose file can be rewritten to reclaim the space freed by deleted values
+type Compacter interface {
+	// Compact rewrites the store and returns its size in bytes before and after
+	Compact() (int64, int64, error)
+}
+
+// isBolt reports whether path names a bbolt store
+func isBolt(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, boltExt := range boltExtensions {
+		if ext == boltExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+func openBolt(path, passphrase string) (*Bolt, error) {
+	db, err := openBoltDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Bolt{path: path, db: db}
+
+	var salt []byte
+	var empty bool
+	err = db.View(func(tx *bolt.Tx) error {
+		if stored := tx.Bucket(boltMeta).Get(boltSalt); stored != nil {
+			salt = append([]byte{}, stored...)
+		}
+		first, _ := tx.Bucket(boltData).Cursor().First()
+		empty = first == nil
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to read store '%s'", path)
+	}
+
+	if salt != nil {
+		if passphrase == "" {
+			db.Close()
+			return nil, errors.Errorf("failed to read store '%s': store is encrypted but no key is configured", path)
+		}
+
+		s.secret = deriveSecret(passphrase, salt)
+		if err := s.checkKey(); err != nil {
+			db.Close()
+			return nil, errors.Wrapf(err, "failed to decrypt store '%s'", path)
+		}
+	} else if passphrase != "" {
+		if !empty {
+			db.Close()
+			return nil, errors.Errorf("failed to read store '%s': store is not encrypted, migrate it to a new store to encrypt it", path)
+		}
+
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			db.Close()
+			return nil, errors.Wrap(err, "failed to generate salt")
+		}
+		err := db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(boltMeta).Put(boltSalt, salt)
+		})
+		if err != nil {
+			db.Close()
+			return nil, errors.Wrapf(err, "failed to write store '%s'", path)
+		}
+		s.secret = deriveSecret(passphrase, salt)
+	}
+
+	return s, nil
+}
+
+// openBoltDB opens the bbolt database at path with its root buckets
+func openBoltDB(path string) (*bolt.DB, error) {
+	// tzpay serv holds the store open, so another process fails rather than waiting on its lock forever
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open store '%s'", path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltData); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltMeta)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to read store '%s'", path)
+	}
+
+	return db, nil
+}
+
+// checkKey tries the secret on a value of the store, if any, so that a wrong key fails when the store is opened
+func (s *Bolt) checkKey() error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		var value []byte
+		c := tx.Bucket(boltData).Cursor()
+		for name, v := c.First(); name != nil && value == nil; name, v = c.Next() {
+			if v == nil {
+				_, value = tx.Bucket(boltData).Bucket(name).Cursor().First()
+			}
+		}
+		if value == nil {
+			return nil
+		}
+
+		_, err := unseal(s.secret, value)
+		return err
+	})
+}
+
+// Get decodes the value found at key in bucket into v and reports whether it existed
+func (s *Bolt) Get(bucket, key string, v interface{}) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var value []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(boltData).Bucket([]byte(bucket)); b != nil {
+			if stored := b.Get([]byte(key)); stored != nil {
+				value = append([]byte{}, stored...)
+			}
+		}
+		return nil
+	})
+	if value == nil {
+		return false, nil
+	}
+
+	raw, err := unseal(s.secret, value)
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to decrypt '%s' in bucket '%s'", key, bucket)
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, errors.Wrapf(err, "failed to decode '%s' in bucket '%s'", key, bucket)
+	}
+
+	return true, nil
+}
+
+// Put encodes v and stores it at key in bucket
+func (s *Bolt) Put(bucket, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode '%s' in bucket '%s'", key, bucket)
+	}
+
+	value, err := seal(s.secret, raw)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt '%s' in bucket '%s'", key, bucket)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(boltData).CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to write '%s' in bucket '%s'", key, bucket)
+	}
+
+	return nil
+}
+
+// Delete removes key from bucket, and bucket once it is empty
+func (s *Bolt) Delete(bucket, key string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltData).Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		if err := b.Delete([]byte(key)); err != nil {
+			return err
+		}
+		if first, _ := b.Cursor().First(); first == nil {
+			return tx.Bucket(boltData).DeleteBucket([]byte(bucket))
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete '%s' in bucket '%s'", key, bucket)
+	}
+
+	return nil
+}
+
+// Keys returns the sorted keys found in bucket
+func (s *Bolt) Keys(bucket string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []string{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltData).Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(key, _ []byte) error {
+			keys = append(keys, string(key))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read store")
+	}
+
+	return keys, nil
+}
+
+// Buckets returns the sorted names of the buckets in the store
+func (s *Bolt) Buckets() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	buckets := []string{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltData).ForEach(func(name, _ []byte) error {
+			buckets = append(buckets, string(name))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read store")
+	}
+
+	return buckets, nil
+}
+
+// cycleKeys returns the sorted keys of cycle in bucket, seeking to the cycle rather than listing the bucket
+func (s *Bolt) cycleKeys(bucket string, cycle int) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefix := []byte(cyclePrefix(cycle))
+	keys := []string{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(boltData).Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for key, _ := c.Seek(prefix); key != nil && strings.HasPrefix(string(key), string(prefix)); key, _ = c.Next() {
+			keys = append(keys, string(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read store")
+	}
+
+	return keys, nil
+}
+
+// SavePayout records an injected payout. A cycle paid again keeps every payout recorded for it.
+func (s *Bolt) SavePayout(baker string, payout Payout) error {
+	return savePayout(s, baker, payout)
+}
+
+// GetPayout returns the payouts recorded for cycle, oldest first
+func (s *Bolt) GetPayout(baker string, cycle int) ([]Payout, error) {
+	keys, err := s.cycleKeys(PayoutsBucket(baker), cycle)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get payouts of cycle %d", cycle)
+	}
+
+	return getPayouts(s, baker, keys)
+}
+
+// ListPayouts returns every payout recorded, oldest cycle first
+func (s *Bolt) ListPayouts(baker string) ([]Payout, error) {
+	keys, err := s.Keys(PayoutsBucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list payouts")
+	}
+
+	return getPayouts(s, baker, keys)
+}
+
+// MarkPaid records address as paid for cycle
+func (s *Bolt) MarkPaid(baker string, cycle int, address string, paid Paid) error {
+	return markPaid(s, baker, cycle, address, paid)
+}
+
+// UnmarkPaid removes the record that address was paid for cycle
+func (s *Bolt) UnmarkPaid(baker string, cycle int, address string) error {
+	return unmarkPaid(s, baker, cycle, address)
+}
+
+// IsPaid reports whether address was paid for cycle
+func (s *Bolt) IsPaid(baker string, cycle int, address string) (bool, error) {
+	return isPaid(s, baker, cycle, address)
+}
+
+// ListUnpaid returns the addresses not paid for cycle, in order
+func (s *Bolt) ListUnpaid(baker string, cycle int, addresses []string) ([]string, error) {
+	keys, err := s.cycleKeys(PaidBucket(baker), cycle)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list delegators not paid for cycle %d", cycle)
+	}
+
+	return unpaid(keys, cycle, addresses), nil
+}
+
+// Backup writes a consistent copy of the store to w while it stays open for writes, and returns the bytes written
+func (s *Bolt) Backup(w io.Writer) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var written int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		written, err = tx.WriteTo(w)
+		return err
+	})
+	if err != nil {
+		return written, errors.Wrapf(err, "failed to back up store '%s'", s.path)
+	}
+
+	return written, nil
+}
+
+/*
+Compact rewrites the store to a new file holding only the values it still has, then replaces the store with it.
+bbolt never shrinks its file by itself, so the space of the values deleted, e.g. the carried rewards paid out of the
+accumulation ledger, is only reclaimed by compacting.
+*/
+func (s *Bolt) Compact() (int64, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before, err := fileSize(s.path)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	tmp := s.path + ".compact"
+	os.Remove(tmp)
+	dst, err := openBoltDB(tmp)
+	if err != nil {
+		return before, before, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	err = s.db.View(func(src *bolt.Tx) error {
+		return dst.Update(func(tx *bolt.Tx) error {
+			return src.ForEach(func(name []byte, b *bolt.Bucket) error {
+				return copyBoltBucket(tx.Bucket(name), b)
+			})
+		})
+	})
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return before, before, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	if err := s.db.Close(); err != nil {
+		os.Remove(tmp)
+		return before, before, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	renameErr := os.Rename(tmp, s.path)
+	if s.db, err = openBoltDB(s.path); err != nil {
+		return before, before, errors.Wrapf(err, "failed to reopen store '%s' after compacting", s.path)
+	}
+	if renameErr != nil {
+		os.Remove(tmp)
+		return before, before, errors.Wrapf(renameErr, "failed to compact store '%s'", s.path)
+	}
+
+	after, err := fileSize(s.path)
+	if err != nil {
+		return before, 0, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	return before, after, nil
+}
+
+// copyBoltBucket copies every value and nested bucket of src into dst
+func copyBoltBucket(dst, src *bolt.Bucket) error {
+	return src.ForEach(func(key, value []byte) error {
+		if value != nil {
+			return dst.Put(key, value)
+		}
+
+		nested, err := dst.CreateBucketIfNotExists(key)
+		if err != nil {
+			return err
+		}
+		return copyBoltBucket(nested, src.Bucket(key))
+	})
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bolt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.bolt")
+	s, err := openBolt(path, "")
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Put("bucket", "b", 2))
+	assert.Nil(t, s.Put("bucket", "a", 1))
+	assert.Nil(t, s.Put("bucket", "a", 3))
+	assert.Nil(t, s.Put("other", "c", 4))
+
+	var v int
+	ok, err := s.Get("bucket", "a", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	ok, err = s.Get("bucket", "missing", &v)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	keys, err := s.Keys("bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	buckets, err := s.Buckets()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket", "other"}, buckets)
+
+	assert.Nil(t, s.Delete("bucket", "a"))
+	assert.Nil(t, s.Delete("bucket", "missing"))
+	assert.Nil(t, s.Delete("other", "c"))
+	keys, err = s.Keys("bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, keys)
+
+	buckets, err = s.Buckets()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket"}, buckets)
+	assert.Nil(t, s.db.Close())
+
+	reopened, err := openBolt(path, "")
+	assert.Nil(t, err)
+	defer reopened.db.Close()
+	ok, err = reopened.Get("bucket", "b", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+}
+
+func Test_BoltEncryption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.bolt")
+	encrypted, err := openBolt(path, "some_passphrase")
+	assert.Nil(t, err)
+	assert.Nil(t, encrypted.Put("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "secret_value"))
+	assert.Nil(t, encrypted.db.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "secret_value")
+
+	plainPath := filepath.Join(dir, "plain.bolt")
+	plain, err := openBolt(plainPath, "")
+	assert.Nil(t, err)
+	assert.Nil(t, plain.Put("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", 100))
+	assert.Nil(t, plain.db.Close())
+
+	cases := []struct {
+		name       string
+		path       string
+		passphrase string
+		wantErr    bool
+		contains   string
+	}{
+		{
+			"is successful",
+			path,
+			"some_passphrase",
+			false,
+			"",
+		},
+		{
+			"handles missing key",
+			path,
+			"",
+			true,
+			"store is encrypted but no key is configured",
+		},
+		{
+			"handles invalid key",
+			path,
+			"wrong_passphrase",
+			true,
+			"invalid key",
+		},
+		{
+			"handles plain text store",
+			plainPath,
+			"some_passphrase",
+			true,
+			"store is not encrypted",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openBolt(tt.path, tt.passphrase)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+			defer s.db.Close()
+
+			var v string
+			ok, err := s.Get("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", &v)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, "secret_value", v)
+		})
+	}
+}
+
+func Test_BoltCompactAndBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.bolt")
+	s, err := openBolt(path, "")
+	assert.Nil(t, err)
+	defer s.db.Close()
+
+	value := bytes.Repeat([]byte("x"), 1024)
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, s.Put("ledger", fmt.Sprintf("%04d", i), value))
+	}
+	for i := 0; i < 990; i++ {
+		assert.Nil(t, s.Delete("ledger", fmt.Sprintf("%04d", i)))
+	}
+
+	before, after, err := s.Compact()
+	assert.Nil(t, err)
+	assert.True(t, after < before)
+
+	keys, err := s.Keys("ledger")
+	assert.Nil(t, err)
+	assert.Len(t, keys, 10)
+	assert.Nil(t, s.Put("ledger", "new", 1))
+
+	var backup bytes.Buffer
+	written, err := s.Backup(&backup)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(backup.Len()), written)
+
+	backupPath := filepath.Join(dir, "backup.bolt")
+	assert.Nil(t, ioutil.WriteFile(backupPath, backup.Bytes(), 0600))
+	restored, err := openBolt(backupPath, "")
+	assert.Nil(t, err)
+	defer restored.db.Close()
+
+	keys, err = restored.Keys("ledger")
+	assert.Nil(t, err)
+	assert.Len(t, keys, 11)
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Payout is the record of an injected payout, with the price of XTZ when it was made if a currency is configured
+type Payout struct {
+	Cycle      int       `json:"cycle"`
+	Time       time.Time `json:"time"`
+	Operations []string  `json:"operations"`
+	Amount     int64     `json:"amount,omitempty"`
+	Fees       int64     `json:"fees,omitempty"`
+	Currency   string    `json:"currency,omitempty"`
+	Rate       float64   `json:"rate,omitempty"`
+	Memo       string    `json:"memo,omitempty"`
+	MemoHash   string    `json:"memo_hash,omitempty"`
+	Partial    bool      `json:"partial,omitempty"`
+}
+
+// Paid is the record that a delegator, or a liquidity provider of a contract, was paid for a cycle
+type Paid struct {
+	Amount int       `json:"amount"`
+	Time   time.Time `json:"time"`
+}
+
+/*
+Payouts is the interface for persisting the payouts of a baker and the delegators they paid, so that the payout
+engine does not depend on how the store lays them out. NewPayouts returns the implementation of a store.
+*/
+type Payouts interface {
+	// SavePayout records an injected payout. A cycle paid again keeps every payout recorded for it.
+	SavePayout(baker string, payout Payout) error
+	// GetPayout returns the payouts recorded for cycle, oldest first
+	GetPayout(baker string, cycle int) ([]Payout, error)
+	// ListPayouts returns every payout recorded, oldest cycle first
+	ListPayouts(baker string) ([]Payout, error)
+	// MarkPaid records address as paid for cycle
+	MarkPaid(baker string, cycle int, address string, paid Paid) error
+	// UnmarkPaid removes the record that address was paid for cycle
+	UnmarkPaid(baker string, cycle int, address string) error
+	// IsPaid reports whether address was paid for cycle
+	IsPaid(baker string, cycle int, address string) (bool, error)
+	// ListUnpaid returns the addresses not paid for cycle, in order
+	ListUnpaid(baker string, cycle int, addresses []string) ([]string, error)
+}
+
+// PayoutsBucket is the bucket the payouts of baker are recorded in
+func PayoutsBucket(baker string) string {
+	return "payouts/" + baker
+}
+
+// PaidBucket is the bucket the delegators paid by baker are recorded in
+func PaidBucket(baker string) string {
+	return "paid/" + baker
+}
+
+// PaidKey is the key of address in the buckets recording what was paid per cycle
+func PaidKey(cycle int, address string) string {
+	return fmt.Sprintf("%08d/%s", cycle, address)
+}
+
+// cyclePrefix is the prefix of the keys of cycle in the buckets of payouts and delegators paid
+func cyclePrefix(cycle int) string {
+	return fmt.Sprintf("%08d/", cycle)
+}
+
+// NewPayouts returns the payouts persisted in s: the store's own implementation if it has one, or else one over its buckets
+func NewPayouts(s IFace) Payouts {
+	if payouts, ok := s.(Payouts); ok {
+		return payouts
+	}
+
+	return &kvPayouts{store: s}
+}
+
+// kvPayouts keeps payouts in the buckets of any store, e.g. the JSON file of Store
+type kvPayouts struct {
+	store IFace
+}
+
+func (k *kvPayouts) SavePayout(baker string, payout Payout) error {
+	return savePayout(k.store, baker, payout)
+}
+
+func (k *kvPayouts) GetPayout(baker string, cycle int) ([]Payout, error) {
+	keys, err := k.store.Keys(PayoutsBucket(baker))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get payouts of cycle %d", cycle)
+	}
+
+	return getPayouts(k.store, baker, filterPrefix(keys, cyclePrefix(cycle)))
+}
+
+func (k *kvPayouts) ListPayouts(baker string) ([]Payout, error) {
+	keys, err := k.store.Keys(PayoutsBucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list payouts")
+	}
+
+	return getPayouts(k.store, baker, keys)
+}
+
+func (k *kvPayouts) MarkPaid(baker string, cycle int, address string, paid Paid) error {
+	return markPaid(k.store, baker, cycle, address, paid)
+}
+
+func (k *kvPayouts) UnmarkPaid(baker string, cycle int, address string) error {
+	return unmarkPaid(k.store, baker, cycle, address)
+}
+
+func (k *kvPayouts) IsPaid(baker string, cycle int, address string) (bool, error) {
+	return isPaid(k.store, baker, cycle, address)
+}
+
+func (k *kvPayouts) ListUnpaid(baker string, cycle int, addresses []string) ([]string, error) {
+	keys, err := k.store.Keys(PaidBucket(baker))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list delegators not paid for cycle %d", cycle)
+	}
+
+	return unpaid(filterPrefix(keys, cyclePrefix(cycle)), cycle, addresses), nil
+}
+
+func savePayout(s IFace, baker string, payout Payout) error {
+	key := cyclePrefix(payout.Cycle) + payout.Time.UTC().Format(time.RFC3339Nano)
+	if err := s.Put(PayoutsBucket(baker), key, payout); err != nil {
+		return errors.Wrapf(err, "failed to save payout of cycle %d", payout.Cycle)
+	}
+
+	return nil
+}
+
+func getPayouts(s IFace, baker string, keys []string) ([]Payout, error) {
+	payouts := []Payout{}
+	for _, key := range keys {
+		var payout Payout
+		if _, err := s.Get(PayoutsBucket(baker), key, &payout); err != nil {
+			return nil, errors.Wrap(err, "failed to get payout")
+		}
+		payouts = append(payouts, payout)
+	}
+
+	return payouts, nil
+}
+
+func markPaid(s IFace, baker string, cycle int, address string, paid Paid) error {
+	if err := s.Put(PaidBucket(baker), PaidKey(cycle, address), paid); err != nil {
+		return errors.Wrapf(err, "failed to record '%s' as paid for cycle %d", address, cycle)
+	}
+
+	return nil
+}
+
+func unmarkPaid(s IFace, baker string, cycle int, address string) error {
+	if err := s.Delete(PaidBucket(baker), PaidKey(cycle, address)); err != nil {
+		return errors.Wrapf(err, "failed to clear paid record of '%s' for cycle %d", address, cycle)
+	}
+
+	return nil
+}
+
+func isPaid(s IFace, baker string, cycle int, address string) (bool, error) {
+	var paid Paid
+	ok, err := s.Get(PaidBucket(baker), PaidKey(cycle, address), &paid)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to check if '%s' was paid for cycle %d", address, cycle)
+	}
+
+	return ok, nil
+}
+
+// unpaid returns the addresses whose key is not among the keys paid for cycle
+func unpaid(paidKeys []string, cycle int, addresses []string) []string {
+	paid := map[string]bool{}
+	for _, key := range paidKeys {
+		paid[key] = true
+	}
+
+	unpaid := []string{}
+	for _, address := range addresses {
+		if !paid[PaidKey(cycle, address)] {
+			unpaid = append(unpaid, address)
+		}
+	}
+
+	return unpaid
+}
+
+func filterPrefix(keys []string, prefix string) []string {
+	filtered := []string{}
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			filtered = append(filtered, key)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Payouts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	baker := "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+	paidAt := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		path string
+		want interface{}
+	}{
+		{
+			"is successful with file",
+			filepath.Join(dir, "tzpay.json"),
+			&kvPayouts{},
+		},
+		{
+			"is successful with SQLite",
+			filepath.Join(dir, "tzpay.db"),
+			&SQLite{},
+		},
+		{
+			"is successful with bbolt",
+			filepath.Join(dir, "tzpay.bolt"),
+			&Bolt{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.path, "")
+			assert.Nil(t, err)
+
+			payouts := NewPayouts(s)
+			assert.IsType(t, tt.want, payouts)
+
+			assert.Nil(t, payouts.SavePayout(baker, Payout{Cycle: 301, Time: paidAt, Operations: []string{"oo2"}, Amount: 20}))
+			assert.Nil(t, payouts.SavePayout(baker, Payout{Cycle: 300, Time: paidAt, Operations: []string{"oo1"}, Amount: 10}))
+			assert.Nil(t, payouts.SavePayout(baker, Payout{Cycle: 300, Time: paidAt.Add(time.Hour), Operations: []string{"oo3"}, Amount: 5}))
+
+			got, err := payouts.GetPayout(baker, 300)
+			assert.Nil(t, err)
+			assert.Equal(t, []Payout{
+				{Cycle: 300, Time: paidAt, Operations: []string{"oo1"}, Amount: 10},
+				{Cycle: 300, Time: paidAt.Add(time.Hour), Operations: []string{"oo3"}, Amount: 5},
+			}, got)
+
+			got, err = payouts.GetPayout(baker, 302)
+			assert.Nil(t, err)
+			assert.Empty(t, got)
+
+			got, err = payouts.ListPayouts(baker)
+			assert.Nil(t, err)
+			assert.Len(t, got, 3)
+			assert.Equal(t, 301, got[2].Cycle)
+
+			assert.Nil(t, payouts.MarkPaid(baker, 300, "tz1a", Paid{Amount: 7, Time: paidAt}))
+			assert.Nil(t, payouts.MarkPaid(baker, 300, "tz1c", Paid{Amount: 3, Time: paidAt}))
+			assert.Nil(t, payouts.MarkPaid(baker, 301, "tz1b", Paid{Amount: 3, Time: paidAt}))
+
+			paid, err := payouts.IsPaid(baker, 300, "tz1a")
+			assert.Nil(t, err)
+			assert.True(t, paid)
+
+			paid, err = payouts.IsPaid(baker, 301, "tz1a")
+			assert.Nil(t, err)
+			assert.False(t, paid)
+
+			unpaid, err := payouts.ListUnpaid(baker, 300, []string{"tz1a", "tz1b", "tz1c", "tz1d"})
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"tz1b", "tz1d"}, unpaid)
+
+			assert.Nil(t, payouts.UnmarkPaid(baker, 300, "tz1a"))
+			unpaid, err = payouts.ListUnpaid(baker, 300, []string{"tz1a", "tz1b", "tz1c"})
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"tz1a", "tz1b"}, unpaid)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package store
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	// registers the sqlite3 driver with database/sql
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// sqliteExtensions are the extensions of a store path that open a SQLite store instead of a JSON file
+var sqliteExtensions = []string{".db", ".sqlite", ".sqlite3"}
+
+/*
+sqliteSchema keeps every bucket in a single table, and exposes the payouts recorded, the delegators paid and the
+chunks of partial payouts as views, so that the history of the payouts can be queried with SQL, e.g. by
+'sqlite3 tzpay.db "SELECT * FROM payouts"'. Views only show the values of a store that is not encrypted.
+*/
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	bucket TEXT NOT NULL,
+	key    TEXT NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+);
+CREATE TABLE IF NOT EXISTS meta (
+	name  TEXT PRIMARY KEY,
+	value BLOB NOT NULL
+);
+CREATE VIEW IF NOT EXISTS payouts AS
+	SELECT substr(bucket, length('payouts/') + 1) AS baker,
+		CAST(substr(key, 1, 8) AS INTEGER) AS cycle,
+		json_extract(value, '$.time') AS time,
+		json_extract(value, '$.amount') AS amount,
+		json_extract(value, '$.fees') AS fees,
+		json_extract(value, '$.operations') AS operations
+	FROM entries WHERE bucket LIKE 'payouts/%' AND json_valid(value);
+CREATE VIEW IF NOT EXISTS paid AS
+	SELECT substr(bucket, length('paid/') + 1) AS baker,
+		CAST(substr(key, 1, 8) AS INTEGER) AS cycle,
+		substr(key, 10) AS delegator,
+		json_extract(value, '$.amount') AS amount,
+		json_extract(value, '$.time') AS confirmed
+	FROM entries WHERE bucket LIKE 'paid/%' AND json_valid(value);
+CREATE VIEW IF NOT EXISTS partial AS
+	SELECT substr(bucket, length('partial/') + 1) AS baker,
+		CAST(key AS INTEGER) AS cycle,
+		json_extract(value, '$.paid') AS paid
+	FROM entries WHERE bucket LIKE 'partial/%' AND json_valid(value);
+`
+
+/*
+SQLite is a persistent key value store backed by a SQLite database, for bakers whose history of payouts outgrows a
+JSON file. Every write is a transaction of its own, so unlike Store it does not rewrite the whole history on each
+payment. If a passphrase is set, values are encrypted at rest with AES-256-GCM using a key derived from the
+passphrase, while buckets and keys are left in the clear to be looked up.
+*/
+type SQLite struct {
+	path   string
+	db     *sql.DB
+	secret []byte
+}
+
+// isSQLite reports whether path names a SQLite store
+func isSQLite(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, sqliteExt := range sqliteExtensions {
+		if ext == sqliteExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+func openSQLite(path, passphrase string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open store '%s'", path)
+	}
+	// a single connection serializes the writes of concurrent payouts instead of failing them with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to read store '%s'", path)
+	}
+
+	s := &SQLite{path: path, db: db}
+
+	var salt []byte
+	err = db.QueryRow(`SELECT value FROM meta WHERE name = 'salt'`).Scan(&salt)
+	if err != nil && err != sql.ErrNoRows {
+		db.Close()
+		return nil, errors.Wrapf(err, "failed to read store '%s'", path)
+	}
+
+	if salt != nil {
+		if passphrase == "" {
+			db.Close()
+			return nil, errors.Errorf("failed to read store '%s': store is encrypted but no key is configured", path)
+		}
+
+		s.secret = deriveSecret(passphrase, salt)
+		if err := s.checkKey(); err != nil {
+			db.Close()
+			return nil, errors.Wrapf(err, "failed to decrypt store '%s'", path)
+		}
+	} else if passphrase != "" {
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM entries`).Scan(&count); err != nil {
+			db.Close()
+			return nil, errors.Wrapf(err, "failed to read store '%s'", path)
+		}
+		if count > 0 {
+			db.Close()
+			return nil, errors.Errorf("failed to read store '%s': store is not encrypted, migrate it to a new store to encrypt it", path)
+		}
+
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			db.Close()
+			return nil, errors.Wrap(err, "failed to generate salt")
+		}
+		if _, err := db.Exec(`INSERT INTO meta (name, value) VALUES ('salt', ?)`, salt); err != nil {
+			db.Close()
+			return nil, errors.Wrapf(err, "failed to write store '%s'", path)
+		}
+		s.secret = deriveSecret(passphrase, salt)
+	}
+
+	return s, nil
+}
+
+// checkKey tries the secret on a value of the store, if any, so that a wrong key fails when the store is opened
+func (s *SQLite) checkKey() error {
+	var value []byte
+	err := s.db.QueryRow(`SELECT value FROM entries LIMIT 1`).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = unseal(s.secret, value)
+	return err
+}
+
+// Get decodes the value found at key in bucket into v and reports whether it existed
+func (s *SQLite) Get(bucket, key string, v interface{}) (bool, error) {
+	var value []byte
+	err := s.db.QueryRow(`SELECT value FROM entries WHERE bucket = ? AND key = ?`, bucket, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrapf(err, "failed to read '%s' in bucket '%s'", key, bucket)
+	}
+
+	raw, err := unseal(s.secret, value)
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to decrypt '%s' in bucket '%s'", key, bucket)
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, errors.Wrapf(err, "failed to decode '%s' in bucket '%s'", key, bucket)
+	}
+
+	return true, nil
+}
+
+// Put encodes v and stores it at key in bucket
+func (s *SQLite) Put(bucket, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode '%s' in bucket '%s'", key, bucket)
+	}
+
+	value, err := seal(s.secret, raw)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt '%s' in bucket '%s'", key, bucket)
+	}
+
+	if _, err := s.db.Exec(`INSERT INTO entries (bucket, key, value) VALUES (?, ?, ?)
+		ON CONFLICT (bucket, key) DO UPDATE SET value = excluded.value`, bucket, key, value); err != nil {
+		return errors.Wrapf(err, "failed to write '%s' in bucket '%s'", key, bucket)
+	}
+
+	return nil
+}
+
+// Delete removes key from bucket
+func (s *SQLite) Delete(bucket, key string) error {
+	if _, err := s.db.Exec(`DELETE FROM entries WHERE bucket = ? AND key = ?`, bucket, key); err != nil {
+		return errors.Wrapf(err, "failed to delete '%s' in bucket '%s'", key, bucket)
+	}
+
+	return nil
+}
+
+// Keys returns the sorted keys found in bucket
+func (s *SQLite) Keys(bucket string) ([]string, error) {
+	return s.strings(`SELECT key FROM entries WHERE bucket = ? ORDER BY key`, bucket)
+}
+
+// Buckets returns the sorted names of the buckets in the store
+func (s *SQLite) Buckets() ([]string, error) {
+	return s.strings(`SELECT DISTINCT bucket FROM entries ORDER BY bucket`)
+}
+
+func (s *SQLite) strings(query string, args ...interface{}) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read store")
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, errors.Wrap(err, "failed to read store")
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read store")
+	}
+
+	return values, nil
+}
+
+// Compact rebuilds the database to reclaim the space freed by deleted values, and returns its size in bytes before and after
+func (s *SQLite) Compact() (int64, int64, error) {
+	before, err := fileSize(s.path)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return before, before, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+	// the database is only rewritten once the write ahead log is checkpointed
+	if _, err := s.db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return before, before, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	after, err := fileSize(s.path)
+	if err != nil {
+		return before, 0, errors.Wrapf(err, "failed to compact store '%s'", s.path)
+	}
+
+	return before, after, nil
+}
+
+// cycleKeys returns the sorted keys of cycle in bucket, looked up by the primary key rather than listing the bucket
+func (s *SQLite) cycleKeys(bucket string, cycle int) ([]string, error) {
+	return s.strings(`SELECT key FROM entries WHERE bucket = ? AND key >= ? AND key < ? ORDER BY key`,
+		bucket, cyclePrefix(cycle), cyclePrefix(cycle+1))
+}
+
+// SavePayout records an injected payout. A cycle paid again keeps every payout recorded for it.
+func (s *SQLite) SavePayout(baker string, payout Payout) error {
+	return savePayout(s, baker, payout)
+}
+
+// GetPayout returns the payouts recorded for cycle, oldest first
+func (s *SQLite) GetPayout(baker string, cycle int) ([]Payout, error) {
+	keys, err := s.cycleKeys(PayoutsBucket(baker), cycle)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get payouts of cycle %d", cycle)
+	}
+
+	return getPayouts(s, baker, keys)
+}
+
+// ListPayouts returns every payout recorded, oldest cycle first
+func (s *SQLite) ListPayouts(baker string) ([]Payout, error) {
+	keys, err := s.Keys(PayoutsBucket(baker))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list payouts")
+	}
+
+	return getPayouts(s, baker, keys)
+}
+
+// MarkPaid records address as paid for cycle
+func (s *SQLite) MarkPaid(baker string, cycle int, address string, paid Paid) error {
+	return markPaid(s, baker, cycle, address, paid)
+}
+
+// UnmarkPaid removes the record that address was paid for cycle
+func (s *SQLite) UnmarkPaid(baker string, cycle int, address string) error {
+	return unmarkPaid(s, baker, cycle, address)
+}
+
+// IsPaid reports whether address was paid for cycle
+func (s *SQLite) IsPaid(baker string, cycle int, address string) (bool, error) {
+	return isPaid(s, baker, cycle, address)
+}
+
+// ListUnpaid returns the addresses not paid for cycle, in order
+func (s *SQLite) ListUnpaid(baker string, cycle int, addresses []string) ([]string, error) {
+	keys, err := s.cycleKeys(PaidBucket(baker), cycle)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list delegators not paid for cycle %d", cycle)
+	}
+
+	return unpaid(keys, cycle, addresses), nil
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.db")
+	s, err := openSQLite(path, "")
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Put("bucket", "b", 2))
+	assert.Nil(t, s.Put("bucket", "a", 1))
+	assert.Nil(t, s.Put("bucket", "a", 3))
+	assert.Nil(t, s.Put("other", "c", 4))
+
+	var v int
+	ok, err := s.Get("bucket", "a", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	ok, err = s.Get("bucket", "missing", &v)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	keys, err := s.Keys("bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	buckets, err := s.Buckets()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bucket", "other"}, buckets)
+
+	reopened, err := openSQLite(path, "")
+	assert.Nil(t, err)
+	ok, err = reopened.Get("bucket", "b", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	assert.Nil(t, s.Delete("bucket", "a"))
+	assert.Nil(t, s.Delete("bucket", "missing"))
+	keys, err = s.Keys("bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, keys)
+}
+
+func Test_SQLiteViews(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.db")
+	s, err := openSQLite(path, "")
+	assert.Nil(t, err)
+
+	paidAt := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, s.Put("payouts/tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "00000300/2020-09-01T00:00:00Z", map[string]interface{}{
+		"cycle": 300, "time": paidAt, "operations": []string{"oo1"}, "amount": 1000, "fees": 50,
+	}))
+	assert.Nil(t, s.Put("paid/tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "00000300/tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", map[string]interface{}{
+		"amount": 100, "time": paidAt,
+	}))
+
+	db, err := sql.Open("sqlite3", path)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	var baker string
+	var cycle, amount, fees int
+	err = db.QueryRow(`SELECT baker, cycle, amount, fees FROM payouts`).Scan(&baker, &cycle, &amount, &fees)
+	assert.Nil(t, err)
+	assert.Equal(t, "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", baker)
+	assert.Equal(t, 300, cycle)
+	assert.Equal(t, 1000, amount)
+	assert.Equal(t, 50, fees)
+
+	var delegator string
+	err = db.QueryRow(`SELECT cycle, delegator, amount FROM paid`).Scan(&cycle, &delegator, &amount)
+	assert.Nil(t, err)
+	assert.Equal(t, 300, cycle)
+	assert.Equal(t, "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", delegator)
+	assert.Equal(t, 100, amount)
+}
+
+func Test_SQLiteEncryption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.db")
+	encrypted, err := openSQLite(path, "some_passphrase")
+	assert.Nil(t, err)
+	assert.Nil(t, encrypted.Put("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", "secret_value"))
+
+	plainPath := filepath.Join(dir, "plain.db")
+	plain, err := openSQLite(plainPath, "")
+	assert.Nil(t, err)
+	assert.Nil(t, plain.Put("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", 100))
+
+	cases := []struct {
+		name       string
+		path       string
+		passphrase string
+		wantErr    bool
+		contains   string
+	}{
+		{
+			"is successful",
+			path,
+			"some_passphrase",
+			false,
+			"",
+		},
+		{
+			"handles missing key",
+			path,
+			"",
+			true,
+			"store is encrypted but no key is configured",
+		},
+		{
+			"handles invalid key",
+			path,
+			"wrong_passphrase",
+			true,
+			"invalid key",
+		},
+		{
+			"handles plain text store",
+			plainPath,
+			"some_passphrase",
+			true,
+			"store is not encrypted",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := openSQLite(tt.path, tt.passphrase)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+
+			var v string
+			ok, err := s.Get("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", &v)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, "secret_value", v)
+		})
+	}
+}
+
+func Test_Copy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	src, err := New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, src.Put("bucket", "a", 1))
+	assert.Nil(t, src.Put("other", "b", map[string]int{"amount": 2}))
+
+	dst, err := New(filepath.Join(dir, "tzpay.sqlite"), "")
+	assert.Nil(t, err)
+	_, ok := dst.(*SQLite)
+	assert.True(t, ok)
+
+	copied, err := Copy(dst, src)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, copied)
+
+	var v map[string]int
+	ok, err = dst.Get("other", "b", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"amount": 2}, v)
+}
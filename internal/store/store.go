@@ -0,0 +1,372 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const encryptionCipher = "aes-256-gcm"
+
+// envelope is the on disk format of an encrypted store
+type envelope struct {
+	Cipher     string `json:"cipher"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// IFace is the interface for persisting tzpay state between runs
+type IFace interface {
+	Get(bucket, key string, v interface{}) (bool, error)
+	Put(bucket, key string, v interface{}) error
+	Delete(bucket, key string) error
+	Keys(bucket string) ([]string, error)
+	Buckets() ([]string, error)
+}
+
+/*
+Store is a persistent key value store backed by a single JSON file. Values are grouped into buckets
+and encoded as JSON. Every write rewrites the file atomically, so the store is safe against crashes
+mid write but is only suited for the small amount of state tzpay keeps. If a passphrase is set, the file is
+encrypted at rest with AES-256-GCM using a key derived from the passphrase.
+*/
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	buckets map[string]map[string]json.RawMessage
+	salt    []byte
+	secret  []byte
+}
+
+var (
+	openMu sync.Mutex
+	opened = map[string]IFace{}
+)
+
+/*
+New opens the store found at path, or an empty store if the file does not exist yet. Paths ending in .db, .sqlite
+or .sqlite3 open a SQLite store, paths ending in .bolt or .bbolt a bbolt store, any other path a JSON file. The store
+is encrypted with passphrase unless it is empty. Stores are shared per path within the process, so that concurrent
+payouts never overwrite each others writes.
+*/
+func New(path, passphrase string) (IFace, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	openMu.Lock()
+	defer openMu.Unlock()
+
+	if s, ok := opened[path]; ok {
+		return s, nil
+	}
+
+	s, err := openPath(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	opened[path] = s
+
+	return s, nil
+}
+
+// openPath opens the store at path with the backend its extension names, without sharing it
+func openPath(path, passphrase string) (IFace, error) {
+	var s IFace
+	var err error
+	if isSQLite(path) {
+		s, err = openSQLite(path, passphrase)
+	} else if isBolt(path) {
+		s, err = openBolt(path, passphrase)
+	} else {
+		s, err = open(path, passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// closePath closes a store opened by openPath, releasing its file
+func closePath(s IFace) error {
+	switch s := s.(type) {
+	case *SQLite:
+		return s.db.Close()
+	case *Bolt:
+		return s.db.Close()
+	}
+
+	return nil
+}
+
+// Copy copies every value of src into dst, e.g. to migrate a JSON store to SQLite, and returns how many were copied
+func Copy(dst, src IFace) (int, error) {
+	var copied int
+	err := walk(src, func(record Record) error {
+		if err := dst.Put(record.Bucket, record.Key, record.Value); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		return copied, errors.Wrap(err, "failed to copy store")
+	}
+
+	return copied, nil
+}
+
+func open(path, passphrase string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		buckets: map[string]map[string]json.RawMessage{},
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to read store '%s'", path)
+	}
+
+	var env envelope
+	if len(data) != 0 && json.Unmarshal(data, &env) == nil && env.Cipher != "" {
+		if passphrase == "" {
+			return nil, errors.Errorf("failed to read store '%s': store is encrypted but no key is configured", path)
+		}
+		if env.Cipher != encryptionCipher {
+			return nil, errors.Errorf("failed to read store '%s': unsupported cipher '%s'", path, env.Cipher)
+		}
+
+		s.salt = env.Salt
+		s.secret = deriveSecret(passphrase, s.salt)
+		if data, err = s.decrypt(env); err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt store '%s'", path)
+		}
+	} else if passphrase != "" {
+		// new or plain text stores are encrypted on their next write
+		s.salt = make([]byte, 16)
+		if _, err := rand.Read(s.salt); err != nil {
+			return nil, errors.Wrap(err, "failed to generate salt")
+		}
+		s.secret = deriveSecret(passphrase, s.salt)
+	}
+
+	if len(data) == 0 {
+		return s, nil
+	}
+
+	if err := json.Unmarshal(data, &s.buckets); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse store '%s'", path)
+	}
+
+	return s, nil
+}
+
+func deriveSecret(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, 32768, 32, sha512.New)
+}
+
+// seal encrypts a single value with secret, or returns it as is if secret is nil
+func seal(secret, data []byte) ([]byte, error) {
+	if secret == nil {
+		return data, nil
+	}
+
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// unseal decrypts a single value sealed with secret
+func unseal(secret, data []byte) ([]byte, error) {
+	if secret == nil {
+		return data, nil
+	}
+
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid key")
+	}
+
+	data, err = gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("invalid key")
+	}
+
+	return data, nil
+}
+
+func (s *Store) encrypt(data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope{
+		Cipher:     encryptionCipher,
+		Salt:       s.salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, data, nil),
+	})
+}
+
+func (s *Store) decrypt(env envelope) ([]byte, error) {
+	block, err := aes.NewCipher(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := gcm.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("invalid key")
+	}
+
+	return data, nil
+}
+
+// Get decodes the value found at key in bucket into v and reports whether it existed
+func (s *Store) Get(bucket, key string, v interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.buckets[bucket][key]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, errors.Wrapf(err, "failed to decode '%s' in bucket '%s'", key, bucket)
+	}
+
+	return true, nil
+}
+
+// Put encodes v and stores it at key in bucket
+func (s *Store) Put(bucket, key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode '%s' in bucket '%s'", key, bucket)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket]; !ok {
+		s.buckets[bucket] = map[string]json.RawMessage{}
+	}
+	s.buckets[bucket][key] = raw
+
+	return s.flush()
+}
+
+// Delete removes key from bucket
+func (s *Store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucket][key]; !ok {
+		return nil
+	}
+
+	delete(s.buckets[bucket], key)
+	if len(s.buckets[bucket]) == 0 {
+		delete(s.buckets, bucket)
+	}
+
+	return s.flush()
+}
+
+// Keys returns the sorted keys found in bucket
+func (s *Store) Keys(bucket string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []string{}
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Buckets returns the sorted names of the buckets in the store
+func (s *Store) Buckets() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets := []string{}
+	for bucket := range s.buckets {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+
+	return buckets, nil
+}
+
+func (s *Store) flush() error {
+	data, err := json.MarshalIndent(s.buckets, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode store")
+	}
+
+	if s.secret != nil {
+		if data, err = s.encrypt(data); err != nil {
+			return errors.Wrap(err, "failed to encrypt store")
+		}
+	}
+
+	if err := writeFile(s.path, data); err != nil {
+		return errors.Wrap(err, "failed to write store")
+	}
+
+	return nil
+}
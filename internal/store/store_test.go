@@ -0,0 +1,153 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Store(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.json")
+	s, err := open(path, "")
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Put("bucket", "b", 2))
+	assert.Nil(t, s.Put("bucket", "a", 1))
+
+	var v int
+	ok, err := s.Get("bucket", "a", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	ok, err = s.Get("bucket", "missing", &v)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	keys, err := s.Keys("bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	reopened, err := open(path, "")
+	assert.Nil(t, err)
+	ok, err = reopened.Get("bucket", "b", &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	assert.Nil(t, s.Delete("bucket", "a"))
+	assert.Nil(t, s.Delete("bucket", "missing"))
+	keys, err = s.Keys("bucket")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, keys)
+}
+
+func Test_New(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name     string
+		contents string
+		wantErr  bool
+		contains string
+	}{
+		{
+			"is successful with empty file",
+			"",
+			false,
+			"",
+		},
+		{
+			"handles invalid store",
+			"{not json",
+			true,
+			"failed to parse store",
+		},
+	}
+
+	for i, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			assert.Nil(t, ioutil.WriteFile(path, []byte(tt.contents), 0600))
+
+			_, err := New(path, "")
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+		})
+	}
+
+	first, err := New(filepath.Join(dir, "shared.json"), "")
+	assert.Nil(t, err)
+	second, err := New(filepath.Join(dir, "shared.json"), "")
+	assert.Nil(t, err)
+	assert.True(t, first == second)
+}
+
+func Test_Encryption(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.json")
+	plain, err := open(path, "")
+	assert.Nil(t, err)
+	assert.Nil(t, plain.Put("ledger", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc", 100))
+
+	encrypted, err := open(path, "some_passphrase")
+	assert.Nil(t, err)
+	assert.Nil(t, encrypted.Put("ledger", "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", 200))
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc")
+	assert.Contains(t, string(data), encryptionCipher)
+
+	cases := []struct {
+		name       string
+		passphrase string
+		wantErr    bool
+		contains   string
+	}{
+		{
+			"is successful",
+			"some_passphrase",
+			false,
+			"",
+		},
+		{
+			"handles missing key",
+			"",
+			true,
+			"store is encrypted but no key is configured",
+		},
+		{
+			"handles invalid key",
+			"wrong_passphrase",
+			true,
+			"invalid key",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := open(path, tt.passphrase)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+
+			keys, err := s.Keys("ledger")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV", "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"}, keys)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Record is a single value kept in the store
+type Record struct {
+	Bucket string          `json:"bucket"`
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// Export returns every record about subject (e.g. a delegator address), either keyed by it or mentioning it
+func Export(s IFace, subject string) ([]Record, error) {
+	if subject == "" {
+		return nil, errors.New("failed to export: missing subject")
+	}
+
+	records := []Record{}
+	err := walk(s, func(record Record) error {
+		if record.Key == subject || strings.Contains(record.Bucket, subject) || bytes.Contains(record.Value, []byte(subject)) {
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to export")
+	}
+
+	return records, nil
+}
+
+// Pseudonym returns the stable pseudonym replacing subject once anonymized
+func Pseudonym(subject string) string {
+	sum := sha256.Sum256([]byte(subject))
+	return "anon-" + hex.EncodeToString(sum[:8])
+}
+
+/*
+Anonymize replaces subject with its pseudonym in every record of the store. Records keyed by subject are moved to
+the pseudonym, so amounts stay in the store and aggregate accounting remains intact. It returns the number of
+records changed.
+*/
+func Anonymize(s IFace, subject string) (int, error) {
+	if subject == "" {
+		return 0, errors.New("failed to anonymize: missing subject")
+	}
+
+	pseudonym := Pseudonym(subject)
+	records, err := Export(s, subject)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to anonymize")
+	}
+
+	for _, record := range records {
+		bucket := strings.Replace(record.Bucket, subject, pseudonym, -1)
+		key := strings.Replace(record.Key, subject, pseudonym, -1)
+		value := json.RawMessage(bytes.Replace(record.Value, []byte(subject), []byte(pseudonym), -1))
+
+		if err := s.Put(bucket, key, value); err != nil {
+			return 0, errors.Wrap(err, "failed to anonymize")
+		}
+
+		if bucket != record.Bucket || key != record.Key {
+			if err := s.Delete(record.Bucket, record.Key); err != nil {
+				return 0, errors.Wrap(err, "failed to anonymize")
+			}
+		}
+	}
+
+	return len(records), nil
+}
+
+func walk(s IFace, fn func(record Record) error) error {
+	buckets, err := s.Buckets()
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		keys, err := s.Keys(bucket)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			var value json.RawMessage
+			if _, err := s.Get(bucket, key, &value); err != nil {
+				return err
+			}
+
+			if err := fn(Record{Bucket: bucket, Key: key, Value: value}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExportAndAnonymize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-store")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := open(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	subject := "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+	assert.Nil(t, s.Put("ledger/tz1baker", subject, map[string]int{"amount": 100}))
+	assert.Nil(t, s.Put("ledger/tz1baker", "tz1other", map[string]int{"amount": 200}))
+	assert.Nil(t, s.Put("audit", "2020-09-01", map[string]string{"to": subject}))
+
+	records, err := Export(s, subject)
+	assert.Nil(t, err)
+	assert.Equal(t, []Record{
+		{Bucket: "audit", Key: "2020-09-01", Value: json.RawMessage(`{"to":"` + subject + `"}`)},
+		{Bucket: "ledger/tz1baker", Key: subject, Value: json.RawMessage(`{"amount":100}`)},
+	}, records)
+
+	changed, err := Anonymize(s, subject)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, changed)
+
+	records, err = Export(s, subject)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	keys, err := s.Keys("ledger/tz1baker")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{Pseudonym(subject), "tz1other"}, keys)
+
+	var amount map[string]int
+	_, err = s.Get("ledger/tz1baker", Pseudonym(subject), &amount)
+	assert.Nil(t, err)
+	assert.Equal(t, 100, amount["amount"])
+
+	_, err = Export(s, "")
+	assert.NotNil(t, err)
+}
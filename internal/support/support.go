@@ -0,0 +1,332 @@
+package support
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/goat-systems/go-tezos/v3/rpc"
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/redact"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// Removed replaces the secrets left out of a bundle
+const Removed = "[REMOVED]"
+
+// BundleInput is the input for WriteBundle. Anything that failed to load is left nil and its error passed instead.
+type BundleInput struct {
+	Version   Version
+	Config    *config.Config
+	ConfigErr error
+	RPC       rpc.IFace
+	RPCErr    error
+	Tzkt      tzkt.IFace
+	Store     store.IFace
+	StoreErr  error
+	Logs      io.Reader
+	LogLines  int
+	Redactor  *redact.Redactor
+}
+
+// Version describes the tzpay build a bundle was generated with
+type Version struct {
+	Version   string    `json:"version"`
+	Changed   string    `json:"changed"`
+	GoVersion string    `json:"go_version"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	Generated time.Time `json:"generated"`
+}
+
+// ConfigReport is the configuration of a bundle, or why it failed to load
+type ConfigReport struct {
+	Config *config.Config `json:"config,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// NodeReport describes the state of the tezos node and of tzkt as seen from tzpay
+type NodeReport struct {
+	Node        string         `json:"node"`
+	Level       int            `json:"level,omitempty"`
+	Cycle       int            `json:"cycle,omitempty"`
+	Protocol    string         `json:"protocol,omitempty"`
+	ChainID     string         `json:"chain_id,omitempty"`
+	Timestamp   time.Time      `json:"timestamp,omitempty"`
+	Latency     string         `json:"latency,omitempty"`
+	Constants   *rpc.Constants `json:"constants,omitempty"`
+	NodeError   string         `json:"node_error,omitempty"`
+	Tzkt        string         `json:"tzkt"`
+	TzktHead    *tzkt.Head     `json:"tzkt_head,omitempty"`
+	TzktLatency string         `json:"tzkt_latency,omitempty"`
+	TzktLag     int            `json:"tzkt_lag"`
+	TzktError   string         `json:"tzkt_error,omitempty"`
+}
+
+// StoreReport summarizes the store: the number of keys in each bucket, never their values
+type StoreReport struct {
+	Buckets map[string]int `json:"buckets,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+/*
+WriteBundle writes a gzipped tar archive to attach to a bug report, holding:
+
+	version.json  the tzpay build and platform
+	config.json   the configuration with its secrets removed
+	node.json     the state of the tezos node and tzkt
+	store.json    the number of keys in each bucket of the store
+	logs.txt      the last LogLines lines of Logs, if passed
+
+The configuration, store summary and logs are passed through Redactor, so that a bundle masks delegator addresses
+when asked to.
+*/
+func WriteBundle(w io.Writer, input BundleInput) error {
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+
+	version := input.Version
+	version.GoVersion, version.OS, version.Arch = runtime.Version(), runtime.GOOS, runtime.GOARCH
+	if version.Generated.IsZero() {
+		version.Generated = time.Now().UTC()
+	}
+
+	files := []struct {
+		name   string
+		report interface{}
+	}{
+		{"version.json", version},
+		{"config.json", configReport(input)},
+		{"node.json", nodeReport(input)},
+		{"store.json", storeReport(input)},
+	}
+
+	for _, file := range files {
+		byts, err := json.MarshalIndent(file.report, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to write '%s' to support bundle", file.name)
+		}
+		if err := writeFile(archive, file.name, []byte(input.Redactor.String(string(byts))), version.Generated); err != nil {
+			return err
+		}
+	}
+
+	if input.Logs != nil {
+		logs, err := tail(input.Logs, input.LogLines)
+		if err != nil {
+			return errors.Wrap(err, "failed to read logs")
+		}
+		if err := writeFile(archive, "logs.txt", []byte(input.Redactor.String(logs)), version.Generated); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return errors.Wrap(err, "failed to write support bundle")
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrap(err, "failed to write support bundle")
+	}
+
+	return nil
+}
+
+func writeFile(archive *tar.Writer, name string, data []byte, modified time.Time) error {
+	err := archive.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modified,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to write '%s' to support bundle", name)
+	}
+
+	if _, err := archive.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write '%s' to support bundle", name)
+	}
+
+	return nil
+}
+
+func configReport(input BundleInput) ConfigReport {
+	var report ConfigReport
+	if input.ConfigErr != nil {
+		report.Error = input.ConfigErr.Error()
+	}
+	if input.Config != nil {
+		cfg := Sanitize(*input.Config)
+		report.Config = &cfg
+	}
+
+	return report
+}
+
+// Sanitize returns cfg with its keys, passwords and API credentials removed
+func Sanitize(cfg config.Config) config.Config {
+	cfg.Key = sanitizeKey(cfg.Key)
+	cfg.Insurance.Esk = remove(cfg.Insurance.Esk)
+	cfg.Insurance.Password = remove(cfg.Insurance.Password)
+	cfg.Funding.Esk = remove(cfg.Funding.Esk)
+	cfg.Funding.Password = remove(cfg.Funding.Password)
+	cfg.Store.Key = remove(cfg.Store.Key)
+	cfg.HTTP.APIKey = remove(cfg.HTTP.APIKey)
+	cfg.Notifications.Twitter = config.Twitter{
+		ConsumerKey:    remove(cfg.Notifications.Twitter.ConsumerKey),
+		ConsumerSecret: remove(cfg.Notifications.Twitter.ConsumerSecret),
+		AccessToken:    remove(cfg.Notifications.Twitter.AccessToken),
+		AccessSecret:   remove(cfg.Notifications.Twitter.AccessSecret),
+	}
+	cfg.Notifications.Twilio.AuthToken = remove(cfg.Notifications.Twilio.AuthToken)
+	cfg.Notifications.Discord.WebhookURL = remove(cfg.Notifications.Discord.WebhookURL)
+	cfg.Notifications.Webhook.Headers = removeEach(cfg.Notifications.Webhook.Headers)
+	cfg.Notifications.Email.Password = remove(cfg.Notifications.Email.Password)
+	cfg.Notifications.Email.Delegators = removeEach(cfg.Notifications.Email.Delegators)
+	cfg.Notifications.Deactivation.Esk = remove(cfg.Notifications.Deactivation.Esk)
+	cfg.Notifications.Deactivation.Password = remove(cfg.Notifications.Deactivation.Password)
+
+	delegates := make([]config.Delegate, len(cfg.Delegates))
+	for i, delegate := range cfg.Delegates {
+		delegate.Key = sanitizeKey(delegate.Key)
+		delegates[i] = delegate
+	}
+	if cfg.Delegates != nil {
+		cfg.Delegates = delegates
+	}
+
+	return cfg
+}
+
+func sanitizeKey(key config.Key) config.Key {
+	key.Esk = remove(key.Esk)
+	key.Password = remove(key.Password)
+	key.PKCS11PIN = remove(key.PKCS11PIN)
+
+	key.SignerHeaders = removeEach(key.SignerHeaders)
+
+	return key
+}
+
+// removeEach replaces every element of a list, e.g. the headers of remote signers carrying their credentials
+func removeEach(secrets []string) []string {
+	if secrets == nil {
+		return nil
+	}
+
+	removed := make([]string, len(secrets))
+	for i, secret := range secrets {
+		removed[i] = remove(secret)
+	}
+
+	return removed
+}
+
+// remove replaces a secret that is set, so that the bundle still shows which secrets are configured
+func remove(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return Removed
+}
+
+func nodeReport(input BundleInput) NodeReport {
+	var report NodeReport
+	if input.Config != nil {
+		report.Node, report.Tzkt = input.Config.API.Tezos, input.Config.API.TZKT
+	}
+	if input.RPCErr != nil {
+		report.NodeError = input.RPCErr.Error()
+	}
+
+	if input.RPC != nil {
+		start := time.Now()
+		head, err := input.RPC.Head()
+		if err != nil {
+			report.NodeError = err.Error()
+		} else {
+			report.Latency = time.Since(start).String()
+			report.Level, report.Cycle = head.Header.Level, head.Metadata.Level.Cycle
+			report.Protocol, report.ChainID, report.Timestamp = head.Protocol, head.ChainID, head.Header.Timestamp
+
+			if constants, err := input.RPC.Constants(head.Hash); err != nil {
+				report.NodeError = err.Error()
+			} else {
+				report.Constants = &constants
+			}
+		}
+	}
+
+	if input.Tzkt != nil {
+		start := time.Now()
+		head, err := input.Tzkt.GetHead()
+		if err != nil {
+			report.TzktError = err.Error()
+		} else {
+			report.TzktLatency = time.Since(start).String()
+			report.TzktHead = &head
+			if report.Level > 0 {
+				report.TzktLag = report.Level - head.Level
+			}
+		}
+	}
+
+	return report
+}
+
+func storeReport(input BundleInput) StoreReport {
+	var report StoreReport
+	if input.StoreErr != nil {
+		report.Error = input.StoreErr.Error()
+	}
+	if input.Store == nil {
+		return report
+	}
+
+	buckets, err := input.Store.Buckets()
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	report.Buckets = map[string]int{}
+	for _, bucket := range buckets {
+		keys, err := input.Store.Keys(bucket)
+		if err != nil {
+			report.Error = err.Error()
+			return report
+		}
+		report.Buckets[bucket] = len(keys)
+	}
+
+	return report
+}
+
+// tail returns the last n lines read from r, or every line if n is 0
+func tail(r io.Reader, n int) (string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
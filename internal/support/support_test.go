@@ -0,0 +1,156 @@
+package support
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/config"
+	"github.com/goat-systems/tzpay/v3/internal/redact"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	baker     = "tz1S82rGFZK8cVbNDpP1Hf9VhTUa4W8oc2WV"
+	delegator = "tz1SUgyRB8T5jXgXAwS33pgRHAKrafyg87Yc"
+)
+
+type tzktMock struct {
+	tzkt.IFace
+	err bool
+}
+
+func (t *tzktMock) GetHead() (tzkt.Head, error) {
+	if t.err {
+		return tzkt.Head{}, errors.New("too many requests")
+	}
+
+	return tzkt.Head{Level: 90}, nil
+}
+
+func readBundle(t *testing.T, data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+
+	files := map[string]string{}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err != nil {
+			break
+		}
+		byts, err := ioutil.ReadAll(archive)
+		assert.Nil(t, err)
+		files[header.Name] = string(byts)
+	}
+
+	return files
+}
+
+func Test_WriteBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-support")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+	assert.Nil(t, s.Put("payouts/"+baker, "00000010", "record"))
+	assert.Nil(t, s.Put("ledger/"+baker, delegator, 100))
+	assert.Nil(t, s.Put("ledger/"+baker, "tz1other", 100))
+
+	cfg := config.Config{
+		Baker: config.Baker{Address: baker, Blacklist: []string{delegator}},
+		Key:   config.Key{Esk: "edesk...", Password: "secret"},
+	}
+
+	var out bytes.Buffer
+	err = WriteBundle(&out, BundleInput{
+		Version:  Version{Version: "v2.7.0"},
+		Config:   &cfg,
+		RPC:      &test.RPCMock{HeadCycle: 50},
+		Tzkt:     &tzktMock{},
+		Store:    s,
+		Logs:     strings.NewReader("first\nsecond\npaid " + delegator + "\n"),
+		LogLines: 2,
+		Redactor: redact.New(nil, true, baker),
+	})
+	assert.Nil(t, err)
+
+	files := readBundle(t, out.Bytes())
+	assert.Contains(t, files["version.json"], `"version": "v2.7.0"`)
+	assert.Contains(t, files["config.json"], `"Esk": "[REMOVED]"`)
+	assert.NotContains(t, files["config.json"], "secret")
+	assert.NotContains(t, files["config.json"], delegator)
+	assert.Contains(t, files["config.json"], baker)
+	assert.Contains(t, files["node.json"], `"cycle": 50`)
+	assert.Contains(t, files["node.json"], `"blocks_per_cycle": 2`)
+	assert.Contains(t, files["store.json"], `"ledger/`+baker+`": 2`)
+	assert.Equal(t, "second\npaid tz1…[REDACTED]\n", files["logs.txt"])
+	assert.Equal(t, "edesk...", cfg.Key.Esk, "the configuration passed is left as is")
+}
+
+func Test_WriteBundleErrors(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteBundle(&out, BundleInput{
+		ConfigErr: errors.New("failed to load enviroment variables"),
+		RPCErr:    errors.New("could not get head block"),
+		Tzkt:      &tzktMock{err: true},
+		StoreErr:  errors.New("failed to decrypt store"),
+	})
+	assert.Nil(t, err)
+
+	files := readBundle(t, out.Bytes())
+	assert.Contains(t, files["config.json"], "failed to load enviroment variables")
+	assert.Contains(t, files["node.json"], `"node_error": "could not get head block"`)
+	assert.Contains(t, files["node.json"], `"tzkt_error": "too many requests"`)
+	assert.Contains(t, files["store.json"], "failed to decrypt store")
+	assert.NotContains(t, files, "logs.txt")
+
+	out.Reset()
+	assert.Nil(t, WriteBundle(&out, BundleInput{RPC: &test.RPCMock{HeadErr: true}}))
+	assert.Contains(t, readBundle(t, out.Bytes())["node.json"], `"node_error": "failed to get block"`)
+}
+
+func Test_Sanitize(t *testing.T) {
+	cfg := Sanitize(config.Config{
+		Key:       config.Key{Esk: "edesk...", Password: "secret", Address: baker},
+		Insurance: config.Insurance{Esk: "edesk...", Password: "secret"},
+		Funding:   config.Funding{Enabled: true, Esk: "edesk...", Password: "secret"},
+		Store:     config.Store{Path: "tzpay.json", Key: "secret"},
+		Notifications: config.Notifications{
+			Twitter:      config.Twitter{ConsumerKey: "key", AccessSecret: "secret"},
+			Twilio:       config.Twilio{AccountSID: "sid", AuthToken: "token"},
+			Discord:      config.Discord{WebhookURL: "https://discord.com/api/webhooks/1/token", Username: "tzpay"},
+			Webhook:      config.Webhook{URL: "https://hooks.example.com", Headers: []string{"Authorization:Bearer secret"}},
+			Email:        config.Email{Host: "smtp.example.com", Password: "secret", Delegators: []string{"tz1delegator:alice@example.com"}},
+			Deactivation: config.Deactivation{Cycles: 3, Esk: "edesk...", Password: "secret"},
+		},
+		Delegates: []config.Delegate{
+			{Key: config.Key{Signer: "http://signer:6732", SignerHeaders: []string{"Authorization:Bearer secret"}, Address: baker}},
+			{Key: config.Key{PKCS11Module: "/usr/lib/softhsm/libsofthsm2.so", PKCS11PIN: "1234", PKCS11Label: "payout"}},
+		},
+	})
+
+	assert.Equal(t, config.Key{Esk: Removed, Password: Removed, Address: baker}, cfg.Key)
+	assert.Equal(t, config.Insurance{Esk: Removed, Password: Removed}, cfg.Insurance)
+	assert.Equal(t, config.Funding{Enabled: true, Esk: Removed, Password: Removed}, cfg.Funding)
+	assert.Equal(t, config.Store{Path: "tzpay.json", Key: Removed}, cfg.Store)
+	assert.Equal(t, config.Twitter{ConsumerKey: Removed, AccessSecret: Removed}, cfg.Notifications.Twitter)
+	assert.Equal(t, "sid", cfg.Notifications.Twilio.AccountSID)
+	assert.Equal(t, Removed, cfg.Notifications.Twilio.AuthToken)
+	assert.Equal(t, config.Discord{WebhookURL: Removed, Username: "tzpay"}, cfg.Notifications.Discord)
+	assert.Equal(t, config.Webhook{URL: "https://hooks.example.com", Headers: []string{Removed}}, cfg.Notifications.Webhook)
+	assert.Equal(t, config.Email{Host: "smtp.example.com", Password: Removed, Delegators: []string{Removed}}, cfg.Notifications.Email)
+	assert.Equal(t, config.Deactivation{Cycles: 3, Esk: Removed, Password: Removed}, cfg.Notifications.Deactivation)
+	assert.Equal(t, config.Key{Signer: "http://signer:6732", SignerHeaders: []string{Removed}, Address: baker}, cfg.Delegates[0].Key)
+	assert.Equal(t, config.Key{PKCS11Module: "/usr/lib/softhsm/libsofthsm2.so", PKCS11PIN: Removed, PKCS11Label: "payout"}, cfg.Delegates[1].Key)
+}
@@ -0,0 +1,122 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Ready tells systemd the service finished starting up
+	Ready = "READY=1"
+	// Stopping tells systemd the service is stopping
+	Stopping = "STOPPING=1"
+	// Ping tells systemd the service is alive, resetting its watchdog
+	Ping = "WATCHDOG=1"
+)
+
+/*
+Notify sends state to systemd over the socket in NOTIFY_SOCKET, e.g. Ready, as sd_notify does. It returns false
+without error when the process is not run by systemd with a notify socket, e.g. a service of Type=simple.
+*/
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	if socket[0] == '@' {
+		// An abstract socket
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to notify systemd")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, errors.Wrap(err, "failed to notify systemd")
+	}
+
+	return true, nil
+}
+
+// WatchdogInterval returns the WatchdogSec of the service, or 0 if systemd does not watch this process
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// The watchdog is meant for another process, e.g. the parent of this one
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.Errorf("invalid WATCHDOG_USEC '%s'", usec)
+	}
+
+	return time.Duration(n) * time.Microsecond, nil
+}
+
+/*
+Watchdog pings the watchdog of systemd twice per interval as long as the process shows it is alive by calling Alive at
+least once per interval. A process that stops calling Alive, e.g. hung on a call that never returns, is no longer
+pinged, and is restarted by systemd once the interval elapses, if the service is configured to.
+*/
+type Watchdog struct {
+	interval time.Duration
+	mu       sync.Mutex
+	alive    time.Time
+	now      func() time.Time
+}
+
+// NewWatchdog returns a Watchdog pinging systemd for a WatchdogSec of interval
+func NewWatchdog(interval time.Duration) *Watchdog {
+	return &Watchdog{interval: interval, alive: time.Now(), now: time.Now}
+}
+
+// Alive records that the process is alive
+func (w *Watchdog) Alive() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.alive = w.now()
+}
+
+// Start pings systemd in the background
+func (w *Watchdog) Start() {
+	log.WithField("interval", w.interval).Info("Starting systemd watchdog.")
+	go func() {
+		ticker := time.NewTicker(w.interval / 2)
+		for range ticker.C {
+			w.ping()
+		}
+	}()
+}
+
+// ping pings systemd if the process was alive within the interval, and returns whether it did
+func (w *Watchdog) ping() bool {
+	w.mu.Lock()
+	stale := w.now().Sub(w.alive)
+	w.mu.Unlock()
+
+	if stale > w.interval {
+		log.WithField("stale", stale).Error("Server not alive, no longer pinging systemd watchdog.")
+		return false
+	}
+
+	if _, err := Notify(Ping); err != nil {
+		log.WithField("error", err.Error()).Warn("Failed to ping systemd watchdog.")
+		return false
+	}
+
+	return true
+}
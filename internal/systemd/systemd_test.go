@@ -0,0 +1,112 @@
+package systemd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// listen listens on a notify socket in dir set in NOTIFY_SOCKET, and returns it
+func listen(t *testing.T, dir string) *net.UnixConn {
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.Nil(t, err)
+	os.Setenv("NOTIFY_SOCKET", socket)
+
+	return conn
+}
+
+func read(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 64)
+	assert.Nil(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+
+	return string(buf[:n])
+}
+
+func Test_Notify(t *testing.T) {
+	os.Unsetenv("NOTIFY_SOCKET")
+	ok, err := Notify(Ready)
+	assert.Nil(t, err)
+	assert.False(t, ok, "nothing is sent without a notify socket")
+
+	dir, err := ioutil.TempDir("", "tzpay-systemd")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	conn := listen(t, dir)
+	defer conn.Close()
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	ok, err = Notify(Ready)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Ready, read(t, conn))
+
+	os.Setenv("NOTIFY_SOCKET", filepath.Join(dir, "missing.sock"))
+	_, err = Notify(Ready)
+	assert.Error(t, err)
+}
+
+func Test_WatchdogInterval(t *testing.T) {
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	cases := []struct {
+		name     string
+		usec     string
+		pid      string
+		interval time.Duration
+		err      bool
+	}{
+		{"is disabled", "", "", 0, false},
+		{"is enabled", "120000000", "", 2 * time.Minute, false},
+		{"is enabled for process", "120000000", strconv.Itoa(os.Getpid()), 2 * time.Minute, false},
+		{"is enabled for another process", "120000000", "1", 0, false},
+		{"handles invalid interval", "abc", "", 0, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("WATCHDOG_USEC", tt.usec)
+			os.Setenv("WATCHDOG_PID", tt.pid)
+
+			interval, err := WatchdogInterval()
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.interval, interval)
+		})
+	}
+}
+
+func Test_Watchdog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-systemd")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	conn := listen(t, dir)
+	defer conn.Close()
+	defer os.Unsetenv("NOTIFY_SOCKET")
+
+	now := time.Now()
+	watchdog := NewWatchdog(time.Minute)
+	watchdog.now = func() time.Time { return now }
+	watchdog.Alive()
+
+	now = now.Add(50 * time.Second)
+	assert.True(t, watchdog.ping())
+	assert.Equal(t, Ping, read(t, conn))
+
+	now = now.Add(20 * time.Second)
+	assert.False(t, watchdog.ping(), "a process not alive within the interval is not pinged")
+
+	watchdog.Alive()
+	assert.True(t, watchdog.ping())
+	assert.Equal(t, Ping, read(t, conn))
+}
@@ -3,7 +3,9 @@ package test
 import (
 	"encoding/json"
 	"errors"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/goat-systems/go-tezos/v3/rpc"
 	"github.com/goat-systems/tzpay/v3/internal/tzkt"
@@ -15,6 +17,14 @@ type TzktMock struct {
 	tzkt.IFace
 	TransactionsErr bool
 	RewardsSplitErr bool
+	// Transactions overrides the transactions returned when set
+	Transactions []tzkt.Transaction
+	// CycleErr fails getting cycles and blocks, Cycles overrides the cycles returned, of 2 blocks each otherwise
+	CycleErr bool
+	Cycles   map[int]tzkt.Cycle
+	// HeadErr fails getting the head, HeadLevel is the level of the head returned
+	HeadErr   bool
+	HeadLevel int
 }
 
 var _ rpc.IFace = &RPCMock{}
@@ -23,6 +33,9 @@ func (t *TzktMock) GetTransactions(options ...tzkt.URLParameters) ([]tzkt.Transa
 	if t.TransactionsErr {
 		return []tzkt.Transaction{}, errors.New("failed to get transaction")
 	}
+	if t.Transactions != nil {
+		return t.Transactions, nil
+	}
 
 	return []tzkt.Transaction{
 		{
@@ -37,6 +50,54 @@ func (t *TzktMock) GetTransactions(options ...tzkt.URLParameters) ([]tzkt.Transa
 
 }
 
+func (t *TzktMock) GetHead() (tzkt.Head, error) {
+	if t.HeadErr {
+		return tzkt.Head{}, errors.New("failed to get head")
+	}
+
+	return tzkt.Head{Level: t.HeadLevel}, nil
+}
+
+func (t *TzktMock) GetCycle(index int) (tzkt.Cycle, error) {
+	if t.CycleErr {
+		return tzkt.Cycle{}, errors.New("failed to get cycle")
+	}
+	if cycle, ok := t.Cycles[index]; ok {
+		return cycle, nil
+	}
+
+	return tzkt.Cycle{Index: index, FirstLevel: index*2 + 1, LastLevel: (index + 1) * 2}, nil
+}
+
+// GetBlocks returns the block at the level filtered on, in the cycle of Cycles spanning it
+func (t *TzktMock) GetBlocks(options ...tzkt.URLParameters) (tzkt.Blocks, error) {
+	if t.CycleErr {
+		return tzkt.Blocks{}, errors.New("failed to get blocks")
+	}
+
+	blocks := tzkt.Blocks{}
+	for _, option := range options {
+		if option.Key != "level" {
+			continue
+		}
+
+		level, err := strconv.Atoi(option.Value)
+		if err != nil {
+			return tzkt.Blocks{}, err
+		}
+
+		blocks = make(tzkt.Blocks, 1)
+		blocks[0].Level, blocks[0].Cycle = level, (level-1)/2
+		for _, cycle := range t.Cycles {
+			if level >= cycle.FirstLevel && level <= cycle.LastLevel {
+				blocks[0].Cycle = cycle.Index
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
 func (t *TzktMock) GetRewardsSplit(delegate string, cycle int, options ...tzkt.URLParameters) (tzkt.RewardsSplit, error) {
 	if t.RewardsSplitErr {
 		return tzkt.RewardsSplit{}, errors.New("failed to get rewards split")
@@ -67,6 +128,86 @@ type RPCMock struct {
 	BigMapErr             bool
 	BakingRightsErr       bool
 	EndorsingRightsErr    bool
+	ConstantsErr          bool
+	BlockErr              bool
+	// RightsEstimatedTime is the estimated time of the baking and endorsing rights returned, EndorsingSlots the slots
+	// of the endorsing rights
+	RightsEstimatedTime time.Time
+	EndorsingSlots      []int
+	// ContractStorages overrides the storage returned for the contracts it contains
+	ContractStorages map[string]string
+	// HeadCycle and HeadLevel are the cycle and level of the head block returned
+	HeadCycle int
+	HeadLevel int
+	// LevelBlocks overrides the block returned at the levels it contains
+	LevelBlocks map[int]*rpc.Block
+	// PreapplyErr fails simulations, PreapplyStatus overrides the status of simulated operations
+	PreapplyErr    bool
+	PreapplyStatus string
+	// DelegateErr fails getting the status of a delegate, GracePeriod and Deactivated set the status returned
+	DelegateErr bool
+	GracePeriod int
+	Deactivated bool
+	// Unrevealed reports addresses as not having revealed their public key, ManagerKeyErr fails checking it
+	Unrevealed    bool
+	ManagerKeyErr bool
+}
+
+// Constants -
+func (r *RPCMock) Constants(blockhash string) (rpc.Constants, error) {
+	if r.ConstantsErr {
+		return rpc.Constants{}, errors.New("failed to get constants")
+	}
+
+	return rpc.Constants{
+		BlocksPerCycle:    2,
+		PreservedCycles:   5,
+		EndorsersPerBlock: 32,
+		BlockReward:       rpc.IntArray{1250000, 187500},
+		EndorsementReward: rpc.IntArray{1250000, 833333},
+	}, nil
+}
+
+// Block -
+func (r *RPCMock) Block(id interface{}) (*rpc.Block, error) {
+	if r.BlockErr {
+		return &rpc.Block{}, errors.New("failed to get block")
+	}
+
+	if level, ok := id.(int); ok && r.LevelBlocks[level] != nil {
+		return r.LevelBlocks[level], nil
+	}
+
+	return &rpc.Block{
+		Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p",
+		Metadata: rpc.Metadata{
+			Baker: "some_delegate",
+			BalanceUpdates: []rpc.BalanceUpdates{
+				{Kind: "contract", Contract: "some_delegate", Change: -512000000},
+				{Kind: "freezer", Category: "deposits", Delegate: "some_delegate", Cycle: 10, Change: 512000000},
+				{Kind: "freezer", Category: "rewards", Delegate: "some_delegate", Cycle: 10, Change: 40000000},
+				{Kind: "freezer", Category: "fees", Delegate: "some_delegate", Cycle: 10, Change: 3000},
+			},
+		},
+		Operations: [][]rpc.Operations{
+			{
+				{
+					Hash: "ooYympR9wfV98X4MUHtE78NjXYRDeMTAD4ei7zEZDqoHv2rfb1M",
+					Contents: rpc.Contents{
+						{
+							Kind: rpc.ENDORSEMENT,
+							Metadata: &rpc.ContentsHelperMetadata{
+								BalanceUpdates: []rpc.BalanceUpdates{
+									{Kind: "freezer", Category: "rewards", Delegate: "some_delegate", Cycle: 10, Change: 1250000},
+									{Kind: "freezer", Category: "rewards", Delegate: "some_other_delegate", Cycle: 10, Change: 1250000},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
 }
 
 // EndorsingRights -
@@ -77,8 +218,10 @@ func (r *RPCMock) EndorsingRights(input rpc.EndorsingRightsInput) (*rpc.Endorsin
 
 	return &rpc.EndorsingRights{
 		{
-			Level:    100,
-			Delegate: "some_delegate",
+			Level:         100,
+			Delegate:      "some_delegate",
+			Slots:         r.EndorsingSlots,
+			EstimatedTime: r.RightsEstimatedTime,
 		},
 	}, nil
 }
@@ -91,8 +234,9 @@ func (r *RPCMock) BakingRights(input rpc.BakingRightsInput) (*rpc.BakingRights,
 
 	return &rpc.BakingRights{
 		{
-			Level:    100,
-			Delegate: "some_delegate",
+			Level:         100,
+			Delegate:      "some_delegate",
+			EstimatedTime: r.RightsEstimatedTime,
 		},
 	}, nil
 }
@@ -102,11 +246,57 @@ func (r *RPCMock) Head() (*rpc.Block, error) {
 	if r.HeadErr {
 		return &rpc.Block{}, errors.New("failed to get block")
 	}
-	return &rpc.Block{
+	block := &rpc.Block{
 		Hash: "BLfEWKVudXH15N8nwHZehyLNjRuNLoJavJDjSZ7nq8ggfzbZ18p",
+	}
+	block.Metadata.Level.Cycle = r.HeadCycle
+	block.Metadata.Level.Level = r.HeadLevel
+
+	return block, nil
+}
+
+// Delegate -
+func (r *RPCMock) Delegate(blockhash, delegate string) (rpc.Delegate, error) {
+	if r.DelegateErr {
+		return rpc.Delegate{}, errors.New("failed to get delegate")
+	}
+
+	return rpc.Delegate{
+		StakingBalance: "10000000000",
+		GracePeriod:    r.GracePeriod,
+		Deactivated:    r.Deactivated,
 	}, nil
 }
 
+// PreapplyOperations simulates every content as consuming 1427 gas, and 67 bytes if it has a storage limit
+func (r *RPCMock) PreapplyOperations(input rpc.PreapplyOperationsInput) ([]rpc.Operations, error) {
+	if r.PreapplyErr {
+		return nil, errors.New("failed to preapply operation")
+	}
+
+	status := "applied"
+	if r.PreapplyStatus != "" {
+		status = r.PreapplyStatus
+	}
+
+	var operations []rpc.Operations
+	for _, operation := range input.Operations {
+		var contents rpc.Contents
+		for _, content := range operation.Contents {
+			result := &rpc.OperationResultsHelper{Status: status, ConsumedGas: 1427}
+			if content.StorageLimit > 0 {
+				result.PaidStorageSizeDiff = 67
+			}
+			content.Metadata = &rpc.ContentsHelperMetadata{OperationResults: result}
+			contents = append(contents, content)
+		}
+		operation.Contents = contents
+		operations = append(operations, operation)
+	}
+
+	return operations, nil
+}
+
 // Counter -
 func (r *RPCMock) Counter(blockhash, pkh string) (int, error) {
 	counter := 0
@@ -117,6 +307,17 @@ func (r *RPCMock) Counter(blockhash, pkh string) (int, error) {
 	return counter, nil
 }
 
+// ManagerKey -
+func (r *RPCMock) ManagerKey(blockhash, address string) (string, error) {
+	if r.ManagerKeyErr {
+		return "", errors.New("failed to get manager key")
+	}
+	if r.Unrevealed {
+		return "", nil
+	}
+	return "edpkvH4rzbmfvAEgiJQU1TKYfrTvBbpVJGHmQByh9Nph4BzvRh8aXP", nil
+}
+
 // Balance -
 func (r *RPCMock) Balance(input rpc.BalanceInput) (int, error) {
 	if r.BalanceErr {
@@ -196,6 +397,9 @@ func (r *RPCMock) ContractStorage(blockhash string, KT1 string) ([]byte, error)
 	if r.ContractStorageErr {
 		return nil, errors.New("failed to get contract storage")
 	}
+	if storage, ok := r.ContractStorages[KT1]; ok {
+		return []byte(storage), nil
+	}
 	if r.ContractStorageV15 {
 		return []byte(`{"prim":"Pair","args":[{"int":"541"},{"prim":"Pair","args":[{"prim":"False"},{"prim":"False"},{"int":"49707523463"}]},{"prim":"Pair","args":[{"string":"KT1B5VTw8ZSMnrjhy337CEvAm4tnT8Gu8Geu"},{"string":"KT1PWx2mnDueood7fEmfbBDKx1D9BAnnXitn"}]},{"int":"382997319"},{"int":"47813915032"}]}`), nil
 	}
@@ -23,6 +23,7 @@ type Head struct {
 
 type Blocks []struct {
 	Level         int       `json:"level"`
+	Cycle         int       `json:"cycle"`
 	Hash          string    `json:"hash"`
 	Timestamp     time.Time `json:"timestamp"`
 	Proto         int       `json:"proto"`
@@ -1,6 +1,7 @@
 package tzkt
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -23,19 +24,31 @@ type URLParameters struct {
 
 type IFace interface {
 	GetTransactions(options ...URLParameters) ([]Transaction, error)
+	GetDelegations(options ...URLParameters) ([]Delegation, error)
+	GetDelegates(options ...URLParameters) ([]Delegate, error)
+	GetContractScript(address string) (Script, error)
 	GetRewardsSplit(delegate string, cycle int, options ...URLParameters) (RewardsSplit, error)
 	GetRights(options ...URLParameters) (Rights, error)
 	GetHead() (Head, error)
 	GetBlocks(options ...URLParameters) (Blocks, error)
+	GetCycle(index int) (Cycle, error)
 }
 
 type Tzkt struct {
 	client client
 	Host   string
+	// ctx cancels the requests in flight once done, nil for none
+	ctx context.Context
 }
 
 func NewTZKT(host string) *Tzkt {
+	return NewTZKTWithContext(context.Background(), host)
+}
+
+// NewTZKTWithContext returns a client of tzkt at host whose every request is bound to ctx
+func NewTZKTWithContext(ctx context.Context, host string) *Tzkt {
 	return &Tzkt{
+		ctx: ctx,
 		client: &http.Client{
 			Timeout: time.Second * 10,
 			Transport: &http.Transport{
@@ -50,7 +63,7 @@ func NewTZKT(host string) *Tzkt {
 }
 
 func (t *Tzkt) get(path string, opts ...URLParameters) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s", t.Host, path), nil)
+	req, err := http.NewRequestWithContext(t.context(), http.MethodGet, fmt.Sprintf("%s%s", t.Host, path), nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to construct request")
 	}
@@ -60,6 +73,14 @@ func (t *Tzkt) get(path string, opts ...URLParameters) ([]byte, error) {
 	return t.do(req)
 }
 
+func (t *Tzkt) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+
+	return t.ctx
+}
+
 func (t *Tzkt) do(req *http.Request) ([]byte, error) {
 	req.Header.Set("Content-Type", "application/json")
 	resp, err := t.client.Do(req)
@@ -0,0 +1,55 @@
+package tzkt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Micheline is a michelson type expression in its JSON format
+type Micheline struct {
+	Prim   string      `json:"prim,omitempty"`
+	Args   []Micheline `json:"args,omitempty"`
+	Annots []string    `json:"annots,omitempty"`
+}
+
+// Script is the parameter type of a contract, taken from its code
+type Script struct {
+	Parameter Micheline
+}
+
+/*
+GetContractScript -
+See: https://api.tzkt.io/#operation/Contracts_GetCode
+*/
+func (t *Tzkt) GetContractScript(address string) (Script, error) {
+	resp, err := t.get(fmt.Sprintf("/v1/contracts/%s/code", address), URLParameters{Key: "format", Value: "1"})
+	if err != nil {
+		return Script{}, errors.Wrapf(err, "failed to get script of '%s'", address)
+	}
+
+	// The code section holds sequences of instructions, which do not fit a type expression
+	var sections []struct {
+		Prim string            `json:"prim"`
+		Args []json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(resp, &sections); err != nil {
+		return Script{}, errors.Wrapf(err, "failed to unmarshal script of '%s'", address)
+	}
+
+	for _, section := range sections {
+		if section.Prim != "parameter" || len(section.Args) == 0 {
+			continue
+		}
+
+		var script Script
+		if err := json.Unmarshal(section.Args[0], &script.Parameter); err != nil {
+			return Script{}, errors.Wrapf(err, "failed to unmarshal parameter of '%s'", address)
+		}
+
+		return script, nil
+	}
+
+	return Script{}, errors.Errorf("failed to get script of '%s': missing parameter", address)
+}
@@ -0,0 +1,34 @@
+package tzkt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Cycle is a cycle of the chain and the range of levels it spans
+type Cycle struct {
+	Index      int `json:"index"`
+	FirstLevel int `json:"firstLevel"`
+	LastLevel  int `json:"lastLevel"`
+}
+
+/*
+GetCycle returns the cycle of index, including the cycles to come whose rights are known. The levels of a cycle are
+not derived from the current number of blocks per cycle, which protocols change (Granada doubled it).
+See: https://api.tzkt.io/#operation/Cycles_GetByIndex
+*/
+func (t *Tzkt) GetCycle(index int) (Cycle, error) {
+	resp, err := t.get(fmt.Sprintf("/v1/cycles/%d", index))
+	if err != nil {
+		return Cycle{}, errors.Wrapf(err, "failed to get cycle %d", index)
+	}
+
+	var cycle Cycle
+	if err := json.Unmarshal(resp, &cycle); err != nil {
+		return Cycle{}, errors.Wrapf(err, "failed to unmarshal cycle %d", index)
+	}
+
+	return cycle, nil
+}
@@ -0,0 +1,57 @@
+package tzkt
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+/*
+Delegate -
+see: https://api.tzkt.io/#operation/Delegates_Get
+*/
+type Delegate struct {
+	Alias             string    `json:"alias"`
+	Address           string    `json:"address"`
+	Active            bool      `json:"active"`
+	Balance           int       `json:"balance"`
+	StakingBalance    int       `json:"stakingBalance"`
+	NumDelegators     int       `json:"numDelegators"`
+	ActivationLevel   int       `json:"activationLevel"`
+	DeactivationLevel int       `json:"deactivationLevel"`
+	LastActivity      int       `json:"lastActivity"`
+	LastActivityTime  time.Time `json:"lastActivityTime"`
+}
+
+/*
+GetDelegates -
+See: https://api.tzkt.io/#operation/Delegates_Get
+*/
+func (t *Tzkt) GetDelegates(options ...URLParameters) ([]Delegate, error) {
+	resp, err := t.get("/v1/delegates", options...)
+	if err != nil {
+		return []Delegate{}, errors.Wrapf(err, "failed to get delegates")
+	}
+
+	var delegates []Delegate
+	if err := json.Unmarshal(resp, &delegates); err != nil {
+		return []Delegate{}, errors.Wrapf(err, "failed to unmarshal delegates")
+	}
+
+	return delegates, nil
+}
+
+// Active returns the parameter keeping the active delegates, or the inactive ones if active is false
+func Active(active bool) URLParameters {
+	return URLParameters{Key: "active", Value: strconv.FormatBool(active)}
+}
+
+// Page returns the parameters selecting a page of limit results, the first page being 0
+func Page(page, limit int) []URLParameters {
+	return []URLParameters{
+		{Key: "offset", Value: strconv.Itoa(page * limit)},
+		{Key: "limit", Value: strconv.Itoa(limit)},
+	}
+}
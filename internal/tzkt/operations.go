@@ -70,3 +70,48 @@ func (t *Tzkt) GetTransactions(options ...URLParameters) ([]Transaction, error)
 
 	return transactions, nil
 }
+
+/*
+Delegation -
+see: https://api.tzkt.io/#operation/Operations_GetDelegations
+*/
+type Delegation struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"`
+	Level     int       `json:"level"`
+	Timestamp time.Time `json:"timestamp"`
+	Block     string    `json:"block"`
+	Hash      string    `json:"hash"`
+	Sender    struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"sender"`
+	PrevDelegate *struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"prevDelegate"`
+	NewDelegate *struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"newDelegate"`
+	Amount int    `json:"amount"`
+	Status string `json:"status"`
+}
+
+/*
+GetDelegations -
+see: https://api.tzkt.io/#operation/Operations_GetDelegations
+*/
+func (t *Tzkt) GetDelegations(options ...URLParameters) ([]Delegation, error) {
+	resp, err := t.get("/v1/operations/delegations", options...)
+	if err != nil {
+		return []Delegation{}, errors.Wrapf(err, "failed to get delegations")
+	}
+
+	var delegations []Delegation
+	if err := json.Unmarshal(resp, &delegations); err != nil {
+		return []Delegation{}, errors.Wrap(err, "failed to get delegations")
+	}
+
+	return delegations, nil
+}
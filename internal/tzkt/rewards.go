@@ -40,6 +40,48 @@ type Delegator struct {
 	Fee                int                 `json:"fee"`
 	LiquidityProviders []LiquidityProvider `json:"liquidity_providers,omitempty"`
 	BlackListed        bool                `json:"blacklisted,omitempty"`
+	Accumulated        bool                `json:"accumulated,omitempty"`
+	CarriedRewards     int                 `json:"carried_rewards,omitempty"`
+	PartialRewards     int                 `json:"partial_rewards,omitempty"`
+	Manager            string              `json:"manager,omitempty"`
+	Insurance          int                 `json:"insurance,omitempty"`
+	SkipReason         string              `json:"skip_reason,omitempty"`
+	PayoutAddress      string              `json:"payout_address,omitempty"`
+	Campaign           string              `json:"campaign,omitempty"`
+	CampaignRebate     int                 `json:"campaign_rebate,omitempty"`
+	CampaignBonus      int                 `json:"campaign_bonus,omitempty"`
+}
+
+// Campaigns is what the campaigns of a baker cost it in a payout
+type Campaigns []Campaign
+
+// Campaign is what a campaign cost the baker in a payout: the fees rebated to its delegators and the bonuses paid to them
+type Campaign struct {
+	Spec       string `json:"spec"`
+	Delegators int    `json:"delegators"`
+	Rebates    int    `json:"rebates"`
+	Bonuses    int    `json:"bonuses"`
+}
+
+// Bonuses sums the bonuses the baker pays on top of the rewards of the delegators of its campaigns
+func (c Campaigns) Bonuses() int {
+	var bonuses int
+	for _, campaign := range c {
+		bonuses += campaign.Bonuses
+	}
+
+	return bonuses
+}
+
+// BondPool is the members of a bond pool sharing the baker's own rewards and fees
+type BondPool []BondPoolMember
+
+// BondPoolMember is a party contributing to the security deposits of the baker, paid according to its weight
+type BondPoolMember struct {
+	Address string  `json:"address"`
+	Weight  float64 `json:"weight"`
+	Share   float64 `json:"share"`
+	Rewards int     `json:"rewards"`
 }
 
 /*
@@ -48,13 +90,15 @@ This is an extra structure to be embedded in Delegators for the purpose of payin
 out Dexter Exchange Contracts.
 */
 type LiquidityProvider struct {
-	Address      string  `json:"address"`
-	Balance      int     `json:"balance"`
-	NetRewards   int     `json:"net_rewards"`
-	GrossRewards int     `json:"gross_rewards"`
-	Share        float64 `json:"share"`
-	Fee          int     `json:"fee"`
-	BlackListed  bool    `json:"blacklisted"`
+	Address       string  `json:"address"`
+	Balance       int     `json:"balance"`
+	NetRewards    int     `json:"net_rewards"`
+	GrossRewards  int     `json:"gross_rewards"`
+	Share         float64 `json:"share"`
+	Fee           int     `json:"fee"`
+	BlackListed   bool    `json:"blacklisted"`
+	SkipReason    string  `json:"skip_reason,omitempty"`
+	PayoutAddress string  `json:"payout_address,omitempty"`
 }
 
 /*
@@ -111,11 +155,32 @@ type RewardsSplit struct {
 	RevelationRewards           int        `json:"revelationRewards"`
 	RevelationLostRewards       int        `json:"revelationLostRewards"`
 	RevelationLostFees          int        `json:"revelationLostFees"`
+	Blocks                      int        `json:"blocks"`
+	BlockRewards                int        `json:"blockRewards"`
+	MissedBlocks                int        `json:"missedBlocks"`
+	MissedBlockRewards          int        `json:"missedBlockRewards"`
+	BlockFees                   int        `json:"blockFees"`
+	MissedBlockFees             int        `json:"missedBlockFees"`
 	Delegators                  Delegators `json:"delegators"`
 	OperationLink               []string   `json:"operation_links,omitempty"`
 	BakerRewards                int        `json:"baker_rewards,omitempty"`
 	BakerShare                  float64    `json:"baker_share,omitempty"`
 	BakerCollectedFees          int        `json:"collected_fees,omitempty"`
+	DenunciationLosses          int        `json:"denunciation_losses,omitempty"`
+	Skipped                     bool       `json:"skipped,omitempty"`
+	MissedRights                float64    `json:"missed_rights,omitempty"`
+	InsurancePaid               int        `json:"insurance_paid,omitempty"`
+	Donation                    int        `json:"donation,omitempty"`
+	DonationAddress             string     `json:"donation_address,omitempty"`
+	BondPool                    BondPool   `json:"bond_pool,omitempty"`
+	Campaigns                   Campaigns  `json:"campaigns,omitempty"`
+	Sweep                       int        `json:"sweep,omitempty"`
+	SweepAddress                string     `json:"sweep_address,omitempty"`
+	Funded                      int        `json:"funded,omitempty"`
+	Memo                        string     `json:"memo,omitempty"`
+	MemoHash                    string     `json:"memo_hash,omitempty"`
+	Protocol                    string     `json:"protocol,omitempty"`
+	RewardModel                 string     `json:"reward_model,omitempty"`
 }
 
 /*
@@ -0,0 +1,80 @@
+package tzstats
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+/*
+GetHead returns the tip of the chain indexed by TzStats, normalized into the head of tzkt
+See: https://tzstats.com/docs/api#tip
+*/
+func (t *TzStats) GetHead() (tzkt.Head, error) {
+	var tip struct {
+		Height    int       `json:"height"`
+		BestHash  string    `json:"best_hash"`
+		Protocol  string    `json:"protocol"`
+		Timestamp time.Time `json:"timestamp"`
+		Status    struct {
+			Status  string `json:"status"`
+			Blocks  int    `json:"blocks"`
+			Indexed int    `json:"indexed"`
+		} `json:"status"`
+	}
+	if err := t.get("/explorer/tip", url.Values{}, &tip); err != nil {
+		return tzkt.Head{}, errors.Wrap(err, "failed to get head")
+	}
+
+	return tzkt.Head{
+		Level:      tip.Height,
+		Hash:       tip.BestHash,
+		Protocol:   tip.Protocol,
+		Timestamp:  tip.Timestamp,
+		KnownLevel: tip.Status.Blocks,
+		LastSync:   tip.Timestamp,
+		Synced:     tip.Status.Status == "synced",
+	}, nil
+}
+
+// blockFields are the columns of the block table of the fields of tzkt blocks
+var blockFields = map[string]string{
+	"level":    "height",
+	"hash":     "hash",
+	"priority": "priority",
+	"baker":    "baker",
+}
+
+/*
+GetBlocks returns the blocks of the block table matching options, the tzkt query parameters of
+https://api.tzkt.io/#operation/Blocks_Get, with their header only: the operations of a block are not normalized.
+See: https://tzstats.com/docs/api#block-table
+*/
+func (t *TzStats) GetBlocks(options ...tzkt.URLParameters) (tzkt.Blocks, error) {
+	query, err := filters(options, blockFields)
+	if err != nil {
+		return tzkt.Blocks{}, errors.Wrap(err, "failed to get blocks")
+	}
+
+	rows, err := t.table("block", []string{"height", "cycle", "hash", "time", "priority", "n_endorsed_slots", "reward", "fee", "baker"}, query)
+	if err != nil {
+		return tzkt.Blocks{}, errors.Wrap(err, "failed to get blocks")
+	}
+
+	blocks := make(tzkt.Blocks, len(rows))
+	for i, row := range rows {
+		blocks[i].Level = row.int("height")
+		blocks[i].Cycle = row.int("cycle")
+		blocks[i].Hash = row.str("hash")
+		blocks[i].Timestamp = row.time("time")
+		blocks[i].Priority = row.int("priority")
+		blocks[i].Validations = row.int("n_endorsed_slots")
+		blocks[i].Reward = row.mutez("reward")
+		blocks[i].Fees = row.mutez("fee")
+		blocks[i].Baker = account{Address: row.str("baker")}
+	}
+
+	return blocks, nil
+}
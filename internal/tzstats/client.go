@@ -0,0 +1,291 @@
+package tzstats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+type client interface {
+	Do(req *http.Request) (*http.Response, error)
+	CloseIdleConnections()
+}
+
+/*
+TzStats is a client of the TzStats API, an indexer tzpay can use instead of tzkt. Its responses are normalized into
+the structures of the tzkt package, so that the payout calculator and every other user of tzkt.IFace work from either
+indexer, and the tzkt query parameters they pass are translated into the filters of the TzStats tables.
+*/
+type TzStats struct {
+	client client
+	Host   string
+	// ctx cancels the requests in flight once done, nil for none
+	ctx context.Context
+
+	mu              sync.Mutex
+	preservedCycles int // cached from the chain configuration
+}
+
+var _ tzkt.IFace = &TzStats{}
+
+// New returns a new client of the TzStats API at host
+func New(host string) *TzStats {
+	return NewWithContext(context.Background(), host)
+}
+
+// NewWithContext returns a new client of the TzStats API at host whose every request is bound to ctx
+func NewWithContext(ctx context.Context, host string) *TzStats {
+	return &TzStats{
+		ctx: ctx,
+		client: &http.Client{
+			Timeout: time.Second * 10,
+			Transport: &http.Transport{
+				Dial: (&net.Dialer{
+					Timeout: 10 * time.Second,
+				}).Dial,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		},
+		Host: cleanseHost(host),
+	}
+}
+
+func (t *TzStats) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+
+	return t.ctx
+}
+
+func (t *TzStats) get(path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(t.context(), http.MethodGet, fmt.Sprintf("%s%s", t.Host, path), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to construct request")
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to complete request")
+	}
+	defer resp.Body.Close()
+
+	byts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "could not read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response returned code %d with body %s", resp.StatusCode, string(byts))
+	}
+
+	t.client.CloseIdleConnections()
+
+	if err := json.Unmarshal(byts, v); err != nil {
+		return errors.Wrap(err, "failed to unmarshal response")
+	}
+
+	return nil
+}
+
+// row is a row of a TzStats table, by column
+type row map[string]json.RawMessage
+
+// pageSize is the number of rows asked of a table at once, below the most TzStats returns
+var pageSize = 10000
+
+/*
+table returns the rows of the TzStats table name matching query, with columns. Tables return every row as an array of
+its columns in the order requested, a page of rows at a time: pages are asked for with a limit and the cursor of the
+last row of the previous page, until a page comes back short. A limit in query caps the rows returned across pages.
+*/
+func (t *TzStats) table(name string, columns []string, query url.Values) ([]row, error) {
+	// The row id of the last row of a page is the cursor of the next one
+	requested := columns
+	if !contains(columns, "row_id") {
+		requested = append(append([]string{}, columns...), "row_id")
+	}
+	query.Set("columns", strings.Join(requested, ","))
+
+	max := -1
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if max, err = strconv.Atoi(limit); err != nil || max < 0 {
+			return nil, errors.Errorf("failed to query table %s: invalid limit '%s'", name, limit)
+		}
+	}
+
+	rows := []row{}
+	for max < 0 || len(rows) < max {
+		page := pageSize
+		if max >= 0 && max-len(rows) < page {
+			page = max - len(rows)
+		}
+		query.Set("limit", strconv.Itoa(page))
+
+		var values [][]json.RawMessage
+		if err := t.get(fmt.Sprintf("/tables/%s", name), query, &values); err != nil {
+			return nil, errors.Wrapf(err, "failed to query table %s", name)
+		}
+
+		for _, value := range values {
+			if len(value) != len(requested) {
+				return nil, errors.Errorf("failed to query table %s: expected %d columns, got %d", name, len(requested), len(value))
+			}
+			r := row{}
+			for j, column := range requested {
+				r[column] = value[j]
+			}
+			rows = append(rows, r)
+		}
+
+		if len(values) < page {
+			break
+		}
+		// The offset only skips rows before the first page
+		query.Del("offset")
+		query.Set("cursor", strconv.Itoa(rows[len(rows)-1].int("row_id")))
+	}
+
+	return rows, nil
+}
+
+func contains(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r row) str(column string) string {
+	var s string
+	json.Unmarshal(r[column], &s)
+	return s
+}
+
+func (r row) number(column string) float64 {
+	var n float64
+	if err := json.Unmarshal(r[column], &n); err != nil {
+		// Some columns are numbers encoded as strings
+		var s string
+		json.Unmarshal(r[column], &s)
+		fmt.Sscan(s, &n)
+	}
+	return n
+}
+
+func (r row) int(column string) int {
+	return int(r.number(column))
+}
+
+// mutez returns the amount in column, which TzStats gives in tez
+func (r row) mutez(column string) int {
+	return int(math.Round(r.number(column) * 1e6))
+}
+
+// bool returns the flag in column, which TzStats gives as true or false, or 1 or 0
+func (r row) bool(column string) bool {
+	var b bool
+	if err := json.Unmarshal(r[column], &b); err != nil {
+		return r.number(column) != 0
+	}
+	return b
+}
+
+// time returns the time in column, which TzStats gives in milliseconds since the epoch
+func (r row) time(column string) time.Time {
+	ms := int64(r.number(column))
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+}
+
+// modes are the TzStats filter modes of the tzkt filter modes
+var modes = map[string]string{
+	"":   "",
+	"eq": "",
+	"ne": ".ne",
+	"gt": ".gt",
+	"ge": ".gte",
+	"lt": ".lt",
+	"le": ".lte",
+	"in": ".in",
+	"ni": ".nin",
+}
+
+// ordered are the columns the rows of a table are in the order of, tables being ordered by row id and filled block by
+// block
+var ordered = map[string]bool{
+	"row_id": true,
+	"height": true,
+}
+
+/*
+filters translates the tzkt query parameters of options into filters of a TzStats table, fields naming the column of
+every tzkt field the table supports. Paging and sorting are kept, a field or mode TzStats has no equivalent of is an
+error rather than being dropped, as the rows returned would not be the ones asked for. TzStats only orders rows by
+their id, so a sort on a field whose column is not in that order is an error too.
+*/
+func filters(options []tzkt.URLParameters, fields map[string]string) (url.Values, error) {
+	query := url.Values{}
+	for _, option := range options {
+		switch option.Key {
+		case "limit", "offset":
+			query.Set(option.Key, option.Value)
+			continue
+		case "sort", "sort.asc", "sort.desc":
+			if column, ok := fields[option.Value]; !ok || !ordered[column] {
+				return nil, errors.Errorf("sort by '%s' is not supported by tzstats", option.Value)
+			}
+			query.Set("order", "asc")
+			if option.Key == "sort.desc" {
+				query.Set("order", "desc")
+			}
+			continue
+		}
+
+		field, mode := option.Key, ""
+		if i := strings.LastIndex(option.Key, "."); i >= 0 {
+			field, mode = option.Key[:i], option.Key[i+1:]
+		}
+		column, ok := fields[field]
+		op, known := modes[mode]
+		if !ok || !known {
+			return nil, errors.Errorf("filter '%s' is not supported by tzstats", option.Key)
+		}
+		query.Set(column+op, option.Value)
+	}
+
+	return query, nil
+}
+
+func cleanseHost(host string) string {
+	if len(host) == 0 {
+		return ""
+	}
+	if host[len(host)-1] == '/' {
+		host = host[:len(host)-1]
+	}
+	if !strings.HasPrefix(host, "http://") && !strings.HasPrefix(host, "https://") {
+		host = fmt.Sprintf("http://%s", host) //default to http
+	}
+	return host
+}
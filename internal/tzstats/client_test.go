@@ -0,0 +1,142 @@
+package tzstats
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+// newServer returns a client of a TzStats API answering every path with its body, and the queries it received
+func newServer(t *testing.T, bodies map[string]string) (*TzStats, map[string]url.Values, func()) {
+	queries := map[string]url.Values{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		queries[r.URL.Path] = r.URL.Query()
+		w.Write([]byte(body))
+	}))
+
+	return New(server.URL), queries, server.Close
+}
+
+func Test_filters(t *testing.T) {
+	query, err := filters([]tzkt.URLParameters{
+		{Key: "sender", Value: "tz1a"},
+		{Key: "level.ge", Value: "100"},
+		{Key: "level.lt", Value: "200"},
+		{Key: "sender.in", Value: "tz1a,tz1b"},
+		{Key: "sort.asc", Value: "id"},
+		{Key: "limit", Value: "10000"},
+	}, transactionFields)
+	assert.Nil(t, err)
+	assert.Equal(t, url.Values{
+		"sender":     {"tz1a"},
+		"height.gte": {"100"},
+		"height.lt":  {"200"},
+		"sender.in":  {"tz1a,tz1b"},
+		"order":      {"asc"},
+		"limit":      {"10000"},
+	}, query)
+
+	_, err = filters([]tzkt.URLParameters{{Key: "parameters.as", Value: "*addLiquidity*"}}, transactionFields)
+	test.CheckErr(t, true, "filter 'parameters.as' is not supported by tzstats", err)
+
+	_, err = filters([]tzkt.URLParameters{{Key: "level.between", Value: "1,2"}}, transactionFields)
+	test.CheckErr(t, true, "filter 'level.between' is not supported by tzstats", err)
+
+	query, err = filters([]tzkt.URLParameters{{Key: "sort.desc", Value: "level"}}, transactionFields)
+	assert.Nil(t, err)
+	assert.Equal(t, "desc", query.Get("order"))
+
+	_, err = filters([]tzkt.URLParameters{{Key: "sort.desc", Value: "amount"}}, transactionFields)
+	test.CheckErr(t, true, "sort by 'amount' is not supported by tzstats", err)
+}
+
+func Test_table(t *testing.T) {
+	client, queries, close := newServer(t, map[string]string{
+		"/tables/op": `[["tz1a",1.5,1,1596240000000,1],["tz1b","0.000001",0,0,2]]`,
+	})
+	defer close()
+
+	rows, err := client.table("op", []string{"sender", "volume", "is_success", "time"}, url.Values{"type": {"transaction"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "sender,volume,is_success,time,row_id", queries["/tables/op"].Get("columns"))
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "tz1a", rows[0].str("sender"))
+	assert.Equal(t, 1500000, rows[0].mutez("volume"))
+	assert.True(t, rows[0].bool("is_success"))
+	assert.Equal(t, int64(1596240000), rows[0].time("time").Unix())
+	assert.Equal(t, 1, rows[1].mutez("volume"))
+	assert.False(t, rows[1].bool("is_success"))
+	assert.True(t, rows[1].time("time").IsZero())
+
+	_, err = client.table("op", []string{"sender"}, url.Values{})
+	test.CheckErr(t, true, "failed to query table op: expected 2 columns, got 5", err)
+
+	_, err = client.table("income", []string{"sender"}, url.Values{})
+	test.CheckErr(t, true, "failed to query table income: response returned code 404", err)
+}
+
+func Test_table_pages(t *testing.T) {
+	pageSize = 2
+	defer func() { pageSize = 10000 }()
+
+	// Five rows with ids 10 to 14, paged after the cursor
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		cursor, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		var rows []string
+		for id := 10; id < 15 && len(rows) < limit; id++ {
+			if id > cursor {
+				rows = append(rows, fmt.Sprintf(`["tz1%d",%d]`, id, id))
+			}
+		}
+		w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	}))
+	defer server.Close()
+	client := New(server.URL)
+
+	rows, err := client.table("snapshot", []string{"address"}, url.Values{"cycle": {"263"}})
+	assert.Nil(t, err)
+	assert.Len(t, rows, 5)
+	assert.Equal(t, "tz114", rows[4].str("address"))
+	assert.Len(t, queries, 3)
+	assert.Equal(t, "", queries[0].Get("cursor"))
+	assert.Equal(t, "11", queries[1].Get("cursor"))
+	assert.Equal(t, "13", queries[2].Get("cursor"))
+	assert.Equal(t, "263", queries[2].Get("cycle"))
+
+	// A limit caps the rows across pages
+	queries = nil
+	rows, err = client.table("snapshot", []string{"address"}, url.Values{"limit": {"3"}})
+	assert.Nil(t, err)
+	assert.Len(t, rows, 3)
+	assert.Len(t, queries, 2)
+	assert.Equal(t, "1", queries[1].Get("limit"))
+}
+
+func Test_get_cancelled(t *testing.T) {
+	client, _, close := newServer(t, map[string]string{"/tables/op": `[]`})
+	defer close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client = NewWithContext(ctx, client.Host)
+
+	_, err := client.table("op", []string{"sender"}, url.Values{})
+	test.CheckErr(t, true, "context canceled", err)
+}
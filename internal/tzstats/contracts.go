@@ -0,0 +1,44 @@
+package tzstats
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+/*
+GetContractScript returns the parameter type of the contract at address, from its script in micheline
+See: https://tzstats.com/docs/api#contract-script
+*/
+func (t *TzStats) GetContractScript(address string) (tzkt.Script, error) {
+	// The code section holds sequences of instructions, which do not fit a type expression
+	var contract struct {
+		Script struct {
+			Code []struct {
+				Prim string            `json:"prim"`
+				Args []json.RawMessage `json:"args"`
+			} `json:"code"`
+		} `json:"script"`
+	}
+	if err := t.get(fmt.Sprintf("/explorer/contract/%s/script", address), url.Values{"prim": []string{"1"}}, &contract); err != nil {
+		return tzkt.Script{}, errors.Wrapf(err, "failed to get script of '%s'", address)
+	}
+
+	for _, section := range contract.Script.Code {
+		if section.Prim != "parameter" || len(section.Args) == 0 {
+			continue
+		}
+
+		var script tzkt.Script
+		if err := json.Unmarshal(section.Args[0], &script.Parameter); err != nil {
+			return tzkt.Script{}, errors.Wrapf(err, "failed to unmarshal parameter of '%s'", address)
+		}
+
+		return script, nil
+	}
+
+	return tzkt.Script{}, errors.Errorf("failed to get script of '%s': missing parameter", address)
+}
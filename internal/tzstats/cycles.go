@@ -0,0 +1,30 @@
+package tzstats
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+/*
+GetCycle returns the cycle of index and the range of levels it spans, normalized into the cycle of tzkt
+See: https://tzstats.com/docs/api#cycle
+*/
+func (t *TzStats) GetCycle(index int) (tzkt.Cycle, error) {
+	var cycle struct {
+		Cycle       int `json:"cycle"`
+		StartHeight int `json:"start_height"`
+		EndHeight   int `json:"end_height"`
+	}
+	if err := t.get(fmt.Sprintf("/explorer/cycle/%d", index), url.Values{}, &cycle); err != nil {
+		return tzkt.Cycle{}, errors.Wrapf(err, "failed to get cycle %d", index)
+	}
+
+	return tzkt.Cycle{
+		Index:      cycle.Cycle,
+		FirstLevel: cycle.StartHeight,
+		LastLevel:  cycle.EndHeight,
+	}, nil
+}
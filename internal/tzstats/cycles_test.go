@@ -0,0 +1,23 @@
+package tzstats
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetCycle(t *testing.T) {
+	client, _, close := newServer(t, map[string]string{
+		"/explorer/cycle/394": `{"cycle":394,"start_height":1589249,"end_height":1597440,"progress":100}`,
+	})
+	defer close()
+
+	cycle, err := client.GetCycle(394)
+	assert.Nil(t, err)
+	assert.Equal(t, tzkt.Cycle{Index: 394, FirstLevel: 1589249, LastLevel: 1597440}, cycle)
+
+	_, err = client.GetCycle(395)
+	test.CheckErr(t, true, "failed to get cycle 395", err)
+}
@@ -0,0 +1,51 @@
+package tzstats
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// delegateFields are the columns of the account table of the fields of tzkt delegates
+var delegateFields = map[string]string{
+	"address": "address",
+	"active":  "is_active_delegate",
+}
+
+/*
+GetDelegates returns the delegates of the account table matching options, the tzkt query parameters of
+https://api.tzkt.io/#operation/Delegates_Get. TzStats has no aliases, which are left empty.
+See: https://tzstats.com/docs/api#account-table
+*/
+func (t *TzStats) GetDelegates(options ...tzkt.URLParameters) ([]tzkt.Delegate, error) {
+	query, err := filters(options, delegateFields)
+	if err != nil {
+		return []tzkt.Delegate{}, errors.Wrap(err, "failed to get delegates")
+	}
+	query.Set("is_delegate", "1")
+
+	rows, err := t.table("account", []string{
+		"address", "is_active_delegate", "spendable_balance", "frozen_deposits", "frozen_rewards", "frozen_fees",
+		"delegated_balance", "active_delegations", "delegate_since", "delegate_until", "last_seen", "last_seen_time",
+	}, query)
+	if err != nil {
+		return []tzkt.Delegate{}, errors.Wrap(err, "failed to get delegates")
+	}
+
+	delegates := []tzkt.Delegate{}
+	for _, row := range rows {
+		balance := row.mutez("spendable_balance") + row.mutez("frozen_deposits") + row.mutez("frozen_rewards") + row.mutez("frozen_fees")
+		delegates = append(delegates, tzkt.Delegate{
+			Address:           row.str("address"),
+			Active:            row.bool("is_active_delegate"),
+			Balance:           balance,
+			StakingBalance:    balance + row.mutez("delegated_balance"),
+			NumDelegators:     row.int("active_delegations"),
+			ActivationLevel:   row.int("delegate_since"),
+			DeactivationLevel: row.int("delegate_until"),
+			LastActivity:      row.int("last_seen"),
+			LastActivityTime:  row.time("last_seen_time"),
+		})
+	}
+
+	return delegates, nil
+}
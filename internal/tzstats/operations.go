@@ -0,0 +1,179 @@
+package tzstats
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// account is the type of the accounts the operations of tzkt refer to
+type account = struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+var transactionColumns = []string{
+	"row_id", "type", "height", "time", "hash", "counter", "status", "gas_limit", "gas_used", "storage_limit",
+	"storage_size", "fee", "burned", "volume", "sender", "receiver", "creator",
+}
+
+// transactionFields are the columns of the op table of the fields of tzkt transactions
+var transactionFields = map[string]string{
+	"id":        "row_id",
+	"level":     "height",
+	"hash":      "hash",
+	"status":    "status",
+	"sender":    "sender",
+	"target":    "receiver",
+	"initiator": "creator",
+	"amount":    "volume",
+}
+
+/*
+GetTransactions returns the transactions of the op table matching options, the tzkt query parameters of
+https://api.tzkt.io/#operation/Operations_GetTransactions, oldest first. The initiator of an internal transaction is
+its creator in TzStats, and anyof.sender.target queries the transactions of either role.
+See: https://tzstats.com/docs/api#operation-table
+*/
+func (t *TzStats) GetTransactions(options ...tzkt.URLParameters) ([]tzkt.Transaction, error) {
+	var rest []tzkt.URLParameters
+	var anyof string
+	for _, option := range options {
+		if option.Key == "anyof.sender.target" {
+			anyof = option.Value
+			continue
+		}
+		rest = append(rest, option)
+	}
+
+	if anyof == "" {
+		return t.transactions(rest)
+	}
+
+	seen := map[int]struct{}{}
+	transactions := []tzkt.Transaction{}
+	for _, role := range []string{"sender", "target"} {
+		found, err := t.transactions(append(rest, tzkt.URLParameters{Key: role, Value: anyof}))
+		if err != nil {
+			return []tzkt.Transaction{}, err
+		}
+		for _, transaction := range found {
+			if _, ok := seen[transaction.ID]; !ok {
+				seen[transaction.ID] = struct{}{}
+				transactions = append(transactions, transaction)
+			}
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].ID < transactions[j].ID
+	})
+
+	// Either role is limited on its own, the transactions of both are limited together
+	for _, option := range rest {
+		if limit, err := strconv.Atoi(option.Value); option.Key == "limit" && err == nil && limit < len(transactions) {
+			transactions = transactions[:limit]
+		}
+	}
+
+	return transactions, nil
+}
+
+func (t *TzStats) transactions(options []tzkt.URLParameters) ([]tzkt.Transaction, error) {
+	query, err := filters(options, transactionFields)
+	if err != nil {
+		return []tzkt.Transaction{}, errors.Wrap(err, "failed to get transactions")
+	}
+	query.Set("type", "transaction")
+	if query.Get("order") == "" {
+		query.Set("order", "asc")
+	}
+
+	rows, err := t.table("op", transactionColumns, query)
+	if err != nil {
+		return []tzkt.Transaction{}, errors.Wrap(err, "failed to get transactions")
+	}
+
+	transactions := []tzkt.Transaction{}
+	for _, row := range rows {
+		transaction := tzkt.Transaction{
+			Type:         row.str("type"),
+			ID:           row.int("row_id"),
+			Level:        row.int("height"),
+			Timestamp:    row.time("time"),
+			Hash:         row.str("hash"),
+			Counter:      row.int("counter"),
+			GasLimit:     row.int("gas_limit"),
+			GasUsed:      row.int("gas_used"),
+			StorageLimit: row.int("storage_limit"),
+			StorageUsed:  row.int("storage_size"),
+			BakerFee:     row.mutez("fee"),
+			StorageFee:   row.mutez("burned"),
+			Amount:       row.mutez("volume"),
+			Status:       row.str("status"),
+		}
+		transaction.Sender = account{Address: row.str("sender")}
+		transaction.Target = account{Address: row.str("receiver")}
+		transaction.Initiator = account{Address: row.str("creator")}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+// delegationFields are the columns of the op table of the fields of tzkt delegations
+var delegationFields = map[string]string{
+	"id":           "row_id",
+	"level":        "height",
+	"hash":         "hash",
+	"status":       "status",
+	"sender":       "sender",
+	"prevDelegate": "receiver",
+	"newDelegate":  "delegate",
+}
+
+/*
+GetDelegations returns the delegations of the op table matching options, the tzkt query parameters of
+https://api.tzkt.io/#operation/Operations_GetDelegations, oldest first. The previous delegate of a delegation is its
+receiver in TzStats.
+See: https://tzstats.com/docs/api#operation-table
+*/
+func (t *TzStats) GetDelegations(options ...tzkt.URLParameters) ([]tzkt.Delegation, error) {
+	query, err := filters(options, delegationFields)
+	if err != nil {
+		return []tzkt.Delegation{}, errors.Wrap(err, "failed to get delegations")
+	}
+	query.Set("type", "delegation")
+	if query.Get("order") == "" {
+		query.Set("order", "asc")
+	}
+
+	rows, err := t.table("op", []string{"row_id", "type", "height", "time", "hash", "status", "volume", "sender", "receiver", "delegate"}, query)
+	if err != nil {
+		return []tzkt.Delegation{}, errors.Wrap(err, "failed to get delegations")
+	}
+
+	delegations := []tzkt.Delegation{}
+	for _, row := range rows {
+		delegation := tzkt.Delegation{
+			Type:      row.str("type"),
+			ID:        row.int("row_id"),
+			Level:     row.int("height"),
+			Timestamp: row.time("time"),
+			Hash:      row.str("hash"),
+			Amount:    row.mutez("volume"),
+			Status:    row.str("status"),
+		}
+		delegation.Sender = account{Address: row.str("sender")}
+		if prev := row.str("receiver"); prev != "" {
+			delegation.PrevDelegate = &account{Address: prev}
+		}
+		if next := row.str("delegate"); next != "" {
+			delegation.NewDelegate = &account{Address: next}
+		}
+		delegations = append(delegations, delegation)
+	}
+
+	return delegations, nil
+}
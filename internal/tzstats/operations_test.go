@@ -0,0 +1,60 @@
+package tzstats
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetTransactions(t *testing.T) {
+	client, queries, close := newServer(t, map[string]string{
+		"/tables/op": `[[42,"transaction",1000,1596240000000,"ooHash",7,"applied",1420,1320,300,0,0.00142,0.257,12.5,"tz1wallet","tz1delegator",""]]`,
+	})
+	defer close()
+
+	transactions, err := client.GetTransactions(
+		tzkt.URLParameters{Key: "sender", Value: "tz1wallet"},
+		tzkt.URLParameters{Key: "level.ge", Value: "1000"},
+		tzkt.URLParameters{Key: "status", Value: "applied"},
+	)
+	assert.Nil(t, err)
+	query := queries["/tables/op"]
+	assert.Equal(t, "transaction", query.Get("type"))
+	assert.Equal(t, "tz1wallet", query.Get("sender"))
+	assert.Equal(t, "1000", query.Get("height.gte"))
+	assert.Equal(t, "asc", query.Get("order"))
+
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, 42, transactions[0].ID)
+	assert.Equal(t, 1000, transactions[0].Level)
+	assert.Equal(t, "ooHash", transactions[0].Hash)
+	assert.Equal(t, 1420, transactions[0].BakerFee)
+	assert.Equal(t, 257000, transactions[0].StorageFee)
+	assert.Equal(t, 12500000, transactions[0].Amount)
+	assert.Equal(t, "tz1wallet", transactions[0].Sender.Address)
+	assert.Equal(t, "tz1delegator", transactions[0].Target.Address)
+
+	// Either role is queried, and a transaction matching both is returned once
+	transactions, err = client.GetTransactions(tzkt.URLParameters{Key: "anyof.sender.target", Value: "tz1wallet"})
+	assert.Nil(t, err)
+	assert.Len(t, transactions, 1)
+	assert.Equal(t, "tz1wallet", queries["/tables/op"].Get("receiver"))
+}
+
+func Test_GetDelegations(t *testing.T) {
+	client, queries, close := newServer(t, map[string]string{
+		"/tables/op": `[[43,"delegation",1001,1596240000000,"ooHash","applied",0,"tz1delegator","tz1baker",""]]`,
+	})
+	defer close()
+
+	delegations, err := client.GetDelegations(tzkt.URLParameters{Key: "prevDelegate", Value: "tz1baker"})
+	assert.Nil(t, err)
+	assert.Equal(t, "delegation", queries["/tables/op"].Get("type"))
+	assert.Equal(t, "tz1baker", queries["/tables/op"].Get("receiver"))
+
+	assert.Len(t, delegations, 1)
+	assert.Equal(t, "tz1delegator", delegations[0].Sender.Address)
+	assert.Equal(t, "tz1baker", delegations[0].PrevDelegate.Address)
+	assert.Nil(t, delegations[0].NewDelegate)
+}
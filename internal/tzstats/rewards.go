@@ -0,0 +1,167 @@
+package tzstats
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+var incomeColumns = []string{
+	"cycle",
+	"balance",
+	"delegated",
+	"n_delegations",
+	"baking_income",
+	"endorsing_income",
+	"double_baking_income",
+	"double_endorsing_income",
+	"seed_income",
+	"fees_income",
+	"missed_baking_income",
+	"missed_endorsing_income",
+	"stolen_baking_income",
+	"lost_accusation_fees",
+	"lost_accusation_rewards",
+	"lost_accusation_deposits",
+	"lost_revelation_fees",
+	"lost_revelation_rewards",
+	"n_blocks_baked",
+	"n_blocks_lost",
+	"n_blocks_stolen",
+	"n_slots_endorsed",
+	"n_slots_missed",
+}
+
+/*
+GetRewardsSplit returns the rewards of delegate for cycle from the income table, and its delegators from the roll
+snapshot selected for cycle, normalized into the rewards split of tzkt. TzStats does not tell the baking rights of a
+baker from those it stole from others, nor accusations of double baking from those of double endorsing, so blocks
+stolen are counted as extra blocks and every accusation as double baking; it does not track the fees of missed blocks
+either, which are 0. Only limit and offset are supported in options, paging the delegators sorted by balance.
+See: https://tzstats.com/docs/api#income-table
+*/
+func (t *TzStats) GetRewardsSplit(delegate string, cycle int, options ...tzkt.URLParameters) (tzkt.RewardsSplit, error) {
+	query := url.Values{}
+	query.Set("address", delegate)
+	query.Set("cycle", strconv.Itoa(cycle))
+	rows, err := t.table("income", incomeColumns, query)
+	if err != nil {
+		return tzkt.RewardsSplit{}, errors.Wrap(err, "failed to get reward split")
+	} else if len(rows) == 0 {
+		return tzkt.RewardsSplit{}, errors.Errorf("failed to get reward split: no income of '%s' for cycle %d", delegate, cycle)
+	}
+
+	income := rows[0]
+	rewardsSplit := tzkt.RewardsSplit{
+		Cycle:                    cycle,
+		StakingBalance:           income.mutez("balance") + income.mutez("delegated"),
+		DelegatedBalance:         income.mutez("delegated"),
+		NumDelegators:            income.int("n_delegations"),
+		Blocks:                   income.int("n_blocks_baked"),
+		BlockRewards:             income.mutez("baking_income"),
+		BlockFees:                income.mutez("fees_income"),
+		OwnBlocks:                income.int("n_blocks_baked") - income.int("n_blocks_stolen"),
+		OwnBlockRewards:          income.mutez("baking_income") - income.mutez("stolen_baking_income"),
+		OwnBlockFees:             income.mutez("fees_income"),
+		ExtraBlocks:              income.int("n_blocks_stolen"),
+		ExtraBlockRewards:        income.mutez("stolen_baking_income"),
+		MissedBlocks:             income.int("n_blocks_lost"),
+		MissedBlockRewards:       income.mutez("missed_baking_income"),
+		MissedOwnBlocks:          income.int("n_blocks_lost"),
+		MissedOwnBlockRewards:    income.mutez("missed_baking_income"),
+		Endorsements:             income.int("n_slots_endorsed"),
+		EndorsementRewards:       income.mutez("endorsing_income"),
+		MissedEndorsements:       income.int("n_slots_missed"),
+		MissedEndorsementRewards: income.mutez("missed_endorsing_income"),
+		RevelationRewards:        income.mutez("seed_income"),
+		RevelationLostRewards:    income.mutez("lost_revelation_rewards"),
+		RevelationLostFees:       income.mutez("lost_revelation_fees"),
+		DoubleBakingRewards:      income.mutez("double_baking_income"),
+		DoubleBakingLostDeposits: income.mutez("lost_accusation_deposits"),
+		DoubleBakingLostRewards:  income.mutez("lost_accusation_rewards"),
+		DoubleBakingLostFees:     income.mutez("lost_accusation_fees"),
+		DoubleEndorsingRewards:   income.mutez("double_endorsing_income"),
+	}
+
+	if rewardsSplit.Delegators, err = t.delegators(delegate, cycle, options); err != nil {
+		return tzkt.RewardsSplit{}, errors.Wrap(err, "failed to get reward split")
+	}
+
+	return rewardsSplit, nil
+}
+
+// delegators returns the delegators of delegate in the roll snapshot selected for cycle, by balance
+func (t *TzStats) delegators(delegate string, cycle int, options []tzkt.URLParameters) (tzkt.Delegators, error) {
+	preservedCycles, err := t.getPreservedCycles()
+	if err != nil {
+		return nil, err
+	}
+
+	// The snapshot of the rights of a cycle is taken preserved_cycles + 2 cycles before it
+	query := url.Values{}
+	query.Set("cycle", strconv.Itoa(cycle-preservedCycles-2))
+	query.Set("is_selected", "1")
+	query.Set("delegate", delegate)
+	rows, err := t.table("snapshot", []string{"address", "balance"}, query)
+	if err != nil {
+		return nil, err
+	}
+
+	delegators := tzkt.Delegators{}
+	for _, row := range rows {
+		if address := row.str("address"); address != delegate {
+			delegators = append(delegators, tzkt.Delegator{Address: address, Balance: row.mutez("balance")})
+		}
+	}
+	sort.SliceStable(delegators, func(i, j int) bool {
+		return delegators[i].Balance > delegators[j].Balance
+	})
+
+	offset, limit := 0, len(delegators)
+	for _, option := range options {
+		if option.Key != "offset" && option.Key != "limit" {
+			return nil, errors.Errorf("option '%s' of the reward split is not supported by tzstats", option.Key)
+		}
+		n, err := strconv.Atoi(option.Value)
+		if err != nil || n < 0 {
+			return nil, errors.Errorf("invalid %s '%s' of the reward split", option.Key, option.Value)
+		}
+		if option.Key == "offset" {
+			offset = n
+		} else {
+			limit = n
+		}
+	}
+	if offset > len(delegators) {
+		offset = len(delegators)
+	}
+	if limit < len(delegators)-offset {
+		return delegators[offset : offset+limit], nil
+	}
+
+	return delegators[offset:], nil
+}
+
+// getPreservedCycles returns the number of cycles rewards are frozen for, from the configuration of the chain
+func (t *TzStats) getPreservedCycles() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.preservedCycles > 0 {
+		return t.preservedCycles, nil
+	}
+
+	var config struct {
+		PreservedCycles int `json:"preserved_cycles"`
+	}
+	if err := t.get("/explorer/config/head", url.Values{}, &config); err != nil {
+		return 0, errors.Wrap(err, "failed to get chain configuration")
+	} else if config.PreservedCycles <= 0 {
+		return 0, errors.New("failed to get chain configuration: missing preserved cycles")
+	}
+	t.preservedCycles = config.PreservedCycles
+
+	return t.preservedCycles, nil
+}
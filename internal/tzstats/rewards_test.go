@@ -0,0 +1,63 @@
+package tzstats
+
+import (
+	"testing"
+
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetRewardsSplit(t *testing.T) {
+	client, queries, close := newServer(t, map[string]string{
+		"/tables/income":        `[[270,185183.0,555430.526884,107,191.25,157.5,0,0,0,0.04718,77.5,20,0,0,0,0,0,0,5,2,0,126,16,1]]`,
+		"/explorer/config/head": `{"preserved_cycles":5}`,
+		"/tables/snapshot": `[
+			["tz1baker",185183.0,1],
+			["tz1small",1000.5,2],
+			["KT1large",60545.965782,3]
+		]`,
+	})
+	defer close()
+
+	rewardsSplit, err := client.GetRewardsSplit("tz1baker", 270)
+	assert.Nil(t, err)
+	assert.Equal(t, "tz1baker", queries["/tables/income"].Get("address"))
+	assert.Equal(t, "270", queries["/tables/income"].Get("cycle"))
+	assert.Equal(t, "263", queries["/tables/snapshot"].Get("cycle"))
+	assert.Equal(t, "1", queries["/tables/snapshot"].Get("is_selected"))
+	assert.Equal(t, "tz1baker", queries["/tables/snapshot"].Get("delegate"))
+
+	assert.Equal(t, 270, rewardsSplit.Cycle)
+	assert.Equal(t, 740613526884, rewardsSplit.StakingBalance)
+	assert.Equal(t, 555430526884, rewardsSplit.DelegatedBalance)
+	assert.Equal(t, 107, rewardsSplit.NumDelegators)
+	assert.Equal(t, 5, rewardsSplit.OwnBlocks)
+	assert.Equal(t, 191250000, rewardsSplit.OwnBlockRewards)
+	assert.Equal(t, 47180, rewardsSplit.OwnBlockFees)
+	assert.Equal(t, 2, rewardsSplit.MissedOwnBlocks)
+	assert.Equal(t, 77500000, rewardsSplit.MissedOwnBlockRewards)
+	assert.Equal(t, 126, rewardsSplit.Endorsements)
+	assert.Equal(t, 157500000, rewardsSplit.EndorsementRewards)
+	assert.Equal(t, 16, rewardsSplit.MissedEndorsements)
+	assert.Equal(t, 20000000, rewardsSplit.MissedEndorsementRewards)
+	assert.Equal(t, tzkt.Delegators{
+		{Address: "KT1large", Balance: 60545965782},
+		{Address: "tz1small", Balance: 1000500000},
+	}, rewardsSplit.Delegators)
+
+	rewardsSplit, err = client.GetRewardsSplit("tz1baker", 270, tzkt.URLParameters{Key: "limit", Value: "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, tzkt.Delegators{{Address: "KT1large", Balance: 60545965782}}, rewardsSplit.Delegators)
+
+	_, err = client.GetRewardsSplit("tz1baker", 270, tzkt.URLParameters{Key: "sort", Value: "balance"})
+	test.CheckErr(t, true, "option 'sort' of the reward split is not supported by tzstats", err)
+}
+
+func Test_GetRewardsSplit_noIncome(t *testing.T) {
+	client, _, close := newServer(t, map[string]string{"/tables/income": `[]`})
+	defer close()
+
+	_, err := client.GetRewardsSplit("tz1baker", 270)
+	test.CheckErr(t, true, "no income of 'tz1baker' for cycle 270", err)
+}
@@ -0,0 +1,59 @@
+package tzstats
+
+import (
+	"github.com/goat-systems/tzpay/v3/internal/tzkt"
+	"github.com/pkg/errors"
+)
+
+// rightFields are the columns of the rights table of the fields of tzkt rights
+var rightFields = map[string]string{
+	"type":     "type",
+	"cycle":    "cycle",
+	"level":    "height",
+	"priority": "priority",
+	"baker":    "address",
+}
+
+/*
+GetRights returns the rights of the rights table matching options, the tzkt query parameters of
+https://api.tzkt.io/#operation/Rights_Get. A right above the tip is future, one the baker lost or missed is missed,
+and every other right is realized. Every endorsing right is a single slot.
+See: https://tzstats.com/docs/api#rights-table
+*/
+func (t *TzStats) GetRights(options ...tzkt.URLParameters) (tzkt.Rights, error) {
+	query, err := filters(options, rightFields)
+	if err != nil {
+		return tzkt.Rights{}, errors.Wrap(err, "failed to get rights")
+	}
+
+	head, err := t.GetHead()
+	if err != nil {
+		return tzkt.Rights{}, errors.Wrap(err, "failed to get rights")
+	}
+
+	rows, err := t.table("rights", []string{"type", "height", "cycle", "priority", "address", "is_lost", "is_missed"}, query)
+	if err != nil {
+		return tzkt.Rights{}, errors.Wrap(err, "failed to get rights")
+	}
+
+	rights := make(tzkt.Rights, len(rows))
+	for i, row := range rows {
+		rights[i].Type = row.str("type")
+		rights[i].Level = row.int("height")
+		rights[i].Cycle = row.int("cycle")
+		rights[i].Priority = row.int("priority")
+		rights[i].Slots = 1
+		rights[i].Baker = account{Address: row.str("address")}
+
+		switch {
+		case rights[i].Level > head.Level:
+			rights[i].Status = "future"
+		case row.bool("is_lost") || row.bool("is_missed"):
+			rights[i].Status = "missed"
+		default:
+			rights[i].Status = "realized"
+		}
+	}
+
+	return rights, nil
+}
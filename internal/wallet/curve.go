@@ -0,0 +1,129 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"strings"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/goat-systems/tzpay/v3/internal/signer"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Curve is the elliptic curve of the key of a payout wallet
+type Curve string
+
+const (
+	// Ed25519 keys have tz1 addresses
+	Ed25519 Curve = "ed25519"
+	// P256 keys, on the NIST P-256 curve, have tz3 addresses
+	P256 Curve = "p256"
+)
+
+var (
+	edeskPrefix = []byte{7, 90, 60, 179, 41}
+	p2eskPrefix = []byte{9, 48, 57, 115, 171}
+)
+
+// ParseCurve returns the curve named, by name or by the prefix of its addresses
+func ParseCurve(curve string) (Curve, error) {
+	switch strings.ToLower(curve) {
+	case "ed25519", "tz1":
+		return Ed25519, nil
+	case "p256", "p-256", "tz3":
+		return P256, nil
+	case "secp256k1", "tz2":
+		return "", errors.New("invalid curve 'secp256k1': tzpay only signs with secp256k1 keys held in AWS KMS or on a PKCS#11 token")
+	}
+
+	return "", errors.Errorf("invalid curve '%s': expected ed25519 or p256", curve)
+}
+
+// Generate generates a new payout wallet on curve encrypted with password
+func Generate(curve Curve, password string) (Wallet, error) {
+	if password == "" {
+		return Wallet{}, errors.New("failed to generate wallet: missing password")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Wallet{}, errors.Wrap(err, "failed to generate wallet")
+	}
+
+	var wallet Wallet
+	var err error
+	switch curve {
+	case Ed25519:
+		wallet, err = FromSeed(secret, password)
+	case P256:
+		wallet, err = fromP256(secret, password)
+	default:
+		return Wallet{}, errors.Errorf("failed to generate wallet: invalid curve '%s'", curve)
+	}
+	if err != nil {
+		return Wallet{}, errors.Wrap(err, "failed to generate wallet")
+	}
+
+	return wallet, nil
+}
+
+func fromP256(secret []byte, password string) (Wallet, error) {
+	key, err := signer.NewP256(secret)
+	if err != nil {
+		return Wallet{}, err
+	}
+
+	esk, err := encrypt(p2eskPrefix, secret, password)
+	if err != nil {
+		return Wallet{}, err
+	}
+
+	return Wallet{
+		Curve:     P256,
+		Address:   key.Address(),
+		PublicKey: key.PublicKey(),
+		Esk:       esk,
+		Password:  password,
+	}, nil
+}
+
+// Decrypt returns the curve and the secret key of an edesk or p2esk encrypted secret key
+func Decrypt(esk, password string) (Curve, []byte, error) {
+	decoded := base58.Decode(esk)
+	if len(decoded) < 5+8+4 {
+		return "", nil, errors.New("failed to decrypt key: invalid encrypted secret key")
+	}
+
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return "", nil, errors.New("failed to decrypt key: invalid checksum")
+	}
+
+	var curve Curve
+	switch {
+	case bytes.HasPrefix(payload, edeskPrefix):
+		curve = Ed25519
+	case bytes.HasPrefix(payload, p2eskPrefix):
+		curve = P256
+	default:
+		return "", nil, errors.New("failed to decrypt key: expected an edesk or p2esk encrypted secret key")
+	}
+
+	salt, encrypted := payload[5:13], payload[13:]
+	var secret [32]byte
+	copy(secret[:], pbkdf2.Key([]byte(password), salt, 32768, 32, sha512.New))
+
+	var nonce [24]byte
+	decrypted, ok := secretbox.Open(nil, encrypted, &nonce, &secret)
+	if !ok {
+		return "", nil, errors.New("failed to decrypt key: invalid password")
+	}
+
+	return curve, decrypted, nil
+}
@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/signer"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Generate(t *testing.T) {
+	cases := []struct {
+		name     string
+		curve    Curve
+		password string
+		prefixes []string
+		wantErr  bool
+		contains string
+	}{
+		{
+			name:     "generates ed25519 wallet",
+			curve:    Ed25519,
+			password: "password12345##",
+			prefixes: []string{"tz1", "edpk", "edesk"},
+		},
+		{
+			name:     "generates p256 wallet",
+			curve:    P256,
+			password: "password12345##",
+			prefixes: []string{"tz3", "p2pk", "p2esk"},
+		},
+		{
+			name:     "handles missing password",
+			curve:    P256,
+			wantErr:  true,
+			contains: "missing password",
+		},
+		{
+			name:     "handles invalid curve",
+			curve:    Curve("secp256k1"),
+			password: "password12345##",
+			wantErr:  true,
+			contains: "invalid curve",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := Generate(tt.curve, tt.password)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, tt.curve, w.Curve)
+			assert.Equal(t, tt.prefixes[0], w.Address[:3])
+			assert.Equal(t, tt.prefixes[1], w.PublicKey[:4])
+			assert.Equal(t, tt.prefixes[2], w.Esk[:5])
+
+			curve, secret, err := Decrypt(w.Esk, tt.password)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.curve, curve)
+
+			if curve == P256 {
+				key, err := signer.NewP256(secret)
+				assert.Nil(t, err)
+				assert.Equal(t, w.Address, key.Address())
+				return
+			}
+
+			key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Bytes: secret})
+			assert.Nil(t, err)
+			assert.Equal(t, w.Address, key.PubKey.GetPublicKeyHash())
+		})
+	}
+}
+
+func Test_Decrypt(t *testing.T) {
+	esk := "edesk1fddn27MaLcQVEdZpAYiyGQNm6UjtWiBfNP2ZenTy3CFsoSVJgeHM9pP9cvLJ2r5Xp2quQ5mYexW1LRKee2"
+	key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Esk: esk, Password: "password12345##"})
+	assert.Nil(t, err)
+
+	curve, secret, err := Decrypt(esk, "password12345##")
+	assert.Nil(t, err)
+	assert.Equal(t, Ed25519, curve)
+	decrypted, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Bytes: secret})
+	assert.Nil(t, err)
+	assert.Equal(t, key.PubKey.GetPublicKeyHash(), decrypted.PubKey.GetPublicKeyHash())
+
+	_, _, err = Decrypt(esk, "wrong")
+	test.CheckErr(t, true, "invalid password", err)
+
+	_, _, err = Decrypt(esk[:len(esk)-1]+"1", "password12345##")
+	test.CheckErr(t, true, "invalid checksum", err)
+
+	_, _, err = Decrypt("edsk3QoqBuvdamxouPhin7swCvkQNgq4jP5KZPbwWNnwdZpSpJiEbq", "password12345##")
+	test.CheckErr(t, true, "expected an edesk or p2esk encrypted secret key", err)
+}
+
+func Test_ParseCurve(t *testing.T) {
+	cases := []struct {
+		curve    string
+		want     Curve
+		wantErr  bool
+		contains string
+	}{
+		{curve: "ed25519", want: Ed25519},
+		{curve: "tz1", want: Ed25519},
+		{curve: "P256", want: P256},
+		{curve: "tz3", want: P256},
+		{curve: "secp256k1", wantErr: true, contains: "held in AWS KMS or on a PKCS#11 token"},
+		{curve: "rsa", wantErr: true, contains: "expected ed25519 or p256"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.curve, func(t *testing.T) {
+			curve, err := ParseCurve(tt.curve)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			assert.Equal(t, tt.want, curve)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/pkg/errors"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// DefaultPath is the derivation path of the first Tezos account of Ledger Live and Temple
+const DefaultPath = "m/44'/1729'/0'/0'"
+
+// hardened is the offset of hardened indexes in a derivation path
+const hardened = uint32(1) << 31
+
+// Derived is a key derived from a mnemonic at a derivation path
+type Derived struct {
+	Path    string
+	Address string
+	Key     keys.Key
+	Seed    []byte
+}
+
+/*
+ParsePath parses a derivation path such as m/44'/1729'/0'/0'. Ed25519 keys only derive hardened children, so every
+index must be hardened, marked with ' or h.
+*/
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) < 2 || parts[0] != "m" {
+		return nil, errors.Errorf("invalid derivation path '%s': expected m/44'/1729'/...", path)
+	}
+
+	var indexes []uint32
+	for _, part := range parts[1:] {
+		if !strings.HasSuffix(part, "'") && !strings.HasSuffix(part, "h") {
+			return nil, errors.Errorf("invalid derivation path '%s': index '%s' is not hardened, which ed25519 requires", path, part)
+		}
+
+		index, err := strconv.ParseUint(part[:len(part)-1], 10, 31)
+		if err != nil {
+			return nil, errors.Errorf("invalid derivation path '%s': invalid index '%s'", path, part)
+		}
+		indexes = append(indexes, uint32(index)+hardened)
+	}
+
+	return indexes, nil
+}
+
+// AccountPath returns the derivation path of the account at index, following Ledger Live and Temple
+func AccountPath(account int) string {
+	return fmt.Sprintf("m/44'/1729'/%d'/0'", account)
+}
+
+/*
+Derive derives the ed25519 key at path from a BIP39 mnemonic and its optional passphrase, with the SLIP-0010
+derivation Ledger and Temple use, so that the payout wallet matches the addresses they show.
+*/
+func Derive(mnemonic, passphrase, path string) (Derived, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return Derived{}, errors.Wrap(err, "failed to derive key")
+	}
+
+	seed, err := bip39.NewSeedWithErrorChecking(strings.Join(strings.Fields(mnemonic), " "), passphrase)
+	if err != nil {
+		return Derived{}, errors.Wrap(err, "failed to derive key: invalid mnemonic")
+	}
+
+	secret := deriveSecret(seed, indexes)
+	key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Bytes: secret})
+	if err != nil {
+		return Derived{}, errors.Wrap(err, "failed to derive key")
+	}
+
+	return Derived{
+		Path:    path,
+		Address: key.PubKey.GetPublicKeyHash(),
+		Key:     key,
+		Seed:    secret,
+	}, nil
+}
+
+// deriveSecret returns the ed25519 secret of the hardened child at indexes of the master key of seed, per SLIP-0010
+func deriveSecret(seed []byte, indexes []uint32) []byte {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	secret, chainCode := sum[:32], sum[32:]
+
+	for _, index := range indexes {
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, secret...)
+		data = append(data, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(data[33:], index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		secret, chainCode = sum[:32], sum[32:]
+	}
+
+	return secret
+}
@@ -0,0 +1,94 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_deriveSecret(t *testing.T) {
+	// Test vector 1 for ed25519 of SLIP-0010
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	assert.Nil(t, err)
+
+	cases := []struct {
+		path   string
+		secret string
+	}{
+		{"m", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{"m/0'", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{"m/0'/1'", "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+		{"m/0h/1h/2h/2h/1000000000h", "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.path, func(t *testing.T) {
+			var indexes []uint32
+			if tt.path != "m" {
+				indexes, err = ParsePath(tt.path)
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.secret, hex.EncodeToString(deriveSecret(seed, indexes)))
+		})
+	}
+}
+
+func Test_Derive(t *testing.T) {
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+	derived, err := Derive(mnemonic, "", DefaultPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "c62dc125754854b804d4d40b3559bc239e5bacf0da85e2f25e9970b0be1f8705", hex.EncodeToString(derived.Seed))
+	assert.Equal(t, DefaultPath, derived.Path)
+	assert.Equal(t, derived.Key.PubKey.GetPublicKeyHash(), derived.Address)
+
+	spaced, err := Derive("  abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about ", "", AccountPath(0))
+	assert.Nil(t, err)
+	assert.Equal(t, derived.Address, spaced.Address)
+
+	other, err := Derive(mnemonic, "", AccountPath(1))
+	assert.Nil(t, err)
+	assert.NotEqual(t, derived.Address, other.Address)
+
+	passphrase, err := Derive(mnemonic, "TREZOR", DefaultPath)
+	assert.Nil(t, err)
+	assert.NotEqual(t, derived.Address, passphrase.Address)
+
+	w, err := FromSeed(derived.Seed, "password12345##")
+	assert.Nil(t, err)
+	key, err := keys.NewKey(keys.NewKeyInput{Kind: keys.Ed25519, Esk: w.Esk, Password: "password12345##"})
+	assert.Nil(t, err)
+	assert.Equal(t, derived.Address, key.PubKey.GetPublicKeyHash())
+
+	_, err = Derive("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "", DefaultPath)
+	test.CheckErr(t, true, "invalid mnemonic", err)
+
+	_, err = Derive(mnemonic, "", "m/44'/1729'/0/0'")
+	test.CheckErr(t, true, "is not hardened", err)
+}
+
+func Test_ParsePath(t *testing.T) {
+	cases := []struct {
+		path     string
+		indexes  []uint32
+		contains string
+	}{
+		{path: "m/44'/1729'/0'/0'", indexes: []uint32{hardened + 44, hardened + 1729, hardened, hardened}},
+		{path: "m/44h/1729h", indexes: []uint32{hardened + 44, hardened + 1729}},
+		{path: "44'/1729'", contains: "expected m/44'/1729'/..."},
+		{path: "m", contains: "expected m/44'/1729'/..."},
+		{path: "m/44'/x'", contains: "invalid index 'x''"},
+		{path: "m/44'/2147483648'", contains: "invalid index"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.path, func(t *testing.T) {
+			indexes, err := ParsePath(tt.path)
+			test.CheckErr(t, tt.contains != "", tt.contains, err)
+			assert.Equal(t, tt.indexes, indexes)
+		})
+	}
+}
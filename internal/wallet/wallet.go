@@ -0,0 +1,273 @@
+package wallet
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcutil/base58"
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Wallet is a payout wallet and its encrypted secret key. Key is only set for ed25519 wallets.
+type Wallet struct {
+	Curve     Curve
+	Address   string
+	PublicKey string
+	Key       keys.Key
+	Esk       string
+	Password  string
+}
+
+// New generates a new ed25519 payout wallet encrypted with password
+func New(password string) (Wallet, error) {
+	return Generate(Ed25519, password)
+}
+
+// FromSeed returns the ed25519 payout wallet of seed, e.g. derived from a mnemonic, encrypted with password
+func FromSeed(seed []byte, password string) (Wallet, error) {
+	if password == "" {
+		return Wallet{}, errors.New("failed to import wallet: missing password")
+	}
+
+	key, err := keys.NewKey(keys.NewKeyInput{
+		Kind:  keys.Ed25519,
+		Bytes: seed,
+	})
+	if err != nil {
+		return Wallet{}, errors.Wrap(err, "failed to import wallet")
+	}
+
+	esk, err := Encrypt(seed, password)
+	if err != nil {
+		return Wallet{}, errors.Wrap(err, "failed to import wallet")
+	}
+
+	return Wallet{
+		Curve:     Ed25519,
+		Address:   key.PubKey.GetPublicKeyHash(),
+		PublicKey: key.PubKey.GetPublicKey(),
+		Key:       key,
+		Esk:       esk,
+		Password:  password,
+	}, nil
+}
+
+// Encrypt encrypts an ed25519 seed into an edesk encrypted secret key, as done by tezos-client
+func Encrypt(seed []byte, password string) (string, error) {
+	return encrypt(edeskPrefix, seed, password)
+}
+
+func encrypt(prefix, seed []byte, password string) (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate salt")
+	}
+
+	var secret [32]byte
+	copy(secret[:], pbkdf2.Key([]byte(password), salt, 32768, 32, sha512.New))
+
+	var nonce [24]byte
+	encrypted := secretbox.Seal(nil, seed, &nonce, &secret)
+
+	payload := append(append(append([]byte{}, prefix...), salt...), encrypted...)
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+
+	return base58.Encode(append(payload, second[:4]...)), nil
+}
+
+/*
+UpdateEnvFile sets the variables passed in the enviroment file found at path. Variables already in the file
+are replaced in place, and missing ones are appended. The file is replaced atomically.
+*/
+func UpdateEnvFile(path string, values map[string]string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read enviroment file '%s'", path)
+	}
+
+	set := map[string]bool{}
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if parts := strings.SplitN(line, "=", 2); len(parts) == 2 {
+			name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(parts[0]), "export "))
+			if value, ok := values[name]; ok {
+				line = fmt.Sprintf("%s=%s", parts[0], value)
+				set[name] = true
+			}
+		}
+		out.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read enviroment file '%s'", path)
+	}
+
+	for name, value := range values {
+		if !set[name] {
+			out.WriteString(fmt.Sprintf("%s=%s\n", name, value))
+		}
+	}
+
+	return writeFile(path, out.Bytes())
+}
+
+// UpdateDelegatesFile sets the key of a baker configured in the delegates file found at path, and the address of
+// its payout wallet if address is not empty
+func UpdateDelegatesFile(path, baker, esk, password, address string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read delegates file '%s'", path)
+	}
+
+	var delegates []map[string]interface{}
+	if err := json.Unmarshal(data, &delegates); err != nil {
+		return errors.Wrapf(err, "failed to parse delegates file '%s'", path)
+	}
+
+	found := false
+	for _, delegate := range delegates {
+		b, _ := field(delegate, "baker").(map[string]interface{})
+		if address, _ := field(b, "address").(string); address != baker {
+			continue
+		}
+
+		key, _ := field(delegate, "key").(map[string]interface{})
+		if key == nil {
+			key = map[string]interface{}{}
+			delegate["key"] = key
+		}
+		setField(key, "esk", esk)
+		setField(key, "password", password)
+		if address != "" {
+			setField(key, "address", address)
+		}
+		found = true
+	}
+
+	if !found {
+		return errors.Errorf("failed to find baker '%s' in delegates file '%s'", baker, path)
+	}
+
+	out, err := json.MarshalIndent(delegates, "", "    ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode delegates")
+	}
+
+	return writeFile(path, append(out, '\n'))
+}
+
+// field returns the value of a JSON object field matched case insensitively, like encoding/json does
+func field(object map[string]interface{}, name string) interface{} {
+	for k, v := range object {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func setField(object map[string]interface{}, name string, value interface{}) {
+	for k := range object {
+		if strings.EqualFold(k, name) {
+			object[k] = value
+			return
+		}
+	}
+	object[name] = value
+}
+
+func writeFile(path string, data []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write '%s'", path)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to write '%s'", path)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write '%s'", path)
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return errors.Wrapf(err, "failed to write '%s'", path)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrapf(err, "failed to write '%s'", path)
+	}
+
+	return nil
+}
+
+// keystoreBucket is the store bucket keeping every payout wallet tzpay generated, keyed by address
+const keystoreBucket = "keystore"
+
+// KeystoreEntry is a payout wallet kept in the keystore
+type KeystoreEntry struct {
+	Baker   string `json:"baker"`
+	Esk     string `json:"esk"`
+	Created string `json:"created"`
+}
+
+// Save keeps the encrypted secret key of the wallet in the keystore, so that it is never lost before it is configured
+func Save(s store.IFace, baker string, wallet Wallet) error {
+	err := s.Put(keystoreBucket, wallet.Address, KeystoreEntry{
+		Baker:   baker,
+		Esk:     wallet.Esk,
+		Created: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to save wallet to keystore")
+	}
+
+	return nil
+}
+
+// Latest returns the address and entry of the payout wallet generated last for baker, if any
+func Latest(s store.IFace, baker string) (string, KeystoreEntry, bool, error) {
+	addresses, err := s.Keys(keystoreBucket)
+	if err != nil {
+		return "", KeystoreEntry{}, false, errors.Wrap(err, "failed to list keystore")
+	}
+
+	var latest string
+	var latestEntry KeystoreEntry
+	for _, address := range addresses {
+		var entry KeystoreEntry
+		if _, err := s.Get(keystoreBucket, address, &entry); err != nil {
+			return "", KeystoreEntry{}, false, errors.Wrapf(err, "failed to get wallet '%s' from keystore", address)
+		}
+
+		if entry.Baker == baker && entry.Created >= latestEntry.Created {
+			latest, latestEntry = address, entry
+		}
+	}
+
+	return latest, latestEntry, latest != "", nil
+}
@@ -0,0 +1,177 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goat-systems/go-tezos/v3/keys"
+	"github.com/goat-systems/tzpay/v3/internal/store"
+	"github.com/goat-systems/tzpay/v3/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_New(t *testing.T) {
+	cases := []struct {
+		name     string
+		password string
+		wantErr  bool
+		contains string
+	}{
+		{
+			"is successful",
+			"password12345##",
+			false,
+			"",
+		},
+		{
+			"handles missing password",
+			"",
+			true,
+			"missing password",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := New(tt.password)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+
+			key, err := keys.NewKey(keys.NewKeyInput{
+				Kind:     keys.Ed25519,
+				Esk:      w.Esk,
+				Password: tt.password,
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, "edesk", w.Esk[:5])
+			assert.Equal(t, w.Key.PubKey.GetPublicKeyHash(), key.PubKey.GetPublicKeyHash())
+		})
+	}
+}
+
+func Test_UpdateEnvFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-wallet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tzpay.env")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("TZPAY_BAKER=tz1a\nexport TZPAY_WALLET_ESK=old\n# comment\n"), 0640))
+
+	err = UpdateEnvFile(path, map[string]string{
+		"TZPAY_WALLET_ESK":      "new",
+		"TZPAY_WALLET_PASSWORD": "pass",
+	})
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "TZPAY_BAKER=tz1a\nexport TZPAY_WALLET_ESK=new\n# comment\nTZPAY_WALLET_PASSWORD=pass\n", string(data))
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode())
+}
+
+func Test_UpdateDelegatesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-wallet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		name     string
+		baker    string
+		address  string
+		wantErr  bool
+		contains string
+		want     string
+	}{
+		{
+			"is successful",
+			"tz1b",
+			"",
+			false,
+			"",
+			`[{"Baker":{"Address":"tz1b","Fee":0.05},"Key":{"Esk":"new","Password":"pass"}}]`,
+		},
+		{
+			"is successful with payout wallet address",
+			"tz1b",
+			"tz1new",
+			false,
+			"",
+			`[{"Baker":{"Address":"tz1b","Fee":0.05},"Key":{"Esk":"new","Password":"pass","address":"tz1new"}}]`,
+		},
+		{
+			"handles unknown baker",
+			"tz1c",
+			"",
+			true,
+			"failed to find baker 'tz1c'",
+			"",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "delegates.json")
+			assert.Nil(t, ioutil.WriteFile(path, []byte(`[{"Baker":{"Address":"tz1b","Fee":0.05},"Key":{"Esk":"old","Password":"old"}}]`), 0600))
+
+			err := UpdateDelegatesFile(path, tt.baker, "new", "pass", tt.address)
+			test.CheckErr(t, tt.wantErr, tt.contains, err)
+			if err != nil {
+				return
+			}
+
+			data, err := ioutil.ReadFile(path)
+			assert.Nil(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+}
+
+func Test_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-wallet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	w, err := New("password12345##")
+	assert.Nil(t, err)
+	assert.Nil(t, Save(s, "tz1baker", w))
+
+	var entry KeystoreEntry
+	ok, err := s.Get(keystoreBucket, w.Key.PubKey.GetPublicKeyHash(), &entry)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "tz1baker", entry.Baker)
+	assert.Equal(t, w.Esk, entry.Esk)
+}
+
+func Test_Latest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tzpay-wallet")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := store.New(filepath.Join(dir, "tzpay.json"), "")
+	assert.Nil(t, err)
+
+	_, _, ok, err := Latest(s, "tz1baker")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, s.Put(keystoreBucket, "tz1old", KeystoreEntry{Baker: "tz1baker", Created: "2020-09-01T10:00:00Z"}))
+	assert.Nil(t, s.Put(keystoreBucket, "tz1new", KeystoreEntry{Baker: "tz1baker", Created: "2020-10-01T10:00:00Z"}))
+	assert.Nil(t, s.Put(keystoreBucket, "tz1other", KeystoreEntry{Baker: "tz1other", Created: "2020-11-01T10:00:00Z"}))
+
+	address, entry, ok, err := Latest(s, "tz1baker")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "tz1new", address)
+	assert.Equal(t, "2020-10-01T10:00:00Z", entry.Created)
+}
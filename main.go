@@ -16,7 +16,33 @@ func main() {
 		cmd.RunCommand(),
 		cmd.NewVersionCommand(),
 		cmd.NewSetupCommand(),
+		cmd.DisperseCommand(),
+		cmd.WalletCommand(),
+		cmd.DelegatorCommand(),
+		cmd.SkippedCommand(),
+		cmd.CalendarCommand(),
+		cmd.BenchCommand(),
+		cmd.RecoverCommand(),
+		cmd.SupportBundleCommand(),
+		cmd.DelegatesCommand(),
+		cmd.BooksCommand(),
+		cmd.StatusCommand(),
+		cmd.LookupCommand(),
+		cmd.ThresholdsCommand(),
+		cmd.ForgeCommand(),
+		cmd.SignCommand(),
+		cmd.InjectCommand(),
+		cmd.ApproveCommand(),
+		cmd.ReportCommand(),
+		cmd.ShowCommand(),
+		cmd.HistoryCommand(),
+		cmd.ReceiptCommand(),
+		cmd.DBCommand(),
+		cmd.VerifyCommand(),
+		cmd.DeadLetterCommand(),
+		cmd.QueueCommand(),
 	)
+	cmd.AddGlobalFlags(rootCommand)
 
 	rootCommand.Execute()
 }